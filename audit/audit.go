@@ -0,0 +1,89 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+//
+// Package audit implements an append-only trail of the writes served by
+// sysbox-fs handlers on behalf of sys containers (e.g. sysctl updates), so
+// that it can be proven which emulated resources were altered, by whom, and
+// that the host's kernel parameters were left untouched.
+//
+package audit
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+//
+// Audit record. One per write request, encoded as a JSON line.
+//
+type Entry struct {
+	Time        time.Time `json:"time"`
+	ContainerID string    `json:"container-id"`
+	Pid         uint32    `json:"pid"`
+	Uid         uint32    `json:"uid"`
+	Path        string    `json:"path"`
+	Handler     string    `json:"handler"`
+	OldValue    string    `json:"old-value"`
+	NewValue    string    `json:"new-value"`
+	Error       string    `json:"error,omitempty"` // set if the write failed
+}
+
+type Log struct {
+	sync.Mutex
+	file *os.File
+	enc  *json.Encoder
+}
+
+//
+// Opens the audit log at 'path'. Records are only ever appended to it.
+//
+func Open(path string) (*Log, error) {
+
+	f, err := os.OpenFile(
+		path,
+		os.O_CREATE|os.O_WRONLY|os.O_APPEND|os.O_SYNC,
+		0600,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Log{file: f, enc: json.NewEncoder(f)}, nil
+}
+
+// Appends the given entry to the audit log.
+func (l *Log) Record(e *Entry) error {
+
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	l.Lock()
+	defer l.Unlock()
+
+	return l.enc.Encode(e)
+}
+
+func (l *Log) Close() error {
+
+	l.Lock()
+	defer l.Unlock()
+
+	return l.file.Close()
+}
@@ -0,0 +1,78 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogRecord(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "sysbox-fs-audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "audit.log")
+
+	// Records are appended across re-openings of the log.
+	for i, val := range []string{"1", "0"} {
+		l, err := Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = l.Record(&Entry{
+			ContainerID: "c1",
+			Pid:         uint32(1000 + i),
+			Path:        "/proc/sys/net/ipv4/ip_forward",
+			NewValue:    val,
+		})
+		assert.NoError(t, err)
+		l.Close()
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		var e Entry
+		if err := json.Unmarshal(s.Bytes(), &e); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, e)
+	}
+
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, uint32(1000), entries[0].Pid)
+		assert.Equal(t, "1", entries[0].NewValue)
+		assert.Equal(t, "0", entries[1].NewValue)
+		assert.False(t, entries[1].Time.IsZero())
+	}
+}
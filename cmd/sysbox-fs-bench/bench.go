@@ -0,0 +1,284 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/handler"
+)
+
+// Latencies of the ops served by a given handler.
+type statKey struct {
+	handler string
+	op      domain.FuseOp
+}
+
+type stat struct {
+	samples []time.Duration
+	errors  int
+}
+
+type stats map[statKey]*stat
+
+func (s stats) add(k statKey, d time.Duration, failed bool) {
+
+	st, ok := s[k]
+	if !ok {
+		st = &stat{}
+		s[k] = st
+	}
+
+	st.samples = append(st.samples, d)
+	if failed {
+		st.errors++
+	}
+}
+
+// Returns the p-th percentile (nearest-rank) of the given sorted samples.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	i := int(p/100*float64(len(sorted))+0.5) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+
+	return sorted[i]
+}
+
+//
+// Returns the name of the handler serving the given resource: that of the
+// resource itself or, failing that, of its closest ancestor (e.g. the
+// passthrough handlers of the emulated directories).
+//
+func handlerName(byPath map[string]domain.HandlerIface, path string) string {
+
+	for p := path; ; p = filepath.Dir(p) {
+		if h, ok := byPath[p]; ok {
+			return h.GetName()
+		}
+		if p == "/" {
+			return "none"
+		}
+	}
+}
+
+func runBench(ctx *cli.Context) error {
+
+	if ctx.NArg() != 1 {
+		return cli.NewExitError("a trace file must be given", 1)
+	}
+
+	containers := ctx.Int("containers")
+	concurrency := ctx.Int("concurrency")
+	iterations := ctx.Int("iterations")
+	if containers < 1 || concurrency < 1 || iterations < 1 {
+		return cli.NewExitError(
+			"containers, concurrency and iterations must be positive", 1)
+	}
+
+	if os.Geteuid() != 0 {
+		return cli.NewExitError("sysbox-fs-bench must be run as root", 1)
+	}
+
+	f, err := os.Open(ctx.Args().First())
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+	ops, err := parseTrace(f)
+	f.Close()
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+
+	// Containers are unregistered no matter how the benchmark ends.
+	var cntrs []*container
+	defer func() {
+		for _, c := range cntrs {
+			if err := c.stop(); err != nil {
+				logrus.Warn(err)
+			}
+		}
+	}()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+
+	uidBase := uint32(ctx.Uint("uid-base"))
+	for i := 0; i < containers; i++ {
+		c, err := startContainer(ctx.String("ipc-socket"),
+			uidBase+uint32(i)*idRangeSize)
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		cntrs = append(cntrs, c)
+	}
+
+	results, elapsed, err := replayAll(
+		cntrs,
+		ctx.String("mountpoint"),
+		concurrency,
+		iterations,
+		ops,
+		sigChan)
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+
+	byPath := make(map[string]domain.HandlerIface)
+	for _, h := range handler.DefaultHandlers {
+		byPath[h.GetPath()] = h
+	}
+
+	s := make(stats)
+	for _, res := range results {
+		for _, smp := range res.Samples {
+			failed := smp.Err != ""
+			if failed && ctx.Bool("verbose") {
+				logrus.Warnf("%v on %v failed: %v", smp.Op, smp.Path, smp.Err)
+			}
+			s.add(statKey{handlerName(byPath, smp.Path), traceOps[smp.Op]},
+				smp.Latency, failed)
+		}
+	}
+
+	fmt.Printf("%d ops replayed by %d containers (%d iterations, concurrency %d per container) in %v\n\n",
+		len(ops)*containers*iterations, containers, iterations, concurrency,
+		elapsed.Round(time.Millisecond))
+
+	printStats(os.Stdout, s, elapsed)
+
+	return nil
+}
+
+//
+// Has all the containers replay the trace at once, and collects the outcome.
+// Returns the time taken by the slowest of them.
+//
+func replayAll(
+	cntrs []*container,
+	mountpoint string,
+	concurrency int,
+	iterations int,
+	ops []traceOp,
+	sigChan <-chan os.Signal) ([]*replayResult, time.Duration, error) {
+
+	type outcome struct {
+		res *replayResult
+		err error
+	}
+
+	done := make(chan outcome, len(cntrs))
+
+	start := time.Now()
+
+	for _, c := range cntrs {
+		root := filepath.Join(mountpoint, c.id)
+		if err := c.replay(newReplayConfig(root, concurrency, iterations, ops)); err != nil {
+			return nil, 0, err
+		}
+
+		go func(c *container) {
+			res, err := c.results()
+			done <- outcome{res, err}
+		}(c)
+	}
+
+	var results []*replayResult
+
+	for range cntrs {
+		select {
+		case o := <-done:
+			if o.err != nil {
+				return nil, 0, o.err
+			}
+			results = append(results, o.res)
+
+		case sig := <-sigChan:
+			return nil, 0, fmt.Errorf("interrupted by %v", sig)
+		}
+	}
+
+	return results, time.Since(start), nil
+}
+
+// Displays the latencies per handler and op, busiest first.
+func printStats(out io.Writer, s stats, elapsed time.Duration) {
+
+	keys := make([]statKey, 0, len(s))
+	for k := range s {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		ni, nj := len(s[keys[i]].samples), len(s[keys[j]].samples)
+		if ni != nj {
+			return ni > nj
+		}
+		if keys[i].handler != keys[j].handler {
+			return keys[i].handler < keys[j].handler
+		}
+		return keys[i].op < keys[j].op
+	})
+
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "HANDLER\tOP\tCOUNT\tERRORS\tOPS/S\tMEAN\tP50\tP99\tMAX")
+
+	for _, k := range keys {
+		st := s[k]
+
+		sorted := append([]time.Duration(nil), st.samples...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+		var total time.Duration
+		for _, d := range sorted {
+			total += d
+		}
+		mean := total / time.Duration(len(sorted))
+
+		var rate float64
+		if elapsed > 0 {
+			rate = float64(len(sorted)) / elapsed.Seconds()
+		}
+
+		fmt.Fprintf(w, "%s\t%v\t%d\t%d\t%.0f\t%v\t%v\t%v\t%v\n",
+			k.handler, k.op, len(sorted), st.errors, rate, mean,
+			percentile(sorted, 50), percentile(sorted, 99),
+			sorted[len(sorted)-1])
+	}
+
+	w.Flush()
+}
@@ -0,0 +1,85 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/handler"
+)
+
+func Test_percentile(t *testing.T) {
+
+	var sorted []time.Duration
+	for i := 1; i <= 100; i++ {
+		sorted = append(sorted, time.Duration(i)*time.Millisecond)
+	}
+
+	assert.Equal(t, 50*time.Millisecond, percentile(sorted, 50))
+	assert.Equal(t, 99*time.Millisecond, percentile(sorted, 99))
+	assert.Equal(t, 100*time.Millisecond, percentile(sorted, 100))
+	assert.Equal(t, time.Millisecond, percentile(sorted, 0))
+	assert.Equal(t, time.Duration(0), percentile(nil, 50))
+}
+
+func Test_printStats(t *testing.T) {
+
+	s := make(stats)
+	for i := 1; i <= 4; i++ {
+		s.add(statKey{"procUptime", domain.FuseOpRead}, time.Duration(i)*time.Millisecond, false)
+	}
+	s.add(statKey{"commonHandler", domain.FuseOpWrite}, time.Millisecond, true)
+
+	var buf bytes.Buffer
+	printStats(&buf, s, time.Second)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if assert.Len(t, lines, 3) {
+		assert.Equal(t,
+			[]string{"procUptime", "read", "4", "0", "4", "2.5ms", "2ms", "4ms", "4ms"},
+			strings.Fields(lines[1]))
+		assert.Equal(t,
+			[]string{"commonHandler", "write", "1", "1", "1", "1ms", "1ms", "1ms", "1ms"},
+			strings.Fields(lines[2]))
+	}
+}
+
+func Test_handlerName(t *testing.T) {
+
+	byPath := make(map[string]domain.HandlerIface)
+	for _, h := range handler.DefaultHandlers {
+		byPath[h.GetPath()] = h
+	}
+
+	// Resources served by a dedicated handler.
+	assert.Equal(t, "procUptime", handlerName(byPath, "/proc/uptime"))
+	assert.Equal(t, "fsMountMax", handlerName(byPath, "/proc/sys/fs/mount-max"))
+
+	// Resources served by the handler of an ancestor.
+	assert.Equal(t, "procSys", handlerName(byPath, "/proc/sys/net/ipv4/ip_forward"))
+	assert.Equal(t, "sys", handlerName(byPath, "/sys/kernel"))
+	assert.Equal(t, "root", handlerName(byPath, "/etc/hostname"))
+
+	// Resources out of the handlers' reach.
+	assert.Equal(t, "none", handlerName(nil, "/proc/uptime"))
+}
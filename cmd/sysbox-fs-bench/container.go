@@ -0,0 +1,214 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+
+	grpc "github.com/nestybox/sysbox-ipc/sysboxFsGrpc"
+)
+
+// Command-line argument identifying the synthetic containers' init processes.
+const containerInitCmd = "container-init"
+
+// Size of the uid (and gid) range mapped to each synthetic container.
+const idRangeSize = 65536
+
+// Namespaces of the synthetic containers, those of a sys container.
+const containerCloneFlags = syscall.CLONE_NEWUSER |
+	syscall.CLONE_NEWPID |
+	syscall.CLONE_NEWNS |
+	syscall.CLONE_NEWNET |
+	syscall.CLONE_NEWIPC |
+	syscall.CLONE_NEWUTS |
+	syscall.CLONE_NEWCGROUP
+
+//
+// Synthetic sys container registered with sysbox-fs. Its init process waits
+// for the trace to replay on its stdin, and hands the latencies of the
+// replayed ops over through its stdout (see containerInit()).
+//
+type container struct {
+	id         string
+	socket     string
+	cmd        *exec.Cmd
+	stdin      io.WriteCloser
+	stdout     io.ReadCloser
+	registered bool
+}
+
+// Returns a random container id, in the format used by container managers.
+func newContainerID() (string, error) {
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+//
+// Creates a synthetic sys container and registers it with the sysbox-fs
+// instance serving the given ipc socket, following the same steps as
+// sysbox-runc: the container is pre-registered (which sets up its fuse mount),
+// its init process created, and the container registered.
+//
+func startContainer(socket string, uidBase uint32) (*container, error) {
+
+	id, err := newContainerID()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &container{
+		id:     id,
+		socket: socket,
+	}
+
+	_, err = grpc.SendMessage(socket, grpc.ContainerPreRegisterMessage,
+		&grpc.ContainerData{Id: id})
+	if err != nil {
+		return nil, fmt.Errorf("container %s pre-registration failed: %v", id, err)
+	}
+
+	// From here on the container must be unregistered.
+	c.registered = true
+
+	if err := c.startInit(uidBase); err != nil {
+		c.stop()
+		return nil, err
+	}
+
+	idMappings := []grpc.IDMapping{
+		{ContainerID: 0, HostID: uidBase, Size: idRangeSize},
+	}
+
+	_, err = grpc.SendMessage(socket, grpc.ContainerRegisterMessage,
+		&grpc.ContainerData{
+			Id:             id,
+			InitPid:        int32(c.cmd.Process.Pid),
+			Ctime:          time.Now(),
+			UidFirst:       int32(uidBase),
+			UidSize:        idRangeSize,
+			GidFirst:       int32(uidBase),
+			GidSize:        idRangeSize,
+			UidMappings:    idMappings,
+			UidMappingsSet: true,
+			GidMappings:    idMappings,
+			GidMappingsSet: true,
+		})
+	if err != nil {
+		c.stop()
+		return nil, fmt.Errorf("container %s registration failed: %v", id, err)
+	}
+
+	return c, nil
+}
+
+// Launches the container's init process, which maps root to the given host
+// uid (and gid).
+func (c *container) startInit(uidBase uint32) error {
+
+	idMappings := []syscall.SysProcIDMap{
+		{ContainerID: 0, HostID: int(uidBase), Size: idRangeSize},
+	}
+
+	cmd := exec.Command("/proc/self/exe", containerInitCmd)
+	cmd.Stderr = os.Stderr
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Cloneflags:  containerCloneFlags,
+		UidMappings: idMappings,
+		GidMappings: idMappings,
+		Pdeathsig:   syscall.SIGKILL,
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("container %s init process could not be created: %v",
+			c.id, err)
+	}
+
+	c.cmd = cmd
+	c.stdin = stdin
+	c.stdout = stdout
+
+	return nil
+}
+
+// Hands the trace over to the container's init process, which replays it
+// right away.
+func (c *container) replay(cfg *replayConfig) error {
+	return json.NewEncoder(c.stdin).Encode(cfg)
+}
+
+// Collects the outcome of the trace replayed by the container.
+func (c *container) results() (*replayResult, error) {
+
+	var res replayResult
+
+	if err := json.NewDecoder(c.stdout).Decode(&res); err != nil {
+		return nil, fmt.Errorf("container %s results could not be collected: %v",
+			c.id, err)
+	}
+
+	return &res, nil
+}
+
+//
+// Unregisters the container, and then lets its init process exit (an init
+// process going away ahead of the unregistration would be deemed a crash by
+// sysbox-fs).
+//
+func (c *container) stop() error {
+
+	var err error
+
+	if c.registered {
+		_, err = grpc.SendMessage(c.socket, grpc.ContainerUnregisterMessage,
+			&grpc.ContainerData{Id: c.id})
+		if err != nil {
+			err = fmt.Errorf("container %s unregistration failed: %v", c.id, err)
+		}
+		c.registered = false
+	}
+
+	if c.cmd != nil {
+		c.stdin.Close()
+		c.stdout.Close()
+		c.cmd.Wait()
+		c.cmd = nil
+	}
+
+	return err
+}
@@ -0,0 +1,138 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli"
+
+	"github.com/nestybox/sysbox-fs/ipc"
+)
+
+const (
+	usage = `sysbox-fs benchmark suite and load generator
+
+sysbox-fs-bench registers a number of synthetic sys containers with a
+running sysbox-fs instance, replays a recorded trace of /proc and /sys
+accesses from within each of them, and reports the latency of the
+requests per emulation handler.
+
+Each synthetic container is made of an init process living in its own
+user, pid, mount, network, ipc, uts and cgroup namespaces, which is
+registered through sysbox-fs' ipc socket (just like sysbox-runc does)
+and which replays the trace through the container's fuse mount. Reported
+latencies are thereby those experienced by the containers' processes,
+fuse round-trips and nsenter requests included. Containers are
+unregistered once done.
+
+The resources written by the trace are modified within the synthetic
+containers only, but the ones sysbox-fs pushes down to the host (e.g.
+the larger limits of some sysctls) are modified in the host too, so run
+it on test hosts only. It must be run as root.
+
+Traces hold one operation per line, either as '<op> <path> [<value>]'
+(op being one of lookup, open, read, write or readdir), or as the json
+entries logged by sysbox-fs at debug level (--log-format json). Writes
+lacking a value write back the one read within the container.
+`
+)
+
+// Globals to be populated at build time during Makefile processing.
+var (
+	version  string // extracted from VERSION file
+	commitId string // latest git commit-id of sysbox superproject
+	builtAt  string // build time
+	builtBy  string // build owner
+)
+
+//
+// sysbox-fs-bench main function
+//
+func main() {
+
+	// Synthetic containers' init processes are instances of this same
+	// binary (see startContainer()).
+	if len(os.Args) > 1 && os.Args[1] == containerInitCmd {
+		if err := containerInit(os.Stdin, os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "sysbox-fs-bench: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	app := cli.NewApp()
+	app.Name = "sysbox-fs-bench"
+	app.Usage = usage
+	app.Version = version
+	app.ArgsUsage = "<trace-file>"
+
+	app.Flags = []cli.Flag{
+		cli.StringFlag{
+			Name:  "ipc-socket",
+			Value: ipc.DefaultSocketPath,
+			Usage: "unix socket sysbox-fs serves ipc requests at",
+		},
+		cli.StringFlag{
+			Name:  "mountpoint",
+			Value: "/var/lib/sysboxfs",
+			Usage: "sysbox-fs mount-point location",
+		},
+		cli.IntFlag{
+			Name:  "containers",
+			Value: 10,
+			Usage: "number of synthetic sys containers replaying the trace",
+		},
+		cli.IntFlag{
+			Name:  "concurrency",
+			Value: 8,
+			Usage: "number of requests issued concurrently by each container",
+		},
+		cli.IntFlag{
+			Name:  "iterations",
+			Value: 1,
+			Usage: "number of times each container replays the trace",
+		},
+		cli.UintFlag{
+			Name:  "uid-base",
+			Value: 231072,
+			Usage: "first host uid (and gid) mapped to the synthetic containers",
+		},
+		cli.BoolFlag{
+			Name:  "verbose",
+			Usage: "display the errors returned by sysbox-fs",
+		},
+	}
+
+	// show-version specialization.
+	cli.VersionPrinter = func(c *cli.Context) {
+		fmt.Printf("sysbox-fs-bench\n"+
+			"\tversion: \t%s\n"+
+			"\tcommit: \t%s\n"+
+			"\tbuilt at: \t%s\n"+
+			"\tbuilt by: \t%s\n",
+			c.App.Version, commitId, builtAt, builtBy)
+	}
+
+	app.Action = runBench
+
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintf(os.Stderr, "sysbox-fs-bench: %v\n", err)
+		os.Exit(1)
+	}
+}
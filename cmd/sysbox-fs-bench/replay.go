@@ -0,0 +1,217 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nestybox/sysbox-fs/domain"
+)
+
+// Op of the trace, as handed over to the containers' init processes.
+type replayOp struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	Value string `json:"value,omitempty"`
+}
+
+// Trace to be replayed by a container's init process.
+type replayConfig struct {
+	Root        string     `json:"root"` // container's fuse mount
+	Concurrency int        `json:"concurrency"`
+	Iterations  int        `json:"iterations"`
+	Ops         []replayOp `json:"ops"`
+}
+
+// Outcome of an op replayed by a container's init process.
+type replaySample struct {
+	Op      string        `json:"op"`
+	Path    string        `json:"path"`
+	Latency time.Duration `json:"latency"`
+	Err     string        `json:"err,omitempty"`
+}
+
+type replayResult struct {
+	Samples []replaySample `json:"samples"`
+}
+
+func newReplayConfig(root string, concurrency, iterations int,
+	ops []traceOp) *replayConfig {
+
+	cfg := &replayConfig{
+		Root:        root,
+		Concurrency: concurrency,
+		Iterations:  iterations,
+	}
+
+	for _, op := range ops {
+		cfg.Ops = append(cfg.Ops, replayOp{
+			Op:    op.op.String(),
+			Path:  op.path,
+			Value: op.value,
+		})
+	}
+
+	return cfg
+}
+
+//
+// Entry point of the synthetic containers' init processes: waits for the
+// trace on the given input, replays it, and writes the outcome to the given
+// output. The process then lingers until its input is closed, so that the
+// container can be unregistered ahead of its exit.
+//
+func containerInit(in io.Reader, out io.Writer) error {
+
+	var cfg replayConfig
+
+	if err := json.NewDecoder(in).Decode(&cfg); err != nil {
+		return err
+	}
+
+	res, err := replayTrace(&cfg)
+	if err != nil {
+		return err
+	}
+
+	if err := json.NewEncoder(out).Encode(res); err != nil {
+		return err
+	}
+
+	io.Copy(ioutil.Discard, in)
+
+	return nil
+}
+
+//
+// Replays the trace through the container's fuse mount, as many times as
+// requested, with the given number of ops in flight. The values to write back
+// for the write ops lacking one are read upfront.
+//
+func replayTrace(cfg *replayConfig) (*replayResult, error) {
+
+	ops := make([]replayOp, 0, len(cfg.Ops))
+
+	for _, op := range cfg.Ops {
+		if _, ok := traceOps[op.Op]; !ok {
+			return nil, fmt.Errorf("unknown operation %q", op.Op)
+		}
+
+		if op.Op == domain.FuseOpWrite.String() && op.Value == "" {
+			data, err := ioutil.ReadFile(filepath.Join(cfg.Root, op.Path))
+			if err == nil {
+				op.Value = strings.TrimSpace(string(data))
+			}
+		}
+
+		ops = append(ops, op)
+	}
+
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		res   = &replayResult{}
+		queue = make(chan replayOp, cfg.Concurrency)
+	)
+
+	for i := 0; i < cfg.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			var samples []replaySample
+
+			for op := range queue {
+				start := time.Now()
+				err := replay(cfg.Root, op)
+				s := replaySample{
+					Op:      op.Op,
+					Path:    op.Path,
+					Latency: time.Since(start),
+				}
+				if err != nil {
+					s.Err = err.Error()
+				}
+
+				samples = append(samples, s)
+			}
+
+			mu.Lock()
+			res.Samples = append(res.Samples, samples...)
+			mu.Unlock()
+		}()
+	}
+
+	for it := 0; it < cfg.Iterations; it++ {
+		for _, op := range ops {
+			queue <- op
+		}
+	}
+	close(queue)
+
+	wg.Wait()
+
+	return res, nil
+}
+
+// Issues the system calls leading to the given fuse op on the resource.
+func replay(root string, op replayOp) error {
+
+	path := filepath.Join(root, op.Path)
+
+	switch traceOps[op.Op] {
+	case domain.FuseOpLookup:
+		_, err := os.Lstat(path)
+		return err
+
+	case domain.FuseOpOpen:
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		return f.Close()
+
+	case domain.FuseOpRead:
+		_, err := ioutil.ReadFile(path)
+		return err
+
+	case domain.FuseOpWrite:
+		f, err := os.OpenFile(path, os.O_WRONLY, 0)
+		if err != nil {
+			return err
+		}
+		_, err = f.Write([]byte(op.Value + "\n"))
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		return err
+
+	case domain.FuseOpReadDir:
+		_, err := ioutil.ReadDir(path)
+		return err
+	}
+
+	return fmt.Errorf("unknown operation %q", op.Op)
+}
@@ -0,0 +1,93 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nestybox/sysbox-fs/domain"
+)
+
+func Test_containerInit(t *testing.T) {
+
+	// Container's fuse mount stand-in.
+	root, err := ioutil.TempDir("", "sysbox-fs-bench")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(root)
+
+	kernel := filepath.Join(root, "proc/sys/kernel")
+	assert.NoError(t, os.MkdirAll(kernel, 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(kernel, "panic"), []byte("0\n"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(kernel, "hostname"), []byte("foo\n"), 0644))
+
+	ops := []traceOp{
+		{op: domain.FuseOpLookup, path: "/proc/sys/kernel/panic"},
+		{op: domain.FuseOpRead, path: "/proc/sys/kernel/panic"},
+		{op: domain.FuseOpWrite, path: "/proc/sys/kernel/panic", value: "10"},
+		{op: domain.FuseOpWrite, path: "/proc/sys/kernel/hostname"},
+		{op: domain.FuseOpReadDir, path: "/proc/sys/kernel"},
+		{op: domain.FuseOpOpen, path: "/proc/sys/kernel/no-such-sysctl"},
+	}
+
+	var in, out bytes.Buffer
+	assert.NoError(t, json.NewEncoder(&in).Encode(newReplayConfig(root, 2, 3, ops)))
+
+	if !assert.NoError(t, containerInit(&in, &out)) {
+		return
+	}
+
+	var res replayResult
+	assert.NoError(t, json.NewDecoder(&out).Decode(&res))
+
+	// Every op is replayed once per iteration, and only the ones on missing
+	// resources fail.
+	if assert.Len(t, res.Samples, len(ops)*3) {
+		for _, s := range res.Samples {
+			if s.Path == "/proc/sys/kernel/no-such-sysctl" {
+				assert.NotEmpty(t, s.Err)
+			} else {
+				assert.Empty(t, s.Err, "%v on %v", s.Op, s.Path)
+			}
+		}
+	}
+
+	// Writes lacking a value write back the one read upfront.
+	data, _ := ioutil.ReadFile(filepath.Join(kernel, "panic"))
+	assert.Equal(t, "10\n", string(data))
+	data, _ = ioutil.ReadFile(filepath.Join(kernel, "hostname"))
+	assert.Equal(t, "foo\n", string(data))
+}
+
+func Test_replayTraceErrors(t *testing.T) {
+
+	_, err := replayTrace(&replayConfig{
+		Root:        "/",
+		Concurrency: 1,
+		Iterations:  1,
+		Ops:         []replayOp{{Op: "stat", Path: "/proc/uptime"}},
+	})
+	assert.EqualError(t, err, `unknown operation "stat"`)
+}
@@ -0,0 +1,146 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/logging"
+)
+
+// Operation recorded in a trace.
+type traceOp struct {
+	op    domain.FuseOp
+	path  string
+	value string // written value (write ops only)
+}
+
+var traceOps = map[string]domain.FuseOp{
+	domain.FuseOpLookup.String():  domain.FuseOpLookup,
+	domain.FuseOpOpen.String():    domain.FuseOpOpen,
+	domain.FuseOpRead.String():    domain.FuseOpRead,
+	domain.FuseOpWrite.String():   domain.FuseOpWrite,
+	domain.FuseOpReadDir.String(): domain.FuseOpReadDir,
+}
+
+//
+// Parses a trace, which holds one operation per line: either '<op> <path>
+// [<value>]', or a json entry logged by sysbox-fs' request tracking (other
+// json entries are skipped). Blank lines and '#' comments are ignored.
+//
+func parseTrace(r io.Reader) ([]traceOp, error) {
+
+	var ops []traceOp
+
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for lineno := 1; s.Scan(); lineno++ {
+		line := strings.TrimSpace(s.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var (
+			op  traceOp
+			ok  bool
+			err error
+		)
+		if strings.HasPrefix(line, "{") {
+			op, ok, err = parseLogEntry(line)
+		} else {
+			op, ok, err = parseTraceLine(line)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("trace line %d: %v", lineno, err)
+		}
+		if ok {
+			ops = append(ops, op)
+		}
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(ops) == 0 {
+		return nil, fmt.Errorf("no operations found in trace")
+	}
+
+	return ops, nil
+}
+
+func parseTraceLine(line string) (traceOp, bool, error) {
+
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return traceOp{}, false, fmt.Errorf("missing path")
+	}
+
+	op, ok := traceOps[fields[0]]
+	if !ok {
+		return traceOp{}, false, fmt.Errorf("unknown operation %q", fields[0])
+	}
+
+	if !filepath.IsAbs(fields[1]) {
+		return traceOp{}, false, fmt.Errorf("path %q is not absolute", fields[1])
+	}
+
+	t := traceOp{
+		op:   op,
+		path: filepath.Clean(fields[1]),
+	}
+	if len(fields) > 2 {
+		if op != domain.FuseOpWrite {
+			return traceOp{}, false, fmt.Errorf("unexpected value for %v operation", op)
+		}
+		t.value = strings.Join(fields[2:], " ")
+	}
+
+	return t, true, nil
+}
+
+// Log entries carry no written values; writes replay the resources' host
+// values instead.
+func parseLogEntry(line string) (traceOp, bool, error) {
+
+	var entry map[string]interface{}
+
+	if err := json.Unmarshal([]byte(line), &entry); err != nil {
+		return traceOp{}, false, err
+	}
+
+	opName, _ := entry[logging.FieldOp].(string)
+	path, _ := entry[logging.FieldPath].(string)
+	if opName == "" || path == "" {
+		return traceOp{}, false, nil
+	}
+
+	op, ok := traceOps[opName]
+	if !ok {
+		return traceOp{}, false, fmt.Errorf("unknown operation %q", opName)
+	}
+
+	return traceOp{op: op, path: filepath.Clean(path)}, true, nil
+}
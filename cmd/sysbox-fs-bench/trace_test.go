@@ -0,0 +1,71 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nestybox/sysbox-fs/domain"
+)
+
+func Test_parseTrace(t *testing.T) {
+
+	trace := `# boot of a sys container
+lookup /proc/sys/net/core/somaxconn
+read /proc/sys/net/core/somaxconn
+write /proc/sys/kernel/domainname  example com
+
+{"level":"debug","msg":"Executing read operation","time":"2020-01-01T00:00:00Z"}
+{"handler":"procUptime","latency":"40µs","level":"debug","op":"read","path":"/proc/uptime","pid":1001}
+readdir /proc/sys/net/ipv4/
+`
+
+	ops, err := parseTrace(strings.NewReader(trace))
+	assert.NoError(t, err)
+	assert.Equal(t, []traceOp{
+		{op: domain.FuseOpLookup, path: "/proc/sys/net/core/somaxconn"},
+		{op: domain.FuseOpRead, path: "/proc/sys/net/core/somaxconn"},
+		{op: domain.FuseOpWrite, path: "/proc/sys/kernel/domainname", value: "example com"},
+		{op: domain.FuseOpRead, path: "/proc/uptime"},
+		{op: domain.FuseOpReadDir, path: "/proc/sys/net/ipv4"},
+	}, ops)
+}
+
+func Test_parseTraceErrors(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		trace string
+		want  string
+	}{
+		{"unknown op", "stat /proc/uptime\n", `trace line 1: unknown operation "stat"`},
+		{"missing path", "# comment\nread\n", "trace line 2: missing path"},
+		{"relative path", "read proc/uptime\n", `trace line 1: path "proc/uptime" is not absolute`},
+		{"read value", "read /proc/uptime 1\n", "trace line 1: unexpected value for read operation"},
+		{"empty", "# nothing\n", "no operations found in trace"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseTrace(strings.NewReader(tt.trace))
+			assert.EqualError(t, err, tt.want)
+		})
+	}
+}
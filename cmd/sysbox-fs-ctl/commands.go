@@ -0,0 +1,269 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	grpc "github.com/nestybox/sysbox-ipc/sysboxFsGrpc"
+	"github.com/urfave/cli"
+
+	"github.com/nestybox/sysbox-fs/conformance"
+)
+
+// Sends the given message to sysbox-fs and returns its response.
+func send(ctx *cli.Context, msg grpc.MessageType, data *grpc.ContainerData) (*grpc.ContainerData, error) {
+
+	resp, err := grpc.SendMessage(ctx.GlobalString("ipc-socket"), msg, data)
+	if err != nil {
+		return nil, cli.NewExitError(err.Error(), 1)
+	}
+
+	return resp, nil
+}
+
+// Verifies the number of positional arguments of a command.
+func checkArgs(ctx *cli.Context, min, max int) error {
+
+	if n := ctx.NArg(); n < min || n > max {
+		return cli.NewExitError(fmt.Sprintf("usage: %s %s %s",
+			ctx.App.Name, ctx.Command.Name, ctx.Command.ArgsUsage), 1)
+	}
+
+	return nil
+}
+
+func listContainers(ctx *cli.Context) error {
+
+	if err := checkArgs(ctx, 0, 0); err != nil {
+		return err
+	}
+
+	resp, err := send(ctx, grpc.ContainerListMessage, &grpc.ContainerData{})
+	if err != nil {
+		return err
+	}
+
+	return printContainers(os.Stdout, resp)
+}
+
+// Prints the op-stats of each container in the order reported by sysbox-fs
+// (busiest first).
+func printContainers(out io.Writer, data *grpc.ContainerData) error {
+
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "CONTAINER ID\tLOOKUPS\tOPENS\tREADS\tWRITES\tREADDIRS\tERRORS")
+	for _, s := range data.OpStats {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%d\t%d\n",
+			s.Id, s.Lookups, s.Opens, s.Reads, s.Writes, s.ReadDirs, s.Errors)
+	}
+
+	return w.Flush()
+}
+
+func inspectContainer(ctx *cli.Context) error {
+
+	if err := checkArgs(ctx, 1, 1); err != nil {
+		return err
+	}
+
+	resp, err := send(ctx, grpc.ContainerInspectMessage,
+		&grpc.ContainerData{Id: ctx.Args().Get(0)})
+	if err != nil {
+		return err
+	}
+
+	return printContainer(os.Stdout, resp)
+}
+
+// Container attributes as displayed by the 'inspect' command.
+type containerView struct {
+	Id              string                  `json:"id"`
+	InitPid         int32                   `json:"init-pid"`
+	Ctime           time.Time               `json:"ctime"`
+	Hostname        string                  `json:"hostname"`
+	UidMappings     []grpc.IDMapping        `json:"uid-mappings"`
+	GidMappings     []grpc.IDMapping        `json:"gid-mappings"`
+	CgroupV1Paths   map[string]string       `json:"cgroup-v1-paths,omitempty"`
+	CgroupV2Path    string                  `json:"cgroup-v2-path,omitempty"`
+	ProcRoPaths     []string                `json:"proc-ro-paths"`
+	ProcMaskPaths   []string                `json:"proc-mask-paths"`
+	Limits          limitsView              `json:"limits"`
+	Overrides       []grpc.NodeOverride     `json:"overrides,omitempty"`
+	RebootRequested bool                    `json:"reboot-requested"`
+	OpStats         []grpc.ContainerOpStats `json:"op-stats"`
+	State           json.RawMessage         `json:"state,omitempty"`
+}
+
+type limitsView struct {
+	CpusetCpus   string `json:"cpuset-cpus"`
+	CpusetMems   string `json:"cpuset-mems"`
+	CpuQuota     int64  `json:"cpu-quota"`
+	CpuPeriod    uint64 `json:"cpu-period"`
+	CpuShares    uint64 `json:"cpu-shares"`
+	MemLimit     int64  `json:"mem-limit"`
+	MemSwapLimit int64  `json:"mem-swap-limit"`
+}
+
+func printContainer(out io.Writer, data *grpc.ContainerData) error {
+
+	v := containerView{
+		Id:              data.Id,
+		InitPid:         data.InitPid,
+		Ctime:           data.Ctime,
+		Hostname:        data.Hostname,
+		UidMappings:     data.UidMappings,
+		GidMappings:     data.GidMappings,
+		CgroupV1Paths:   data.CgroupV1Paths,
+		CgroupV2Path:    data.CgroupV2Path,
+		ProcRoPaths:     data.ProcRoPaths,
+		ProcMaskPaths:   data.ProcMaskPaths,
+		Overrides:       data.Overrides,
+		RebootRequested: data.RebootRequested,
+		OpStats:         data.OpStats,
+		Limits: limitsView{
+			CpusetCpus:   data.CpusetCpus,
+			CpusetMems:   data.CpusetMems,
+			CpuQuota:     data.CpuQuota,
+			CpuPeriod:    data.CpuPeriod,
+			CpuShares:    data.CpuShares,
+			MemLimit:     data.MemLimit,
+			MemSwapLimit: data.MemSwapLimit,
+		},
+	}
+
+	// The emulated state is exported by sysbox-fs in json format already.
+	if json.Valid(data.State) {
+		v.State = data.State
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(v)
+}
+
+func dumpHandlers(ctx *cli.Context) error {
+
+	if err := checkArgs(ctx, 0, 0); err != nil {
+		return err
+	}
+
+	resp, err := send(ctx, grpc.HandlerListMessage, &grpc.ContainerData{})
+	if err != nil {
+		return err
+	}
+
+	return printHandlers(os.Stdout, resp)
+}
+
+func printHandlers(out io.Writer, data *grpc.ContainerData) error {
+
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tPATH\tENABLED")
+	for _, h := range data.Handlers {
+		fmt.Fprintf(w, "%s\t%s\t%v\n", h.Name, h.Path, h.Enabled)
+	}
+
+	return w.Flush()
+}
+
+func setValue(ctx *cli.Context) error {
+
+	if err := checkArgs(ctx, 3, 3); err != nil {
+		return err
+	}
+
+	// Values are written the way 'echo' would do it.
+	value := ctx.Args().Get(2)
+	if !strings.HasSuffix(value, "\n") {
+		value += "\n"
+	}
+
+	_, err := send(ctx, grpc.ContainerSetMessage, &grpc.ContainerData{
+		Id:    ctx.Args().Get(0),
+		Path:  ctx.Args().Get(1),
+		Value: value,
+	})
+
+	return err
+}
+
+func invalidateCache(ctx *cli.Context) error {
+
+	if err := checkArgs(ctx, 0, 1); err != nil {
+		return err
+	}
+
+	_, err := send(ctx, grpc.CacheInvalidateMessage,
+		&grpc.ContainerData{Id: ctx.Args().Get(0)})
+
+	return err
+}
+
+func captureConformance(ctx *cli.Context) error {
+
+	if err := checkArgs(ctx, 1, 1); err != nil {
+		return err
+	}
+
+	if err := conformance.Capture(conformance.DefaultSpecs, ctx.Args().Get(0)); err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+
+	return nil
+}
+
+func compareConformance(ctx *cli.Context) error {
+
+	if err := checkArgs(ctx, 2, 2); err != nil {
+		return err
+	}
+
+	diffs, err := conformance.CompareDirs(conformance.DefaultSpecs,
+		ctx.Args().Get(0), ctx.Args().Get(1))
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+
+	if err := printDiffs(os.Stdout, diffs); err != nil {
+		return err
+	}
+
+	if len(diffs) > 0 {
+		return cli.NewExitError(fmt.Sprintf("%d non-conforming entries", len(diffs)), 1)
+	}
+
+	return nil
+}
+
+func printDiffs(out io.Writer, diffs []conformance.Diff) error {
+
+	for _, d := range diffs {
+		if _, err := fmt.Fprintln(out, d); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	grpc "github.com/nestybox/sysbox-ipc/sysboxFsGrpc"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nestybox/sysbox-fs/conformance"
+)
+
+func Test_printContainers(t *testing.T) {
+
+	var buf bytes.Buffer
+
+	data := &grpc.ContainerData{
+		OpStats: []grpc.ContainerOpStats{
+			{Id: "c2", Lookups: 5, Writes: 5},
+			{Id: "c1", Reads: 1, Errors: 1},
+		},
+	}
+
+	assert.NoError(t, printContainers(&buf, data))
+	assert.Equal(t,
+		"CONTAINER ID  LOOKUPS  OPENS  READS  WRITES  READDIRS  ERRORS\n"+
+			"c2            5        0      0      5       0         0\n"+
+			"c1            0        0      1      0       0         1\n",
+		buf.String())
+}
+
+func Test_printHandlers(t *testing.T) {
+
+	var buf bytes.Buffer
+
+	data := &grpc.ContainerData{
+		Handlers: []grpc.HandlerInfo{
+			{Name: "swaps", Path: "/proc/swaps", Enabled: false},
+			{Name: "uptime", Path: "/proc/uptime", Enabled: true},
+		},
+	}
+
+	assert.NoError(t, printHandlers(&buf, data))
+	assert.Equal(t,
+		"NAME    PATH          ENABLED\n"+
+			"swaps   /proc/swaps   false\n"+
+			"uptime  /proc/uptime  true\n",
+		buf.String())
+}
+
+func Test_printContainer(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		state     []byte
+		wantState bool
+	}{
+		{
+			//
+			// Test-case 1: Exported state is embedded as is.
+			//
+			name:      "1",
+			state:     []byte(`{"version":1,"id":"c1"}`),
+			wantState: true,
+		},
+		{
+			//
+			// Test-case 2: No state exported.
+			//
+			name:      "2",
+			state:     nil,
+			wantState: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			var buf bytes.Buffer
+
+			data := &grpc.ContainerData{Id: "c1", InitPid: 1001, State: tt.state}
+			assert.NoError(t, printContainer(&buf, data))
+
+			var got map[string]interface{}
+			assert.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+			assert.Equal(t, "c1", got["id"])
+			assert.Equal(t, float64(1001), got["init-pid"])
+
+			if tt.wantState {
+				assert.Equal(t, map[string]interface{}{"version": float64(1), "id": "c1"},
+					got["state"])
+			} else {
+				assert.NotContains(t, got, "state")
+			}
+		})
+	}
+}
+
+func Test_printDiffs(t *testing.T) {
+
+	var buf bytes.Buffer
+
+	diffs := []conformance.Diff{
+		{Path: "/proc/meminfo", Key: "MemTotal", Native: "1024 kB",
+			Emulated: "2048 kB", Reason: "field 0 exceeds native value"},
+		{Path: "/proc/uptime", Reason: "missing node"},
+	}
+
+	assert.NoError(t, printDiffs(&buf, diffs))
+	assert.Equal(t,
+		"/proc/meminfo: MemTotal: field 0 exceeds native value "+
+			"(native \"1024 kB\", emulated \"2048 kB\")\n"+
+			"/proc/uptime: missing node\n",
+		buf.String())
+}
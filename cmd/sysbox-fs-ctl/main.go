@@ -0,0 +1,126 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli"
+
+	"github.com/nestybox/sysbox-fs/ipc"
+)
+
+const (
+	usage = `sysbox-fs control utility
+
+sysbox-fs-ctl queries and adjusts the state of a running sysbox-fs
+daemon through its ipc socket, allowing live troubleshooting of
+sys containers without restarting sysbox-fs.
+`
+)
+
+// Globals to be populated at build time during Makefile processing.
+var (
+	version  string // extracted from VERSION file
+	commitId string // latest git commit-id of sysbox superproject
+	builtAt  string // build time
+	builtBy  string // build owner
+)
+
+//
+// sysbox-fs-ctl main function
+//
+func main() {
+
+	app := cli.NewApp()
+	app.Name = "sysbox-fs-ctl"
+	app.Usage = usage
+	app.Version = version
+
+	app.Flags = []cli.Flag{
+		cli.StringFlag{
+			Name:  "ipc-socket",
+			Value: ipc.DefaultSocketPath,
+			Usage: "unix socket sysbox-fs serves ipc requests at",
+		},
+	}
+
+	// show-version specialization.
+	cli.VersionPrinter = func(c *cli.Context) {
+		fmt.Printf("sysbox-fs-ctl\n"+
+			"\tversion: \t%s\n"+
+			"\tcommit: \t%s\n"+
+			"\tbuilt at: \t%s\n"+
+			"\tbuilt by: \t%s\n",
+			c.App.Version, commitId, builtAt, builtBy)
+	}
+
+	app.Commands = []cli.Command{
+		{
+			Name:   "list",
+			Usage:  "List the registered containers, busiest first",
+			Action: listContainers,
+		},
+		{
+			Name:      "inspect",
+			Usage:     "Display the state of a container",
+			ArgsUsage: "<container>",
+			Action:    inspectContainer,
+		},
+		{
+			Name:   "dump-handlers",
+			Usage:  "List the emulation handlers registered in sysbox-fs",
+			Action: dumpHandlers,
+		},
+		{
+			Name:      "set",
+			Usage:     "Write a value into an emulated resource of a container",
+			ArgsUsage: "<container> <path> <value>",
+			Action:    setValue,
+		},
+		{
+			Name:      "invalidate-cache",
+			Usage:     "Drop the cached node attributes of a container (all containers if none given)",
+			ArgsUsage: "[container]",
+			Action:    invalidateCache,
+		},
+		{
+			Name:  "conformance",
+			Usage: "Verify the format of the emulated resources against the native ones",
+			Subcommands: []cli.Command{
+				{
+					Name:      "capture",
+					Usage:     "Capture the resources into a directory (run in the host and within a test container)",
+					ArgsUsage: "<dir>",
+					Action:    captureConformance,
+				},
+				{
+					Name:      "compare",
+					Usage:     "Compare the native and emulated captures",
+					ArgsUsage: "<native-dir> <emulated-dir>",
+					Action:    compareConformance,
+				},
+			},
+		},
+	}
+
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintf(os.Stderr, "sysbox-fs-ctl: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,100 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/urfave/cli"
+	"gopkg.in/yaml.v2"
+
+	"github.com/nestybox/sysbox-fs/handler"
+)
+
+//
+// Operator-facing commands. Unlike the 'serve' one, these don't launch the
+// sysbox-fs engine, and report problems through their exit status rather
+// than through the log.
+//
+
+// Loads and verifies the configuration, exiting with a non-zero status if
+// it's not valid.
+func validateConfig(ctx *cli.Context) error {
+
+	cfg, err := loadValidConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("configuration is valid (mountpoint %s)\n", cfg.Mountpoint)
+
+	return nil
+}
+
+// Prints the configuration resulting from the config file and the flags.
+func debugConfig(ctx *cli.Context) error {
+
+	cfg, err := loadValidConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	out, err := yaml.Marshal(cfg)
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+
+	fmt.Print(string(out))
+
+	return nil
+}
+
+// Lists the emulation handlers, along with their state as per the config.
+func debugHandlers(ctx *cli.Context) error {
+
+	cfg, err := loadValidConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := cfg.applyHandlerPolicies(handler.DefaultHandlers, nil); err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tPATH\tENABLED")
+	for _, h := range handler.DefaultHandlers {
+		fmt.Fprintf(w, "%s\t%s\t%v\n", h.GetName(), h.GetPath(), h.GetEnabled())
+	}
+
+	return w.Flush()
+}
+
+func loadValidConfig(ctx *cli.Context) (*config, error) {
+
+	cfg, err := loadConfig(ctx)
+	if err == nil {
+		err = cfg.validate(handler.DefaultHandlers)
+	}
+	if err != nil {
+		return nil, cli.NewExitError(fmt.Sprintf("invalid configuration: %v", err), 1)
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,381 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli"
+	"gopkg.in/yaml.v2"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/logging"
+)
+
+// Location of the config file if none is explicitly given.
+const defaultConfigFile = "/etc/sysbox/sysbox-fs.yaml"
+
+//
+// sysbox-fs configuration. Settings are obtained from the following sources,
+// in increasing order of precedence:
+//
+// 1) Built-in defaults (i.e. the default values of the cli flags).
+// 2) Config file (yaml).
+// 3) Cli flags explicitly passed in the command-line.
+//
+type config struct {
+	Mountpoint     string         `yaml:"mountpoint"`
+	StateDir       string         `yaml:"state-dir"`
+	ReaperInterval time.Duration  `yaml:"reaper-interval"`
+	Log            logConfig      `yaml:"log"`
+	Fuse           fuseConfig     `yaml:"fuse"`
+	Handlers       handlersConfig `yaml:"handlers"`
+	Ipc            ipcConfig      `yaml:"ipc"`
+	Nsenter        nsenterConfig  `yaml:"nsenter"`
+	Tracing        tracingConfig  `yaml:"tracing"`
+	PprofAddress   string         `yaml:"pprof-address"`   // disabled if empty
+	FaultInjection bool           `yaml:"fault-injection"` // served at the pprof address
+	AuditLog       string         `yaml:"audit-log"`       // disabled if empty
+	HostCacheTTL   time.Duration  `yaml:"host-cache-ttl"`  // zero disables the cache
+	SoakInterval   time.Duration  `yaml:"soak-interval"`   // zero disables soak accounting
+}
+
+type logConfig struct {
+	File   string            `yaml:"file"`
+	Level  string            `yaml:"level"`
+	Format string            `yaml:"format"` // text or json
+	Levels map[string]string `yaml:"levels"` // per-subsystem level overrides
+
+	MaxSize    int64 `yaml:"max-size"`    // in MiB; zero disables rotation
+	MaxBackups int   `yaml:"max-backups"` // rotated files to keep
+
+	SlowThreshold time.Duration `yaml:"slow-threshold"` // zero disables slow-op logging
+}
+
+type fuseConfig struct {
+	DentryCacheTimeout time.Duration `yaml:"dentry-cache-timeout"` // zero means no expiration
+}
+
+type handlersConfig struct {
+	IgnoreErrors bool              `yaml:"ignore-errors"`
+	Disabled     []string          `yaml:"disabled"` // names of the handlers to disable
+	Defaults     map[string]string `yaml:"defaults"` // initial values of emulated resources (by path)
+}
+
+type ipcConfig struct {
+	Socket      string   `yaml:"socket"`
+	AllowedUids []uint32 `yaml:"allowed-uids"`
+	AllowedExes []string `yaml:"allowed-exes"`
+}
+
+type tracingConfig struct {
+	Enabled   bool          `yaml:"enabled"`
+	Threshold time.Duration `yaml:"threshold"` // shorter requests aren't traced
+	File      string        `yaml:"file"`      // spans are logged if empty
+}
+
+type nsenterConfig struct {
+	MaxConcurrency  int `yaml:"max-concurrency"`
+	MaxPerContainer int `yaml:"max-per-container"`
+}
+
+// Builds the sysbox-fs configuration out of the cli flags and the config file
+// they point to (if any). A missing config file is only an error if it was
+// explicitly requested.
+func loadConfig(ctx *cli.Context) (*config, error) {
+
+	cfg := &config{}
+
+	if err := cfg.applyFlags(ctx, false); err != nil {
+		return nil, err
+	}
+
+	path := ctx.GlobalString("config")
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) || ctx.GlobalIsSet("config") {
+			return nil, fmt.Errorf("failed to read config file: %v", err)
+		}
+		return cfg, nil
+	}
+
+	// Unknown settings are rejected to catch typos.
+	if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %v", path, err)
+	}
+
+	if err := cfg.applyFlags(ctx, true); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// Populates the config with the values of the cli flags. If 'onlySet' is
+// true, only the flags explicitly passed in the command-line are considered.
+func (cfg *config) applyFlags(ctx *cli.Context, onlySet bool) error {
+
+	isSet := func(name string) bool {
+		return !onlySet || ctx.GlobalIsSet(name)
+	}
+
+	if isSet("mountpoint") {
+		cfg.Mountpoint = ctx.GlobalString("mountpoint")
+	}
+	if isSet("state-dir") {
+		cfg.StateDir = ctx.GlobalString("state-dir")
+	}
+	if isSet("reaper-interval") {
+		cfg.ReaperInterval = ctx.GlobalDuration("reaper-interval")
+	}
+	if isSet("log-file") {
+		cfg.Log.File = ctx.GlobalString("log-file")
+	}
+	// Former name of the "log-file" flag.
+	if ctx.GlobalIsSet("log") {
+		cfg.Log.File = ctx.GlobalString("log")
+	}
+	if isSet("log-level") {
+		cfg.Log.Level = ctx.GlobalString("log-level")
+	}
+	if isSet("log-max-size") {
+		cfg.Log.MaxSize = ctx.GlobalInt64("log-max-size")
+	}
+	if isSet("log-max-backups") {
+		cfg.Log.MaxBackups = ctx.GlobalInt("log-max-backups")
+	}
+	if isSet("log-slow-threshold") {
+		cfg.Log.SlowThreshold = ctx.GlobalDuration("log-slow-threshold")
+	}
+	if isSet("log-format") {
+		cfg.Log.Format = ctx.GlobalString("log-format")
+	}
+	if isSet("log-subsystem-levels") {
+		levels, err := parseLevelList(ctx.GlobalString("log-subsystem-levels"))
+		if err != nil {
+			return fmt.Errorf("invalid log-subsystem-levels option: %v", err)
+		}
+		cfg.Log.Levels = levels
+	}
+	if isSet("audit-log") {
+		cfg.AuditLog = ctx.GlobalString("audit-log")
+	}
+	if isSet("pprof-address") {
+		cfg.PprofAddress = ctx.GlobalString("pprof-address")
+	}
+	if isSet("fault-injection") {
+		cfg.FaultInjection = ctx.GlobalBool("fault-injection")
+	}
+	if isSet("host-cache-ttl") {
+		cfg.HostCacheTTL = ctx.GlobalDuration("host-cache-ttl")
+	}
+	if isSet("soak-interval") {
+		cfg.SoakInterval = ctx.GlobalDuration("soak-interval")
+	}
+	if isSet("dentry-cache-timeout") {
+		cfg.Fuse.DentryCacheTimeout = ctx.GlobalDuration("dentry-cache-timeout")
+	}
+	if isSet("ignore-handler-errors") {
+		cfg.Handlers.IgnoreErrors = ctx.GlobalBool("ignore-handler-errors")
+	}
+	if isSet("ipc-socket") {
+		cfg.Ipc.Socket = ctx.GlobalString("ipc-socket")
+	}
+	if isSet("ipc-allowed-uids") {
+		uids, err := parseUidList(ctx.GlobalString("ipc-allowed-uids"))
+		if err != nil {
+			return fmt.Errorf("invalid ipc-allowed-uids option: %v", err)
+		}
+		cfg.Ipc.AllowedUids = uids
+	}
+	if isSet("ipc-allowed-exes") {
+		cfg.Ipc.AllowedExes = nil
+		if exes := ctx.GlobalString("ipc-allowed-exes"); exes != "" {
+			cfg.Ipc.AllowedExes = strings.Split(exes, ",")
+		}
+	}
+	if isSet("nsenter-max-concurrency") {
+		cfg.Nsenter.MaxConcurrency = ctx.GlobalInt("nsenter-max-concurrency")
+	}
+	if isSet("nsenter-max-per-container") {
+		cfg.Nsenter.MaxPerContainer = ctx.GlobalInt("nsenter-max-per-container")
+	}
+	if isSet("tracing") {
+		cfg.Tracing.Enabled = ctx.GlobalBool("tracing")
+	}
+	if isSet("tracing-threshold") {
+		cfg.Tracing.Threshold = ctx.GlobalDuration("tracing-threshold")
+	}
+	if isSet("tracing-file") {
+		cfg.Tracing.File = ctx.GlobalString("tracing-file")
+	}
+
+	return nil
+}
+
+// Log levels supported by the "log.level" setting.
+var logLevels = map[string]bool{
+	"debug":   true,
+	"info":    true,
+	"warning": true,
+	"error":   true,
+	"fatal":   true,
+}
+
+// Parses a comma-separated list of "subsystem=level" pairs.
+func parseLevelList(s string) (map[string]string, error) {
+
+	var levels map[string]string
+
+	for _, f := range strings.Split(s, ",") {
+		if f = strings.TrimSpace(f); f == "" {
+			continue
+		}
+
+		kv := strings.SplitN(f, "=", 2)
+		if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+			return nil, fmt.Errorf("invalid subsystem level %q", f)
+		}
+		if levels == nil {
+			levels = make(map[string]string)
+		}
+		levels[kv[0]] = kv[1]
+	}
+
+	return levels, nil
+}
+
+// Verifies the consistency of the config settings. Handler names and resource
+// paths are checked against the given handlers.
+func (cfg *config) validate(hdlrs []domain.HandlerIface) error {
+
+	if !filepath.IsAbs(cfg.Mountpoint) {
+		return fmt.Errorf("mountpoint must be an absolute path: %q", cfg.Mountpoint)
+	}
+
+	if cfg.Ipc.Socket != "" && !filepath.IsAbs(cfg.Ipc.Socket) {
+		return fmt.Errorf("ipc socket must be an absolute path: %q", cfg.Ipc.Socket)
+	}
+
+	if cfg.Log.Level != "" && !logLevels[cfg.Log.Level] {
+		return fmt.Errorf("log-level %q not recognized", cfg.Log.Level)
+	}
+
+	if cfg.Log.Format != "" && cfg.Log.Format != "text" && cfg.Log.Format != "json" {
+		return fmt.Errorf("log-format %q not recognized", cfg.Log.Format)
+	}
+
+	for sub, level := range cfg.Log.Levels {
+		if !logging.IsSubsystem(sub) {
+			return fmt.Errorf("unknown logging subsystem %q", sub)
+		}
+		if !logLevels[level] {
+			return fmt.Errorf("log-level %q of subsystem %s not recognized", level, sub)
+		}
+	}
+
+	if cfg.ReaperInterval < 0 || cfg.Fuse.DentryCacheTimeout < 0 ||
+		cfg.Tracing.Threshold < 0 || cfg.Log.SlowThreshold < 0 ||
+		cfg.HostCacheTTL < 0 || cfg.SoakInterval < 0 {
+		return fmt.Errorf("negative durations are not allowed")
+	}
+
+	if cfg.Log.MaxSize < 0 || cfg.Log.MaxBackups < 0 {
+		return fmt.Errorf("negative log rotation settings are not allowed")
+	}
+
+	if cfg.Nsenter.MaxConcurrency < 0 || cfg.Nsenter.MaxPerContainer < 0 {
+		return fmt.Errorf("negative nsenter limits are not allowed")
+	}
+
+	if cfg.AuditLog != "" && !filepath.IsAbs(cfg.AuditLog) {
+		return fmt.Errorf("audit log must be an absolute path: %q", cfg.AuditLog)
+	}
+
+	if cfg.PprofAddress != "" {
+		if err := checkPprofAddr(cfg.PprofAddress); err != nil {
+			return err
+		}
+	}
+
+	if cfg.FaultInjection && cfg.PprofAddress == "" {
+		return fmt.Errorf("fault-injection requires a pprof-address")
+	}
+
+	names := make(map[string]bool, len(hdlrs))
+	byPath := make(map[string]domain.HandlerIface, len(hdlrs))
+	for _, h := range hdlrs {
+		names[h.GetName()] = true
+		byPath[h.GetPath()] = h
+	}
+
+	for _, name := range cfg.Handlers.Disabled {
+		if !names[name] {
+			return fmt.Errorf("unknown handler %q", name)
+		}
+	}
+
+	// Default values are only supported by the resources emulated at
+	// sys-container level (i.e. those initialized with the host values).
+	for p, val := range cfg.Handlers.Defaults {
+		if _, ok := byPath[p].(domain.HostSnapshotIface); !ok {
+			return fmt.Errorf("default value not supported for resource %q", p)
+		}
+		if strings.TrimSpace(val) == "" {
+			return fmt.Errorf("empty default value for resource %q", p)
+		}
+	}
+
+	return nil
+}
+
+// Disables the handlers listed in the config. Unknown handler names are
+// reported as errors. If 'defaults' is given, the handlers not listed are
+// reset to their default state (i.e. upon config reloads).
+func (cfg *config) applyHandlerPolicies(
+	hdlrs []domain.HandlerIface,
+	defaults map[string]bool) error {
+
+	byName := make(map[string]domain.HandlerIface, len(hdlrs))
+	for _, h := range hdlrs {
+		byName[h.GetName()] = h
+	}
+
+	for _, name := range cfg.Handlers.Disabled {
+		if _, ok := byName[name]; !ok {
+			return fmt.Errorf("unknown handler %q", name)
+		}
+	}
+
+	for name, enabled := range defaults {
+		if h, ok := byName[name]; ok {
+			h.SetEnabled(enabled)
+		}
+	}
+
+	for _, name := range cfg.Handlers.Disabled {
+		byName[name].SetEnabled(false)
+	}
+
+	return nil
+}
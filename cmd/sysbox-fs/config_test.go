@@ -0,0 +1,260 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/handler/implementations"
+)
+
+// Builds a cli context out of the given command-line arguments.
+func testContext(t *testing.T, args ...string) *cli.Context {
+
+	set := flag.NewFlagSet("test", flag.ContinueOnError)
+	set.String("config", "/nonexistent/sysbox-fs.yaml", "")
+	set.String("mountpoint", "/var/lib/sysboxfs", "")
+	set.String("log-level", "info", "")
+	set.String("log-subsystem-levels", "", "")
+	set.String("ipc-allowed-uids", "0", "")
+	set.Duration("reaper-interval", time.Minute, "")
+	set.String("pprof-address", "", "")
+	set.Bool("fault-injection", false, "")
+
+	if err := set.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+
+	return cli.NewContext(nil, set, nil)
+}
+
+func Test_loadConfig(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "sysbox-fs-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "sysbox-fs.yaml")
+	err = ioutil.WriteFile(path, []byte(`
+mountpoint: /mnt/sysboxfs
+reaper-interval: 5m
+log:
+  level: debug
+  levels:
+    nsenter: debug
+ipc:
+  allowed-uids: [0, 1000]
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	badPath := filepath.Join(dir, "bad.yaml")
+	if err := ioutil.WriteFile(badPath, []byte("mountpont: /mnt\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    func(*config)
+		wantErr bool
+	}{
+		{
+			//
+			// Test-case 1: No config file in the default location. Built-in
+			// defaults expected.
+			//
+			name: "1",
+			args: nil,
+			want: func(cfg *config) {
+				assert.Equal(t, "/var/lib/sysboxfs", cfg.Mountpoint)
+				assert.Equal(t, "info", cfg.Log.Level)
+				assert.Equal(t, []uint32{0}, cfg.Ipc.AllowedUids)
+				assert.Equal(t, time.Minute, cfg.ReaperInterval)
+			},
+		},
+		{
+			//
+			// Test-case 2: Config file settings override the defaults.
+			//
+			name: "2",
+			args: []string{"--config", path},
+			want: func(cfg *config) {
+				assert.Equal(t, "/mnt/sysboxfs", cfg.Mountpoint)
+				assert.Equal(t, "debug", cfg.Log.Level)
+				assert.Equal(t, []uint32{0, 1000}, cfg.Ipc.AllowedUids)
+				assert.Equal(t, 5*time.Minute, cfg.ReaperInterval)
+				assert.Equal(t, map[string]string{"nsenter": "debug"}, cfg.Log.Levels)
+			},
+		},
+		{
+			//
+			// Test-case 3: Command-line flags override the config file.
+			//
+			name: "3",
+			args: []string{"--config", path, "--log-level", "error", "--ipc-allowed-uids", "5"},
+			want: func(cfg *config) {
+				assert.Equal(t, "/mnt/sysboxfs", cfg.Mountpoint)
+				assert.Equal(t, "error", cfg.Log.Level)
+				assert.Equal(t, []uint32{5}, cfg.Ipc.AllowedUids)
+			},
+		},
+		{
+			//
+			// Test-case 4: Explicitly requested config file missing.
+			//
+			name:    "4",
+			args:    []string{"--config", filepath.Join(dir, "missing.yaml")},
+			wantErr: true,
+		},
+		{
+			//
+			// Test-case 5: Unknown settings are rejected.
+			//
+			name:    "5",
+			args:    []string{"--config", badPath},
+			wantErr: true,
+		},
+		{
+			//
+			// Test-case 6: Per-subsystem log-levels passed in the command-line
+			// replace the ones in the config file.
+			//
+			name: "6",
+			args: []string{"--config", path, "--log-subsystem-levels", "fuse=error, ipc=debug"},
+			want: func(cfg *config) {
+				assert.Equal(t, map[string]string{"fuse": "error", "ipc": "debug"}, cfg.Log.Levels)
+			},
+		},
+		{
+			//
+			// Test-case 7: Malformed per-subsystem log-level.
+			//
+			name:    "7",
+			args:    []string{"--log-subsystem-levels", "fuse"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := loadConfig(testContext(t, tt.args...))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.want != nil {
+				tt.want(cfg)
+			}
+		})
+	}
+}
+
+func Test_applyHandlerPolicies(t *testing.T) {
+
+	h1 := &implementations.RootHandler{Name: "h1", Path: "/h1", Enabled: true}
+	h2 := &implementations.RootHandler{Name: "h2", Path: "/h2", Enabled: true}
+	h3 := &implementations.RootHandler{Name: "h3", Path: "/h3", Enabled: false}
+	hdlrs := []domain.HandlerIface{h1, h2, h3}
+
+	defaults := map[string]bool{"h1": true, "h2": true, "h3": false}
+
+	// Initial policy.
+	cfg := &config{Handlers: handlersConfig{Disabled: []string{"h1"}}}
+	assert.NoError(t, cfg.applyHandlerPolicies(hdlrs, nil))
+	assert.False(t, h1.GetEnabled())
+	assert.True(t, h2.GetEnabled())
+	assert.False(t, h3.GetEnabled())
+
+	// Reloaded policy: handlers no longer listed get back to their defaults.
+	cfg = &config{Handlers: handlersConfig{Disabled: []string{"h2"}}}
+	assert.NoError(t, cfg.applyHandlerPolicies(hdlrs, defaults))
+	assert.True(t, h1.GetEnabled())
+	assert.False(t, h2.GetEnabled())
+	assert.False(t, h3.GetEnabled())
+
+	// Unknown handlers are rejected, leaving the current policy untouched.
+	cfg = &config{Handlers: handlersConfig{Disabled: []string{"foo"}}}
+	assert.Error(t, cfg.applyHandlerPolicies(hdlrs, defaults))
+	assert.True(t, h1.GetEnabled())
+	assert.False(t, h2.GetEnabled())
+}
+
+func Test_validateHandlerDefaults(t *testing.T) {
+
+	h1 := &implementations.RootHandler{Name: "h1", Path: "/h1", Enabled: true}
+	h2 := &implementations.KernelPanicHandler{
+		Name:    "kernelPanic",
+		Path:    "/proc/sys/kernel/panic",
+		Enabled: true,
+	}
+	hdlrs := []domain.HandlerIface{h1, h2}
+
+	newCfg := func(defaults map[string]string) *config {
+		return &config{
+			Mountpoint: "/var/lib/sysboxfs",
+			Handlers:   handlersConfig{Defaults: defaults},
+		}
+	}
+
+	// Resources emulated at sys-container level accept default values.
+	cfg := newCfg(map[string]string{"/proc/sys/kernel/panic": "10"})
+	assert.NoError(t, cfg.validate(hdlrs))
+
+	// Empty values are rejected.
+	cfg = newCfg(map[string]string{"/proc/sys/kernel/panic": " "})
+	assert.Error(t, cfg.validate(hdlrs))
+
+	// Unknown resources, and those not emulated at sys-container level, are
+	// rejected.
+	cfg = newCfg(map[string]string{"/proc/sys/kernel/foo": "1"})
+	assert.Error(t, cfg.validate(hdlrs))
+
+	cfg = newCfg(map[string]string{"/h1": "1"})
+	assert.Error(t, cfg.validate(hdlrs))
+}
+
+func Test_validateFaultInjection(t *testing.T) {
+
+	cfg := &config{Mountpoint: "/var/lib/sysboxfs", FaultInjection: true}
+
+	// The fault-injection endpoint is served at the pprof address.
+	assert.Error(t, cfg.validate(nil))
+
+	cfg.PprofAddress = "localhost:6060"
+	assert.NoError(t, cfg.validate(nil))
+
+	// Same when requested through the command-line, as done at start-up.
+	cfg, err := loadConfig(testContext(t, "--fault-injection"))
+	assert.NoError(t, err)
+	assert.Error(t, cfg.validate(nil))
+
+	cfg, err = loadConfig(testContext(t, "--fault-injection", "--pprof-address", "localhost:6060"))
+	assert.NoError(t, err)
+	assert.NoError(t, cfg.validate(nil))
+}
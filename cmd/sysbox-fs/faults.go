@@ -0,0 +1,300 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+
+	"github.com/nestybox/sysbox-fs/domain"
+)
+
+// Debug-server endpoint through which the injected faults are managed.
+const faultsEndpoint = "/debug/faults"
+
+//
+// Faults injected into each sysbox-fs service, as exchanged through the
+// fault-injection endpoint.
+//
+type faultsView struct {
+	IO      chaosView `json:"io"`
+	Nsenter chaosView `json:"nsenter"`
+}
+
+// domain.ChaosConfig with a human-readable delay (e.g. "50ms").
+type chaosView struct {
+	ErrorRate float64 `json:"error-rate"`
+	DelayRate float64 `json:"delay-rate"`
+	Delay     string  `json:"delay"`
+	CrashRate float64 `json:"crash-rate"`
+}
+
+func newChaosView(c domain.ChaosConfig) chaosView {
+	return chaosView{
+		ErrorRate: c.ErrorRate,
+		DelayRate: c.DelayRate,
+		Delay:     c.Delay.String(),
+		CrashRate: c.CrashRate,
+	}
+}
+
+func (v chaosView) config() (domain.ChaosConfig, error) {
+
+	c := domain.ChaosConfig{
+		ErrorRate: v.ErrorRate,
+		DelayRate: v.DelayRate,
+		CrashRate: v.CrashRate,
+	}
+
+	if v.Delay != "" {
+		d, err := time.ParseDuration(v.Delay)
+		if err != nil {
+			return c, fmt.Errorf("invalid fault delay: %v", err)
+		}
+		c.Delay = d
+	}
+
+	return c, c.Validate()
+}
+
+//
+// Serves the fault-injection endpoint: GET reports the injected faults, PUT
+// replaces them, and DELETE stops injecting them.
+//
+type faultsHandler struct {
+	io      domain.ChaosIface
+	nsenter domain.ChaosIface
+}
+
+func newFaultsHandler(ios domain.IOServiceIface, nss domain.NSenterServiceIface) http.Handler {
+
+	h := &faultsHandler{}
+	h.io, _ = ios.(domain.ChaosIface)
+	h.nsenter, _ = nss.(domain.ChaosIface)
+
+	return h
+}
+
+func (h *faultsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	switch r.Method {
+	case http.MethodGet:
+
+	case http.MethodPut:
+		var v faultsView
+		if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		ioCfg, err := v.IO.config()
+		if err == nil && h.io == nil && !ioCfg.IsZero() {
+			err = fmt.Errorf("host I/O fault injection not supported")
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		nsCfg, err := v.Nsenter.config()
+		if err == nil && h.nsenter == nil && !nsCfg.IsZero() {
+			err = fmt.Errorf("nsenter fault injection not supported")
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		h.set(ioCfg, nsCfg)
+
+	case http.MethodDelete:
+		h.set(domain.ChaosConfig{}, domain.ChaosConfig{})
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.view())
+}
+
+func (h *faultsHandler) set(ioCfg, nsCfg domain.ChaosConfig) {
+
+	if h.io != nil {
+		h.io.SetChaos(ioCfg)
+	}
+	if h.nsenter != nil {
+		h.nsenter.SetChaos(nsCfg)
+	}
+
+	logrus.Warnf("Injected faults set to: host I/O %+v, nsenter %+v", ioCfg, nsCfg)
+}
+
+func (h *faultsHandler) view() faultsView {
+
+	var v faultsView
+
+	if h.io != nil {
+		v.IO = newChaosView(h.io.Chaos())
+	}
+	if h.nsenter != nil {
+		v.Nsenter = newChaosView(h.nsenter.Chaos())
+	}
+
+	return v
+}
+
+// Flags of the 'debug faults' command.
+var faultFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "target",
+		Value: "all",
+		Usage: "service the faults apply to: io, nsenter or all",
+	},
+	cli.Float64Flag{
+		Name:  "error-rate",
+		Usage: "fraction of operations failed with EIO",
+	},
+	cli.Float64Flag{
+		Name:  "delay-rate",
+		Usage: "fraction of operations delayed",
+	},
+	cli.DurationFlag{
+		Name:  "delay",
+		Usage: "delay of the delayed operations",
+	},
+	cli.Float64Flag{
+		Name:  "crash-rate",
+		Usage: "fraction of nsenter processes killed mid-request",
+	},
+	cli.BoolFlag{
+		Name:  "clear",
+		Usage: "stop injecting faults",
+	},
+}
+
+//
+// Displays the faults injected into the running sysbox-fs instance (as per
+// the configuration's pprof-address), updating them first if requested.
+//
+func debugFaults(ctx *cli.Context) error {
+
+	cfg, err := loadValidConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !cfg.FaultInjection {
+		return cli.NewExitError("fault injection is not enabled", 1)
+	}
+
+	url := "http://" + cfg.PprofAddress + faultsEndpoint
+
+	v, err := requestFaults(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case ctx.Bool("clear"):
+		v, err = requestFaults(http.MethodDelete, url, nil)
+
+	case ctx.IsSet("error-rate") || ctx.IsSet("delay-rate") ||
+		ctx.IsSet("delay") || ctx.IsSet("crash-rate"):
+
+		var targets []*chaosView
+		switch ctx.String("target") {
+		case "io":
+			targets = []*chaosView{&v.IO}
+		case "nsenter":
+			targets = []*chaosView{&v.Nsenter}
+		case "all":
+			targets = []*chaosView{&v.IO, &v.Nsenter}
+		default:
+			return cli.NewExitError(
+				fmt.Sprintf("unknown fault target %q", ctx.String("target")), 1)
+		}
+
+		for _, t := range targets {
+			if ctx.IsSet("error-rate") {
+				t.ErrorRate = ctx.Float64("error-rate")
+			}
+			if ctx.IsSet("delay-rate") {
+				t.DelayRate = ctx.Float64("delay-rate")
+			}
+			if ctx.IsSet("delay") {
+				t.Delay = ctx.Duration("delay").String()
+			}
+			if ctx.IsSet("crash-rate") {
+				t.CrashRate = ctx.Float64("crash-rate")
+			}
+		}
+
+		v, err = requestFaults(http.MethodPut, url, v)
+	}
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(v)
+}
+
+// Issues a request to the fault-injection endpoint, and returns the injected
+// faults it reports.
+func requestFaults(method, url string, body *faultsView) (*faultsView, error) {
+
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return nil, cli.NewExitError(err.Error(), 1)
+		}
+	}
+
+	req, err := http.NewRequest(method, url, bytes.NewReader(data))
+	if err != nil {
+		return nil, cli.NewExitError(err.Error(), 1)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, cli.NewExitError(err.Error(), 1)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return nil, cli.NewExitError(
+			fmt.Sprintf("fault injection request failed: %s", bytes.TrimSpace(msg)), 1)
+	}
+
+	var v faultsView
+	if err := json.NewDecoder(resp.Body).Decode(&v); err != nil {
+		return nil, cli.NewExitError(err.Error(), 1)
+	}
+
+	return &v, nil
+}
@@ -0,0 +1,73 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nestybox/sysbox-fs/domain"
+)
+
+type fakeChaos struct {
+	cfg domain.ChaosConfig
+}
+
+func (f *fakeChaos) SetChaos(c domain.ChaosConfig) { f.cfg = c }
+func (f *fakeChaos) Chaos() domain.ChaosConfig     { return f.cfg }
+
+func Test_faultsHandler(t *testing.T) {
+
+	io := &fakeChaos{}
+	ns := &fakeChaos{}
+
+	srv := httptest.NewServer(&faultsHandler{io: io, nsenter: ns})
+	defer srv.Close()
+
+	v, err := requestFaults(http.MethodGet, srv.URL, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "0s", v.IO.Delay)
+
+	v.IO.ErrorRate = 0.1
+	v.Nsenter = chaosView{DelayRate: 0.5, Delay: "50ms", CrashRate: 0.01}
+
+	_, err = requestFaults(http.MethodPut, srv.URL, v)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.ChaosConfig{ErrorRate: 0.1}, io.cfg)
+	assert.Equal(t, domain.ChaosConfig{
+		DelayRate: 0.5,
+		Delay:     50 * time.Millisecond,
+		CrashRate: 0.01,
+	}, ns.cfg)
+
+	// Invalid settings are rejected.
+	v.IO.ErrorRate = 2
+	_, err = requestFaults(http.MethodPut, srv.URL, v)
+	assert.Error(t, err)
+	assert.Equal(t, domain.ChaosConfig{ErrorRate: 0.1}, io.cfg)
+
+	v, err = requestFaults(http.MethodDelete, srv.URL, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, faultsView{
+		IO:      chaosView{Delay: "0s"},
+		Nsenter: chaosView{Delay: "0s"},
+	}, *v)
+}
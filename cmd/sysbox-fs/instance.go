@@ -0,0 +1,210 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+//
+// Single-instance enforcement.
+//
+// Every sysbox-fs instance holds an exclusive flock() over a pidfile within
+// its mountpoint for as long as it runs, so a second instance serving the
+// same mountpoint is refused. As the kernel drops the lock when its owner
+// dies, the lock being available while sysbox-fs mounts are still present
+// under the mountpoint means that a previous instance died without cleaning
+// them up. Those stale mounts are only taken over (i.e. unmounted) upon
+// explicit request.
+//
+
+// Name of the pidfile within the mountpoint.
+const pidFileName = ".sysbox-fs.pid"
+
+// Source name of sysbox-fs' fuse mounts.
+const fuseFsName = "sysboxfs"
+
+//
+// Acquires the instance lock of the given mountpoint and records our pid in
+// it. If 'timeout' is not zero, the lock is retried for up to that long (e.g.
+// while a previous instance completes a live-upgrade). The returned file must
+// be kept open for as long as the lock is meant to be held.
+//
+func lockInstance(mountpoint string, timeout time.Duration) (*os.File, error) {
+
+	if err := os.MkdirAll(mountpoint, 0700); err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(mountpoint, pidFileName)
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(timeout)
+
+	for {
+		err = unix.Flock(int(f.Fd()), unix.LOCK_EX|unix.LOCK_NB)
+		if err != unix.EWOULDBLOCK || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	if err == unix.EWOULDBLOCK {
+		f.Close()
+		return nil, fmt.Errorf("mountpoint %s is already served by sysbox-fs (pid %s)",
+			mountpoint, readPid(path))
+	} else if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	if err := f.Truncate(0); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if _, err := f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func readPid(path string) string {
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil || len(strings.TrimSpace(string(data))) == 0 {
+		return "unknown"
+	}
+
+	return strings.TrimSpace(string(data))
+}
+
+//
+// Returns the sysbox-fs mounts present right under the given mountpoint. Must
+// be invoked with the instance lock held, as they are otherwise owned by the
+// running instance.
+//
+func staleMounts(mountpoint string) ([]string, error) {
+
+	f, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var mounts []string
+
+	// Format: id parent major:minor root mountpoint options [optional...] -
+	// fstype source super-options
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) < 5 {
+			continue
+		}
+
+		sep := -1
+		for i := 5; i < len(fields); i++ {
+			if fields[i] == "-" {
+				sep = i
+				break
+			}
+		}
+		if sep < 0 || sep+2 >= len(fields) {
+			continue
+		}
+
+		mp := unescapeMountPath(fields[4])
+		fstype, source := fields[sep+1], fields[sep+2]
+
+		if strings.HasPrefix(fstype, "fuse") && source == fuseFsName &&
+			filepath.Dir(mp) == filepath.Clean(mountpoint) {
+			mounts = append(mounts, mp)
+		}
+	}
+
+	return mounts, s.Err()
+}
+
+// Reverts the octal escaping of mountinfo paths (e.g. "\040" for spaces).
+func unescapeMountPath(path string) string {
+
+	if !strings.Contains(path, `\`) {
+		return path
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		if path[i] == '\\' && i+3 < len(path) {
+			if c, err := strconv.ParseUint(path[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(c))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(path[i])
+	}
+
+	return b.String()
+}
+
+//
+// Verifies that no sysbox-fs mounts from a dead instance are left behind
+// under the mountpoint. If 'takeover' is set, these are lazily unmounted so
+// that the fuse-servers of the restored containers can be mounted afresh.
+//
+func checkStaleMounts(mountpoint string, takeover bool) error {
+
+	mounts, err := staleMounts(mountpoint)
+	if err != nil {
+		return err
+	}
+
+	if len(mounts) == 0 {
+		return nil
+	}
+
+	if !takeover {
+		return fmt.Errorf("found %d stale sysbox-fs mounts under %s left by a "+
+			"previous instance; use --takeover to clean them up",
+			len(mounts), mountpoint)
+	}
+
+	for _, mp := range mounts {
+		logrus.Warnf("Taking over stale sysbox-fs mount %s", mp)
+		if err := unix.Unmount(mp, unix.MNT_DETACH); err != nil {
+			return fmt.Errorf("failed to unmount %s: %v", mp, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_lockInstance(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "sysbox-fs-lock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	mp := filepath.Join(dir, "mnt")
+
+	f, err := lockInstance(mp, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, strconv.Itoa(os.Getpid()), readPid(filepath.Join(mp, pidFileName)))
+
+	// A second instance is refused while the lock is held.
+	_, err = lockInstance(mp, 0)
+	if assert.Error(t, err) {
+		assert.True(t, strings.Contains(err.Error(), strconv.Itoa(os.Getpid())))
+	}
+
+	// Once released, the lock can be acquired again.
+	f.Close()
+
+	f, err = lockInstance(mp, 0)
+	if assert.NoError(t, err) {
+		f.Close()
+	}
+}
+
+func Test_unescapeMountPath(t *testing.T) {
+
+	assert.Equal(t, "/var/lib/sysboxfs", unescapeMountPath("/var/lib/sysboxfs"))
+	assert.Equal(t, "/mnt/sysbox fs", unescapeMountPath(`/mnt/sysbox\040fs`))
+	assert.Equal(t, `/mnt/a\b`, unescapeMountPath(`/mnt/a\134b`))
+}
@@ -20,25 +20,33 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"runtime"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/nestybox/sysbox-fs/audit"
+	"github.com/nestybox/sysbox-fs/crash"
 	"github.com/nestybox/sysbox-fs/domain"
 	"github.com/nestybox/sysbox-fs/fuse"
 	"github.com/nestybox/sysbox-fs/handler"
 	"github.com/nestybox/sysbox-fs/ipc"
+	"github.com/nestybox/sysbox-fs/logging"
 	"github.com/nestybox/sysbox-fs/nsenter"
 	"github.com/nestybox/sysbox-fs/process"
 	"github.com/nestybox/sysbox-fs/seccomp"
 	"github.com/nestybox/sysbox-fs/state"
 	"github.com/nestybox/sysbox-fs/sysio"
+	"github.com/nestybox/sysbox-fs/tracing"
 
 	"github.com/pkg/profile"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 )
 
 // TODO: Improve one-liner description.
@@ -60,12 +68,93 @@ var (
 	builtBy  string // build owner
 )
 
+// Log file destination, if any.
+var logFile *logging.File
+
+//
+// Log-reopen handler goroutine. Allows external tools to rotate sysbox-fs'
+// log file: once renamed, SIGUSR1 makes sysbox-fs move on to a new file.
+//
+func logReopenHandler(signalChan chan os.Signal) {
+
+	for range signalChan {
+		if logFile == nil {
+			continue
+		}
+		if err := logFile.Reopen(); err != nil {
+			logrus.Errorf("Unable to reopen log file: %v", err)
+			continue
+		}
+		logrus.Info("Log file reopened")
+	}
+}
+
+//
+// Shuts sysbox-fs down upon panic recovery, making sure that the fuse mounts
+// are torn down so that sys containers don't hang on them.
+//
+func crashHandler(fss domain.FuseServerServiceIface) func(string, interface{}) {
+
+	return func(where string, cause interface{}) {
+		sdNotify(fmt.Sprintf("STATUS=degraded: panic in %s: %v", where, cause))
+
+		fss.AbortFuseService()
+
+		logrus.Error("Exiting due to unrecoverable error.")
+		os.Exit(1)
+	}
+}
+
+//
+// Config-reload handler goroutine. Upon SIGHUP, the config is re-read and its
+// log-levels and handler policies are applied. Other settings only take
+// effect after a restart; handler default values only apply to the containers
+// registered after the reload.
+//
+func reloadHandler(
+	signalChan chan os.Signal,
+	ctx *cli.Context,
+	hds domain.HandlerServiceIface,
+	defaults map[string]bool) {
+
+	for range signalChan {
+		logrus.Info("Reloading configuration ...")
+
+		cfg, err := loadConfig(ctx)
+		if err == nil {
+			err = cfg.validate(handler.DefaultHandlers)
+		}
+		if err != nil {
+			logrus.Errorf("Configuration reload failed: %v", err)
+			continue
+		}
+
+		if err := applyLogLevels(cfg); err != nil {
+			logrus.Errorf("Configuration reload failed: %v", err)
+			continue
+		}
+
+		sysio.SetHostCacheTTL(cfg.HostCacheTTL)
+
+		if err := cfg.applyHandlerPolicies(handler.DefaultHandlers, defaults); err != nil {
+			logrus.Errorf("Configuration reload failed: %v", err)
+			continue
+		}
+		hds.SyncHandlers(handler.DefaultHandlers)
+		hds.SetDefaultValues(cfg.Handlers.Defaults)
+
+		logrus.Info("Configuration reloaded")
+	}
+}
+
 //
 // sysbox-fs exit handler goroutine.
 //
 func exitHandler(
 	signalChan chan os.Signal,
+	css domain.ContainerStateServiceIface,
 	fss domain.FuseServerServiceIface,
+	hds domain.HandlerServiceIface,
 	profile interface{ Stop() }) {
 
 	var printStack = false
@@ -96,9 +185,22 @@ func exitHandler(
 		logrus.Warnf("\n\n%s\n", string(stacktrace[:length]))
 	}
 
+	sdNotify("STOPPING=1")
+
 	// Destroy fuse-service and inner fuse-servers.
 	fss.DestroyFuseService()
 
+	// Let handlers flush and release their state once no more requests can
+	// reach them.
+	hds.Stop()
+
+	// Stop the container-state background tasks, flushing any pending
+	// checkpoint.
+	css.Stop()
+
+	// Flush the traces pending export.
+	tracing.SetExporter(nil, 0)
+
 	// Stop cpu/mem profiling tasks.
 	if profile != nil {
 		profile.Stop()
@@ -116,8 +218,8 @@ func runProfiler(ctx *cli.Context) (interface{ Stop() }, error) {
 
 	var prof interface{ Stop() }
 
-	cpuProfOn := ctx.Bool("cpu-profiling")
-	memProfOn := ctx.Bool("memory-profiling")
+	cpuProfOn := ctx.GlobalBool("cpu-profiling")
+	memProfOn := ctx.GlobalBool("memory-profiling")
 
 	// Cpu and Memory profiling options seem to be mutually exclused in pprof.
 	if cpuProfOn && memProfOn {
@@ -152,6 +254,26 @@ func runProfiler(ctx *cli.Context) (interface{ Stop() }, error) {
 	return prof, nil
 }
 
+// Parses a comma-separated list of uids.
+func parseUidList(s string) ([]uint32, error) {
+
+	var uids []uint32
+
+	for _, f := range strings.Split(s, ",") {
+		if f = strings.TrimSpace(f); f == "" {
+			continue
+		}
+
+		uid, err := strconv.ParseUint(f, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid uid %q", f)
+		}
+		uids = append(uids, uint32(uid))
+	}
+
+	return uids, nil
+}
+
 //
 // sysbox-fs main function
 //
@@ -163,26 +285,148 @@ func main() {
 	app.Version = version
 
 	app.Flags = []cli.Flag{
+		cli.StringFlag{
+			Name:  "config",
+			Value: defaultConfigFile,
+			Usage: "config file location (command-line flags take precedence over its settings)",
+		},
 		cli.StringFlag{
 			Name:  "mountpoint",
 			Value: "/var/lib/sysboxfs",
 			Usage: "mount-point location",
 		},
 		cli.StringFlag{
-			Name:  "log",
+			Name:  "state-dir",
+			Value: "/var/lib/sysbox-fs",
+			Usage: "container-state checkpoint location (empty string disables persistence)",
+		},
+		cli.DurationFlag{
+			Name:  "reaper-interval",
+			Value: time.Minute,
+			Usage: "stale-container detection interval (zero disables it)",
+		},
+		cli.StringFlag{
+			Name:  "ipc-socket",
+			Value: "",
+			Usage: "ipc unix-socket location (sysbox-ipc's default if empty)",
+		},
+		cli.StringFlag{
+			Name:  "ipc-allowed-uids",
+			Value: "0",
+			Usage: "comma-separated list of uids allowed to connect to the ipc socket",
+		},
+		cli.StringFlag{
+			Name:  "ipc-allowed-exes",
+			Value: "",
+			Usage: "comma-separated list of executables (paths) allowed to connect to the ipc socket (any if empty)",
+		},
+		cli.IntFlag{
+			Name:  "nsenter-max-concurrency",
+			Value: 64,
+			Usage: "maximum number of concurrent nsenter requests (zero means no limit)",
+		},
+		cli.IntFlag{
+			Name:  "nsenter-max-per-container",
+			Value: 8,
+			Usage: "maximum number of concurrent nsenter requests per container (zero means no limit)",
+		},
+		cli.DurationFlag{
+			Name:  "dentry-cache-timeout",
+			Value: 0,
+			Usage: "expiration of the kernel's dentry cache for sysbox-fs nodes (zero means no expiration)",
+		},
+		cli.DurationFlag{
+			Name:  "host-cache-ttl",
+			Value: 5 * time.Second,
+			Usage: "expiration of the cached host sysctl values (zero disables the cache)",
+		},
+		cli.StringFlag{
+			Name:  "log-file",
 			Value: "/dev/stdout",
 			Usage: "log file path",
 		},
+		cli.StringFlag{
+			Name:   "log",
+			Usage:  "log file path (deprecated, use log-file)",
+			Hidden: true,
+		},
 		cli.StringFlag{
 			Name:  "log-level",
 			Value: "info",
 			Usage: "log categories to include (debug, info, warning, error, fatal)",
 		},
+		cli.Int64Flag{
+			Name:  "log-max-size",
+			Value: 0,
+			Usage: "log file size (in MiB) triggering its rotation (zero disables rotation; SIGUSR1 reopens the file)",
+		},
+		cli.IntFlag{
+			Name:  "log-max-backups",
+			Value: 3,
+			Usage: "number of rotated log files to keep",
+		},
+		cli.DurationFlag{
+			Name:  "log-slow-threshold",
+			Value: 0,
+			Usage: "log handler executions and nsenter requests lasting longer than this (disabled if zero)",
+		},
+		cli.StringFlag{
+			Name:  "log-format",
+			Value: "text",
+			Usage: "log output format (text, json)",
+		},
+		cli.StringFlag{
+			Name:  "log-subsystem-levels",
+			Value: "",
+			Usage: "comma-separated list of per-subsystem log-levels (e.g. \"fuse=debug,ipc=error\"); subsystems: fuse, handlers, nsenter, ipc, tracing",
+		},
 		cli.BoolFlag{
 			Name:   "ignore-handler-errors",
 			Usage:  "ignore errors during procfs / sysfs node interactions (testing purposes)",
 			Hidden: true,
 		},
+		cli.BoolFlag{
+			Name:  "takeover",
+			Usage: "unmount the stale sysbox-fs mounts left behind by a dead instance",
+		},
+		cli.StringFlag{
+			Name:   "upgrade-from",
+			Usage:  "unix socket to obtain fuse connections and container-state from (live-upgrades)",
+			Hidden: true,
+		},
+		cli.StringFlag{
+			Name:  "audit-log",
+			Value: "",
+			Usage: "file recording every write served on behalf of sys containers (disabled if empty)",
+		},
+		cli.BoolFlag{
+			Name:  "tracing",
+			Usage: "trace fuse requests through the handler and nsenter layers (spans are logged unless a tracing-file is set)",
+		},
+		cli.DurationFlag{
+			Name:  "tracing-threshold",
+			Value: 0,
+			Usage: "only export the traces of requests lasting at least this long",
+		},
+		cli.StringFlag{
+			Name:  "tracing-file",
+			Value: "",
+			Usage: "file to append the spans to, in OpenTelemetry's JSON encoding (spans are logged if empty)",
+		},
+		cli.StringFlag{
+			Name:  "pprof-address",
+			Value: "",
+			Usage: "loopback address to serve pprof endpoints at (e.g. \"localhost:6060\"); disabled if empty",
+		},
+		cli.BoolFlag{
+			Name:  "fault-injection",
+			Usage: "allow faults to be injected at runtime into host I/O and nsenter requests (see 'debug faults'); requires pprof-address, testing only",
+		},
+		cli.DurationFlag{
+			Name:  "soak-interval",
+			Value: 0,
+			Usage: "interval at which the resources held by sysbox-fs (fuse nodes and handles, container state, goroutines, heap) are logged, to detect leaks (zero disables it)",
+		},
 		cli.BoolFlag{
 			Name:   "cpu-profiling",
 			Usage:  "enable cpu-profiling data collection",
@@ -205,161 +449,380 @@ func main() {
 			c.App.Version, commitId, builtAt, builtBy)
 	}
 
-	// Nsenter command to allow 'rexec' functionality.
 	app.Commands = []cli.Command{
 		{
-			Name:  "nsenter",
-			Usage: "Execute action within container namespaces",
-			Action: func(c *cli.Context) error {
-				// nsenter errors are passed back to sysbox-fs via a pipe
+			Name:   "serve",
+			Usage:  "Run the sysbox-fs daemon (default command)",
+			Action: serve,
+		},
+		{
+			Name:   "validate-config",
+			Usage:  "Verify the configuration (config file and flags) and exit",
+			Action: validateConfig,
+		},
+		{
+			Name:  "version",
+			Usage: "Print version information",
+			Action: func(ctx *cli.Context) error {
+				cli.VersionPrinter(ctx)
+				return nil
+			},
+		},
+		{
+			Name:  "debug",
+			Usage: "Display sysbox-fs internal information",
+			Subcommands: []cli.Command{
+				{
+					Name:   "config",
+					Usage:  "Print the effective configuration",
+					Action: debugConfig,
+				},
+				{
+					Name:   "handlers",
+					Usage:  "List the emulation handlers",
+					Action: debugHandlers,
+				},
+				{
+					Name:   "faults",
+					Usage:  "Display or set the faults injected into a running sysbox-fs (requires fault-injection)",
+					Flags:  faultFlags,
+					Action: debugFaults,
+				},
+			},
+		},
+		// Nsenter command to allow 'rexec' functionality.
+		{
+			Name:   "nsenter",
+			Usage:  "Execute action within container namespaces",
+			Hidden: true,
+			Action: func(ctx *cli.Context) error {
+				// No config loading nor log-file setup here: nsenter children
+				// join the container's mount namespace, so any config file
+				// found there is container-controlled, and reopening the
+				// log-file would interfere with its rotation. nsenter errors
+				// are passed back to sysbox-fs via a pipe.
 				nsenter.Init()
 				return nil
 			},
 		},
 	}
 
-	// Define 'debug' and 'log' settings.
-	app.Before = func(ctx *cli.Context) error {
+	// Operators used to launch sysbox-fs with no command.
+	app.Action = serve
 
-		// Create/set the log-file destination.
-		if path := ctx.GlobalString("log"); path != "" {
-			f, err := os.OpenFile(
-				path,
-				os.O_CREATE|os.O_WRONLY|os.O_APPEND|os.O_SYNC,
-				0666,
-			)
-			if err != nil {
-				logrus.Fatalf(
-					"Error opening log file %v: %v. Exiting ...",
-					path, err,
-				)
-				return err
-			}
+	if err := app.Run(os.Args); err != nil {
+		logrus.Panic(err)
+	}
+}
 
-			// Set a proper logging formatter.
-			logrus.SetFormatter(&logrus.TextFormatter{
-				ForceColors:     true,
-				TimestampFormat: "2006-01-02 15:04:05",
-				FullTimestamp:   true,
-			})
-			logrus.SetOutput(f)
-			log.SetOutput(f)
-		}
+//
+// Loads the configuration and sets up logging accordingly. Utilized by the
+// commands that run sysbox-fs' engine (but not by the nsenter one, see above).
+//
+func setup(ctx *cli.Context) (*config, error) {
 
-		// Set desired log-level.
-		if logLevel := ctx.GlobalString("log-level"); logLevel != "" {
-			switch logLevel {
-			case "debug":
-				// Following instruction is to have Bazil's fuze-lib logs being
-				// included into sysbox-fs' log stream.
-				flag.Set("fuse.debug", "true")
-				logrus.SetLevel(logrus.DebugLevel)
-			case "info":
-				logrus.SetLevel(logrus.InfoLevel)
-			case "warning":
-				logrus.SetLevel(logrus.WarnLevel)
-			case "error":
-				logrus.SetLevel(logrus.ErrorLevel)
-			case "fatal":
-				logrus.SetLevel(logrus.FatalLevel)
-			default:
-				logrus.Fatalf(
-					"log-level option '%v' not recognized. Exiting ...",
-					logLevel,
-				)
-			}
-		} else {
-			// Set 'info' as our default log-level.
-			logrus.SetLevel(logrus.InfoLevel)
+	cfg, err := loadConfig(ctx)
+	if err == nil {
+		err = cfg.validate(handler.DefaultHandlers)
+	}
+	if err != nil {
+		logrus.Fatalf("Error loading configuration: %v. Exiting ...", err)
+		return nil, err
+	}
+
+	if err := setupLogging(cfg); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// Defines the 'debug' and 'log' settings.
+func setupLogging(cfg *config) error {
+
+	// Create/set the log-file destination.
+	if path := cfg.Log.File; path != "" {
+		f, err := logging.OpenFile(path, cfg.Log.MaxSize<<20, cfg.Log.MaxBackups)
+		if err != nil {
+			logrus.Fatalf(
+				"Error opening log file %v: %v. Exiting ...",
+				path, err,
+			)
+			return err
 		}
 
-		return nil
+		logrus.SetOutput(f)
+		log.SetOutput(f)
+		logFile = f
 	}
 
-	// sysbox-fs main-loop execution.
-	app.Action = func(ctx *cli.Context) error {
+	// Set a proper logging formatter.
+	if cfg.Log.Format == "json" {
+		logrus.SetFormatter(&logrus.JSONFormatter{
+			TimestampFormat: "2006-01-02 15:04:05",
+		})
+	} else if cfg.Log.File != "" {
+		logrus.SetFormatter(&logrus.TextFormatter{
+			ForceColors:     true,
+			TimestampFormat: "2006-01-02 15:04:05",
+			FullTimestamp:   true,
+		})
+	}
 
-		// Construct sysbox-fs services.
-		var nsenterService = nsenter.NewNSenterService()
-		var ioService = sysio.NewIOService(domain.IOOsFileService)
-		var processService = process.NewProcessService()
-		var handlerService = handler.NewHandlerService()
-		var fuseServerService = fuse.NewFuseServerService()
-		var containerStateService = state.NewContainerStateService()
-		var syscallMonitorService = seccomp.NewSyscallMonitorService()
-		var ipcService = ipc.NewIpcService()
+	return applyLogLevels(cfg)
+}
 
-		// Setup sysbox-fs services.
-		processService.Setup(ioService)
+// Sets the log-levels (and slow-op threshold) defined in the config; 'info' is
+// our default level. Level names are expected to be already validated.
+func applyLogLevels(cfg *config) error {
 
-		nsenterService.Setup(processService)
+	base := logrus.InfoLevel
+	if cfg.Log.Level != "" {
+		base, _ = logrus.ParseLevel(cfg.Log.Level)
+	}
 
-		handlerService.Setup(
-			handler.DefaultHandlers,
-			ctx.Bool("ignore-handler-errors"),
-			containerStateService,
-			nsenterService,
-			processService,
-			ioService,
-		)
+	overrides := make(map[string]logrus.Level)
+	for sub, name := range cfg.Log.Levels {
+		overrides[sub], _ = logrus.ParseLevel(name)
+	}
 
-		fuseServerService.Setup(
-			ctx.GlobalString("mountpoint"),
-			containerStateService,
-			ioService,
-			handlerService,
-		)
+	if err := logging.SetLevels(base, overrides); err != nil {
+		return err
+	}
 
-		containerStateService.Setup(
-			fuseServerService,
-			processService,
-			ioService,
-		)
+	logging.SetSlowThreshold(cfg.Log.SlowThreshold)
 
-		syscallMonitorService.Setup(
-			nsenterService,
-			containerStateService,
-			handlerService,
-			processService,
-		)
+	// Following instruction is to have Bazil's fuze-lib logs being included
+	// into sysbox-fs' log stream.
+	if base == logrus.DebugLevel || overrides[logging.Fuse] == logrus.DebugLevel {
+		flag.Set("fuse.debug", "true")
+	}
 
-		ipcService.Setup(
-			containerStateService,
-			processService,
-			ioService,
-		)
+	return nil
+}
 
-		// If requested, launch cpu/mem profiling collection.
-		profile, err := runProfiler(ctx)
+//
+// sysbox-fs main-loop execution.
+//
+func serve(ctx *cli.Context) error {
+
+	cfg, err := setup(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Construct sysbox-fs services.
+	var nsenterService = nsenter.NewNSenterService()
+	var ioService domain.IOServiceIface
+	if cfg.FaultInjection {
+		ioService = sysio.NewIOService(domain.IOChaosFileService)
+	} else {
+		ioService = sysio.NewIOService(domain.IOOsFileService)
+	}
+	var processService = process.NewProcessService()
+	var handlerService = handler.NewHandlerService()
+	var fuseServerService = fuse.NewFuseServerService()
+	var containerStateService = state.NewContainerStateService()
+	var syscallMonitorService = seccomp.NewSyscallMonitorService()
+	var ipcService = ipc.NewIpcService()
+
+	// Setup sysbox-fs services.
+	processService.Setup(ioService)
+
+	nsenterService.Setup(processService)
+	nsenterService.SetConcurrencyLimits(
+		cfg.Nsenter.MaxConcurrency,
+		cfg.Nsenter.MaxPerContainer,
+	)
+
+	// Release the nsenter agents of the containers going away.
+	if sub, ok := nsenterService.(domain.ContainerEventSubscriberIface); ok {
+		containerStateService.Subscribe(sub.HandleContainerEvent)
+	}
+
+	// Forward the containers' restart requests to sysbox-mgr.
+	if sub, ok := ipcService.(domain.ContainerEventSubscriberIface); ok {
+		containerStateService.Subscribe(sub.HandleContainerEvent)
+	}
+
+	// Keep track of the handlers' default state for config reloads.
+	handlerDefaults := make(map[string]bool)
+	for _, h := range handler.DefaultHandlers {
+		handlerDefaults[h.GetName()] = h.GetEnabled()
+	}
+
+	if err := cfg.applyHandlerPolicies(handler.DefaultHandlers, nil); err != nil {
+		logrus.Fatalf("Invalid handlers configuration: %v", err)
+	}
+
+	handlerService.Setup(
+		handler.DefaultHandlers,
+		cfg.Handlers.IgnoreErrors,
+		containerStateService,
+		nsenterService,
+		processService,
+		ioService,
+	)
+	handlerService.SetDefaultValues(cfg.Handlers.Defaults)
+
+	if err := handlerService.Start(); err != nil {
+		logrus.Fatal(err)
+	}
+
+	if cfg.AuditLog != "" {
+		auditLog, err := audit.Open(cfg.AuditLog)
 		if err != nil {
-			logrus.Fatal(err)
+			logrus.Fatalf("Unable to open audit log: %v", err)
+		}
+		handlerService.Use(handler.AuditMiddleware(auditLog))
+	}
+
+	if cfg.Tracing.Enabled {
+		var exporter sdktrace.SpanExporter = &tracing.LogExporter{
+			Logger: logging.Subsystem(logging.Tracing),
+		}
+		if cfg.Tracing.File != "" {
+			exporter, err = tracing.NewFileExporter(cfg.Tracing.File)
+			if err != nil {
+				logrus.Fatalf("Unable to open tracing file: %v", err)
+			}
 		}
+		tracing.SetExporter(exporter, cfg.Tracing.Threshold)
+	}
+
+	sysio.SetHostCacheTTL(cfg.HostCacheTTL)
+
+	if cfg.Fuse.DentryCacheTimeout > 0 {
+		fuse.DentryCacheTimeout = int64(cfg.Fuse.DentryCacheTimeout)
+	}
+
+	fuseServerService.Setup(
+		cfg.Mountpoint,
+		containerStateService,
+		ioService,
+		handlerService,
+	)
+
+	containerStateService.Setup(
+		fuseServerService,
+		processService,
+		ioService,
+		cfg.StateDir,
+	)
+
+	syscallMonitorService.Setup(
+		nsenterService,
+		containerStateService,
+		handlerService,
+		processService,
+	)
+
+	ipcService.Setup(
+		containerStateService,
+		processService,
+		ioService,
+		fuseServerService,
+		handlerService,
+	)
+
+	ipcService.SetAuthorizedPeers(cfg.Ipc.AllowedUids, cfg.Ipc.AllowedExes)
+	ipcService.SetSocketPath(cfg.Ipc.Socket)
+
+	// If requested, launch cpu/mem profiling collection.
+	profile, err := runProfiler(ctx)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+
+	// Expose pprof endpoints (and the fault-injection one) if requested.
+	if cfg.PprofAddress != "" {
+		var faults http.Handler
+		if cfg.FaultInjection {
+			logrus.Warn("Fault injection enabled: not meant for production use")
+			faults = newFaultsHandler(ioService, nsenterService)
+		}
+		if err := startPprof(cfg.PprofAddress, faults); err != nil {
+			logrus.Fatalf("Unable to serve pprof endpoints: %v", err)
+		}
+	}
 
-		// Launch exit handler (performs proper cleanup of sysbox-fs upon
-		// receiving termination signals).
-		var exitChan = make(chan os.Signal, 1)
-		signal.Notify(
-			exitChan,
-			syscall.SIGHUP,
-			syscall.SIGINT,
-			syscall.SIGTERM,
-			syscall.SIGSEGV,
-			syscall.SIGQUIT)
-		go exitHandler(exitChan, fuseServerService, profile)
-
-		// TODO: Consider adding sync.Workgroups to ensure that all goroutines
-		// are done with their in-fly tasks before exit()ing.
-
-		logrus.Info("Initiating sysbox-fs engine ...")
-
-		if err := ipcService.Init(); err != nil {
-			logrus.Panic(err)
+	// Tear the fuse mounts down should a panic ever occur.
+	crash.SetHandler(crashHandler(fuseServerService))
+
+	// Launch exit handler (performs proper cleanup of sysbox-fs upon
+	// receiving termination signals).
+	var exitChan = make(chan os.Signal, 1)
+	signal.Notify(
+		exitChan,
+		syscall.SIGINT,
+		syscall.SIGTERM,
+		syscall.SIGSEGV,
+		syscall.SIGQUIT)
+	go exitHandler(exitChan, containerStateService, fuseServerService,
+		handlerService, profile)
+
+	// Launch config-reload handler.
+	var reloadChan = make(chan os.Signal, 1)
+	signal.Notify(reloadChan, syscall.SIGHUP)
+	go reloadHandler(reloadChan, ctx, handlerService, handlerDefaults)
+
+	// Launch live-upgrade handler.
+	var upgradeChan = make(chan os.Signal, 1)
+	signal.Notify(upgradeChan, syscall.SIGUSR2)
+	go upgradeHandler(upgradeChan, containerStateService, fuseServerService)
+
+	// Launch log-reopen handler.
+	var logChan = make(chan os.Signal, 1)
+	signal.Notify(logChan, syscall.SIGUSR1)
+	go logReopenHandler(logChan)
+
+	// TODO: Consider adding sync.Workgroups to ensure that all goroutines
+	// are done with their in-fly tasks before exit()ing.
+
+	logrus.Info("Initiating sysbox-fs engine ...")
+
+	// Recover the state of the containers launched prior to sysbox-fs
+	// restart / upgrade (if any). Notice that during live-upgrades the
+	// instance lock is only released once the previous instance exits.
+	var lockFile *os.File
+	if sock := ctx.GlobalString("upgrade-from"); sock != "" {
+		if err := upgradeRecv(sock, containerStateService, fuseServerService); err != nil {
+			logrus.Fatalf("Live-upgrade failed: %v", err)
 		}
+		if lockFile, err = lockInstance(cfg.Mountpoint, upgradeTimeout); err != nil {
+			logrus.Fatalf("Unable to lock sysbox-fs instance: %v", err)
+		}
+	} else {
+		if lockFile, err = lockInstance(cfg.Mountpoint, 0); err != nil {
+			logrus.Fatalf("Unable to lock sysbox-fs instance: %v", err)
+		}
+		if err := checkStaleMounts(cfg.Mountpoint, ctx.GlobalBool("takeover")); err != nil {
+			logrus.Fatal(err)
+		}
+		if err := containerStateService.ContainerDBRestore(); err != nil {
+			logrus.Warnf("Unable to restore container-state: %v", err)
+		}
+	}
+	defer lockFile.Close()
 
-		return nil
+	// Launch stale-container reaper.
+	containerStateService.ReaperStart(cfg.ReaperInterval)
+
+	// Launch soak accounting (leak detection) if requested.
+	if cfg.SoakInterval > 0 {
+		startSoak(cfg.SoakInterval, containerStateService, fuseServerService)
 	}
 
-	if err := app.Run(os.Args); err != nil {
+	// Let systemd know when we are ready to serve requests, and keep its
+	// watchdog (if any) fed while the fuse servers remain responsive.
+	go readyNotifier(ipcService.SocketPath(), time.Minute)
+	if interval := sdWatchdogInterval(); interval > 0 {
+		go watchdogHandler(fuseServerService, interval)
+	}
+
+	if err := ipcService.Init(); err != nil {
 		logrus.Panic(err)
 	}
+
+	return nil
 }
@@ -0,0 +1,96 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/nestybox/sysbox-fs/crash"
+)
+
+// Average time spent blocked per sampled event in block profiles.
+const pprofBlockRate = time.Millisecond
+
+//
+// Verifies that the pprof endpoints are only exposed on a loopback address,
+// as they leak sensitive details of the daemon (e.g. its command-line).
+//
+func checkPprofAddr(addr string) error {
+
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid pprof address %q: %v", addr, err)
+	}
+
+	if host == "localhost" {
+		return nil
+	}
+
+	if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+		return fmt.Errorf("pprof address %q is not a loopback one", addr)
+	}
+
+	return nil
+}
+
+//
+// Serves the net/http/pprof endpoints (cpu, heap, goroutine, block profiles,
+// etc) under /debug/pprof/ at the given address, along with the
+// fault-injection endpoint (/debug/faults) if a handler is given for it.
+//
+func startPprof(addr string, faults http.Handler) error {
+
+	if err := checkPprofAddr(addr); err != nil {
+		return err
+	}
+
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	// Block profiles are empty unless a sampling rate is set.
+	runtime.SetBlockProfileRate(int(pprofBlockRate))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	if faults != nil {
+		mux.Handle(faultsEndpoint, faults)
+	}
+
+	crash.Go("pprof server", func() {
+		if err := http.Serve(l, mux); err != nil {
+			logrus.Errorf("pprof server failed: %v", err)
+		}
+	})
+
+	logrus.Infof("Serving pprof endpoints at http://%s/debug/pprof/", l.Addr())
+
+	return nil
+}
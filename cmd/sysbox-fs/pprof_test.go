@@ -0,0 +1,34 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_checkPprofAddr(t *testing.T) {
+
+	assert.NoError(t, checkPprofAddr("localhost:6060"))
+	assert.NoError(t, checkPprofAddr("127.0.0.1:6060"))
+	assert.NoError(t, checkPprofAddr("[::1]:6060"))
+	assert.Error(t, checkPprofAddr("0.0.0.0:6060"))
+	assert.Error(t, checkPprofAddr(":6060"))
+	assert.Error(t, checkPprofAddr("10.0.0.1:6060"))
+	assert.Error(t, checkPprofAddr("localhost"))
+}
@@ -0,0 +1,246 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/nestybox/sysbox-fs/crash"
+	"github.com/nestybox/sysbox-fs/domain"
+)
+
+//
+// Soak accounting. The resources held on behalf of the sys containers (fuse
+// nodeDB entries and open handles, data-store entries), along with sysbox-fs'
+// goroutines and heap, are sampled periodically and logged as deltas from the
+// previous sample. Meant to catch slow leaks (e.g. nodeDB growth on hosts
+// with high container churn) during soak tests, or in the field.
+//
+
+// Number of containers whose growth is itemized in each interval.
+const soakTopGrowers = 5
+
+// Consecutive intervals of nodeDB growth after which a warning is logged.
+const soakGrowthWarn = 6
+
+// Resources held on behalf of a sys container.
+type soakUsage struct {
+	nodes   int
+	handles int
+	data    int
+}
+
+type soakSample struct {
+	containers  int
+	goroutines  int
+	heapAlloc   uint64
+	heapObjects uint64
+	total       soakUsage
+	cntrs       map[string]soakUsage
+	orphans     map[string]bool // fuse-servers of unregistered containers
+}
+
+// Notice that sampling the heap briefly stops the world.
+func takeSoakSample(
+	css domain.ContainerStateServiceIface,
+	fss domain.FuseServerServiceIface) *soakSample {
+
+	s := &soakSample{
+		cntrs:   make(map[string]soakUsage),
+		orphans: make(map[string]bool),
+	}
+
+	for _, cntr := range css.ContainerList() {
+		s.cntrs[cntr.ID()] = soakUsage{data: cntr.DataSize()}
+	}
+	s.containers = len(s.cntrs)
+
+	for _, fu := range fss.Usage() {
+		u, ok := s.cntrs[fu.ContainerID]
+		if !ok {
+			s.orphans[fu.ContainerID] = true
+		}
+		u.nodes = fu.Nodes
+		u.handles = fu.Handles
+		s.cntrs[fu.ContainerID] = u
+	}
+
+	for _, u := range s.cntrs {
+		s.total.nodes += u.nodes
+		s.total.handles += u.handles
+		s.total.data += u.data
+	}
+
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+
+	s.goroutines = runtime.NumGoroutine()
+	s.heapAlloc = ms.HeapAlloc
+	s.heapObjects = ms.HeapObjects
+
+	return s
+}
+
+// Counter reported by the soak accounting, along with its previous value.
+type soakCounter struct {
+	name string
+	cur  int64
+	old  int64
+}
+
+// Formats the given counters, along with their deltas if requested.
+func formatSoak(deltas bool, counters ...soakCounter) string {
+
+	parts := make([]string, 0, len(counters))
+
+	for _, c := range counters {
+		if deltas {
+			parts = append(parts, fmt.Sprintf("%s %d (%+d)", c.name, c.cur, c.cur-c.old))
+		} else {
+			parts = append(parts, fmt.Sprintf("%s %d", c.name, c.cur))
+		}
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+type soakAccountant struct {
+	css    domain.ContainerStateServiceIface
+	fss    domain.FuseServerServiceIface
+	logger logrus.FieldLogger
+	prev   *soakSample
+	growth int // consecutive intervals of nodeDB growth
+	grown  int // nodes gained throughout them
+}
+
+// Samples the resources, and logs their evolution since the previous sample.
+func (a *soakAccountant) account() {
+
+	cur := takeSoakSample(a.css, a.fss)
+
+	prev := a.prev
+	a.prev = cur
+
+	old := prev
+	if old == nil {
+		old = &soakSample{}
+	}
+
+	a.logger.Infof("Soak accounting: %s",
+		formatSoak(prev != nil,
+			soakCounter{"containers", int64(cur.containers), int64(old.containers)},
+			soakCounter{"nodes", int64(cur.total.nodes), int64(old.total.nodes)},
+			soakCounter{"handles", int64(cur.total.handles), int64(old.total.handles)},
+			soakCounter{"data-entries", int64(cur.total.data), int64(old.total.data)},
+			soakCounter{"goroutines", int64(cur.goroutines), int64(old.goroutines)},
+			soakCounter{"heap-kb", int64(cur.heapAlloc / 1024), int64(old.heapAlloc / 1024)},
+			soakCounter{"heap-objects", int64(cur.heapObjects), int64(old.heapObjects)}))
+
+	// Fuse-servers are expected to go away along with their containers.
+	for id := range cur.orphans {
+		if !old.orphans[id] {
+			u := cur.cntrs[id]
+			a.logger.Warnf("Soak accounting: fuse-server of unregistered container %s "+
+				"holds %d nodes and %d handles", id, u.nodes, u.handles)
+		}
+	}
+
+	if prev == nil {
+		return
+	}
+
+	a.logGrowers(cur, prev)
+
+	if cur.total.nodes > prev.total.nodes {
+		a.growth++
+		a.grown += cur.total.nodes - prev.total.nodes
+		if a.growth%soakGrowthWarn == 0 {
+			a.logger.Warnf("Soak accounting: nodeDB grew for %d consecutive intervals "+
+				"(%+d nodes)", a.growth, a.grown)
+		}
+	} else {
+		a.growth = 0
+		a.grown = 0
+	}
+}
+
+// Itemizes the containers whose nodeDB grew the most since the previous
+// sample. Containers created in between aren't considered.
+func (a *soakAccountant) logGrowers(cur, prev *soakSample) {
+
+	type grower struct {
+		id    string
+		delta int
+	}
+
+	var growers []grower
+
+	for id, u := range cur.cntrs {
+		old, ok := prev.cntrs[id]
+		if !ok || u.nodes <= old.nodes {
+			continue
+		}
+		growers = append(growers, grower{id, u.nodes - old.nodes})
+	}
+
+	sort.Slice(growers, func(i, j int) bool {
+		if growers[i].delta != growers[j].delta {
+			return growers[i].delta > growers[j].delta
+		}
+		return growers[i].id < growers[j].id
+	})
+
+	if len(growers) > soakTopGrowers {
+		growers = growers[:soakTopGrowers]
+	}
+
+	for _, g := range growers {
+		u, old := cur.cntrs[g.id], prev.cntrs[g.id]
+
+		a.logger.Infof("Soak accounting: container %s %s", g.id,
+			formatSoak(true,
+				soakCounter{"nodes", int64(u.nodes), int64(old.nodes)},
+				soakCounter{"handles", int64(u.handles), int64(old.handles)},
+				soakCounter{"data-entries", int64(u.data), int64(old.data)}))
+	}
+}
+
+// Launches the soak accounting, sampling resources at the given interval.
+func startSoak(
+	interval time.Duration,
+	css domain.ContainerStateServiceIface,
+	fss domain.FuseServerServiceIface) {
+
+	a := &soakAccountant{
+		css:    css,
+		fss:    fss,
+		logger: logrus.StandardLogger(),
+	}
+
+	crash.Go("soak accounting", func() {
+		a.account()
+		for range time.Tick(interval) {
+			a.account()
+		}
+	})
+}
@@ -0,0 +1,125 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/mocks"
+)
+
+func newSoakAccountant(
+	css domain.ContainerStateServiceIface,
+	fss domain.FuseServerServiceIface) (*soakAccountant, *bytes.Buffer) {
+
+	var buf bytes.Buffer
+
+	logger := logrus.New()
+	logger.Out = &buf
+	logger.Formatter = &logrus.TextFormatter{DisableTimestamp: true}
+
+	return &soakAccountant{css: css, fss: fss, logger: logger}, &buf
+}
+
+func Test_soakAccountant_account(t *testing.T) {
+
+	c1 := &mocks.ContainerIface{}
+	c1.On("ID").Return("c1")
+	c1.On("DataSize").Return(2).Once()
+	c1.On("DataSize").Return(3)
+
+	c2 := &mocks.ContainerIface{}
+	c2.On("ID").Return("c2")
+	c2.On("DataSize").Return(0)
+
+	css := &mocks.ContainerStateServiceIface{}
+	css.On("ContainerList").Return([]domain.ContainerIface{c1, c2})
+
+	// c3's fuse-server outlived its container.
+	fss := &mocks.FuseServerServiceIface{}
+	fss.On("Usage").Return([]domain.FuseServerUsage{
+		{ContainerID: "c1", Nodes: 10, Handles: 1},
+		{ContainerID: "c2", Nodes: 5},
+		{ContainerID: "c3", Nodes: 3},
+	}).Once()
+	fss.On("Usage").Return([]domain.FuseServerUsage{
+		{ContainerID: "c1", Nodes: 25, Handles: 1},
+		{ContainerID: "c2", Nodes: 5},
+		{ContainerID: "c3", Nodes: 3},
+	})
+
+	a, buf := newSoakAccountant(css, fss)
+
+	// Baseline sample.
+	a.account()
+	out := buf.String()
+	assert.Contains(t, out,
+		"Soak accounting: containers 2, nodes 18, handles 1, data-entries 2, goroutines ")
+	assert.Contains(t, out,
+		"fuse-server of unregistered container c3 holds 3 nodes and 0 handles")
+
+	// Deltas are reported from then on, orphaned fuse-servers only once.
+	buf.Reset()
+	a.account()
+	out = buf.String()
+	assert.Contains(t, out,
+		"Soak accounting: containers 2 (+0), nodes 33 (+15), handles 1 (+0), data-entries 3 (+1), goroutines ")
+	assert.Contains(t, out,
+		"Soak accounting: container c1 nodes 25 (+15), handles 1 (+0), data-entries 3 (+1)")
+	assert.NotContains(t, out, "container c2 nodes")
+	assert.NotContains(t, out, "unregistered container c3")
+}
+
+func Test_soakAccountant_growth(t *testing.T) {
+
+	css := &mocks.ContainerStateServiceIface{}
+	css.On("ContainerList").Return([]domain.ContainerIface{})
+
+	nodes := 0
+	fss := &mocks.FuseServerServiceIface{}
+	fss.On("Usage").Return(func() []domain.FuseServerUsage {
+		nodes++
+		return []domain.FuseServerUsage{{ContainerID: "c1", Nodes: nodes}}
+	})
+
+	a, buf := newSoakAccountant(css, fss)
+
+	// Baseline plus one interval short of the warning.
+	for i := 0; i < soakGrowthWarn; i++ {
+		a.account()
+	}
+	assert.NotContains(t, buf.String(), "consecutive intervals")
+
+	a.account()
+	assert.Contains(t, buf.String(),
+		"nodeDB grew for 6 consecutive intervals (+6 nodes)")
+
+	// Growth streaks are broken by intervals without growth.
+	nodes--
+	a.account()
+	assert.Equal(t, 0, a.growth)
+	assert.Equal(t, 0, a.grown)
+
+	lines := strings.Count(buf.String(), "consecutive intervals")
+	assert.Equal(t, 1, lines)
+}
@@ -0,0 +1,128 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package main
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/nestybox/sysbox-fs/crash"
+	"github.com/nestybox/sysbox-fs/domain"
+)
+
+//
+// Minimal implementation of systemd's notification protocol (sd_notify(3)).
+// All these routines are no-ops when sysbox-fs is not launched by systemd
+// (or the unit's 'Type' is not 'notify').
+//
+
+// Sends the given state (e.g. "READY=1") to systemd.
+func sdNotify(state string) error {
+
+	path := os.Getenv("NOTIFY_SOCKET")
+	if path == "" {
+		return nil
+	}
+
+	// Abstract namespace sockets.
+	if path[0] == '@' {
+		path = "\x00" + path[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.Write([]byte(state))
+
+	return err
+}
+
+// Returns the interval at which systemd expects watchdog keep-alives, or zero
+// if the watchdog is not enabled for this process.
+func sdWatchdogInterval() time.Duration {
+
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" {
+		if pid != strconv.Itoa(os.Getpid()) {
+			return 0
+		}
+	}
+
+	return time.Duration(usec) * time.Microsecond
+}
+
+//
+// Notifies systemd that sysbox-fs is ready once the ipc socket accepts
+// connections, which is the last step of sysbox-fs initialization (fuse
+// servers of the pre-existing containers are already serving by then).
+//
+func readyNotifier(socket string, timeout time.Duration) {
+
+	deadline := time.Now().Add(timeout)
+
+	for {
+		conn, err := net.Dial("unix", socket)
+		if err == nil {
+			conn.Close()
+			break
+		}
+		if time.Now().After(deadline) {
+			logrus.Errorf("ipc socket %s not ready after %v: %v", socket, timeout, err)
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	if err := sdNotify("READY=1"); err != nil {
+		logrus.Warnf("Unable to notify systemd readiness: %v", err)
+	}
+}
+
+//
+// Sends keep-alives to systemd's watchdog for as long as the fuse servers
+// keep serving requests. A wedged fuse event loop stops the keep-alives,
+// letting systemd restart sysbox-fs.
+//
+func watchdogHandler(fss domain.FuseServerServiceIface, interval time.Duration) {
+
+	// Ping at half the interval, as recommended by sd_watchdog_enabled(3).
+	period := interval / 2
+
+	for range time.Tick(period) {
+		if crash.Degraded() {
+			continue
+		}
+		if err := fss.HealthCheck(period); err != nil {
+			logrus.Errorf("Skipping watchdog keep-alive: %v", err)
+			continue
+		}
+		if err := sdNotify("WATCHDOG=1"); err != nil {
+			logrus.Warnf("Unable to send watchdog keep-alive: %v", err)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package main
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_sdNotify(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "sysbox-fs-notify")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	defer os.Unsetenv("NOTIFY_SOCKET")
+
+	// No notification socket, nothing to do.
+	os.Unsetenv("NOTIFY_SOCKET")
+	assert.NoError(t, sdNotify("READY=1"))
+
+	os.Setenv("NOTIFY_SOCKET", path)
+	assert.NoError(t, sdNotify("READY=1"))
+
+	buf := make([]byte, 64)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "READY=1", string(buf[:n]))
+}
+
+func Test_sdWatchdogInterval(t *testing.T) {
+
+	defer os.Unsetenv("WATCHDOG_USEC")
+	defer os.Unsetenv("WATCHDOG_PID")
+
+	tests := []struct {
+		name string
+		usec string
+		pid  string
+		want time.Duration
+	}{
+		{
+			//
+			// Test-case 1: Watchdog not enabled.
+			//
+			name: "1",
+			want: 0,
+		},
+		{
+			//
+			// Test-case 2: Watchdog enabled for this process.
+			//
+			name: "2",
+			usec: "30000000",
+			pid:  strconv.Itoa(os.Getpid()),
+			want: 30 * time.Second,
+		},
+		{
+			//
+			// Test-case 3: Watchdog enabled for a different process.
+			//
+			name: "3",
+			usec: "30000000",
+			pid:  "1",
+			want: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Setenv("WATCHDOG_USEC", tt.usec)
+			os.Setenv("WATCHDOG_PID", tt.pid)
+			assert.Equal(t, tt.want, sdWatchdogInterval())
+		})
+	}
+}
@@ -0,0 +1,326 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+
+	"github.com/nestybox/sysbox-fs/domain"
+)
+
+//
+// Live-upgrade support.
+//
+// Upon SIGUSR2 arrival, sysbox-fs re-executes its binary (which may have been
+// replaced by a newer version) and hands off the open /dev/fuse fds and the
+// serialized container-state to the new instance over a unix socket. The new
+// instance resumes the fuse-servers over the inherited connections, so the
+// sysbox-fs mounts within the running sys containers are left untouched. Once
+// the new instance acknowledges the handoff, the old one exits.
+//
+
+// Location of the unix socket used for the handoff.
+const upgradeSocket = "/run/sysbox/sysbox-fs-upgrade.sock"
+
+// Max number of fds to transfer per message (SCM_MAX_FD is 253).
+const upgradeMaxFds = 200
+
+// Time allowed for the new sysbox-fs instance to complete the handoff.
+const upgradeTimeout = 60 * time.Second
+
+type upgradeConn struct {
+	ContainerID string `json:"containerId"`
+	ProtoMajor  uint32 `json:"protoMajor"`
+	ProtoMinor  uint32 `json:"protoMinor"`
+	MaxWrite    uint32 `json:"maxWrite,omitempty"`
+}
+
+type upgradeMsg struct {
+	Containers json.RawMessage `json:"containers"`
+	Conns      []upgradeConn   `json:"conns"`
+}
+
+//
+// sysbox-fs live-upgrade handler goroutine.
+//
+func upgradeHandler(
+	signalChan chan os.Signal,
+	css domain.ContainerStateServiceIface,
+	fss domain.FuseServerServiceIface) {
+
+	for range signalChan {
+		logrus.Info("Live-upgrade requested ...")
+
+		if err := upgradeSend(css, fss); err != nil {
+			logrus.Errorf("Live-upgrade failed: %v", err)
+			continue
+		}
+
+		// Notice that fuse-servers are not destroyed here, as their fuse
+		// connections are now owned by the new sysbox-fs instance.
+		logrus.Info("Live-upgrade completed. Exiting.")
+		os.Exit(0)
+	}
+}
+
+// Launches the new sysbox-fs instance and hands off the fuse connections and
+// container-state to it.
+func upgradeSend(
+	css domain.ContainerStateServiceIface,
+	fss domain.FuseServerServiceIface) error {
+
+	if err := os.MkdirAll(filepath.Dir(upgradeSocket), 0700); err != nil {
+		return err
+	}
+	os.Remove(upgradeSocket)
+
+	l, err := net.ListenUnix("unix", &net.UnixAddr{Name: upgradeSocket, Net: "unix"})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		l.Close()
+		os.Remove(upgradeSocket)
+	}()
+
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(exe, append([]string{"--upgrade-from", upgradeSocket},
+		os.Args[1:]...)...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	if err := upgradeSendState(l, css, fss); err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return err
+	}
+
+	cmd.Process.Release()
+
+	return nil
+}
+
+func upgradeSendState(
+	l *net.UnixListener,
+	css domain.ContainerStateServiceIface,
+	fss domain.FuseServerServiceIface) error {
+
+	l.SetDeadline(time.Now().Add(upgradeTimeout))
+
+	conn, err := l.AcceptUnix()
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(upgradeTimeout))
+
+	// The connection is purposely left open on success (it's closed upon
+	// exit) to let the new instance know when the old one is gone.
+	if err := upgradeSendConn(conn, css, fss); err != nil {
+		conn.Close()
+		return err
+	}
+
+	return nil
+}
+
+// Hands off the container-state and fuse connections over the given
+// connection, and waits for the new instance to acknowledge them.
+func upgradeSendConn(
+	conn *net.UnixConn,
+	css domain.ContainerStateServiceIface,
+	fss domain.FuseServerServiceIface) error {
+
+	cntrs, err := css.ContainerDBExport()
+	if err != nil {
+		return err
+	}
+
+	fuseConns := fss.ExportFuseConns()
+
+	msg := upgradeMsg{Containers: cntrs}
+	fds := make([]int, 0, len(fuseConns))
+	for _, fc := range fuseConns {
+		msg.Conns = append(msg.Conns, upgradeConn{
+			ContainerID: fc.ContainerID,
+			ProtoMajor:  fc.ProtoMajor,
+			ProtoMinor:  fc.ProtoMinor,
+			MaxWrite:    fc.MaxWrite,
+		})
+		fds = append(fds, int(fc.Dev.Fd()))
+	}
+
+	buf, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	// Message body is preceded by its length.
+	hdr := make([]byte, 4)
+	binary.BigEndian.PutUint32(hdr, uint32(len(buf)))
+
+	if _, err := conn.Write(append(hdr, buf...)); err != nil {
+		return err
+	}
+
+	// Fds are transferred in chunks, each one attached to a single-byte
+	// message.
+	for len(fds) > 0 {
+		n := len(fds)
+		if n > upgradeMaxFds {
+			n = upgradeMaxFds
+		}
+
+		_, _, err := conn.WriteMsgUnix([]byte{0}, unix.UnixRights(fds[:n]...), nil)
+		if err != nil {
+			return err
+		}
+		fds = fds[n:]
+	}
+
+	// Wait for the new instance to acknowledge the handoff.
+	ack := make([]byte, 1)
+	if _, err := io.ReadFull(conn, ack); err != nil {
+		return fmt.Errorf("handoff not acknowledged: %v", err)
+	}
+
+	return nil
+}
+
+// Receives the fuse connections and container-state handed off by a previous
+// sysbox-fs instance. Returns once the previous instance is gone.
+func upgradeRecv(
+	sockPath string,
+	css domain.ContainerStateServiceIface,
+	fss domain.FuseServerServiceIface) error {
+
+	conn, err := net.DialUnix("unix", nil, &net.UnixAddr{Name: sockPath, Net: "unix"})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := upgradeRecvConn(conn, css, fss); err != nil {
+		return err
+	}
+
+	// Wait for the previous instance to exit before taking over its ipc
+	// endpoint.
+	io.Copy(ioutil.Discard, conn)
+
+	return nil
+}
+
+// Receives the container-state and fuse connections handed off over the given
+// connection, and acknowledges them once imported.
+func upgradeRecvConn(
+	conn *net.UnixConn,
+	css domain.ContainerStateServiceIface,
+	fss domain.FuseServerServiceIface) error {
+
+	hdr := make([]byte, 4)
+	if _, err := io.ReadFull(conn, hdr); err != nil {
+		return err
+	}
+
+	buf := make([]byte, binary.BigEndian.Uint32(hdr))
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return err
+	}
+
+	var msg upgradeMsg
+	if err := json.Unmarshal(buf, &msg); err != nil {
+		return err
+	}
+
+	var fds []int
+	for len(fds) < len(msg.Conns) {
+		b := make([]byte, 1)
+		oob := make([]byte, unix.CmsgSpace(upgradeMaxFds*4))
+
+		_, oobn, _, _, err := conn.ReadMsgUnix(b, oob)
+		if err != nil {
+			return err
+		}
+
+		scms, err := unix.ParseSocketControlMessage(oob[:oobn])
+		if err != nil {
+			return err
+		}
+		if len(scms) == 0 {
+			return errors.New("missing fds in handoff message")
+		}
+
+		for _, scm := range scms {
+			rights, err := unix.ParseUnixRights(&scm)
+			if err != nil {
+				return err
+			}
+			fds = append(fds, rights...)
+		}
+	}
+
+	var fuseConns []domain.FuseConnState
+	for i, c := range msg.Conns {
+		fuseConns = append(fuseConns, domain.FuseConnState{
+			ContainerID: c.ContainerID,
+			Dev:         os.NewFile(uintptr(fds[i]), "/dev/fuse"),
+			ProtoMajor:  c.ProtoMajor,
+			ProtoMinor:  c.ProtoMinor,
+			MaxWrite:    c.MaxWrite,
+		})
+	}
+
+	if err := fss.ImportFuseConns(fuseConns); err != nil {
+		return err
+	}
+
+	if err := css.ContainerDBImport(msg.Containers); err != nil {
+		return err
+	}
+
+	// Get rid of the connections of the containers that couldn't be restored.
+	fss.DiscardFuseConns()
+
+	if _, err := conn.Write([]byte{0}); err != nil {
+		return err
+	}
+
+	return nil
+}
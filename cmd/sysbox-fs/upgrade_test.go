@@ -0,0 +1,150 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/sys/unix"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/mocks"
+)
+
+// Returns a connected pair of unix sockets.
+func unixConnPair(t *testing.T) (*net.UnixConn, *net.UnixConn) {
+
+	fds, err := unix.Socketpair(unix.AF_UNIX, unix.SOCK_STREAM|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var conns [2]*net.UnixConn
+	for i, fd := range fds {
+		f := os.NewFile(uintptr(fd), "handoff")
+		c, err := net.FileConn(f)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		conns[i] = c.(*net.UnixConn)
+	}
+
+	return conns[0], conns[1]
+}
+
+func Test_upgradeHandoff(t *testing.T) {
+
+	// Stand-in for the /dev/fuse fds being handed off.
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	var devStat unix.Stat_t
+	if err := unix.Fstat(int(r.Fd()), &devStat); err != nil {
+		t.Fatal(err)
+	}
+
+	state := []byte(`{"version":2,"containers":[]}`)
+
+	tests := []struct {
+		name  string
+		conns int
+	}{
+		// Test-case 1: No fuse connections in place.
+		{"1", 0},
+
+		// Test-case 2: Fds fitting within a single message.
+		{"2", 3},
+
+		// Test-case 3: Fds spread across several messages.
+		{"3", upgradeMaxFds + 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			var exported []domain.FuseConnState
+			for i := 0; i < tt.conns; i++ {
+				exported = append(exported, domain.FuseConnState{
+					ContainerID: fmt.Sprintf("c%d", i),
+					Dev:         r,
+					ProtoMajor:  7,
+					ProtoMinor:  uint32(i),
+					MaxWrite:    128 * 1024,
+				})
+			}
+
+			oldCss := &mocks.ContainerStateServiceIface{}
+			oldCss.On("ContainerDBExport").Return(state, nil)
+			oldFss := &mocks.FuseServerServiceIface{}
+			oldFss.On("ExportFuseConns").Return(exported)
+
+			var imported []domain.FuseConnState
+			newCss := &mocks.ContainerStateServiceIface{}
+			newCss.On("ContainerDBImport", state).Return(nil)
+			newFss := &mocks.FuseServerServiceIface{}
+			newFss.On("ImportFuseConns", mock.Anything).Return(nil).Run(
+				func(args mock.Arguments) {
+					imported = args.Get(0).([]domain.FuseConnState)
+				})
+			newFss.On("DiscardFuseConns").Return()
+
+			oldConn, newConn := unixConnPair(t)
+			defer oldConn.Close()
+			defer newConn.Close()
+
+			sendErr := make(chan error, 1)
+			go func() {
+				sendErr <- upgradeSendConn(oldConn, oldCss, oldFss)
+			}()
+
+			assert.NoError(t, upgradeRecvConn(newConn, newCss, newFss))
+			assert.NoError(t, <-sendErr)
+
+			newCss.AssertExpectations(t)
+			newFss.AssertExpectations(t)
+
+			if !assert.Len(t, imported, tt.conns) {
+				return
+			}
+
+			for i, c := range imported {
+				assert.Equal(t, exported[i].ContainerID, c.ContainerID)
+				assert.Equal(t, exported[i].ProtoMajor, c.ProtoMajor)
+				assert.Equal(t, exported[i].ProtoMinor, c.ProtoMinor)
+				assert.Equal(t, exported[i].MaxWrite, c.MaxWrite)
+
+				// Received fds must refer to the exported files.
+				var st unix.Stat_t
+				if assert.NoError(t, unix.Fstat(int(c.Dev.Fd()), &st)) {
+					assert.Equal(t, devStat.Dev, st.Dev)
+					assert.Equal(t, devStat.Ino, st.Ino)
+				}
+				c.Dev.Close()
+			}
+		})
+	}
+}
@@ -0,0 +1,433 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+//
+// Package conformance verifies that the resources emulated by sysbox-fs keep
+// the format of the kernel's native ones, so that the tools parsing them (e.g.
+// free, top, lscpu) aren't broken by formatting regressions.
+//
+// The emulated nodes are captured from within a test container (i.e. through
+// sysbox-fs) and the native ones from the host, each into a directory of
+// golden files (see Capture()). Both captures are then compared entry by entry
+// as per the tolerance spec of the handler serving each node (see Compare()),
+// since the emulated values are expected to differ from the native ones (e.g.
+// memory limits) in known ways only.
+//
+package conformance
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Layout of the entries (lines) of a node.
+type Format int
+
+const (
+	FormatColon   Format = iota // "key: values" entries (e.g. /proc/meminfo)
+	FormatColumns               // "key values" entries (e.g. /proc/stat)
+	FormatValues                // keyless entries, keyed by line number (e.g. /proc/uptime)
+)
+
+// Kind of difference tolerated between the native and emulated values of an
+// entry.
+type ToleranceKind int
+
+const (
+	// Values must be identical.
+	Exact ToleranceKind = iota
+
+	// Values must have the same shape: same number of fields, each one made
+	// of the same characters except for digits (e.g. "12 kB" vs "3456 kB").
+	Shape
+
+	// Same shape, and the emulated numeric fields can't exceed the native
+	// ones (e.g. container memory limits vs host memory).
+	AtMost
+
+	// Same shape, and the numeric fields must be within the given fraction
+	// (Delta) of the native ones.
+	Relative
+
+	// Values aren't compared.
+	Ignore
+)
+
+type Tolerance struct {
+	Kind  ToleranceKind
+	Delta float64 // Relative only
+}
+
+//
+// Conformance spec of an emulated node.
+//
+type Spec struct {
+	Handler string // name of the handler serving the node
+	Path    string
+	Format  Format
+
+	// Tolerance of the entries, per key (without occurrence suffix), and of
+	// those lacking one.
+	Fields  map[string]Tolerance
+	Default Tolerance
+
+	// Whether the values of FormatColon entries are right-aligned (e.g.
+	// "MemTotal:       16314532 kB"), in which case the column their first
+	// field ends at must be preserved.
+	Aligned bool
+
+	// Whether the emulated node may lack native entries (e.g. the cpus out of
+	// the container's cpuset).
+	AllowMissing bool
+}
+
+// Specs of the nodes verified by default.
+var DefaultSpecs = []Spec{
+	{
+		Handler: "procMeminfo",
+		Path:    "/proc/meminfo",
+		Format:  FormatColon,
+		Aligned: true,
+		Default: Tolerance{Kind: Shape},
+		Fields: map[string]Tolerance{
+			"MemTotal":     {Kind: AtMost},
+			"SwapTotal":    {Kind: AtMost},
+			"Hugepagesize": {Kind: Exact},
+		},
+	},
+	{
+		Handler:      "procCpuinfo",
+		Path:         "/proc/cpuinfo",
+		Format:       FormatColon,
+		Default:      Tolerance{Kind: Exact},
+		AllowMissing: true,
+		Fields: map[string]Tolerance{
+			"processor": {Kind: Shape},
+			"cpu MHz":   {Kind: Shape},
+			"siblings":  {Kind: AtMost},
+			"cpu cores": {Kind: AtMost},
+		},
+	},
+	{
+		Handler:      "procStat",
+		Path:         "/proc/stat",
+		Format:       FormatColumns,
+		Default:      Tolerance{Kind: Shape},
+		AllowMissing: true,
+		Fields: map[string]Tolerance{
+			"intr":    {Kind: Ignore},
+			"softirq": {Kind: Ignore},
+		},
+	},
+	{
+		Handler: "procUptime",
+		Path:    "/proc/uptime",
+		Format:  FormatValues,
+		Default: Tolerance{Kind: AtMost},
+	},
+	{
+		Handler: "procLoadavg",
+		Path:    "/proc/loadavg",
+		Format:  FormatValues,
+		Default: Tolerance{Kind: Shape},
+	},
+}
+
+//
+// Difference found between the native and emulated content of a node.
+//
+type Diff struct {
+	Path     string
+	Key      string
+	Native   string
+	Emulated string
+	Reason   string
+}
+
+func (d Diff) String() string {
+	if d.Key == "" {
+		return fmt.Sprintf("%s: %s", d.Path, d.Reason)
+	}
+
+	return fmt.Sprintf("%s: %s: %s (native %q, emulated %q)",
+		d.Path, d.Key, d.Reason, d.Native, d.Emulated)
+}
+
+// Entry (line) of a node.
+type entry struct {
+	key    string   // key, with occurrence suffix if repeated (e.g. "processor#1")
+	base   string   // key without occurrence suffix
+	sep    string   // text between key and first field
+	fields []string // whitespace-separated values
+	column int      // column the first field ends at
+}
+
+var digitsRe = regexp.MustCompile(`[0-9]+`)
+
+// Splits the given content into its entries, as per the given format.
+func parse(format Format, data string) []entry {
+
+	var entries []entry
+
+	seen := make(map[string]int)
+
+	for i, line := range strings.Split(strings.TrimSuffix(data, "\n"), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		var e entry
+		var rest string
+
+		switch format {
+		case FormatColon:
+			idx := strings.Index(line, ":")
+			if idx < 0 {
+				e.base, rest = strings.TrimSpace(line), ""
+			} else {
+				e.base, rest = strings.TrimSpace(line[:idx]), line[idx:]
+			}
+
+		case FormatColumns:
+			trimmed := strings.TrimLeft(line, " \t")
+			idx := strings.IndexAny(trimmed, " \t")
+			if idx < 0 {
+				e.base, rest = trimmed, ""
+			} else {
+				e.base, rest = trimmed[:idx], trimmed[idx:]
+			}
+
+		case FormatValues:
+			e.base, rest = strconv.Itoa(i), line
+		}
+
+		e.fields = strings.Fields(strings.TrimPrefix(rest, ":"))
+
+		if len(e.fields) > 0 {
+			start := strings.Index(rest, e.fields[0])
+			e.sep = rest[:start]
+			e.column = len(line) - len(rest) + start + len(e.fields[0])
+		} else {
+			e.sep = rest
+		}
+
+		e.key = e.base
+		if n := seen[e.base]; n > 0 {
+			e.key = fmt.Sprintf("%s#%d", e.base, n)
+		}
+		seen[e.base]++
+
+		entries = append(entries, e)
+	}
+
+	return entries
+}
+
+//
+// Compares the native and emulated content of the node described by the
+// given spec, and returns the differences not tolerated by it.
+//
+func Compare(spec Spec, native, emulated []byte) []Diff {
+
+	var diffs []Diff
+
+	nat := parse(spec.Format, string(native))
+	emu := parse(spec.Format, string(emulated))
+
+	if len(native) > 0 && len(emulated) > 0 &&
+		strings.HasSuffix(string(native), "\n") != strings.HasSuffix(string(emulated), "\n") {
+		diffs = append(diffs, Diff{Path: spec.Path, Reason: "trailing newline mismatch"})
+	}
+
+	natIdx := make(map[string]int)
+	for i, e := range nat {
+		natIdx[e.key] = i
+	}
+
+	// Native position of the last emulated entry, to verify the entries'
+	// order.
+	last := -1
+
+	found := make(map[string]bool)
+
+	for _, e := range emu {
+		i, ok := natIdx[e.key]
+		if !ok {
+			diffs = append(diffs, Diff{
+				Path:     spec.Path,
+				Key:      e.key,
+				Emulated: strings.Join(e.fields, " "),
+				Reason:   "unexpected entry",
+			})
+			continue
+		}
+		found[e.key] = true
+
+		if i < last {
+			diffs = append(diffs, Diff{
+				Path:   spec.Path,
+				Key:    e.key,
+				Reason: "entry out of order",
+			})
+		}
+		last = i
+
+		tol, ok := spec.Fields[e.base]
+		if !ok {
+			tol = spec.Default
+		}
+
+		if reason := compareEntry(spec, tol, nat[i], e); reason != "" {
+			diffs = append(diffs, Diff{
+				Path:     spec.Path,
+				Key:      e.key,
+				Native:   strings.Join(nat[i].fields, " "),
+				Emulated: strings.Join(e.fields, " "),
+				Reason:   reason,
+			})
+		}
+	}
+
+	if !spec.AllowMissing {
+		for _, e := range nat {
+			if !found[e.key] {
+				diffs = append(diffs, Diff{
+					Path:   spec.Path,
+					Key:    e.key,
+					Native: strings.Join(e.fields, " "),
+					Reason: "missing entry",
+				})
+			}
+		}
+	}
+
+	return diffs
+}
+
+// Returns the reason the given entries don't conform, if they don't.
+func compareEntry(spec Spec, tol Tolerance, nat, emu entry) string {
+
+	if tol.Kind == Ignore {
+		return ""
+	}
+
+	if strings.TrimSpace(nat.sep) != strings.TrimSpace(emu.sep) {
+		return "separator mismatch"
+	}
+
+	if spec.Format == FormatColon && spec.Aligned && nat.column != emu.column {
+		return "alignment mismatch"
+	}
+
+	if tol.Kind == Exact {
+		if strings.Join(nat.fields, " ") != strings.Join(emu.fields, " ") {
+			return "value mismatch"
+		}
+		return ""
+	}
+
+	if len(nat.fields) != len(emu.fields) {
+		return "field count mismatch"
+	}
+
+	for i := range nat.fields {
+		n, e := nat.fields[i], emu.fields[i]
+
+		if digitsRe.ReplaceAllString(n, "0") != digitsRe.ReplaceAllString(e, "0") {
+			return fmt.Sprintf("field %d shape mismatch", i)
+		}
+
+		nv, err1 := strconv.ParseFloat(n, 64)
+		ev, err2 := strconv.ParseFloat(e, 64)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+
+		switch tol.Kind {
+		case AtMost:
+			if ev > nv {
+				return fmt.Sprintf("field %d exceeds native value", i)
+			}
+		case Relative:
+			if math.Abs(ev-nv) > tol.Delta*math.Abs(nv) {
+				return fmt.Sprintf("field %d out of tolerance", i)
+			}
+		}
+	}
+
+	return ""
+}
+
+//
+// Captures the content of the nodes described by the given specs into the
+// 'dir' directory, under their own paths (e.g. <dir>/proc/meminfo). Meant to
+// be run both in the host (native nodes) and within a test container
+// (emulated ones).
+//
+func Capture(specs []Spec, dir string) error {
+
+	for _, s := range specs {
+		data, err := ioutil.ReadFile(s.Path)
+		if err != nil {
+			return err
+		}
+
+		dst := filepath.Join(dir, s.Path)
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(dst, data, 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//
+// Compares the native and emulated captures (see Capture()) of the nodes
+// described by the given specs.
+//
+func CompareDirs(specs []Spec, nativeDir, emulatedDir string) ([]Diff, error) {
+
+	var diffs []Diff
+
+	for _, s := range specs {
+		native, err := ioutil.ReadFile(filepath.Join(nativeDir, s.Path))
+		if err != nil {
+			return nil, err
+		}
+
+		emulated, err := ioutil.ReadFile(filepath.Join(emulatedDir, s.Path))
+		if os.IsNotExist(err) {
+			diffs = append(diffs, Diff{Path: s.Path, Reason: "missing node"})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		diffs = append(diffs, Compare(s, native, emulated)...)
+	}
+
+	return diffs, nil
+}
@@ -0,0 +1,162 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package conformance
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareDirs(t *testing.T) {
+
+	// Golden captures of a host and of a container restricted to 2GB of
+	// memory and one cpu of each core.
+	diffs, err := CompareDirs(DefaultSpecs, "testdata/native", "testdata/emulated")
+	assert.NoError(t, err)
+	assert.Empty(t, diffs)
+}
+
+func TestCompare(t *testing.T) {
+
+	meminfo := DefaultSpecs[0]
+
+	native := "MemTotal:       16314532 kB\n" +
+		"MemFree:         9418704 kB\n" +
+		"Hugepagesize:       2048 kB\n"
+
+	tests := []struct {
+		name     string
+		emulated string
+		want     []string
+	}{
+		{
+			name: "conforming",
+			emulated: "MemTotal:        2097152 kB\n" +
+				"MemFree:         1843200 kB\n" +
+				"Hugepagesize:       2048 kB\n",
+		},
+		{
+			name: "misaligned",
+			emulated: "MemTotal: 2097152 kB\n" +
+				"MemFree:         1843200 kB\n" +
+				"Hugepagesize:       2048 kB\n",
+			want: []string{"MemTotal: alignment mismatch"},
+		},
+		{
+			name: "missing unit",
+			emulated: "MemTotal:        2097152 kB\n" +
+				"MemFree:         1843200\n" +
+				"Hugepagesize:       2048 kB\n",
+			want: []string{"MemFree: field count mismatch"},
+		},
+		{
+			name: "exceeding",
+			emulated: "MemTotal:       32629064 kB\n" +
+				"MemFree:         1843200 kB\n" +
+				"Hugepagesize:       2048 kB\n",
+			want: []string{"MemTotal: field 0 exceeds native value"},
+		},
+		{
+			name: "exact",
+			emulated: "MemTotal:        2097152 kB\n" +
+				"MemFree:         1843200 kB\n" +
+				"Hugepagesize:       4096 kB\n",
+			want: []string{"Hugepagesize: value mismatch"},
+		},
+		{
+			name: "missing and unexpected entries",
+			emulated: "MemTotal:        2097152 kB\n" +
+				"MemUsed:          253952 kB\n" +
+				"Hugepagesize:       2048 kB\n",
+			want: []string{"MemUsed: unexpected entry", "MemFree: missing entry"},
+		},
+		{
+			name: "out of order",
+			emulated: "MemFree:         1843200 kB\n" +
+				"MemTotal:        2097152 kB\n" +
+				"Hugepagesize:       2048 kB\n",
+			want: []string{"MemTotal: entry out of order"},
+		},
+		{
+			name: "trailing newline",
+			emulated: "MemTotal:        2097152 kB\n" +
+				"MemFree:         1843200 kB\n" +
+				"Hugepagesize:       2048 kB",
+			want: []string{"trailing newline mismatch"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, d := range Compare(meminfo, []byte(native), []byte(tt.emulated)) {
+				if d.Key == "" {
+					got = append(got, d.Reason)
+				} else {
+					got = append(got, d.Key+": "+d.Reason)
+				}
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCompareRepeatedKeys(t *testing.T) {
+
+	spec := Spec{
+		Path:    "/proc/cpuinfo",
+		Format:  FormatColon,
+		Default: Tolerance{Kind: Exact},
+		Fields: map[string]Tolerance{
+			"cpu MHz": {Kind: Relative, Delta: 0.1},
+		},
+	}
+
+	native := "processor\t: 0\ncpu MHz\t\t: 2200.000\n\n" +
+		"processor\t: 1\ncpu MHz\t\t: 2200.000\n\n"
+	emulated := "processor\t: 0\ncpu MHz\t\t: 2100.000\n\n" +
+		"processor\t: 1\ncpu MHz\t\t: 1800.000\n\n"
+
+	diffs := Compare(spec, []byte(native), []byte(emulated))
+	if assert.Len(t, diffs, 1) {
+		assert.Equal(t, "cpu MHz#1", diffs[0].Key)
+		assert.Equal(t, "field 0 out of tolerance", diffs[0].Reason)
+	}
+}
+
+func TestCapture(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "conformance")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "uptime")
+	assert.NoError(t, ioutil.WriteFile(src, []byte("1.00 2.00\n"), 0644))
+
+	specs := []Spec{{Path: src, Format: FormatValues}}
+
+	out := filepath.Join(dir, "capture")
+	assert.NoError(t, Capture(specs, out))
+
+	data, err := ioutil.ReadFile(filepath.Join(out, src))
+	assert.NoError(t, err)
+	assert.Equal(t, "1.00 2.00\n", string(data))
+}
@@ -0,0 +1,91 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+//
+// Package crash provides panic recovery for sysbox-fs goroutines and fuse
+// request handlers.
+//
+// A panic within any of sysbox-fs' goroutines would otherwise bring the daemon
+// down right away, leaving the fuse mounts of every sys container behind. By
+// deferring Recover(), the panic is logged along with its stack trace, the
+// daemon is flagged as degraded, and the registered handler gets a chance to
+// tear down the fuse mounts before sysbox-fs exits.
+//
+package crash
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	degraded int32
+	once     sync.Once
+	handler  func(where string, cause interface{})
+)
+
+//
+// Registers the function in charge of shutting sysbox-fs down upon panic
+// recovery. The function is expected not to return.
+//
+func SetHandler(fn func(where string, cause interface{})) {
+	handler = fn
+}
+
+// Reports whether a panic has been caught.
+func Degraded() bool {
+	return atomic.LoadInt32(&degraded) == 1
+}
+
+//
+// Recovers from a panic within the calling goroutine, if any. Must be invoked
+// through a defer statement; 'where' identifies the panicking component.
+//
+func Recover(where string) {
+
+	cause := recover()
+	if cause == nil {
+		return
+	}
+
+	atomic.StoreInt32(&degraded, 1)
+
+	// Buffer size = 1024 x 32, enough to hold the goroutine's stack-trace.
+	stacktrace := make([]byte, 32768)
+	length := runtime.Stack(stacktrace, false)
+
+	logrus.Errorf("Panic caught in %s: %v\n\n%s\n", where, cause,
+		string(stacktrace[:length]))
+
+	if handler == nil {
+		panic(cause)
+	}
+
+	// Panics caught concurrently wait here while the first one is being
+	// handled (i.e. until sysbox-fs exits).
+	once.Do(func() { handler(where, cause) })
+}
+
+// Runs the given function in a new goroutine with panic recovery.
+func Go(where string, fn func()) {
+	go func() {
+		defer Recover(where)
+		fn()
+	}()
+}
@@ -0,0 +1,65 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package crash
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecover(t *testing.T) {
+
+	logrus.SetOutput(ioutil.Discard)
+
+	type call struct {
+		where string
+		cause interface{}
+	}
+	calls := make(chan call, 2)
+
+	SetHandler(func(where string, cause interface{}) {
+		calls <- call{where, cause}
+	})
+	defer SetHandler(nil)
+
+	assert.False(t, Degraded())
+
+	// No panic, no handler invocation.
+	func() {
+		defer Recover("no-op")
+	}()
+	assert.False(t, Degraded())
+
+	// Panics are recovered and reported once.
+	for i := 0; i < 2; i++ {
+		done := make(chan struct{})
+		Go("test", func() {
+			defer close(done)
+			panic("boom")
+		})
+		<-done
+	}
+
+	c := <-calls
+	assert.Equal(t, "test", c.where)
+	assert.Equal(t, "boom", c.cause)
+	assert.Len(t, calls, 0)
+	assert.True(t, Degraded())
+}
@@ -0,0 +1,61 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+//
+// Faults injected at runtime into sysbox-fs internals (host I/O and nsenter
+// requests), so that operators and CI can validate that sys containers degrade
+// gracefully when these misbehave. Rates are the fraction (0 to 1) of the
+// operations affected; the zero value injects no faults.
+//
+type ChaosConfig struct {
+	ErrorRate float64       `json:"error-rate"` // operations failed with EIO
+	DelayRate float64       `json:"delay-rate"` // operations delayed by Delay
+	Delay     time.Duration `json:"delay"`
+	CrashRate float64       `json:"crash-rate"` // nsenter processes killed mid-request (nsenter only)
+}
+
+func (c ChaosConfig) IsZero() bool {
+	return c == ChaosConfig{}
+}
+
+func (c ChaosConfig) Validate() error {
+
+	for _, r := range []float64{c.ErrorRate, c.DelayRate, c.CrashRate} {
+		if r < 0 || r > 1 {
+			return fmt.Errorf("fault rate %v out of [0, 1] range", r)
+		}
+	}
+
+	if c.Delay < 0 {
+		return fmt.Errorf("negative fault delay %v", c.Delay)
+	}
+
+	return nil
+}
+
+// ChaosIface is implemented by the services supporting runtime fault
+// injection.
+type ChaosIface interface {
+	SetChaos(c ChaosConfig)
+	Chaos() ChaosConfig
+}
@@ -16,7 +16,12 @@
 
 package domain
 
-import "time"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
 
 //
 // Container interface.
@@ -29,28 +34,332 @@ type ContainerIface interface {
 	InitPid() uint32
 	Ctime() time.Time
 	Data(path string, name string) (string, bool)
+	DataValue(path string, name string) (StateValue, bool)
+	DataSize() int
 	String() string
 	UID() uint32
 	GID() uint32
 	ProcRoPaths() []string
 	ProcMaskPaths() []string
+	UidMappings() []IDMapping
+	GidMappings() []IDMapping
+	CgroupPaths() CgroupPaths
+	Hostname() string
+	Limits() ResourceLimits
 	IsSpecPath(s string) bool
 	InitProc() ProcessIface
+	Parent() ContainerIface
+	Children() []ContainerIface
+	Level() uint
+	Override(path string) (NodeOverride, bool)
+	Policy(path string) (ValuePolicy, bool)
+	MountOptions() MountOptions
+	RebootRequested() bool
+	OpStats() ContainerOpStats
 	//
 	// Setters
 	//
 	//Update(cntr ContainerIface) error
 	SetData(path string, name string, data string)
+	SetDataValue(path string, name string, val interface{}, version uint64) (uint64, error)
+	SetOverrides(o map[string]NodeOverride)
+	SetPolicies(p map[string]ValuePolicy)
+	SetMountOptions(o MountOptions)
+	SetRebootRequested()
+	AccountOp(op FuseOp, failed bool)
 	SetInitProc(pid, uid, gid uint32) error
 	SetService(css ContainerStateServiceIface)
 }
 
 //
-// Auxiliary types to deal with the per-container-state associated to all the
-// emulated resources.
+// Container's user-namespace uid/gid mapping entry, as defined in
+// /proc/<pid>/uid_map and /proc/<pid>/gid_map.
 //
-type StateDataMap = map[string]map[string]string
-type StateData = map[string]string
+type IDMapping struct {
+	ContainerID uint32 // first id within the container's user-ns
+	HostID      uint32 // first id within the host's user-ns
+	Size        uint32 // id range size
+}
+
+// Kernel's default overflowuid / overflowgid: the id reported by the kernel
+// within a user-ns for the ids that aren't mapped into it.
+const OverflowID uint32 = 65534
+
+//
+// Translates the given id of the container's user-ns into the host's one, as
+// per the passed mappings. Returns false if the id isn't mapped.
+//
+func IDToHost(mappings []IDMapping, id uint32) (uint32, bool) {
+
+	for _, m := range mappings {
+		if id >= m.ContainerID && id-m.ContainerID < m.Size {
+			return m.HostID + (id - m.ContainerID), true
+		}
+	}
+
+	return 0, false
+}
+
+//
+// Translates the given id of the host's user-ns into the container's one, as
+// per the passed mappings. Returns false if the id isn't mapped.
+//
+func IDToContainer(mappings []IDMapping, id uint32) (uint32, bool) {
+
+	for _, m := range mappings {
+		if id >= m.HostID && id-m.HostID < m.Size {
+			return m.ContainerID + (id - m.HostID), true
+		}
+	}
+
+	return 0, false
+}
+
+//
+// Cgroup paths associated to a container. In cgroup-v1 setups every controller
+// can be mounted at a different location, hence the per-controller map; in
+// cgroup-v2 setups a single path (unified hierarchy) is all we need.
+//
+type CgroupPaths struct {
+	V1 map[string]string // controller -> cgroup-v1 path
+	V2 string            // cgroup-v2 unified path
+}
+
+//
+// Resource limits configured for a container. Handlers rely on these to
+// virtualize resources such as /proc/meminfo or /proc/cpuinfo. A zero value
+// in any of the numerical fields means 'no limit'.
+//
+type ResourceLimits struct {
+	CpusetCpus   string // cpus allowed (e.g. "0-3,6")
+	CpusetMems   string // memory nodes allowed (e.g. "0")
+	CpuQuota     int64  // cfs quota (usecs)
+	CpuPeriod    uint64 // cfs period (usecs)
+	CpuShares    uint64 // relative cpu weight
+	MemLimit     int64  // memory limit (bytes)
+	MemSwapLimit int64  // memory + swap limit (bytes)
+}
+
+//
+// FUSE operations accounted on a per-container basis.
+//
+type FuseOp int
+
+const (
+	FuseOpLookup FuseOp = iota
+	FuseOpOpen
+	FuseOpRead
+	FuseOpWrite
+	FuseOpReadDir
+)
+
+func (op FuseOp) String() string {
+	switch op {
+	case FuseOpLookup:
+		return "lookup"
+	case FuseOpOpen:
+		return "open"
+	case FuseOpRead:
+		return "read"
+	case FuseOpWrite:
+		return "write"
+	case FuseOpReadDir:
+		return "readdir"
+	}
+	return "unknown"
+}
+
+//
+// Number of FUSE operations served on behalf of a container, and how many of
+// them failed.
+//
+type ContainerOpStats struct {
+	Lookups  uint64
+	Opens    uint64
+	Reads    uint64
+	Writes   uint64
+	ReadDirs uint64
+	Errors   uint64
+}
+
+// Returns the overall number of operations.
+func (s ContainerOpStats) Total() uint64 {
+	return s.Lookups + s.Opens + s.Reads + s.Writes + s.ReadDirs
+}
+
+//
+// Override of an emulated resource (e.g. /proc/sys/kernel/osrelease) pushed by
+// sysbox-mgr for a given container. Overridden resources are either hidden to
+// the container (lookups fail with ENOENT), or serve a fixed content that can't
+// be modified from within the container.
+//
+type NodeOverride struct {
+	Hide  bool   `json:"hide,omitempty"`  // resource not visible
+	Value string `json:"value,omitempty"` // content to serve (if not hidden)
+}
+
+//
+// Mount options of a container's emulated view, as requested by sysbox-mgr upon
+// registration. Much like procfs' own mount options (e.g. hidepid), these apply
+// to the whole container: if ReadOnly is set, no emulated resource can be
+// modified (EROFS), and the subtrees under HiddenPaths are not visible (ENOENT).
+// If RestrictBlockDevices is set, the only virtual block devices (e.g. loop
+// ones) visible to the container are those in BlockDevices (along with their
+// partitions).
+//
+type MountOptions struct {
+	ReadOnly             bool     `json:"readOnly,omitempty"`
+	HiddenPaths          []string `json:"hiddenPaths,omitempty"`
+	RestrictBlockDevices bool     `json:"restrictBlockDevices,omitempty"`
+	BlockDevices         []string `json:"blockDevices,omitempty"`
+}
+
+// IsZero reports whether no mount option is set.
+func (o MountOptions) IsZero() bool {
+	return !o.ReadOnly && len(o.HiddenPaths) == 0 &&
+		!o.RestrictBlockDevices && len(o.BlockDevices) == 0
+}
+
+// BlockDeviceVisible reports whether the given virtual block device (or
+// partition) is visible to the container.
+func (o MountOptions) BlockDeviceVisible(name string) bool {
+
+	if !o.RestrictBlockDevices {
+		return true
+	}
+
+	for _, dev := range o.BlockDevices {
+		if IsBlockDeviceOf(name, dev) {
+			return true
+		}
+	}
+
+	return false
+}
+
+//
+// IsBlockDeviceOf reports whether the given block device name refers to the
+// given device or to one of its partitions (e.g. "loop0p1" for "loop0", or
+// "sda1" for "sda").
+//
+func IsBlockDeviceOf(name string, dev string) bool {
+
+	if !strings.HasPrefix(name, dev) {
+		return false
+	}
+
+	part := name[len(dev):]
+	if part == "" {
+		return true
+	}
+
+	// Partitions of devices whose name ends in a digit carry a 'p' separator.
+	if dev != "" && dev[len(dev)-1] >= '0' && dev[len(dev)-1] <= '9' {
+		if part[0] != 'p' {
+			return false
+		}
+		part = part[1:]
+	}
+
+	if part == "" {
+		return false
+	}
+	for _, c := range part {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// IsHidden reports whether the given path lies within a hidden subtree.
+func (o MountOptions) IsHidden(path string) bool {
+
+	for _, h := range o.HiddenPaths {
+		h = strings.TrimSuffix(h, "/")
+		if path == h || strings.HasPrefix(path, h+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+//
+// Bounds of the values that a container can write into an emulated resource
+// (e.g. a cap on fs.inotify.max_user_watches), as pushed by sysbox-mgr. Min and
+// Max apply to every integer field of the written value; if Allowed isn't
+// empty, the value must also match one of its entries. If set, Default is
+// the value exposed by the resource upon container registration, regardless
+// of the host's one.
+//
+type ValuePolicy struct {
+	Min     *int64   `json:"min,omitempty"`
+	Max     *int64   `json:"max,omitempty"`
+	Allowed []string `json:"allowed,omitempty"`
+	Default string   `json:"default,omitempty"`
+}
+
+// Check verifies that the given value (as written into the resource) honors
+// the policy.
+func (p ValuePolicy) Check(value string) error {
+
+	value = strings.TrimSpace(value)
+
+	if len(p.Allowed) > 0 {
+		var found bool
+		for _, a := range p.Allowed {
+			if value == a {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("value %q not allowed", value)
+		}
+	}
+
+	if p.Min == nil && p.Max == nil {
+		return nil
+	}
+
+	for _, field := range strings.Fields(value) {
+		i, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			return fmt.Errorf("value %q not an integer", field)
+		}
+		if p.Min != nil && i < *p.Min {
+			return fmt.Errorf("value %d below minimum %d", i, *p.Min)
+		}
+		if p.Max != nil && i > *p.Max {
+			return fmt.Errorf("value %d above maximum %d", i, *p.Max)
+		}
+	}
+
+	return nil
+}
+
+//
+// Container lifecycle events.
+//
+type ContainerEventType int
+
+const (
+	ContainerRegisterEvent ContainerEventType = iota
+	ContainerUpdateEvent
+	ContainerUnregisterEvent
+	ContainerPreRegisterEvent
+	ContainerRebootEvent
+)
+
+type ContainerEvent struct {
+	Type      ContainerEventType
+	Container ContainerIface
+}
+
+// Callback to be invoked upon arrival of container lifecycle events.
+type ContainerEventHandler func(e ContainerEvent)
 
 //
 // ContainerStateService interface defines the APIs that sysbox-fs components
@@ -60,7 +369,8 @@ type ContainerStateServiceIface interface {
 	Setup(
 		fss FuseServerServiceIface,
 		prs ProcessServiceIface,
-		ios IOServiceIface)
+		ios IOServiceIface,
+		stateDir string)
 
 	ContainerCreate(
 		id string,
@@ -71,16 +381,36 @@ type ContainerStateServiceIface interface {
 		gidFirst uint32,
 		gidSize uint32,
 		procRoPaths []string,
-		procMaskPaths []string) ContainerIface
+		procMaskPaths []string,
+		uidMappings []IDMapping,
+		gidMappings []IDMapping,
+		cgroupPaths CgroupPaths,
+		hostname string,
+		limits ResourceLimits) ContainerIface
 
-	ContainerPreRegister(id string) error
+	ContainerPreRegister(id string, c ContainerIface) error
 	ContainerRegister(c ContainerIface) error
 	ContainerUpdate(c ContainerIface) error
 	ContainerUnregister(c ContainerIface) error
 	ContainerLookupById(id string) ContainerIface
 	ContainerLookupByInode(usernsInode Inode) ContainerIface
 	ContainerLookupByProcess(process ProcessIface) ContainerIface
+	ContainerLookupByPid(pid uint32) ContainerIface
+	ContainerList() []ContainerIface
 	FuseServerService() FuseServerServiceIface
 	ProcessService() ProcessServiceIface
 	ContainerDBSize() int
+	ContainerDBDrain() (int, error)
+	ContainerDBUndrain()
+	ContainerDBCheckpoint() error
+	ContainerDBRestore() error
+	ContainerDBExport() ([]byte, error)
+	ContainerDBImport(buf []byte) error
+	ContainerStateExport(id string) ([]byte, error)
+	ContainerStateImport(id string, state []byte) error
+	Subscribe(h ContainerEventHandler) int
+	Unsubscribe(id int)
+	ReaperStart(interval time.Duration)
+	ReaperStop()
+	Stop()
 }
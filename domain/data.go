@@ -0,0 +1,191 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+//
+// Auxiliary types to deal with the per-container-state associated to all the
+// emulated resources.
+//
+type StateDataMap = map[string]StateData
+type StateData = map[string]StateValue
+
+// Error returned when a data-store update is attempted against a stale
+// version of the stored value.
+var ErrDataVersionConflict = errors.New("data-store version conflict")
+
+type StateValueKind int
+
+const (
+	StringValue StateValueKind = iota
+	IntValue
+	BoolValue
+	StructValue
+)
+
+//
+// Typed value held within the per-container data-store. Structured values
+// are kept in their serialized form, so that they can be decoded into the
+// caller's type (see Decode()) regardless of whether they've been set in
+// the current sysbox-fs instance, or restored from a checkpoint.
+//
+// Every update bumps the value's version, which allows concurrent writers
+// to detect conflicts (optimistic concurrency control).
+//
+type StateValue struct {
+	kind    StateValueKind
+	str     string
+	num     int64
+	flag    bool
+	raw     json.RawMessage
+	version uint64
+}
+
+func NewStateValue(val interface{}) (StateValue, error) {
+
+	switch v := val.(type) {
+	case string:
+		return StateValue{kind: StringValue, str: v}, nil
+	case int:
+		return StateValue{kind: IntValue, num: int64(v)}, nil
+	case int32:
+		return StateValue{kind: IntValue, num: int64(v)}, nil
+	case int64:
+		return StateValue{kind: IntValue, num: v}, nil
+	case uint32:
+		return StateValue{kind: IntValue, num: int64(v)}, nil
+	case bool:
+		return StateValue{kind: BoolValue, flag: v}, nil
+	}
+
+	raw, err := json.Marshal(val)
+	if err != nil {
+		return StateValue{}, err
+	}
+
+	return StateValue{kind: StructValue, raw: raw}, nil
+}
+
+func (v StateValue) Kind() StateValueKind {
+	return v.kind
+}
+
+func (v StateValue) Version() uint64 {
+	return v.version
+}
+
+func (v StateValue) WithVersion(version uint64) StateValue {
+	v.version = version
+	return v
+}
+
+func (v StateValue) Int() (int64, bool) {
+	return v.num, v.kind == IntValue
+}
+
+func (v StateValue) Bool() (bool, bool) {
+	return v.flag, v.kind == BoolValue
+}
+
+// String returns the textual representation of the value, regardless of its
+// kind.
+func (v StateValue) String() string {
+
+	switch v.kind {
+	case IntValue:
+		return strconv.FormatInt(v.num, 10)
+	case BoolValue:
+		if v.flag {
+			return "1"
+		}
+		return "0"
+	case StructValue:
+		return string(v.raw)
+	}
+
+	return v.str
+}
+
+// Decode unmarshals a structured value into the passed object.
+func (v StateValue) Decode(out interface{}) error {
+
+	if v.kind != StructValue {
+		return fmt.Errorf("value of kind %d is not a structured one", v.kind)
+	}
+
+	return json.Unmarshal(v.raw, out)
+}
+
+type stateValueJSON struct {
+	Kind    StateValueKind  `json:"kind"`
+	Value   json.RawMessage `json:"value"`
+	Version uint64          `json:"version"`
+}
+
+func (v StateValue) MarshalJSON() ([]byte, error) {
+
+	var (
+		val []byte
+		err error
+	)
+
+	switch v.kind {
+	case IntValue:
+		val, err = json.Marshal(v.num)
+	case BoolValue:
+		val, err = json.Marshal(v.flag)
+	case StructValue:
+		val = v.raw
+	default:
+		val, err = json.Marshal(v.str)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(stateValueJSON{v.kind, val, v.version})
+}
+
+func (v *StateValue) UnmarshalJSON(buf []byte) error {
+
+	var sv stateValueJSON
+	if err := json.Unmarshal(buf, &sv); err != nil {
+		return err
+	}
+
+	*v = StateValue{kind: sv.Kind, version: sv.Version}
+
+	switch sv.Kind {
+	case StringValue:
+		return json.Unmarshal(sv.Value, &v.str)
+	case IntValue:
+		return json.Unmarshal(sv.Value, &v.num)
+	case BoolValue:
+		return json.Unmarshal(sv.Value, &v.flag)
+	case StructValue:
+		v.raw = append(json.RawMessage(nil), sv.Value...)
+		return nil
+	}
+
+	return fmt.Errorf("unknown value kind %d", sv.Kind)
+}
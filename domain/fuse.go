@@ -16,6 +16,11 @@
 
 package domain
 
+import (
+	"os"
+	"time"
+)
+
 type FuseServerServiceIface interface {
 	Setup(
 		mp string,
@@ -26,6 +31,38 @@ type FuseServerServiceIface interface {
 	CreateFuseServer(cntr ContainerIface) error
 	DestroyFuseServer(mp string) error
 	DestroyFuseService()
+	AbortFuseService()
+	InvalidateNodes(cntrId string, paths []string) error
+	ExportFuseConns() []FuseConnState
+	ImportFuseConns(conns []FuseConnState) error
+	DiscardFuseConns()
+	HealthCheck(timeout time.Duration) error
+	InvalidateCache(cntrId string) error
+	NotifyChange(cntrId string, path string) error
+	Usage() []FuseServerUsage
+}
+
+//
+// FuseConnState represents an established FUSE connection (/dev/fuse fd plus
+// negotiated protocol version) to be handed off to a different sysbox-fs
+// process during live-upgrades.
+//
+type FuseConnState struct {
+	ContainerID string
+	Dev         *os.File
+	ProtoMajor  uint32
+	ProtoMinor  uint32
+	MaxWrite    uint32
+}
+
+//
+// Resources held by the fuse-server of a sys container, as accounted for leak
+// detection purposes.
+//
+type FuseServerUsage struct {
+	ContainerID string
+	Nodes       int // entries of the node-attributes cache (nodeDB)
+	Handles     int // open file handles
 }
 
 type FuseServerIface interface {
@@ -35,4 +72,7 @@ type FuseServerIface interface {
 	MountPoint() string
 	Unmount()
 	InitWait()
+	InvalidateCache()
+	InvalidateNodes(paths ...string)
+	NotifyChange(path string)
 }
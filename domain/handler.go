@@ -17,6 +17,7 @@
 package domain
 
 import (
+	"context"
 	"os"
 	"syscall"
 )
@@ -59,6 +60,16 @@ type HandlerRequest struct {
 	Offset    int64
 	Data      []byte
 	Container ContainerIface
+	Ctx       context.Context // cancelled upon request interruption
+}
+
+// Context returns the context of the request (background if none).
+func (r *HandlerRequest) Context() context.Context {
+	if r.Ctx == nil {
+		return context.Background()
+	}
+
+	return r.Ctx
 }
 
 type Handler struct {
@@ -90,6 +101,97 @@ type HandlerIface interface {
 	SetService(hs HandlerServiceIface)
 }
 
+//
+// Optional interface to be implemented by handlers that need to carry out
+// per-container setup/teardown tasks (e.g. allocate a per-container buffer
+// during container registration).
+//
+type ContainerEventSubscriberIface interface {
+	HandleContainerEvent(e ContainerEvent)
+}
+
+//
+// Optional interface to be implemented by handlers whose resources demand
+// from their writers capabilities other than the ones implied by their path
+// (e.g. CAP_SYS_PTRACE for yama's ptrace_scope).
+//
+type WriteCapabilitiesIface interface {
+	WriteCapabilities() []Capability
+}
+
+//
+// Optional interface to be implemented by handlers whose resources must be
+// presented with permission bits other than the default ones of emulated
+// sysctls (0644), such as read-only kernel files (e.g. cap_last_cap).
+//
+type NodeModeIface interface {
+	NodeMode() os.FileMode
+}
+
+//
+// Optional interface to be implemented by handlers emulating their resources
+// at sys-container level out of the host's values (e.g. kernel.panic). These
+// are snapshotted upon container registration, so that later changes in the
+// host don't bleed into running containers. HostSnapshot returns the initial
+// value of the resource represented by the given node, or false if there's
+// none to take (e.g. namespaced resources).
+//
+type HostSnapshotIface interface {
+	HostSnapshot(n IOnodeIface) (string, bool)
+}
+
+//
+// Optional interface to be implemented by handlers generating the full content
+// of their resources at once (e.g. /proc/schedstat). Reads of these resources
+// are served out of a copy of the content generated upon the first read of
+// each open file, so that windows at arbitrary offsets are consistent with
+// each other, and content is not regenerated for every read chunk.
+//
+type ContentIface interface {
+	Content(n IOnodeIface, req *HandlerRequest) ([]byte, error)
+}
+
+//
+// Optional interface to be implemented by stateful handlers (e.g. a
+// binfmt_misc registry or a kmsg buffer) that need to acquire and release
+// resources deterministically. Start is invoked upon sysbox-fs initialization,
+// before any container is served. Stop is invoked upon each container's
+// unregistration (to release the state associated to it), as well as upon
+// sysbox-fs termination with a nil container (to flush and release all of it).
+//
+type LifecycleIface interface {
+	Start() error
+	Stop(cntr ContainerIface) error
+}
+
+//
+// Invocation of a handler operation (see HandlerServiceIface's Invoke()), along
+// with its outcome. Depending on the operation, the outcome is either the
+// number of bytes read / written (N), the attributes of the node (Info), or
+// the entries of the directory (Entries).
+//
+type HandlerCall struct {
+	Op      FuseOp
+	Handler HandlerIface
+	Node    IOnodeIface
+	Req     *HandlerRequest
+
+	N       int
+	Info    os.FileInfo
+	Entries []os.FileInfo
+}
+
+// Function carrying out a handler call.
+type HandlerFunc func(c *HandlerCall) error
+
+//
+// Middleware wrapped around the execution of handler calls, so that concerns
+// common to all handlers (e.g. logging, metrics, auditing or validation) are
+// kept out of their implementations. Middlewares pass the call along the chain
+// by invoking 'next', or reject it by returning an error.
+//
+type HandlerMiddleware func(next HandlerFunc) HandlerFunc
+
 type HandlerServiceIface interface {
 	Setup(
 		hdlrs []HandlerIface,
@@ -105,16 +207,24 @@ type HandlerServiceIface interface {
 	FindHandler(s string) (HandlerIface, bool)
 	EnableHandler(h HandlerIface) error
 	DisableHandler(h HandlerIface) error
+	SyncHandlers(hdlrs []HandlerIface)
 	DirHandlerEntries(s string) []string
+	WriteAllowed(h HandlerIface, req *HandlerRequest) bool
+	Use(mws ...HandlerMiddleware)
+	Invoke(c *HandlerCall) error
+	Start() error
+	Stop()
 
 	// getters/setter
 	HandlerDB() map[string]HandlerIface
+	Handlers() []HandlerIface
 	StateService() ContainerStateServiceIface
 	SetStateService(css ContainerStateServiceIface)
 	ProcessService() ProcessServiceIface
 	NSenterService() NSenterServiceIface
 	IOService() IOServiceIface
 	IgnoreErrors() bool
+	SetDefaultValues(vals map[string]string)
 
 	// Auxiliar methods.
 	HostUserNsInode() Inode
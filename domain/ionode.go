@@ -32,6 +32,12 @@ type Inode = uint64
 // 2. ioNodeBuffer: An enhanced byte-buffer class wrapper. To be utilized
 //    during UT efforts.
 //
+// 3. ioNodeFault: A memory-backed ioNodeFile that fails, truncates or delays
+//    the operations matching the injected faults. To be utilized during UT
+//    efforts. Its host-backed variant (IOChaosFileService) serves the host FS
+//    as ioNodeFile does, failing or delaying operations as per the faults
+//    injected at runtime (see ChaosConfig).
+//
 
 type IOServiceType = int
 
@@ -40,6 +46,8 @@ const (
 	IOOsFileService                // production / regular purposes
 	IOMemFileService               // unit-testing purposes
 	IOBufferService
+	IOFaultFileService // unit-testing purposes (fault injection)
+	IOChaosFileService // production, with runtime fault injection
 )
 
 type IOServiceIface interface {
@@ -49,11 +57,14 @@ type IOServiceIface interface {
 	WriteNode(i IOnodeIface, p []byte) (int, error)
 	CloseNode(i IOnodeIface) error
 	ReadAtNode(i IOnodeIface, p []byte, off int64) (int, error)
+	WriteAtNode(i IOnodeIface, p []byte, off int64) (int, error)
 	ReadDirAllNode(i IOnodeIface) ([]os.FileInfo, error)
+	StatAllNode(i IOnodeIface, names []string) ([]os.FileInfo, error)
 	ReadFileNode(i IOnodeIface) ([]byte, error)
 	ReadLineNode(i IOnodeIface) (string, error)
 	StatNode(i IOnodeIface) (os.FileInfo, error)
 	SeekResetNode(i IOnodeIface) (int64, error)
+	SeekNode(i IOnodeIface, off int64, whence int) (int64, error)
 	RemoveAllIOnodes() error
 	PathNode(i IOnodeIface) string
 	GetServiceType() IOServiceType
@@ -65,6 +76,7 @@ type IOnodeIface interface {
 	Write(p []byte) (n int, err error)
 	Close() error
 	ReadAt(p []byte, off int64) (n int, err error)
+	WriteAt(p []byte, off int64) (n int, err error)
 	ReadDirAll() ([]os.FileInfo, error)
 	ReadFile() ([]byte, error)
 	ReadLine() (string, error)
@@ -72,9 +84,12 @@ type IOnodeIface interface {
 	Mkdir() error
 	MkdirAll() error
 	Stat() (os.FileInfo, error)
+	StatAll(names []string) ([]os.FileInfo, error)
 	SeekReset() (int64, error)
+	Seek(off int64, whence int) (int64, error)
 	Remove() error
 	RemoveAll() error
+	Rename(newpath string) error
 	//
 	// Required getters/setters.
 	//
@@ -20,7 +20,12 @@ type IpcServiceIface interface {
 	Setup(
 		css ContainerStateServiceIface,
 		prs ProcessServiceIface,
-		ios IOServiceIface)
+		ios IOServiceIface,
+		fss FuseServerServiceIface,
+		hds HandlerServiceIface)
 
+	SetAuthorizedPeers(uids []uint32, exes []string)
+	SetSocketPath(path string)
+	SocketPath() string
 	Init() error
 }
@@ -16,6 +16,11 @@
 
 package domain
 
+import (
+	"context"
+	"os"
+)
+
 // Aliases to leverage strong-typing.
 type NStype = string
 type NSenterMsgType = string
@@ -59,6 +64,19 @@ var AllNSsButUser = []NStype{
 	string(NStypeUts),
 }
 
+// Namespaces required to observe the network interfaces of the container.
+var NetNSs = []NStype{
+	string(NStypeUser),
+	string(NStypeNet),
+}
+
+// Namespaces required to observe cgroup paths as seen by the container (i.e.
+// relative to its cgroup namespace root).
+var CgroupNSs = []NStype{
+	string(NStypeUser),
+	string(NStypeCgroup),
+}
+
 //
 // NSenterEvent types. Define all possible messages that can be handled
 // by nsenterEvent class.
@@ -80,6 +98,20 @@ const (
 	MountSyscallResponse  NSenterMsgType = "mountSyscallResponse"
 	UmountSyscallRequest  NSenterMsgType = "umountSyscallRequest"
 	UmountSyscallResponse NSenterMsgType = "umountSyscallResponse"
+	StatRequest           NSenterMsgType = "statRequest"
+	StatResponse          NSenterMsgType = "statResponse"
+	ReadlinkRequest       NSenterMsgType = "readlinkRequest"
+	ReadlinkResponse      NSenterMsgType = "readlinkResponse"
+	MkdirRequest          NSenterMsgType = "mkdirRequest"
+	MkdirResponse         NSenterMsgType = "mkdirResponse"
+	ChownRequest          NSenterMsgType = "chownRequest"
+	ChownResponse         NSenterMsgType = "chownResponse"
+	CgroupRequest         NSenterMsgType = "cgroupRequest"
+	CgroupResponse        NSenterMsgType = "cgroupResponse"
+	NetDevRequest         NSenterMsgType = "netDevRequest"
+	NetDevResponse        NSenterMsgType = "netDevResponse"
+	BatchRequest          NSenterMsgType = "batchRequest"
+	BatchResponse         NSenterMsgType = "batchResponse"
 	ErrorResponse         NSenterMsgType = "errorResponse"
 )
 
@@ -96,7 +128,8 @@ type NSenterServiceIface interface {
 		res *NSenterMessage) NSenterEventIface
 
 	Setup(prs ProcessServiceIface)
-	SendRequestEvent(e NSenterEventIface) error
+	SetConcurrencyLimits(total, perContainer int)
+	SendRequestEvent(ctx context.Context, e NSenterEventIface) error
 	ReceiveResponseEvent(e NSenterEventIface) *NSenterMessage
 }
 
@@ -113,7 +146,7 @@ type NSenterServiceIface interface {
 // message exchanges.
 //
 type NSenterEventIface interface {
-	SendRequest() error
+	SendRequest(ctx context.Context) error
 	ReceiveResponse() *NSenterMessage
 	SetRequestMsg(m *NSenterMessage)
 	GetRequestMsg() *NSenterMessage
@@ -164,13 +197,67 @@ type ReadDirPayload struct {
 	Dir string `json:"dir"`
 }
 
+// Unlike lookups, stat requests don't follow symlinks.
+type StatPayload struct {
+	Entry string `json:"entry"`
+}
+
+type ReadlinkPayload struct {
+	Link string `json:"link"`
+}
+
+type MkdirPayload struct {
+	Dir  string      `json:"dir"`
+	Mode os.FileMode `json:"mode"`
+}
+
+// Uid / gid values of -1 leave the corresponding owner unchanged.
+type ChownPayload struct {
+	Entry string `json:"entry"`
+	Uid   int    `json:"uid"`
+	Gid   int    `json:"gid"`
+}
+
+// Cgroup requests obtain the cgroup membership of the given process. The
+// response carries a controller -> path map (the cgroup-v2 unified hierarchy
+// is keyed by an empty string).
+type CgroupPayload struct {
+	Pid uint32 `json:"pid"`
+}
+
+// Net-device requests obtain the attributes and statistics of the given network
+// interface (see NetDevInfo).
+type NetDevPayload struct {
+	Interface string `json:"interface"`
+}
+
+//
+// Attributes and statistics of a network interface, as reported by the kernel
+// within a given network namespace. Statistics are keyed by their sysfs names
+// (e.g. "rx_bytes"). Speed (Mbps) is nil if not known to the kernel (e.g.
+// interface down).
+//
+type NetDevInfo struct {
+	Mtu       int               `json:"mtu"`
+	OperState string            `json:"operstate"`
+	Speed     *int64            `json:"speed,omitempty"`
+	Stats     map[string]uint64 `json:"stats"`
+}
+
+// Batch requests carry a list of lookup / open / read / write / readdir
+// sub-requests, all served by a single nsenter process. Their responses are
+// returned in the same order, with failed sub-requests reported through
+// individual ErrorResponse messages.
+type BatchPayload []NSenterMessage
+
 type MountSyscallPayload struct {
-	Header NSenterMsgHeader
-	Source string `json:"source"`
-	Target string `json:"target"`
-	FsType string `json:"fstype"`
-	Flags  uint64 `json:"flags"`
-	Data   string `json:"data"`
+	Header   NSenterMsgHeader
+	Source   string `json:"source"`
+	Target   string `json:"target"`
+	FsType   string `json:"fstype"`
+	Flags    uint64 `json:"flags"`
+	Data     string `json:"data"`
+	Optional bool   `json:"optional"` // skipped if target is not present
 }
 
 type UmountSyscallPayload struct {
@@ -32,6 +32,17 @@ const (
 	X_OK AccessMode = 0x1 // execute ok
 )
 
+// Linux capabilities, as numbered by the kernel (see capabilities(7)). Only
+// the ones sysbox-fs checks on its own are defined.
+type Capability int
+
+const (
+	CAP_NET_ADMIN  Capability = 12
+	CAP_SYS_RAWIO  Capability = 17
+	CAP_SYS_PTRACE Capability = 19
+	CAP_SYS_ADMIN  Capability = 21
+)
+
 type ProcessIface interface {
 	Pid() uint32
 	Uid() uint32
@@ -39,9 +50,12 @@ type ProcessIface interface {
 	Cwd() string
 	Root() string
 	IsSysAdminCapabilitySet() bool
+	IsCapabilitySet(c Capability) bool
 	NsInodes() (map[string]Inode, error)
 	UserNsInode() (Inode, error)
 	UserNsInodeParent() (Inode, error)
+	UserNsInodeAncestors() ([]Inode, error)
+	PidNsWithin(inode Inode) (bool, error)
 	CreateNsInodes(Inode) error
 	PathAccess(path string, accessFlags AccessMode) error
 	GetEffCaps() [2]uint32
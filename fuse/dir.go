@@ -25,11 +25,11 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/nestybox/sysbox-fs/crash"
 	"github.com/nestybox/sysbox-fs/domain"
 
 	"bazil.org/fuse"
 	"bazil.org/fuse/fs"
-	"github.com/sirupsen/logrus"
 )
 
 // Default dentry-cache-timeout interval: This is the maximum
@@ -77,10 +77,21 @@ func (d *Dir) Lookup(
 	req *fuse.LookupRequest,
 	resp *fuse.LookupResponse) (fs.Node, error) {
 
-	logrus.Debugf("Requested Lookup() operation for entry %v (req ID=%#x)", req.Name, uint64(req.ID))
+	defer crash.Recover("fuse Lookup()")
+
+	logger.Debugf("Requested Lookup() operation for entry %v (req ID=%#x)", req.Name, uint64(req.ID))
 
 	path := filepath.Join(d.path, req.Name)
 
+	ctx, span := d.server.startSpan(ctx, domain.FuseOpLookup, path, req.Pid)
+	defer span.End()
+
+	// Resources hidden through overrides or mount options are reported as
+	// non-existent.
+	if d.server.hidden(path) {
+		return nil, fuse.ENOENT
+	}
+
 	//
 	// nodeDB caches the attributes associated with each file. This way, we perform the
 	// lookup of a given procfs/sysfs dir/file only once, improving performance. This works
@@ -95,19 +106,23 @@ func (d *Dir) Lookup(
 	if ok == true {
 		d.server.RUnlock()
 
-		// The uid & gid attributes must be obtained from the request.
-		uid, gid, err := d.getUsernsRootUid(req.Pid, req.Uid, req.Gid)
-		if err != nil {
-			return nil, err
+		// Identify node type and overwrite uid & gid values, which must be
+		// obtained from the request.
+		var file *File
+		if f, ok := (*node).(*File); ok {
+			file = f
+		} else if dir, ok := (*node).(*Dir); ok {
+			file = &dir.File
 		}
 
-		// Identify node type and overwrite uid & gid values.
-		if file, ok := (*node).(*File); ok {
+		if file != nil {
+			uid, gid, err := d.getUsernsOwnerIds(req.Pid, req.Uid, req.Gid,
+				file.uid, file.gid)
+			if err != nil {
+				return nil, err
+			}
 			file.attr.Uid = uid
 			file.attr.Gid = gid
-		} else if dir, ok := (*node).(*Dir); ok {
-			dir.attr.Uid = uid
-			dir.attr.Gid = gid
 		}
 
 		return *node, nil
@@ -121,7 +136,7 @@ func (d *Dir) Lookup(
 	// Lookup the associated handler within handler-DB.
 	handler, ok := d.server.service.hds.LookupHandler(ionode)
 	if !ok {
-		logrus.Errorf("No supported handler for %v resource", d.path)
+		logger.Errorf("No supported handler for %v resource", d.path)
 		return nil, fmt.Errorf("No supported handler for %v resource", d.path)
 	}
 
@@ -131,13 +146,20 @@ func (d *Dir) Lookup(
 		Uid:       req.Uid,
 		Gid:       req.Gid,
 		Container: d.server.container,
+		Ctx:       ctx,
 	}
 
 	// Handler execution.
-	info, err := handler.Lookup(ionode, request)
-	if err != nil {
-		return nil, fuse.ENOENT
+	call := &domain.HandlerCall{
+		Op:      domain.FuseOpLookup,
+		Handler: handler,
+		Node:    ionode,
+		Req:     request,
+	}
+	if err := d.server.service.hds.Invoke(call); err != nil {
+		return nil, errorToErrno(err, fuse.ENOENT)
 	}
+	info := call.Info
 
 	// Extract received file attributes and create a new element within
 	// sysbox file-system.
@@ -146,14 +168,11 @@ func (d *Dir) Lookup(
 	// Adjust response to carry the proper dentry-cache-timeout value.
 	resp.EntryValid = time.Duration(DentryCacheTimeout)
 
-	// Override the uid & gid attributes with the root uid & gid in the
-	// requester's user-ns.
-	uid, gid, err := d.getUsernsRootUid(req.Pid, req.Uid, req.Gid)
+	// Ids the node's owner maps to in the requester's user-ns.
+	uid, gid, err := d.getUsernsOwnerIds(req.Pid, req.Uid, req.Gid, attr.Uid, attr.Gid)
 	if err != nil {
 		return nil, err
 	}
-	attr.Uid = uid
-	attr.Gid = gid
 
 	var newNode fs.Node
 
@@ -161,9 +180,14 @@ func (d *Dir) Lookup(
 		attr.Mode = os.ModeDir | attr.Mode
 		newNode = NewDir(req.Name, path, &attr, d.File.server)
 	} else {
+		attr.Mode = d.server.nodeMode(path, attr.Mode)
 		newNode = NewFile(req.Name, path, &attr, d.File.server)
 	}
 
+	// Override the uid & gid attributes once the node has recorded its owner.
+	attr.Uid = uid
+	attr.Gid = gid
+
 	// Insert new fs node into nodeDB.
 	d.server.Lock()
 	d.server.nodeDB[path] = &newNode
@@ -196,10 +220,16 @@ func (d *Dir) Create(
 	req *fuse.CreateRequest,
 	resp *fuse.CreateResponse) (fs.Node, fs.Handle, error) {
 
-	logrus.Debugf("Requested Create() operation for entry %v (req ID=%#x)", req.Name, uint64(req.ID))
+	defer crash.Recover("fuse Create()")
+
+	logger.Debugf("Requested Create() operation for entry %v (req ID=%#x)", req.Name, uint64(req.ID))
 
 	path := filepath.Join(d.path, req.Name)
 
+	if d.server.readOnly() {
+		return nil, nil, fuse.Errno(syscall.EROFS)
+	}
+
 	// New ionode reflecting the path of the element to be created.
 	ionode := d.server.service.ios.NewIOnode(req.Name, path, 0)
 	ionode.SetOpenFlags(int(req.Flags))
@@ -208,7 +238,7 @@ func (d *Dir) Create(
 	// Lookup the associated handler within handler-DB.
 	handler, ok := d.server.service.hds.LookupHandler(ionode)
 	if !ok {
-		logrus.Errorf("No supported handler for %v resource", path)
+		logger.Errorf("No supported handler for %v resource", path)
 		return nil, nil, fmt.Errorf("No supported handler for %v resource", path)
 	}
 
@@ -218,13 +248,14 @@ func (d *Dir) Create(
 		Uid:       req.Uid,
 		Gid:       req.Gid,
 		Container: d.server.container,
+		Ctx:       ctx,
 	}
 
 	// Handler execution. 'Open' handler will create new element if requesting
 	// process has the proper credentials / capabilities.
 	err := handler.Open(ionode, request)
 	if err != nil && err != io.EOF {
-		logrus.Debugf("Open() error: %v", err)
+		logger.Debugf("Open() error: %v", err)
 		return nil, nil, err
 	}
 	resp.Flags |= fuse.OpenDirectIO
@@ -233,7 +264,7 @@ func (d *Dir) Create(
 	// and an open-response, let's start with the lookup() one.
 	info, err := handler.Lookup(ionode, request)
 	if err != nil {
-		return nil, nil, fuse.ENOENT
+		return nil, nil, errorToErrno(err, fuse.ENOENT)
 	}
 
 	// Extract received file attributes.
@@ -258,9 +289,14 @@ func (d *Dir) Create(
 //
 func (d *Dir) ReadDirAll(ctx context.Context, req *fuse.ReadRequest) ([]fuse.Dirent, error) {
 
+	defer crash.Recover("fuse ReadDirAll()")
+
+	ctx, span := d.server.startSpan(ctx, domain.FuseOpReadDir, d.path, req.Pid)
+	defer span.End()
+
 	var children []fuse.Dirent
 
-	logrus.Debugf("Requested ReadDirAll() on directory %v (req ID=%#v)", d.path, uint64(req.ID))
+	logger.Debugf("Requested ReadDirAll() on directory %v (req ID=%#v)", d.path, uint64(req.ID))
 
 	// New ionode reflecting the path of the element to be created.
 	ionode := d.server.service.ios.NewIOnode(d.name, d.path, 0)
@@ -269,7 +305,7 @@ func (d *Dir) ReadDirAll(ctx context.Context, req *fuse.ReadRequest) ([]fuse.Dir
 	// Lookup the associated handler within handler-DB.
 	handler, ok := d.server.service.hds.LookupHandler(ionode)
 	if !ok {
-		logrus.Errorf("No supported handler for %v resource", d.path)
+		logger.Errorf("No supported handler for %v resource", d.path)
 		return nil, fmt.Errorf("No supported handler for %v resource", d.path)
 	}
 
@@ -279,14 +315,21 @@ func (d *Dir) ReadDirAll(ctx context.Context, req *fuse.ReadRequest) ([]fuse.Dir
 		Uid:       req.Uid,
 		Gid:       req.Gid,
 		Container: d.server.container,
+		Ctx:       ctx,
 	}
 
 	// Handler execution.
-	files, err := handler.ReadDirAll(ionode, request)
-	if err != nil {
-		logrus.Errorf("ReadDirAll() error: %v", err)
-		return nil, fuse.ENOENT
+	call := &domain.HandlerCall{
+		Op:      domain.FuseOpReadDir,
+		Handler: handler,
+		Node:    ionode,
+		Req:     request,
+	}
+	if err := d.server.service.hds.Invoke(call); err != nil {
+		logger.Errorf("ReadDirAll() error: %v", err)
+		return nil, errorToErrno(err, fuse.ENOENT)
 	}
+	files := call.Entries
 
 	for _, node := range files {
 		//
@@ -300,6 +343,15 @@ func (d *Dir) ReadDirAll(ctx context.Context, req *fuse.ReadRequest) ([]fuse.Dir
 			}
 		}
 
+		if d.server.hidden(filepath.Join(d.path, node.Name())) {
+			continue
+		}
+
+		// The entries' attributes come along with the listing, so nodeDB is
+		// seeded with them to spare the lookup that the kernel issues for
+		// each entry right after (e.g. 'ls -l').
+		d.cacheEntry(node)
+
 		elem := fuse.Dirent{Name: node.Name()}
 
 		if node.IsDir() {
@@ -314,12 +366,50 @@ func (d *Dir) ReadDirAll(ctx context.Context, req *fuse.ReadRequest) ([]fuse.Dir
 	return children, nil
 }
 
+//
+// Inserts the node of the given directory entry into nodeDB, unless it's
+// already present or its attributes are unknown. Symlinks are skipped as
+// lookups follow them, whereas directory listings don't.
+//
+func (d *Dir) cacheEntry(info os.FileInfo) {
+
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || stat == nil || info.Mode()&os.ModeSymlink != 0 {
+		return
+	}
+
+	path := filepath.Join(d.path, info.Name())
+	attr := statToAttr(stat)
+
+	var newNode fs.Node
+
+	if info.IsDir() {
+		attr.Mode = os.ModeDir | attr.Mode
+		newNode = NewDir(info.Name(), path, &attr, d.File.server)
+	} else {
+		attr.Mode = d.server.nodeMode(path, attr.Mode)
+		newNode = NewFile(info.Name(), path, &attr, d.File.server)
+	}
+
+	d.server.Lock()
+	if _, ok := d.server.nodeDB[path]; !ok {
+		d.server.nodeDB[path] = &newNode
+	}
+	d.server.Unlock()
+}
+
 //
 // Mkdir FS operation.
 //
 func (d *Dir) Mkdir(ctx context.Context, req *fuse.MkdirRequest) (fs.Node, error) {
 
-	logrus.Debugf("Requested Mkdir() on directory %v (Req ID=%#v)", req.Name, uint64(req.ID))
+	defer crash.Recover("fuse Mkdir()")
+
+	logger.Debugf("Requested Mkdir() on directory %v (Req ID=%#v)", req.Name, uint64(req.ID))
+
+	if d.server.readOnly() {
+		return nil, fuse.Errno(syscall.EROFS)
+	}
 
 	path := filepath.Join(d.path, req.Name)
 	newDir := NewDir(req.Name, path, &fuse.Attr{}, d.File.server)
@@ -17,7 +17,10 @@
 package fuse
 
 import (
+	"bytes"
+	"encoding/gob"
 	"encoding/json"
+	"errors"
 	"os"
 	"reflect"
 	"syscall"
@@ -37,11 +40,16 @@ import (
 // reason that the 'RcvError' member below is not being exposed to JSON
 // marshalling logic.
 //
+// Besides the errno, IOerrors carry the context of the failed operation (e.g.
+// "open" and the path of the file being opened) whenever it's available.
+//
 type IOerror struct {
 	RcvError error         `json:"-"`
 	Type     string        `json:"type"`
 	Code     syscall.Errno `json:"code"`
 	Message  string        `json:"message"`
+	Op       string        `json:"op,omitempty"`
+	Path     string        `json:"path,omitempty"`
 }
 
 func (e IOerror) Error() string {
@@ -50,42 +58,118 @@ func (e IOerror) Error() string {
 
 // Method requested by fuse.ErrorNumber interface. By implementing this
 // interface, we are allowed to return IOerrors back to our FUSE-lib
-// modules without making any modification to Bazil-FUSE code.
+// modules without making any modification to Bazil-FUSE code. Errors
+// lacking an errno are reported as EIO, as a zero errno would be taken
+// as a success.
 func (e IOerror) Errno() fuse.Errno {
+	if e.Code == 0 {
+		return fuse.Errno(syscall.EIO)
+	}
 	return fuse.Errno(e.Code)
 }
 
+//
+// Returns the errno to report to FUSE clients for the given error, or the
+// 'fallback' one if the error carries none (e.g. errors generated by
+// sysbox-fs itself).
+//
+func errorToErrno(err error, fallback fuse.Errno) fuse.Errno {
+
+	var ioerr IOerror
+	if errors.As(err, &ioerr) {
+		return ioerr.Errno()
+	}
+
+	var errno syscall.Errno
+	if errors.As(err, &errno) {
+		return fuse.Errno(errno)
+	}
+
+	return fallback
+}
+
 // MarshallJSON's interface specialization to allow a customized encoding
 // of IOerror struct.
 func (e *IOerror) MarshalJSON() ([]byte, error) {
 
-	err := e.RcvError
-	if err == nil {
+	if e.RcvError == nil {
 		return nil, nil
 	}
 
-	var errcode syscall.Errno
+	e.resolve()
+
+	return json.Marshal(*e)
+}
+
+// Gob encoding counterpart of MarshalJSON(). Errors with no RcvError are
+// encoded as they are (e.g. errors generated by sysbox-fs itself).
+func (e *IOerror) GobEncode() ([]byte, error) {
+
+	if e.RcvError != nil {
+		e.resolve()
+	}
+
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(
+		ioErrorFields{e.Type, e.Code, e.Message, e.Op, e.Path})
+
+	return buf.Bytes(), err
+}
+
+func (e *IOerror) GobDecode(data []byte) error {
+
+	var f ioErrorFields
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&f); err != nil {
+		return err
+	}
+
+	e.Type, e.Code, e.Message = f.Type, f.Code, f.Message
+	e.Op, e.Path = f.Op, f.Path
+
+	return nil
+}
+
+// Encodable subset of IOerror's fields.
+type ioErrorFields struct {
+	Type    string
+	Code    syscall.Errno
+	Message string
+	Op      string
+	Path    string
+}
+
+// Populates the encodable fields of the IOerror based on the received error.
+// Wrapped errors are inspected too, so that the original errno is preserved
+// regardless of how the error was generated.
+func (e *IOerror) resolve() {
+
+	err := e.RcvError
 
-	// Type assertion is needed here to extract the error code corresponding
-	// to the different error flavors that may be generated during I/O ops.
-	switch v := err.(type) {
-	case *os.PathError:
-		errcode = v.Err.(syscall.Errno)
+	var (
+		pathErr *os.PathError
+		linkErr *os.LinkError
+		sysErr  *os.SyscallError
+		errno   syscall.Errno
+	)
 
-	case *os.SyscallError:
-		errcode = v.Err.(syscall.Errno)
+	// Extract the context of the failed operation, if any.
+	switch {
+	case errors.As(err, &pathErr):
+		e.Op, e.Path = pathErr.Op, pathErr.Path
 
-	case syscall.Errno:
-		errcode = v
+	case errors.As(err, &linkErr):
+		e.Op, e.Path = linkErr.Op, linkErr.Old
 
-	default:
-		errcode = syscall.EIO
+	case errors.As(err, &sysErr):
+		e.Op = sysErr.Syscall
 	}
 
-	// Finally, let's populate the fields of NSenterError struct.
+	e.Code = syscall.EIO
+	if errors.As(err, &errno) {
+		e.Code = errno
+	}
+
+	// Finally, let's populate the remaining fields of NSenterError struct.
 	e.Type = reflect.TypeOf(err).String()
-	e.Code = errcode
 	e.Message = err.Error()
-
-	return json.Marshal(*e)
 }
@@ -0,0 +1,104 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package fuse
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"testing"
+
+	"bazil.org/fuse"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIOerror_resolve(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		err      error
+		wantCode syscall.Errno
+		wantOp   string
+		wantPath string
+	}{
+		{
+			//
+			// Test-case 1: Path errors.
+			//
+			name:     "1",
+			err:      &os.PathError{Op: "open", Path: "/proc/sys/a", Err: syscall.EACCES},
+			wantCode: syscall.EACCES,
+			wantOp:   "open",
+			wantPath: "/proc/sys/a",
+		},
+		{
+			//
+			// Test-case 2: Wrapped path errors.
+			//
+			name: "2",
+			err: fmt.Errorf("lookup failed: %w",
+				&os.PathError{Op: "stat", Path: "/proc/sys/b", Err: syscall.ENOENT}),
+			wantCode: syscall.ENOENT,
+			wantOp:   "stat",
+			wantPath: "/proc/sys/b",
+		},
+		{
+			//
+			// Test-case 3: Plain errnos.
+			//
+			name:     "3",
+			err:      syscall.EINVAL,
+			wantCode: syscall.EINVAL,
+		},
+		{
+			//
+			// Test-case 4: Errors carrying no errno are reported as EIO.
+			//
+			name:     "4",
+			err:      errors.New("unexpected"),
+			wantCode: syscall.EIO,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &IOerror{RcvError: tt.err}
+			e.resolve()
+
+			assert.Equal(t, tt.wantCode, e.Code)
+			assert.Equal(t, tt.wantOp, e.Op)
+			assert.Equal(t, tt.wantPath, e.Path)
+			assert.Equal(t, tt.err.Error(), e.Message)
+		})
+	}
+}
+
+func Test_errorToErrno(t *testing.T) {
+
+	assert.Equal(t, fuse.Errno(syscall.EACCES),
+		errorToErrno(IOerror{Code: syscall.EACCES}, fuse.ENOENT))
+
+	assert.Equal(t, fuse.Errno(syscall.EIO),
+		errorToErrno(IOerror{}, fuse.ENOENT))
+
+	assert.Equal(t, fuse.Errno(syscall.EPERM),
+		errorToErrno(fmt.Errorf("failed: %w", syscall.EPERM), fuse.ENOENT))
+
+	assert.Equal(t, fuse.ENOENT,
+		errorToErrno(errors.New("Container not found"), fuse.ENOENT))
+}
@@ -22,13 +22,14 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
 	"syscall"
 	"time"
 
 	"bazil.org/fuse"
 	"bazil.org/fuse/fs"
-	"github.com/sirupsen/logrus"
 
+	"github.com/nestybox/sysbox-fs/crash"
 	"github.com/nestybox/sysbox-fs/domain"
 )
 
@@ -42,6 +43,11 @@ type File struct {
 	// File attributes.
 	attr *fuse.Attr
 
+	// Owner of the underlying node within the host's user-ns. Kept apart from
+	// 'attr', whose uid & gid are adjusted to every requester.
+	uid uint32
+	gid uint32
+
 	// Pointer to parent fuseService hosting this file/dir.
 	server *fuseServer
 }
@@ -55,6 +61,8 @@ func NewFile(name string, path string, attr *fuse.Attr, srv *fuseServer) *File {
 		name:   name,
 		path:   path,
 		attr:   attr,
+		uid:    attr.Uid,
+		gid:    attr.Gid,
 		server: srv,
 	}
 
@@ -66,7 +74,7 @@ func NewFile(name string, path string, attr *fuse.Attr, srv *fuseServer) *File {
 //
 func (f *File) Attr(ctx context.Context, a *fuse.Attr) error {
 
-	logrus.Debugf("Requested Attr() operation for entry %v", f.path)
+	logger.Debugf("Requested Attr() operation for entry %v", f.path)
 
 	// Simply return the attributes that were previously collected during the
 	// lookup() execution.
@@ -83,20 +91,21 @@ func (f *File) Getattr(
 	req *fuse.GetattrRequest,
 	resp *fuse.GetattrResponse) error {
 
-	logrus.Debugf("Requested GetAttr() operation for entry %v (Req ID=%#v)",
+	defer crash.Recover("fuse Getattr()")
+
+	logger.Debugf("Requested GetAttr() operation for entry %v (Req ID=%#v)",
 		f.path, uint64(req.ID))
 
 	// Use the attributes obtained during Lookup()
 	resp.Attr = *f.attr
 
-	// Override the uid & gid attributes with the user-ns' root uid & gid of the
-	// sys container under which the request is received. In the future we should
-	// return the requester's user-ns root uid & gid instead, which could differ
-	// from the sys container's one if request is originated from an L2 container.
-	// Also, this will help us to support "unshare -U -m --mount-proc" inside a
-	// sys container.
-	resp.Attr.Uid = f.server.container.UID()
-	resp.Attr.Gid = f.server.container.GID()
+	// Override the uid & gid attributes with the ones the node's owner maps to
+	// within the sys container under which the request is received. In the
+	// future we should rely on the requester's user-ns instead, which could
+	// differ from the sys container's one if request is originated from an L2
+	// container. Also, this will help us to support "unshare -U -m --mount-proc"
+	// inside a sys container.
+	resp.Attr.Uid, resp.Attr.Gid = ownerIds(f.server.container, f.uid, f.gid)
 
 	return nil
 }
@@ -109,16 +118,47 @@ func (f *File) Open(
 	req *fuse.OpenRequest,
 	resp *fuse.OpenResponse) (fs.Handle, error) {
 
-	logrus.Debugf("Requested Open() operation for entry %v (Req ID=%#v)",
+	defer crash.Recover("fuse Open()")
+
+	ctx, span := f.server.startSpan(ctx, domain.FuseOpOpen, f.path, req.Pid)
+	defer span.End()
+
+	logger.Debugf("Requested Open() operation for entry %v (Req ID=%#v)",
 		f.path, uint64(req.ID))
 
+	// Much like in procfs, appending to or truncating an emulated resource
+	// has no effect other than the one of the ensuing write (i.e. a shell's
+	// '>>' redirection behaves as a '>' one), so these flags are kept away
+	// from the handlers.
+	flags := req.Flags &^ (fuse.OpenAppend | fuse.OpenTruncate)
+
+	if f.server.hidden(f.path) {
+		return nil, fuse.ENOENT
+	}
+
+	// Emulated views mounted read-only can't be opened for writing.
+	if !req.Flags.IsReadOnly() && f.server.readOnly() {
+		return nil, fuse.Errno(syscall.EROFS)
+	}
+
+	// Overridden resources are served without handler intervention.
+	if _, ok := f.server.override(f.path); ok {
+		resp.Flags |= fuse.OpenDirectIO
+		return newHandle(f, flags), nil
+	}
+
+	// Container's state is only exposed to the container's processes.
+	if !f.server.originValid(req.Pid) {
+		return nil, fuse.EPERM
+	}
+
 	ionode := f.server.service.ios.NewIOnode(f.name, f.path, f.attr.Mode)
-	ionode.SetOpenFlags(int(req.Flags))
+	ionode.SetOpenFlags(int(flags))
 
 	// Lookup the associated handler within handler-DB.
 	handler, ok := f.server.service.hds.LookupHandler(ionode)
 	if !ok {
-		logrus.Errorf("No supported handler for %v resource", f.path)
+		logger.Errorf("No supported handler for %v resource", f.path)
 		return nil, fmt.Errorf("No supported handler for %v resource", f.path)
 	}
 
@@ -128,12 +168,18 @@ func (f *File) Open(
 		Uid:       req.Uid,
 		Gid:       req.Gid,
 		Container: f.server.container,
+		Ctx:       ctx,
 	}
 
 	// Handler execution.
-	err := handler.Open(ionode, request)
+	err := f.server.service.hds.Invoke(&domain.HandlerCall{
+		Op:      domain.FuseOpOpen,
+		Handler: handler,
+		Node:    ionode,
+		Req:     request,
+	})
 	if err != nil && err != io.EOF {
-		logrus.Debugf("Open() error: %v", err)
+		logger.Debugf("Open() error: %v", err)
 		return nil, err
 	}
 
@@ -153,62 +199,46 @@ func (f *File) Open(
 	//
 	resp.Flags |= fuse.OpenDirectIO
 
-	return f, nil
-}
-
-//
-// Release FS operation.
-//
-func (f *File) Release(ctx context.Context, req *fuse.ReleaseRequest) error {
-
-	logrus.Debugf("Requested Release() operation for entry %v (Req ID=%#v)",
-		f.path, uint64(req.ID))
-
-	//
-	// Upon arrival of incoming fuse requests, sysbox-fs open()s and close()s
-	// the associated file-system node. IOW, upon successful handling of an
-	// open() fuse request, no file-system state (i.e. opened file-descriptor)
-	// will be held in sysbox-fs for opened dentries. Subsequent fuse requests
-	// generated by the same fuse-client process, will re-open the associated
-	// file to carry out the corresponding read/write operation.
-	//
-	// Notice that this approach allows us to handle emulated and non-emulated
-	// fs resources in the same manner. Non-emulated resources are only
-	// reachable through 'nsexec' mechanisms, which relies on the utilization
-	// of different processes to perform a determined i/o operation. In this
-	// scenario, there's no point in open()ing and clos()ing files, as the
-	// process performing the interim action (let's say, an open request) will
-	// die upon completion, which will necessarily end up with the process'
-	// fd-table getting wiped out by kernel upon process' exit().
-	//
-	// That is all to say, that there is no need to do anything with these
-	// release() requests, as the associated inode is already closed by the
-	// time these requests arrive. And that covers both non-emulated ('nsexec')
-	// and emulated nodes.
-
-	return nil
+	return newHandle(f, flags), nil
 }
 
 //
-// Read FS operation.
+// Read FS operation, as received through the given handle.
 //
-func (f *File) Read(
+func (f *File) read(
 	ctx context.Context,
+	h *Handle,
 	req *fuse.ReadRequest,
 	resp *fuse.ReadResponse) error {
 
-	logrus.Debugf("Requested Read() operation for entry %v (Req ID=%#v)",
+	defer crash.Recover("fuse Read()")
+
+	ctx, span := f.server.startSpan(ctx, domain.FuseOpRead, f.path, req.Pid)
+	defer span.End()
+
+	logger.Debugf("Requested Read() operation for entry %v (Req ID=%#v)",
 		f.path, uint64(req.ID))
 
-	ionode := f.server.service.ios.NewIOnode(f.name, f.path, f.attr.Mode)
+	// Container's state is only exposed to the container's processes.
+	if !f.server.originValid(req.Pid) {
+		return fuse.EPERM
+	}
 
 	// Adjust receiving buffer to the request's size.
 	resp.Data = resp.Data[:req.Size]
 
+	if o, ok := f.server.override(f.path); ok && !o.Hide {
+		resp.Data = resp.Data[:overrideRead(o.Value, req.Offset, resp.Data)]
+		return nil
+	}
+
+	ionode := f.server.service.ios.NewIOnode(f.name, f.path, f.attr.Mode)
+	ionode.SetOpenFlags(int(h.flags))
+
 	// Identify the associated handler and execute it accordingly.
 	handler, ok := f.server.service.hds.LookupHandler(ionode)
 	if !ok {
-		logrus.Errorf("Read() error: No supported handler for %v resource", f.path)
+		logger.Errorf("Read() error: No supported handler for %v resource", f.path)
 		return fmt.Errorf("No supported handler for %v resource", f.path)
 	}
 
@@ -220,59 +250,139 @@ func (f *File) Read(
 		Offset:    req.Offset,
 		Data:      resp.Data,
 		Container: f.server.container,
+		Ctx:       ctx,
+	}
+
+	// Handlers generating the full content of their resources are served out
+	// of the handle's copy of it.
+	if ch, ok := handler.(domain.ContentIface); ok {
+		handler = &contentHandler{handler, ch, h}
 	}
 
 	// Handler execution.
-	n, err := handler.Read(ionode, request)
+	call := &domain.HandlerCall{
+		Op:      domain.FuseOpRead,
+		Handler: handler,
+		Node:    ionode,
+		Req:     request,
+	}
+	err := f.server.service.hds.Invoke(call)
 	if err != nil && err != io.EOF {
-		logrus.Debugf("Read() error: %v", err)
+		logger.Debugf("Read() error: %v", err)
 		return err
 	}
 
-	resp.Data = resp.Data[:n]
+	resp.Data = resp.Data[:call.N]
 
 	return nil
 }
 
 //
-// Write FS operation.
+// Write FS operation, as received through the given handle.
 //
-func (f *File) Write(
+func (f *File) write(
 	ctx context.Context,
+	h *Handle,
 	req *fuse.WriteRequest,
 	resp *fuse.WriteResponse) error {
 
-	logrus.Debugf("Requested Write() operation for entry %v (Req ID=%#v)",
+	defer crash.Recover("fuse Write()")
+
+	ctx, span := f.server.startSpan(ctx, domain.FuseOpWrite, f.path, req.Pid)
+	defer span.End()
+
+	logger.Debugf("Requested Write() operation for entry %v (Req ID=%#v)",
 		f.path, uint64(req.ID))
 
+	if f.server.readOnly() {
+		return fuse.Errno(syscall.EROFS)
+	}
+
+	// Overridden resources can't be modified from within the container.
+	if _, ok := f.server.override(f.path); ok {
+		return fuse.EPERM
+	}
+
+	// Container's state can only be modified by the container's processes.
+	if !f.server.originValid(req.Pid) {
+		return fuse.EPERM
+	}
+
+	// Writes exceeding the fuse payload limit are split by the kernel into
+	// several requests, which are put back together before reaching the
+	// handler (see Handle.accumulateWrite()). The write is handed over as
+	// soon as its last (partial) request is received, so that errors are
+	// reported to the writer; only writes that are a multiple of the payload
+	// limit need to wait for the file to be flushed.
+	if pending, err := h.accumulateWrite(req); pending {
+		if err != nil {
+			return err
+		}
+		if len(req.Data) < f.server.maxWrite {
+			if err := h.commitPendingWrite(ctx); err != nil {
+				return err
+			}
+		}
+		resp.Size = len(req.Data)
+		return nil
+	}
+
+	n, err := f.commitWrite(ctx, h, req.Header, req.Data)
+	if err != nil {
+		return err
+	}
+
+	resp.Size = n
+
+	return nil
+}
+
+//
+// Hands the given write payload over to the resource's handler.
+//
+func (f *File) commitWrite(
+	ctx context.Context,
+	h *Handle,
+	hdr fuse.Header,
+	data []byte) (int, error) {
+
 	ionode := f.server.service.ios.NewIOnode(f.name, f.path, f.attr.Mode)
+	ionode.SetOpenFlags(int(h.flags))
 
 	// Lookup the associated handler within handler-DB.
 	handler, ok := f.server.service.hds.LookupHandler(ionode)
 	if !ok {
-		logrus.Errorf("Write() error: No supported handler for %v resource", f.path)
-		return fmt.Errorf("No supported handler for %v resource", f.path)
+		logger.Errorf("Write() error: No supported handler for %v resource", f.path)
+		return 0, fmt.Errorf("No supported handler for %v resource", f.path)
 	}
 
 	request := &domain.HandlerRequest{
-		ID:        uint64(req.ID),
-		Pid:       req.Pid,
-		Uid:       req.Uid,
-		Gid:       req.Gid,
-		Data:      req.Data,
+		ID:        uint64(hdr.ID),
+		Pid:       hdr.Pid,
+		Uid:       hdr.Uid,
+		Gid:       hdr.Gid,
+		Data:      data,
 		Container: f.server.container,
+		Ctx:       ctx,
 	}
 
-	// Handler execution.
-	n, err := handler.Write(ionode, request)
+	// Handler execution. Capabilities and value bounds are enforced along the
+	// handlers' middleware chain.
+	call := &domain.HandlerCall{
+		Op:      domain.FuseOpWrite,
+		Handler: handler,
+		Node:    ionode,
+		Req:     request,
+	}
+	err := f.server.service.hds.Invoke(call)
 	if err != nil && err != io.EOF {
-		logrus.Debugf("Write() error: %v", err)
-		return err
+		logger.Debugf("Write() error: %v", err)
+		return 0, err
 	}
 
-	resp.Size = n
+	f.server.NotifyChange(f.path)
 
-	return nil
+	return call.N, nil
 }
 
 //
@@ -283,17 +393,27 @@ func (f *File) Setattr(
 	req *fuse.SetattrRequest,
 	resp *fuse.SetattrResponse) error {
 
-	logrus.Debugf("Requested Setattr() operation for entry %v (Req ID=%#v)",
+	defer crash.Recover("fuse Setattr()")
+
+	logger.Debugf("Requested Setattr() operation for entry %v (Req ID=%#v)",
 		f.path, uint64(req.ID))
 
-	// No file attr changes are allowed in a procfs, with the exception of
-	// 'size' modifications which are needed to allow write()/truncate() ops.
-	// All other 'fuse.SetattrValid' operations will be rejected.
-	if req.Valid.Size() {
-		return nil
+	if f.server.readOnly() {
+		return fuse.Errno(syscall.EROFS)
+	}
+
+	// Just like procfs does, ownership and permission changes are rejected.
+	// Every other attribute change is accepted but has no effect: 'size' ones
+	// are needed for truncate() / ftruncate() / O_TRUNC opens to succeed, and
+	// time ones for utimes() (e.g. touch).
+	if req.Valid.Mode() || req.Valid.Uid() || req.Valid.Gid() {
+		return fuse.EPERM
 	}
 
-	return fuse.EPERM
+	resp.Attr = *f.attr
+	resp.Attr.Uid, resp.Attr.Gid = ownerIds(f.server.container, f.uid, f.gid)
+
+	return nil
 }
 
 //
@@ -301,7 +421,7 @@ func (f *File) Setattr(
 //
 func (f *File) Forget() {
 
-	logrus.Debugf("Requested Forget() operation for entry %v", f.path)
+	logger.Debugf("Requested Forget() operation for entry %v", f.path)
 
 	f.server.Lock()
 	defer f.server.Unlock()
@@ -334,9 +454,10 @@ func (f *File) ModTime() time.Time {
 	return f.attr.Mtime
 }
 
-// getUsernsRootUid returns the uid and gid for the root user in the user-ns associated
+// getUsernsOwnerIds returns the uid and gid that the owner of a node (as given
+// by 'uid' and 'gid' within the host's user-ns) maps to in the user-ns associated
 // with the given request.
-func (f *File) getUsernsRootUid(reqPid, reqUid, reqGid uint32) (uint32, uint32, error) {
+func (f *File) getUsernsOwnerIds(reqPid, reqUid, reqGid, uid, gid uint32) (uint32, uint32, error) {
 
 	usernsInode, err := f.server.service.hds.FindUserNsInode(reqPid)
 	if err != nil {
@@ -347,21 +468,58 @@ func (f *File) getUsernsRootUid(reqPid, reqUid, reqGid uint32) (uint32, uint32,
 		return 0, 0, nil
 	}
 
-	// TODO: for now we return the root uid and gid associated with the the sys container.
-	// in the future we should return the requester's user-ns root uid & gid instead; this
+	// TODO: for now we rely on the uid and gid mappings of the sys container. In
+	// the future we should use the requester's user-ns mappings instead; this
 	// will help us to support "unshare -U -m --mount-proc" inside a sys container.
 
-	prs := f.server.service.hds.ProcessService()
 	css := f.server.service.hds.StateService()
 
-	process := prs.ProcessCreate(reqPid, reqUid, reqGid)
-	cntr := css.ContainerLookupByProcess(process)
+	cntr := css.ContainerLookupByPid(reqPid)
 
 	if cntr == nil {
 		return 0, 0, errors.New("Could not find container")
 	}
 
-	return cntr.UID(), cntr.GID(), nil
+	uid, gid = ownerIds(cntr, uid, gid)
+
+	return uid, gid, nil
+}
+
+//
+// ownerIds returns the uid and gid to report as the owner of a node owned by
+// the given host ids. Nodes owned by ids mapped into the container's user-ns
+// keep their owner. The rest are reported as owned by the container's root
+// rather than by the overflow id (nobody), which would otherwise break the
+// permission checks of the tools running as root within the container.
+//
+func ownerIds(cntr domain.ContainerIface, uid, gid uint32) (uint32, uint32) {
+
+	if _, ok := domain.IDToContainer(cntr.UidMappings(), uid); !ok {
+		uid = cntr.UID()
+	}
+	if _, ok := domain.IDToContainer(cntr.GidMappings(), gid); !ok {
+		gid = cntr.GID()
+	}
+
+	return uid, gid
+}
+
+//
+// overrideRead copies the content of an overridden resource, starting at the
+// given offset, into the passed buffer. Returns the number of bytes copied.
+//
+func overrideRead(value string, offset int64, buf []byte) int {
+
+	// Emulate the format of procfs / sysfs nodes.
+	if !strings.HasSuffix(value, "\n") {
+		value += "\n"
+	}
+
+	if offset >= int64(len(value)) {
+		return 0
+	}
+
+	return copy(buf, value[offset:])
 }
 
 //
@@ -0,0 +1,297 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package fuse
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+	"syscall"
+
+	"bazil.org/fuse"
+
+	"github.com/nestybox/sysbox-fs/crash"
+	"github.com/nestybox/sysbox-fs/domain"
+)
+
+//
+// Handle of an open File. Every open() request is served with a handle of its
+// own, so that the per-open state of concurrent openers of the same resource
+// (e.g. the content generated for their reads) is kept apart.
+//
+type Handle struct {
+	sync.Mutex
+
+	// File being accessed through this handle.
+	file *File
+
+	// Flags the file was opened with.
+	flags fuse.OpenFlags
+
+	// Content of the file as generated for this handle (see
+	// domain.ContentIface), and offset right past the last read of it.
+	content []byte
+	offset  int64
+
+	// Payload of a write split across several fuse requests, and header of
+	// the first of them (see accumulateWrite()).
+	wbuf []byte
+	whdr fuse.Header
+
+	// Change counter of the file last seen by this handle's pollers (see
+	// changeNotifier).
+	event uint64
+}
+
+// Max size of the writes put back together out of several fuse requests.
+const maxAccumulatedWrite = 1024 * 1024
+
+func newHandle(f *File, flags fuse.OpenFlags) *Handle {
+	atomic.AddInt64(&f.server.handles, 1)
+
+	return &Handle{
+		file:  f,
+		flags: flags,
+		event: f.server.changes.event(f.path),
+	}
+}
+
+//
+// Read FS operation.
+//
+func (h *Handle) Read(
+	ctx context.Context,
+	req *fuse.ReadRequest,
+	resp *fuse.ReadResponse) error {
+
+	return h.file.read(ctx, h, req, resp)
+}
+
+//
+// Write FS operation.
+//
+func (h *Handle) Write(
+	ctx context.Context,
+	req *fuse.WriteRequest,
+	resp *fuse.WriteResponse) error {
+
+	return h.file.write(ctx, h, req, resp)
+}
+
+//
+// Poll FS operation. Resources are always readable; on top of that, and just
+// like the kernel does for sysctls, pollers are notified with POLLERR | POLLPRI
+// of the changes of the resource's value since they opened it (or were last
+// notified).
+//
+func (h *Handle) Poll(
+	ctx context.Context,
+	req *fuse.PollRequest,
+	resp *fuse.PollResponse) error {
+
+	defer crash.Recover("fuse Poll()")
+
+	logger.Debugf("Requested Poll() operation for entry %v (Req ID=%#v)",
+		h.file.path, uint64(req.ID))
+
+	changes := &h.file.server.changes
+
+	if w, ok := req.Wakeup(); ok {
+		changes.watch(h.file.path, w)
+	}
+
+	resp.REvents = fuse.PollIn | fuse.PollRdNorm
+
+	event := changes.event(h.file.path)
+
+	h.Lock()
+	if event != h.event {
+		h.event = event
+		resp.REvents |= fuse.PollErr | fuse.PollPri
+	}
+	h.Unlock()
+
+	return nil
+}
+
+//
+// Flush FS operation. Writes split across several fuse requests that are still
+// pending (see File.write()) are committed at this point, so that their
+// outcome is reported through close().
+//
+func (h *Handle) Flush(ctx context.Context, req *fuse.FlushRequest) error {
+
+	defer crash.Recover("fuse Flush()")
+
+	logger.Debugf("Requested Flush() operation for entry %v (Req ID=%#v)",
+		h.file.path, uint64(req.ID))
+
+	return h.commitPendingWrite(ctx)
+}
+
+//
+// Release FS operation.
+//
+func (h *Handle) Release(ctx context.Context, req *fuse.ReleaseRequest) error {
+
+	logger.Debugf("Requested Release() operation for entry %v (Req ID=%#v)",
+		h.file.path, uint64(req.ID))
+
+	// Flush requests are usually received ahead of this one, but that's not
+	// guaranteed.
+	if err := h.commitPendingWrite(ctx); err != nil {
+		logger.Debugf("Release() error: %v", err)
+	}
+
+	//
+	// Upon arrival of incoming fuse requests, sysbox-fs open()s and close()s
+	// the associated file-system node. IOW, upon successful handling of an
+	// open() fuse request, no file-system state (i.e. opened file-descriptor)
+	// will be held in sysbox-fs for opened dentries. Subsequent fuse requests
+	// generated by the same fuse-client process, will re-open the associated
+	// file to carry out the corresponding read/write operation.
+	//
+	// Notice that this approach allows us to handle emulated and non-emulated
+	// fs resources in the same manner. Non-emulated resources are only
+	// reachable through 'nsexec' mechanisms, which relies on the utilization
+	// of different processes to perform a determined i/o operation. In this
+	// scenario, there's no point in open()ing and clos()ing files, as the
+	// process performing the interim action (let's say, an open request) will
+	// die upon completion, which will necessarily end up with the process'
+	// fd-table getting wiped out by kernel upon process' exit().
+	//
+	// That is all to say, that there is no need to do anything with these
+	// release() requests, as the associated inode is already closed by the
+	// time these requests arrive. And that covers both non-emulated ('nsexec')
+	// and emulated nodes. The only state to drop is the handle's own one.
+
+	h.Lock()
+	h.content = nil
+	h.Unlock()
+
+	atomic.AddInt64(&h.file.server.handles, -1)
+
+	return nil
+}
+
+//
+// Serves a read request out of the content generated by the handler for this
+// handle. Content is generated upon the first read, as well as every time the
+// file is read from the beginning again, so that the windows (at arbitrary
+// offsets) being read in between are consistent with each other.
+//
+func (h *Handle) readContent(
+	ch domain.ContentIface,
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	h.Lock()
+	defer h.Unlock()
+
+	if h.content == nil || (req.Offset == 0 && h.offset != 0) {
+		data, err := ch.Content(n, req)
+		if err != nil {
+			return 0, err
+		}
+		h.content = data
+	}
+
+	if req.Offset >= int64(len(h.content)) {
+		h.offset = req.Offset
+		return 0, io.EOF
+	}
+
+	sz := copy(req.Data, h.content[req.Offset:])
+	h.offset = req.Offset + int64(sz)
+
+	return sz, nil
+}
+
+//
+// Handler whose reads are served by readContent() out of the given handle (see
+// domain.ContentIface).
+//
+type contentHandler struct {
+	domain.HandlerIface
+	content domain.ContentIface
+	handle  *Handle
+}
+
+func (c *contentHandler) Read(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	return c.handle.readContent(c.content, n, req)
+}
+
+//
+// Accumulates the requests of a write split across several ones, returning
+// true if the request has been taken over. Writes are split by the kernel only
+// if exceeding the payload limit negotiated on the fuse connection, so a
+// request filling it up is considered the first chunk of a larger write; the
+// remaining ones must follow it in order.
+//
+func (h *Handle) accumulateWrite(req *fuse.WriteRequest) (bool, error) {
+
+	maxWrite := h.file.server.maxWrite
+
+	h.Lock()
+	defer h.Unlock()
+
+	if h.wbuf == nil {
+		if maxWrite == 0 || req.Offset != 0 || len(req.Data) < maxWrite {
+			return false, nil
+		}
+
+		h.wbuf = append([]byte(nil), req.Data...)
+		h.whdr = req.Header
+
+		return true, nil
+	}
+
+	if req.Offset != int64(len(h.wbuf)) {
+		h.wbuf = nil
+		return true, fuse.Errno(syscall.EINVAL)
+	}
+
+	if len(h.wbuf)+len(req.Data) > maxAccumulatedWrite {
+		h.wbuf = nil
+		return true, fuse.Errno(syscall.EFBIG)
+	}
+
+	h.wbuf = append(h.wbuf, req.Data...)
+
+	return true, nil
+}
+
+// Commits the write accumulated by accumulateWrite() (if any).
+func (h *Handle) commitPendingWrite(ctx context.Context) error {
+
+	h.Lock()
+	data, hdr := h.wbuf, h.whdr
+	h.wbuf = nil
+	h.Unlock()
+
+	if data == nil {
+		return nil
+	}
+
+	_, err := h.file.commitWrite(ctx, h, hdr, data)
+
+	return err
+}
@@ -0,0 +1,174 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package fuse
+
+import (
+	"context"
+	"io/ioutil"
+	"strings"
+	"syscall"
+	"testing"
+
+	"bazil.org/fuse"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/mocks"
+	"github.com/nestybox/sysbox-fs/sysio"
+)
+
+func TestHandle_splitWrite(t *testing.T) {
+
+	// Disable log generation during UT.
+	logrus.SetOutput(ioutil.Discard)
+
+	ios := sysio.NewIOService(domain.IOMemFileService)
+
+	// Payloads reaching the handler, which rejects the ones starting with
+	// "bad".
+	var committed []string
+
+	hds := &mocks.HandlerServiceIface{}
+	hds.On("LookupHandler", mock.Anything).Return(&roHandler{}, true)
+	hds.On("Invoke", mock.Anything).Return(func(call *domain.HandlerCall) error {
+		data := string(call.Req.Data)
+		committed = append(committed, data)
+		if strings.HasPrefix(data, "bad") {
+			return fuse.Errno(syscall.EINVAL)
+		}
+		call.N = len(data)
+		return nil
+	})
+
+	// Small payload limit, so that writes are split across several requests.
+	s := &fuseServer{
+		maxWrite: 4,
+		service:  &FuseServerService{ios: ios, hds: hds},
+	}
+
+	type write struct {
+		offset  int64
+		data    string
+		wantErr error
+	}
+
+	tests := []struct {
+		name          string
+		writes        []write
+		wantCommitted []string
+		wantFlushErr  error
+	}{
+		// Test-case 1: Write fitting in a single request.
+		{
+			name:          "1",
+			writes:        []write{{0, "abc", nil}},
+			wantCommitted: []string{"abc"},
+		},
+
+		// Test-case 2: Split write, handed over along its last request.
+		{
+			name: "2",
+			writes: []write{
+				{0, "abcd", nil},
+				{4, "efgh", nil},
+				{8, "ij", nil},
+			},
+			wantCommitted: []string{"abcdefghij"},
+		},
+
+		// Test-case 3: Split write rejected by the handler, which is reported
+		// through its last request.
+		{
+			name: "3",
+			writes: []write{
+				{0, "badd", nil},
+				{4, "efgh", nil},
+				{8, "ij", fuse.Errno(syscall.EINVAL)},
+			},
+			wantCommitted: []string{"baddefghij"},
+		},
+
+		// Test-case 4: Split write that is a multiple of the payload limit,
+		// handed over upon flush.
+		{
+			name: "4",
+			writes: []write{
+				{0, "abcd", nil},
+				{4, "efgh", nil},
+			},
+			wantCommitted: []string{"abcdefgh"},
+		},
+
+		// Test-case 5: Same as above, but rejected by the handler, which is
+		// reported through the flush.
+		{
+			name: "5",
+			writes: []write{
+				{0, "badd", nil},
+				{4, "efgh", nil},
+			},
+			wantCommitted: []string{"baddefgh"},
+			wantFlushErr:  fuse.Errno(syscall.EINVAL),
+		},
+
+		// Test-case 6: Requests out of order are rejected, and the write
+		// discarded.
+		{
+			name: "6",
+			writes: []write{
+				{0, "abcd", nil},
+				{8, "ij", fuse.Errno(syscall.EINVAL)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			committed = nil
+
+			f := NewFile(
+				"hostname",
+				"/proc/sys/kernel/hostname",
+				&fuse.Attr{Mode: 0644},
+				s)
+			h := newHandle(f, fuse.OpenWriteOnly)
+
+			for _, w := range tt.writes {
+				resp := &fuse.WriteResponse{}
+				err := h.Write(
+					context.Background(),
+					&fuse.WriteRequest{
+						Header: fuse.Header{Pid: 1001},
+						Offset: w.offset,
+						Data:   []byte(w.data),
+					},
+					resp)
+
+				assert.Equal(t, w.wantErr, err)
+				if err == nil {
+					assert.Equal(t, len(w.data), resp.Size)
+				}
+			}
+
+			err := h.Flush(context.Background(), &fuse.FlushRequest{})
+			assert.Equal(t, tt.wantFlushErr, err)
+			assert.Equal(t, tt.wantCommitted, committed)
+		})
+	}
+}
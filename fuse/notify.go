@@ -0,0 +1,95 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package fuse
+
+import (
+	"sync"
+
+	"bazil.org/fuse"
+)
+
+//
+// Keeps track of the changes of the resources' values within a sys container,
+// and of the clients polling for them. Much like the kernel does for some of
+// its sysctls (e.g. kernel.hostname), pollers are notified (POLLERR |
+// POLLPRI) of the value changes since they opened the resource (see
+// Handle.Poll()).
+//
+type changeNotifier struct {
+	sync.Mutex
+
+	// Change counter of every modified resource, indexed by path.
+	events map[string]uint64
+
+	// Clients to wake up upon the next change of a resource, indexed by path.
+	wakeups map[string][]fuse.PollWakeup
+}
+
+// Returns the change counter of the given resource.
+func (c *changeNotifier) event(path string) uint64 {
+	c.Lock()
+	defer c.Unlock()
+
+	return c.events[path]
+}
+
+// Registers a client to wake up upon the next change of the given resource.
+func (c *changeNotifier) watch(path string, w fuse.PollWakeup) {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.wakeups == nil {
+		c.wakeups = make(map[string][]fuse.PollWakeup)
+	}
+	c.wakeups[path] = append(c.wakeups[path], w)
+}
+
+// Records a change of the given resource, returning the clients to wake up.
+func (c *changeNotifier) notify(path string) []fuse.PollWakeup {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.events == nil {
+		c.events = make(map[string]uint64)
+	}
+	c.events[path]++
+
+	wakeups := c.wakeups[path]
+	delete(c.wakeups, path)
+
+	return wakeups
+}
+
+//
+// Notifies the pollers of the given resource that its value has changed
+// (e.g. written by a container process or through sysbox-fs' ipc interface).
+//
+func (s *fuseServer) NotifyChange(path string) {
+
+	wakeups := s.changes.notify(path)
+
+	server := s.server
+	if server == nil {
+		return
+	}
+
+	for _, w := range wakeups {
+		if err := server.NotifyPollWakeup(w); err != nil {
+			logger.Debugf("Unable to wake up pollers of %v: %v", path, err)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package fuse
+
+import (
+	"testing"
+
+	"bazil.org/fuse"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangeNotifier(t *testing.T) {
+
+	const path = "/proc/sys/kernel/hostname"
+
+	var c changeNotifier
+
+	// Untouched resources have no changes recorded.
+	assert.Equal(t, uint64(0), c.event(path))
+
+	// Pollers are woken up upon the next change only.
+	c.watch(path, fuse.PollWakeup{})
+	assert.Len(t, c.notify(path), 1)
+	assert.Equal(t, uint64(1), c.event(path))
+
+	assert.Len(t, c.notify(path), 0)
+	assert.Equal(t, uint64(2), c.event(path))
+
+	// Changes of other resources are tracked separately.
+	assert.Equal(t, uint64(0), c.event("/proc/sys/kernel/domainname"))
+}
@@ -17,20 +17,28 @@
 package fuse
 
 import (
+	"context"
 	"errors"
 	"os"
+	"path/filepath"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 
 	"bazil.org/fuse"
 	"bazil.org/fuse/fs"
 
 	_ "bazil.org/fuse/fs/fstestutil"
-	"github.com/sirupsen/logrus"
 
 	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/logging"
+	"github.com/nestybox/sysbox-fs/tracing"
 )
 
+// Logger of the fuse subsystem.
+var logger = logging.Subsystem(logging.Fuse)
+
 // FuseServer class in charge of running/hosting sysbox-fs' FUSE server features.
 type fuseServer struct {
 	sync.RWMutex                       // nodeDB protection
@@ -38,10 +46,14 @@ type fuseServer struct {
 	mountPoint   string                // mountpoint -- "/var/lib/sysboxfs" by default
 	container    domain.ContainerIface // associated sys container
 	server       *fs.Server            // bazil-fuse server instance
+	conn         *fuse.Conn            // fuse connection -- inherited during live-upgrades
+	maxWrite     int                   // max payload of write requests, as negotiated on conn
 	nodeDB       map[string]*fs.Node   // map to store all fs nodes, e.g. "/proc/uptime" -> File
 	root         *Dir                  // root node of fuse fs -- "/" by default
 	initDone     chan bool             // sync-up channel to alert about fuse-server's init-completion
 	service      *FuseServerService    // backpointer to parent service
+	changes      changeNotifier        // resources' value changes and their pollers
+	handles      int64                 // open file handles (atomically updated)
 }
 
 func NewFuseServer(
@@ -67,10 +79,10 @@ func (s *fuseServer) Create() error {
 	pathInfo, err := pathIOnode.Stat()
 	if err != nil {
 		if os.IsNotExist(err) {
-			logrus.Errorf("File-System path not found: %v", s.path)
+			logger.Errorf("File-System path not found: %v", s.path)
 			return err
 		} else {
-			logrus.Errorf("File-System path not accessible: %v", s.path)
+			logger.Errorf("File-System path not accessible: %v", s.path)
 			return err
 		}
 	}
@@ -84,10 +96,10 @@ func (s *fuseServer) Create() error {
 	_, err = mountPointIOnode.Stat()
 	if err != nil {
 		if os.IsNotExist(err) {
-			logrus.Errorf("File-System mountpoint not found: %v", s.mountPoint)
+			logger.Errorf("File-System mountpoint not found: %v", s.mountPoint)
 			return err
 		} else {
-			logrus.Errorf("File-System mountpoint not accessible: %v", s.mountPoint)
+			logger.Errorf("File-System mountpoint not accessible: %v", s.mountPoint)
 			return err
 		}
 	}
@@ -95,7 +107,7 @@ func (s *fuseServer) Create() error {
 	// Creating a first node corresponding to the root (dir) element in
 	// sysbox-fs.
 	var attr fuse.Attr
-	if s.service.ios.GetServiceType() == domain.IOMemFileService {
+	if s.service.ios.GetServiceType() != domain.IOOsFileService {
 		attr = fuse.Attr{}
 	} else {
 		attr = statToAttr(pathInfo.Sys().(*syscall.Stat_t))
@@ -123,16 +135,25 @@ func (s *fuseServer) Run() error {
 	// its own permission check, instead of deferring all permission checking
 	// to sysbox-fs filesystem.
 	//
-	c, err := fuse.Mount(
-		s.mountPoint,
-		fuse.FSName("sysboxfs"),
-		fuse.AllowOther(),
-		fuse.DefaultPermissions(),
-	)
-	if err != nil {
-		logrus.Fatal(err)
-		return err
+	// During live-upgrades the fuse connection is inherited from the previous
+	// sysbox-fs instance, so there's no need to mount anything.
+	//
+	c := s.conn
+	if c == nil {
+		var err error
+		c, err = fuse.Mount(
+			s.mountPoint,
+			fuse.FSName("sysboxfs"),
+			fuse.AllowOther(),
+			fuse.DefaultPermissions(),
+		)
+		if err != nil {
+			logger.Fatal(err)
+			return err
+		}
+		s.conn = c
 	}
+	s.maxWrite = int(c.MaxWrite())
 
 	// Deferred routine to enforce a clean exit should an unrecoverable error is
 	// ever returned from fuse-lib.
@@ -142,14 +163,14 @@ func (s *fuseServer) Run() error {
 	}()
 
 	if p := c.Protocol(); !p.HasInvalidate() {
-		logrus.Panic("Kernel FUSE support is too old to have invalidations: version ", p)
-		return err
+		logger.Panic("Kernel FUSE support is too old to have invalidations: version ", p)
+		return errors.New("FUSE invalidations not supported")
 	}
 
 	// Creating a FUSE server to drive kernel interactions.
 	s.server = fs.New(c, nil)
 	if s.server == nil {
-		logrus.Panic("FUSE file-system could not be created")
+		logger.Panic("FUSE file-system could not be created")
 		return errors.New("FUSE file-system could not be created")
 	}
 
@@ -159,14 +180,14 @@ func (s *fuseServer) Run() error {
 
 	// Launch fuse-server's main-loop to handle incoming requests.
 	if err := s.server.Serve(s); err != nil {
-		logrus.Panic(err)
+		logger.Panic(err)
 		return err
 	}
 
 	// Return if any error is reported by mount logic.
 	<-c.Ready
 	if err := c.MountError; err != nil {
-		logrus.Panic(err)
+		logger.Panic(err)
 		return err
 	}
 
@@ -178,7 +199,7 @@ func (s *fuseServer) Destroy() error {
 	// Unmount sysboxfs from mountpoint.
 	err := fuse.Unmount(s.mountPoint)
 	if err != nil {
-		logrus.Errorf("FUSE file-system could not be unmounted: %v", err)
+		logger.Errorf("FUSE file-system could not be unmounted: %v", err)
 		return err
 	}
 
@@ -215,3 +236,223 @@ func (s *fuseServer) Unmount() {
 
 	fuse.Unmount(s.mountPoint)
 }
+
+// Drops the cached attributes of the given resources, both from nodeDB and
+// from the kernel's dentry and page caches, so that they are looked up and read
+// afresh by the handlers upon the next access (e.g. their size and content
+// reflect the container's updated resource limits).
+func (s *fuseServer) InvalidateNodes(paths ...string) {
+
+	type entry struct {
+		node   fs.Node
+		parent fs.Node
+		name   string
+	}
+	var entries []entry
+
+	s.Lock()
+	for _, p := range paths {
+		node, ok := s.nodeDB[p]
+		if !ok {
+			continue
+		}
+		delete(s.nodeDB, p)
+
+		e := entry{node: *node, name: filepath.Base(p)}
+		if parent, ok := s.nodeDB[filepath.Dir(p)]; ok {
+			e.parent = *parent
+		} else if s.root != nil && filepath.Dir(p) == s.root.path {
+			e.parent = s.root
+		}
+		entries = append(entries, e)
+	}
+	server := s.server
+	s.Unlock()
+
+	if server == nil {
+		return
+	}
+
+	for _, e := range entries {
+		if e.parent != nil {
+			err := server.InvalidateEntry(e.parent, e.name)
+			if err != nil && err != fuse.ErrNotCached {
+				logger.Debugf("Unable to invalidate dentry of %v: %v", e.name, err)
+			}
+		}
+		err := server.InvalidateNodeData(e.node)
+		if err != nil && err != fuse.ErrNotCached {
+			logger.Debugf("Unable to invalidate data of %v: %v", e.name, err)
+		}
+	}
+}
+
+// Drops the cached node attributes, so that they are looked up afresh by the
+// handlers upon the next request.
+func (s *fuseServer) InvalidateCache() {
+
+	s.Lock()
+	s.nodeDB = make(map[string]*fs.Node)
+	s.Unlock()
+}
+
+// Number of nodes cached in nodeDB, and of open file handles.
+func (s *fuseServer) usage() (int, int) {
+
+	s.RLock()
+	nodes := len(s.nodeDB)
+	s.RUnlock()
+
+	return nodes, int(atomic.LoadInt64(&s.handles))
+}
+
+// override returns the override (if any) that sysbox-mgr defined for the given
+// resource within the associated container.
+func (s *fuseServer) override(path string) (domain.NodeOverride, bool) {
+	if s.container == nil {
+		return domain.NodeOverride{}, false
+	}
+
+	return s.container.Override(path)
+}
+
+// hidden reports whether the given resource is not visible within the
+// associated container, either due to an override or to the container's
+// mount options.
+func (s *fuseServer) hidden(path string) bool {
+	if s.container == nil {
+		return false
+	}
+
+	if o, ok := s.container.Override(path); ok && o.Hide {
+		return true
+	}
+
+	return s.container.MountOptions().IsHidden(path)
+}
+
+// readOnly reports whether the emulated view of the associated container is
+// mounted read-only.
+func (s *fuseServer) readOnly() bool {
+	if s.container == nil {
+		return false
+	}
+
+	return s.container.MountOptions().ReadOnly
+}
+
+//
+// nodeMode returns the mode with which the regular node at the given path is
+// exposed. Resources served by a dedicated handler don't inherit the host's
+// permission bits, which may not reflect the emulated behavior: handlers can
+// state their own (e.g. 0444 for read-only files), and emulated sysctls are
+// otherwise presented as writable by their owner (0644). Nodes backed by the
+// common handlers keep the host's bits.
+//
+func (s *fuseServer) nodeMode(path string, mode os.FileMode) os.FileMode {
+
+	if mode.IsDir() {
+		return mode
+	}
+
+	h, ok := s.service.hds.FindHandler(path)
+	if !ok {
+		return mode
+	}
+
+	if m, ok := h.(domain.NodeModeIface); ok {
+		return mode&^os.ModePerm | m.NodeMode()&os.ModePerm
+	}
+
+	if strings.HasPrefix(path, "/proc/sys/") {
+		return mode&^os.ModePerm | 0644
+	}
+
+	return mode
+}
+
+//
+// originValid verifies that the process originating a request lives within the
+// sys container associated with this server, so that neither host processes
+// nor sibling containers can access the container's emulated state through the
+// mountpoint. The requester's user-ns and pid-ns must be the container's ones
+// or descendants of them (e.g. for L2 containers, which may share the
+// container's user-ns while having their own pid-ns). Requests received
+// before the container's registration can't be verified, and are thereby let
+// through.
+//
+func (s *fuseServer) originValid(pid uint32) bool {
+
+	if s.container == nil || s.container.InitProc() == nil {
+		return true
+	}
+
+	cntrNs, err := s.container.InitProc().NsInodes()
+	if err != nil {
+		logger.Errorf("Could not obtain the namespaces of container %v: %v",
+			s.container.ID(), err)
+		return false
+	}
+
+	process := s.service.hds.ProcessService().ProcessCreate(pid, 0, 0)
+
+	reqNs, err := process.NsInodes()
+	if err != nil {
+		logger.Debugf("Could not obtain the namespaces of pid %v: %v", pid, err)
+		return false
+	}
+
+	userNs := string(domain.NStypeUser)
+	pidNs := string(domain.NStypePid)
+
+	if reqNs[userNs] != cntrNs[userNs] {
+		ancestors, err := process.UserNsInodeAncestors()
+		within := false
+		for _, inode := range ancestors {
+			if inode == cntrNs[userNs] {
+				within = true
+				break
+			}
+		}
+		if err != nil || !within {
+			logger.Warnf("Request from pid %v rejected: user-ns %v not within container %v",
+				pid, reqNs[userNs], s.container.ID())
+			return false
+		}
+	}
+
+	if reqNs[pidNs] != cntrNs[pidNs] {
+		ok, err := process.PidNsWithin(cntrNs[pidNs])
+		if err != nil || !ok {
+			logger.Warnf("Request from pid %v rejected: pid-ns %v not within container %v",
+				pid, reqNs[pidNs], s.container.ID())
+			return false
+		}
+	}
+
+	return true
+}
+
+//
+// Starts the root span of a fuse request, to be ended once the request has
+// been fully served.
+//
+func (s *fuseServer) startSpan(
+	ctx context.Context,
+	op domain.FuseOp,
+	path string,
+	pid uint32) (context.Context, *tracing.Span) {
+
+	ctx, span := tracing.Start(ctx, "fuse."+op.String())
+	if span == nil {
+		return ctx, nil
+	}
+
+	span.SetAttribute(logging.FieldPath, path)
+	span.SetAttribute(logging.FieldPid, pid)
+	if s.container != nil {
+		span.SetAttribute(logging.FieldContainerID, s.container.ID())
+	}
+
+	return ctx, span
+}
@@ -0,0 +1,339 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package fuse
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"bazil.org/fuse"
+	"bazil.org/fuse/fs"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/mocks"
+	"github.com/nestybox/sysbox-fs/process"
+	"github.com/nestybox/sysbox-fs/sysio"
+)
+
+func Test_fuseServer_originValid(t *testing.T) {
+
+	// Disable log generation during UT.
+	logrus.SetOutput(ioutil.Discard)
+
+	ios := sysio.NewIOService(domain.IOMemFileService)
+	prs := process.NewProcessService()
+	prs.Setup(ios)
+
+	hds := &mocks.HandlerServiceIface{}
+	hds.On("ProcessService").Return(prs)
+
+	// Sys container's init process and requesters living within the
+	// container's namespaces and outside of them (pid far beyond the range
+	// of any real process).
+	initProc := prs.ProcessCreate(1001, 0, 0)
+	initProc.CreateNsInodes(123456)
+	prs.ProcessCreate(2002, 0, 0).CreateNsInodes(123456)
+	prs.ProcessCreate(4194303, 0, 0).CreateNsInodes(777777)
+
+	cntr := &mocks.ContainerIface{}
+	cntr.On("ID").Return("c1")
+
+	s := &fuseServer{
+		container: cntr,
+		service:   &FuseServerService{ios: ios, hds: hds},
+	}
+
+	// Requests received prior to the container's registration are let
+	// through.
+	cntr.On("InitProc").Return(nil).Once()
+	assert.True(t, s.originValid(4194303))
+
+	cntr.On("InitProc").Return(initProc)
+
+	// Requests originated within the container.
+	assert.True(t, s.originValid(1001))
+	assert.True(t, s.originValid(2002))
+
+	// Requests originated outside the container.
+	assert.False(t, s.originValid(4194303))
+}
+
+func Test_fuseServer_originValidNestedPidNs(t *testing.T) {
+
+	// Disable log generation during UT.
+	logrus.SetOutput(ioutil.Discard)
+
+	// Requester two pid-ns levels below the sys container's one (i.e. ours),
+	// within the container's user-ns, as an inner docker container's process
+	// (without userns-remap) would be. The unshare process is the init of the
+	// first level, and forks sleep into the second one.
+	cmd := exec.Command("unshare", "-p", "-f", "sleep", "30")
+	cmd.SysProcAttr = &syscall.SysProcAttr{Cloneflags: syscall.CLONE_NEWPID}
+	if err := cmd.Start(); err != nil {
+		t.Skipf("could not create nested pid-ns: %v", err)
+	}
+	defer func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+
+	pid := cmd.Process.Pid
+
+	var child int
+
+	for i := 0; ; i++ {
+		children, _ := ioutil.ReadFile(fmt.Sprintf("/proc/%d/task/%d/children", pid, pid))
+		fmt.Sscan(string(children), &child)
+		if child != 0 {
+			comm, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/comm", child))
+			if err == nil && strings.TrimSpace(string(comm)) == "sleep" {
+				break
+			}
+		}
+		if i == 100 {
+			t.Skipf("could not create nested pid-ns")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	nsInode := func(pid int, ns string) domain.Inode {
+		var st syscall.Stat_t
+		if err := syscall.Stat(fmt.Sprintf("/proc/%d/ns/%s", pid, ns), &st); err != nil {
+			t.Fatalf("failed to stat %s-ns of pid %d: %v", ns, pid, err)
+		}
+		return st.Ino
+	}
+
+	ios := sysio.NewIOService(domain.IOMemFileService)
+	prs := process.NewProcessService()
+	prs.Setup(ios)
+
+	hds := &mocks.HandlerServiceIface{}
+	hds.On("ProcessService").Return(prs)
+
+	// The namespaces' inodes are served out of the in-memory file-system,
+	// whereas the pid-ns hierarchy is walked through the actual one.
+	setNsInodes := func(pid int, userNs, pidNs domain.Inode) domain.ProcessIface {
+		p := prs.ProcessCreate(uint32(pid), 0, 0)
+		p.CreateNsInodes(userNs)
+		nsPath := "/proc/" + strconv.Itoa(pid) + "/ns/pid"
+		ios.NewIOnode("", nsPath, 0).WriteFile([]byte(strconv.FormatUint(pidNs, 10)))
+		return p
+	}
+
+	self := os.Getpid()
+	userNs := nsInode(self, "user")
+
+	cntr := &mocks.ContainerIface{}
+	cntr.On("ID").Return("c1")
+
+	s := &fuseServer{
+		container: cntr,
+		service:   &FuseServerService{ios: ios, hds: hds},
+	}
+
+	// Sys container within our namespaces: requests from the nested pid-ns
+	// are let through.
+	initProc := setNsInodes(self, userNs, nsInode(self, "pid"))
+	setNsInodes(child, userNs, nsInode(child, "pid"))
+
+	cntr.On("InitProc").Return(initProc).Twice()
+	assert.True(t, s.originValid(uint32(child)))
+
+	// Sys container within the nested pid-ns: requests from its ancestors
+	// are rejected.
+	initProc = setNsInodes(child, userNs, nsInode(child, "pid"))
+	setNsInodes(pid, userNs, nsInode(pid, "pid"))
+
+	cntr.On("InitProc").Return(initProc).Twice()
+	assert.False(t, s.originValid(uint32(pid)))
+}
+
+// Handler of a read-only resource.
+type roHandler struct {
+	mocks.HandlerIface
+}
+
+func (h *roHandler) NodeMode() os.FileMode {
+	return 0444
+}
+
+func Test_fuseServer_nodeMode(t *testing.T) {
+
+	hds := &mocks.HandlerServiceIface{}
+	hds.On("FindHandler", "/proc/sys/kernel/cap_last_cap").Return(&roHandler{}, true)
+	hds.On("FindHandler", "/proc/sys/kernel/panic").Return(&mocks.HandlerIface{}, true)
+	hds.On("FindHandler", "/proc/uptime").Return(&roHandler{}, true)
+	hds.On("FindHandler", "/sys/module/nf_conntrack/parameters/hashsize").Return(&mocks.HandlerIface{}, true)
+	hds.On("FindHandler", "/proc/sys/kernel/threads-max").Return(nil, false)
+
+	s := &fuseServer{
+		service: &FuseServerService{hds: hds},
+	}
+
+	tests := []struct {
+		name string
+		path string
+		mode os.FileMode
+		want os.FileMode
+	}{
+		// Test-case 1: Read-only emulated sysctl.
+		{"1", "/proc/sys/kernel/cap_last_cap", 0644, 0444},
+
+		// Test-case 2: Writable emulated sysctl.
+		{"2", "/proc/sys/kernel/panic", 0600, 0644},
+
+		// Test-case 3: Read-only emulated procfs file.
+		{"3", "/proc/uptime", 0644, 0444},
+
+		// Test-case 4: Emulated resource outside of /proc/sys keeps the
+		// host's permission bits.
+		{"4", "/sys/module/nf_conntrack/parameters/hashsize", 0600, 0600},
+
+		// Test-case 5: Non-emulated resource keeps the host's permission bits.
+		{"5", "/proc/sys/kernel/threads-max", 0644, 0644},
+
+		// Test-case 6: Directories are left untouched.
+		{"6", "/proc/sys/kernel/cap_last_cap", os.ModeDir | 0555, os.ModeDir | 0555},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, s.nodeMode(tt.path, tt.mode))
+		})
+	}
+}
+
+func Test_fuseServer_hidden(t *testing.T) {
+
+	cntr := &mocks.ContainerIface{}
+	cntr.On("Override", "/proc/sys/kernel/osrelease").Return(
+		domain.NodeOverride{Hide: true}, true)
+	cntr.On("Override", "/proc/sys/kernel/hostname").Return(
+		domain.NodeOverride{Value: "foo"}, true)
+	cntr.On("Override", mock.Anything).Return(domain.NodeOverride{}, false)
+	cntr.On("MountOptions").Return(domain.MountOptions{
+		ReadOnly:    true,
+		HiddenPaths: []string{"/proc/sys/kernel/random/"},
+	})
+
+	s := &fuseServer{
+		container: cntr,
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want bool
+	}{
+		// Test-case 1: Resource hidden through an override.
+		{"1", "/proc/sys/kernel/osrelease", true},
+
+		// Test-case 2: Overridden resource that isn't hidden.
+		{"2", "/proc/sys/kernel/hostname", false},
+
+		// Test-case 3: Root of a hidden subtree.
+		{"3", "/proc/sys/kernel/random", true},
+
+		// Test-case 4: Resource within a hidden subtree.
+		{"4", "/proc/sys/kernel/random/boot_id", true},
+
+		// Test-case 5: Sibling sharing the hidden subtree's prefix.
+		{"5", "/proc/sys/kernel/randomize_va_space", false},
+
+		// Test-case 6: Resource outside of the hidden subtrees.
+		{"6", "/proc/sys/kernel/panic", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, s.hidden(tt.path))
+		})
+	}
+
+	assert.True(t, s.readOnly())
+
+	// Requests received by servers not yet associated with a container.
+	s = &fuseServer{}
+	assert.False(t, s.hidden("/proc/sys/kernel/osrelease"))
+	assert.False(t, s.readOnly())
+}
+
+func Test_fuseServer_InvalidateNodes(t *testing.T) {
+
+	// Disable log generation during UT.
+	logrus.SetOutput(ioutil.Discard)
+
+	ios := sysio.NewIOService(domain.IOMemFileService)
+
+	// Size reported by the handler for /proc/meminfo, which changes once the
+	// container's memory limit is updated.
+	var size int64 = 1024
+
+	hds := &mocks.HandlerServiceIface{}
+	hds.On("FindUserNsInode", uint32(1001)).Return(uint64(123456), nil)
+	hds.On("HostUserNsInode").Return(uint64(123456))
+	hds.On("FindHandler", "/proc/meminfo").Return(&roHandler{}, true)
+	hds.On("LookupHandler", mock.Anything).Return(&roHandler{}, true)
+	hds.On("Invoke", mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		call := args.Get(0).(*domain.HandlerCall)
+		call.Info = &domain.FileInfo{
+			Fname: "meminfo",
+			Fsize: size,
+			Fsys:  &syscall.Stat_t{Size: size, Mode: 0444},
+		}
+	})
+
+	s := &fuseServer{
+		path:    "/",
+		nodeDB:  make(map[string]*fs.Node),
+		service: &FuseServerService{ios: ios, hds: hds},
+	}
+	s.root = NewDir("/", "/", &fuse.Attr{Mode: os.ModeDir | 0555}, s)
+	proc := NewDir("proc", "/proc", &fuse.Attr{Mode: os.ModeDir | 0555}, s)
+
+	lookup := func() *File {
+		node, err := proc.Lookup(
+			context.Background(),
+			&fuse.LookupRequest{Header: fuse.Header{Pid: 1001}, Name: "meminfo"},
+			&fuse.LookupResponse{},
+		)
+		assert.NoError(t, err)
+		return node.(*File)
+	}
+
+	assert.Equal(t, uint64(1024), lookup().attr.Size)
+
+	// Attributes are served from nodeDB, and thereby are stale, until the
+	// node is invalidated.
+	size = 2048
+	assert.Equal(t, uint64(1024), lookup().attr.Size)
+
+	s.InvalidateNodes("/proc/meminfo", "/proc/cpuinfo")
+	assert.Equal(t, uint64(2048), lookup().attr.Size)
+}
@@ -18,14 +18,19 @@ package fuse
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
+	"time"
 
+	"bazil.org/fuse"
 	_ "bazil.org/fuse/fs/fstestutil"
+	"golang.org/x/sys/unix"
 
+	"github.com/nestybox/sysbox-fs/crash"
 	"github.com/nestybox/sysbox-fs/domain"
-	"github.com/sirupsen/logrus"
 )
 
 type FuseServerService struct {
@@ -33,6 +38,7 @@ type FuseServerService struct {
 	path         string                            // fs path to emulate -- "/" by default
 	mountPoint   string                            // base mountpoint -- "/var/lib/sysboxfs" by default
 	serversMap   map[string]*fuseServer            // tracks created fuse-servers
+	connsMap     map[string]*fuse.Conn             // fuse-conns inherited during live-upgrades
 	css          domain.ContainerStateServiceIface // containerState service pointer
 	ios          domain.IOServiceIface             // i/o service pointer
 	hds          domain.HandlerServiceIface        // handler service pointer
@@ -43,6 +49,7 @@ func NewFuseServerService() *FuseServerService {
 
 	newServerService := &FuseServerService{
 		serversMap: make(map[string]*fuseServer),
+		connsMap:   make(map[string]*fuse.Conn),
 	}
 
 	return newServerService
@@ -68,6 +75,23 @@ func (fss *FuseServerService) DestroyFuseService() {
 	}
 }
 
+//
+// Lazily unmounts all the fuse-servers. Meant for emergency shutdowns, where
+// regular unmounts would fail as the mountpoints are still being referenced
+// by the sys containers. Once sysbox-fs exits, the containers' sysbox-fs
+// mounts report errors instead of blocking on a dead fuse-server.
+//
+func (fss *FuseServerService) AbortFuseService() {
+
+	// No locking here, as the lock could be held by the goroutine whose
+	// failure led us here.
+	for _, srv := range fss.serversMap {
+		if err := unix.Unmount(srv.mountPoint, unix.MNT_DETACH); err != nil {
+			logger.Errorf("Unable to unmount %s: %v", srv.mountPoint, err)
+		}
+	}
+}
+
 // Creates new fuse-server.
 func (fss *FuseServerService) CreateFuseServer(cntr domain.ContainerIface) error {
 
@@ -77,7 +101,7 @@ func (fss *FuseServerService) CreateFuseServer(cntr domain.ContainerIface) error
 	fss.RLock()
 	if _, ok := fss.serversMap[cntrId]; ok {
 		fss.RUnlock()
-		logrus.Errorf("FuseServer to create is already present for container id %s",
+		logger.Errorf("FuseServer to create is already present for container id %s",
 			cntrId)
 		return errors.New("FuseServer already present")
 	}
@@ -102,9 +126,18 @@ func (fss *FuseServerService) CreateFuseServer(cntr domain.ContainerIface) error
 		return errors.New("FuseServer initialization error")
 	}
 
+	// Pick up the fuse-conn inherited from a previous sysbox-fs instance (if
+	// any).
+	fss.Lock()
+	if conn, ok := fss.connsMap[cntrId]; ok {
+		srv.(*fuseServer).conn = conn
+		delete(fss.connsMap, cntrId)
+	}
+	fss.Unlock()
+
 	// Launch fuse-server in a separate goroutine and wait for 'ack' before
 	// moving on.
-	go srv.Run()
+	crash.Go("fuse-server", func() { srv.Run() })
 	srv.InitWait()
 
 	// Store newly created fuse-server.
@@ -123,7 +156,7 @@ func (fss *FuseServerService) DestroyFuseServer(cntrId string) error {
 	srv, ok := fss.serversMap[cntrId]
 	if !ok {
 		fss.RUnlock()
-		logrus.Errorf("FuseServer to destroy is not present for container id %s",
+		logger.Errorf("FuseServer to destroy is not present for container id %s",
 			cntrId)
 		return nil
 	}
@@ -131,7 +164,7 @@ func (fss *FuseServerService) DestroyFuseServer(cntrId string) error {
 
 	// Destroy fuse-server.
 	if err := srv.Destroy(); err != nil {
-		logrus.Errorf("FuseServer to destroy could not be eliminated for container id %s",
+		logger.Errorf("FuseServer to destroy could not be eliminated for container id %s",
 			cntrId)
 		return nil
 	}
@@ -139,7 +172,7 @@ func (fss *FuseServerService) DestroyFuseServer(cntrId string) error {
 	// Remove mountpoint dir from host file-system.
 	cntrMountpoint := filepath.Join(fss.mountPoint, cntrId)
 	if err := os.Remove(cntrMountpoint); err != nil {
-		logrus.Errorf("FuseServer mountpoint could not be eliminated for container id %s",
+		logger.Errorf("FuseServer mountpoint could not be eliminated for container id %s",
 			cntrId)
 		return nil
 	}
@@ -151,3 +184,214 @@ func (fss *FuseServerService) DestroyFuseServer(cntrId string) error {
 
 	return nil
 }
+
+//
+// Drops the cached attributes of the given resources within the fuse-server of
+// the specified container.
+//
+func (fss *FuseServerService) InvalidateNodes(cntrId string, paths []string) error {
+
+	fss.RLock()
+	srv, ok := fss.serversMap[cntrId]
+	fss.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("no fuse-server found for container id %s", cntrId)
+	}
+
+	srv.InvalidateNodes(paths...)
+
+	return nil
+}
+
+// Collect the fuse-conns of all the running fuse-servers so that they can be
+// handed off to a new sysbox-fs instance.
+func (fss *FuseServerService) ExportFuseConns() []domain.FuseConnState {
+
+	fss.RLock()
+	defer fss.RUnlock()
+
+	var conns []domain.FuseConnState
+
+	for cntrId, srv := range fss.serversMap {
+		if srv.conn == nil {
+			continue
+		}
+
+		proto := srv.conn.Protocol()
+
+		conns = append(conns, domain.FuseConnState{
+			ContainerID: cntrId,
+			Dev:         srv.conn.Dev(),
+			ProtoMajor:  proto.Major,
+			ProtoMinor:  proto.Minor,
+			MaxWrite:    srv.conn.MaxWrite(),
+		})
+	}
+
+	return conns
+}
+
+// Import the fuse-conns handed off by a previous sysbox-fs instance. These
+// will be picked up by the fuse-servers to be created for each container.
+func (fss *FuseServerService) ImportFuseConns(conns []domain.FuseConnState) error {
+
+	fss.Lock()
+	defer fss.Unlock()
+
+	for _, cs := range conns {
+		conn, err := fuse.Resume(
+			cs.Dev,
+			fuse.Protocol{Major: cs.ProtoMajor, Minor: cs.ProtoMinor},
+			cs.MaxWrite,
+		)
+		if err != nil {
+			logger.Errorf("FuseServer connection could not be resumed for container id %s: %v",
+				cs.ContainerID, err)
+			return err
+		}
+
+		fss.connsMap[cs.ContainerID] = conn
+	}
+
+	return nil
+}
+
+// Release the inherited fuse-conns that haven't been claimed by any
+// fuse-server (i.e. their containers are gone).
+func (fss *FuseServerService) DiscardFuseConns() {
+
+	fss.Lock()
+	defer fss.Unlock()
+
+	for cntrId, conn := range fss.connsMap {
+		cntrMountpoint := filepath.Join(fss.mountPoint, cntrId)
+
+		fuse.Unmount(cntrMountpoint)
+		conn.Close()
+
+		if err := os.Remove(cntrMountpoint); err != nil {
+			logger.Errorf("FuseServer mountpoint could not be eliminated for container id %s",
+				cntrId)
+		}
+
+		delete(fss.connsMap, cntrId)
+	}
+}
+
+//
+// Drops the node-attributes cache of the fuse-server of the given container,
+// or of all fuse-servers if no container is specified.
+//
+func (fss *FuseServerService) InvalidateCache(cntrId string) error {
+
+	fss.RLock()
+	defer fss.RUnlock()
+
+	if cntrId != "" {
+		srv, ok := fss.serversMap[cntrId]
+		if !ok {
+			return fmt.Errorf("no fuse-server found for container id %s", cntrId)
+		}
+		srv.InvalidateCache()
+		return nil
+	}
+
+	for _, srv := range fss.serversMap {
+		srv.InvalidateCache()
+	}
+
+	return nil
+}
+
+//
+// Notifies the pollers of the given resource within a sys container that its
+// value has changed.
+//
+func (fss *FuseServerService) NotifyChange(cntrId string, path string) error {
+
+	fss.RLock()
+	srv, ok := fss.serversMap[cntrId]
+	fss.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("no fuse-server found for container id %s", cntrId)
+	}
+
+	srv.NotifyChange(path)
+
+	return nil
+}
+
+// Reports the resources held by each fuse-server, sorted by container id.
+func (fss *FuseServerService) Usage() []domain.FuseServerUsage {
+
+	fss.RLock()
+	defer fss.RUnlock()
+
+	usage := make([]domain.FuseServerUsage, 0, len(fss.serversMap))
+
+	for cntrId, srv := range fss.serversMap {
+		nodes, handles := srv.usage()
+		usage = append(usage, domain.FuseServerUsage{
+			ContainerID: cntrId,
+			Nodes:       nodes,
+			Handles:     handles,
+		})
+	}
+
+	sort.Slice(usage, func(i, j int) bool {
+		return usage[i].ContainerID < usage[j].ContainerID
+	})
+
+	return usage
+}
+
+//
+// Verifies that the fuse-servers are responsive by issuing a readdir request
+// against each of their mountpoints, which forces a round-trip through their
+// event loops. Requests not completed within 'timeout' are reported as
+// errors.
+//
+func (fss *FuseServerService) HealthCheck(timeout time.Duration) error {
+
+	fss.RLock()
+	mps := make(map[string]string, len(fss.serversMap))
+	for id, srv := range fss.serversMap {
+		mps[id] = srv.mountPoint
+	}
+	fss.RUnlock()
+
+	if len(mps) == 0 {
+		return nil
+	}
+
+	// Notice that a wedged fuse-server would also block the goroutine probing
+	// it, which is left behind as there's no way to cancel the request.
+	done := make(chan string, len(mps))
+	for id, mp := range mps {
+		go func(id, mp string) {
+			if f, err := os.Open(mp); err == nil {
+				f.Readdirnames(-1)
+				f.Close()
+			}
+			done <- id
+		}(id, mp)
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for n := len(mps); n > 0; n-- {
+		select {
+		case id := <-done:
+			delete(mps, id)
+		case <-timer.C:
+			for id := range mps {
+				return fmt.Errorf("fuse-server for container %s is unresponsive", id)
+			}
+		}
+	}
+
+	return nil
+}
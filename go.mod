@@ -1,6 +1,6 @@
 module github.com/nestybox/sysbox-fs
 
-go 1.13
+go 1.18
 
 require (
 	bazil.org/fuse v0.0.0-20180421153158-65cc252bf669
@@ -13,16 +13,46 @@ require (
 	github.com/pkg/profile v1.4.0
 	github.com/sirupsen/logrus v1.4.2
 	github.com/spf13/afero v1.2.2
-	github.com/stretchr/objx v0.2.0 // indirect
-	github.com/stretchr/testify v1.5.1
+	github.com/stretchr/testify v1.8.2
 	github.com/urfave/cli v1.20.0
 	github.com/vektra/mockery v1.1.2 // indirect
 	github.com/vishvananda/netlink v1.0.0
-	golang.org/x/sys v0.0.0-20200420163511-1957bb5e6d1f
+	go.opentelemetry.io/otel v1.14.0
+	go.opentelemetry.io/otel/exporters/stdout/stdouttrace v1.14.0
+	go.opentelemetry.io/otel/sdk v1.14.0
+	go.opentelemetry.io/otel/trace v1.14.0
+	golang.org/x/sys v0.5.0
 	google.golang.org/grpc v1.27.0
 	gopkg.in/hlandau/service.v1 v1.0.7
+	gopkg.in/yaml.v2 v2.2.2
 )
 
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/go-logr/logr v1.2.3 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/golang/protobuf v1.4.1 // indirect
+	github.com/konsorten/go-windows-terminal-sequences v1.0.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/stretchr/objx v0.5.0 // indirect
+	github.com/vishvananda/netns v0.0.0-20200520041808-52d707b772fe // indirect
+	golang.org/x/net v0.0.0-20200625001655-4c5254603344 // indirect
+	golang.org/x/text v0.3.0 // indirect
+	google.golang.org/genproto v0.0.0-20190819201941-24fa4b261c55 // indirect
+	google.golang.org/protobuf v1.22.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+// sysbox-ipc is built from the sibling checkout, whose revision is pinned by
+// the sysbox superproject. It must carry the sysbox-fs protocol extensions
+// the ipc package relies on: the container metadata, presence flags, mount
+// options, reboot, op-stats and health-report fields of ContainerData (along
+// with IDMapping, ContainerOpStats, NodeOverride and HandlerInfo), the
+// ContainerQuery, ContainerStateExport, ContainerStateImport, Handshake,
+// Health, Drain, Undrain, ContainerList, ContainerInspect, ContainerOverride,
+// ContainerSet, ContainerPolicy, HandlerList, CacheInvalidate and
+// ContainerReboot messages, NewServerWithCreds(), Server.InitAt() and
+// SendMessage().
 replace github.com/nestybox/sysbox-ipc => ../sysbox-ipc
 
 replace github.com/nestybox/sysbox-runc => ../sysbox-runc
@@ -39,6 +69,11 @@ replace github.com/nestybox/sysbox-libs/capability => ../sysbox-libs/capability
 
 replace github.com/opencontainers/runc => ./../sysbox-runc
 
+// bazil is the nestybox/fuse submodule; its pinned revision must export the
+// live-upgrade primitives fuse.Resume() (which takes the negotiated max write
+// size, zero standing for the library's default) and Conn.Dev(), the
+// negotiated Conn.MaxWrite(), as well as the poll support (PollRequest,
+// PollWakeup and Server.NotifyPollWakeup()).
 replace bazil.org/fuse => ./bazil
 
 replace github.com/godbus/dbus => github.com/godbus/dbus/v5 v5.0.3
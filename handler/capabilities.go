@@ -0,0 +1,84 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package handler
+
+import (
+	"strings"
+
+	"github.com/nestybox/sysbox-fs/domain"
+)
+
+//
+// Capabilities demanded by the kernel from the writers of the sysctls emulated
+// by sysbox-fs, indexed by path prefix. As the emulated values are kept by
+// sysbox-fs (or pushed to the host by sysbox-fs itself), the kernel doesn't
+// get a chance to carry out these checks on its own. Handlers can override
+// these defaults through domain.WriteCapabilitiesIface.
+//
+var writeCapsByPrefix = []struct {
+	prefix string
+	caps   []domain.Capability
+}{
+	{"/proc/sys/net/", []domain.Capability{domain.CAP_NET_ADMIN}},
+	{"/proc/sys/kernel/", []domain.Capability{domain.CAP_SYS_ADMIN}},
+	{"/proc/sys/fs/", []domain.Capability{domain.CAP_SYS_ADMIN}},
+	{"/proc/sys/vm/", []domain.Capability{domain.CAP_SYS_ADMIN}},
+}
+
+// Returns the capabilities required to write into the resources of the given
+// handler.
+func writeCapabilities(h domain.HandlerIface) []domain.Capability {
+
+	if wc, ok := h.(domain.WriteCapabilitiesIface); ok {
+		return wc.WriteCapabilities()
+	}
+
+	for _, e := range writeCapsByPrefix {
+		if strings.HasPrefix(h.GetPath(), e.prefix) {
+			return e.caps
+		}
+	}
+
+	return nil
+}
+
+//
+// Reports whether the process originating the given request holds all the
+// capabilities required to write into the resources of the given handler.
+// Capabilities are obtained from the process' /proc/<pid>/status file.
+//
+func (hs *handlerService) WriteAllowed(
+	h domain.HandlerIface,
+	req *domain.HandlerRequest) bool {
+
+	caps := writeCapabilities(h)
+	if len(caps) == 0 {
+		return true
+	}
+
+	process := hs.prs.ProcessCreate(req.Pid, req.Uid, req.Gid)
+
+	for _, c := range caps {
+		if !process.IsCapabilitySet(c) {
+			logger.Debugf("Write on %v denied to pid %v: capability %v not set",
+				h.GetPath(), req.Pid, c)
+			return false
+		}
+	}
+
+	return true
+}
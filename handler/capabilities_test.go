@@ -0,0 +1,77 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package handler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/handler/implementations"
+)
+
+func Test_writeCapabilities(t *testing.T) {
+
+	tests := []struct {
+		name string
+		h    domain.HandlerIface
+		want []domain.Capability
+	}{
+		{
+			//
+			// Test-case 1: Net sysctl.
+			//
+			name: "1",
+			h: &implementations.MaxIntBaseHandler{
+				Path: "/proc/sys/net/netfilter/nf_conntrack_max",
+			},
+			want: []domain.Capability{domain.CAP_NET_ADMIN},
+		},
+		{
+			//
+			// Test-case 2: Kernel sysctl.
+			//
+			name: "2",
+			h:    &implementations.KernelPanicHandler{Path: "/proc/sys/kernel/panic"},
+			want: []domain.Capability{domain.CAP_SYS_ADMIN},
+		},
+		{
+			//
+			// Test-case 3: Handler overriding the capabilities implied by its
+			// path.
+			//
+			name: "3",
+			h:    &implementations.VmMmapMinAddrHandler{Path: "/proc/sys/vm/mmap_min_addr"},
+			want: []domain.Capability{domain.CAP_SYS_RAWIO},
+		},
+		{
+			//
+			// Test-case 4: Resource with no capability requirements.
+			//
+			name: "4",
+			h:    &implementations.CommonHandler{Path: "commonHandler"},
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, writeCapabilities(tt.h))
+		})
+	}
+}
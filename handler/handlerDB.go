@@ -18,17 +18,20 @@ package handler
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"path"
 	"strings"
 	"sync"
 
-	"github.com/sirupsen/logrus"
-
 	"github.com/nestybox/sysbox-fs/domain"
 	"github.com/nestybox/sysbox-fs/handler/implementations"
+	"github.com/nestybox/sysbox-fs/logging"
 )
 
+// Logger of the handlers subsystem.
+var logger = logging.Subsystem(logging.Handlers)
+
 //
 // Slice of sysbox-fs' default handlers. Please keep me alphabetically
 // ordered within each functional bucket.
@@ -109,6 +112,41 @@ var DefaultHandlers = []domain.HandlerIface{
 		Enabled:   true,
 		Cacheable: false,
 	},
+	&implementations.ProcPressureHandler{
+		Name:      "procPressure",
+		Path:      "/proc/pressure",
+		Type:      domain.NODE_SUBSTITUTION | domain.NODE_BINDMOUNT,
+		Enabled:   true,
+		Cacheable: true,
+	},
+	&implementations.ProcPressureFileHandler{
+		Name:      "procPressureCpu",
+		Path:      "/proc/pressure/cpu",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: false,
+	},
+	&implementations.ProcPressureFileHandler{
+		Name:      "procPressureIo",
+		Path:      "/proc/pressure/io",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: false,
+	},
+	&implementations.ProcPressureFileHandler{
+		Name:      "procPressureMemory",
+		Path:      "/proc/pressure/memory",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: false,
+	},
+	&implementations.ProcSchedstatHandler{
+		Name:      "procSchedstat",
+		Path:      "/proc/schedstat",
+		Type:      domain.NODE_SUBSTITUTION | domain.NODE_BINDMOUNT,
+		Enabled:   true,
+		Cacheable: false,
+	},
 	&implementations.ProcStatHandler{
 		Name:      "procStat",
 		Path:      "/proc/stat",
@@ -130,6 +168,27 @@ var DefaultHandlers = []domain.HandlerIface{
 		Enabled:   true,
 		Cacheable: false,
 	},
+	&implementations.ProcSysStubDirHandler{
+		Name:      "procSysAbi",
+		Path:      "/proc/sys/abi",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: true,
+	},
+	&implementations.ProcSysStubDirHandler{
+		Name:      "procSysDebug",
+		Path:      "/proc/sys/debug",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: true,
+	},
+	&implementations.ProcTimerListHandler{
+		Name:      "procTimerList",
+		Path:      "/proc/timer_list",
+		Type:      domain.NODE_SUBSTITUTION | domain.NODE_BINDMOUNT,
+		Enabled:   true,
+		Cacheable: false,
+	},
 	&implementations.ProcUptimeHandler{
 		Name:      "procUptime",
 		Path:      "/proc/uptime",
@@ -177,6 +236,48 @@ var DefaultHandlers = []domain.HandlerIface{
 		Enabled:   true,
 		Cacheable: true,
 	},
+	&implementations.BoundedIntBaseHandler{
+		Name:      "fsSuidDumpable",
+		Path:      "/proc/sys/fs/suid_dumpable",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: true,
+	},
+	&implementations.BoundedIntBaseHandler{
+		Name:      "fsEpollMaxUserWatches",
+		Path:      "/proc/sys/fs/epoll/max_user_watches",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: true,
+	},
+	&implementations.BoundedIntBaseHandler{
+		Name:      "fsLeaseBreakTime",
+		Path:      "/proc/sys/fs/lease-break-time",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: true,
+	},
+	&implementations.BoundedIntBaseHandler{
+		Name:      "fsLeasesEnable",
+		Path:      "/proc/sys/fs/leases-enable",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: true,
+	},
+	&implementations.BoundedIntBaseHandler{
+		Name:      "fsMayDetachMounts",
+		Path:      "/proc/sys/fs/may_detach_mounts",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: true,
+	},
+	&implementations.BoundedIntBaseHandler{
+		Name:      "fsMountMax",
+		Path:      "/proc/sys/fs/mount-max",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: true,
+	},
 	//
 	// /proc/sys/kernel handlers
 	//
@@ -215,6 +316,69 @@ var DefaultHandlers = []domain.HandlerIface{
 		Enabled:   true,
 		Cacheable: true,
 	},
+	&implementations.BoundedIntBaseHandler{
+		Name:      "kernelNumaBalancing",
+		Path:      "/proc/sys/kernel/numa_balancing",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: true,
+	},
+	&implementations.BoundedIntBaseHandler{
+		Name:      "kernelPerfEventParanoid",
+		Path:      "/proc/sys/kernel/perf_event_paranoid",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: true,
+	},
+	&implementations.BoundedIntBaseHandler{
+		Name:      "kernelPerfEventMaxSampleRate",
+		Path:      "/proc/sys/kernel/perf_event_max_sample_rate",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: true,
+	},
+	&implementations.BoundedIntBaseHandler{
+		Name:      "kernelPerfEventMaxStack",
+		Path:      "/proc/sys/kernel/perf_event_max_stack",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: true,
+	},
+	&implementations.BoundedIntBaseHandler{
+		Name:      "kernelPerfEventMaxContextsPerStack",
+		Path:      "/proc/sys/kernel/perf_event_max_contexts_per_stack",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: true,
+	},
+	&implementations.KernelRandomBootIdHandler{
+		Name:      "kernelRandomBootId",
+		Path:      "/proc/sys/kernel/random/boot_id",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: false,
+	},
+	&implementations.KernelSeccompActionsAvailHandler{
+		Name:      "kernelSeccompActionsAvail",
+		Path:      "/proc/sys/kernel/seccomp/actions_avail",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: true,
+	},
+	&implementations.KernelSeccompActionsLoggedHandler{
+		Name:      "kernelSeccompActionsLogged",
+		Path:      "/proc/sys/kernel/seccomp/actions_logged",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: true,
+	},
+	&implementations.BoundedIntBaseHandler{
+		Name:      "kernelRandomizeVaSpace",
+		Path:      "/proc/sys/kernel/randomize_va_space",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: true,
+	},
 	&implementations.KernelPrintkHandler{
 		Name:      "kernelPrintk",
 		Path:      "/proc/sys/kernel/printk",
@@ -229,6 +393,41 @@ var DefaultHandlers = []domain.HandlerIface{
 		Enabled:   true,
 		Cacheable: true,
 	},
+	&implementations.BoundedIntBaseHandler{
+		Name:      "kernelUnprivilegedBpfDisabled",
+		Path:      "/proc/sys/kernel/unprivileged_bpf_disabled",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: true,
+	},
+	&implementations.KernelUnprivUsernsCloneHandler{
+		Name:      "kernelUnprivUsernsClone",
+		Path:      "/proc/sys/kernel/unprivileged_userns_clone",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: true,
+	},
+	&implementations.IpcNsIntBaseHandler{
+		Name:      "kernelShmRmidForced",
+		Path:      "/proc/sys/kernel/shm_rmid_forced",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: false,
+	},
+	&implementations.IpcNsIntBaseHandler{
+		Name:      "kernelMsgmni",
+		Path:      "/proc/sys/kernel/msgmni",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: false,
+	},
+	&implementations.VectorIntBaseHandler{
+		Name:      "kernelSem",
+		Path:      "/proc/sys/kernel/sem",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: false,
+	},
 	&implementations.KernelYamaPtraceScopeHandler{
 		Name:      "kernelYamaPtraceScope",
 		Path:      "/proc/sys/kernel/yama/ptrace_scope",
@@ -239,13 +438,93 @@ var DefaultHandlers = []domain.HandlerIface{
 	//
 	// /proc/sys/net/core handlers
 	//
-	&implementations.CoreDefaultQdiscHandler{
+	&implementations.BoundedIntBaseHandler{
+		Name:      "coreBpfJitEnable",
+		Path:      "/proc/sys/net/core/bpf_jit_enable",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: true,
+	},
+	&implementations.BoundedIntBaseHandler{
+		Name:      "coreBpfJitHarden",
+		Path:      "/proc/sys/net/core/bpf_jit_harden",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: true,
+	},
+	&implementations.StringBaseHandler{
 		Name:      "coreDefaultQdisc",
 		Path:      "/proc/sys/net/core/default_qdisc",
 		Type:      domain.NODE_SUBSTITUTION,
 		Enabled:   true,
 		Cacheable: true,
 	},
+	&implementations.NetnsIntBaseHandler{
+		Name:      "coreNetdevMaxBacklog",
+		Path:      "/proc/sys/net/core/netdev_max_backlog",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: true,
+	},
+	&implementations.NetnsIntBaseHandler{
+		Name:      "coreRmemMax",
+		Path:      "/proc/sys/net/core/rmem_max",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: true,
+	},
+	&implementations.NetnsIntBaseHandler{
+		Name:      "coreWmemMax",
+		Path:      "/proc/sys/net/core/wmem_max",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: true,
+	},
+	&implementations.NetnsIntBaseHandler{
+		Name:      "coreRmemDefault",
+		Path:      "/proc/sys/net/core/rmem_default",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: true,
+	},
+	&implementations.NetnsIntBaseHandler{
+		Name:      "coreWmemDefault",
+		Path:      "/proc/sys/net/core/wmem_default",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: true,
+	},
+	//
+	// /proc/sys/net/bridge handlers
+	//
+	&implementations.NetBridgeHandler{
+		Name:      "netBridge",
+		Path:      "/proc/sys/net/bridge",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: true,
+	},
+	&implementations.BridgeNfCallHandler{
+		Name:      "bridgeNfCallIptables",
+		Path:      "/proc/sys/net/bridge/bridge-nf-call-iptables",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: true,
+	},
+	&implementations.BridgeNfCallHandler{
+		Name:      "bridgeNfCallIp6tables",
+		Path:      "/proc/sys/net/bridge/bridge-nf-call-ip6tables",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: true,
+	},
+	&implementations.BridgeNfCallHandler{
+		Name:      "bridgeNfCallArptables",
+		Path:      "/proc/sys/net/bridge/bridge-nf-call-arptables",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: true,
+	},
 	//
 	// /proc/sys/net/netfilter handlers
 	//
@@ -271,6 +550,30 @@ var DefaultHandlers = []domain.HandlerIface{
 		Cacheable: true,
 	},
 	//
+	// /proc/sys/net/ipv4 handlers
+	//
+	&implementations.StringBaseHandler{
+		Name:      "ipv4TcpCongestionControl",
+		Path:      "/proc/sys/net/ipv4/tcp_congestion_control",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: false,
+	},
+	&implementations.VectorIntBaseHandler{
+		Name:      "ipv4TcpRmem",
+		Path:      "/proc/sys/net/ipv4/tcp_rmem",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: false,
+	},
+	&implementations.VectorIntBaseHandler{
+		Name:      "ipv4TcpWmem",
+		Path:      "/proc/sys/net/ipv4/tcp_wmem",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: false,
+	},
+	//
 	// /proc/sys/net/ipv4/vs handlers
 	//
 	&implementations.VsConntrackHandler{
@@ -313,21 +616,21 @@ var DefaultHandlers = []domain.HandlerIface{
 		Enabled:   true,
 		Cacheable: true,
 	},
-	&implementations.MaxIntBaseHandler{
+	&implementations.NetnsIntBaseHandler{
 		Name:      "neighDefaultGcThresh1",
 		Path:      "/proc/sys/net/ipv4/neigh/default/gc_thresh1",
 		Type:      domain.NODE_SUBSTITUTION,
 		Enabled:   true,
 		Cacheable: true,
 	},
-	&implementations.MaxIntBaseHandler{
+	&implementations.NetnsIntBaseHandler{
 		Name:      "neighDefaultGcThresh2",
 		Path:      "/proc/sys/net/ipv4/neigh/default/gc_thresh2",
 		Type:      domain.NODE_SUBSTITUTION,
 		Enabled:   true,
 		Cacheable: true,
 	},
-	&implementations.MaxIntBaseHandler{
+	&implementations.NetnsIntBaseHandler{
 		Name:      "neighDefaultGcThresh3",
 		Path:      "/proc/sys/net/ipv4/neigh/default/gc_thresh3",
 		Type:      domain.NODE_SUBSTITUTION,
@@ -335,6 +638,16 @@ var DefaultHandlers = []domain.HandlerIface{
 		Cacheable: true,
 	},
 	//
+	// /proc/sys/net/ipv4/route handlers
+	//
+	&implementations.NetnsIntBaseHandler{
+		Name:      "routeMaxSize",
+		Path:      "/proc/sys/net/ipv4/route/max_size",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: true,
+	},
+	//
 	// /proc/sys/net/unix handlers
 	//
 	&implementations.MaxIntBaseHandler{
@@ -361,6 +674,41 @@ var DefaultHandlers = []domain.HandlerIface{
 		Enabled:   true,
 		Cacheable: true,
 	},
+	&implementations.VmHugePagesHandler{
+		Name:      "vmNrHugepages",
+		Path:      "/proc/sys/vm/nr_hugepages",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: true,
+	},
+	&implementations.VmHugePagesHandler{
+		Name:      "vmNrOvercommitHugepages",
+		Path:      "/proc/sys/vm/nr_overcommit_hugepages",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: true,
+	},
+	&implementations.VmHugePagesHandler{
+		Name:      "vmHugetlbShmGroup",
+		Path:      "/proc/sys/vm/hugetlb_shm_group",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: true,
+	},
+	&implementations.BoundedIntBaseHandler{
+		Name:      "vmZoneReclaimMode",
+		Path:      "/proc/sys/vm/zone_reclaim_mode",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: true,
+	},
+	&implementations.VectorIntBaseHandler{
+		Name:      "vmLowmemReserveRatio",
+		Path:      "/proc/sys/vm/lowmem_reserve_ratio",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: true,
+	},
 	//
 	// /sys handlers
 	//
@@ -378,6 +726,202 @@ var DefaultHandlers = []domain.HandlerIface{
 		Enabled:   true,
 		Cacheable: true,
 	},
+	&implementations.SysKernelMmHugepagesHandler{
+		Name:      "sysKernelMmHugepages",
+		Path:      "/sys/kernel/mm/hugepages",
+		Type:      domain.NODE_SUBSTITUTION | domain.NODE_BINDMOUNT | domain.NODE_PROPAGATE,
+		Enabled:   true,
+		Cacheable: true,
+	},
+	&implementations.SysKernelMmHugepagesHandler{
+		Name:      "sysKernelMmHugepages2M",
+		Path:      "/sys/kernel/mm/hugepages/hugepages-2048kB",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: true,
+	},
+	&implementations.SysHugepagesHandler{
+		Name:      "sysHugepages2MNrHugepages",
+		Path:      "/sys/kernel/mm/hugepages/hugepages-2048kB/nr_hugepages",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: false,
+	},
+	&implementations.SysHugepagesHandler{
+		Name:      "sysHugepages2MFreeHugepages",
+		Path:      "/sys/kernel/mm/hugepages/hugepages-2048kB/free_hugepages",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: false,
+	},
+	&implementations.SysKernelMmHugepagesHandler{
+		Name:      "sysKernelMmHugepages1G",
+		Path:      "/sys/kernel/mm/hugepages/hugepages-1048576kB",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: true,
+	},
+	&implementations.SysHugepagesHandler{
+		Name:      "sysHugepages1GNrHugepages",
+		Path:      "/sys/kernel/mm/hugepages/hugepages-1048576kB/nr_hugepages",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: false,
+	},
+	&implementations.SysHugepagesHandler{
+		Name:      "sysHugepages1GFreeHugepages",
+		Path:      "/sys/kernel/mm/hugepages/hugepages-1048576kB/free_hugepages",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: false,
+	},
+	&implementations.StringBaseHandler{
+		Name:      "sysTransparentHugepageEnabled",
+		Path:      "/sys/kernel/mm/transparent_hugepage/enabled",
+		Type:      domain.NODE_SUBSTITUTION | domain.NODE_BINDMOUNT | domain.NODE_PROPAGATE,
+		Enabled:   true,
+		Cacheable: true,
+	},
+	&implementations.StringBaseHandler{
+		Name:      "sysTransparentHugepageDefrag",
+		Path:      "/sys/kernel/mm/transparent_hugepage/defrag",
+		Type:      domain.NODE_SUBSTITUTION | domain.NODE_BINDMOUNT | domain.NODE_PROPAGATE,
+		Enabled:   true,
+		Cacheable: true,
+	},
+	&implementations.SysDevicesSystemHandler{
+		Name:      "sysDevicesSystemCpu",
+		Path:      "/sys/devices/system/cpu",
+		Type:      domain.NODE_SUBSTITUTION | domain.NODE_BINDMOUNT | domain.NODE_PROPAGATE,
+		Enabled:   true,
+		Cacheable: false,
+	},
+	&implementations.SysDevicesSystemHandler{
+		Name:      "sysDevicesSystemCpuTree",
+		Path:      "/sys/devices/system/cpu/**",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: false,
+	},
+	&implementations.SysDevicesSystemHandler{
+		Name:      "sysDevicesSystemNode",
+		Path:      "/sys/devices/system/node",
+		Type:      domain.NODE_SUBSTITUTION | domain.NODE_BINDMOUNT | domain.NODE_PROPAGATE,
+		Enabled:   true,
+		Cacheable: false,
+	},
+	&implementations.SysDevicesSystemHandler{
+		Name:      "sysDevicesSystemNodeTree",
+		Path:      "/sys/devices/system/node/**",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: false,
+	},
+	&implementations.SysDevicesVirtualBlockHandler{
+		Name:      "sysDevicesVirtualBlock",
+		Path:      "/sys/devices/virtual/block",
+		Type:      domain.NODE_SUBSTITUTION | domain.NODE_BINDMOUNT | domain.NODE_PROPAGATE,
+		Enabled:   true,
+		Cacheable: false,
+	},
+	&implementations.SysDevicesVirtualBlockHandler{
+		Name:      "sysDevicesVirtualBlockTree",
+		Path:      "/sys/devices/virtual/block/**",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: false,
+	},
+	&implementations.PassthroughBaseHandler{
+		Name:      "sysClassTty",
+		Path:      "/sys/class/tty",
+		Type:      domain.NODE_SUBSTITUTION | domain.NODE_BINDMOUNT | domain.NODE_PROPAGATE,
+		Enabled:   true,
+		Cacheable: true,
+	},
+	&implementations.PassthroughBaseHandler{
+		Name:      "sysClassTtyTree",
+		Path:      "/sys/class/tty/**",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: true,
+	},
+	&implementations.PassthroughBaseHandler{
+		Name:      "sysClassMisc",
+		Path:      "/sys/class/misc",
+		Type:      domain.NODE_SUBSTITUTION | domain.NODE_BINDMOUNT | domain.NODE_PROPAGATE,
+		Enabled:   true,
+		Cacheable: true,
+	},
+	&implementations.PassthroughBaseHandler{
+		Name:      "sysClassMiscTree",
+		Path:      "/sys/class/misc/**",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: true,
+	},
+	&implementations.SysStubDirHandler{
+		Name:      "sysFsBpf",
+		Path:      "/sys/fs/bpf",
+		Type:      domain.NODE_SUBSTITUTION | domain.NODE_BINDMOUNT | domain.NODE_PROPAGATE,
+		Enabled:   true,
+		Cacheable: true,
+	},
+	&implementations.SysStubDirHandler{
+		Name:      "sysFsBpfTree",
+		Path:      "/sys/fs/bpf/**",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: true,
+	},
+	&implementations.SysStubDirHandler{
+		Name:      "sysKernelSecurity",
+		Path:      "/sys/kernel/security",
+		Type:      domain.NODE_SUBSTITUTION | domain.NODE_BINDMOUNT | domain.NODE_PROPAGATE,
+		Enabled:   true,
+		Cacheable: true,
+	},
+	&implementations.SysStubDirHandler{
+		Name:      "sysKernelSecurityTree",
+		Path:      "/sys/kernel/security/**",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: true,
+	},
+	&implementations.SysClassNetHandler{
+		Name:      "sysClassNetStatistics",
+		Path:      "/sys/class/net/*/statistics/*",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: false,
+	},
+	&implementations.SysClassNetHandler{
+		Name:      "sysClassNetMtu",
+		Path:      "/sys/class/net/*/mtu",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: false,
+	},
+	&implementations.SysClassNetHandler{
+		Name:      "sysClassNetOperstate",
+		Path:      "/sys/class/net/*/operstate",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: false,
+	},
+	&implementations.SysClassNetHandler{
+		Name:      "sysClassNetSpeed",
+		Path:      "/sys/class/net/*/speed",
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: false,
+	},
+	&implementations.SysDmiProductUuidHandler{
+		Name:      "sysDmiProductUuid",
+		Path:      "/sys/class/dmi/id/product_uuid",
+		Type:      domain.NODE_SUBSTITUTION | domain.NODE_BINDMOUNT | domain.NODE_PROPAGATE,
+		Enabled:   true,
+		Cacheable: false,
+	},
 	//
 	// Common handler -- to be utilized for all namespaced resources.
 	//
@@ -414,6 +958,15 @@ type handlerService struct {
 	// object (value).
 	handlerDB map[string]domain.HandlerIface
 
+	// Full set of handlers known to the service, be them enabled or not, as
+	// last passed to Setup() / SyncHandlers().
+	handlers []domain.HandlerIface
+
+	// Trie of the handlers serving resource paths (as opposed to the ones
+	// solely reachable by name, e.g. "commonHandler"), which supports path
+	// patterns (see pathTrie).
+	pathDB *pathTrie
+
 	// Map to keep track of the resources being emulated and the directory where
 	// these are being placed. Map is indexed by directory path (string), and
 	// the value corresponds to a slice of strings that holds the full path of
@@ -438,6 +991,15 @@ type handlerService struct {
 	// Handler i/o errors should be obviated if this flag is enabled (testing
 	// purposes).
 	ignoreErrors bool
+
+	// Initial values of emulated resources (indexed by path) to expose within
+	// sys containers regardless of the host ones (see snapshotHostValues).
+	defaultValues map[string]string
+
+	// Middlewares wrapped around the handlers' execution, and the resulting
+	// chain (see Use()).
+	middlewares []domain.HandlerMiddleware
+	chain       domain.HandlerFunc
 }
 
 // HandlerService constructor.
@@ -445,6 +1007,7 @@ func NewHandlerService() domain.HandlerServiceIface {
 
 	newhs := &handlerService{
 		handlerDB:     make(map[string]domain.HandlerIface),
+		pathDB:        newPathTrie(),
 		dirHandlerMap: make(map[string][]string),
 	}
 
@@ -464,6 +1027,7 @@ func (hs *handlerService) Setup(
 	hs.prs = prs
 	hs.ios = ios
 	hs.ignoreErrors = ignoreErrors
+	hs.handlers = hdlrs
 
 	// Register all handlers declared as 'enabled'.
 	for _, h := range hdlrs {
@@ -472,6 +1036,23 @@ func (hs *handlerService) Setup(
 		}
 	}
 
+	// Subscribe handlers to container lifecycle events (if interested).
+	if css != nil {
+		for _, h := range hs.handlerDB {
+			if sub, ok := h.(domain.ContainerEventSubscriberIface); ok {
+				css.Subscribe(sub.HandleContainerEvent)
+			}
+		}
+
+		css.Subscribe(hs.snapshotHostValues)
+		css.Subscribe(hs.stopContainerHandlers)
+	}
+
+	// Wrap the handlers' execution with the default middlewares: request
+	// tracking (accounting, latency and tracing) goes first so that calls
+	// rejected by the inner ones are accounted too.
+	hs.Use(trackingMiddleware, loggingMiddleware, hs.validationMiddleware)
+
 	// Create a directory-handler map to keep track of the association between
 	// emulated resource paths, and the parent directory hosting them.
 	hs.createDirHandlerMap()
@@ -479,7 +1060,7 @@ func (hs *handlerService) Setup(
 	// Obtain user-ns inode corresponding to the host fs (root user-ns).
 	hostUserNsInode, err := hs.FindUserNsInode(uint32(os.Getpid()))
 	if err != nil {
-		logrus.Fatalf("Invalid init user-namespace found")
+		logger.Fatalf("Invalid init user-namespace found")
 	}
 	hs.hostUserNsInode = hostUserNsInode
 }
@@ -488,13 +1069,26 @@ func (hs *handlerService) createDirHandlerMap() {
 	hs.Lock()
 	defer hs.Unlock()
 
-	var dirHandlerMap = hs.dirHandlerMap
+	hs.dirHandlerMap = hs.buildDirHandlerMap()
+}
+
+//
+// Builds a directory-handler map out of the registered handlers. Caller must
+// hold the handler-service lock.
+//
+func (hs *handlerService) buildDirHandlerMap() map[string][]string {
+
+	var dirHandlerMap = make(map[string][]string)
 
 	// Iterate through all the registered handlers to populate the dirHandlerMap
 	// structure. Even though this is an O(n^2) logic, notice that 'n' here is
 	// very small (number of handlers), and that this is only executed during
 	// process initialization.
 	for h1, _ := range hs.handlerDB {
+		// Path patterns don't stand for any particular directory entry.
+		if isPathPattern(h1) {
+			continue
+		}
 		dir_h1 := path.Dir(h1)
 
 		for h2, _ := range hs.handlerDB {
@@ -523,7 +1117,123 @@ func (hs *handlerService) createDirHandlerMap() {
 		}
 	}
 
-	hs.dirHandlerMap = dirHandlerMap
+	return dirHandlerMap
+}
+
+//
+// Seeds the resources emulated at sys-container level with the host values at
+// container registration time (see domain.HostSnapshotIface). Resources already
+// initialized (e.g. containers restored from a previous sysbox-fs instance) are
+// left untouched.
+//
+func (hs *handlerService) snapshotHostValues(e domain.ContainerEvent) {
+
+	if e.Type != domain.ContainerRegisterEvent {
+		return
+	}
+
+	hs.RLock()
+	snaps := make(map[string]domain.HostSnapshotIface)
+	for p, h := range hs.handlerDB {
+		if isPathPattern(p) {
+			continue
+		}
+		if snap, ok := h.(domain.HostSnapshotIface); ok && h.GetEnabled() {
+			snaps[p] = snap
+		}
+	}
+	defaults := hs.defaultValues
+	hs.RUnlock()
+
+	cntr := e.Container
+
+	for p, snap := range snaps {
+		name := path.Base(p)
+
+		if _, ok := cntr.Data(p, name); ok {
+			continue
+		}
+
+		// Defaults requested for this container (see domain.ValuePolicy) take
+		// precedence over the configured ones, and both over the host values.
+		if pol, ok := cntr.Policy(p); ok && pol.Default != "" {
+			cntr.SetData(p, name, pol.Default)
+			continue
+		}
+		if val, ok := defaults[p]; ok {
+			cntr.SetData(p, name, val)
+			continue
+		}
+
+		n := hs.ios.NewIOnode(name, p, 0)
+
+		if val, ok := snap.HostSnapshot(n); ok {
+			cntr.SetData(p, name, val)
+		}
+	}
+}
+
+// Returns the enabled handlers implementing domain.LifecycleIface.
+func (hs *handlerService) lifecycleHandlers() []domain.HandlerIface {
+	hs.RLock()
+	defer hs.RUnlock()
+
+	var hdlrs []domain.HandlerIface
+	for _, h := range hs.handlerDB {
+		if _, ok := h.(domain.LifecycleIface); ok && h.GetEnabled() {
+			hdlrs = append(hdlrs, h)
+		}
+	}
+
+	return hdlrs
+}
+
+//
+// Starts the handlers implementing domain.LifecycleIface. Expected to be
+// invoked once, before any container is served. Start errors are fatal unless
+// handler errors are to be ignored.
+//
+func (hs *handlerService) Start() error {
+
+	for _, h := range hs.lifecycleHandlers() {
+		if err := h.(domain.LifecycleIface).Start(); err != nil {
+			if !hs.ignoreErrors {
+				return fmt.Errorf("Unable to start handler %v: %v", h.GetName(), err)
+			}
+			logger.Warnf("Unable to start handler %v: %v", h.GetName(), err)
+		}
+	}
+
+	return nil
+}
+
+//
+// Stops the handlers implementing domain.LifecycleIface upon sysbox-fs
+// termination. Errors are logged, as there's nothing else to be done about
+// them at this point.
+//
+func (hs *handlerService) Stop() {
+
+	for _, h := range hs.lifecycleHandlers() {
+		if err := h.(domain.LifecycleIface).Stop(nil); err != nil {
+			logger.Errorf("Unable to stop handler %v: %v", h.GetName(), err)
+		}
+	}
+}
+
+// Releases the handlers' state associated to the containers being unregistered.
+func (hs *handlerService) stopContainerHandlers(e domain.ContainerEvent) {
+
+	if e.Type != domain.ContainerUnregisterEvent {
+		return
+	}
+
+	for _, h := range hs.lifecycleHandlers() {
+		if err := h.(domain.LifecycleIface).Stop(e.Container); err != nil {
+			logger.Errorf("Unable to stop handler %v for container %v: %v",
+				h.GetName(), e.Container.ID(), err)
+		}
+	}
 }
 
 func (hs *handlerService) RegisterHandler(h domain.HandlerIface) error {
@@ -534,12 +1244,11 @@ func (hs *handlerService) RegisterHandler(h domain.HandlerIface) error {
 
 	if _, ok := hs.handlerDB[path]; ok {
 		hs.Unlock()
-		logrus.Errorf("Handler %v already registered", name)
+		logger.Errorf("Handler %v already registered", name)
 		return errors.New("Handler already registered")
 	}
 
-	h.SetService(hs)
-	hs.handlerDB[path] = h
+	hs.insertHandler(h)
 	hs.Unlock()
 
 	return nil
@@ -553,23 +1262,43 @@ func (hs *handlerService) UnregisterHandler(h domain.HandlerIface) error {
 
 	if _, ok := hs.handlerDB[path]; !ok {
 		hs.Unlock()
-		logrus.Errorf("Handler %v not previously registered", name)
+		logger.Errorf("Handler %v not previously registered", name)
 		return errors.New("Handler not previously registered")
 	}
 
-	delete(hs.handlerDB, name)
+	hs.removeHandler(path)
 	hs.Unlock()
 
 	return nil
 }
 
+// Adds the handler to the handler DB. Caller must hold the handler-service lock.
+func (hs *handlerService) insertHandler(h domain.HandlerIface) {
+	path := h.GetPath()
+
+	h.SetService(hs)
+	hs.handlerDB[path] = h
+	if strings.HasPrefix(path, "/") {
+		hs.pathDB.insert(path, h)
+	}
+}
+
+// Drops the handler of the given path from the handler DB. Caller must hold
+// the handler-service lock.
+func (hs *handlerService) removeHandler(path string) {
+	delete(hs.handlerDB, path)
+	if strings.HasPrefix(path, "/") {
+		hs.pathDB.remove(path)
+	}
+}
+
 func (hs *handlerService) LookupHandler(
 	i domain.IOnodeIface) (domain.HandlerIface, bool) {
 
 	hs.RLock()
 	defer hs.RUnlock()
 
-	h, ok := hs.handlerDB[i.Path()]
+	h, ok := hs.pathDB.lookup(i.Path())
 	if !ok {
 		if strings.HasPrefix(i.Path(), "/sys") {
 			h, ok = hs.handlerDB["sysCommonHandler"]
@@ -589,6 +1318,11 @@ func (hs *handlerService) LookupHandler(
 	return h, true
 }
 
+//
+// Returns the handler registered under the given name or path. Paths not
+// matching any handler exactly are looked up among the path patterns (see
+// pathTrie); unlike LookupHandler(), no common handler is returned otherwise.
+//
 func (hs *handlerService) FindHandler(s string) (domain.HandlerIface, bool) {
 
 	hs.RLock()
@@ -596,6 +1330,9 @@ func (hs *handlerService) FindHandler(s string) (domain.HandlerIface, bool) {
 
 	h, ok := hs.handlerDB[s]
 	if !ok {
+		if strings.HasPrefix(s, "/") {
+			return hs.pathDB.lookup(s)
+		}
 		return nil, false
 	}
 
@@ -610,7 +1347,7 @@ func (hs *handlerService) EnableHandler(h domain.HandlerIface) error {
 
 	if _, ok := hs.handlerDB[path]; !ok {
 		hs.Unlock()
-		logrus.Errorf("Handler %v not found", name)
+		logger.Errorf("Handler %v not found", name)
 		return errors.New("Handler not found")
 	}
 
@@ -628,7 +1365,7 @@ func (hs *handlerService) DisableHandler(h domain.HandlerIface) error {
 
 	if _, ok := hs.handlerDB[path]; !ok {
 		hs.Unlock()
-		logrus.Errorf("Handler %v not found", name)
+		logger.Errorf("Handler %v not found", name)
 		return errors.New("Handler not found")
 	}
 
@@ -638,6 +1375,33 @@ func (hs *handlerService) DisableHandler(h domain.HandlerIface) error {
 	return nil
 }
 
+//
+// Brings the set of registered handlers in line with their 'enabled' state,
+// so that handlers can be enabled / disabled at runtime.
+//
+func (hs *handlerService) SyncHandlers(hdlrs []domain.HandlerIface) {
+
+	// The handler DB and its directory map are updated under a single lock,
+	// so that lookups never observe one of them out of sync with the other.
+	hs.Lock()
+	defer hs.Unlock()
+
+	hs.handlers = hdlrs
+
+	for _, h := range hdlrs {
+		path := h.GetPath()
+		_, registered := hs.handlerDB[path]
+
+		if h.GetEnabled() && !registered {
+			hs.insertHandler(h)
+		} else if !h.GetEnabled() && registered {
+			hs.removeHandler(path)
+		}
+	}
+
+	hs.dirHandlerMap = hs.buildDirHandlerMap()
+}
+
 func (hs *handlerService) DirHandlerEntries(s string) []string {
 	hs.RLock()
 	defer hs.RUnlock()
@@ -645,8 +1409,31 @@ func (hs *handlerService) DirHandlerEntries(s string) []string {
 	return hs.dirHandlerMap[s]
 }
 
+//
+// Returns a snapshot of the registered handlers (indexed by path), as the
+// handler DB may be altered at any time by SyncHandlers().
+//
 func (hs *handlerService) HandlerDB() map[string]domain.HandlerIface {
-	return hs.handlerDB
+	hs.RLock()
+	defer hs.RUnlock()
+
+	hdb := make(map[string]domain.HandlerIface, len(hs.handlerDB))
+	for path, h := range hs.handlerDB {
+		hdb[path] = h
+	}
+
+	return hdb
+}
+
+//
+// Returns a snapshot of the full set of handlers, including the ones not
+// registered due to being disabled.
+//
+func (hs *handlerService) Handlers() []domain.HandlerIface {
+	hs.RLock()
+	defer hs.RUnlock()
+
+	return append([]domain.HandlerIface(nil), hs.handlers...)
 }
 
 func (hs *handlerService) StateService() domain.ContainerStateServiceIface {
@@ -673,6 +1460,22 @@ func (hs *handlerService) IgnoreErrors() bool {
 	return hs.ignoreErrors
 }
 
+//
+// Sets the initial values (indexed by path) of the emulated resources of the
+// sys containers registered from now on. Passed values are expected to be
+// already validated.
+//
+func (hs *handlerService) SetDefaultValues(vals map[string]string) {
+	defaults := make(map[string]string, len(vals))
+	for p, v := range vals {
+		defaults[p] = strings.TrimSpace(v)
+	}
+
+	hs.Lock()
+	hs.defaultValues = defaults
+	hs.Unlock()
+}
+
 //
 // Auxiliary methods
 //
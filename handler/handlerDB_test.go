@@ -0,0 +1,155 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package handler
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/handler/implementations"
+	"github.com/nestybox/sysbox-fs/mocks"
+)
+
+// Handler keeping track of its lifecycle.
+type lifecycleHandler struct {
+	mocks.HandlerIface
+	startErr error
+	started  bool
+	stopped  []domain.ContainerIface
+}
+
+func (h *lifecycleHandler) Start() error {
+	h.started = true
+	return h.startErr
+}
+
+func (h *lifecycleHandler) Stop(cntr domain.ContainerIface) error {
+	h.stopped = append(h.stopped, cntr)
+	return nil
+}
+
+func newLifecycleHandler(path string, startErr error) *lifecycleHandler {
+	h := &lifecycleHandler{startErr: startErr}
+	h.On("GetName").Return(path)
+	h.On("GetPath").Return(path)
+	h.On("GetEnabled").Return(true)
+	h.On("SetService", mock.Anything).Return()
+
+	return h
+}
+
+func Test_handlerService_lifecycle(t *testing.T) {
+
+	h1 := newLifecycleHandler("/proc/sys/fs/binfmt_misc/register", nil)
+	h2 := newLifecycleHandler("/dev/kmsg", errors.New("no buffer"))
+
+	hs := NewHandlerService().(*handlerService)
+	hs.RegisterHandler(h1)
+	hs.RegisterHandler(h2)
+
+	// Start errors are only tolerated if handler errors are to be ignored.
+	assert.Error(t, hs.Start())
+
+	hs.ignoreErrors = true
+	assert.NoError(t, hs.Start())
+	assert.True(t, h1.started)
+	assert.True(t, h2.started)
+
+	cntr := &mocks.ContainerIface{}
+	cntr.On("ID").Return("c1")
+
+	// Only unregistration events are of interest.
+	hs.stopContainerHandlers(domain.ContainerEvent{
+		Type:      domain.ContainerRegisterEvent,
+		Container: cntr,
+	})
+	assert.Empty(t, h1.stopped)
+
+	hs.stopContainerHandlers(domain.ContainerEvent{
+		Type:      domain.ContainerUnregisterEvent,
+		Container: cntr,
+	})
+	assert.Equal(t, []domain.ContainerIface{cntr}, h1.stopped)
+
+	hs.Stop()
+	assert.Equal(t, []domain.ContainerIface{cntr, nil}, h1.stopped)
+	assert.Equal(t, []domain.ContainerIface{cntr, nil}, h2.stopped)
+}
+
+func Test_handlerService_SyncHandlers(t *testing.T) {
+
+	h1 := &implementations.PassthroughBaseHandler{
+		Name:    "h1",
+		Path:    "/proc/sys/kernel/h1",
+		Enabled: true,
+	}
+	h2 := &implementations.PassthroughBaseHandler{
+		Name:    "h2",
+		Path:    "/proc/sys/kernel/h2",
+		Enabled: true,
+	}
+	hdlrs := []domain.HandlerIface{h1, h2}
+
+	hs := NewHandlerService().(*handlerService)
+	hs.SyncHandlers(hdlrs)
+
+	assert.ElementsMatch(t,
+		[]string{h1.Path, h2.Path},
+		hs.DirHandlerEntries("/proc/sys/kernel"))
+
+	// Readers running along the config reloads (meant for the race detector).
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			for _, p := range hs.DirHandlerEntries("/proc/sys/kernel") {
+				if h, ok := hs.FindHandler(p); ok {
+					h.GetEnabled()
+				}
+			}
+			for path := range hs.HandlerDB() {
+				hs.FindHandler(path)
+			}
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		h2.SetEnabled(i%2 == 0)
+		hs.SyncHandlers(hdlrs)
+	}
+	<-done
+
+	h2.SetEnabled(false)
+	hs.SyncHandlers(hdlrs)
+
+	_, ok := hs.FindHandler(h2.Path)
+	assert.False(t, ok)
+	assert.Equal(t,
+		[]string{h1.Path},
+		hs.DirHandlerEntries("/proc/sys/kernel"))
+
+	// Disabled handlers are left out of the handler DB, but not out of the
+	// full handler set.
+	assert.Equal(t,
+		map[string]domain.HandlerIface{h1.Path: h1},
+		hs.HandlerDB())
+	assert.Equal(t, hdlrs, hs.Handlers())
+}
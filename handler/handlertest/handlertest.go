@@ -0,0 +1,247 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package handlertest provides utilities for the behavioral testing of
+// sysbox-fs handlers: an environment made of an in-memory host file-system,
+// a container-state service and mocked handler / nsenter services, along with
+// a table-driven runner exercising the handlers' Lookup, Open, Read and Write
+// operations on behalf of a (fake) sys container.
+package handlertest
+
+import (
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/handler/implementations"
+	"github.com/nestybox/sysbox-fs/mocks"
+	"github.com/nestybox/sysbox-fs/process"
+	"github.com/nestybox/sysbox-fs/state"
+	"github.com/nestybox/sysbox-fs/sysio"
+)
+
+// Pid of the processes originating the requests of the fake containers.
+const ContainerPid = 1001
+
+// Size of the buffers handed over to the handlers' reads.
+const readBufSize = 64 * 1024
+
+//
+// Services available to the handlers under test. The host file-system is an
+// in-memory one (see WriteHostFile()), and the handler and nsenter services
+// are mocks; the former is preset with the expectations common to all
+// handlers (e.g. IOService(), commonHandler lookups), and further ones can be
+// added by the tests (e.g. nsenter requests).
+//
+type Env struct {
+	IOS domain.IOServiceIface
+	CSS domain.ContainerStateServiceIface
+	PRS domain.ProcessServiceIface
+	NSS *mocks.NSenterServiceIface
+	HDS *mocks.HandlerServiceIface
+}
+
+// NewEnv returns a new (empty) test environment.
+func NewEnv() *Env {
+
+	e := &Env{
+		IOS: sysio.NewIOService(domain.IOMemFileService),
+		PRS: process.NewProcessService(),
+		CSS: state.NewContainerStateService(),
+		NSS: &mocks.NSenterServiceIface{},
+		HDS: &mocks.HandlerServiceIface{},
+	}
+
+	e.PRS.Setup(e.IOS)
+	e.CSS.Setup(nil, e.PRS, e.IOS, "")
+
+	common := &implementations.CommonHandler{
+		Name:      "common",
+		Path:      "commonHandler",
+		Enabled:   true,
+		Cacheable: true,
+		Service:   e.HDS,
+	}
+
+	e.HDS.On("IOService").Return(e.IOS)
+	e.HDS.On("NSenterService").Return(e.NSS)
+	e.HDS.On("ProcessService").Return(e.PRS)
+	e.HDS.On("StateService").Return(e.CSS)
+	e.HDS.On("FindHandler", "commonHandler").Return(common, true)
+	e.HDS.On("DirHandlerEntries", mock.Anything).Return(nil)
+
+	return e
+}
+
+// WriteHostFile sets the content of a file of the host file-system.
+func (e *Env) WriteHostFile(path string, content string) error {
+	return e.IOS.NewIOnode("", path, 0).WriteFile([]byte(content))
+}
+
+// ReadHostFile returns the content of a file of the host file-system.
+func (e *Env) ReadHostFile(path string) (string, error) {
+	data, err := e.IOS.NewIOnode("", path, 0).ReadFile()
+	return string(data), err
+}
+
+//
+// NewContainer returns a sys container with the given id and resource limits,
+// whose requests originate from ContainerPid. The container isn't registered,
+// so its state is only reachable through the requests.
+//
+func (e *Env) NewContainer(id string, limits domain.ResourceLimits) domain.ContainerIface {
+
+	return e.CSS.ContainerCreate(
+		id,
+		ContainerPid,
+		time.Time{},
+		231072,
+		65535,
+		231072,
+		65535,
+		nil,
+		nil,
+		nil,
+		nil,
+		domain.CgroupPaths{},
+		"",
+		limits)
+}
+
+//
+// Case describes an operation to carry out on a handler, and its expected
+// outcome. Host files are set prior to the operation, and checked right after
+// it.
+//
+type Case struct {
+	Name string
+
+	// Operation (domain.FuseOpLookup, FuseOpOpen, FuseOpRead or FuseOpWrite)
+	// and the path it applies to.
+	Op   domain.FuseOp
+	Path string
+
+	// Open flags (FuseOpOpen), written data (FuseOpWrite) and offset of the
+	// read / written data.
+	Flags  int
+	Data   string
+	Offset int64
+
+	// Host files to set prior to the operation (path -> content).
+	Host map[string]string
+
+	// Content expected from reads (FuseOpRead).
+	Want string
+
+	// Error expected from the operation (none if nil). Reads past the end of
+	// the resource (io.EOF) are considered successful.
+	WantErr error
+
+	// Content expected from the host files after the operation.
+	WantHost map[string]string
+}
+
+//
+// Run executes the given cases, in order, against the handler, on behalf of
+// the given container. Handlers lacking a service are handed the env's one.
+//
+func Run(
+	t *testing.T,
+	env *Env,
+	h domain.HandlerIface,
+	cntr domain.ContainerIface,
+	cases []Case) {
+
+	t.Helper()
+
+	if h.GetService() == nil {
+		h.SetService(env.HDS)
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.Name, func(t *testing.T) {
+
+			for path, content := range tc.Host {
+				if err := env.WriteHostFile(path, content); err != nil {
+					t.Fatalf("Unable to set host file %s: %v", path, err)
+				}
+			}
+
+			n := env.IOS.NewIOnode(filepath.Base(tc.Path), tc.Path, 0)
+
+			req := &domain.HandlerRequest{
+				Pid:       ContainerPid,
+				Offset:    tc.Offset,
+				Container: cntr,
+			}
+
+			var (
+				got string
+				err error
+			)
+
+			switch tc.Op {
+			case domain.FuseOpLookup:
+				_, err = h.Lookup(n, req)
+
+			case domain.FuseOpOpen:
+				n.SetOpenFlags(tc.Flags)
+				err = h.Open(n, req)
+
+			case domain.FuseOpRead:
+				req.Data = make([]byte, readBufSize)
+				var sz int
+				sz, err = h.Read(n, req)
+				if err == io.EOF {
+					err = nil
+				}
+				if err == nil {
+					got = string(req.Data[:sz])
+				}
+
+			case domain.FuseOpWrite:
+				req.Data = []byte(tc.Data)
+				_, err = h.Write(n, req)
+
+			default:
+				t.Fatalf("Unsupported %v operation", tc.Op)
+			}
+
+			if tc.WantErr != nil {
+				assert.Equal(t, tc.WantErr, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			if tc.Op == domain.FuseOpRead && tc.WantErr == nil {
+				assert.Equal(t, tc.Want, got)
+			}
+
+			for path, want := range tc.WantHost {
+				content, err := env.ReadHostFile(path)
+				assert.NoError(t, err)
+				assert.Equal(t, want, content, path)
+			}
+		})
+	}
+}
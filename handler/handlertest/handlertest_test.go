@@ -0,0 +1,77 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package handlertest_test
+
+import (
+	"syscall"
+	"testing"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/fuse"
+	"github.com/nestybox/sysbox-fs/handler/handlertest"
+	"github.com/nestybox/sysbox-fs/handler/implementations"
+)
+
+func TestRun(t *testing.T) {
+
+	env := handlertest.NewEnv()
+	cntr := env.NewContainer("c1", domain.ResourceLimits{CpusetCpus: "0-1"})
+
+	h := &implementations.PassthroughBaseHandler{
+		Name:    "sysClassMiscTree",
+		Path:    "/sys/class/misc/**",
+		Type:    domain.NODE_SUBSTITUTION,
+		Enabled: true,
+	}
+
+	handlertest.Run(t, env, h, cntr, []handlertest.Case{
+		{
+			Name: "lookup",
+			Op:   domain.FuseOpLookup,
+			Path: "/sys/class/misc/tun/dev",
+			Host: map[string]string{"/sys/class/misc/tun/dev": "10:200\n"},
+		},
+		{
+			Name: "read",
+			Op:   domain.FuseOpRead,
+			Path: "/sys/class/misc/tun/dev",
+			Want: "10:200\n",
+		},
+		{
+			Name:   "read past end",
+			Op:     domain.FuseOpRead,
+			Path:   "/sys/class/misc/tun/dev",
+			Offset: 7,
+			Want:   "",
+		},
+		{
+			Name:    "open for writing",
+			Op:      domain.FuseOpOpen,
+			Path:    "/sys/class/misc/tun/dev",
+			Flags:   syscall.O_WRONLY,
+			WantErr: fuse.IOerror{Code: syscall.EACCES},
+		},
+		{
+			Name:     "write",
+			Op:       domain.FuseOpWrite,
+			Path:     "/sys/class/misc/tun/dev",
+			Data:     "10:201\n",
+			WantErr:  fuse.IOerror{Code: syscall.EACCES},
+			WantHost: map[string]string{"/sys/class/misc/tun/dev": "10:200\n"},
+		},
+	})
+}
@@ -0,0 +1,310 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package implementations
+
+import (
+	"errors"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/fuse"
+)
+
+// This is a base handler for system-wide sysctls holding a single integer
+// within a fixed range (e.g. fs.suid_dumpable). Written values are validated
+// against the range of each sysctl (see boundedIntSysctls), and emulated at
+// sys-container level, starting with the host's value. IOW, the host FS value
+// is left untouched.
+
+// Largest int value, for sysctls lacking an upper bound.
+const maxInt = int(^uint(0) >> 1)
+
+//
+// Layout of a bounded integer sysctl. Sysctls offered by some kernels only
+// (e.g. RHEL's fs.may_detach_mounts) are emulated with a fallback value in the
+// remaining ones. Sticky sysctls can't be changed once they hold the sticky
+// value (e.g. kernel.unprivileged_bpf_disabled).
+//
+type boundedIntSysctl struct {
+	min      int
+	max      int
+	fallback string
+	sticky   string
+}
+
+// Layout of the bounded integer sysctls served by BoundedIntBaseHandler.
+var boundedIntSysctls = map[string]boundedIntSysctl{
+	// Core dump mode of setuid binaries: 0 (default), 1 (debug), 2 (suidsafe).
+	"/proc/sys/fs/suid_dumpable": {min: 0, max: 2},
+
+	// Max number of epoll watches per user, commonly raised by file watchers
+	// (e.g. IDEs, inotify-based tools) and network servers. Unbounded (long)
+	// in the kernel.
+	"/proc/sys/fs/epoll/max_user_watches": {min: 0, max: maxInt},
+
+	// File leases (see fcntl(2) F_SETLEASE), relied upon by file servers
+	// (e.g. NFS, Samba) to implement client-side caching.
+	"/proc/sys/fs/lease-break-time": {min: 0, max: math.MaxInt32},
+	"/proc/sys/fs/leases-enable":    {min: 0, max: 1},
+
+	// RHEL / CentOS 7 toggle allowing mount points in use by other mount
+	// namespaces to be lazily detached; upstream kernels always behave as if
+	// it were set to "1". Docker daemon checks and sets it upon start-up.
+	"/proc/sys/fs/may_detach_mounts": {min: 0, max: 1, fallback: "1"},
+
+	// Max number of mounts per mount namespace, commonly raised by hosts of
+	// many (nested) containers.
+	"/proc/sys/fs/mount-max": {min: 1, max: math.MaxInt32},
+
+	// Automatic NUMA memory balancing, commonly disabled by database tuning
+	// guides.
+	"/proc/sys/kernel/numa_balancing": {min: 0, max: 1},
+
+	// Use of the performance events system by unprivileged users, and bounds
+	// of the resources consumed by perf samples.
+	"/proc/sys/kernel/perf_event_paranoid":               {min: -1, max: 3},
+	"/proc/sys/kernel/perf_event_max_sample_rate":        {min: 1, max: math.MaxInt32},
+	"/proc/sys/kernel/perf_event_max_stack":              {min: 0, max: 640 * 1024},
+	"/proc/sys/kernel/perf_event_max_contexts_per_stack": {min: 0, max: 1000},
+
+	// Process address space randomization: 0 (off), 1 (mmap base, stack and
+	// VDSO page), 2 (1 plus heap).
+	"/proc/sys/kernel/randomize_va_space": {min: 0, max: 2},
+
+	// Use of bpf() by unprivileged users: 0 (allowed), 1 (disabled for good),
+	// 2 (disabled, changeable by admins).
+	"/proc/sys/kernel/unprivileged_bpf_disabled": {min: 0, max: 2, sticky: "1"},
+
+	// BPF JIT compiler and its hardening.
+	"/proc/sys/net/core/bpf_jit_enable": {min: 0, max: 2},
+	"/proc/sys/net/core/bpf_jit_harden": {min: 0, max: 2},
+
+	// Memory reclaim approach when a zone runs out of memory, commonly
+	// disabled by file server and database tuning guides.
+	"/proc/sys/vm/zone_reclaim_mode": {min: 0, max: 1},
+}
+
+// Returns the (validated) host value of the given bounded integer sysctl, or
+// its fallback one when the host lacks a valid value.
+func boundedIntHostVal(n domain.IOnodeIface, s boundedIntSysctl) (string, error) {
+
+	val, err := n.ReadLine()
+	if err != nil && err != io.EOF {
+		if s.fallback != "" && isNotExist(err) {
+			return s.fallback, nil
+		}
+		return "", fuse.IOerror{Code: syscall.EIO}
+	}
+
+	// High-level verification to ensure that format is the expected one.
+	val = strings.TrimSpace(val)
+	valInt, err := strconv.Atoi(val)
+	if err != nil || valInt < s.min || valInt > s.max {
+		if s.fallback != "" {
+			return s.fallback, nil
+		}
+		if err != nil {
+			return "", fuse.IOerror{Code: syscall.EINVAL}
+		}
+	}
+
+	return val, nil
+}
+
+type BoundedIntBaseHandler struct {
+	Name      string
+	Path      string
+	Type      domain.HandlerType
+	Enabled   bool
+	Cacheable bool
+	Service   domain.HandlerServiceIface
+}
+
+func (h *BoundedIntBaseHandler) Lookup(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (os.FileInfo, error) {
+
+	info, err := n.Stat()
+	if isNotExist(err) && boundedIntSysctls[n.Path()].fallback != "" {
+		return syntheticFileInfo(filepath.Base(n.Path()), 0644), nil
+	}
+
+	return info, err
+}
+
+func (h *BoundedIntBaseHandler) Getattr(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (*syscall.Stat_t, error) {
+
+	return nil, nil
+}
+
+func (h *BoundedIntBaseHandler) Open(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) error {
+
+	flags := n.OpenFlags()
+	if flags != syscall.O_RDONLY && flags != syscall.O_WRONLY {
+		return fuse.IOerror{Code: syscall.EACCES}
+	}
+
+	return nil
+}
+
+func (h *BoundedIntBaseHandler) Close(n domain.IOnodeIface) error {
+
+	return nil
+}
+
+func (h *BoundedIntBaseHandler) Read(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	// We are dealing with a single integer element being read, so we can save
+	// some cycles by returning right away if offset is any higher than zero.
+	if req.Offset > 0 {
+		return 0, io.EOF
+	}
+
+	name := n.Name()
+	path := n.Path()
+	cntr := req.Container
+
+	// Ensure operation is generated from within a registered sys container.
+	if cntr == nil {
+		logger.Errorf("Could not find the container originating this request (pid %v)",
+			req.Pid)
+		return 0, errors.New("Container not found")
+	}
+
+	// Check if this resource has been initialized for this container. Otherwise,
+	// fetch the information from the host FS and store it accordingly within
+	// the container struct.
+	data, ok := cntr.Data(path, name)
+	if !ok {
+		curHostVal, err := boundedIntHostVal(n, boundedIntSysctls[path])
+		if err != nil {
+			logger.Errorf("Could not read a valid value from file %v: %v", path, err)
+			return 0, err
+		}
+
+		data = curHostVal
+		cntr.SetData(path, name, data)
+	}
+
+	data += "\n"
+
+	return copyResultBuffer(req.Data, []byte(data))
+}
+
+func (h *BoundedIntBaseHandler) Write(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	name := n.Name()
+	path := n.Path()
+	cntr := req.Container
+
+	// Ensure operation is generated from within a registered sys container.
+	if cntr == nil {
+		logger.Errorf("Could not find the container originating this request (pid %v)",
+			req.Pid)
+		return 0, errors.New("Container not found")
+	}
+
+	sysctl, ok := boundedIntSysctls[path]
+	if !ok {
+		logger.Errorf("Unsupported bounded integer sysctl %v", path)
+		return 0, fuse.IOerror{Code: syscall.EIO}
+	}
+
+	newVal := strings.TrimSpace(string(req.Data))
+	newValInt, err := strconv.Atoi(newVal)
+	if err != nil {
+		return 0, fuse.IOerror{Code: syscall.EINVAL}
+	}
+
+	// Ensure that only proper values are allowed as per this resource's
+	// supported values.
+	if newValInt < sysctl.min || newValInt > sysctl.max {
+		return 0, fuse.IOerror{Code: syscall.EINVAL}
+	}
+
+	// Sticky values can't be changed once set.
+	if sysctl.sticky != "" && newVal != sysctl.sticky {
+		if curVal, ok := cntr.Data(path, name); ok && curVal == sysctl.sticky {
+			return 0, fuse.IOerror{Code: syscall.EPERM}
+		}
+	}
+
+	// Store the new value within the container struct.
+	cntr.SetData(path, name, newVal)
+
+	return len(req.Data), nil
+}
+
+func (h *BoundedIntBaseHandler) ReadDirAll(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) ([]os.FileInfo, error) {
+
+	return nil, nil
+}
+
+// Initial value of this resource within a sys container.
+func (h *BoundedIntBaseHandler) HostSnapshot(n domain.IOnodeIface) (string, bool) {
+
+	val, err := boundedIntHostVal(n, boundedIntSysctls[n.Path()])
+	if err != nil {
+		return "", false
+	}
+
+	return val, true
+}
+
+func (h *BoundedIntBaseHandler) GetName() string {
+	return h.Name
+}
+
+func (h *BoundedIntBaseHandler) GetPath() string {
+	return h.Path
+}
+
+func (h *BoundedIntBaseHandler) GetEnabled() bool {
+	return getEnabled(&h.Enabled)
+}
+
+func (h *BoundedIntBaseHandler) GetType() domain.HandlerType {
+	return h.Type
+}
+
+func (h *BoundedIntBaseHandler) GetService() domain.HandlerServiceIface {
+	return h.Service
+}
+
+func (h *BoundedIntBaseHandler) SetEnabled(val bool) {
+	setEnabled(&h.Enabled, val)
+}
+
+func (h *BoundedIntBaseHandler) SetService(hs domain.HandlerServiceIface) {
+	h.Service = hs
+}
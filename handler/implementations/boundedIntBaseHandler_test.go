@@ -0,0 +1,205 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package implementations_test
+
+import (
+	"syscall"
+	"testing"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/fuse"
+	"github.com/nestybox/sysbox-fs/handler/handlertest"
+	"github.com/nestybox/sysbox-fs/handler/implementations"
+)
+
+func TestBoundedIntBaseHandler(t *testing.T) {
+
+	const (
+		dumpable = "/proc/sys/fs/suid_dumpable"
+		paranoid = "/proc/sys/kernel/perf_event_paranoid"
+		bpf      = "/proc/sys/kernel/unprivileged_bpf_disabled"
+		detach   = "/proc/sys/fs/may_detach_mounts"
+		watches  = "/proc/sys/fs/epoll/max_user_watches"
+		mountMax = "/proc/sys/fs/mount-max"
+	)
+
+	env := handlertest.NewEnv()
+	cntr := env.NewContainer("c1", domain.ResourceLimits{})
+
+	h := &implementations.BoundedIntBaseHandler{
+		Name:    "boundedInt",
+		Path:    "boundedInt",
+		Type:    domain.NODE_SUBSTITUTION,
+		Enabled: true,
+	}
+
+	handlertest.Run(t, env, h, cntr, []handlertest.Case{
+		{
+			Name: "read host value",
+			Op:   domain.FuseOpRead,
+			Path: dumpable,
+			Host: map[string]string{dumpable: "0\n"},
+			Want: "0\n",
+		},
+		{
+			Name:     "write within range",
+			Op:       domain.FuseOpWrite,
+			Path:     dumpable,
+			Data:     "2\n",
+			WantHost: map[string]string{dumpable: "0\n"},
+		},
+		{
+			Name: "read written value",
+			Op:   domain.FuseOpRead,
+			Path: dumpable,
+			Want: "2\n",
+		},
+		{
+			Name:    "write above range",
+			Op:      domain.FuseOpWrite,
+			Path:    dumpable,
+			Data:    "3\n",
+			WantErr: fuse.IOerror{Code: syscall.EINVAL},
+		},
+		{
+			Name:    "write non-integer",
+			Op:      domain.FuseOpWrite,
+			Path:    dumpable,
+			Data:    "on\n",
+			WantErr: fuse.IOerror{Code: syscall.EINVAL},
+		},
+		{
+			Name: "write negative within range",
+			Op:   domain.FuseOpWrite,
+			Path: paranoid,
+			Data: "-1\n",
+			Host: map[string]string{paranoid: "2\n"},
+		},
+		{
+			Name:     "write below range",
+			Op:       domain.FuseOpWrite,
+			Path:     paranoid,
+			Data:     "-2\n",
+			WantErr:  fuse.IOerror{Code: syscall.EINVAL},
+			WantHost: map[string]string{paranoid: "2\n"},
+		},
+		{
+			Name:    "read invalid host value",
+			Op:      domain.FuseOpRead,
+			Path:    bpf,
+			Host:    map[string]string{bpf: "unknown\n"},
+			WantErr: fuse.IOerror{Code: syscall.EINVAL},
+		},
+		{
+			Name: "write sticky value",
+			Op:   domain.FuseOpWrite,
+			Path: bpf,
+			Data: "1\n",
+		},
+		{
+			Name:    "write over sticky value",
+			Op:      domain.FuseOpWrite,
+			Path:    bpf,
+			Data:    "0\n",
+			WantErr: fuse.IOerror{Code: syscall.EPERM},
+		},
+		{
+			Name: "rewrite sticky value",
+			Op:   domain.FuseOpWrite,
+			Path: bpf,
+			Data: "1\n",
+		},
+		{
+			Name: "lookup missing sysctl with fallback",
+			Op:   domain.FuseOpLookup,
+			Path: detach,
+		},
+		{
+			Name: "read missing sysctl with fallback",
+			Op:   domain.FuseOpRead,
+			Path: detach,
+			Want: "1\n",
+		},
+		{
+			Name:    "write above fallback range",
+			Op:      domain.FuseOpWrite,
+			Path:    detach,
+			Data:    "2\n",
+			WantErr: fuse.IOerror{Code: syscall.EINVAL},
+		},
+		{
+			Name: "write within fallback range",
+			Op:   domain.FuseOpWrite,
+			Path: detach,
+			Data: "0\n",
+		},
+		{
+			Name: "read written fallback value",
+			Op:   domain.FuseOpRead,
+			Path: detach,
+			Want: "0\n",
+		},
+		{
+			Name: "read unbounded host value",
+			Op:   domain.FuseOpRead,
+			Path: watches,
+			Host: map[string]string{watches: "1048576\n"},
+			Want: "1048576\n",
+		},
+		{
+			Name:     "write below host value",
+			Op:       domain.FuseOpWrite,
+			Path:     watches,
+			Data:     "8192\n",
+			WantHost: map[string]string{watches: "1048576\n"},
+		},
+		{
+			Name:     "write above host value",
+			Op:       domain.FuseOpWrite,
+			Path:     watches,
+			Data:     "4194304\n",
+			WantHost: map[string]string{watches: "1048576\n"},
+		},
+		{
+			Name: "read written unbounded value",
+			Op:   domain.FuseOpRead,
+			Path: watches,
+			Want: "4194304\n",
+		},
+		{
+			Name:     "write above host mount limit",
+			Op:       domain.FuseOpWrite,
+			Path:     mountMax,
+			Data:     "200000\n",
+			Host:     map[string]string{mountMax: "100000\n"},
+			WantHost: map[string]string{mountMax: "100000\n"},
+		},
+		{
+			Name: "read written mount limit",
+			Op:   domain.FuseOpRead,
+			Path: mountMax,
+			Want: "200000\n",
+		},
+		{
+			Name:    "write zero mount limit",
+			Op:      domain.FuseOpWrite,
+			Path:    mountMax,
+			Data:    "0\n",
+			WantErr: fuse.IOerror{Code: syscall.EINVAL},
+		},
+	})
+}
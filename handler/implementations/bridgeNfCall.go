@@ -0,0 +1,206 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package implementations
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/fuse"
+)
+
+//
+// /proc/sys/net/bridge/bridge-nf-call-{iptables,ip6tables,arptables} handler
+//
+// Documentation: Setting these to 1 passes bridged traffic to the iptables,
+// ip6tables and arptables chains respectively. The sysctls are exposed by the
+// br_netfilter module, and default to 1 once it's loaded.
+//
+// Kubernetes' tooling (e.g. kubeadm preflight checks) expects these to read 1
+// within the nodes it deploys, which may well run inside sys containers. When
+// br_netfilter exposes the sysctls within the container's netns, accesses are
+// carried out there (via nsenter). Otherwise, their values are emulated at sys
+// container level, starting with the kernel's default.
+//
+
+const bridgeNfCallDefault = "1"
+
+type BridgeNfCallHandler struct {
+	Name      string
+	Path      string
+	Type      domain.HandlerType
+	Enabled   bool
+	Cacheable bool
+	Service   domain.HandlerServiceIface
+}
+
+func (h *BridgeNfCallHandler) Lookup(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (os.FileInfo, error) {
+
+	commonHandler, ok := h.Service.FindHandler("commonHandler")
+	if !ok {
+		return nil, fmt.Errorf("No commonHandler found")
+	}
+
+	info, err := commonHandler.Lookup(n, req)
+	if isNotExist(err) {
+		return syntheticFileInfo(filepath.Base(n.Path()), 0644), nil
+	}
+
+	return info, err
+}
+
+func (h *BridgeNfCallHandler) Getattr(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (*syscall.Stat_t, error) {
+
+	return nil, nil
+}
+
+func (h *BridgeNfCallHandler) Open(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) error {
+
+	flags := n.OpenFlags()
+	if flags != syscall.O_RDONLY && flags != syscall.O_WRONLY {
+		return fuse.IOerror{Code: syscall.EACCES}
+	}
+
+	return nil
+}
+
+func (h *BridgeNfCallHandler) Close(n domain.IOnodeIface) error {
+
+	return nil
+}
+
+func (h *BridgeNfCallHandler) Read(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	// We are dealing with a single integer element being read, so we can save
+	// some cycles by returning right away if offset is any higher than zero.
+	if req.Offset > 0 {
+		return 0, io.EOF
+	}
+
+	cntr := req.Container
+
+	// Ensure operation is generated from within a registered sys container.
+	if cntr == nil {
+		logger.Errorf("Could not find the container originating this request (pid %v)",
+			req.Pid)
+		return 0, errors.New("Container not found")
+	}
+
+	commonHandler, ok := h.Service.FindHandler("commonHandler")
+	if !ok {
+		return 0, fmt.Errorf("No commonHandler found")
+	}
+
+	sz, err := commonHandler.Read(n, req)
+	if !isNotExist(err) {
+		return sz, err
+	}
+
+	// Emulated resource.
+	data, ok := cntr.Data(n.Path(), n.Name())
+	if !ok {
+		data = bridgeNfCallDefault
+	}
+
+	data += "\n"
+
+	return copyResultBuffer(req.Data, []byte(data))
+}
+
+func (h *BridgeNfCallHandler) Write(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	cntr := req.Container
+
+	// Ensure operation is generated from within a registered sys container.
+	if cntr == nil {
+		logger.Errorf("Could not find the container originating this request (pid %v)",
+			req.Pid)
+		return 0, errors.New("Container not found")
+	}
+
+	newVal := strings.TrimSpace(string(req.Data))
+	if _, err := strconv.Atoi(newVal); err != nil {
+		return 0, fuse.IOerror{Code: syscall.EINVAL}
+	}
+
+	commonHandler, ok := h.Service.FindHandler("commonHandler")
+	if !ok {
+		return 0, fmt.Errorf("No commonHandler found")
+	}
+
+	sz, err := commonHandler.Write(n, req)
+	if !isNotExist(err) {
+		return sz, err
+	}
+
+	// Emulated resource: store the new value within the container struct.
+	cntr.SetData(n.Path(), n.Name(), newVal)
+
+	return len(req.Data), nil
+}
+
+func (h *BridgeNfCallHandler) ReadDirAll(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) ([]os.FileInfo, error) {
+
+	return nil, nil
+}
+
+func (h *BridgeNfCallHandler) GetName() string {
+	return h.Name
+}
+
+func (h *BridgeNfCallHandler) GetPath() string {
+	return h.Path
+}
+
+func (h *BridgeNfCallHandler) GetEnabled() bool {
+	return getEnabled(&h.Enabled)
+}
+
+func (h *BridgeNfCallHandler) GetType() domain.HandlerType {
+	return h.Type
+}
+
+func (h *BridgeNfCallHandler) GetService() domain.HandlerServiceIface {
+	return h.Service
+}
+
+func (h *BridgeNfCallHandler) SetEnabled(val bool) {
+	setEnabled(&h.Enabled, val)
+}
+
+func (h *BridgeNfCallHandler) SetService(hs domain.HandlerServiceIface) {
+	h.Service = hs
+}
@@ -0,0 +1,102 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package implementations_test
+
+import (
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/fuse"
+	"github.com/nestybox/sysbox-fs/handler/implementations"
+	"github.com/nestybox/sysbox-fs/mocks"
+)
+
+func TestBridgeNfCallHandler(t *testing.T) {
+
+	const path = "/proc/sys/net/bridge/bridge-nf-call-iptables"
+
+	// br_netfilter sysctls not exposed within the container's netns.
+	enoent := fuse.IOerror{Code: syscall.ENOENT}
+
+	commonHandler := &mocks.HandlerIface{}
+	commonHandler.On("Lookup", mock.Anything, mock.Anything).Return(nil, enoent)
+	commonHandler.On("Read", mock.Anything, mock.Anything).Return(0, enoent)
+	commonHandler.On("Write", mock.Anything, mock.Anything).Return(0, enoent)
+
+	hs := &mocks.HandlerServiceIface{}
+	hs.On("FindHandler", "commonHandler").Return(commonHandler, true)
+
+	h := &implementations.BridgeNfCallHandler{
+		Name:      "bridgeNfCallIptables",
+		Path:      path,
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: true,
+		Service:   hs,
+	}
+
+	cntr := css.ContainerCreate(
+		"c1",
+		uint32(1001),
+		time.Time{},
+		231072,
+		65535,
+		231072,
+		65535,
+		nil,
+		nil,
+		nil,
+		nil,
+		domain.CgroupPaths{},
+		"",
+		domain.ResourceLimits{})
+
+	n := ios.NewIOnode("bridge-nf-call-iptables", path, 0)
+
+	// Test-case 1: Emulated node attributes.
+	info, err := h.Lookup(n, &domain.HandlerRequest{Pid: 1001, Container: cntr})
+	assert.NoError(t, err)
+	assert.Equal(t, "bridge-nf-call-iptables", info.Name())
+	assert.False(t, info.IsDir())
+	assert.NotNil(t, info.Sys().(*syscall.Stat_t))
+
+	read := func() string {
+		buf := make([]byte, 16)
+		sz, err := h.Read(n, &domain.HandlerRequest{Pid: 1001, Data: buf, Container: cntr})
+		assert.NoError(t, err)
+		return string(buf[:sz])
+	}
+
+	// Test-case 2: Emulated resources read the kernel's default.
+	assert.Equal(t, "1\n", read())
+
+	// Test-case 3: Writes are kept at container level.
+	sz, err := h.Write(n, &domain.HandlerRequest{Pid: 1001, Data: []byte("0\n"), Container: cntr})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, sz)
+	assert.Equal(t, "0\n", read())
+
+	// Test-case 4: Non-integer values are rejected.
+	_, err = h.Write(n, &domain.HandlerRequest{Pid: 1001, Data: []byte("on"), Container: cntr})
+	assert.Equal(t, fuse.IOerror{Code: syscall.EINVAL}, err)
+	assert.Equal(t, "0\n", read())
+}
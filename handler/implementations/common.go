@@ -17,18 +17,22 @@
 package implementations
 
 import (
+	"context"
 	"errors"
 	"io"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"syscall"
 
 	"github.com/nestybox/sysbox-fs/domain"
-
-	"github.com/sirupsen/logrus"
+	"github.com/nestybox/sysbox-fs/logging"
 )
 
+// Logger of the handlers subsystem.
+var logger = logging.Subsystem(logging.Handlers)
+
 //
 // Common Handler for all namespaced resources within /proc/sys subtree.
 //
@@ -45,11 +49,9 @@ func (h *CommonHandler) Lookup(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (os.FileInfo, error) {
 
-	logrus.Debugf("Executing Lookup() method for Req ID=%#x on %v handler", req.ID, h.Name)
-
 	// Ensure operation is generated from within a registered sys container.
 	if req.Container == nil {
-		logrus.Errorf("Could not find the container originating this request (pid %v)",
+		logger.Errorf("Could not find the container originating this request (pid %v)",
 			req.Pid)
 		return nil, errors.New("Container not found")
 	}
@@ -69,7 +71,7 @@ func (h *CommonHandler) Lookup(
 	)
 
 	// Launch nsenter-event.
-	err := nss.SendRequestEvent(event)
+	err := nss.SendRequestEvent(req.Context(), event)
 	if err != nil {
 		return nil, err
 	}
@@ -80,7 +82,7 @@ func (h *CommonHandler) Lookup(
 		return nil, responseMsg.Payload.(error)
 	}
 
-	info := responseMsg.Payload.(domain.FileInfo)
+	info := hostOwnedFileInfo(req.Container, responseMsg.Payload.(domain.FileInfo))
 
 	return info, nil
 }
@@ -89,11 +91,9 @@ func (h *CommonHandler) Getattr(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (*syscall.Stat_t, error) {
 
-	logrus.Debugf("Executing Getattr() method for Req ID=%#x on %v handler", req.ID, h.Name)
-
 	// Ensure operation is generated from within a registered sys container.
 	if req.Container == nil {
-		logrus.Errorf("Could not find the container originating this request (pid %v)",
+		logger.Errorf("Could not find the container originating this request (pid %v)",
 			req.Pid)
 		return nil, errors.New("Container not found")
 	}
@@ -110,11 +110,9 @@ func (h *CommonHandler) Open(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) error {
 
-	logrus.Debugf("Executing Open() method for Req ID=%#x on %v handler", req.ID, h.Name)
-
 	// Ensure operation is generated from within a registered sys container.
 	if req.Container == nil {
-		logrus.Errorf("Could not find the container originating this request (pid %v)",
+		logger.Errorf("Could not find the container originating this request (pid %v)",
 			req.Pid)
 		return errors.New("Container not found")
 	}
@@ -136,7 +134,7 @@ func (h *CommonHandler) Open(
 	)
 
 	// Launch nsenter-event.
-	err := nss.SendRequestEvent(event)
+	err := nss.SendRequestEvent(req.Context(), event)
 	if err != nil {
 		return err
 	}
@@ -152,8 +150,6 @@ func (h *CommonHandler) Open(
 
 func (h *CommonHandler) Close(node domain.IOnodeIface) error {
 
-	logrus.Debugf("Executing Close() method on %v handler", h.Name)
-
 	return nil
 }
 
@@ -161,8 +157,6 @@ func (h *CommonHandler) Read(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (int, error) {
 
-	logrus.Debugf("Executing Read() method for Req ID=%#x on %v handler", req.ID, h.Name)
-
 	if req.Offset > 0 {
 		return 0, io.EOF
 	}
@@ -172,7 +166,7 @@ func (h *CommonHandler) Read(
 
 	// Ensure operation is generated from within a registered sys container.
 	if req.Container == nil {
-		logrus.Errorf("Could not find the container originating this request (pid %v)",
+		logger.Errorf("Could not find the container originating this request (pid %v)",
 			req.Pid)
 		return 0, errors.New("Container not found")
 	}
@@ -202,7 +196,7 @@ func (h *CommonHandler) Read(
 
 		data, ok = cntr.Data(path, name)
 		if !ok {
-			data, err = h.fetchFile(n, process)
+			data, err = h.fetchFile(req.Context(), n, process)
 			if err != nil {
 				return 0, err
 			}
@@ -210,7 +204,7 @@ func (h *CommonHandler) Read(
 			cntr.SetData(path, name, data)
 		}
 	} else {
-		data, err = h.fetchFile(n, process)
+		data, err = h.fetchFile(req.Context(), n, process)
 		if err != nil {
 			return 0, err
 		}
@@ -225,14 +219,12 @@ func (h *CommonHandler) Write(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (int, error) {
 
-	logrus.Debugf("Executing Write() method for Req ID=%#x on %v handler", req.ID, h.Name)
-
 	name := n.Name()
 	path := n.Path()
 
 	// Ensure operation is generated from within a registered sys container.
 	if req.Container == nil {
-		logrus.Errorf("Could not find the container originating this request (pid %v)",
+		logger.Errorf("Could not find the container originating this request (pid %v)",
 			req.Pid)
 		return 0, errors.New("Container not found")
 	}
@@ -246,13 +238,13 @@ func (h *CommonHandler) Write(
 	// If caching is enabled, store the data in the cache and do a write-through to the
 	// host FS. Otherwise just do the write-through.
 	if h.Cacheable && domain.ProcessNsMatch(process, cntr.InitProc()) {
-		if err := h.pushFile(n, process, newContent); err != nil {
+		if err := h.pushFile(req.Context(), n, process, newContent); err != nil {
 			return 0, err
 		}
 		cntr.SetData(path, name, newContent)
 
 	} else {
-		if err := h.pushFile(n, process, newContent); err != nil {
+		if err := h.pushFile(req.Context(), n, process, newContent); err != nil {
 			return 0, err
 		}
 	}
@@ -260,16 +252,16 @@ func (h *CommonHandler) Write(
 	return len(req.Data), nil
 }
 
+// Maximum number of files prefetched upon ReadDirAll().
+const prefetchMax = 128
+
 func (h *CommonHandler) ReadDirAll(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) ([]os.FileInfo, error) {
 
-	logrus.Debugf("Executing ReadDirAll() method for Req ID=%#x on %v handler",
-		req.ID, h.Name)
-
 	// Ensure operation is generated from within a registered sys container.
 	if req.Container == nil {
-		logrus.Errorf("Could not find the container originating this request (pid %v)",
+		logger.Errorf("Could not find the container originating this request (pid %v)",
 			req.Pid)
 		return nil, errors.New("Container not found")
 	}
@@ -289,7 +281,7 @@ func (h *CommonHandler) ReadDirAll(
 	)
 
 	// Launch nsenter-event.
-	err := nss.SendRequestEvent(event)
+	err := nss.SendRequestEvent(req.Context(), event)
 	if err != nil {
 		return nil, err
 	}
@@ -318,25 +310,88 @@ func (h *CommonHandler) ReadDirAll(
 	// convert []T1 struct to a []T2 one, we must iterate through each element
 	// and do the conversion one element at a time.
 	dirEntries := responseMsg.Payload.([]domain.FileInfo)
-	for _, v := range dirEntries {
+	for i, v := range dirEntries {
+		v = hostOwnedFileInfo(req.Container, v)
+		dirEntries[i] = v
+
 		// Append nodes that don't overlap with emulated resources
 		if _, ok := osEmulatedFileEntries[v.Name()]; !ok {
 			osFileEntries = append(osFileEntries, v)
 		}
 	}
 
+	h.prefetchFiles(n, req, dirEntries, osEmulatedFileEntries)
+
 	return osFileEntries, nil
 }
 
+//
+// Directory listings are usually followed by reads of each of the listed
+// files (e.g. "sysctl -a"). For cacheable resources, we populate the cache
+// with the content of all the (non-emulated) files of the directory through a
+// single nsenter batch, instead of spawning one nsenter process per file.
+//
+func (h *CommonHandler) prefetchFiles(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest,
+	entries []domain.FileInfo,
+	emulated map[string]os.FileInfo) {
+
+	if !h.Cacheable {
+		return
+	}
+
+	prs := h.Service.ProcessService()
+	process := prs.ProcessCreate(req.Pid, req.Uid, req.Gid)
+	cntr := req.Container
+
+	if !domain.ProcessNsMatch(process, cntr.InitProc()) {
+		return
+	}
+
+	var paths []string
+	for _, v := range entries {
+		if _, ok := emulated[v.Name()]; ok {
+			continue
+		}
+		// Skip non-regular and non-readable files (e.g. write-only sysctls).
+		if !v.Mode().IsRegular() || v.Mode().Perm()&0444 == 0 {
+			continue
+		}
+		path := filepath.Join(n.Path(), v.Name())
+		if _, ok := cntr.Data(path, v.Name()); ok {
+			continue
+		}
+		paths = append(paths, path)
+		if len(paths) == prefetchMax {
+			break
+		}
+	}
+
+	if len(paths) == 0 {
+		return
+	}
+
+	// Prefetching is just an optimization, so errors are simply ignored: files
+	// not prefetched will be fetched individually upon access.
+	contents, err := h.fetchFiles(req.Context(), paths, process)
+	if err != nil {
+		logger.Debugf("Could not prefetch files of %v: %v", n.Path(), err)
+		return
+	}
+
+	for path, data := range contents {
+		cntr.SetData(path, filepath.Base(path), data)
+	}
+}
+
 func (h *CommonHandler) Setattr(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) error {
 
-	logrus.Debugf("Executing Setattr() method for Req ID=%#x on %v handler", req.ID, h.Name)
-
 	// Ensure operation is generated from within a registered sys container.
 	if req.Container == nil {
-		logrus.Errorf("Could not find the container originating this request (pid %v)",
+		logger.Errorf("Could not find the container originating this request (pid %v)",
 			req.Pid)
 		return errors.New("Container not found")
 	}
@@ -358,7 +413,7 @@ func (h *CommonHandler) Setattr(
 	)
 
 	// Launch nsenter-event.
-	err := nss.SendRequestEvent(event)
+	err := nss.SendRequestEvent(req.Context(), event)
 	if err != nil {
 		return err
 	}
@@ -372,8 +427,164 @@ func (h *CommonHandler) Setattr(
 	return nil
 }
 
+//
+// Namespaced filesystem operations not covered by the handler interface, for
+// handlers that need them to emulate their resources (e.g. binfmt_misc or
+// sysfs dirs).
+//
+
+// Stat returns the attributes of the given node (or of the symlink itself,
+// if the node is a symlink) as seen within the container namespaces.
+func (h *CommonHandler) Stat(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (os.FileInfo, error) {
+
+	responseMsg, err := h.nsenterRequest(req, &domain.NSenterMessage{
+		Type: domain.StatRequest,
+		Payload: &domain.StatPayload{
+			Entry: n.Path(),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return responseMsg.Payload.(domain.FileInfo), nil
+}
+
+// Readlink returns the target of the given symlink node.
+func (h *CommonHandler) Readlink(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (string, error) {
+
+	responseMsg, err := h.nsenterRequest(req, &domain.NSenterMessage{
+		Type: domain.ReadlinkRequest,
+		Payload: &domain.ReadlinkPayload{
+			Link: n.Path(),
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return responseMsg.Payload.(string), nil
+}
+
+// Mkdir creates the directory represented by the given node.
+func (h *CommonHandler) Mkdir(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest,
+	mode os.FileMode) error {
+
+	_, err := h.nsenterRequest(req, &domain.NSenterMessage{
+		Type: domain.MkdirRequest,
+		Payload: &domain.MkdirPayload{
+			Dir:  n.Path(),
+			Mode: mode,
+		},
+	})
+
+	return err
+}
+
+// Chown changes the ownership of the given node. Uid / gid values of -1 are
+// left unchanged.
+func (h *CommonHandler) Chown(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest,
+	uid int,
+	gid int) error {
+
+	_, err := h.nsenterRequest(req, &domain.NSenterMessage{
+		Type: domain.ChownRequest,
+		Payload: &domain.ChownPayload{
+			Entry: n.Path(),
+			Uid:   uid,
+			Gid:   gid,
+		},
+	})
+
+	return err
+}
+
+// CgroupPaths returns the cgroup membership (controller -> path) of the process
+// originating the request, with paths relative to the container's cgroup
+// namespace root. The cgroup-v2 unified hierarchy is keyed by "".
+func (h *CommonHandler) CgroupPaths(
+	req *domain.HandlerRequest) (map[string]string, error) {
+
+	// Ensure operation is generated from within a registered sys container.
+	if req.Container == nil {
+		logger.Errorf("Could not find the container originating this request (pid %v)",
+			req.Pid)
+		return nil, errors.New("Container not found")
+	}
+
+	// Create nsenterEvent to initiate interaction with container namespaces.
+	// The user namespace is joined to be allowed into the cgroup one.
+	nss := h.Service.NSenterService()
+	event := nss.NewEvent(
+		req.Pid,
+		&domain.CgroupNSs,
+		&domain.NSenterMessage{
+			Type: domain.CgroupRequest,
+			Payload: &domain.CgroupPayload{
+				Pid: req.Pid,
+			},
+		},
+		nil,
+	)
+
+	// Launch nsenter-event.
+	err := nss.SendRequestEvent(req.Context(), event)
+	if err != nil {
+		return nil, err
+	}
+
+	// Obtain nsenter-event response.
+	responseMsg := nss.ReceiveResponseEvent(event)
+	if responseMsg.Type == domain.ErrorResponse {
+		return nil, responseMsg.Payload.(error)
+	}
+
+	return responseMsg.Payload.(map[string]string), nil
+}
+
+// Auxiliary method to send a request to the namespaces of the process
+// originating the handler request, and obtain its (non-error) response.
+func (h *CommonHandler) nsenterRequest(
+	req *domain.HandlerRequest,
+	msg *domain.NSenterMessage) (*domain.NSenterMessage, error) {
+
+	// Ensure operation is generated from within a registered sys container.
+	if req.Container == nil {
+		logger.Errorf("Could not find the container originating this request (pid %v)",
+			req.Pid)
+		return nil, errors.New("Container not found")
+	}
+
+	// Create nsenterEvent to initiate interaction with container namespaces.
+	nss := h.Service.NSenterService()
+	event := nss.NewEvent(req.Pid, &domain.AllNSsButMount, msg, nil)
+
+	// Launch nsenter-event.
+	err := nss.SendRequestEvent(req.Context(), event)
+	if err != nil {
+		return nil, err
+	}
+
+	// Obtain nsenter-event response.
+	responseMsg := nss.ReceiveResponseEvent(event)
+	if responseMsg.Type == domain.ErrorResponse {
+		return nil, responseMsg.Payload.(error)
+	}
+
+	return responseMsg, nil
+}
+
 // Auxiliary method to fetch the content of any given file within a container.
 func (h *CommonHandler) fetchFile(
+	ctx context.Context,
 	n domain.IOnodeIface,
 	process domain.ProcessIface) (string, error) {
 
@@ -393,7 +604,7 @@ func (h *CommonHandler) fetchFile(
 
 	// Launch nsenter-event to obtain file state within container
 	// namespaces.
-	err := nss.SendRequestEvent(event)
+	err := nss.SendRequestEvent(ctx, event)
 	if err != nil {
 		return "", err
 	}
@@ -409,8 +620,68 @@ func (h *CommonHandler) fetchFile(
 	return info, nil
 }
 
+// Auxiliary method to fetch the content of a set of files within a container
+// through a single nsenter batch request. Files that can't be read are left
+// out of the returned map.
+func (h *CommonHandler) fetchFiles(
+	ctx context.Context,
+	paths []string,
+	process domain.ProcessIface) (map[string]string, error) {
+
+	batch := make(domain.BatchPayload, 0, len(paths))
+	for _, path := range paths {
+		batch = append(batch, domain.NSenterMessage{
+			Type: domain.ReadFileRequest,
+			Payload: &domain.ReadFilePayload{
+				File: path,
+			},
+		})
+	}
+
+	// Create nsenterEvent to initiate interaction with container namespaces.
+	nss := h.Service.NSenterService()
+	event := nss.NewEvent(
+		process.Pid(),
+		&domain.AllNSsButMount,
+		&domain.NSenterMessage{
+			Type:    domain.BatchRequest,
+			Payload: batch,
+		},
+		nil,
+	)
+
+	// Launch nsenter-event to obtain files' state within container
+	// namespaces.
+	err := nss.SendRequestEvent(ctx, event)
+	if err != nil {
+		return nil, err
+	}
+
+	// Obtain nsenter-event response.
+	responseMsg := nss.ReceiveResponseEvent(event)
+	if responseMsg.Type == domain.ErrorResponse {
+		return nil, responseMsg.Payload.(error)
+	}
+
+	results := responseMsg.Payload.(domain.BatchPayload)
+	if len(results) != len(paths) {
+		return nil, errors.New("Unexpected nsenter batch response")
+	}
+
+	contents := make(map[string]string, len(paths))
+	for i, res := range results {
+		if res.Type != domain.ReadFileResponse {
+			continue
+		}
+		contents[paths[i]] = res.Payload.(string)
+	}
+
+	return contents, nil
+}
+
 // Auxiliary method to inject content into any given file within a container.
 func (h *CommonHandler) pushFile(
+	ctx context.Context,
 	n domain.IOnodeIface,
 	process domain.ProcessIface,
 	s string) error {
@@ -432,7 +703,7 @@ func (h *CommonHandler) pushFile(
 
 	// Launch nsenter-event to write file state within container
 	// namespaces.
-	err := nss.SendRequestEvent(event)
+	err := nss.SendRequestEvent(ctx, event)
 	if err != nil {
 		return err
 	}
@@ -455,7 +726,7 @@ func (h *CommonHandler) GetPath() string {
 }
 
 func (h *CommonHandler) GetEnabled() bool {
-	return h.Enabled
+	return getEnabled(&h.Enabled)
 }
 
 func (h *CommonHandler) GetType() domain.HandlerType {
@@ -467,7 +738,7 @@ func (h *CommonHandler) GetService() domain.HandlerServiceIface {
 }
 
 func (h *CommonHandler) SetEnabled(val bool) {
-	h.Enabled = val
+	setEnabled(&h.Enabled, val)
 }
 
 func (h *CommonHandler) SetService(hs domain.HandlerServiceIface) {
@@ -34,6 +34,7 @@ import (
 	"github.com/nestybox/sysbox-fs/state"
 	"github.com/nestybox/sysbox-fs/sysio"
 	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/mock"
 )
 
 // Sysbox-fs global services for all handler's testing consumption.
@@ -59,7 +60,7 @@ func TestMain(m *testing.M) {
 	css = state.NewContainerStateService()
 
 	prs.Setup(ios)
-	css.Setup(nil, prs, ios)
+	css.Setup(nil, prs, ios, "")
 
 	// HandlerService's common mocking instructions.
 	hds.On("NSenterService").Return(nss)
@@ -107,7 +108,12 @@ func TestCommonHandler_Lookup(t *testing.T) {
 				231072,
 				65535,
 				nil,
-				nil),
+				nil,
+				nil,
+				nil,
+				domain.CgroupPaths{},
+				"",
+				domain.ResourceLimits{}),
 		},
 	}
 
@@ -119,6 +125,29 @@ func TestCommonHandler_Lookup(t *testing.T) {
 		},
 	}
 
+	// Valid method arguments -- sys container with user-ns id mappings.
+	var a3 = args{
+		n: ios.NewIOnode("net", "/proc/sys/net", 0),
+		req: &domain.HandlerRequest{
+			Pid: 1001,
+			Container: css.ContainerCreate(
+				"c1",
+				uint32(1001),
+				time.Time{},
+				231072,
+				65535,
+				231072,
+				65535,
+				nil,
+				nil,
+				[]domain.IDMapping{{ContainerID: 0, HostID: 231072, Size: 65535}},
+				[]domain.IDMapping{{ContainerID: 0, HostID: 231072, Size: 65535}},
+				domain.CgroupPaths{},
+				"",
+				domain.ResourceLimits{}),
+		},
+	}
+
 	tests := []struct {
 		name       string
 		fields     fields
@@ -166,7 +195,7 @@ func TestCommonHandler_Lookup(t *testing.T) {
 					nsenterEventReq.ReqMsg,
 					(*domain.NSenterMessage)(nil)).Return(nsenterEventReq)
 
-				nss.On("SendRequestEvent", nsenterEventReq).Return(nil)
+				nss.On("SendRequestEvent", mock.Anything, nsenterEventReq).Return(nil)
 				nss.On("ReceiveResponseEvent", nsenterEventReq).Return(nsenterEventResp.ResMsg)
 			},
 		},
@@ -221,7 +250,55 @@ func TestCommonHandler_Lookup(t *testing.T) {
 					nsenterEventReq.ReqMsg,
 					(*domain.NSenterMessage)(nil)).Return(nsenterEventReq)
 
-				nss.On("SendRequestEvent", nsenterEventReq).Return(nil)
+				nss.On("SendRequestEvent", mock.Anything, nsenterEventReq).Return(nil)
+				nss.On("ReceiveResponseEvent", nsenterEventReq).Return(nsenterEventResp.ResMsg)
+			},
+		},
+		{
+			//
+			// Test-case 4: Verify that the owner of the node, as seen within the
+			// container's user-ns, is translated into the host's user-ns. Ids not
+			// mapped into the container (overflow id) are deemed host's root ones.
+			//
+			name:   "4",
+			fields: f1,
+			args:   a3,
+			want: domain.FileInfo{
+				Fname: a3.n.Path(),
+				Fsys:  &syscall.Stat_t{Uid: 232072, Gid: 0},
+			},
+			wantErr:    false,
+			wantErrVal: nil,
+			prepare: func() {
+
+				// Expected nsenter request.
+				nsenterEventReq := &nsenter.NSenterEvent{
+					Pid:       a3.req.Pid,
+					Namespace: &domain.AllNSsButMount,
+					ReqMsg: &domain.NSenterMessage{
+						Type:    domain.LookupRequest,
+						Payload: &domain.LookupPayload{a3.n.Path()},
+					},
+				}
+
+				// Expected nsenter response.
+				nsenterEventResp := &nsenter.NSenterEvent{
+					ResMsg: &domain.NSenterMessage{
+						Type: domain.LookupResponse,
+						Payload: domain.FileInfo{
+							Fname: a3.n.Path(),
+							Fsys:  &syscall.Stat_t{Uid: 1000, Gid: domain.OverflowID}},
+					},
+				}
+
+				nss.On(
+					"NewEvent",
+					a3.req.Pid,
+					&domain.AllNSsButMount,
+					nsenterEventReq.ReqMsg,
+					(*domain.NSenterMessage)(nil)).Return(nsenterEventReq)
+
+				nss.On("SendRequestEvent", mock.Anything, nsenterEventReq).Return(nil)
 				nss.On("ReceiveResponseEvent", nsenterEventReq).Return(nsenterEventResp.ResMsg)
 			},
 		},
@@ -307,7 +384,12 @@ func TestCommonHandler_Getattr(t *testing.T) {
 				231072,
 				65535,
 				nil,
-				nil),
+				nil,
+				nil,
+				nil,
+				domain.CgroupPaths{},
+				"",
+				domain.ResourceLimits{}),
 		},
 	}
 
@@ -432,7 +514,12 @@ func TestCommonHandler_Open(t *testing.T) {
 				231072,
 				65535,
 				nil,
-				nil),
+				nil,
+				nil,
+				nil,
+				domain.CgroupPaths{},
+				"",
+				domain.ResourceLimits{}),
 		},
 	}
 
@@ -491,7 +578,7 @@ func TestCommonHandler_Open(t *testing.T) {
 					nsenterEventReq.ReqMsg,
 					(*domain.NSenterMessage)(nil)).Return(nsenterEventReq)
 
-				nss.On("SendRequestEvent", nsenterEventReq).Return(nil)
+				nss.On("SendRequestEvent", mock.Anything, nsenterEventReq).Return(nil)
 				nss.On("ReceiveResponseEvent", nsenterEventReq).Return(nsenterEventResp.ResMsg)
 			},
 		},
@@ -547,7 +634,7 @@ func TestCommonHandler_Open(t *testing.T) {
 					nsenterEventReq.ReqMsg,
 					(*domain.NSenterMessage)(nil)).Return(nsenterEventReq)
 
-				nss.On("SendRequestEvent", nsenterEventReq).Return(nil)
+				nss.On("SendRequestEvent", mock.Anything, nsenterEventReq).Return(nil)
 				nss.On("ReceiveResponseEvent", nsenterEventReq).Return(nsenterEventResp.ResMsg)
 			},
 		},
@@ -637,7 +724,12 @@ func TestCommonHandler_Read(t *testing.T) {
 				231072,
 				65535,
 				nil,
-				nil),
+				nil,
+				nil,
+				nil,
+				domain.CgroupPaths{},
+				"",
+				domain.ResourceLimits{}),
 		},
 	}
 
@@ -703,7 +795,7 @@ func TestCommonHandler_Read(t *testing.T) {
 					nsenterEventReq.ReqMsg,
 					(*domain.NSenterMessage)(nil)).Return(nsenterEventReq)
 
-				nss.On("SendRequestEvent", nsenterEventReq).Return(nil)
+				nss.On("SendRequestEvent", mock.Anything, nsenterEventReq).Return(nil)
 				nss.On("ReceiveResponseEvent", nsenterEventReq).Return(nsenterEventResp.ResMsg)
 			},
 		},
@@ -766,7 +858,7 @@ func TestCommonHandler_Read(t *testing.T) {
 					nsenterEventReq.ReqMsg,
 					(*domain.NSenterMessage)(nil)).Return(nsenterEventReq)
 
-				nss.On("SendRequestEvent", nsenterEventReq).Return(nil)
+				nss.On("SendRequestEvent", mock.Anything, nsenterEventReq).Return(nil)
 				nss.On("ReceiveResponseEvent", nsenterEventReq).Return(nsenterEventResp.ResMsg)
 			},
 		},
@@ -846,7 +938,12 @@ func TestCommonHandler_Write(t *testing.T) {
 				231072,
 				65535,
 				nil,
-				nil),
+				nil,
+				nil,
+				nil,
+				domain.CgroupPaths{},
+				"",
+				domain.ResourceLimits{}),
 		},
 	}
 
@@ -913,7 +1010,7 @@ func TestCommonHandler_Write(t *testing.T) {
 					nsenterEventReq.ReqMsg,
 					(*domain.NSenterMessage)(nil)).Return(nsenterEventReq)
 
-				nss.On("SendRequestEvent", nsenterEventReq).Return(nil)
+				nss.On("SendRequestEvent", mock.Anything, nsenterEventReq).Return(nil)
 				nss.On("ReceiveResponseEvent", nsenterEventReq).Return(nsenterEventResp.ResMsg)
 			},
 		},
@@ -977,7 +1074,7 @@ func TestCommonHandler_Write(t *testing.T) {
 					nsenterEventReq.ReqMsg,
 					(*domain.NSenterMessage)(nil)).Return(nsenterEventReq)
 
-				nss.On("SendRequestEvent", nsenterEventReq).Return(nil)
+				nss.On("SendRequestEvent", mock.Anything, nsenterEventReq).Return(nil)
 				nss.On("ReceiveResponseEvent", nsenterEventReq).Return(nsenterEventResp.ResMsg)
 			},
 		},
@@ -1056,7 +1153,12 @@ func TestCommonHandler_ReadDirAll(t *testing.T) {
 				231072,
 				65535,
 				nil,
-				nil),
+				nil,
+				nil,
+				nil,
+				domain.CgroupPaths{},
+				"",
+				domain.ResourceLimits{}),
 		},
 	}
 
@@ -1078,6 +1180,32 @@ func TestCommonHandler_ReadDirAll(t *testing.T) {
 		},
 	}
 
+	// Directory with regular (readable and non-readable) and dir entries.
+	var t4_entries = []domain.FileInfo{
+		domain.FileInfo{
+			Fname: "ipv4",
+			Fmode: os.ModeDir | 0555,
+		},
+		domain.FileInfo{
+			Fname: "somaxconn",
+			Fmode: 0644,
+		},
+		domain.FileInfo{
+			Fname: "unreadable",
+			Fmode: 0600,
+		},
+		domain.FileInfo{
+			Fname: "writeonly",
+			Fmode: 0200,
+		},
+	}
+	var t4_result = []os.FileInfo{
+		t4_entries[0],
+		t4_entries[1],
+		t4_entries[2],
+		t4_entries[3],
+	}
+
 	tests := []struct {
 		name       string
 		fields     fields
@@ -1086,6 +1214,7 @@ func TestCommonHandler_ReadDirAll(t *testing.T) {
 		wantErr    bool
 		wantErrVal error
 		prepare    func()
+		verify     func()
 	}{
 		{
 			//
@@ -1139,7 +1268,7 @@ func TestCommonHandler_ReadDirAll(t *testing.T) {
 					nsenterEventReq.ReqMsg,
 					(*domain.NSenterMessage)(nil)).Return(nsenterEventReq)
 
-				nss.On("SendRequestEvent", nsenterEventReq).Return(nil)
+				nss.On("SendRequestEvent", mock.Anything, nsenterEventReq).Return(nil)
 				nss.On("ReceiveResponseEvent", nsenterEventReq).Return(nsenterEventResp.ResMsg)
 			},
 		},
@@ -1202,10 +1331,115 @@ func TestCommonHandler_ReadDirAll(t *testing.T) {
 					nsenterEventReq.ReqMsg,
 					(*domain.NSenterMessage)(nil)).Return(nsenterEventReq)
 
-				nss.On("SendRequestEvent", nsenterEventReq).Return(nil)
+				nss.On("SendRequestEvent", mock.Anything, nsenterEventReq).Return(nil)
 				nss.On("ReceiveResponseEvent", nsenterEventReq).Return(nsenterEventResp.ResMsg)
 			},
 		},
+		{
+			//
+			// Test-case 4: Verify that the content of the readable files of a
+			// cacheable directory is prefetched through a single batch request.
+			//
+			name:       "4",
+			fields:     f1,
+			args:       a1,
+			want:       t4_result,
+			wantErr:    false,
+			wantErrVal: nil,
+			prepare: func() {
+
+				// Setup dynamic state associated to tested container.
+				c1 := a1.req.Container
+				c1.SetService(css)
+				_ = c1.SetInitProc(c1.InitPid(), c1.UID(), c1.GID())
+				c1.InitProc().CreateNsInodes(123456)
+
+				// Expected nsenter requests.
+				nsenterEventReq := &nsenter.NSenterEvent{
+					Pid:       a1.req.Pid,
+					Namespace: &domain.AllNSsButMount,
+					ReqMsg: &domain.NSenterMessage{
+						Type: domain.ReadDirRequest,
+						Payload: &domain.ReadDirPayload{
+							Dir: a1.n.Path(),
+						},
+					},
+				}
+				nsenterBatchReq := &nsenter.NSenterEvent{
+					Pid:       a1.req.Pid,
+					Namespace: &domain.AllNSsButMount,
+					ReqMsg: &domain.NSenterMessage{
+						Type: domain.BatchRequest,
+						Payload: domain.BatchPayload{
+							domain.NSenterMessage{
+								Type: domain.ReadFileRequest,
+								Payload: &domain.ReadFilePayload{
+									File: "/proc/sys/net/somaxconn",
+								},
+							},
+							domain.NSenterMessage{
+								Type: domain.ReadFileRequest,
+								Payload: &domain.ReadFilePayload{
+									File: "/proc/sys/net/unreadable",
+								},
+							},
+						},
+					},
+				}
+
+				// Expected nsenter responses.
+				nsenterEventResp := &nsenter.NSenterEvent{
+					ResMsg: &domain.NSenterMessage{
+						Type:    domain.ReadDirResponse,
+						Payload: t4_entries,
+					},
+				}
+				nsenterBatchResp := &nsenter.NSenterEvent{
+					ResMsg: &domain.NSenterMessage{
+						Type: domain.BatchResponse,
+						Payload: domain.BatchPayload{
+							domain.NSenterMessage{
+								Type:    domain.ReadFileResponse,
+								Payload: "4096",
+							},
+							domain.NSenterMessage{
+								Type:    domain.ErrorResponse,
+								Payload: syscall.Errno(syscall.EACCES),
+							},
+						},
+					},
+				}
+
+				nss.On(
+					"NewEvent",
+					a1.req.Pid,
+					&domain.AllNSsButMount,
+					nsenterEventReq.ReqMsg,
+					(*domain.NSenterMessage)(nil)).Return(nsenterEventReq)
+				nss.On(
+					"NewEvent",
+					a1.req.Pid,
+					&domain.AllNSsButMount,
+					nsenterBatchReq.ReqMsg,
+					(*domain.NSenterMessage)(nil)).Return(nsenterBatchReq)
+
+				nss.On("SendRequestEvent", mock.Anything, nsenterEventReq).Return(nil)
+				nss.On("ReceiveResponseEvent", nsenterEventReq).Return(nsenterEventResp.ResMsg)
+				nss.On("SendRequestEvent", mock.Anything, nsenterBatchReq).Return(nil)
+				nss.On("ReceiveResponseEvent", nsenterBatchReq).Return(nsenterBatchResp.ResMsg)
+			},
+			verify: func() {
+				c1 := a1.req.Container
+
+				data, ok := c1.Data("/proc/sys/net/somaxconn", "somaxconn")
+				if !ok || data != "4096" {
+					t.Errorf("somaxconn not prefetched: %q, %v", data, ok)
+				}
+				if _, ok := c1.Data("/proc/sys/net/unreadable", "unreadable"); ok {
+					t.Errorf("unreadable file unexpectedly prefetched")
+				}
+			},
+		},
 	}
 
 	//
@@ -1238,6 +1472,10 @@ func TestCommonHandler_ReadDirAll(t *testing.T) {
 					got, tt.want)
 			}
 
+			if tt.verify != nil {
+				tt.verify()
+			}
+
 			// Ensure that mocks were properly invoked and reset expectedCalls
 			// object.
 			nss.AssertExpectations(t)
@@ -1285,6 +1523,181 @@ func TestCommonHandler_Setattr(t *testing.T) {
 	}
 }
 
+func TestCommonHandler_Readlink(t *testing.T) {
+	type fields struct {
+		Name      string
+		Path      string
+		Type      domain.HandlerType
+		Enabled   bool
+		Cacheable bool
+		Service   domain.HandlerServiceIface
+	}
+
+	var f1 = fields{
+		Name:    "common",
+		Path:    "commonHandler",
+		Enabled: true,
+		Service: hds,
+	}
+
+	type args struct {
+		n   domain.IOnodeIface
+		req *domain.HandlerRequest
+	}
+
+	// Valid method arguments.
+	var a1 = args{
+		n: ios.NewIOnode("self", "/proc/self", 0),
+		req: &domain.HandlerRequest{
+			Pid: 1001,
+			Container: css.ContainerCreate(
+				"c1",
+				uint32(1001),
+				time.Time{},
+				231072,
+				65535,
+				231072,
+				65535,
+				nil,
+				nil,
+				nil,
+				nil,
+				domain.CgroupPaths{},
+				"",
+				domain.ResourceLimits{}),
+		},
+	}
+
+	// Invalid method arguments -- missing sys-container attribute.
+	var a2 = args{
+		n: ios.NewIOnode("self", "/proc/self", 0),
+		req: &domain.HandlerRequest{
+			Pid: 1001,
+		},
+	}
+
+	// Expected nsenter request.
+	nsenterEventReq := &nsenter.NSenterEvent{
+		Pid:       a1.req.Pid,
+		Namespace: &domain.AllNSsButMount,
+		ReqMsg: &domain.NSenterMessage{
+			Type: domain.ReadlinkRequest,
+			Payload: &domain.ReadlinkPayload{
+				Link: a1.n.Path(),
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    string
+		wantErr bool
+		prepare func()
+	}{
+		{
+			//
+			// Test-case 1: Regular Readlink operation. No errors expected.
+			//
+			name:    "1",
+			fields:  f1,
+			args:    a1,
+			want:    "1001",
+			wantErr: false,
+			prepare: func() {
+				nss.On(
+					"NewEvent",
+					a1.req.Pid,
+					&domain.AllNSsButMount,
+					nsenterEventReq.ReqMsg,
+					(*domain.NSenterMessage)(nil)).Return(nsenterEventReq)
+
+				nss.On("SendRequestEvent", mock.Anything, nsenterEventReq).Return(nil)
+				nss.On("ReceiveResponseEvent", nsenterEventReq).Return(
+					&domain.NSenterMessage{
+						Type:    domain.ReadlinkResponse,
+						Payload: "1001",
+					})
+			},
+		},
+		{
+			//
+			// Test-case 2: Verify proper behavior if an invalid handlerReq is
+			// received -- missing sys-container attribute.
+			//
+			name:    "2",
+			fields:  f1,
+			args:    a2,
+			want:    "",
+			wantErr: true,
+			prepare: func() {},
+		},
+		{
+			//
+			// Test-case 3: Verify proper behavior during nsenter error conditions
+			// (EINVAL -- not a symlink).
+			//
+			name:    "3",
+			fields:  f1,
+			args:    a1,
+			want:    "",
+			wantErr: true,
+			prepare: func() {
+				nss.On(
+					"NewEvent",
+					a1.req.Pid,
+					&domain.AllNSsButMount,
+					nsenterEventReq.ReqMsg,
+					(*domain.NSenterMessage)(nil)).Return(nsenterEventReq)
+
+				nss.On("SendRequestEvent", mock.Anything, nsenterEventReq).Return(nil)
+				nss.On("ReceiveResponseEvent", nsenterEventReq).Return(
+					&domain.NSenterMessage{
+						Type:    domain.ErrorResponse,
+						Payload: syscall.Errno(syscall.EINVAL),
+					})
+			},
+		},
+	}
+
+	//
+	// Testcase executions.
+	//
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &implementations.CommonHandler{
+				Name:      tt.fields.Name,
+				Path:      tt.fields.Path,
+				Type:      tt.fields.Type,
+				Enabled:   tt.fields.Enabled,
+				Cacheable: tt.fields.Cacheable,
+				Service:   tt.fields.Service,
+			}
+
+			// Prepare the mocks.
+			if tt.prepare != nil {
+				tt.prepare()
+			}
+
+			got, err := h.Readlink(tt.args.n, tt.args.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CommonHandler.Readlink() error = %v, wantErr %v",
+					err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("CommonHandler.Readlink() = %v, want %v", got, tt.want)
+			}
+
+			// Ensure that mocks were properly invoked and reset expectedCalls
+			// object.
+			nss.AssertExpectations(t)
+			nss.ExpectedCalls = nil
+		})
+	}
+}
+
 func TestCommonHandler_GetName(t *testing.T) {
 	type fields struct {
 		Name      string
@@ -0,0 +1,188 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package implementations
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nestybox/sysbox-fs/domain"
+)
+
+//
+// Parses a cpuset list (e.g. "0-3,6") into the sorted set of cpus it refers
+// to. Malformed entries are skipped.
+//
+func parseCpuList(s string) []int {
+
+	var cpus []int
+
+	seen := make(map[int]bool)
+
+	for _, elem := range strings.Split(strings.TrimSpace(s), ",") {
+		if elem == "" {
+			continue
+		}
+
+		bounds := strings.SplitN(elem, "-", 2)
+
+		lo, err := strconv.Atoi(bounds[0])
+		if err != nil || lo < 0 {
+			continue
+		}
+
+		hi := lo
+		if len(bounds) == 2 {
+			hi, err = strconv.Atoi(bounds[1])
+			if err != nil || hi < lo {
+				continue
+			}
+		}
+
+		for cpu := lo; cpu <= hi; cpu++ {
+			if !seen[cpu] {
+				seen[cpu] = true
+				cpus = append(cpus, cpu)
+			}
+		}
+	}
+
+	sort.Ints(cpus)
+
+	return cpus
+}
+
+// Returns the cpus the container is allowed to run on, as per its cpuset
+// limits. Containers with no cpuset constraint get all the host cpus.
+func containerCpus(cntr domain.ContainerIface) []int {
+
+	if cpus := parseCpuList(cntr.Limits().CpusetCpus); len(cpus) > 0 {
+		return cpus
+	}
+
+	cpus := make([]int, runtime.NumCPU())
+	for i := range cpus {
+		cpus[i] = i
+	}
+
+	return cpus
+}
+
+// Formats the given (sorted) set of cpus as a cpuset list (e.g. "0-3,6").
+func formatCpuList(cpus []int) string {
+
+	var elems []string
+
+	for i := 0; i < len(cpus); {
+		j := i
+		for j+1 < len(cpus) && cpus[j+1] == cpus[j]+1 {
+			j++
+		}
+
+		if i == j {
+			elems = append(elems, strconv.Itoa(cpus[i]))
+		} else {
+			elems = append(elems, strconv.Itoa(cpus[i])+"-"+strconv.Itoa(cpus[j]))
+		}
+
+		i = j + 1
+	}
+
+	return strings.Join(elems, ",")
+}
+
+//
+// Parses a cpu bitmask as presented by sysfs (comma-separated 32-bit words in
+// hex, most significant first, e.g. "00000000,0000000f") into the sorted set
+// of cpus it refers to. Returns the number of words too, so that the mask can
+// be formatted back with the same width. Malformed words are skipped.
+//
+func parseCpuMask(s string) ([]int, int) {
+
+	var cpus []int
+
+	words := strings.Split(strings.TrimSpace(s), ",")
+
+	for i := range words {
+		// Least significant word first.
+		w, err := strconv.ParseUint(words[len(words)-1-i], 16, 32)
+		if err != nil {
+			continue
+		}
+
+		for bit := 0; bit < 32; bit++ {
+			if w&(1<<uint(bit)) != 0 {
+				cpus = append(cpus, i*32+bit)
+			}
+		}
+	}
+
+	return cpus, len(words)
+}
+
+// Formats the given set of cpus as a sysfs cpu bitmask of (at least) the given
+// number of 32-bit words.
+func formatCpuMask(cpus []int, nwords int) string {
+
+	for _, cpu := range cpus {
+		if cpu/32+1 > nwords {
+			nwords = cpu/32 + 1
+		}
+	}
+
+	words := make([]uint32, nwords)
+	for _, cpu := range cpus {
+		words[cpu/32] |= 1 << uint(cpu%32)
+	}
+
+	elems := make([]string, nwords)
+	for i, w := range words {
+		elems[nwords-1-i] = fmt.Sprintf("%08x", w)
+	}
+
+	return strings.Join(elems, ",")
+}
+
+// Returns the cpus present in both of the given (sorted) sets.
+func intersectCpus(a, b []int) []int {
+
+	var res []int
+
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			res = append(res, a[i])
+			i++
+			j++
+		}
+	}
+
+	return res
+}
+
+// Returns the memory nodes the container is allowed to allocate memory from,
+// as per its cpuset limits, or nil if unconstrained.
+func containerMems(cntr domain.ContainerIface) []int {
+	return parseCpuList(cntr.Limits().CpusetMems)
+}
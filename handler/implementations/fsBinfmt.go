@@ -21,8 +21,6 @@ import (
 	"os"
 	"syscall"
 
-	"github.com/sirupsen/logrus"
-
 	"github.com/nestybox/sysbox-fs/domain"
 )
 
@@ -42,8 +40,6 @@ func (h *FsBinfmtHandler) Lookup(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (os.FileInfo, error) {
 
-	logrus.Debugf("Executing Lookup() method on %v handler", h.Name)
-
 	return n.Stat()
 }
 
@@ -51,8 +47,6 @@ func (h *FsBinfmtHandler) Getattr(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (*syscall.Stat_t, error) {
 
-	logrus.Debugf("Executing Getattr() method for Req ID=%#x on %v handler", req.ID, h.Name)
-
 	commonHandler, ok := h.Service.FindHandler("commonHandler")
 	if !ok {
 		return nil, fmt.Errorf("No commonHandler found")
@@ -65,15 +59,11 @@ func (h *FsBinfmtHandler) Open(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) error {
 
-	logrus.Debugf("Executing %v Open() method", h.Name)
-
 	return nil
 }
 
 func (h *FsBinfmtHandler) Close(node domain.IOnodeIface) error {
 
-	logrus.Debugf("Executing Close() method on %v handler", h.Name)
-
 	return nil
 }
 
@@ -81,8 +71,6 @@ func (h *FsBinfmtHandler) Read(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (int, error) {
 
-	logrus.Debugf("Executing %v Read() method", h.Name)
-
 	return 0, nil
 }
 
@@ -90,8 +78,6 @@ func (h *FsBinfmtHandler) Write(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (int, error) {
 
-	logrus.Debugf("Executing Write() method on %v handler", h.Name)
-
 	return 0, nil
 }
 
@@ -99,8 +85,6 @@ func (h *FsBinfmtHandler) ReadDirAll(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) ([]os.FileInfo, error) {
 
-	logrus.Debugf("Executing ReadDirAll() method for Req ID=%#x on %v handler", req.ID, h.Name)
-
 	commonHandler, ok := h.Service.FindHandler("commonHandler")
 	if !ok {
 		return nil, fmt.Errorf("No commonHandler found")
@@ -118,7 +102,7 @@ func (h *FsBinfmtHandler) GetPath() string {
 }
 
 func (h *FsBinfmtHandler) GetEnabled() bool {
-	return h.Enabled
+	return getEnabled(&h.Enabled)
 }
 
 func (h *FsBinfmtHandler) GetType() domain.HandlerType {
@@ -130,7 +114,7 @@ func (h *FsBinfmtHandler) GetService() domain.HandlerServiceIface {
 }
 
 func (h *FsBinfmtHandler) SetEnabled(val bool) {
-	h.Enabled = val
+	setEnabled(&h.Enabled, val)
 }
 
 func (h *FsBinfmtHandler) SetService(hs domain.HandlerServiceIface) {
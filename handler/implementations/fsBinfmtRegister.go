@@ -20,8 +20,6 @@ import (
 	"os"
 	"syscall"
 
-	"github.com/sirupsen/logrus"
-
 	"github.com/nestybox/sysbox-fs/domain"
 )
 
@@ -41,8 +39,6 @@ func (h *FsBinfmtRegisterHandler) Lookup(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (os.FileInfo, error) {
 
-	logrus.Debugf("Executing Lookup() method on %v handler", h.Name)
-
 	return n.Stat()
 }
 
@@ -50,8 +46,6 @@ func (h *FsBinfmtRegisterHandler) Getattr(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (*syscall.Stat_t, error) {
 
-	logrus.Debugf("Executing Getattr() method on %v handler", h.Name)
-
 	return nil, nil
 }
 
@@ -59,15 +53,11 @@ func (h *FsBinfmtRegisterHandler) Open(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) error {
 
-	logrus.Debugf("Executing %v Open() method", h.Name)
-
 	return nil
 }
 
 func (h *FsBinfmtRegisterHandler) Close(node domain.IOnodeIface) error {
 
-	logrus.Debugf("Executing Close() method on %v handler", h.Name)
-
 	return nil
 }
 
@@ -75,8 +65,6 @@ func (h *FsBinfmtRegisterHandler) Read(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (int, error) {
 
-	logrus.Debugf("Executing %v Read() method", h.Name)
-
 	return 0, nil
 }
 
@@ -84,8 +72,6 @@ func (h *FsBinfmtRegisterHandler) Write(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (int, error) {
 
-	logrus.Debugf("Executing %v Write() method", h.Name)
-
 	return 0, nil
 }
 
@@ -93,8 +79,6 @@ func (h *FsBinfmtRegisterHandler) ReadDirAll(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) ([]os.FileInfo, error) {
 
-	logrus.Debugf("Executing %v ReadDirAll() method", h.Name)
-
 	return nil, nil
 }
 
@@ -107,7 +91,7 @@ func (h *FsBinfmtRegisterHandler) GetPath() string {
 }
 
 func (h *FsBinfmtRegisterHandler) GetEnabled() bool {
-	return h.Enabled
+	return getEnabled(&h.Enabled)
 }
 
 func (h *FsBinfmtRegisterHandler) GetType() domain.HandlerType {
@@ -119,9 +103,15 @@ func (h *FsBinfmtRegisterHandler) GetService() domain.HandlerServiceIface {
 }
 
 func (h *FsBinfmtRegisterHandler) SetEnabled(val bool) {
-	h.Enabled = val
+	setEnabled(&h.Enabled, val)
 }
 
 func (h *FsBinfmtRegisterHandler) SetService(hs domain.HandlerServiceIface) {
 	h.Service = hs
 }
+
+// Write-only resource: binfmt entries are registered through it but it
+// can't be read back.
+func (h *FsBinfmtRegisterHandler) NodeMode() os.FileMode {
+	return 0200
+}
@@ -24,8 +24,6 @@ import (
 	"strings"
 	"syscall"
 
-	"github.com/sirupsen/logrus"
-
 	"github.com/nestybox/sysbox-fs/domain"
 	"github.com/nestybox/sysbox-fs/fuse"
 )
@@ -62,8 +60,6 @@ func (h *FsProtectHardLinksHandler) Lookup(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (os.FileInfo, error) {
 
-	logrus.Debugf("Executing Lookup() method on %v handler", h.Name)
-
 	return n.Stat()
 }
 
@@ -71,8 +67,6 @@ func (h *FsProtectHardLinksHandler) Getattr(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (*syscall.Stat_t, error) {
 
-	logrus.Debugf("Executing Getattr() method on %v handler", h.Name)
-
 	return nil, nil
 }
 
@@ -80,15 +74,13 @@ func (h *FsProtectHardLinksHandler) Open(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) error {
 
-	logrus.Debugf("Executing %v Open() method\n", h.Name)
-
 	flags := n.OpenFlags()
 	if flags != syscall.O_RDONLY && flags != syscall.O_WRONLY {
 		return fuse.IOerror{Code: syscall.EACCES}
 	}
 
 	if err := n.Open(); err != nil {
-		logrus.Debugf("Error opening file %v", h.Path)
+		logger.Debugf("Error opening file %v", h.Path)
 		return fuse.IOerror{Code: syscall.EIO}
 	}
 
@@ -97,10 +89,8 @@ func (h *FsProtectHardLinksHandler) Open(
 
 func (h *FsProtectHardLinksHandler) Close(n domain.IOnodeIface) error {
 
-	logrus.Debugf("Executing Close() method on %v handler", h.Name)
-
 	if err := n.Close(); err != nil {
-		logrus.Debugf("Error closing file %v", h.Path)
+		logger.Debugf("Error closing file %v", h.Path)
 		return fuse.IOerror{Code: syscall.EIO}
 	}
 
@@ -111,8 +101,6 @@ func (h *FsProtectHardLinksHandler) Read(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (int, error) {
 
-	logrus.Debugf("Executing %v Read() method", h.Name)
-
 	// We are dealing with a single integer element being read, so we can save
 	// some cycles by returning right away if offset is any higher than zero.
 	if req.Offset > 0 {
@@ -125,7 +113,7 @@ func (h *FsProtectHardLinksHandler) Read(
 
 	// Ensure operation is generated from within a registered sys container.
 	if cntr == nil {
-		logrus.Errorf("Could not find the container originating this request (pid %v)",
+		logger.Errorf("Could not find the container originating this request (pid %v)",
 			req.Pid)
 		return 0, errors.New("Container not found")
 	}
@@ -138,14 +126,14 @@ func (h *FsProtectHardLinksHandler) Read(
 		// Read from host FS to extract the existing value.
 		curHostVal, err := n.ReadLine()
 		if err != nil && err != io.EOF {
-			logrus.Errorf("Could not read from file %v", h.Path)
+			logger.Errorf("Could not read from file %v", h.Path)
 			return 0, fuse.IOerror{Code: syscall.EIO}
 		}
 
 		// High-level verification to ensure that format is the expected one.
 		_, err = strconv.Atoi(curHostVal)
 		if err != nil {
-			logrus.Errorf("Unsupported content read from file %v, error %v", h.Path, err)
+			logger.Errorf("Unsupported content read from file %v, error %v", h.Path, err)
 			return 0, fuse.IOerror{Code: syscall.EINVAL}
 		}
 
@@ -162,15 +150,13 @@ func (h *FsProtectHardLinksHandler) Write(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (int, error) {
 
-	logrus.Debugf("Executing %v Write() method", h.Name)
-
 	name := n.Name()
 	path := n.Path()
 	cntr := req.Container
 
 	// Ensure operation is generated from within a registered sys container.
 	if cntr == nil {
-		logrus.Errorf("Could not find the container originating this request (pid %v)",
+		logger.Errorf("Could not find the container originating this request (pid %v)",
 			req.Pid)
 		return 0, errors.New("Container not found")
 	}
@@ -200,6 +186,11 @@ func (h *FsProtectHardLinksHandler) ReadDirAll(
 	return nil, nil
 }
 
+// Initial value of this resource within a sys container.
+func (h *FsProtectHardLinksHandler) HostSnapshot(n domain.IOnodeIface) (string, bool) {
+	return hostIntSnapshot(n)
+}
+
 func (h *FsProtectHardLinksHandler) GetName() string {
 	return h.Name
 }
@@ -209,7 +200,7 @@ func (h *FsProtectHardLinksHandler) GetPath() string {
 }
 
 func (h *FsProtectHardLinksHandler) GetEnabled() bool {
-	return h.Enabled
+	return getEnabled(&h.Enabled)
 }
 
 func (h *FsProtectHardLinksHandler) GetType() domain.HandlerType {
@@ -221,7 +212,7 @@ func (h *FsProtectHardLinksHandler) GetService() domain.HandlerServiceIface {
 }
 
 func (h *FsProtectHardLinksHandler) SetEnabled(val bool) {
-	h.Enabled = val
+	setEnabled(&h.Enabled, val)
 }
 
 func (h *FsProtectHardLinksHandler) SetService(hs domain.HandlerServiceIface) {
@@ -24,8 +24,6 @@ import (
 	"strings"
 	"syscall"
 
-	"github.com/sirupsen/logrus"
-
 	"github.com/nestybox/sysbox-fs/domain"
 	"github.com/nestybox/sysbox-fs/fuse"
 )
@@ -60,8 +58,6 @@ func (h *FsProtectSymLinksHandler) Lookup(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (os.FileInfo, error) {
 
-	logrus.Debugf("Executing Lookup() method on %v handler", h.Name)
-
 	return n.Stat()
 }
 
@@ -69,8 +65,6 @@ func (h *FsProtectSymLinksHandler) Getattr(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (*syscall.Stat_t, error) {
 
-	logrus.Debugf("Executing Getattr() method on %v handler", h.Name)
-
 	return nil, nil
 }
 
@@ -78,15 +72,13 @@ func (h *FsProtectSymLinksHandler) Open(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) error {
 
-	logrus.Debugf("Executing %v Open() method\n", h.Name)
-
 	flags := n.OpenFlags()
 	if flags != syscall.O_RDONLY && flags != syscall.O_WRONLY {
 		return fuse.IOerror{Code: syscall.EACCES}
 	}
 
 	if err := n.Open(); err != nil {
-		logrus.Debugf("Error opening file %v", h.Path)
+		logger.Debugf("Error opening file %v", h.Path)
 		return fuse.IOerror{Code: syscall.EIO}
 	}
 
@@ -95,10 +87,8 @@ func (h *FsProtectSymLinksHandler) Open(
 
 func (h *FsProtectSymLinksHandler) Close(n domain.IOnodeIface) error {
 
-	logrus.Debugf("Executing Close() method on %v handler", h.Name)
-
 	if err := n.Close(); err != nil {
-		logrus.Debugf("Error closing file %v", h.Path)
+		logger.Debugf("Error closing file %v", h.Path)
 		return fuse.IOerror{Code: syscall.EIO}
 	}
 
@@ -109,8 +99,6 @@ func (h *FsProtectSymLinksHandler) Read(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (int, error) {
 
-	logrus.Debugf("Executing %v Read() method", h.Name)
-
 	// We are dealing with a single integer element being read, so we can save
 	// some cycles by returning right away if offset is any higher than zero.
 	if req.Offset > 0 {
@@ -123,7 +111,7 @@ func (h *FsProtectSymLinksHandler) Read(
 
 	// Ensure operation is generated from within a registered sys container.
 	if cntr == nil {
-		logrus.Errorf("Could not find the container originating this request (pid %v)",
+		logger.Errorf("Could not find the container originating this request (pid %v)",
 			req.Pid)
 		return 0, errors.New("Container not found")
 	}
@@ -136,14 +124,14 @@ func (h *FsProtectSymLinksHandler) Read(
 		// Read from host FS to extract the existing value.
 		curHostVal, err := n.ReadLine()
 		if err != nil && err != io.EOF {
-			logrus.Errorf("Could not read from file %v", h.Path)
+			logger.Errorf("Could not read from file %v", h.Path)
 			return 0, fuse.IOerror{Code: syscall.EIO}
 		}
 
 		// High-level verification to ensure that format is the expected one.
 		_, err = strconv.Atoi(curHostVal)
 		if err != nil {
-			logrus.Errorf("Unsupported content read from file %v, error %v", h.Path, err)
+			logger.Errorf("Unsupported content read from file %v, error %v", h.Path, err)
 			return 0, fuse.IOerror{Code: syscall.EINVAL}
 		}
 
@@ -160,15 +148,13 @@ func (h *FsProtectSymLinksHandler) Write(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (int, error) {
 
-	logrus.Debugf("Executing %v Write() method", h.Name)
-
 	name := n.Name()
 	path := n.Path()
 	cntr := req.Container
 
 	// Ensure operation is generated from within a registered sys container.
 	if cntr == nil {
-		logrus.Errorf("Could not find the container originating this request (pid %v)",
+		logger.Errorf("Could not find the container originating this request (pid %v)",
 			req.Pid)
 		return 0, errors.New("Container not found")
 	}
@@ -198,6 +184,11 @@ func (h *FsProtectSymLinksHandler) ReadDirAll(
 	return nil, nil
 }
 
+// Initial value of this resource within a sys container.
+func (h *FsProtectSymLinksHandler) HostSnapshot(n domain.IOnodeIface) (string, bool) {
+	return hostIntSnapshot(n)
+}
+
 func (h *FsProtectSymLinksHandler) GetName() string {
 	return h.Name
 }
@@ -207,7 +198,7 @@ func (h *FsProtectSymLinksHandler) GetPath() string {
 }
 
 func (h *FsProtectSymLinksHandler) GetEnabled() bool {
-	return h.Enabled
+	return getEnabled(&h.Enabled)
 }
 
 func (h *FsProtectSymLinksHandler) GetType() domain.HandlerType {
@@ -219,7 +210,7 @@ func (h *FsProtectSymLinksHandler) GetService() domain.HandlerServiceIface {
 }
 
 func (h *FsProtectSymLinksHandler) SetEnabled(val bool) {
-	h.Enabled = val
+	setEnabled(&h.Enabled, val)
 }
 
 func (h *FsProtectSymLinksHandler) SetService(hs domain.HandlerServiceIface) {
@@ -0,0 +1,374 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+//go:build go1.18
+// +build go1.18
+
+package implementations_test
+
+import (
+	"errors"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/fuse"
+	"github.com/nestybox/sysbox-fs/handler/implementations"
+)
+
+//
+// Fuzz targets over the handlers' Write() paths, which parse the content
+// written by (untrusted) sys container processes: whatever is written, the
+// handlers must reject it or store it, but never panic. Run them through 'make
+// fuzz' (or 'go test -fuzz'); the crashing inputs found are saved under
+// testdata/fuzz, and replayed as regression cases by plain 'go test' runs.
+//
+
+// Returns the sys container the fuzzed writes originate from.
+func fuzzContainer() domain.ContainerIface {
+
+	return css.ContainerCreate(
+		"fuzz",
+		uint32(1001),
+		time.Time{},
+		231072,
+		65535,
+		231072,
+		65535,
+		nil,
+		nil,
+		nil,
+		nil,
+		domain.CgroupPaths{},
+		"",
+		domain.ResourceLimits{})
+}
+
+// Handler service stub for the handlers relying on it: it serves the given
+// "commonHandler", and the fuzzed IgnoreErrors() setting. Unexpected calls
+// panic through the nil embedded interface.
+type fuzzService struct {
+	domain.HandlerServiceIface
+	commonHandler domain.HandlerIface
+	ignoreErrors  bool
+}
+
+func (s *fuzzService) FindHandler(name string) (domain.HandlerIface, bool) {
+	if name != "commonHandler" || s.commonHandler == nil {
+		return nil, false
+	}
+	return s.commonHandler, true
+}
+
+func (s *fuzzService) IgnoreErrors() bool {
+	return s.ignoreErrors
+}
+
+// "commonHandler" stub, whose writes within the container's namespaces
+// succeed or fail with the given error.
+type fuzzCommonHandler struct {
+	domain.HandlerIface
+	err error
+}
+
+func (h *fuzzCommonHandler) Write(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	if h.err != nil {
+		return 0, h.err
+	}
+	return len(req.Data), nil
+}
+
+// I/O node whose writes to the host fail, if so requested.
+type fuzzIOnode struct {
+	domain.IOnodeIface
+	failWrite bool
+}
+
+func (n *fuzzIOnode) WriteFile(p []byte) error {
+	if n.failWrite {
+		return errors.New("fuzzed write failure")
+	}
+	return n.IOnodeIface.WriteFile(p)
+}
+
+// Writes the given data through the handler, and verifies that successful
+// writes account for all of it.
+func fuzzWrite(t *testing.T, h domain.HandlerIface, n domain.IOnodeIface,
+	data []byte, cntr domain.ContainerIface) {
+
+	sz, err := h.Write(n, &domain.HandlerRequest{
+		Pid:       1001,
+		Data:      data,
+		Container: cntr,
+	})
+	if err == nil && sz != len(data) {
+		t.Errorf("Write of %q returned %d bytes", data, sz)
+	}
+}
+
+func FuzzMaxIntBaseHandler_Write(f *testing.F) {
+
+	const path = "/proc/sys/fs/file-max"
+
+	ios.NewIOnode("", path, 0).WriteFile([]byte("1048576\n"))
+
+	for _, seed := range []string{
+		"2097152\n", "0", "-1\n", " 42 ", "9223372036854775808", "0x10", "",
+	} {
+		f.Add([]byte(seed), false, false, false)
+		f.Add([]byte(seed), true, false, false)
+		f.Add([]byte(seed), true, true, false)
+		f.Add([]byte(seed), true, true, true)
+	}
+
+	cntr := fuzzContainer()
+
+	// Writes go through either a container with no value stored yet, or a
+	// long-lived one, and may fail to reach the host (ignored or not).
+	f.Fuzz(func(t *testing.T, data []byte,
+		fresh, failWrite, ignoreErrors bool) {
+
+		h := &implementations.MaxIntBaseHandler{
+			Name:    "fsFileMax",
+			Path:    path,
+			Type:    domain.NODE_SUBSTITUTION,
+			Enabled: true,
+			Service: &fuzzService{ignoreErrors: ignoreErrors},
+		}
+
+		c := cntr
+		if fresh {
+			c = fuzzContainer()
+		}
+
+		n := &fuzzIOnode{
+			IOnodeIface: ios.NewIOnode("file-max", path, 0),
+			failWrite:   failWrite,
+		}
+
+		fuzzWrite(t, h, n, data, c)
+	})
+}
+
+func FuzzVectorIntBaseHandler_Write(f *testing.F) {
+
+	const path = "/proc/sys/vm/lowmem_reserve_ratio"
+
+	ios.NewIOnode("", path, 0).WriteFile([]byte("256\t256\t32\t0\n"))
+
+	for _, seed := range []string{
+		"128 128 16 0\n", "128\t128\t16\t0", "128 128\n", "1 2 3 -4\n",
+		"1 2 3 2147483648\n", " \n", "",
+	} {
+		f.Add([]byte(seed))
+	}
+
+	h := &implementations.VectorIntBaseHandler{
+		Name:    "vmLowmemReserveRatio",
+		Path:    path,
+		Type:    domain.NODE_SUBSTITUTION,
+		Enabled: true,
+	}
+	cntr := fuzzContainer()
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		n := ios.NewIOnode(filepath.Base(path), path, 0)
+		fuzzWrite(t, h, n, data, cntr)
+	})
+}
+
+func FuzzStringBaseHandler_Write(f *testing.F) {
+
+	const thpPath = "/sys/kernel/mm/transparent_hugepage/defrag"
+	const qdiscPath = "/proc/sys/net/core/default_qdisc"
+
+	ios.NewIOnode("", thpPath, 0).WriteFile(
+		[]byte("always defer defer+madvise [madvise] never\n"))
+	ios.NewIOnode("", qdiscPath, 0).WriteFile([]byte("fq_codel\n"))
+
+	for _, seed := range []string{
+		"never\n", "[madvise]", "always madvise", "fq", "fq_codel\n", "", "[]",
+	} {
+		f.Add(false, []byte(seed))
+		f.Add(true, []byte(seed))
+	}
+
+	cntr := fuzzContainer()
+
+	f.Fuzz(func(t *testing.T, bracketed bool, data []byte) {
+		path := qdiscPath
+		if bracketed {
+			path = thpPath
+		}
+
+		h := &implementations.StringBaseHandler{
+			Name:    "stringBase",
+			Path:    path,
+			Type:    domain.NODE_SUBSTITUTION,
+			Enabled: true,
+		}
+
+		n := ios.NewIOnode(filepath.Base(path), path, 0)
+		fuzzWrite(t, h, n, data, cntr)
+	})
+}
+
+func FuzzBoundedIntBaseHandler_Write(f *testing.F) {
+
+	// Plain, fallback-backed and sticky bounded sysctls.
+	paths := []string{
+		"/proc/sys/kernel/perf_event_paranoid",
+		"/proc/sys/fs/may_detach_mounts",
+		"/proc/sys/kernel/unprivileged_bpf_disabled",
+		"/proc/sys/fs/mount-max",
+	}
+
+	for _, seed := range []string{
+		"1\n", "0", "-1\n", " 2 ", "3", "2147483648", "0x1", "",
+	} {
+		for i := range paths {
+			f.Add(uint8(i), []byte(seed))
+		}
+	}
+
+	h := &implementations.BoundedIntBaseHandler{
+		Name:    "boundedInt",
+		Type:    domain.NODE_SUBSTITUTION,
+		Enabled: true,
+	}
+	cntr := fuzzContainer()
+
+	f.Fuzz(func(t *testing.T, idx uint8, data []byte) {
+		path := paths[int(idx)%len(paths)]
+		n := ios.NewIOnode(filepath.Base(path), path, 0)
+
+		fuzzWrite(t, h, n, data, cntr)
+	})
+}
+
+func FuzzNetnsIntBaseHandler_Write(f *testing.F) {
+
+	const path = "/proc/sys/net/core/netdev_max_backlog"
+
+	ios.NewIOnode("", path, 0).WriteFile([]byte("1000\n"))
+
+	for _, seed := range []string{
+		"8192\n", "0", "-1\n", " 42 ", "9223372036854775808", "0x10", "",
+	} {
+		f.Add([]byte(seed), false, false, false)
+		f.Add([]byte(seed), true, false, false)
+		f.Add([]byte(seed), true, true, false)
+		f.Add([]byte(seed), true, true, true)
+	}
+
+	cntr := fuzzContainer()
+
+	// Writes are served within the container's netns, or fall back to the
+	// host when the resource isn't exposed there.
+	f.Fuzz(func(t *testing.T, data []byte,
+		notExposed, failWrite, ignoreErrors bool) {
+
+		common := &fuzzCommonHandler{}
+		if notExposed {
+			common.err = fuse.IOerror{Code: syscall.ENOENT}
+		}
+
+		h := &implementations.NetnsIntBaseHandler{
+			Name:    "coreNetdevMaxBacklog",
+			Path:    path,
+			Type:    domain.NODE_SUBSTITUTION,
+			Enabled: true,
+			Service: &fuzzService{
+				commonHandler: common,
+				ignoreErrors:  ignoreErrors,
+			},
+		}
+
+		n := &fuzzIOnode{
+			IOnodeIface: ios.NewIOnode("netdev_max_backlog", path, 0),
+			failWrite:   failWrite,
+		}
+
+		fuzzWrite(t, h, n, data, cntr)
+	})
+}
+
+func FuzzIpcNsIntBaseHandler_Write(f *testing.F) {
+
+	paths := []string{
+		"/proc/sys/kernel/shm_rmid_forced",
+		"/proc/sys/kernel/msgmni",
+	}
+
+	for _, seed := range []string{
+		"1\n", "0", "-1\n", " 2 ", "16777216", "16777217", "0x1", "",
+	} {
+		for i := range paths {
+			f.Add(uint8(i), []byte(seed))
+		}
+	}
+
+	h := &implementations.IpcNsIntBaseHandler{
+		Name:    "ipcNsInt",
+		Type:    domain.NODE_SUBSTITUTION,
+		Enabled: true,
+		Service: &fuzzService{commonHandler: &fuzzCommonHandler{}},
+	}
+	cntr := fuzzContainer()
+
+	f.Fuzz(func(t *testing.T, idx uint8, data []byte) {
+		path := paths[int(idx)%len(paths)]
+		n := ios.NewIOnode(filepath.Base(path), path, 0)
+
+		fuzzWrite(t, h, n, data, cntr)
+	})
+}
+
+func FuzzFsBinfmtRegisterHandler_Write(f *testing.F) {
+
+	const path = "/proc/sys/fs/binfmt_misc/register"
+
+	for _, seed := range []string{
+		":qemu-arm:M::\\x7fELF\\x01\\x01\\x01:\\xff\\xff\\xff\\xff:/usr/bin/qemu-arm:F\n",
+		":exe:E::exe::/usr/bin/wine:\n",
+		":::::::", ":", "",
+	} {
+		f.Add([]byte(seed))
+	}
+
+	h := &implementations.FsBinfmtRegisterHandler{
+		Name:    "binfmtRegister",
+		Path:    path,
+		Type:    domain.NODE_SUBSTITUTION,
+		Enabled: true,
+	}
+	cntr := fuzzContainer()
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		n := ios.NewIOnode("", path, 0)
+
+		h.Write(n, &domain.HandlerRequest{
+			Pid:       1001,
+			Data:      data,
+			Container: cntr,
+		})
+	})
+}
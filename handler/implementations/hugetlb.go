@@ -0,0 +1,164 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package implementations
+
+import (
+	"bufio"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/nestybox/sysbox-fs/domain"
+)
+
+//
+// Hugetlb helpers shared by the handlers emulating the hugepage resources
+// (/proc/sys/vm and /sys/kernel/mm/hugepages), which must be consistent with
+// the hugetlb cgroup limits of the container.
+//
+
+// Host mountpoint of the cgroup hierarchies. Container cgroup paths are
+// relative to the root of each hierarchy.
+const cgroupRoot = "/sys/fs/cgroup"
+
+// Huge page size (kB) assumed when the host doesn't report any.
+const defaultHugePageSizeKB = 2048
+
+// Limits at or above this value stand for 'no limit' in cgroup-v1, where
+// unlimited counters report the largest page-aligned int64.
+const hugetlbUnlimited = uint64(1) << 62
+
+// Returns the default huge page size (kB) of the host, as per /proc/meminfo.
+func hugePageSizeKB(ios domain.IOServiceIface) uint64 {
+
+	n := ios.NewIOnode("meminfo", "/proc/meminfo", 0)
+
+	data, err := n.ReadFile()
+	if err != nil {
+		return defaultHugePageSizeKB
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != "Hugepagesize:" {
+			continue
+		}
+		if kB, err := strconv.ParseUint(fields[1], 10, 64); err == nil && kB > 0 {
+			return kB
+		}
+	}
+
+	return defaultHugePageSizeKB
+}
+
+// Returns the name the hugetlb controller gives to the huge page size (kB)
+// in its interface files (e.g. "2MB" for 2048kB pages).
+func hugePageSizeName(kB uint64) string {
+
+	switch {
+	case kB >= 1<<20 && kB%(1<<20) == 0:
+		return fmt.Sprintf("%dGB", kB>>20)
+	case kB >= 1<<10 && kB%(1<<10) == 0:
+		return fmt.Sprintf("%dMB", kB>>10)
+	}
+
+	return fmt.Sprintf("%dKB", kB)
+}
+
+//
+// Returns the value of the container's hugetlb cgroup file for the given huge
+// page size (kB), as named in cgroup-v1 and cgroup-v2 respectively (e.g.
+// "limit_in_bytes" and "max"). Returns false if the container isn't limited,
+// or the value can't be determined.
+//
+func hugetlbValue(
+	ios domain.IOServiceIface,
+	cntr domain.ContainerIface,
+	kB uint64,
+	v1File string,
+	v2File string) (uint64, bool) {
+
+	var file string
+
+	paths := cntr.CgroupPaths()
+	size := hugePageSizeName(kB)
+
+	if p, ok := paths.V1["hugetlb"]; ok {
+		file = filepath.Join(cgroupRoot, "hugetlb", p, "hugetlb."+size+"."+v1File)
+	} else if paths.V2 != "" {
+		file = filepath.Join(cgroupRoot, paths.V2, "hugetlb."+size+"."+v2File)
+	} else {
+		return 0, false
+	}
+
+	val, err := ios.NewIOnode(filepath.Base(file), file, 0).ReadLine()
+	if err != nil {
+		return 0, false
+	}
+
+	// cgroup-v2 reports "max" for unlimited counters.
+	res, err := strconv.ParseUint(strings.TrimSpace(val), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return res, true
+}
+
+// Returns the hugetlb cgroup limit (bytes) of the container for the given huge
+// page size (kB). Returns false if the container isn't limited.
+func hugetlbLimit(
+	ios domain.IOServiceIface,
+	cntr domain.ContainerIface,
+	kB uint64) (uint64, bool) {
+
+	limit, ok := hugetlbValue(ios, cntr, kB, "limit_in_bytes", "max")
+	if !ok || limit >= hugetlbUnlimited {
+		return 0, false
+	}
+
+	return limit, true
+}
+
+// Returns the huge pages (bytes) of the given size (kB) currently charged to
+// the container's hugetlb cgroup.
+func hugetlbUsage(
+	ios domain.IOServiceIface,
+	cntr domain.ContainerIface,
+	kB uint64) (uint64, bool) {
+
+	return hugetlbValue(ios, cntr, kB, "usage_in_bytes", "current")
+}
+
+//
+// Returns the number of huge pages of the given size (kB) the container can
+// allocate as per its hugetlb cgroup limit, or false if it isn't limited.
+//
+func hugetlbMaxPages(
+	ios domain.IOServiceIface,
+	cntr domain.ContainerIface,
+	kB uint64) (uint64, bool) {
+
+	limit, ok := hugetlbLimit(ios, cntr, kB)
+	if !ok {
+		return 0, false
+	}
+
+	return limit / (kB << 10), true
+}
@@ -0,0 +1,173 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package implementations
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/fuse"
+)
+
+// This is a base handler for SysV IPC sysctls consisting of a single integer
+// value (e.g. kernel.msgmni or kernel.shm_rmid_forced). These are scoped to the
+// IPC namespace, so reads and writes are carried out (via nsenter) within the
+// namespaces of the process originating the request, once the written value
+// has been validated against the bounds enforced by the kernel.
+
+// Range of values accepted by each IPC sysctl (see ipc/ipc_sysctl.c).
+var ipcSysctlBounds = map[string][2]int{
+	"shm_rmid_forced": {0, 1},
+	"msgmni":          {0, 1 << 24},
+}
+
+type IpcNsIntBaseHandler struct {
+	Name      string
+	Path      string
+	Type      domain.HandlerType
+	Enabled   bool
+	Cacheable bool
+	Service   domain.HandlerServiceIface
+}
+
+func (h *IpcNsIntBaseHandler) Lookup(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (os.FileInfo, error) {
+
+	return n.Stat()
+}
+
+func (h *IpcNsIntBaseHandler) Getattr(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (*syscall.Stat_t, error) {
+
+	return nil, nil
+}
+
+func (h *IpcNsIntBaseHandler) Open(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) error {
+
+	flags := n.OpenFlags()
+	if flags != syscall.O_RDONLY && flags != syscall.O_WRONLY {
+		return fuse.IOerror{Code: syscall.EACCES}
+	}
+
+	commonHandler, ok := h.Service.FindHandler("commonHandler")
+	if !ok {
+		return fmt.Errorf("No commonHandler found")
+	}
+
+	return commonHandler.Open(n, req)
+}
+
+func (h *IpcNsIntBaseHandler) Close(n domain.IOnodeIface) error {
+
+	return nil
+}
+
+func (h *IpcNsIntBaseHandler) Read(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	// We are dealing with a single integer element being read, so we can save
+	// some cycles by returning right away if offset is any higher than zero.
+	if req.Offset > 0 {
+		return 0, io.EOF
+	}
+
+	commonHandler, ok := h.Service.FindHandler("commonHandler")
+	if !ok {
+		return 0, fmt.Errorf("No commonHandler found")
+	}
+
+	return commonHandler.Read(n, req)
+}
+
+func (h *IpcNsIntBaseHandler) Write(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	// Ensure operation is generated from within a registered sys container.
+	if req.Container == nil {
+		logger.Errorf("Could not find the container originating this request (pid %v)",
+			req.Pid)
+		return 0, errors.New("Container not found")
+	}
+
+	newVal := strings.TrimSpace(string(req.Data))
+	newValInt, err := strconv.Atoi(newVal)
+	if err != nil {
+		return 0, fuse.IOerror{Code: syscall.EINVAL}
+	}
+
+	// Ensure that only proper values are allowed as per this resource's
+	// supported values.
+	if bounds, ok := ipcSysctlBounds[n.Name()]; ok {
+		if newValInt < bounds[0] || newValInt > bounds[1] {
+			return 0, fuse.IOerror{Code: syscall.EINVAL}
+		}
+	}
+
+	commonHandler, ok := h.Service.FindHandler("commonHandler")
+	if !ok {
+		return 0, fmt.Errorf("No commonHandler found")
+	}
+
+	return commonHandler.Write(n, req)
+}
+
+func (h *IpcNsIntBaseHandler) ReadDirAll(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) ([]os.FileInfo, error) {
+
+	return nil, nil
+}
+
+func (h *IpcNsIntBaseHandler) GetName() string {
+	return h.Name
+}
+
+func (h *IpcNsIntBaseHandler) GetPath() string {
+	return h.Path
+}
+
+func (h *IpcNsIntBaseHandler) GetEnabled() bool {
+	return getEnabled(&h.Enabled)
+}
+
+func (h *IpcNsIntBaseHandler) GetType() domain.HandlerType {
+	return h.Type
+}
+
+func (h *IpcNsIntBaseHandler) GetService() domain.HandlerServiceIface {
+	return h.Service
+}
+
+func (h *IpcNsIntBaseHandler) SetEnabled(val bool) {
+	setEnabled(&h.Enabled, val)
+}
+
+func (h *IpcNsIntBaseHandler) SetService(hs domain.HandlerServiceIface) {
+	h.Service = hs
+}
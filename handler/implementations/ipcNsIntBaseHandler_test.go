@@ -0,0 +1,107 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package implementations_test
+
+import (
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/fuse"
+	"github.com/nestybox/sysbox-fs/handler/implementations"
+	"github.com/nestybox/sysbox-fs/mocks"
+)
+
+func TestIpcNsIntBaseHandler_Write(t *testing.T) {
+
+	commonHandler := &mocks.HandlerIface{}
+	commonHandler.On("Write", mock.Anything, mock.Anything).Return(2, nil)
+
+	hs := &mocks.HandlerServiceIface{}
+	hs.On("FindHandler", "commonHandler").Return(commonHandler, true)
+
+	cntr := css.ContainerCreate(
+		"c1",
+		uint32(1001),
+		time.Time{},
+		231072,
+		65535,
+		231072,
+		65535,
+		nil,
+		nil,
+		nil,
+		nil,
+		domain.CgroupPaths{},
+		"",
+		domain.ResourceLimits{})
+
+	tests := []struct {
+		name    string
+		path    string
+		data    string
+		wantErr bool
+	}{
+		// Test-case 1: Valid shm_rmid_forced value.
+		{"1", "/proc/sys/kernel/shm_rmid_forced", "1\n", false},
+
+		// Test-case 2: shm_rmid_forced value out of range.
+		{"2", "/proc/sys/kernel/shm_rmid_forced", "2\n", true},
+
+		// Test-case 3: Valid msgmni value.
+		{"3", "/proc/sys/kernel/msgmni", "32000\n", false},
+
+		// Test-case 4: Negative msgmni value.
+		{"4", "/proc/sys/kernel/msgmni", "-1\n", true},
+
+		// Test-case 5: Non-integer value.
+		{"5", "/proc/sys/kernel/msgmni", "max\n", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			n := ios.NewIOnode(filepath.Base(tt.path), tt.path, 0)
+			h := &implementations.IpcNsIntBaseHandler{
+				Name:    "ipc",
+				Path:    tt.path,
+				Type:    domain.NODE_SUBSTITUTION,
+				Enabled: true,
+				Service: hs,
+			}
+			req := &domain.HandlerRequest{
+				Pid:       1001,
+				Data:      []byte(tt.data),
+				Container: cntr,
+			}
+
+			_, err := h.Write(n, req)
+			if tt.wantErr {
+				assert.Equal(t, fuse.IOerror{Code: syscall.EINVAL}, err)
+				commonHandler.AssertNotCalled(t, "Write", n, req)
+			} else {
+				assert.NoError(t, err)
+				commonHandler.AssertCalled(t, "Write", n, req)
+			}
+		})
+	}
+}
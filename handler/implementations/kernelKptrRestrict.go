@@ -24,8 +24,6 @@ import (
 	"strings"
 	"syscall"
 
-	"github.com/sirupsen/logrus"
-
 	"github.com/nestybox/sysbox-fs/domain"
 	"github.com/nestybox/sysbox-fs/fuse"
 )
@@ -79,8 +77,6 @@ func (h *KernelKptrRestrictHandler) Lookup(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (os.FileInfo, error) {
 
-	logrus.Debugf("Executing Lookup() method on %v handler", h.Name)
-
 	return n.Stat()
 }
 
@@ -88,8 +84,6 @@ func (h *KernelKptrRestrictHandler) Getattr(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (*syscall.Stat_t, error) {
 
-	logrus.Debugf("Executing Getattr() method on %v handler", h.Name)
-
 	return nil, nil
 }
 
@@ -97,15 +91,13 @@ func (h *KernelKptrRestrictHandler) Open(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) error {
 
-	logrus.Debugf("Executing %v Open() method\n", h.Name)
-
 	flags := n.OpenFlags()
 	if flags != syscall.O_RDONLY && flags != syscall.O_WRONLY {
 		return fuse.IOerror{Code: syscall.EACCES}
 	}
 
 	if err := n.Open(); err != nil {
-		logrus.Debugf("Error opening file %v", h.Path)
+		logger.Debugf("Error opening file %v", h.Path)
 		return fuse.IOerror{Code: syscall.EIO}
 	}
 
@@ -114,10 +106,8 @@ func (h *KernelKptrRestrictHandler) Open(
 
 func (h *KernelKptrRestrictHandler) Close(n domain.IOnodeIface) error {
 
-	logrus.Debugf("Executing Close() method on %v handler", h.Name)
-
 	if err := n.Close(); err != nil {
-		logrus.Debugf("Error closing file %v", h.Path)
+		logger.Debugf("Error closing file %v", h.Path)
 		return fuse.IOerror{Code: syscall.EIO}
 	}
 
@@ -128,8 +118,6 @@ func (h *KernelKptrRestrictHandler) Read(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (int, error) {
 
-	logrus.Debugf("Executing %v Read() method", h.Name)
-
 	// We are dealing with a single integer element being read, so we can save
 	// some cycles by returning right away if offset is any higher than zero.
 	if req.Offset > 0 {
@@ -142,7 +130,7 @@ func (h *KernelKptrRestrictHandler) Read(
 
 	// Ensure operation is generated from within a registered sys container.
 	if cntr == nil {
-		logrus.Errorf("Could not find the container originating this request (pid %v)",
+		logger.Errorf("Could not find the container originating this request (pid %v)",
 			req.Pid)
 		return 0, errors.New("Container not found")
 	}
@@ -155,14 +143,14 @@ func (h *KernelKptrRestrictHandler) Read(
 		// Read from host FS to extract the existing value.
 		curHostVal, err := n.ReadLine()
 		if err != nil && err != io.EOF {
-			logrus.Errorf("Could not read from file %v", h.Path)
+			logger.Errorf("Could not read from file %v", h.Path)
 			return 0, fuse.IOerror{Code: syscall.EIO}
 		}
 
 		// High-level verification to ensure that format is the expected one.
 		_, err = strconv.Atoi(curHostVal)
 		if err != nil {
-			logrus.Errorf("Unsupported content read from file %v, error %v", h.Path, err)
+			logger.Errorf("Unsupported content read from file %v, error %v", h.Path, err)
 			return 0, fuse.IOerror{Code: syscall.EINVAL}
 		}
 
@@ -179,15 +167,13 @@ func (h *KernelKptrRestrictHandler) Write(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (int, error) {
 
-	logrus.Debugf("Executing %v Write() method", h.Name)
-
 	name := n.Name()
 	path := n.Path()
 	cntr := req.Container
 
 	// Ensure operation is generated from within a registered sys container.
 	if cntr == nil {
-		logrus.Errorf("Could not find the container originating this request (pid %v)",
+		logger.Errorf("Could not find the container originating this request (pid %v)",
 			req.Pid)
 		return 0, errors.New("Container not found")
 	}
@@ -217,6 +203,11 @@ func (h *KernelKptrRestrictHandler) ReadDirAll(
 	return nil, nil
 }
 
+// Initial value of this resource within a sys container.
+func (h *KernelKptrRestrictHandler) HostSnapshot(n domain.IOnodeIface) (string, bool) {
+	return hostIntSnapshot(n)
+}
+
 func (h *KernelKptrRestrictHandler) GetName() string {
 	return h.Name
 }
@@ -226,7 +217,7 @@ func (h *KernelKptrRestrictHandler) GetPath() string {
 }
 
 func (h *KernelKptrRestrictHandler) GetEnabled() bool {
-	return h.Enabled
+	return getEnabled(&h.Enabled)
 }
 
 func (h *KernelKptrRestrictHandler) GetType() domain.HandlerType {
@@ -238,7 +229,7 @@ func (h *KernelKptrRestrictHandler) GetService() domain.HandlerServiceIface {
 }
 
 func (h *KernelKptrRestrictHandler) SetEnabled(val bool) {
-	h.Enabled = val
+	setEnabled(&h.Enabled, val)
 }
 
 func (h *KernelKptrRestrictHandler) SetService(hs domain.HandlerServiceIface) {
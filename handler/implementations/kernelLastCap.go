@@ -23,8 +23,6 @@ import (
 	"strconv"
 	"syscall"
 
-	"github.com/sirupsen/logrus"
-
 	"github.com/nestybox/sysbox-fs/domain"
 	"github.com/nestybox/sysbox-fs/fuse"
 )
@@ -49,8 +47,6 @@ func (h *KernelLastCapHandler) Lookup(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (os.FileInfo, error) {
 
-	logrus.Debugf("Executing Lookup() method on %v handler", h.Name)
-
 	return n.Stat()
 }
 
@@ -58,8 +54,6 @@ func (h *KernelLastCapHandler) Getattr(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (*syscall.Stat_t, error) {
 
-	logrus.Debugf("Executing Getattr() method on %v handler", h.Name)
-
 	return nil, nil
 }
 
@@ -67,15 +61,13 @@ func (h *KernelLastCapHandler) Open(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) error {
 
-	logrus.Debugf("Executing %v Open() method\n", h.Name)
-
 	flags := n.OpenFlags()
 	if flags != syscall.O_RDONLY {
 		return fuse.IOerror{Code: syscall.EACCES}
 	}
 
 	if err := n.Open(); err != nil {
-		logrus.Debugf("Error opening file %v", h.Path)
+		logger.Debugf("Error opening file %v", h.Path)
 		return fuse.IOerror{Code: syscall.EIO}
 	}
 
@@ -84,10 +76,8 @@ func (h *KernelLastCapHandler) Open(
 
 func (h *KernelLastCapHandler) Close(n domain.IOnodeIface) error {
 
-	logrus.Debugf("Executing Close() method on %v handler", h.Name)
-
 	if err := n.Close(); err != nil {
-		logrus.Debugf("Error closing file %v", h.Path)
+		logger.Debugf("Error closing file %v", h.Path)
 		return fuse.IOerror{Code: syscall.EIO}
 	}
 
@@ -98,8 +88,6 @@ func (h *KernelLastCapHandler) Read(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (int, error) {
 
-	logrus.Debugf("Executing %v Read() method", h.Name)
-
 	// We are dealing with a single integer element being read, so we can save
 	// some cycles by returning right away if offset is any higher than zero.
 	if req.Offset > 0 {
@@ -112,7 +100,7 @@ func (h *KernelLastCapHandler) Read(
 
 	// Ensure operation is generated from within a registered sys container.
 	if cntr == nil {
-		logrus.Errorf("Could not find the container originating this request (pid %v)",
+		logger.Errorf("Could not find the container originating this request (pid %v)",
 			req.Pid)
 		return 0, errors.New("Container not found")
 	}
@@ -125,14 +113,14 @@ func (h *KernelLastCapHandler) Read(
 		// Read from host FS to extract the existing 'panic' interval value.
 		curHostVal, err := n.ReadLine()
 		if err != nil && err != io.EOF {
-			logrus.Errorf("Could not read from file %v", h.Path)
+			logger.Errorf("Could not read from file %v", h.Path)
 			return 0, fuse.IOerror{Code: syscall.EIO}
 		}
 
 		// High-level verification to ensure that format is the expected one.
 		_, err = strconv.Atoi(curHostVal)
 		if err != nil {
-			logrus.Errorf("Unsupported content read from file %v, error %v", h.Path, err)
+			logger.Errorf("Unsupported content read from file %v, error %v", h.Path, err)
 			return 0, fuse.IOerror{Code: syscall.EINVAL}
 		}
 
@@ -149,8 +137,6 @@ func (h *KernelLastCapHandler) Write(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (int, error) {
 
-	logrus.Debugf("Executing %v Write() method", h.Name)
-
 	return 0, nil
 }
 
@@ -161,6 +147,11 @@ func (h *KernelLastCapHandler) ReadDirAll(
 	return nil, nil
 }
 
+// Initial value of this resource within a sys container.
+func (h *KernelLastCapHandler) HostSnapshot(n domain.IOnodeIface) (string, bool) {
+	return hostIntSnapshot(n)
+}
+
 func (h *KernelLastCapHandler) GetName() string {
 	return h.Name
 }
@@ -170,7 +161,7 @@ func (h *KernelLastCapHandler) GetPath() string {
 }
 
 func (h *KernelLastCapHandler) GetEnabled() bool {
-	return h.Enabled
+	return getEnabled(&h.Enabled)
 }
 
 func (h *KernelLastCapHandler) GetType() domain.HandlerType {
@@ -182,9 +173,14 @@ func (h *KernelLastCapHandler) GetService() domain.HandlerServiceIface {
 }
 
 func (h *KernelLastCapHandler) SetEnabled(val bool) {
-	h.Enabled = val
+	setEnabled(&h.Enabled, val)
 }
 
 func (h *KernelLastCapHandler) SetService(hs domain.HandlerServiceIface) {
 	h.Service = hs
 }
+
+// Read-only resource: writes are silently dropped.
+func (h *KernelLastCapHandler) NodeMode() os.FileMode {
+	return 0444
+}
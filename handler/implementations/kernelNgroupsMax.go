@@ -23,8 +23,6 @@ import (
 	"strconv"
 	"syscall"
 
-	"github.com/sirupsen/logrus"
-
 	"github.com/nestybox/sysbox-fs/domain"
 	"github.com/nestybox/sysbox-fs/fuse"
 )
@@ -59,8 +57,6 @@ func (h *KernelNgroupsMaxHandler) Lookup(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (os.FileInfo, error) {
 
-	logrus.Debugf("Executing Lookup() method on %v handler", h.Name)
-
 	return n.Stat()
 }
 
@@ -68,8 +64,6 @@ func (h *KernelNgroupsMaxHandler) Getattr(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (*syscall.Stat_t, error) {
 
-	logrus.Debugf("Executing Getattr() method on %v handler", h.Name)
-
 	return nil, nil
 }
 
@@ -77,15 +71,13 @@ func (h *KernelNgroupsMaxHandler) Open(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) error {
 
-	logrus.Debugf("Executing %v Open() method\n", h.Name)
-
 	flags := n.OpenFlags()
 	if flags != syscall.O_RDONLY {
 		return fuse.IOerror{Code: syscall.EACCES}
 	}
 
 	if err := n.Open(); err != nil {
-		logrus.Debugf("Error opening file %v", h.Path)
+		logger.Debugf("Error opening file %v", h.Path)
 		return fuse.IOerror{Code: syscall.EIO}
 	}
 
@@ -94,10 +86,8 @@ func (h *KernelNgroupsMaxHandler) Open(
 
 func (h *KernelNgroupsMaxHandler) Close(n domain.IOnodeIface) error {
 
-	logrus.Debugf("Executing Close() method on %v handler", h.Name)
-
 	if err := n.Close(); err != nil {
-		logrus.Debugf("Error closing file %v", h.Path)
+		logger.Debugf("Error closing file %v", h.Path)
 		return fuse.IOerror{Code: syscall.EIO}
 	}
 
@@ -108,8 +98,6 @@ func (h *KernelNgroupsMaxHandler) Read(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (int, error) {
 
-	logrus.Debugf("Executing %v Read() method", h.Name)
-
 	// We are dealing with a single integer element being read, so we can save
 	// some cycles by returning right away if offset is any higher than zero.
 	if req.Offset > 0 {
@@ -122,7 +110,7 @@ func (h *KernelNgroupsMaxHandler) Read(
 
 	// Ensure operation is generated from within a registered sys container.
 	if cntr == nil {
-		logrus.Errorf("Could not find the container originating this request (pid %v)",
+		logger.Errorf("Could not find the container originating this request (pid %v)",
 			req.Pid)
 		return 0, errors.New("Container not found")
 	}
@@ -135,14 +123,14 @@ func (h *KernelNgroupsMaxHandler) Read(
 		// Read from host FS to extract the existing 'ngroups_max' value.
 		curHostVal, err := n.ReadLine()
 		if err != nil && err != io.EOF {
-			logrus.Errorf("Could not read from file %v", h.Path)
+			logger.Errorf("Could not read from file %v", h.Path)
 			return 0, fuse.IOerror{Code: syscall.EIO}
 		}
 
 		// High-level verification to ensure that format is the expected one.
 		_, err = strconv.Atoi(curHostVal)
 		if err != nil {
-			logrus.Errorf("Unsupported content read from file %v, error %v", h.Path, err)
+			logger.Errorf("Unsupported content read from file %v, error %v", h.Path, err)
 			return 0, fuse.IOerror{Code: syscall.EINVAL}
 		}
 
@@ -159,8 +147,6 @@ func (h *KernelNgroupsMaxHandler) Write(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (int, error) {
 
-	logrus.Debugf("Executing %v Write() method", h.Name)
-
 	return 0, nil
 }
 
@@ -171,6 +157,11 @@ func (h *KernelNgroupsMaxHandler) ReadDirAll(
 	return nil, nil
 }
 
+// Initial value of this resource within a sys container.
+func (h *KernelNgroupsMaxHandler) HostSnapshot(n domain.IOnodeIface) (string, bool) {
+	return hostIntSnapshot(n)
+}
+
 func (h *KernelNgroupsMaxHandler) GetName() string {
 	return h.Name
 }
@@ -180,7 +171,7 @@ func (h *KernelNgroupsMaxHandler) GetPath() string {
 }
 
 func (h *KernelNgroupsMaxHandler) GetEnabled() bool {
-	return h.Enabled
+	return getEnabled(&h.Enabled)
 }
 
 func (h *KernelNgroupsMaxHandler) GetType() domain.HandlerType {
@@ -192,9 +183,14 @@ func (h *KernelNgroupsMaxHandler) GetService() domain.HandlerServiceIface {
 }
 
 func (h *KernelNgroupsMaxHandler) SetEnabled(val bool) {
-	h.Enabled = val
+	setEnabled(&h.Enabled, val)
 }
 
 func (h *KernelNgroupsMaxHandler) SetService(hs domain.HandlerServiceIface) {
 	h.Service = hs
 }
+
+// Read-only resource: writes are silently dropped.
+func (h *KernelNgroupsMaxHandler) NodeMode() os.FileMode {
+	return 0444
+}
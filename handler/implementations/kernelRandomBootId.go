@@ -0,0 +1,178 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package implementations
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/fuse"
+)
+
+//
+// /proc/sys/kernel/random/boot_id handler
+//
+// Documentation: Random UUID generated once per boot. Tools such as journald
+// rely on it to tell one boot from another, so every sys container is handed
+// its own one, which is regenerated whenever the container is restarted (e.g.
+// through reboot(2)).
+//
+type KernelRandomBootIdHandler struct {
+	Name      string
+	Path      string
+	Type      domain.HandlerType
+	Enabled   bool
+	Cacheable bool
+	Service   domain.HandlerServiceIface
+}
+
+func (h *KernelRandomBootIdHandler) Lookup(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (os.FileInfo, error) {
+
+	return n.Stat()
+}
+
+func (h *KernelRandomBootIdHandler) Getattr(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (*syscall.Stat_t, error) {
+
+	return nil, nil
+}
+
+func (h *KernelRandomBootIdHandler) Open(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) error {
+
+	flags := n.OpenFlags()
+	if flags != syscall.O_RDONLY {
+		return fuse.IOerror{Code: syscall.EACCES}
+	}
+
+	return nil
+}
+
+func (h *KernelRandomBootIdHandler) Close(n domain.IOnodeIface) error {
+
+	return nil
+}
+
+func (h *KernelRandomBootIdHandler) Read(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	if req.Offset > 0 {
+		return 0, io.EOF
+	}
+
+	name := n.Name()
+	path := n.Path()
+	cntr := req.Container
+
+	// Ensure operation is generated from within a registered sys container.
+	if cntr == nil {
+		logger.Errorf("Could not find the container originating this request (pid %v)",
+			req.Pid)
+		return 0, errors.New("Container not found")
+	}
+
+	// The boot-id is generated upon first access. As the container state is
+	// discarded when the container goes down, a restarted container will get
+	// a new one.
+	data, ok := cntr.Data(path, name)
+	if !ok {
+		id, err := newBootId()
+		if err != nil {
+			logger.Errorf("Could not generate boot_id for container %s: %v",
+				cntr.ID(), err)
+			return 0, fuse.IOerror{Code: syscall.EIO}
+		}
+
+		data = id
+		cntr.SetData(path, name, data)
+	}
+
+	data += "\n"
+
+	return copyResultBuffer(req.Data, []byte(data))
+}
+
+func (h *KernelRandomBootIdHandler) Write(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	return 0, fuse.IOerror{Code: syscall.EPERM}
+}
+
+func (h *KernelRandomBootIdHandler) ReadDirAll(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) ([]os.FileInfo, error) {
+
+	return nil, nil
+}
+
+func (h *KernelRandomBootIdHandler) GetName() string {
+	return h.Name
+}
+
+func (h *KernelRandomBootIdHandler) GetPath() string {
+	return h.Path
+}
+
+func (h *KernelRandomBootIdHandler) GetEnabled() bool {
+	return getEnabled(&h.Enabled)
+}
+
+func (h *KernelRandomBootIdHandler) GetType() domain.HandlerType {
+	return h.Type
+}
+
+func (h *KernelRandomBootIdHandler) GetService() domain.HandlerServiceIface {
+	return h.Service
+}
+
+func (h *KernelRandomBootIdHandler) SetEnabled(val bool) {
+	setEnabled(&h.Enabled, val)
+}
+
+func (h *KernelRandomBootIdHandler) SetService(hs domain.HandlerServiceIface) {
+	h.Service = hs
+}
+
+// Read-only resource: writes are rejected with EPERM.
+func (h *KernelRandomBootIdHandler) NodeMode() os.FileMode {
+	return 0444
+}
+
+// Returns a random (version 4) UUID, formatted as the kernel does.
+func newBootId() (string, error) {
+
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
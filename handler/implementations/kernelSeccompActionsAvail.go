@@ -0,0 +1,182 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package implementations
+
+import (
+	"errors"
+	"io"
+	"os"
+	"syscall"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/fuse"
+)
+
+//
+// /proc/sys/kernel/seccomp/actions_avail handler
+//
+// Documentation: A read-only ordered list of seccomp return values (refer to
+// the SECCOMP_RET_* macros above) in string form. The ordering, from left-to-
+// right, is the least permissive return value to the most permissive return
+// value.
+//
+// The list is exposed as per the host kernel, as nested container runtimes
+// and systemd inspect it while setting up their seccomp filters.
+//
+type KernelSeccompActionsAvailHandler struct {
+	Name      string
+	Path      string
+	Type      domain.HandlerType
+	Enabled   bool
+	Cacheable bool
+	Service   domain.HandlerServiceIface
+}
+
+func (h *KernelSeccompActionsAvailHandler) Lookup(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (os.FileInfo, error) {
+
+	return n.Stat()
+}
+
+func (h *KernelSeccompActionsAvailHandler) Getattr(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (*syscall.Stat_t, error) {
+
+	return nil, nil
+}
+
+func (h *KernelSeccompActionsAvailHandler) Open(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) error {
+
+	flags := n.OpenFlags()
+	if flags != syscall.O_RDONLY {
+		return fuse.IOerror{Code: syscall.EACCES}
+	}
+
+	if err := n.Open(); err != nil {
+		logger.Debugf("Error opening file %v", h.Path)
+		return fuse.IOerror{Code: syscall.EIO}
+	}
+
+	return nil
+}
+
+func (h *KernelSeccompActionsAvailHandler) Close(n domain.IOnodeIface) error {
+
+	if err := n.Close(); err != nil {
+		logger.Debugf("Error closing file %v", h.Path)
+		return fuse.IOerror{Code: syscall.EIO}
+	}
+
+	return nil
+}
+
+func (h *KernelSeccompActionsAvailHandler) Read(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	// We are dealing with a single line element being read, so we can save
+	// some cycles by returning right away if offset is any higher than zero.
+	if req.Offset > 0 {
+		return 0, io.EOF
+	}
+
+	name := n.Name()
+	path := n.Path()
+	cntr := req.Container
+
+	// Ensure operation is generated from within a registered sys container.
+	if cntr == nil {
+		logger.Errorf("Could not find the container originating this request (pid %v)",
+			req.Pid)
+		return 0, errors.New("Container not found")
+	}
+
+	// Check if this resource has been initialized for this container. Otherwise,
+	// fetch the information from the host FS and store it accordingly within
+	// the container struct.
+	data, ok := cntr.Data(path, name)
+	if !ok {
+		// Read from host FS to extract the existing value.
+		curHostVal, err := n.ReadLine()
+		if err != nil && err != io.EOF {
+			logger.Errorf("Could not read from file %v", h.Path)
+			return 0, fuse.IOerror{Code: syscall.EIO}
+		}
+
+		data = curHostVal
+		cntr.SetData(path, name, data)
+	}
+
+	data += "\n"
+
+	return copyResultBuffer(req.Data, []byte(data))
+}
+
+func (h *KernelSeccompActionsAvailHandler) Write(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	return 0, nil
+}
+
+func (h *KernelSeccompActionsAvailHandler) ReadDirAll(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) ([]os.FileInfo, error) {
+
+	return nil, nil
+}
+
+// Initial value of this resource within a sys container.
+func (h *KernelSeccompActionsAvailHandler) HostSnapshot(n domain.IOnodeIface) (string, bool) {
+	return hostSnapshot(n)
+}
+
+func (h *KernelSeccompActionsAvailHandler) GetName() string {
+	return h.Name
+}
+
+func (h *KernelSeccompActionsAvailHandler) GetPath() string {
+	return h.Path
+}
+
+func (h *KernelSeccompActionsAvailHandler) GetEnabled() bool {
+	return getEnabled(&h.Enabled)
+}
+
+func (h *KernelSeccompActionsAvailHandler) GetType() domain.HandlerType {
+	return h.Type
+}
+
+func (h *KernelSeccompActionsAvailHandler) GetService() domain.HandlerServiceIface {
+	return h.Service
+}
+
+func (h *KernelSeccompActionsAvailHandler) SetEnabled(val bool) {
+	setEnabled(&h.Enabled, val)
+}
+
+func (h *KernelSeccompActionsAvailHandler) SetService(hs domain.HandlerServiceIface) {
+	h.Service = hs
+}
+
+// Read-only resource: writes are silently dropped.
+func (h *KernelSeccompActionsAvailHandler) NodeMode() os.FileMode {
+	return 0444
+}
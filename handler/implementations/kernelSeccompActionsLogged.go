@@ -0,0 +1,244 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package implementations
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"syscall"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/fuse"
+)
+
+//
+// /proc/sys/kernel/seccomp/actions_logged handler
+//
+// Documentation: A read-write ordered list of seccomp return values (refer to
+// the SECCOMP_RET_* macros above) that are allowed to be logged. Writes to the
+// file do not need to be in ordered form but reads from the file will be
+// ordered in the same way as the actions_avail sysctl.
+//
+// The "allow" string is not accepted in the actions_logged sysctl as it is not
+// possible to log SECCOMP_RET_ALLOW actions. Attempting to write "allow" to
+// the sysctl will result in an EINVAL being returned.
+//
+// Note: As this is a system-wide attribute, changes will be only made
+// superficially (at sys-container level). IOW, the host FS value will be left
+// untouched.
+//
+
+// Path of the list of seccomp actions supported by the host kernel.
+const seccompActionsAvailPath = "/proc/sys/kernel/seccomp/actions_avail"
+
+type KernelSeccompActionsLoggedHandler struct {
+	Name      string
+	Path      string
+	Type      domain.HandlerType
+	Enabled   bool
+	Cacheable bool
+	Service   domain.HandlerServiceIface
+}
+
+func (h *KernelSeccompActionsLoggedHandler) Lookup(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (os.FileInfo, error) {
+
+	return n.Stat()
+}
+
+func (h *KernelSeccompActionsLoggedHandler) Getattr(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (*syscall.Stat_t, error) {
+
+	return nil, nil
+}
+
+func (h *KernelSeccompActionsLoggedHandler) Open(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) error {
+
+	flags := n.OpenFlags()
+	if flags != syscall.O_RDONLY && flags != syscall.O_WRONLY {
+		return fuse.IOerror{Code: syscall.EACCES}
+	}
+
+	if err := n.Open(); err != nil {
+		logger.Debugf("Error opening file %v", h.Path)
+		return fuse.IOerror{Code: syscall.EIO}
+	}
+
+	return nil
+}
+
+func (h *KernelSeccompActionsLoggedHandler) Close(n domain.IOnodeIface) error {
+
+	if err := n.Close(); err != nil {
+		logger.Debugf("Error closing file %v", h.Path)
+		return fuse.IOerror{Code: syscall.EIO}
+	}
+
+	return nil
+}
+
+func (h *KernelSeccompActionsLoggedHandler) Read(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	// We are dealing with a single line element being read, so we can save
+	// some cycles by returning right away if offset is any higher than zero.
+	if req.Offset > 0 {
+		return 0, io.EOF
+	}
+
+	name := n.Name()
+	path := n.Path()
+	cntr := req.Container
+
+	// Ensure operation is generated from within a registered sys container.
+	if cntr == nil {
+		logger.Errorf("Could not find the container originating this request (pid %v)",
+			req.Pid)
+		return 0, errors.New("Container not found")
+	}
+
+	// Check if this resource has been initialized for this container. Otherwise,
+	// fetch the information from the host FS and store it accordingly within
+	// the container struct.
+	data, ok := cntr.Data(path, name)
+	if !ok {
+		// Read from host FS to extract the existing value.
+		curHostVal, err := n.ReadLine()
+		if err != nil && err != io.EOF {
+			logger.Errorf("Could not read from file %v", h.Path)
+			return 0, fuse.IOerror{Code: syscall.EIO}
+		}
+
+		data = curHostVal
+		cntr.SetData(path, name, data)
+	}
+
+	data += "\n"
+
+	return copyResultBuffer(req.Data, []byte(data))
+}
+
+func (h *KernelSeccompActionsLoggedHandler) Write(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	name := n.Name()
+	path := n.Path()
+	cntr := req.Container
+
+	// Ensure operation is generated from within a registered sys container.
+	if cntr == nil {
+		logger.Errorf("Could not find the container originating this request (pid %v)",
+			req.Pid)
+		return 0, errors.New("Container not found")
+	}
+
+	// Only the actions supported by the kernel (other than "allow") must be
+	// accepted. Values are stored as ordered in actions_avail.
+	newVal, err := h.orderedActions(strings.Fields(string(req.Data)))
+	if err != nil {
+		return 0, err
+	}
+
+	// Store the new value within the container struct.
+	cntr.SetData(path, name, newVal)
+
+	return len(req.Data), nil
+}
+
+func (h *KernelSeccompActionsLoggedHandler) ReadDirAll(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) ([]os.FileInfo, error) {
+
+	return nil, nil
+}
+
+// Returns the given actions ordered as per the host's actions_avail, or an
+// EINVAL error if any of them isn't supported.
+func (h *KernelSeccompActionsLoggedHandler) orderedActions(
+	actions []string) (string, error) {
+
+	ios := h.Service.IOService()
+
+	avail, err := ios.NewIOnode("actions_avail", seccompActionsAvailPath, 0).ReadLine()
+	if err != nil && err != io.EOF {
+		logger.Errorf("Could not read from file %v", seccompActionsAvailPath)
+		return "", fuse.IOerror{Code: syscall.EIO}
+	}
+
+	requested := make(map[string]bool, len(actions))
+	for _, a := range actions {
+		if a == "allow" {
+			return "", fuse.IOerror{Code: syscall.EINVAL}
+		}
+		requested[a] = true
+	}
+
+	var ordered []string
+	for _, a := range strings.Fields(avail) {
+		if requested[a] {
+			ordered = append(ordered, a)
+			delete(requested, a)
+		}
+	}
+
+	if len(requested) > 0 {
+		return "", fuse.IOerror{Code: syscall.EINVAL}
+	}
+
+	return strings.Join(ordered, " "), nil
+}
+
+// Initial value of this resource within a sys container.
+func (h *KernelSeccompActionsLoggedHandler) HostSnapshot(n domain.IOnodeIface) (string, bool) {
+	return hostSnapshot(n)
+}
+
+func (h *KernelSeccompActionsLoggedHandler) GetName() string {
+	return h.Name
+}
+
+func (h *KernelSeccompActionsLoggedHandler) GetPath() string {
+	return h.Path
+}
+
+func (h *KernelSeccompActionsLoggedHandler) GetEnabled() bool {
+	return getEnabled(&h.Enabled)
+}
+
+func (h *KernelSeccompActionsLoggedHandler) GetType() domain.HandlerType {
+	return h.Type
+}
+
+func (h *KernelSeccompActionsLoggedHandler) GetService() domain.HandlerServiceIface {
+	return h.Service
+}
+
+func (h *KernelSeccompActionsLoggedHandler) SetEnabled(val bool) {
+	setEnabled(&h.Enabled, val)
+}
+
+func (h *KernelSeccompActionsLoggedHandler) SetService(hs domain.HandlerServiceIface) {
+	h.Service = hs
+}
@@ -0,0 +1,100 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package implementations_test
+
+import (
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/fuse"
+	"github.com/nestybox/sysbox-fs/handler/implementations"
+	"github.com/nestybox/sysbox-fs/mocks"
+)
+
+func TestKernelSeccompActionsLoggedHandler_Write(t *testing.T) {
+
+	const path = "/proc/sys/kernel/seccomp/actions_logged"
+
+	hs := &mocks.HandlerServiceIface{}
+	hs.On("IOService").Return(ios)
+
+	h := &implementations.KernelSeccompActionsLoggedHandler{
+		Name:      "kernelSeccompActionsLogged",
+		Path:      path,
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: true,
+		Service:   hs,
+	}
+
+	ios.NewIOnode("", "/proc/sys/kernel/seccomp/actions_avail", 0).WriteFile(
+		[]byte("kill_process kill_thread trap errno user_notif trace log allow"))
+
+	n := ios.NewIOnode("actions_logged", path, 0)
+
+	cntr := css.ContainerCreate(
+		"c1",
+		uint32(1001),
+		time.Time{},
+		231072,
+		65535,
+		231072,
+		65535,
+		nil,
+		nil,
+		nil,
+		nil,
+		domain.CgroupPaths{},
+		"",
+		domain.ResourceLimits{})
+
+	tests := []struct {
+		name    string
+		data    string
+		want    string
+		wantErr error
+	}{
+		// Test-case 1: Supported actions are stored as ordered by the kernel.
+		{"1", "log errno kill_process\n", "kill_process errno log", nil},
+
+		// Test-case 2: "allow" actions can't be logged.
+		{"2", "errno allow\n", "kill_process errno log", fuse.IOerror{Code: syscall.EINVAL}},
+
+		// Test-case 3: Unsupported actions.
+		{"3", "kill_everything\n", "kill_process errno log", fuse.IOerror{Code: syscall.EINVAL}},
+
+		// Test-case 4: Empty list.
+		{"4", "\n", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			req := &domain.HandlerRequest{Pid: 1001, Data: []byte(tt.data), Container: cntr}
+
+			_, err := h.Write(n, req)
+			assert.Equal(t, tt.wantErr, err)
+
+			data, _ := cntr.Data(path, "actions_logged")
+			assert.Equal(t, tt.want, data)
+		})
+	}
+}
@@ -0,0 +1,214 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package implementations
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/fuse"
+)
+
+//
+// /proc/sys/kernel/unprivileged_userns_clone handler
+//
+// Documentation: This Debian / Ubuntu specific toggle controls whether
+// unprivileged users are allowed to create user namespaces. Supported values
+// are "0" (disallowed) and "1" (allowed).
+//
+// Nested runtimes (e.g. rootless Docker) check it before setting up their
+// user namespaces. Its value is emulated at sys-container level, starting
+// with the host's one, or with "1" in kernels lacking this sysctl (after all,
+// sys containers are always allowed to create user namespaces).
+//
+// Note: As this is a system-wide attribute, changes will be only made
+// superficially (at sys-container level). IOW, the host FS value will be left
+// untouched.
+//
+
+const unprivUsernsCloneDefault = "1"
+
+type KernelUnprivUsernsCloneHandler struct {
+	Name      string
+	Path      string
+	Type      domain.HandlerType
+	Enabled   bool
+	Cacheable bool
+	Service   domain.HandlerServiceIface
+}
+
+func (h *KernelUnprivUsernsCloneHandler) Lookup(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (os.FileInfo, error) {
+
+	info, err := n.Stat()
+	if isNotExist(err) {
+		return syntheticFileInfo(filepath.Base(n.Path()), 0644), nil
+	}
+
+	return info, err
+}
+
+func (h *KernelUnprivUsernsCloneHandler) Getattr(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (*syscall.Stat_t, error) {
+
+	return nil, nil
+}
+
+func (h *KernelUnprivUsernsCloneHandler) Open(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) error {
+
+	flags := n.OpenFlags()
+	if flags != syscall.O_RDONLY && flags != syscall.O_WRONLY {
+		return fuse.IOerror{Code: syscall.EACCES}
+	}
+
+	return nil
+}
+
+func (h *KernelUnprivUsernsCloneHandler) Close(n domain.IOnodeIface) error {
+
+	return nil
+}
+
+func (h *KernelUnprivUsernsCloneHandler) Read(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	// We are dealing with a single integer element being read, so we can save
+	// some cycles by returning right away if offset is any higher than zero.
+	if req.Offset > 0 {
+		return 0, io.EOF
+	}
+
+	name := n.Name()
+	path := n.Path()
+	cntr := req.Container
+
+	// Ensure operation is generated from within a registered sys container.
+	if cntr == nil {
+		logger.Errorf("Could not find the container originating this request (pid %v)",
+			req.Pid)
+		return 0, errors.New("Container not found")
+	}
+
+	// Check if this resource has been initialized for this container. Otherwise,
+	// fetch the information from the host FS (if present) and store it
+	// accordingly within the container struct.
+	data, ok := cntr.Data(path, name)
+	if !ok {
+		curHostVal, err := n.ReadLine()
+		if err != nil && err != io.EOF && !isNotExist(err) {
+			logger.Errorf("Could not read from file %v", h.Path)
+			return 0, fuse.IOerror{Code: syscall.EIO}
+		}
+
+		data = strings.TrimSpace(curHostVal)
+		if data != "0" && data != "1" {
+			data = unprivUsernsCloneDefault
+		}
+		cntr.SetData(path, name, data)
+	}
+
+	data += "\n"
+
+	return copyResultBuffer(req.Data, []byte(data))
+}
+
+func (h *KernelUnprivUsernsCloneHandler) Write(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	name := n.Name()
+	path := n.Path()
+	cntr := req.Container
+
+	// Ensure operation is generated from within a registered sys container.
+	if cntr == nil {
+		logger.Errorf("Could not find the container originating this request (pid %v)",
+			req.Pid)
+		return 0, errors.New("Container not found")
+	}
+
+	newVal := strings.TrimSpace(string(req.Data))
+	if newVal != "0" && newVal != "1" {
+		return 0, fuse.IOerror{Code: syscall.EINVAL}
+	}
+
+	// Store the new value within the container struct.
+	cntr.SetData(path, name, newVal)
+
+	return len(req.Data), nil
+}
+
+func (h *KernelUnprivUsernsCloneHandler) ReadDirAll(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) ([]os.FileInfo, error) {
+
+	return nil, nil
+}
+
+// Initial value of this resource within a sys container.
+func (h *KernelUnprivUsernsCloneHandler) HostSnapshot(n domain.IOnodeIface) (string, bool) {
+
+	val, err := n.ReadLine()
+	if err != nil && err != io.EOF && !isNotExist(err) {
+		return "", false
+	}
+
+	val = strings.TrimSpace(val)
+	if val != "0" && val != "1" {
+		val = unprivUsernsCloneDefault
+	}
+
+	return val, true
+}
+
+func (h *KernelUnprivUsernsCloneHandler) GetName() string {
+	return h.Name
+}
+
+func (h *KernelUnprivUsernsCloneHandler) GetPath() string {
+	return h.Path
+}
+
+func (h *KernelUnprivUsernsCloneHandler) GetEnabled() bool {
+	return getEnabled(&h.Enabled)
+}
+
+func (h *KernelUnprivUsernsCloneHandler) GetType() domain.HandlerType {
+	return h.Type
+}
+
+func (h *KernelUnprivUsernsCloneHandler) GetService() domain.HandlerServiceIface {
+	return h.Service
+}
+
+func (h *KernelUnprivUsernsCloneHandler) SetEnabled(val bool) {
+	setEnabled(&h.Enabled, val)
+}
+
+func (h *KernelUnprivUsernsCloneHandler) SetService(hs domain.HandlerServiceIface) {
+	h.Service = hs
+}
@@ -0,0 +1,81 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package implementations_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/handler/implementations"
+)
+
+func TestKernelUnprivUsernsCloneHandler_ReadWrite(t *testing.T) {
+
+	const path = "/proc/sys/kernel/unprivileged_userns_clone"
+
+	cntr := css.ContainerCreate(
+		"userns",
+		uint32(1001),
+		time.Time{},
+		231072,
+		65535,
+		231072,
+		65535,
+		nil,
+		nil,
+		nil,
+		nil,
+		domain.CgroupPaths{},
+		"",
+		domain.ResourceLimits{})
+
+	h := &implementations.KernelUnprivUsernsCloneHandler{
+		Name:    "kernelUnprivUsernsClone",
+		Path:    path,
+		Type:    domain.NODE_SUBSTITUTION,
+		Enabled: true,
+	}
+
+	// The host lacks this (distro-specific) sysctl.
+	n := ios.NewIOnode("unprivileged_userns_clone", path, 0)
+
+	// Test-case 1: Lookups succeed regardless.
+	info, err := h.Lookup(n, &domain.HandlerRequest{Pid: 1001, Container: cntr})
+	assert.NoError(t, err)
+	assert.Equal(t, "unprivileged_userns_clone", info.Name())
+
+	// Test-case 2: Reads start off with user namespaces allowed.
+	buf := make([]byte, 8)
+	sz, err := h.Read(n, &domain.HandlerRequest{Pid: 1001, Data: buf, Container: cntr})
+	assert.NoError(t, err)
+	assert.Equal(t, "1\n", string(buf[:sz]))
+
+	// Test-case 3: Valid writes are kept at container level.
+	_, err = h.Write(n, &domain.HandlerRequest{Pid: 1001, Data: []byte("0\n"), Container: cntr})
+	assert.NoError(t, err)
+
+	sz, err = h.Read(n, &domain.HandlerRequest{Pid: 1001, Data: buf, Container: cntr})
+	assert.NoError(t, err)
+	assert.Equal(t, "0\n", string(buf[:sz]))
+
+	// Test-case 4: Invalid writes are rejected.
+	_, err = h.Write(n, &domain.HandlerRequest{Pid: 1001, Data: []byte("2\n"), Container: cntr})
+	assert.Error(t, err)
+}
@@ -24,8 +24,6 @@ import (
 	"strings"
 	"syscall"
 
-	"github.com/sirupsen/logrus"
-
 	"github.com/nestybox/sysbox-fs/domain"
 	"github.com/nestybox/sysbox-fs/fuse"
 )
@@ -47,8 +45,6 @@ func (h *MaxIntBaseHandler) Lookup(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (os.FileInfo, error) {
 
-	logrus.Debugf("Executing Lookup() method on %v handler", h.Name)
-
 	return n.Stat()
 }
 
@@ -56,8 +52,6 @@ func (h *MaxIntBaseHandler) Getattr(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (*syscall.Stat_t, error) {
 
-	logrus.Debugf("Executing Getattr() method on %v handler", h.Name)
-
 	return nil, nil
 }
 
@@ -65,8 +59,6 @@ func (h *MaxIntBaseHandler) Open(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) error {
 
-	logrus.Debugf("Executing %v Open() method\n", h.Name)
-
 	flags := n.OpenFlags()
 	if flags != syscall.O_RDONLY && flags != syscall.O_WRONLY {
 		return fuse.IOerror{Code: syscall.EACCES}
@@ -80,7 +72,7 @@ func (h *MaxIntBaseHandler) Open(
 	}
 
 	if err := n.Open(); err != nil {
-		logrus.Debugf("Error opening file %v", h.Path)
+		logger.Debugf("Error opening file %v", h.Path)
 		return fuse.IOerror{Code: syscall.EIO}
 	}
 
@@ -89,10 +81,8 @@ func (h *MaxIntBaseHandler) Open(
 
 func (h *MaxIntBaseHandler) Close(n domain.IOnodeIface) error {
 
-	logrus.Debugf("Executing Close() method on %v handler", h.Name)
-
 	if err := n.Close(); err != nil {
-		logrus.Debugf("Error closing file %v", h.Path)
+		logger.Debugf("Error closing file %v", h.Path)
 		return fuse.IOerror{Code: syscall.EIO}
 	}
 
@@ -103,8 +93,6 @@ func (h *MaxIntBaseHandler) Read(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (int, error) {
 
-	logrus.Debugf("Executing %v Read() method", h.Name)
-
 	// We are dealing with a single integer element being read, so we can save
 	// some cycles by returning right away if offset is any higher than zero.
 	if req.Offset > 0 {
@@ -117,27 +105,26 @@ func (h *MaxIntBaseHandler) Read(
 
 	// Ensure operation is generated from within a registered sys container.
 	if cntr == nil {
-		logrus.Errorf("Could not find the container originating this request (pid %v)",
+		logger.Errorf("Could not find the container originating this request (pid %v)",
 			req.Pid)
 		return 0, errors.New("Container not found")
 	}
 
-	var err error
-
 	// Check if this resource has been initialized for this container. Otherwise,
 	// fetch the information from the host FS and store it accordingly within
 	// the container struct.
-	data, ok := cntr.Data(path, name)
+	val, ok := cntr.DataValue(path, name)
 	if !ok {
-		data, err = h.fetchFile(n, cntr)
+		curMax, err := h.fetchFile(n, cntr)
 		if err != nil && err != io.EOF {
 			return 0, err
 		}
 
-		cntr.SetData(path, name, data)
+		cntr.SetDataValue(path, name, curMax, 0)
+		val, _ = domain.NewStateValue(curMax)
 	}
 
-	data += "\n"
+	data := val.String() + "\n"
 
 	return copyResultBuffer(req.Data, []byte(data))
 }
@@ -146,8 +133,6 @@ func (h *MaxIntBaseHandler) Write(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (int, error) {
 
-	logrus.Debugf("Executing %v Write() method", h.Name)
-
 	name := n.Name()
 	path := n.Path()
 	cntr := req.Container
@@ -155,53 +140,58 @@ func (h *MaxIntBaseHandler) Write(
 	newMax := strings.TrimSpace(string(req.Data))
 	newMaxInt, err := strconv.Atoi(newMax)
 	if err != nil {
-		logrus.Errorf("Unexpected error: %v", err)
+		logger.Errorf("Unexpected error: %v", err)
 		return 0, err
 	}
 
 	// Ensure operation is generated from within a registered sys container.
 	if cntr == nil {
-		logrus.Errorf("Could not find the container originating this request (pid %v)",
+		logger.Errorf("Could not find the container originating this request (pid %v)",
 			req.Pid)
 		return 0, errors.New("Container not found")
 	}
 
-	// Check if this resource has been initialized for this container. If not,
-	// push it to the host FS and store it within the container struct.
-	curMax, ok := cntr.Data(path, name)
-	if !ok {
-		if err := h.pushFile(n, cntr, newMaxInt); err != nil {
-			return 0, err
+	// The stored value is updated through a version-conditional write, so
+	// that concurrent writers within the same container can't clobber each
+	// other's updates. In case of conflict the whole sequence is retried.
+	for {
+		// Check if this resource has been initialized for this container. If
+		// not, push it to the host FS and store it within the container struct.
+		curVal, ok := cntr.DataValue(path, name)
+		if !ok {
+			if err := h.pushFile(n, cntr, newMaxInt); err != nil {
+				return 0, err
+			}
+
+			cntr.SetDataValue(path, name, newMaxInt, 0)
+
+			return len(req.Data), nil
 		}
 
-		cntr.SetData(path, name, newMax)
-
-		return len(req.Data), nil
-	}
+		curMaxInt, err := intValue(curVal)
+		if err != nil {
+			logger.Errorf("Unexpected error: %v", err)
+			return 0, err
+		}
 
-	curMaxInt, err := strconv.Atoi(curMax)
-	if err != nil {
-		logrus.Errorf("Unexpected error: %v", err)
-		return 0, err
-	}
+		// If new value is lower/equal than the existing one, then let's update
+		// this new value into the container struct but not push it down to the
+		// kernel.
+		if newMaxInt > curMaxInt {
+			// Push new value to the kernel.
+			if err := h.pushFile(n, cntr, newMaxInt); err != nil {
+				return 0, io.EOF
+			}
+		}
 
-	// If new value is lower/equal than the existing one, then let's update this
-	// new value into the container struct but not push it down to the kernel.
-	if newMaxInt <= curMaxInt {
-		cntr.SetData(path, name, newMax)
+		// Writing the new value into container-state struct.
+		_, err = cntr.SetDataValue(path, name, newMaxInt, curVal.Version())
+		if err == domain.ErrDataVersionConflict {
+			continue
+		}
 
 		return len(req.Data), nil
 	}
-
-	// Push new value to the kernel.
-	if err := h.pushFile(n, cntr, newMaxInt); err != nil {
-		return 0, io.EOF
-	}
-
-	// Writing the new value into container-state struct.
-	cntr.SetData(path, name, newMax)
-
-	return len(req.Data), nil
 }
 
 func (h *MaxIntBaseHandler) ReadDirAll(
@@ -213,23 +203,23 @@ func (h *MaxIntBaseHandler) ReadDirAll(
 
 func (h *MaxIntBaseHandler) fetchFile(
 	n domain.IOnodeIface,
-	c domain.ContainerIface) (string, error) {
+	c domain.ContainerIface) (int, error) {
 
 	// Read from host FS to extract the existing value.
 	curHostMax, err := n.ReadLine()
 	if err != nil && err != io.EOF {
-		logrus.Errorf("Could not read from file %v", h.Path)
-		return "", err
+		logger.Errorf("Could not read from file %v", h.Path)
+		return 0, err
 	}
 
 	// High-level verification to ensure that format is the expected one.
-	_, err = strconv.Atoi(curHostMax)
+	curHostMaxInt, err := strconv.Atoi(curHostMax)
 	if err != nil {
-		logrus.Errorf("Unexpected content read from file %v, error %v", h.Path, err)
-		return "", err
+		logger.Errorf("Unexpected content read from file %v, error %v", h.Path, err)
+		return 0, err
 	}
 
-	return curHostMax, nil
+	return curHostMaxInt, nil
 }
 
 func (h *MaxIntBaseHandler) pushFile(n domain.IOnodeIface, c domain.ContainerIface,
@@ -241,7 +231,7 @@ func (h *MaxIntBaseHandler) pushFile(n domain.IOnodeIface, c domain.ContainerIfa
 	}
 	curHostMaxInt, err := strconv.Atoi(curHostMax)
 	if err != nil {
-		logrus.Errorf("Unexpected error: %v", err)
+		logger.Errorf("Unexpected error: %v", err)
 		return err
 	}
 
@@ -256,13 +246,23 @@ func (h *MaxIntBaseHandler) pushFile(n domain.IOnodeIface, c domain.ContainerIfa
 	msg := []byte(strconv.Itoa(newMaxInt))
 	err = n.WriteFile(msg)
 	if err != nil && !h.Service.IgnoreErrors() {
-		logrus.Errorf("Could not write to file: %s", err)
+		logger.Errorf("Could not write to file: %s", err)
 		return err
 	}
 
 	return nil
 }
 
+// Obtains the integer held by a data-store value. Values stored as strings
+// (e.g. restored from old checkpoints) are parsed.
+func intValue(val domain.StateValue) (int, error) {
+	if i, ok := val.Int(); ok {
+		return int(i), nil
+	}
+
+	return strconv.Atoi(val.String())
+}
+
 func (h *MaxIntBaseHandler) GetName() string {
 	return h.Name
 }
@@ -272,7 +272,7 @@ func (h *MaxIntBaseHandler) GetPath() string {
 }
 
 func (h *MaxIntBaseHandler) GetEnabled() bool {
-	return h.Enabled
+	return getEnabled(&h.Enabled)
 }
 
 func (h *MaxIntBaseHandler) GetType() domain.HandlerType {
@@ -284,7 +284,7 @@ func (h *MaxIntBaseHandler) GetService() domain.HandlerServiceIface {
 }
 
 func (h *MaxIntBaseHandler) SetEnabled(val bool) {
-	h.Enabled = val
+	setEnabled(&h.Enabled, val)
 }
 
 func (h *MaxIntBaseHandler) SetService(hs domain.HandlerServiceIface) {
@@ -21,8 +21,6 @@ import (
 	"os"
 	"syscall"
 
-	"github.com/sirupsen/logrus"
-
 	"github.com/nestybox/sysbox-fs/domain"
 )
 
@@ -42,8 +40,6 @@ func (h *NeighDefaultHandler) Lookup(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (os.FileInfo, error) {
 
-	logrus.Debugf("Executing Lookup() method on %v handler", h.Name)
-
 	return n.Stat()
 }
 
@@ -51,11 +47,9 @@ func (h *NeighDefaultHandler) Getattr(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (*syscall.Stat_t, error) {
 
-	logrus.Debugf("Executing Getattr() method for Req ID=%#x on %v handler", req.ID, h.Name)
-
 	// Ensure operation is generated from within a registered sys container.
 	if req.Container == nil {
-		logrus.Errorf("Could not find the container originating this request (pid %v)",
+		logger.Errorf("Could not find the container originating this request (pid %v)",
 			req.Pid)
 		return nil, errors.New("Container not found")
 	}
@@ -72,15 +66,11 @@ func (h *NeighDefaultHandler) Open(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) error {
 
-	logrus.Debugf("Executing %v Open() method", h.Name)
-
 	return nil
 }
 
 func (h *NeighDefaultHandler) Close(node domain.IOnodeIface) error {
 
-	logrus.Debugf("Executing Close() method on %v handler", h.Name)
-
 	return nil
 }
 
@@ -88,8 +78,6 @@ func (h *NeighDefaultHandler) Read(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (int, error) {
 
-	logrus.Debugf("Executing %v Read() method", h.Name)
-
 	return 0, nil
 }
 
@@ -97,8 +85,6 @@ func (h *NeighDefaultHandler) Write(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (int, error) {
 
-	logrus.Debugf("Executing Write() method on %v handler", h.Name)
-
 	return 0, nil
 }
 
@@ -106,8 +92,6 @@ func (h *NeighDefaultHandler) ReadDirAll(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) ([]os.FileInfo, error) {
 
-	logrus.Debugf("Executing ReadDirAll() method for Req ID=%#x on %v handler; path = %s", req.ID, h.Name, n.Path())
-
 	// Return the list of emulated resources in this directory; we don't show
 	// non-emulated resources since write access to them would not be
 	// permissible.
@@ -134,7 +118,7 @@ func (h *NeighDefaultHandler) GetPath() string {
 }
 
 func (h *NeighDefaultHandler) GetEnabled() bool {
-	return h.Enabled
+	return getEnabled(&h.Enabled)
 }
 
 func (h *NeighDefaultHandler) GetType() domain.HandlerType {
@@ -146,7 +130,7 @@ func (h *NeighDefaultHandler) GetService() domain.HandlerServiceIface {
 }
 
 func (h *NeighDefaultHandler) SetEnabled(val bool) {
-	h.Enabled = val
+	setEnabled(&h.Enabled, val)
 }
 
 func (h *NeighDefaultHandler) SetService(hs domain.HandlerServiceIface) {
@@ -0,0 +1,154 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package implementations
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/nestybox/sysbox-fs/domain"
+)
+
+//
+// /proc/sys/net/bridge directory handler
+//
+// The directory is only present when the br_netfilter module is loaded, which
+// kubernetes' tooling expects within sys containers (see BridgeNfCallHandler).
+// If it isn't found within the container's netns, it's emulated from scratch.
+//
+type NetBridgeHandler struct {
+	Name      string
+	Path      string
+	Type      domain.HandlerType
+	Enabled   bool
+	Cacheable bool
+	Service   domain.HandlerServiceIface
+}
+
+func (h *NetBridgeHandler) Lookup(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (os.FileInfo, error) {
+
+	commonHandler, ok := h.Service.FindHandler("commonHandler")
+	if !ok {
+		return nil, fmt.Errorf("No commonHandler found")
+	}
+
+	info, err := commonHandler.Lookup(n, req)
+	if isNotExist(err) {
+		return syntheticFileInfo(filepath.Base(n.Path()), os.ModeDir|0555), nil
+	}
+
+	return info, err
+}
+
+func (h *NetBridgeHandler) Getattr(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (*syscall.Stat_t, error) {
+
+	// Ensure operation is generated from within a registered sys container.
+	if req.Container == nil {
+		logger.Errorf("Could not find the container originating this request (pid %v)",
+			req.Pid)
+		return nil, errors.New("Container not found")
+	}
+
+	stat := &syscall.Stat_t{
+		Uid: req.Container.UID(),
+		Gid: req.Container.GID(),
+	}
+
+	return stat, nil
+}
+
+func (h *NetBridgeHandler) Open(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) error {
+
+	return nil
+}
+
+func (h *NetBridgeHandler) Close(node domain.IOnodeIface) error {
+
+	return nil
+}
+
+func (h *NetBridgeHandler) Read(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	return 0, nil
+}
+
+func (h *NetBridgeHandler) Write(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	return 0, nil
+}
+
+func (h *NetBridgeHandler) ReadDirAll(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) ([]os.FileInfo, error) {
+
+	// Return the list of emulated resources in this directory; we don't show
+	// non-emulated resources since write access to them would not be
+	// permissible.
+
+	osEmulatedFileEntries, err := emulatedFilesInfo(h.Service, n, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var osFileEntries = make([]os.FileInfo, 0)
+	for _, v := range osEmulatedFileEntries {
+		osFileEntries = append(osFileEntries, v)
+	}
+
+	return osFileEntries, nil
+}
+
+func (h *NetBridgeHandler) GetName() string {
+	return h.Name
+}
+
+func (h *NetBridgeHandler) GetPath() string {
+	return h.Path
+}
+
+func (h *NetBridgeHandler) GetEnabled() bool {
+	return getEnabled(&h.Enabled)
+}
+
+func (h *NetBridgeHandler) GetType() domain.HandlerType {
+	return h.Type
+}
+
+func (h *NetBridgeHandler) GetService() domain.HandlerServiceIface {
+	return h.Service
+}
+
+func (h *NetBridgeHandler) SetEnabled(val bool) {
+	setEnabled(&h.Enabled, val)
+}
+
+func (h *NetBridgeHandler) SetService(hs domain.HandlerServiceIface) {
+	h.Service = hs
+}
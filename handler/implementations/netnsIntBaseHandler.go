@@ -0,0 +1,186 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package implementations
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/fuse"
+)
+
+// This is a base handler for network sysctls consisting of a single integer
+// value (e.g. net.ipv4.neigh.default.gc_thresh1 or net.ipv4.route.max_size).
+// Reads and writes are carried out within the network namespace of the process
+// originating the request (via nsenter), so that each sys container tunes its
+// own network stack. Kernels that only expose these sysctls within the initial
+// network namespace make the resource unavailable there; in that case the
+// handler falls back to the MaxIntBaseHandler behavior, where the value written
+// to the host kernel is the max value across sys containers.
+
+type NetnsIntBaseHandler struct {
+	Name      string
+	Path      string
+	Type      domain.HandlerType
+	Enabled   bool
+	Cacheable bool
+	Service   domain.HandlerServiceIface
+}
+
+func (h *NetnsIntBaseHandler) Lookup(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (os.FileInfo, error) {
+
+	return n.Stat()
+}
+
+func (h *NetnsIntBaseHandler) Getattr(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (*syscall.Stat_t, error) {
+
+	return nil, nil
+}
+
+func (h *NetnsIntBaseHandler) Open(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) error {
+
+	flags := n.OpenFlags()
+	if flags != syscall.O_RDONLY && flags != syscall.O_WRONLY {
+		return fuse.IOerror{Code: syscall.EACCES}
+	}
+
+	return nil
+}
+
+func (h *NetnsIntBaseHandler) Close(n domain.IOnodeIface) error {
+
+	return nil
+}
+
+func (h *NetnsIntBaseHandler) Read(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	// We are dealing with a single integer element being read, so we can save
+	// some cycles by returning right away if offset is any higher than zero.
+	if req.Offset > 0 {
+		return 0, io.EOF
+	}
+
+	// Ensure operation is generated from within a registered sys container.
+	if req.Container == nil {
+		logger.Errorf("Could not find the container originating this request (pid %v)",
+			req.Pid)
+		return 0, errors.New("Container not found")
+	}
+
+	commonHandler, ok := h.Service.FindHandler("commonHandler")
+	if !ok {
+		return 0, fmt.Errorf("No commonHandler found")
+	}
+
+	sz, err := commonHandler.Read(n, req)
+	if isNotExist(err) {
+		return h.hostHandler().Read(n, req)
+	}
+
+	return sz, err
+}
+
+func (h *NetnsIntBaseHandler) Write(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	// Ensure operation is generated from within a registered sys container.
+	if req.Container == nil {
+		logger.Errorf("Could not find the container originating this request (pid %v)",
+			req.Pid)
+		return 0, errors.New("Container not found")
+	}
+
+	newVal := strings.TrimSpace(string(req.Data))
+	if _, err := strconv.Atoi(newVal); err != nil {
+		return 0, fuse.IOerror{Code: syscall.EINVAL}
+	}
+
+	commonHandler, ok := h.Service.FindHandler("commonHandler")
+	if !ok {
+		return 0, fmt.Errorf("No commonHandler found")
+	}
+
+	sz, err := commonHandler.Write(n, req)
+	if isNotExist(err) {
+		return h.hostHandler().Write(n, req)
+	}
+
+	return sz, err
+}
+
+func (h *NetnsIntBaseHandler) ReadDirAll(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) ([]os.FileInfo, error) {
+
+	return nil, nil
+}
+
+// Handler serving the resource when it's not exposed within the container's
+// network namespace.
+func (h *NetnsIntBaseHandler) hostHandler() *MaxIntBaseHandler {
+	return &MaxIntBaseHandler{
+		Name:      h.Name,
+		Path:      h.Path,
+		Type:      h.Type,
+		Enabled:   h.GetEnabled(),
+		Cacheable: h.Cacheable,
+		Service:   h.Service,
+	}
+}
+
+func (h *NetnsIntBaseHandler) GetName() string {
+	return h.Name
+}
+
+func (h *NetnsIntBaseHandler) GetPath() string {
+	return h.Path
+}
+
+func (h *NetnsIntBaseHandler) GetEnabled() bool {
+	return getEnabled(&h.Enabled)
+}
+
+func (h *NetnsIntBaseHandler) GetType() domain.HandlerType {
+	return h.Type
+}
+
+func (h *NetnsIntBaseHandler) GetService() domain.HandlerServiceIface {
+	return h.Service
+}
+
+func (h *NetnsIntBaseHandler) SetEnabled(val bool) {
+	setEnabled(&h.Enabled, val)
+}
+
+func (h *NetnsIntBaseHandler) SetService(hs domain.HandlerServiceIface) {
+	h.Service = hs
+}
@@ -0,0 +1,176 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package implementations_test
+
+import (
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/fuse"
+	"github.com/nestybox/sysbox-fs/handler"
+	"github.com/nestybox/sysbox-fs/handler/implementations"
+	"github.com/nestybox/sysbox-fs/mocks"
+)
+
+func TestNetnsIntBaseHandler_Write(t *testing.T) {
+
+	const path = "/proc/sys/net/ipv4/neigh/default/gc_thresh1"
+
+	commonHandler := &mocks.HandlerIface{}
+	hs := &mocks.HandlerServiceIface{}
+	hs.On("FindHandler", "commonHandler").Return(commonHandler, true)
+
+	h := &implementations.NetnsIntBaseHandler{
+		Name:      "neighDefaultGcThresh1",
+		Path:      path,
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: true,
+		Service:   hs,
+	}
+
+	n := ios.NewIOnode("gc_thresh1", path, 0)
+	assert.NoError(t, n.WriteFile([]byte("128")))
+
+	newReq := func(data string) *domain.HandlerRequest {
+		return &domain.HandlerRequest{
+			Pid:  1001,
+			Data: []byte(data),
+			Container: css.ContainerCreate(
+				"c1",
+				uint32(1001),
+				time.Time{},
+				231072,
+				65535,
+				231072,
+				65535,
+				nil,
+				nil,
+				nil,
+				nil,
+				domain.CgroupPaths{},
+				"",
+				domain.ResourceLimits{}),
+		}
+	}
+
+	// Test-case 1: Resource exposed within the container's netns: the write
+	// is carried out there, and the host value is left untouched.
+	req := newReq("1024")
+	commonHandler.On("Write", n, req).Return(4, nil).Once()
+
+	sz, err := h.Write(n, req)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, sz)
+	val, _ := n.ReadLine()
+	assert.Equal(t, "128", val)
+
+	// Test-case 2: Resource not exposed within the container's netns: the
+	// (larger) value is pushed to the host instead.
+	req = newReq("2048")
+	commonHandler.On("Write", n, req).Return(0, fuse.IOerror{Code: syscall.ENOENT}).Once()
+
+	sz, err = h.Write(n, req)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, sz)
+	val, _ = n.ReadLine()
+	assert.Equal(t, "2048", val)
+
+	// Test-case 3: Non-integer values are rejected.
+	_, err = h.Write(n, newReq("abc"))
+	assert.Equal(t, fuse.IOerror{Code: syscall.EINVAL}, err)
+
+	commonHandler.AssertExpectations(t)
+}
+
+func TestNetnsIntBaseHandler_netCore(t *testing.T) {
+
+	paths := []string{
+		"/proc/sys/net/core/netdev_max_backlog",
+		"/proc/sys/net/core/rmem_max",
+		"/proc/sys/net/core/wmem_max",
+		"/proc/sys/net/core/rmem_default",
+		"/proc/sys/net/core/wmem_default",
+	}
+
+	byPath := make(map[string]domain.HandlerIface)
+	for _, h := range handler.DefaultHandlers {
+		byPath[h.GetPath()] = h
+	}
+
+	for _, path := range paths {
+		t.Run(path, func(t *testing.T) {
+
+			// The queue knobs are served within the container's netns.
+			dh, ok := byPath[path].(*implementations.NetnsIntBaseHandler)
+			if !assert.True(t, ok, "unexpected handler %T", byPath[path]) {
+				return
+			}
+
+			commonHandler := &mocks.HandlerIface{}
+			hs := &mocks.HandlerServiceIface{}
+			hs.On("FindHandler", "commonHandler").Return(commonHandler, true)
+
+			h := &implementations.NetnsIntBaseHandler{
+				Name:    dh.Name,
+				Path:    dh.Path,
+				Type:    dh.Type,
+				Enabled: true,
+				Service: hs,
+			}
+
+			n := ios.NewIOnode(filepath.Base(path), path, 0)
+			assert.NoError(t, n.WriteFile([]byte("212992")))
+
+			req := &domain.HandlerRequest{
+				Pid:  1001,
+				Data: []byte("4194304"),
+				Container: css.ContainerCreate(
+					"c1",
+					uint32(1001),
+					time.Time{},
+					231072,
+					65535,
+					231072,
+					65535,
+					nil,
+					nil,
+					nil,
+					nil,
+					domain.CgroupPaths{},
+					"",
+					domain.ResourceLimits{}),
+			}
+			commonHandler.On("Write", n, req).Return(7, nil).Once()
+
+			// Writes land in the container's netns; the host's value is left
+			// untouched.
+			sz, err := h.Write(n, req)
+			assert.NoError(t, err)
+			assert.Equal(t, 7, sz)
+			val, _ := n.ReadLine()
+			assert.Equal(t, "212992", val)
+
+			commonHandler.AssertExpectations(t)
+		})
+	}
+}
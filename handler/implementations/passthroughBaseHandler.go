@@ -0,0 +1,138 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package implementations
+
+import (
+	"os"
+	"syscall"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/fuse"
+)
+
+// This is a base handler for benign subtrees (e.g. /sys/class/tty) that tools
+// expect to find within a sys container, but that don't need any emulation.
+// Directories are listed and files are read straight out of the host, and no
+// resource can be modified. Handlers are meant to be registered for both the
+// subtree's root and its contents (i.e. "<root>/**").
+
+type PassthroughBaseHandler struct {
+	Name      string
+	Path      string
+	Type      domain.HandlerType
+	Enabled   bool
+	Cacheable bool
+	Service   domain.HandlerServiceIface
+}
+
+func (h *PassthroughBaseHandler) Lookup(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (os.FileInfo, error) {
+
+	return n.Stat()
+}
+
+func (h *PassthroughBaseHandler) Getattr(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (*syscall.Stat_t, error) {
+
+	return nil, nil
+}
+
+func (h *PassthroughBaseHandler) Open(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) error {
+
+	flags := n.OpenFlags()
+	if flags != syscall.O_RDONLY {
+		return fuse.IOerror{Code: syscall.EACCES}
+	}
+
+	return nil
+}
+
+func (h *PassthroughBaseHandler) Close(n domain.IOnodeIface) error {
+
+	return nil
+}
+
+func (h *PassthroughBaseHandler) Read(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	data, err := h.Content(n, req)
+	if err != nil {
+		return 0, err
+	}
+
+	return readContentAt(data, req)
+}
+
+func (h *PassthroughBaseHandler) Write(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	return 0, fuse.IOerror{Code: syscall.EACCES}
+}
+
+func (h *PassthroughBaseHandler) ReadDirAll(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) ([]os.FileInfo, error) {
+
+	return n.ReadDirAll()
+}
+
+// Full content of this resource within the sys container (the host's one).
+func (h *PassthroughBaseHandler) Content(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) ([]byte, error) {
+
+	return n.ReadFile()
+}
+
+func (h *PassthroughBaseHandler) GetName() string {
+	return h.Name
+}
+
+func (h *PassthroughBaseHandler) GetPath() string {
+	return h.Path
+}
+
+func (h *PassthroughBaseHandler) GetEnabled() bool {
+	return getEnabled(&h.Enabled)
+}
+
+func (h *PassthroughBaseHandler) GetType() domain.HandlerType {
+	return h.Type
+}
+
+func (h *PassthroughBaseHandler) GetService() domain.HandlerServiceIface {
+	return h.Service
+}
+
+func (h *PassthroughBaseHandler) SetEnabled(val bool) {
+	setEnabled(&h.Enabled, val)
+}
+
+func (h *PassthroughBaseHandler) SetService(hs domain.HandlerServiceIface) {
+	h.Service = hs
+}
+
+// Read-only resources.
+func (h *PassthroughBaseHandler) NodeMode() os.FileMode {
+	return 0444
+}
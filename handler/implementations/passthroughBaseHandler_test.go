@@ -0,0 +1,67 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package implementations_test
+
+import (
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/fuse"
+	"github.com/nestybox/sysbox-fs/handler/implementations"
+)
+
+func TestPassthroughBaseHandler(t *testing.T) {
+
+	const dir = "/sys/class/misc"
+
+	ios.NewIOnode("", dir+"/fuse/dev", 0).WriteFile([]byte("10:229\n"))
+	ios.NewIOnode("", dir+"/tun/dev", 0).WriteFile([]byte("10:200\n"))
+
+	h := &implementations.PassthroughBaseHandler{
+		Name:    "sysClassMiscTree",
+		Path:    dir + "/**",
+		Type:    domain.NODE_SUBSTITUTION,
+		Enabled: true,
+	}
+
+	req := &domain.HandlerRequest{Pid: 1001}
+
+	// Test-case 1: Directories are listed as in the host.
+	entries, err := h.ReadDirAll(ios.NewIOnode("misc", dir, 0), req)
+	assert.NoError(t, err)
+
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	assert.Equal(t, []string{"fuse", "tun"}, names)
+
+	// Test-case 2: Files are read from the host.
+	buf := make([]byte, 64)
+	sz, err := h.Read(ios.NewIOnode("dev", dir+"/tun/dev", 0),
+		&domain.HandlerRequest{Pid: 1001, Data: buf})
+	assert.NoError(t, err)
+	assert.Equal(t, "10:200\n", string(buf[:sz]))
+
+	// Test-case 3: Files can't be opened for writing.
+	n := ios.NewIOnode("dev", dir+"/tun/dev", 0)
+	n.SetOpenFlags(syscall.O_WRONLY)
+	assert.Equal(t, fuse.IOerror{Code: syscall.EACCES}, h.Open(n, req))
+}
@@ -21,7 +21,6 @@ import (
 	"syscall"
 
 	"github.com/nestybox/sysbox-fs/domain"
-	"github.com/sirupsen/logrus"
 )
 
 //
@@ -46,8 +45,6 @@ func (h *ProcHandler) Lookup(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (os.FileInfo, error) {
 
-	logrus.Debugf("Executing Lookup() method on %v handler", h.Name)
-
 	return n.Stat()
 }
 
@@ -55,8 +52,6 @@ func (h *ProcHandler) Getattr(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (*syscall.Stat_t, error) {
 
-	logrus.Debugf("Executing Getattr() method on %v handler", h.Name)
-
 	return nil, nil
 }
 
@@ -64,15 +59,11 @@ func (h *ProcHandler) Open(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) error {
 
-	logrus.Debugf("Executing %v Open() method", h.Name)
-
 	return nil
 }
 
 func (h *ProcHandler) Close(n domain.IOnodeIface) error {
 
-	logrus.Debugf("Executing Close() method on %v handler", h.Name)
-
 	return nil
 }
 
@@ -80,8 +71,6 @@ func (h *ProcHandler) Read(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (int, error) {
 
-	logrus.Debugf("Executing %v Read() method", h.Name)
-
 	return 0, nil
 }
 
@@ -89,8 +78,6 @@ func (h *ProcHandler) Write(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (int, error) {
 
-	logrus.Debugf("Executing %v Write() method", h.Name)
-
 	return 0, nil
 }
 
@@ -110,7 +97,7 @@ func (h *ProcHandler) GetPath() string {
 }
 
 func (h *ProcHandler) GetEnabled() bool {
-	return h.Enabled
+	return getEnabled(&h.Enabled)
 }
 
 func (h *ProcHandler) GetType() domain.HandlerType {
@@ -122,7 +109,7 @@ func (h *ProcHandler) GetService() domain.HandlerServiceIface {
 }
 
 func (h *ProcHandler) SetEnabled(val bool) {
-	h.Enabled = val
+	setEnabled(&h.Enabled, val)
 }
 
 func (h *ProcHandler) SetService(hs domain.HandlerServiceIface) {
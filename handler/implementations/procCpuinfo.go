@@ -17,12 +17,9 @@
 package implementations
 
 import (
-	"io"
 	"os"
 	"syscall"
 
-	"github.com/sirupsen/logrus"
-
 	"github.com/nestybox/sysbox-fs/domain"
 	"github.com/nestybox/sysbox-fs/fuse"
 )
@@ -43,8 +40,6 @@ func (h *ProcCpuinfoHandler) Lookup(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (os.FileInfo, error) {
 
-	logrus.Debugf("Executing Lookup() method on %v handler", h.Name)
-
 	return n.Stat()
 }
 
@@ -52,8 +47,6 @@ func (h *ProcCpuinfoHandler) Getattr(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (*syscall.Stat_t, error) {
 
-	logrus.Debugf("Executing Getattr() method on %v handler", h.Name)
-
 	return nil, nil
 }
 
@@ -61,15 +54,13 @@ func (h *ProcCpuinfoHandler) Open(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) error {
 
-	logrus.Debugf("Executing %v Open() method", h.Name)
-
 	flags := n.OpenFlags()
 	if flags != syscall.O_RDONLY {
 		return fuse.IOerror{Code: syscall.EACCES}
 	}
 
 	if err := n.Open(); err != nil {
-		logrus.Debugf("Error opening file %v", h.Path)
+		logger.Debugf("Error opening file %v", h.Path)
 		return fuse.IOerror{Code: syscall.EIO}
 	}
 
@@ -78,10 +69,8 @@ func (h *ProcCpuinfoHandler) Open(
 
 func (h *ProcCpuinfoHandler) Close(n domain.IOnodeIface) error {
 
-	logrus.Debugf("Executing Close() method on %v handler", h.Name)
-
 	if err := n.Close(); err != nil {
-		logrus.Debugf("Error closing file %v", h.Path)
+		logger.Debugf("Error closing file %v", h.Path)
 		return fuse.IOerror{Code: syscall.EIO}
 	}
 
@@ -92,12 +81,9 @@ func (h *ProcCpuinfoHandler) Read(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (int, error) {
 
-	logrus.Debugf("Executing %v Read() method", h.Name)
-
 	// Bypass emulation logic for now by going straight to host fs.
-	ios := h.Service.IOService()
-	len, err := ios.ReadNode(n, req.Data)
-	if err != nil && err != io.EOF {
+	len, err := readHostFileAt(h.Service.IOService(), n, req)
+	if err != nil {
 		return 0, err
 	}
 
@@ -110,8 +96,6 @@ func (h *ProcCpuinfoHandler) Write(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (int, error) {
 
-	logrus.Debugf("Executing %v Write() method", h.Name)
-
 	return 0, nil
 }
 
@@ -131,7 +115,7 @@ func (h *ProcCpuinfoHandler) GetPath() string {
 }
 
 func (h *ProcCpuinfoHandler) GetEnabled() bool {
-	return h.Enabled
+	return getEnabled(&h.Enabled)
 }
 
 func (h *ProcCpuinfoHandler) GetType() domain.HandlerType {
@@ -143,9 +127,14 @@ func (h *ProcCpuinfoHandler) GetService() domain.HandlerServiceIface {
 }
 
 func (h *ProcCpuinfoHandler) SetEnabled(val bool) {
-	h.Enabled = val
+	setEnabled(&h.Enabled, val)
 }
 
 func (h *ProcCpuinfoHandler) SetService(hs domain.HandlerServiceIface) {
 	h.Service = hs
 }
+
+// Read-only resource: writes are silently dropped.
+func (h *ProcCpuinfoHandler) NodeMode() os.FileMode {
+	return 0444
+}
@@ -21,8 +21,6 @@ import (
 	"os"
 	"syscall"
 
-	"github.com/sirupsen/logrus"
-
 	"github.com/nestybox/sysbox-fs/domain"
 	"github.com/nestybox/sysbox-fs/fuse"
 )
@@ -43,8 +41,6 @@ func (h *ProcDevicesHandler) Lookup(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (os.FileInfo, error) {
 
-	logrus.Debugf("Executing Lookup() method on %v handler", h.Name)
-
 	return n.Stat()
 }
 
@@ -52,8 +48,6 @@ func (h *ProcDevicesHandler) Getattr(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (*syscall.Stat_t, error) {
 
-	logrus.Debugf("Executing Getattr() method on %v handler", h.Name)
-
 	return nil, nil
 }
 
@@ -61,15 +55,13 @@ func (h *ProcDevicesHandler) Open(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) error {
 
-	logrus.Debugf("Executing %v Open() method", h.Name)
-
 	flags := n.OpenFlags()
 	if flags != syscall.O_RDONLY {
 		return fuse.IOerror{Code: syscall.EACCES}
 	}
 
 	if err := n.Open(); err != nil {
-		logrus.Debugf("Error opening file %v", h.Path)
+		logger.Debugf("Error opening file %v", h.Path)
 		return fuse.IOerror{Code: syscall.EIO}
 	}
 
@@ -78,10 +70,8 @@ func (h *ProcDevicesHandler) Open(
 
 func (h *ProcDevicesHandler) Close(n domain.IOnodeIface) error {
 
-	logrus.Debugf("Executing Close() method on %v handler", h.Name)
-
 	if err := n.Close(); err != nil {
-		logrus.Debugf("Error closing file %v", h.Path)
+		logger.Debugf("Error closing file %v", h.Path)
 		return fuse.IOerror{Code: syscall.EIO}
 	}
 
@@ -92,8 +82,6 @@ func (h *ProcDevicesHandler) Read(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (int, error) {
 
-	logrus.Debugf("Executing %v Read() method", h.Name)
-
 	// Bypass emulation logic for now by going straight to host fs.
 	ios := h.Service.IOService()
 	len, err := ios.ReadNode(n, req.Data)
@@ -110,8 +98,6 @@ func (h *ProcDevicesHandler) Write(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (int, error) {
 
-	logrus.Debugf("Executing %v Write() method", h.Name)
-
 	return 0, nil
 }
 
@@ -131,7 +117,7 @@ func (h *ProcDevicesHandler) GetPath() string {
 }
 
 func (h *ProcDevicesHandler) GetEnabled() bool {
-	return h.Enabled
+	return getEnabled(&h.Enabled)
 }
 
 func (h *ProcDevicesHandler) GetType() domain.HandlerType {
@@ -143,9 +129,14 @@ func (h *ProcDevicesHandler) GetService() domain.HandlerServiceIface {
 }
 
 func (h *ProcDevicesHandler) SetEnabled(val bool) {
-	h.Enabled = val
+	setEnabled(&h.Enabled, val)
 }
 
 func (h *ProcDevicesHandler) SetService(hs domain.HandlerServiceIface) {
 	h.Service = hs
 }
+
+// Read-only resource: writes are silently dropped.
+func (h *ProcDevicesHandler) NodeMode() os.FileMode {
+	return 0444
+}
@@ -17,12 +17,9 @@
 package implementations
 
 import (
-	"io"
 	"os"
 	"syscall"
 
-	"github.com/sirupsen/logrus"
-
 	"github.com/nestybox/sysbox-fs/domain"
 	"github.com/nestybox/sysbox-fs/fuse"
 )
@@ -43,8 +40,6 @@ func (h *ProcDiskstatsHandler) Lookup(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (os.FileInfo, error) {
 
-	logrus.Debugf("Executing Lookup() method on %v handler", h.Name)
-
 	return n.Stat()
 }
 
@@ -52,8 +47,6 @@ func (h *ProcDiskstatsHandler) Getattr(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (*syscall.Stat_t, error) {
 
-	logrus.Debugf("Executing Getattr() method on %v handler", h.Name)
-
 	return nil, nil
 }
 
@@ -61,15 +54,13 @@ func (h *ProcDiskstatsHandler) Open(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) error {
 
-	logrus.Debugf("Executing %v Open() method", h.Name)
-
 	flags := n.OpenFlags()
 	if flags != syscall.O_RDONLY {
 		return fuse.IOerror{Code: syscall.EACCES}
 	}
 
 	if err := n.Open(); err != nil {
-		logrus.Debugf("Error opening file %v", h.Path)
+		logger.Debugf("Error opening file %v", h.Path)
 		return fuse.IOerror{Code: syscall.EIO}
 	}
 
@@ -78,10 +69,8 @@ func (h *ProcDiskstatsHandler) Open(
 
 func (h *ProcDiskstatsHandler) Close(n domain.IOnodeIface) error {
 
-	logrus.Debugf("Executing Close() method on %v handler", h.Name)
-
 	if err := n.Close(); err != nil {
-		logrus.Debugf("Error closing file %v", h.Path)
+		logger.Debugf("Error closing file %v", h.Path)
 		return fuse.IOerror{Code: syscall.EIO}
 	}
 
@@ -92,26 +81,18 @@ func (h *ProcDiskstatsHandler) Read(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (int, error) {
 
-	logrus.Debugf("Executing %v Read() method", h.Name)
-
-	// Bypass emulation logic for now by going straight to host fs.
-	ios := h.Service.IOService()
-	len, err := ios.ReadNode(n, req.Data)
-	if err != nil && err != io.EOF {
+	data, err := h.Content(n, req)
+	if err != nil {
 		return 0, err
 	}
 
-	req.Data = req.Data[:len]
-
-	return len, nil
+	return readContentAt(data, req)
 }
 
 func (h *ProcDiskstatsHandler) Write(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (int, error) {
 
-	logrus.Debugf("Executing %v Write() method", h.Name)
-
 	return 0, nil
 }
 
@@ -122,6 +103,26 @@ func (h *ProcDiskstatsHandler) ReadDirAll(
 	return nil, nil
 }
 
+//
+// Full content of this resource within the sys container: the host's one,
+// short of the virtual block devices not visible to the container.
+//
+func (h *ProcDiskstatsHandler) Content(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) ([]byte, error) {
+
+	data, err := n.ReadFile()
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Container == nil {
+		return data, nil
+	}
+
+	return filterBlockDevices(data, 2, req.Container, h.Service.IOService()), nil
+}
+
 func (h *ProcDiskstatsHandler) GetName() string {
 	return h.Name
 }
@@ -131,7 +132,7 @@ func (h *ProcDiskstatsHandler) GetPath() string {
 }
 
 func (h *ProcDiskstatsHandler) GetEnabled() bool {
-	return h.Enabled
+	return getEnabled(&h.Enabled)
 }
 
 func (h *ProcDiskstatsHandler) GetType() domain.HandlerType {
@@ -143,9 +144,14 @@ func (h *ProcDiskstatsHandler) GetService() domain.HandlerServiceIface {
 }
 
 func (h *ProcDiskstatsHandler) SetEnabled(val bool) {
-	h.Enabled = val
+	setEnabled(&h.Enabled, val)
 }
 
 func (h *ProcDiskstatsHandler) SetService(hs domain.HandlerServiceIface) {
 	h.Service = hs
 }
+
+// Read-only resource: writes are silently dropped.
+func (h *ProcDiskstatsHandler) NodeMode() os.FileMode {
+	return 0444
+}
@@ -17,12 +17,9 @@
 package implementations
 
 import (
-	"io"
 	"os"
 	"syscall"
 
-	"github.com/sirupsen/logrus"
-
 	"github.com/nestybox/sysbox-fs/domain"
 	"github.com/nestybox/sysbox-fs/fuse"
 )
@@ -43,8 +40,6 @@ func (h *ProcPartitionsHandler) Lookup(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (os.FileInfo, error) {
 
-	logrus.Debugf("Executing Lookup() method on %v handler", h.Name)
-
 	return n.Stat()
 }
 
@@ -52,8 +47,6 @@ func (h *ProcPartitionsHandler) Getattr(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (*syscall.Stat_t, error) {
 
-	logrus.Debugf("Executing Getattr() method on %v handler", h.Name)
-
 	return nil, nil
 }
 
@@ -61,15 +54,13 @@ func (h *ProcPartitionsHandler) Open(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) error {
 
-	logrus.Debugf("Executing %v Open() method", h.Name)
-
 	flags := n.OpenFlags()
 	if flags != syscall.O_RDONLY {
 		return fuse.IOerror{Code: syscall.EACCES}
 	}
 
 	if err := n.Open(); err != nil {
-		logrus.Debugf("Error opening file %v", h.Path)
+		logger.Debugf("Error opening file %v", h.Path)
 		return fuse.IOerror{Code: syscall.EIO}
 	}
 
@@ -78,10 +69,8 @@ func (h *ProcPartitionsHandler) Open(
 
 func (h *ProcPartitionsHandler) Close(n domain.IOnodeIface) error {
 
-	logrus.Debugf("Executing Close() method on %v handler", h.Name)
-
 	if err := n.Close(); err != nil {
-		logrus.Debugf("Error closing file %v", h.Path)
+		logger.Debugf("Error closing file %v", h.Path)
 		return fuse.IOerror{Code: syscall.EIO}
 	}
 
@@ -92,18 +81,12 @@ func (h *ProcPartitionsHandler) Read(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (int, error) {
 
-	logrus.Debugf("Executing %v Read() method", h.Name)
-
-	// Bypass emulation logic for now by going straight to host fs.
-	ios := h.Service.IOService()
-	len, err := ios.ReadNode(n, req.Data)
-	if err != nil && err != io.EOF {
+	data, err := h.Content(n, req)
+	if err != nil {
 		return 0, err
 	}
 
-	req.Data = req.Data[:len]
-
-	return len, nil
+	return readContentAt(data, req)
 }
 
 func (h *ProcPartitionsHandler) Write(
@@ -120,6 +103,26 @@ func (h *ProcPartitionsHandler) ReadDirAll(
 	return nil, nil
 }
 
+//
+// Full content of this resource within the sys container: the host's one,
+// short of the virtual block devices not visible to the container.
+//
+func (h *ProcPartitionsHandler) Content(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) ([]byte, error) {
+
+	data, err := n.ReadFile()
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Container == nil {
+		return data, nil
+	}
+
+	return filterBlockDevices(data, 3, req.Container, h.Service.IOService()), nil
+}
+
 func (h *ProcPartitionsHandler) GetName() string {
 	return h.Name
 }
@@ -129,7 +132,7 @@ func (h *ProcPartitionsHandler) GetPath() string {
 }
 
 func (h *ProcPartitionsHandler) GetEnabled() bool {
-	return h.Enabled
+	return getEnabled(&h.Enabled)
 }
 
 func (h *ProcPartitionsHandler) GetType() domain.HandlerType {
@@ -141,9 +144,14 @@ func (h *ProcPartitionsHandler) GetService() domain.HandlerServiceIface {
 }
 
 func (h *ProcPartitionsHandler) SetEnabled(val bool) {
-	h.Enabled = val
+	setEnabled(&h.Enabled, val)
 }
 
 func (h *ProcPartitionsHandler) SetService(hs domain.HandlerServiceIface) {
 	h.Service = hs
 }
+
+// Read-only resource: writes are silently dropped.
+func (h *ProcPartitionsHandler) NodeMode() os.FileMode {
+	return 0444
+}
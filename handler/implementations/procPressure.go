@@ -0,0 +1,145 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package implementations
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/nestybox/sysbox-fs/domain"
+)
+
+//
+// /proc/pressure directory handler
+//
+// Lists the pressure-stall (PSI) resources emulated by sysbox-fs, which report
+// the pressure within the container's cgroup rather than the host's (see
+// ProcPressureFileHandler). Kernels with PSI disabled are presented with the
+// same listing.
+//
+
+// PSI resources exposed within /proc/pressure.
+var procPressureResources = []string{"cpu", "io", "memory"}
+
+type ProcPressureHandler struct {
+	Name      string
+	Path      string
+	Type      domain.HandlerType
+	Enabled   bool
+	Cacheable bool
+	Service   domain.HandlerServiceIface
+}
+
+func (h *ProcPressureHandler) Lookup(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (os.FileInfo, error) {
+
+	info, err := n.Stat()
+	if os.IsNotExist(err) {
+		return syntheticFileInfo(filepath.Base(n.Path()), os.ModeDir|0555), nil
+	}
+
+	return info, err
+}
+
+func (h *ProcPressureHandler) Getattr(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (*syscall.Stat_t, error) {
+
+	// Ensure operation is generated from within a registered sys container.
+	if req.Container == nil {
+		logger.Errorf("Could not find the container originating this request (pid %v)",
+			req.Pid)
+		return nil, errors.New("Container not found")
+	}
+
+	stat := &syscall.Stat_t{
+		Uid: req.Container.UID(),
+		Gid: req.Container.GID(),
+	}
+
+	return stat, nil
+}
+
+func (h *ProcPressureHandler) Open(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) error {
+
+	return nil
+}
+
+func (h *ProcPressureHandler) Close(node domain.IOnodeIface) error {
+
+	return nil
+}
+
+func (h *ProcPressureHandler) Read(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	return 0, nil
+}
+
+func (h *ProcPressureHandler) Write(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	return 0, nil
+}
+
+func (h *ProcPressureHandler) ReadDirAll(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) ([]os.FileInfo, error) {
+
+	var entries []os.FileInfo
+
+	for _, res := range procPressureResources {
+		entries = append(entries, syntheticFileInfo(res, 0444))
+	}
+
+	return entries, nil
+}
+
+func (h *ProcPressureHandler) GetName() string {
+	return h.Name
+}
+
+func (h *ProcPressureHandler) GetPath() string {
+	return h.Path
+}
+
+func (h *ProcPressureHandler) GetEnabled() bool {
+	return getEnabled(&h.Enabled)
+}
+
+func (h *ProcPressureHandler) GetType() domain.HandlerType {
+	return h.Type
+}
+
+func (h *ProcPressureHandler) GetService() domain.HandlerServiceIface {
+	return h.Service
+}
+
+func (h *ProcPressureHandler) SetEnabled(val bool) {
+	setEnabled(&h.Enabled, val)
+}
+
+func (h *ProcPressureHandler) SetService(hs domain.HandlerServiceIface) {
+	h.Service = hs
+}
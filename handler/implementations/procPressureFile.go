@@ -0,0 +1,180 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package implementations
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/fuse"
+)
+
+//
+// /proc/pressure/{cpu,io,memory} handler
+//
+// Documentation: Each file reports the share of time in which some (or all)
+// non-idle tasks were stalled on the given resource, averaged over 10s, 60s and
+// 300s windows, along with the total stall time in usecs.
+//
+// Within a sys container the figures are taken from the PSI files of the
+// container's cgroup-v2 (e.g. cpu.pressure), so that monitoring agents track
+// the container's own pressure. Containers with no cgroup-v2 (or kernels
+// with PSI disabled) are reported no pressure at all.
+//
+
+// PSI content reported when the container's cgroup doesn't offer it.
+const procPressureIdle = "some avg10=0.00 avg60=0.00 avg300=0.00 total=0\n" +
+	"full avg10=0.00 avg60=0.00 avg300=0.00 total=0\n"
+
+type ProcPressureFileHandler struct {
+	Name      string
+	Path      string
+	Type      domain.HandlerType
+	Enabled   bool
+	Cacheable bool
+	Service   domain.HandlerServiceIface
+}
+
+func (h *ProcPressureFileHandler) Lookup(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (os.FileInfo, error) {
+
+	// Kernels built without this resource are still served an emulated one.
+	info, err := n.Stat()
+	if isNotExist(err) {
+		return syntheticFileInfo(filepath.Base(n.Path()), 0444), nil
+	}
+
+	return info, err
+}
+
+func (h *ProcPressureFileHandler) Getattr(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (*syscall.Stat_t, error) {
+
+	return nil, nil
+}
+
+func (h *ProcPressureFileHandler) Open(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) error {
+
+	flags := n.OpenFlags()
+	if flags != syscall.O_RDONLY {
+		return fuse.IOerror{Code: syscall.EACCES}
+	}
+
+	return nil
+}
+
+func (h *ProcPressureFileHandler) Close(n domain.IOnodeIface) error {
+
+	return nil
+}
+
+func (h *ProcPressureFileHandler) Read(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	data, err := h.Content(n, req)
+	if err != nil {
+		return 0, err
+	}
+
+	return readContentAt(data, req)
+}
+
+func (h *ProcPressureFileHandler) Write(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	return 0, nil
+}
+
+func (h *ProcPressureFileHandler) ReadDirAll(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) ([]os.FileInfo, error) {
+
+	return nil, nil
+}
+
+// Full content of this resource within the sys container.
+func (h *ProcPressureFileHandler) Content(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) ([]byte, error) {
+
+	cntr := req.Container
+
+	// Ensure operation is generated from within a registered sys container.
+	if cntr == nil {
+		logger.Errorf("Could not find the container originating this request (pid %v)",
+			req.Pid)
+		return nil, errors.New("Container not found")
+	}
+
+	data := procPressureIdle
+
+	if paths := cntr.CgroupPaths(); paths.V2 != "" {
+		file := filepath.Join(cgroupRoot, paths.V2, filepath.Base(n.Path())+".pressure")
+
+		content, err := h.Service.IOService().NewIOnode("", file, 0).ReadFile()
+		if err == nil {
+			data = string(content)
+		} else if !isNotExist(err) {
+			logger.Errorf("Could not read from file %v: %v", file, err)
+			return nil, fuse.IOerror{Code: syscall.EIO}
+		}
+	}
+
+	return []byte(data), nil
+}
+
+func (h *ProcPressureFileHandler) GetName() string {
+	return h.Name
+}
+
+func (h *ProcPressureFileHandler) GetPath() string {
+	return h.Path
+}
+
+func (h *ProcPressureFileHandler) GetEnabled() bool {
+	return getEnabled(&h.Enabled)
+}
+
+func (h *ProcPressureFileHandler) GetType() domain.HandlerType {
+	return h.Type
+}
+
+func (h *ProcPressureFileHandler) GetService() domain.HandlerServiceIface {
+	return h.Service
+}
+
+func (h *ProcPressureFileHandler) SetEnabled(val bool) {
+	setEnabled(&h.Enabled, val)
+}
+
+func (h *ProcPressureFileHandler) SetService(hs domain.HandlerServiceIface) {
+	h.Service = hs
+}
+
+// Read-only resource: writes are silently dropped.
+func (h *ProcPressureFileHandler) NodeMode() os.FileMode {
+	return 0444
+}
@@ -0,0 +1,88 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package implementations_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/handler/implementations"
+	"github.com/nestybox/sysbox-fs/mocks"
+)
+
+func TestProcPressureFileHandler_Read(t *testing.T) {
+
+	const cpuPressure = "some avg10=1.50 avg60=0.75 avg300=0.20 total=123456\n" +
+		"full avg10=0.00 avg60=0.00 avg300=0.00 total=0\n"
+
+	hs := &mocks.HandlerServiceIface{}
+	hs.On("IOService").Return(ios)
+
+	// Only the cpu PSI file is present in the container's cgroup.
+	ios.NewIOnode("", "/sys/fs/cgroup/psi/cpu.pressure", 0).WriteFile([]byte(cpuPressure))
+
+	cntr := css.ContainerCreate(
+		"psi",
+		uint32(1001),
+		time.Time{},
+		231072,
+		65535,
+		231072,
+		65535,
+		nil,
+		nil,
+		nil,
+		nil,
+		domain.CgroupPaths{V2: "/psi"},
+		"",
+		domain.ResourceLimits{})
+
+	tests := []struct {
+		name string
+		file string
+		want string
+	}{
+		// Test-case 1: Pressure taken from the container's cgroup.
+		{"1", "cpu", cpuPressure},
+
+		// Test-case 2: No pressure reported when the cgroup doesn't offer it.
+		{"2", "io", "some avg10=0.00 avg60=0.00 avg300=0.00 total=0\n" +
+			"full avg10=0.00 avg60=0.00 avg300=0.00 total=0\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			h := &implementations.ProcPressureFileHandler{
+				Name:    "procPressure",
+				Path:    "/proc/pressure/" + tt.file,
+				Type:    domain.NODE_SUBSTITUTION,
+				Enabled: true,
+				Service: hs,
+			}
+			n := ios.NewIOnode(tt.file, "/proc/pressure/"+tt.file, 0)
+
+			buf := make([]byte, 256)
+			sz, err := h.Read(n, &domain.HandlerRequest{Pid: 1001, Data: buf, Container: cntr})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, string(buf[:sz]))
+		})
+	}
+}
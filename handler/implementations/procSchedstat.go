@@ -0,0 +1,170 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package implementations
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/fuse"
+)
+
+// Schedstat format version emulated by sysbox-fs.
+const schedstatVersion = 15
+
+//
+// /proc/schedstat Handler
+//
+// Exposes a minimal, well-formed schedstat file that only lists the cpus the
+// container is allowed to run on. Per-cpu counters are reported as zero, and
+// no scheduling domains are shown, as these would leak host topology.
+//
+type ProcSchedstatHandler struct {
+	Name      string
+	Path      string
+	Type      domain.HandlerType
+	Enabled   bool
+	Cacheable bool
+	Service   domain.HandlerServiceIface
+}
+
+func (h *ProcSchedstatHandler) Lookup(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (os.FileInfo, error) {
+
+	// Kernels built without this resource are still served an emulated one.
+	info, err := n.Stat()
+	if isNotExist(err) {
+		return syntheticFileInfo(filepath.Base(n.Path()), 0444), nil
+	}
+
+	return info, err
+}
+
+func (h *ProcSchedstatHandler) Getattr(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (*syscall.Stat_t, error) {
+
+	return nil, nil
+}
+
+func (h *ProcSchedstatHandler) Open(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) error {
+
+	flags := n.OpenFlags()
+	if flags != syscall.O_RDONLY {
+		return fuse.IOerror{Code: syscall.EACCES}
+	}
+
+	return nil
+}
+
+func (h *ProcSchedstatHandler) Close(n domain.IOnodeIface) error {
+
+	return nil
+}
+
+func (h *ProcSchedstatHandler) Read(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	data, err := h.Content(n, req)
+	if err != nil {
+		return 0, err
+	}
+
+	return readContentAt(data, req)
+}
+
+func (h *ProcSchedstatHandler) Write(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	return 0, nil
+}
+
+func (h *ProcSchedstatHandler) ReadDirAll(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) ([]os.FileInfo, error) {
+
+	return nil, nil
+}
+
+// Full content of this resource within the sys container.
+func (h *ProcSchedstatHandler) Content(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) ([]byte, error) {
+
+	cntr := req.Container
+
+	// Ensure operation is generated from within a registered sys container.
+	if cntr == nil {
+		logger.Errorf("Could not find the container originating this request (pid %v)",
+			req.Pid)
+		return nil, errors.New("Container not found")
+	}
+
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "version %d\n", schedstatVersion)
+	sb.WriteString("timestamp 0\n")
+
+	for _, cpu := range containerCpus(cntr) {
+		fmt.Fprintf(&sb, "cpu%d 0 0 0 0 0 0 0 0 0\n", cpu)
+	}
+
+	return []byte(sb.String()), nil
+}
+
+func (h *ProcSchedstatHandler) GetName() string {
+	return h.Name
+}
+
+func (h *ProcSchedstatHandler) GetPath() string {
+	return h.Path
+}
+
+func (h *ProcSchedstatHandler) GetEnabled() bool {
+	return getEnabled(&h.Enabled)
+}
+
+func (h *ProcSchedstatHandler) GetType() domain.HandlerType {
+	return h.Type
+}
+
+func (h *ProcSchedstatHandler) GetService() domain.HandlerServiceIface {
+	return h.Service
+}
+
+func (h *ProcSchedstatHandler) SetEnabled(val bool) {
+	setEnabled(&h.Enabled, val)
+}
+
+func (h *ProcSchedstatHandler) SetService(hs domain.HandlerServiceIface) {
+	h.Service = hs
+}
+
+// Read-only resource: writes are silently dropped.
+func (h *ProcSchedstatHandler) NodeMode() os.FileMode {
+	return 0444
+}
@@ -0,0 +1,77 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package implementations_test
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/handler/implementations"
+)
+
+func TestProcSchedstatHandler_Read(t *testing.T) {
+
+	cntr := css.ContainerCreate(
+		"c1",
+		uint32(1001),
+		time.Time{},
+		231072,
+		65535,
+		231072,
+		65535,
+		nil,
+		nil,
+		nil,
+		nil,
+		domain.CgroupPaths{},
+		"",
+		domain.ResourceLimits{CpusetCpus: "1,3-4"})
+
+	h := &implementations.ProcSchedstatHandler{
+		Name:    "procSchedstat",
+		Path:    "/proc/schedstat",
+		Type:    domain.NODE_SUBSTITUTION | domain.NODE_BINDMOUNT,
+		Enabled: true,
+	}
+	n := ios.NewIOnode("schedstat", "/proc/schedstat", 0)
+
+	want := "version 15\n" +
+		"timestamp 0\n" +
+		"cpu1 0 0 0 0 0 0 0 0 0\n" +
+		"cpu3 0 0 0 0 0 0 0 0 0\n" +
+		"cpu4 0 0 0 0 0 0 0 0 0\n"
+
+	// Test-case 1: Only the cpus in the container's cpuset are listed.
+	buf := make([]byte, 256)
+	sz, err := h.Read(n, &domain.HandlerRequest{Pid: 1001, Data: buf, Container: cntr})
+	assert.NoError(t, err)
+	assert.Equal(t, want, string(buf[:sz]))
+
+	// Test-case 2: Reads past the end of the file return EOF.
+	sz, err = h.Read(n, &domain.HandlerRequest{
+		Pid:       1001,
+		Data:      buf,
+		Offset:    int64(len(want)),
+		Container: cntr,
+	})
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, 0, sz)
+}
@@ -22,8 +22,6 @@ import (
 	"os"
 	"syscall"
 
-	"github.com/sirupsen/logrus"
-
 	"github.com/nestybox/sysbox-fs/domain"
 	"github.com/nestybox/sysbox-fs/fuse"
 )
@@ -47,8 +45,6 @@ func (h *ProcSwapsHandler) Lookup(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (os.FileInfo, error) {
 
-	logrus.Debugf("Executing Lookup() method on %v handler", h.Name)
-
 	return n.Stat()
 }
 
@@ -56,8 +52,6 @@ func (h *ProcSwapsHandler) Getattr(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (*syscall.Stat_t, error) {
 
-	logrus.Debugf("Executing Getattr() method on %v handler", h.Name)
-
 	return nil, nil
 }
 
@@ -65,15 +59,13 @@ func (h *ProcSwapsHandler) Open(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) error {
 
-	logrus.Debugf("Executing %v Open() method", h.Name)
-
 	flags := n.OpenFlags()
 	if flags != syscall.O_RDONLY {
 		return fuse.IOerror{Code: syscall.EACCES}
 	}
 
 	if err := n.Open(); err != nil {
-		logrus.Debugf("Error opening file %v", h.Path)
+		logger.Debugf("Error opening file %v", h.Path)
 		return fuse.IOerror{Code: syscall.EIO}
 	}
 
@@ -82,10 +74,8 @@ func (h *ProcSwapsHandler) Open(
 
 func (h *ProcSwapsHandler) Close(n domain.IOnodeIface) error {
 
-	logrus.Debugf("Executing Close() method on %v handler", h.Name)
-
 	if err := n.Close(); err != nil {
-		logrus.Debugf("Error closing file %v", h.Path)
+		logger.Debugf("Error closing file %v", h.Path)
 		return fuse.IOerror{Code: syscall.EIO}
 	}
 
@@ -96,43 +86,22 @@ func (h *ProcSwapsHandler) Read(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (int, error) {
 
-	logrus.Debugf("Executing %v Read() method", h.Name)
-
 	if req.Offset > 0 {
 		return 0, io.EOF
 	}
 
-	name := n.Name()
-	path := n.Path()
 	cntr := req.Container
 
 	// Ensure operation is generated from within a registered sys container.
 	if cntr == nil {
-		logrus.Errorf("Could not find the container originating this request (pid %v)",
+		logger.Errorf("Could not find the container originating this request (pid %v)",
 			req.Pid)
 		return 0, errors.New("Container not found")
 	}
 
-	// If no modification has been ever made to this container's swapping mode,
-	// then let's assume that swapping in OFF by default.
-	data, ok := cntr.Data(path, name)
-	if !ok || data == "swapoff" {
-		result := []byte(swapsHeader + "\n")
-		return copyResultBuffer(req.Data, result)
-	}
-
-	var result []byte
-
-	// If swapping is enabled ("swapon" value was explicitly set), extract the
-	// information directly from the host fs. Note that this action displays
-	// stats of the overall system, and not of the container itself, but it's
-	// a valid approximation for now given that kernel doesn't expose anything
-	// close to this.
-	ios := h.Service.IOService()
-	_, err := ios.ReadNode(n, result)
-	if err != nil && err != io.EOF {
-		return 0, err
-	}
+	// Sys containers can't make use of swap devices (swapon / swapoff syscalls
+	// are rejected, see seccomp/tracer.go), so no device is ever listed.
+	result := []byte(swapsHeader + "\n")
 
 	return copyResultBuffer(req.Data, result)
 }
@@ -160,7 +129,7 @@ func (h *ProcSwapsHandler) GetPath() string {
 }
 
 func (h *ProcSwapsHandler) GetEnabled() bool {
-	return h.Enabled
+	return getEnabled(&h.Enabled)
 }
 
 func (h *ProcSwapsHandler) GetType() domain.HandlerType {
@@ -172,9 +141,14 @@ func (h *ProcSwapsHandler) GetService() domain.HandlerServiceIface {
 }
 
 func (h *ProcSwapsHandler) SetEnabled(val bool) {
-	h.Enabled = val
+	setEnabled(&h.Enabled, val)
 }
 
 func (h *ProcSwapsHandler) SetService(hs domain.HandlerServiceIface) {
 	h.Service = hs
 }
+
+// Read-only resource: writes are silently dropped.
+func (h *ProcSwapsHandler) NodeMode() os.FileMode {
+	return 0444
+}
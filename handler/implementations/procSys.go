@@ -22,8 +22,6 @@ import (
 	"os"
 	"syscall"
 
-	"github.com/sirupsen/logrus"
-
 	"github.com/nestybox/sysbox-fs/domain"
 )
 
@@ -43,8 +41,6 @@ func (h *ProcSysHandler) Lookup(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (os.FileInfo, error) {
 
-	logrus.Debugf("Executing Lookup() method for Req ID=%#x on %v handler", req.ID, h.Name)
-
 	return n.Stat()
 }
 
@@ -52,8 +48,6 @@ func (h *ProcSysHandler) Getattr(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (*syscall.Stat_t, error) {
 
-	logrus.Debugf("Executing Getattr() method for Req ID=%#x on %v handler", req.ID, h.Name)
-
 	return nil, nil
 }
 
@@ -61,15 +55,11 @@ func (h *ProcSysHandler) Open(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) error {
 
-	logrus.Debugf("Executing Open() method for Req ID=%#x on %v handler", req.ID, h.Name)
-
 	return nil
 }
 
 func (h *ProcSysHandler) Close(node domain.IOnodeIface) error {
 
-	logrus.Debugf("Executing Close() method on %v handler", h.Name)
-
 	return nil
 }
 
@@ -77,8 +67,6 @@ func (h *ProcSysHandler) Read(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (int, error) {
 
-	logrus.Debugf("Executing Read() method for Req ID=%#v method on %v handler", req.ID, h.Name)
-
 	if req.Offset > 0 {
 		return 0, io.EOF
 	}
@@ -97,8 +85,6 @@ func (h *ProcSysHandler) ReadDirAll(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) ([]os.FileInfo, error) {
 
-	logrus.Debugf("Executing ReadDirAll() method for Req ID=%#x on %v handler", req.ID, h.Name)
-
 	commonHandler, ok := h.Service.FindHandler("commonHandler")
 	if !ok {
 		return nil, fmt.Errorf("No commonHandler found")
@@ -116,7 +102,7 @@ func (h *ProcSysHandler) GetPath() string {
 }
 
 func (h *ProcSysHandler) GetEnabled() bool {
-	return h.Enabled
+	return getEnabled(&h.Enabled)
 }
 
 func (h *ProcSysHandler) GetType() domain.HandlerType {
@@ -128,7 +114,7 @@ func (h *ProcSysHandler) GetService() domain.HandlerServiceIface {
 }
 
 func (h *ProcSysHandler) SetEnabled(val bool) {
-	h.Enabled = val
+	setEnabled(&h.Enabled, val)
 }
 
 func (h *ProcSysHandler) SetService(hs domain.HandlerServiceIface) {
@@ -0,0 +1,140 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package implementations
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/nestybox/sysbox-fs/domain"
+)
+
+//
+// /proc/sys/{abi,debug} directory handler
+//
+// These less-common subtrees are listed as in the host, so that scans of the
+// whole /proc/sys hierarchy (e.g. 'sysctl -a') don't trip over them. Kernels
+// lacking them (e.g. /proc/sys/abi in non-x86 architectures) are presented
+// with an empty directory instead.
+//
+type ProcSysStubDirHandler struct {
+	Name      string
+	Path      string
+	Type      domain.HandlerType
+	Enabled   bool
+	Cacheable bool
+	Service   domain.HandlerServiceIface
+}
+
+func (h *ProcSysStubDirHandler) Lookup(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (os.FileInfo, error) {
+
+	info, err := n.Stat()
+	if os.IsNotExist(err) {
+		return syntheticFileInfo(filepath.Base(n.Path()), os.ModeDir|0555), nil
+	}
+
+	return info, err
+}
+
+func (h *ProcSysStubDirHandler) Getattr(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (*syscall.Stat_t, error) {
+
+	// Ensure operation is generated from within a registered sys container.
+	if req.Container == nil {
+		logger.Errorf("Could not find the container originating this request (pid %v)",
+			req.Pid)
+		return nil, errors.New("Container not found")
+	}
+
+	stat := &syscall.Stat_t{
+		Uid: req.Container.UID(),
+		Gid: req.Container.GID(),
+	}
+
+	return stat, nil
+}
+
+func (h *ProcSysStubDirHandler) Open(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) error {
+
+	return nil
+}
+
+func (h *ProcSysStubDirHandler) Close(node domain.IOnodeIface) error {
+
+	return nil
+}
+
+func (h *ProcSysStubDirHandler) Read(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	return 0, nil
+}
+
+func (h *ProcSysStubDirHandler) Write(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	return 0, nil
+}
+
+func (h *ProcSysStubDirHandler) ReadDirAll(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) ([]os.FileInfo, error) {
+
+	entries, err := n.ReadDirAll()
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	return entries, err
+}
+
+func (h *ProcSysStubDirHandler) GetName() string {
+	return h.Name
+}
+
+func (h *ProcSysStubDirHandler) GetPath() string {
+	return h.Path
+}
+
+func (h *ProcSysStubDirHandler) GetEnabled() bool {
+	return getEnabled(&h.Enabled)
+}
+
+func (h *ProcSysStubDirHandler) GetType() domain.HandlerType {
+	return h.Type
+}
+
+func (h *ProcSysStubDirHandler) GetService() domain.HandlerServiceIface {
+	return h.Service
+}
+
+func (h *ProcSysStubDirHandler) SetEnabled(val bool) {
+	setEnabled(&h.Enabled, val)
+}
+
+func (h *ProcSysStubDirHandler) SetService(hs domain.HandlerServiceIface) {
+	h.Service = hs
+}
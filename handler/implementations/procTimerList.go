@@ -0,0 +1,177 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package implementations
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/fuse"
+	"golang.org/x/sys/unix"
+)
+
+// Timer-list format version emulated by sysbox-fs.
+const timerListVersion = "v0.9"
+
+//
+// /proc/timer_list Handler
+//
+// Exposes a minimal, well-formed timer_list file with one entry per cpu the
+// container is allowed to run on. No active timers or clock-event devices are
+// reported, as these would leak host (and other containers') activity.
+//
+type ProcTimerListHandler struct {
+	Name      string
+	Path      string
+	Type      domain.HandlerType
+	Enabled   bool
+	Cacheable bool
+	Service   domain.HandlerServiceIface
+}
+
+func (h *ProcTimerListHandler) Lookup(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (os.FileInfo, error) {
+
+	// Kernels built without this resource are still served an emulated one.
+	info, err := n.Stat()
+	if isNotExist(err) {
+		return syntheticFileInfo(filepath.Base(n.Path()), 0444), nil
+	}
+
+	return info, err
+}
+
+func (h *ProcTimerListHandler) Getattr(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (*syscall.Stat_t, error) {
+
+	return nil, nil
+}
+
+func (h *ProcTimerListHandler) Open(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) error {
+
+	flags := n.OpenFlags()
+	if flags != syscall.O_RDONLY {
+		return fuse.IOerror{Code: syscall.EACCES}
+	}
+
+	return nil
+}
+
+func (h *ProcTimerListHandler) Close(n domain.IOnodeIface) error {
+
+	return nil
+}
+
+func (h *ProcTimerListHandler) Read(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	data, err := h.Content(n, req)
+	if err != nil {
+		return 0, err
+	}
+
+	return readContentAt(data, req)
+}
+
+func (h *ProcTimerListHandler) Write(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	return 0, nil
+}
+
+func (h *ProcTimerListHandler) ReadDirAll(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) ([]os.FileInfo, error) {
+
+	return nil, nil
+}
+
+// Full content of this resource within the sys container.
+func (h *ProcTimerListHandler) Content(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) ([]byte, error) {
+
+	cntr := req.Container
+
+	// Ensure operation is generated from within a registered sys container.
+	if cntr == nil {
+		logger.Errorf("Could not find the container originating this request (pid %v)",
+			req.Pid)
+		return nil, errors.New("Container not found")
+	}
+
+	var sb strings.Builder
+
+	var now unix.Timespec
+	if err := unix.ClockGettime(unix.CLOCK_MONOTONIC, &now); err != nil {
+		return nil, fuse.IOerror{Code: syscall.EIO}
+	}
+
+	fmt.Fprintf(&sb, "Timer List Version: %s\n", timerListVersion)
+	sb.WriteString("HRTIMER_MAX_CLOCK_BASES: 0\n")
+	fmt.Fprintf(&sb, "now at %d nsecs\n", now.Nano())
+
+	for _, cpu := range containerCpus(cntr) {
+		fmt.Fprintf(&sb, "\ncpu: %d\n", cpu)
+	}
+
+	return []byte(sb.String()), nil
+}
+
+func (h *ProcTimerListHandler) GetName() string {
+	return h.Name
+}
+
+func (h *ProcTimerListHandler) GetPath() string {
+	return h.Path
+}
+
+func (h *ProcTimerListHandler) GetEnabled() bool {
+	return getEnabled(&h.Enabled)
+}
+
+func (h *ProcTimerListHandler) GetType() domain.HandlerType {
+	return h.Type
+}
+
+func (h *ProcTimerListHandler) GetService() domain.HandlerServiceIface {
+	return h.Service
+}
+
+func (h *ProcTimerListHandler) SetEnabled(val bool) {
+	setEnabled(&h.Enabled, val)
+}
+
+func (h *ProcTimerListHandler) SetService(hs domain.HandlerServiceIface) {
+	h.Service = hs
+}
+
+// Read-only resource: writes are silently dropped.
+func (h *ProcTimerListHandler) NodeMode() os.FileMode {
+	return 0444
+}
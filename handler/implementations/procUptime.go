@@ -24,8 +24,6 @@ import (
 	"syscall"
 	"time"
 
-	"github.com/sirupsen/logrus"
-
 	"github.com/nestybox/sysbox-fs/domain"
 	"github.com/nestybox/sysbox-fs/fuse"
 )
@@ -46,8 +44,6 @@ func (h *ProcUptimeHandler) Lookup(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (os.FileInfo, error) {
 
-	logrus.Debugf("Executing Lookup() method on %v handler", h.Name)
-
 	return n.Stat()
 }
 
@@ -55,8 +51,6 @@ func (h *ProcUptimeHandler) Getattr(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (*syscall.Stat_t, error) {
 
-	logrus.Debugf("Executing Getattr() method on %v handler", h.Name)
-
 	return nil, nil
 }
 
@@ -64,15 +58,13 @@ func (h *ProcUptimeHandler) Open(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) error {
 
-	logrus.Debugf("Executing %v Open() method", h.Name)
-
 	flags := n.OpenFlags()
 	if flags != syscall.O_RDONLY {
 		return fuse.IOerror{Code: syscall.EACCES}
 	}
 
 	if err := n.Open(); err != nil {
-		logrus.Debugf("Error opening file %v", h.Path)
+		logger.Debugf("Error opening file %v", h.Path)
 		return fuse.IOerror{Code: syscall.EIO}
 	}
 
@@ -81,10 +73,8 @@ func (h *ProcUptimeHandler) Open(
 
 func (h *ProcUptimeHandler) Close(n domain.IOnodeIface) error {
 
-	logrus.Debugf("Executing Close() method on %v handler", h.Name)
-
 	if err := n.Close(); err != nil {
-		logrus.Debugf("Error closing file %v", h.Path)
+		logger.Debugf("Error closing file %v", h.Path)
 		return fuse.IOerror{Code: syscall.EIO}
 	}
 
@@ -95,8 +85,6 @@ func (h *ProcUptimeHandler) Read(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (int, error) {
 
-	logrus.Debugf("Executing %v Read() method", h.Name)
-
 	// We are dealing with a single integer element being read, so we can save
 	// some cycles by returning right away if offset is any higher than zero.
 	if req.Offset > 0 {
@@ -107,7 +95,7 @@ func (h *ProcUptimeHandler) Read(
 
 	// Ensure operation is generated from within a registered sys container.
 	if cntr == nil {
-		logrus.Errorf("Could not find the container originating this request (pid %v)",
+		logger.Errorf("Could not find the container originating this request (pid %v)",
 			req.Pid)
 		return 0, errors.New("Container not found")
 	}
@@ -163,7 +151,7 @@ func (h *ProcUptimeHandler) GetPath() string {
 
 func (h *ProcUptimeHandler) GetEnabled() bool {
 
-	return h.Enabled
+	return getEnabled(&h.Enabled)
 }
 
 func (h *ProcUptimeHandler) GetType() domain.HandlerType {
@@ -175,10 +163,15 @@ func (h *ProcUptimeHandler) GetService() domain.HandlerServiceIface {
 }
 
 func (h *ProcUptimeHandler) SetEnabled(val bool) {
-	h.Enabled = val
+	setEnabled(&h.Enabled, val)
 }
 
 func (h *ProcUptimeHandler) SetService(hs domain.HandlerServiceIface) {
 
 	h.Service = hs
 }
+
+// Read-only resource: writes are silently dropped.
+func (h *ProcUptimeHandler) NodeMode() os.FileMode {
+	return 0444
+}
@@ -0,0 +1,387 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package implementations
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/fuse"
+)
+
+// This is a base handler for sysctls holding a single string out of a set of
+// allowed ones (e.g. net.core.default_qdisc). Written values are validated
+// against the allowed set of each sysctl (see stringSysctls). Namespaced
+// sysctls are then accessed (via nsenter) within the namespaces of the process
+// originating the request; the remaining ones are emulated at sys-container
+// level, starting with the host's value.
+
+//
+// Layout of a string sysctl. The allowed values are either fixed, listed by a
+// separate file (space-separated), or listed by the sysctl itself with the
+// selected one in brackets (e.g. "always [madvise] never").
+//
+type stringSysctl struct {
+	allowed     []string
+	allowedPath string
+	bracketed   bool
+	namespaced  bool
+}
+
+// Layout of the string sysctls served by StringBaseHandler.
+var stringSysctls = map[string]stringSysctl{
+	// Queuing disciplines that work well without configuration (see
+	// net/sched/Kconfig).
+	"/proc/sys/net/core/default_qdisc": {
+		allowed: []string{"fq", "fq_codel", "sfq", "pfifo_fast"},
+	},
+	"/proc/sys/net/ipv4/tcp_congestion_control": {
+		allowedPath: "/proc/sys/net/ipv4/tcp_available_congestion_control",
+		namespaced:  true,
+	},
+	"/sys/kernel/mm/transparent_hugepage/enabled": {
+		bracketed: true,
+	},
+	"/sys/kernel/mm/transparent_hugepage/defrag": {
+		bracketed: true,
+	},
+}
+
+// Splits a bracketed list (e.g. "always [madvise] never") into its values and
+// the selected one.
+func parseBracketed(s string) ([]string, string) {
+
+	var (
+		values   []string
+		selected string
+	)
+
+	for _, f := range strings.Fields(s) {
+		if strings.HasPrefix(f, "[") && strings.HasSuffix(f, "]") {
+			f = strings.Trim(f, "[]")
+			selected = f
+		}
+		values = append(values, f)
+	}
+
+	return values, selected
+}
+
+// Builds a bracketed list out of the given values and the selected one.
+func formatBracketed(values []string, selected string) string {
+
+	fields := make([]string, len(values))
+
+	for i, v := range values {
+		if v == selected {
+			v = "[" + v + "]"
+		}
+		fields[i] = v
+	}
+
+	return strings.Join(fields, " ")
+}
+
+type StringBaseHandler struct {
+	Name      string
+	Path      string
+	Type      domain.HandlerType
+	Enabled   bool
+	Cacheable bool
+	Service   domain.HandlerServiceIface
+}
+
+func (h *StringBaseHandler) Lookup(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (os.FileInfo, error) {
+
+	return n.Stat()
+}
+
+func (h *StringBaseHandler) Getattr(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (*syscall.Stat_t, error) {
+
+	return nil, nil
+}
+
+func (h *StringBaseHandler) Open(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) error {
+
+	flags := n.OpenFlags()
+	if flags != syscall.O_RDONLY && flags != syscall.O_WRONLY {
+		return fuse.IOerror{Code: syscall.EACCES}
+	}
+
+	if !stringSysctls[n.Path()].namespaced {
+		return nil
+	}
+
+	commonHandler, ok := h.Service.FindHandler("commonHandler")
+	if !ok {
+		return fmt.Errorf("No commonHandler found")
+	}
+
+	return commonHandler.Open(n, req)
+}
+
+func (h *StringBaseHandler) Close(n domain.IOnodeIface) error {
+
+	return nil
+}
+
+func (h *StringBaseHandler) Read(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	// We are dealing with a single line element being read, so we can save
+	// some cycles by returning right away if offset is any higher than zero.
+	if req.Offset > 0 {
+		return 0, io.EOF
+	}
+
+	cntr := req.Container
+
+	// Ensure operation is generated from within a registered sys container.
+	if cntr == nil {
+		logger.Errorf("Could not find the container originating this request (pid %v)",
+			req.Pid)
+		return 0, errors.New("Container not found")
+	}
+
+	if stringSysctls[n.Path()].namespaced {
+		commonHandler, ok := h.Service.FindHandler("commonHandler")
+		if !ok {
+			return 0, fmt.Errorf("No commonHandler found")
+		}
+
+		return commonHandler.Read(n, req)
+	}
+
+	data, err := h.emulatedValue(n, cntr)
+	if err != nil {
+		return 0, err
+	}
+
+	data += "\n"
+
+	return copyResultBuffer(req.Data, []byte(data))
+}
+
+func (h *StringBaseHandler) Write(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	name := n.Name()
+	path := n.Path()
+	cntr := req.Container
+
+	// Ensure operation is generated from within a registered sys container.
+	if cntr == nil {
+		logger.Errorf("Could not find the container originating this request (pid %v)",
+			req.Pid)
+		return 0, errors.New("Container not found")
+	}
+
+	s, ok := stringSysctls[path]
+	if !ok {
+		logger.Errorf("Unsupported string sysctl %v", path)
+		return 0, fuse.IOerror{Code: syscall.EINVAL}
+	}
+
+	newVal := strings.TrimSpace(string(req.Data))
+
+	// Only supported values must be accepted.
+	allowed, err := h.allowedValues(n, req, s)
+	if err != nil {
+		return 0, err
+	}
+
+	var found bool
+	for _, v := range allowed {
+		if v == newVal {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return 0, fuse.IOerror{Code: syscall.EINVAL}
+	}
+
+	if s.namespaced {
+		commonHandler, ok := h.Service.FindHandler("commonHandler")
+		if !ok {
+			return 0, fmt.Errorf("No commonHandler found")
+		}
+
+		return commonHandler.Write(n, req)
+	}
+
+	if s.bracketed {
+		newVal = formatBracketed(allowed, newVal)
+	}
+
+	// Store the new value within the container struct.
+	cntr.SetData(path, name, newVal)
+
+	return len(req.Data), nil
+}
+
+func (h *StringBaseHandler) ReadDirAll(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) ([]os.FileInfo, error) {
+
+	return nil, nil
+}
+
+//
+// Returns the value of an emulated sysctl for the given container. If this
+// resource hasn't been initialized for the container yet, the information is
+// fetched from the host FS and stored accordingly within the container struct.
+//
+func (h *StringBaseHandler) emulatedValue(
+	n domain.IOnodeIface,
+	cntr domain.ContainerIface) (string, error) {
+
+	name := n.Name()
+	path := n.Path()
+
+	data, ok := cntr.Data(path, name)
+	if !ok {
+		// Read from host FS to extract the existing value.
+		curHostVal, err := n.ReadLine()
+		if err != nil && err != io.EOF {
+			logger.Errorf("Could not read from file %v", h.Path)
+			return "", fuse.IOerror{Code: syscall.EIO}
+		}
+
+		data = strings.TrimSpace(curHostVal)
+		cntr.SetData(path, name, data)
+	}
+
+	return data, nil
+}
+
+// Returns the values accepted by the given string sysctl.
+func (h *StringBaseHandler) allowedValues(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest,
+	s stringSysctl) ([]string, error) {
+
+	switch {
+	case s.allowed != nil:
+		return s.allowed, nil
+
+	case s.bracketed:
+		cur, err := h.emulatedValue(n, req.Container)
+		if err != nil {
+			return nil, err
+		}
+		values, _ := parseBracketed(cur)
+
+		return values, nil
+
+	case s.allowedPath != "":
+		an := h.Service.IOService().NewIOnode(
+			filepath.Base(s.allowedPath), s.allowedPath, 0)
+
+		// Namespaced lists are read within the namespaces of the process
+		// originating the request, just like the sysctl they refer to.
+		if !s.namespaced {
+			data, err := an.ReadLine()
+			if err != nil && err != io.EOF {
+				logger.Errorf("Could not read from file %v", s.allowedPath)
+				return nil, fuse.IOerror{Code: syscall.EIO}
+			}
+
+			return strings.Fields(data), nil
+		}
+
+		commonHandler, ok := h.Service.FindHandler("commonHandler")
+		if !ok {
+			return nil, fmt.Errorf("No commonHandler found")
+		}
+
+		buf := make([]byte, 4096)
+		sz, err := commonHandler.Read(an, &domain.HandlerRequest{
+			ID:        req.ID,
+			Pid:       req.Pid,
+			Uid:       req.Uid,
+			Gid:       req.Gid,
+			Data:      buf,
+			Container: req.Container,
+			Ctx:       req.Ctx,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return strings.Fields(string(buf[:sz])), nil
+	}
+
+	return nil, nil
+}
+
+// Initial value of this resource within a sys container.
+func (h *StringBaseHandler) HostSnapshot(n domain.IOnodeIface) (string, bool) {
+
+	if stringSysctls[n.Path()].namespaced {
+		return "", false
+	}
+
+	val, ok := hostSnapshot(n)
+	if !ok {
+		return "", false
+	}
+
+	return strings.TrimSpace(val), true
+}
+
+func (h *StringBaseHandler) GetName() string {
+	return h.Name
+}
+
+func (h *StringBaseHandler) GetPath() string {
+	return h.Path
+}
+
+func (h *StringBaseHandler) GetEnabled() bool {
+	return getEnabled(&h.Enabled)
+}
+
+func (h *StringBaseHandler) GetType() domain.HandlerType {
+	return h.Type
+}
+
+func (h *StringBaseHandler) GetService() domain.HandlerServiceIface {
+	return h.Service
+}
+
+func (h *StringBaseHandler) SetEnabled(val bool) {
+	setEnabled(&h.Enabled, val)
+}
+
+func (h *StringBaseHandler) SetService(hs domain.HandlerServiceIface) {
+	h.Service = hs
+}
@@ -0,0 +1,101 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package implementations_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/handler/implementations"
+)
+
+func TestStringBaseHandler_Write(t *testing.T) {
+
+	const thpPath = "/sys/kernel/mm/transparent_hugepage/enabled"
+	const qdiscPath = "/proc/sys/net/core/default_qdisc"
+
+	ios.NewIOnode("", thpPath, 0).WriteFile([]byte("always [madvise] never\n"))
+	ios.NewIOnode("", qdiscPath, 0).WriteFile([]byte("fq_codel\n"))
+
+	cntr := css.ContainerCreate(
+		"string",
+		uint32(1001),
+		time.Time{},
+		231072,
+		65535,
+		231072,
+		65535,
+		nil,
+		nil,
+		nil,
+		nil,
+		domain.CgroupPaths{},
+		"",
+		domain.ResourceLimits{})
+
+	tests := []struct {
+		name    string
+		path    string
+		data    string
+		want    string
+		wantErr bool
+	}{
+		// Test-case 1: Selection within a bracketed list.
+		{"1", thpPath, "never\n", "always madvise [never]\n", false},
+
+		// Test-case 2: Value missing from a bracketed list.
+		{"2", thpPath, "sometimes\n", "always madvise [never]\n", true},
+
+		// Test-case 3: Value within a fixed set.
+		{"3", qdiscPath, "sfq\n", "sfq\n", false},
+
+		// Test-case 4: Value missing from a fixed set.
+		{"4", qdiscPath, "htb\n", "sfq\n", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			h := &implementations.StringBaseHandler{
+				Name:    "stringBase",
+				Path:    tt.path,
+				Type:    domain.NODE_SUBSTITUTION,
+				Enabled: true,
+			}
+			n := ios.NewIOnode("", tt.path, 0)
+
+			_, err := h.Write(n, &domain.HandlerRequest{
+				Pid:       1001,
+				Data:      []byte(tt.data),
+				Container: cntr,
+			})
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			buf := make([]byte, 64)
+			sz, err := h.Read(n, &domain.HandlerRequest{Pid: 1001, Data: buf, Container: cntr})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, string(buf[:sz]))
+		})
+	}
+}
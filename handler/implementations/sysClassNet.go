@@ -0,0 +1,263 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package implementations
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/fuse"
+)
+
+//
+// /sys/class/net/<if>/{statistics/*,mtu,operstate,speed} Handler
+//
+// Serves the counters and attributes of the network interfaces visible within
+// the network namespace of the process originating the request, so that
+// monitoring agents (e.g. node-exporter) running within a sys container report
+// the metrics of the container's interfaces. Values are obtained within the
+// network namespace (via nsenter), as the sysfs instance reachable from
+// sysbox-fs reflects the host's interfaces. Interfaces not present in the
+// network namespace are not visible.
+//
+type SysClassNetHandler struct {
+	Name      string
+	Path      string
+	Type      domain.HandlerType
+	Enabled   bool
+	Cacheable bool
+	Service   domain.HandlerServiceIface
+}
+
+func (h *SysClassNetHandler) Lookup(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (os.FileInfo, error) {
+
+	info, err := h.netDevInfo(n, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := netDevValue(info, filepath.Base(n.Path())); !ok {
+		return nil, fuse.IOerror{Code: syscall.ENOENT}
+	}
+
+	return syntheticFileInfo(filepath.Base(n.Path()), 0444), nil
+}
+
+func (h *SysClassNetHandler) Getattr(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (*syscall.Stat_t, error) {
+
+	return nil, nil
+}
+
+func (h *SysClassNetHandler) Open(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) error {
+
+	flags := n.OpenFlags()
+	if flags != syscall.O_RDONLY {
+		return fuse.IOerror{Code: syscall.EACCES}
+	}
+
+	return nil
+}
+
+func (h *SysClassNetHandler) Close(n domain.IOnodeIface) error {
+
+	return nil
+}
+
+func (h *SysClassNetHandler) Read(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	data, err := h.Content(n, req)
+	if err != nil {
+		return 0, err
+	}
+
+	return readContentAt(data, req)
+}
+
+func (h *SysClassNetHandler) Write(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	return 0, fuse.IOerror{Code: syscall.EACCES}
+}
+
+func (h *SysClassNetHandler) ReadDirAll(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) ([]os.FileInfo, error) {
+
+	return nil, nil
+}
+
+// Full content of this resource within the sys container.
+func (h *SysClassNetHandler) Content(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) ([]byte, error) {
+
+	info, err := h.netDevInfo(n, req)
+	if err != nil {
+		return nil, err
+	}
+
+	val, ok := netDevValue(info, filepath.Base(n.Path()))
+	if !ok {
+		return nil, fuse.IOerror{Code: syscall.ENOENT}
+	}
+
+	// As sysfs does, the speed of interfaces down (or not reporting one)
+	// can't be read.
+	if val == "" {
+		return nil, fuse.IOerror{Code: syscall.EINVAL}
+	}
+
+	return []byte(val + "\n"), nil
+}
+
+//
+// Obtains the attributes of the interface the given node belongs to, within
+// the network namespace of the process originating the request.
+//
+func (h *SysClassNetHandler) netDevInfo(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (*domain.NetDevInfo, error) {
+
+	// Ensure operation is generated from within a registered sys container.
+	if req.Container == nil {
+		logger.Errorf("Could not find the container originating this request (pid %v)",
+			req.Pid)
+		return nil, errors.New("Container not found")
+	}
+
+	ifname := netDevName(n.Path())
+	if ifname == "" {
+		return nil, fuse.IOerror{Code: syscall.ENOENT}
+	}
+
+	// Create nsenterEvent to initiate interaction with container namespaces.
+	nss := h.Service.NSenterService()
+	event := nss.NewEvent(
+		req.Pid,
+		&domain.NetNSs,
+		&domain.NSenterMessage{
+			Type: domain.NetDevRequest,
+			Payload: &domain.NetDevPayload{
+				Interface: ifname,
+			},
+		},
+		nil,
+	)
+
+	// Launch nsenter-event.
+	err := nss.SendRequestEvent(req.Context(), event)
+	if err != nil {
+		return nil, err
+	}
+
+	// Obtain nsenter-event response.
+	responseMsg := nss.ReceiveResponseEvent(event)
+	if responseMsg.Type == domain.ErrorResponse {
+		if ioerr, ok := responseMsg.Payload.(fuse.IOerror); ok && ioerr.Code == syscall.ENODEV {
+			return nil, fuse.IOerror{Code: syscall.ENOENT}
+		}
+		return nil, responseMsg.Payload.(error)
+	}
+
+	info := responseMsg.Payload.(domain.NetDevInfo)
+
+	return &info, nil
+}
+
+// Returns the interface name within the given /sys/class/net path.
+func netDevName(path string) string {
+
+	rel := strings.TrimPrefix(path, "/sys/class/net/")
+	if rel == path {
+		return ""
+	}
+
+	return strings.SplitN(rel, "/", 2)[0]
+}
+
+//
+// Returns the value of the given attribute (or statistic) of an interface, as
+// presented by sysfs. An empty value is returned for attributes that are
+// known but can't be read.
+//
+func netDevValue(info *domain.NetDevInfo, attr string) (string, bool) {
+
+	switch attr {
+	case "mtu":
+		return fmt.Sprintf("%d", info.Mtu), true
+	case "operstate":
+		return info.OperState, true
+	case "speed":
+		if info.Speed == nil {
+			return "", true
+		}
+		return fmt.Sprintf("%d", *info.Speed), true
+	}
+
+	if val, ok := info.Stats[attr]; ok {
+		return fmt.Sprintf("%d", val), true
+	}
+
+	return "", false
+}
+
+func (h *SysClassNetHandler) GetName() string {
+	return h.Name
+}
+
+func (h *SysClassNetHandler) GetPath() string {
+	return h.Path
+}
+
+func (h *SysClassNetHandler) GetEnabled() bool {
+	return getEnabled(&h.Enabled)
+}
+
+func (h *SysClassNetHandler) GetType() domain.HandlerType {
+	return h.Type
+}
+
+func (h *SysClassNetHandler) GetService() domain.HandlerServiceIface {
+	return h.Service
+}
+
+func (h *SysClassNetHandler) SetEnabled(val bool) {
+	setEnabled(&h.Enabled, val)
+}
+
+func (h *SysClassNetHandler) SetService(hs domain.HandlerServiceIface) {
+	h.Service = hs
+}
+
+// Read-only resource.
+func (h *SysClassNetHandler) NodeMode() os.FileMode {
+	return 0444
+}
@@ -0,0 +1,146 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package implementations_test
+
+import (
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/fuse"
+	"github.com/nestybox/sysbox-fs/handler/implementations"
+	"github.com/nestybox/sysbox-fs/mocks"
+	"github.com/nestybox/sysbox-fs/nsenter"
+)
+
+func TestSysClassNetHandler_Read(t *testing.T) {
+
+	cntr := css.ContainerCreate(
+		"netdev",
+		uint32(1001),
+		time.Time{},
+		231072,
+		65535,
+		231072,
+		65535,
+		nil,
+		nil,
+		nil,
+		nil,
+		domain.CgroupPaths{},
+		"",
+		domain.ResourceLimits{})
+
+	speed := int64(10000)
+
+	responses := map[string]*domain.NSenterMessage{
+		"eth0": {
+			Type: domain.NetDevResponse,
+			Payload: domain.NetDevInfo{
+				Mtu:       1500,
+				OperState: "up",
+				Speed:     &speed,
+				Stats:     map[string]uint64{"rx_bytes": 4096, "tx_packets": 12},
+			},
+		},
+		"eth1": {
+			Type: domain.NetDevResponse,
+			Payload: domain.NetDevInfo{
+				Mtu:       9000,
+				OperState: "down",
+				Stats:     map[string]uint64{"rx_bytes": 0},
+			},
+		},
+		"eth2": {
+			Type:    domain.ErrorResponse,
+			Payload: fuse.IOerror{Code: syscall.ENODEV},
+		},
+	}
+
+	nss := &mocks.NSenterServiceIface{}
+	for ifname, resp := range responses {
+		event := &nsenter.NSenterEvent{
+			Pid:       1001,
+			Namespace: &domain.NetNSs,
+			ReqMsg: &domain.NSenterMessage{
+				Type:    domain.NetDevRequest,
+				Payload: &domain.NetDevPayload{Interface: ifname},
+			},
+		}
+
+		nss.On(
+			"NewEvent",
+			uint32(1001),
+			&domain.NetNSs,
+			event.ReqMsg,
+			(*domain.NSenterMessage)(nil)).Return(event)
+		nss.On("SendRequestEvent", mock.Anything, event).Return(nil)
+		nss.On("ReceiveResponseEvent", event).Return(resp)
+	}
+
+	hs := &mocks.HandlerServiceIface{}
+	hs.On("NSenterService").Return(nss)
+
+	h := &implementations.SysClassNetHandler{
+		Name:    "sysClassNetStatistics",
+		Path:    "/sys/class/net/*/statistics/*",
+		Type:    domain.NODE_SUBSTITUTION,
+		Enabled: true,
+		Service: hs,
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr error
+	}{
+		// Test-case 1: Statistics of the container's interface.
+		{"1", "/sys/class/net/eth0/statistics/rx_bytes", "4096\n", nil},
+		{"2", "/sys/class/net/eth0/statistics/tx_packets", "12\n", nil},
+
+		// Test-case 3: Attributes.
+		{"3", "/sys/class/net/eth0/mtu", "1500\n", nil},
+		{"4", "/sys/class/net/eth0/operstate", "up\n", nil},
+		{"5", "/sys/class/net/eth0/speed", "10000\n", nil},
+
+		// Test-case 6: Speed of an interface down can't be read.
+		{"6", "/sys/class/net/eth1/speed", "", fuse.IOerror{Code: syscall.EINVAL}},
+
+		// Test-case 7: Interfaces out of the container's netns don't exist.
+		{"7", "/sys/class/net/eth2/mtu", "", fuse.IOerror{Code: syscall.ENOENT}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := ios.NewIOnode("", tt.path, 0)
+
+			buf := make([]byte, 64)
+			sz, err := h.Read(n, &domain.HandlerRequest{Pid: 1001, Data: buf, Container: cntr})
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, string(buf[:sz]))
+		})
+	}
+}
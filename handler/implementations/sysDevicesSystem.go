@@ -0,0 +1,301 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package implementations
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/fuse"
+)
+
+var (
+	sysCpuDirRe  = regexp.MustCompile(`^cpu([0-9]+)$`)
+	sysNodeDirRe = regexp.MustCompile(`^node([0-9]+)$`)
+)
+
+// Kind of the values held by the topology files.
+type topologyValue int
+
+const (
+	topologyOther    topologyValue = iota
+	topologyCpuList                // cpu list (e.g. "0-3,6")
+	topologyCpuMask                // cpu bitmask (e.g. "0000004f")
+	topologyNodeList               // memory-node list (e.g. "0-1")
+)
+
+//
+// /sys/devices/system/cpu and /sys/devices/system/node Handler
+//
+// Synthesizes a topology consistent with the container's cpuset, so that
+// topology-aware applications (e.g. hwloc / libnuma based ones) don't
+// mis-detect the resources available to them: the per-cpu and per-node
+// subdirectories of the cpus and memory nodes the container can't use are
+// hidden, and the cpu lists and masks (online cpus, thread / core siblings,
+// caches' shared cpus, nodes' cpus, etc) only refer to the allowed ones. The
+// remaining attributes (e.g. core_id, cache sizes) are passed through as is.
+//
+type SysDevicesSystemHandler struct {
+	Name      string
+	Path      string
+	Type      domain.HandlerType
+	Enabled   bool
+	Cacheable bool
+	Service   domain.HandlerServiceIface
+}
+
+func (h *SysDevicesSystemHandler) Lookup(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (os.FileInfo, error) {
+
+	if req.Container != nil && !topologyVisible(n.Path(), req.Container) {
+		return nil, fuse.IOerror{Code: syscall.ENOENT}
+	}
+
+	return n.Stat()
+}
+
+func (h *SysDevicesSystemHandler) Getattr(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (*syscall.Stat_t, error) {
+
+	return nil, nil
+}
+
+func (h *SysDevicesSystemHandler) Open(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) error {
+
+	flags := n.OpenFlags()
+	if flags != syscall.O_RDONLY {
+		return fuse.IOerror{Code: syscall.EACCES}
+	}
+
+	return nil
+}
+
+func (h *SysDevicesSystemHandler) Close(n domain.IOnodeIface) error {
+
+	return nil
+}
+
+func (h *SysDevicesSystemHandler) Read(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	data, err := h.Content(n, req)
+	if err != nil {
+		return 0, err
+	}
+
+	return readContentAt(data, req)
+}
+
+func (h *SysDevicesSystemHandler) Write(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	return 0, fuse.IOerror{Code: syscall.EACCES}
+}
+
+func (h *SysDevicesSystemHandler) ReadDirAll(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) ([]os.FileInfo, error) {
+
+	entries, err := n.ReadDirAll()
+	if err != nil || req.Container == nil {
+		return entries, err
+	}
+
+	var res []os.FileInfo
+
+	for _, e := range entries {
+		if topologyVisible(filepath.Join(n.Path(), e.Name()), req.Container) {
+			res = append(res, e)
+		}
+	}
+
+	return res, nil
+}
+
+// Full content of this resource within the sys container.
+func (h *SysDevicesSystemHandler) Content(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) ([]byte, error) {
+
+	cntr := req.Container
+
+	// Ensure operation is generated from within a registered sys container.
+	if cntr == nil {
+		logger.Errorf("Could not find the container originating this request (pid %v)",
+			req.Pid)
+		return nil, errors.New("Container not found")
+	}
+
+	data, err := n.ReadFile()
+	if err != nil {
+		return nil, err
+	}
+
+	val := strings.TrimSpace(string(data))
+
+	switch topologyValueOf(n.Path()) {
+	case topologyCpuList:
+		cpus := intersectCpus(parseCpuList(val), containerCpus(cntr))
+		return []byte(formatCpuList(cpus) + "\n"), nil
+
+	case topologyCpuMask:
+		cpus, nwords := parseCpuMask(val)
+		cpus = intersectCpus(cpus, containerCpus(cntr))
+		return []byte(formatCpuMask(cpus, nwords) + "\n"), nil
+
+	case topologyNodeList:
+		nodes := parseCpuList(val)
+		if mems := containerMems(cntr); mems != nil {
+			nodes = intersectCpus(nodes, mems)
+		}
+		return []byte(formatCpuList(nodes) + "\n"), nil
+	}
+
+	return data, nil
+}
+
+//
+// Reports whether the given topology path is visible within the container,
+// that is, whether the cpus and memory nodes it refers to (if any) are
+// available to it.
+//
+func topologyVisible(path string, cntr domain.ContainerIface) bool {
+
+	for _, e := range pathElements(path) {
+		if m := sysCpuDirRe.FindStringSubmatch(e); m != nil {
+			cpu, _ := strconv.Atoi(m[1])
+			if !containsInt(containerCpus(cntr), cpu) {
+				return false
+			}
+		} else if m := sysNodeDirRe.FindStringSubmatch(e); m != nil {
+			node, _ := strconv.Atoi(m[1])
+			if mems := containerMems(cntr); mems != nil && !containsInt(mems, node) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// Returns the kind of value held by the given topology file.
+func topologyValueOf(path string) topologyValue {
+
+	name := filepath.Base(path)
+	dir := filepath.Base(filepath.Dir(path))
+
+	switch {
+	case strings.HasPrefix(path, "/sys/devices/system/node/"):
+		switch name {
+		case "cpulist":
+			return topologyCpuList
+		case "cpumap":
+			return topologyCpuMask
+		case "online", "possible", "has_cpu", "has_memory",
+			"has_normal_memory", "has_high_memory":
+			if dir == "node" {
+				return topologyNodeList
+			}
+		}
+
+	case dir == "cpu":
+		switch name {
+		case "online", "present", "possible":
+			return topologyCpuList
+		}
+
+	case dir == "topology":
+		switch name {
+		case "thread_siblings", "core_siblings", "core_cpus", "die_cpus",
+			"package_cpus", "cluster_cpus", "book_siblings", "drawer_siblings":
+			return topologyCpuMask
+		}
+		if strings.HasSuffix(name, "_list") {
+			return topologyCpuList
+		}
+
+	case strings.HasPrefix(dir, "index"):
+		switch name {
+		case "shared_cpu_map":
+			return topologyCpuMask
+		case "shared_cpu_list":
+			return topologyCpuList
+		}
+	}
+
+	return topologyOther
+}
+
+// Splits the given path into its elements.
+func pathElements(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
+
+func containsInt(s []int, v int) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (h *SysDevicesSystemHandler) GetName() string {
+	return h.Name
+}
+
+func (h *SysDevicesSystemHandler) GetPath() string {
+	return h.Path
+}
+
+func (h *SysDevicesSystemHandler) GetEnabled() bool {
+	return getEnabled(&h.Enabled)
+}
+
+func (h *SysDevicesSystemHandler) GetType() domain.HandlerType {
+	return h.Type
+}
+
+func (h *SysDevicesSystemHandler) GetService() domain.HandlerServiceIface {
+	return h.Service
+}
+
+func (h *SysDevicesSystemHandler) SetEnabled(val bool) {
+	setEnabled(&h.Enabled, val)
+}
+
+func (h *SysDevicesSystemHandler) SetService(hs domain.HandlerServiceIface) {
+	h.Service = hs
+}
+
+// Read-only resource.
+func (h *SysDevicesSystemHandler) NodeMode() os.FileMode {
+	return 0444
+}
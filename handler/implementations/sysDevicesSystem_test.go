@@ -0,0 +1,139 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package implementations_test
+
+import (
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/fuse"
+	"github.com/nestybox/sysbox-fs/handler/implementations"
+)
+
+func TestSysDevicesSystemHandler(t *testing.T) {
+
+	const cpuDir = "/sys/devices/system/cpu"
+	const nodeDir = "/sys/devices/system/node"
+
+	// Host with two nodes of four cpus each (two cores with two threads
+	// each), and a container restricted to cpus 1 and 4-5 of node 1.
+	hostFiles := map[string]string{
+		cpuDir + "/online":                             "0-7",
+		cpuDir + "/cpu0/topology/core_id":              "0",
+		cpuDir + "/cpu1/topology/core_id":              "0",
+		cpuDir + "/cpu1/topology/thread_siblings":      "00000003",
+		cpuDir + "/cpu1/topology/thread_siblings_list": "0-1",
+		cpuDir + "/cpu4/topology/core_siblings":        "00000000,000000f0",
+		cpuDir + "/cpu4/cache/index2/shared_cpu_list":  "4-7",
+		cpuDir + "/cpu5/topology/core_id":              "0",
+		nodeDir + "/online":                            "0-1",
+		nodeDir + "/has_memory":                        "0-1",
+		nodeDir + "/node0/cpulist":                     "0-3",
+		nodeDir + "/node1/cpulist":                     "4-7",
+		nodeDir + "/node1/cpumap":                      "000000f0",
+		nodeDir + "/node1/meminfo":                     "Node 1 MemTotal: 1024 kB\n",
+	}
+	for path, val := range hostFiles {
+		ios.NewIOnode("", path, 0).WriteFile([]byte(val))
+	}
+
+	cntr := css.ContainerCreate(
+		"topology",
+		uint32(1001),
+		time.Time{},
+		231072,
+		65535,
+		231072,
+		65535,
+		nil,
+		nil,
+		nil,
+		nil,
+		domain.CgroupPaths{},
+		"",
+		domain.ResourceLimits{CpusetCpus: "1,4-5", CpusetMems: "1"})
+
+	h := &implementations.SysDevicesSystemHandler{
+		Name:    "sysDevicesSystem",
+		Path:    "/sys/devices/system/cpu/**",
+		Type:    domain.NODE_SUBSTITUTION,
+		Enabled: true,
+	}
+
+	readTests := []struct {
+		name string
+		path string
+		want string
+	}{
+		// Test-case 1: Online cpus restricted to the container's cpuset.
+		{"1", cpuDir + "/online", "1,4-5\n"},
+
+		// Test-case 2: Sibling masks keep the host's width.
+		{"2", cpuDir + "/cpu1/topology/thread_siblings", "00000002\n"},
+		{"3", cpuDir + "/cpu4/topology/core_siblings", "00000000,00000030\n"},
+
+		// Test-case 4: Sibling and shared-cache lists.
+		{"4", cpuDir + "/cpu1/topology/thread_siblings_list", "1\n"},
+		{"5", cpuDir + "/cpu4/cache/index2/shared_cpu_list", "4-5\n"},
+
+		// Test-case 6: Node cpus and online nodes.
+		{"6", nodeDir + "/node1/cpulist", "4-5\n"},
+		{"7", nodeDir + "/node1/cpumap", "00000030\n"},
+		{"8", nodeDir + "/online", "1\n"},
+		{"9", nodeDir + "/has_memory", "1\n"},
+
+		// Test-case 10: Other attributes are passed through.
+		{"10", cpuDir + "/cpu5/topology/core_id", "0"},
+		{"11", nodeDir + "/node1/meminfo", "Node 1 MemTotal: 1024 kB\n"},
+	}
+
+	for _, tt := range readTests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := ios.NewIOnode("", tt.path, 0)
+
+			buf := make([]byte, 256)
+			sz, err := h.Read(n, &domain.HandlerRequest{Pid: 1001, Data: buf, Container: cntr})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, string(buf[:sz]))
+		})
+	}
+
+	req := &domain.HandlerRequest{Pid: 1001, Container: cntr}
+
+	// Cpus and nodes out of the container's cpuset are hidden.
+	_, err := h.Lookup(ios.NewIOnode("", cpuDir+"/cpu0/topology/core_id", 0), req)
+	assert.Equal(t, fuse.IOerror{Code: syscall.ENOENT}, err)
+
+	_, err = h.Lookup(ios.NewIOnode("", nodeDir+"/node0", 0), req)
+	assert.Equal(t, fuse.IOerror{Code: syscall.ENOENT}, err)
+
+	_, err = h.Lookup(ios.NewIOnode("", cpuDir+"/cpu1/topology/core_id", 0), req)
+	assert.NoError(t, err)
+
+	entries, err := h.ReadDirAll(ios.NewIOnode("", cpuDir, 0), req)
+	assert.NoError(t, err)
+
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	assert.Equal(t, []string{"cpu1", "cpu4", "cpu5", "online"}, names)
+}
@@ -0,0 +1,234 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package implementations
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"syscall"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/fuse"
+)
+
+// Host directory holding the virtual block devices (e.g. loop, dm, zram ones).
+const sysVirtualBlockDir = "/sys/devices/virtual/block"
+
+//
+// /sys/devices/virtual/block Handler
+//
+// Only exposes the virtual block devices that sysbox-mgr declared visible to
+// the container (see domain.MountOptions), so that e.g. loop devices handed
+// over to a container don't come along with the host's (or other containers')
+// ones. The same devices are filtered out of /proc/partitions and
+// /proc/diskstats. Device attributes are read-only.
+//
+type SysDevicesVirtualBlockHandler struct {
+	Name      string
+	Path      string
+	Type      domain.HandlerType
+	Enabled   bool
+	Cacheable bool
+	Service   domain.HandlerServiceIface
+}
+
+func (h *SysDevicesVirtualBlockHandler) Lookup(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (os.FileInfo, error) {
+
+	if dev := virtualBlockDevice(n.Path()); dev != "" && req.Container != nil {
+		if !req.Container.MountOptions().BlockDeviceVisible(dev) {
+			return nil, fuse.IOerror{Code: syscall.ENOENT}
+		}
+	}
+
+	return n.Stat()
+}
+
+func (h *SysDevicesVirtualBlockHandler) Getattr(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (*syscall.Stat_t, error) {
+
+	return nil, nil
+}
+
+func (h *SysDevicesVirtualBlockHandler) Open(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) error {
+
+	flags := n.OpenFlags()
+	if flags != syscall.O_RDONLY {
+		return fuse.IOerror{Code: syscall.EACCES}
+	}
+
+	return nil
+}
+
+func (h *SysDevicesVirtualBlockHandler) Close(n domain.IOnodeIface) error {
+
+	return nil
+}
+
+func (h *SysDevicesVirtualBlockHandler) Read(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	data, err := h.Content(n, req)
+	if err != nil {
+		return 0, err
+	}
+
+	return readContentAt(data, req)
+}
+
+func (h *SysDevicesVirtualBlockHandler) Write(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	return 0, fuse.IOerror{Code: syscall.EACCES}
+}
+
+func (h *SysDevicesVirtualBlockHandler) ReadDirAll(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) ([]os.FileInfo, error) {
+
+	entries, err := n.ReadDirAll()
+	if err != nil || req.Container == nil || n.Path() != sysVirtualBlockDir {
+		return entries, err
+	}
+
+	opts := req.Container.MountOptions()
+
+	var res []os.FileInfo
+
+	for _, e := range entries {
+		if opts.BlockDeviceVisible(e.Name()) {
+			res = append(res, e)
+		}
+	}
+
+	return res, nil
+}
+
+// Full content of this resource within the sys container.
+func (h *SysDevicesVirtualBlockHandler) Content(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) ([]byte, error) {
+
+	// Ensure operation is generated from within a registered sys container.
+	if req.Container == nil {
+		logger.Errorf("Could not find the container originating this request (pid %v)",
+			req.Pid)
+		return nil, errors.New("Container not found")
+	}
+
+	return n.ReadFile()
+}
+
+func (h *SysDevicesVirtualBlockHandler) GetName() string {
+	return h.Name
+}
+
+func (h *SysDevicesVirtualBlockHandler) GetPath() string {
+	return h.Path
+}
+
+func (h *SysDevicesVirtualBlockHandler) GetEnabled() bool {
+	return getEnabled(&h.Enabled)
+}
+
+func (h *SysDevicesVirtualBlockHandler) GetType() domain.HandlerType {
+	return h.Type
+}
+
+func (h *SysDevicesVirtualBlockHandler) GetService() domain.HandlerServiceIface {
+	return h.Service
+}
+
+func (h *SysDevicesVirtualBlockHandler) SetEnabled(val bool) {
+	setEnabled(&h.Enabled, val)
+}
+
+func (h *SysDevicesVirtualBlockHandler) SetService(hs domain.HandlerServiceIface) {
+	h.Service = hs
+}
+
+// Read-only resource.
+func (h *SysDevicesVirtualBlockHandler) NodeMode() os.FileMode {
+	return 0444
+}
+
+// Returns the virtual block device the given path lies within, if any.
+func virtualBlockDevice(path string) string {
+
+	rel := strings.TrimPrefix(path, sysVirtualBlockDir+"/")
+	if rel == path {
+		return ""
+	}
+
+	return strings.SplitN(rel, "/", 2)[0]
+}
+
+//
+// Filters the (host's) block-device statistics in the given buffer (e.g.
+// /proc/partitions), dropping the lines referring to the virtual block devices
+// that aren't visible to the container. The device name is expected at the
+// given (zero-based) column.
+//
+func filterBlockDevices(
+	data []byte,
+	col int,
+	cntr domain.ContainerIface,
+	ios domain.IOServiceIface) []byte {
+
+	opts := cntr.MountOptions()
+	if !opts.RestrictBlockDevices {
+		return data
+	}
+
+	// Virtual devices in the host; physical ones are always visible.
+	entries, err := ios.NewIOnode("", sysVirtualBlockDir, 0).ReadDirAll()
+	if err != nil {
+		logger.Debugf("Could not list virtual block devices: %v", err)
+		return data
+	}
+
+	var buf bytes.Buffer
+
+	for _, line := range strings.SplitAfter(string(data), "\n") {
+		fields := strings.Fields(line)
+
+		if len(fields) > col && !opts.BlockDeviceVisible(fields[col]) {
+			virtual := false
+			for _, e := range entries {
+				if domain.IsBlockDeviceOf(fields[col], e.Name()) {
+					virtual = true
+					break
+				}
+			}
+			if virtual {
+				continue
+			}
+		}
+
+		buf.WriteString(line)
+	}
+
+	return buf.Bytes()
+}
@@ -0,0 +1,139 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package implementations_test
+
+import (
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/fuse"
+	"github.com/nestybox/sysbox-fs/handler/implementations"
+	"github.com/nestybox/sysbox-fs/mocks"
+)
+
+func TestSysDevicesVirtualBlockHandler(t *testing.T) {
+
+	const dir = "/sys/devices/virtual/block"
+
+	hs := &mocks.HandlerServiceIface{}
+	hs.On("IOService").Return(ios)
+
+	// Host with three loop devices (the first one partitioned), and a
+	// container allowed to see loop1 only.
+	for _, path := range []string{
+		dir + "/loop0/size",
+		dir + "/loop0/loop0p1/size",
+		dir + "/loop1/size",
+		dir + "/loop10/size",
+	} {
+		ios.NewIOnode("", path, 0).WriteFile([]byte("2048\n"))
+	}
+
+	ios.NewIOnode("", "/proc/partitions", 0).WriteFile([]byte(
+		"major minor  #blocks  name\n" +
+			"\n" +
+			"   7        0       1024 loop0\n" +
+			" 259        0       1024 loop0p1\n" +
+			"   7        1       1024 loop1\n" +
+			"   7       10       1024 loop10\n" +
+			"   8        0  488386584 sda\n" +
+			"   8        1     524288 sda1\n"))
+
+	cntr := css.ContainerCreate(
+		"blockdev",
+		uint32(1001),
+		time.Time{},
+		231072,
+		65535,
+		231072,
+		65535,
+		nil,
+		nil,
+		nil,
+		nil,
+		domain.CgroupPaths{},
+		"",
+		domain.ResourceLimits{})
+	cntr.SetMountOptions(domain.MountOptions{
+		RestrictBlockDevices: true,
+		BlockDevices:         []string{"loop1"},
+	})
+
+	h := &implementations.SysDevicesVirtualBlockHandler{
+		Name:    "sysDevicesVirtualBlockTree",
+		Path:    dir + "/**",
+		Type:    domain.NODE_SUBSTITUTION,
+		Enabled: true,
+		Service: hs,
+	}
+
+	req := &domain.HandlerRequest{Pid: 1001, Container: cntr}
+
+	// Test-case 1: Only the visible devices are listed.
+	entries, err := h.ReadDirAll(ios.NewIOnode("block", dir, 0), req)
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, "loop1", entries[0].Name())
+	}
+
+	// Test-case 2: Devices not visible can't be looked up (nor their
+	// attributes or partitions).
+	for _, path := range []string{
+		dir + "/loop0",
+		dir + "/loop0/loop0p1/size",
+		dir + "/loop10/size",
+	} {
+		_, err = h.Lookup(ios.NewIOnode("", path, 0), req)
+		assert.Equal(t, fuse.IOerror{Code: syscall.ENOENT}, err, path)
+	}
+
+	_, err = h.Lookup(ios.NewIOnode("size", dir+"/loop1/size", 0), req)
+	assert.NoError(t, err)
+
+	// Test-case 3: Attributes of visible devices are served from the host.
+	buf := make([]byte, 64)
+	sz, err := h.Read(ios.NewIOnode("size", dir+"/loop1/size", 0),
+		&domain.HandlerRequest{Pid: 1001, Data: buf, Container: cntr})
+	assert.NoError(t, err)
+	assert.Equal(t, "2048\n", string(buf[:sz]))
+
+	// Test-case 4: /proc/partitions is consistent with the above; physical
+	// devices are left alone.
+	ph := &implementations.ProcPartitionsHandler{
+		Name:    "procPartitions",
+		Path:    "/proc/partitions",
+		Type:    domain.NODE_SUBSTITUTION | domain.NODE_BINDMOUNT,
+		Enabled: true,
+		Service: hs,
+	}
+
+	buf = make([]byte, 512)
+	sz, err = ph.Read(ios.NewIOnode("partitions", "/proc/partitions", 0),
+		&domain.HandlerRequest{Pid: 1001, Data: buf, Container: cntr})
+	assert.NoError(t, err)
+	assert.Equal(t,
+		"major minor  #blocks  name\n"+
+			"\n"+
+			"   7        1       1024 loop1\n"+
+			"   8        0  488386584 sda\n"+
+			"   8        1     524288 sda1\n",
+		string(buf[:sz]))
+}
@@ -0,0 +1,184 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package implementations
+
+import (
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"syscall"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/fuse"
+)
+
+//
+// /sys/class/dmi/id/product_uuid handler
+//
+// Documentation: UUID of the machine as reported by its firmware (SMBIOS). Tools
+// such as kubeadm rely on it to tell cluster nodes apart (its preflight checks
+// reject duplicated ones), so every sys container is handed its own one.
+//
+// The UUID is derived from the host's one and the container's ID, so that it's
+// unique per container and stable across restarts of the container (unlike
+// boot_id, which is regenerated). It's also kept in the container's data-store,
+// so that it's persisted along with the rest of the container's state.
+//
+type SysDmiProductUuidHandler struct {
+	Name      string
+	Path      string
+	Type      domain.HandlerType
+	Enabled   bool
+	Cacheable bool
+	Service   domain.HandlerServiceIface
+}
+
+func (h *SysDmiProductUuidHandler) Lookup(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (os.FileInfo, error) {
+
+	return n.Stat()
+}
+
+func (h *SysDmiProductUuidHandler) Getattr(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (*syscall.Stat_t, error) {
+
+	return nil, nil
+}
+
+func (h *SysDmiProductUuidHandler) Open(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) error {
+
+	flags := n.OpenFlags()
+	if flags != syscall.O_RDONLY {
+		return fuse.IOerror{Code: syscall.EACCES}
+	}
+
+	return nil
+}
+
+func (h *SysDmiProductUuidHandler) Close(n domain.IOnodeIface) error {
+
+	return nil
+}
+
+func (h *SysDmiProductUuidHandler) Read(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	if req.Offset > 0 {
+		return 0, io.EOF
+	}
+
+	name := n.Name()
+	path := n.Path()
+	cntr := req.Container
+
+	// Ensure operation is generated from within a registered sys container.
+	if cntr == nil {
+		logger.Errorf("Could not find the container originating this request (pid %v)",
+			req.Pid)
+		return 0, errors.New("Container not found")
+	}
+
+	data, ok := cntr.Data(path, name)
+	if !ok || data == "" {
+		// Hosts whose firmware doesn't report a UUID (or that don't
+		// allow us to read it) still get a per-container one.
+		host, err := n.ReadFile()
+		if err != nil {
+			logger.Debugf("Could not read host's product_uuid: %v", err)
+		}
+
+		data = containerProductUuid(strings.TrimSpace(string(host)), cntr.ID())
+		cntr.SetData(path, name, data)
+	}
+
+	data += "\n"
+
+	return copyResultBuffer(req.Data, []byte(data))
+}
+
+func (h *SysDmiProductUuidHandler) Write(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	return 0, fuse.IOerror{Code: syscall.EPERM}
+}
+
+func (h *SysDmiProductUuidHandler) ReadDirAll(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) ([]os.FileInfo, error) {
+
+	return nil, nil
+}
+
+func (h *SysDmiProductUuidHandler) GetName() string {
+	return h.Name
+}
+
+func (h *SysDmiProductUuidHandler) GetPath() string {
+	return h.Path
+}
+
+func (h *SysDmiProductUuidHandler) GetEnabled() bool {
+	return getEnabled(&h.Enabled)
+}
+
+func (h *SysDmiProductUuidHandler) GetType() domain.HandlerType {
+	return h.Type
+}
+
+func (h *SysDmiProductUuidHandler) GetService() domain.HandlerServiceIface {
+	return h.Service
+}
+
+func (h *SysDmiProductUuidHandler) SetEnabled(val bool) {
+	setEnabled(&h.Enabled, val)
+}
+
+func (h *SysDmiProductUuidHandler) SetService(hs domain.HandlerServiceIface) {
+	h.Service = hs
+}
+
+// Readable by root only, as in the host.
+func (h *SysDmiProductUuidHandler) NodeMode() os.FileMode {
+	return 0400
+}
+
+//
+// Returns the product UUID of the given container: a name-based (version 5)
+// UUID out of the host's UUID and the container's ID, formatted as the kernel
+// does.
+//
+func containerProductUuid(hostUuid string, id string) string {
+
+	sum := sha1.Sum([]byte(strings.ToLower(hostUuid) + "/" + id))
+
+	var b [16]byte
+	copy(b[:], sum[:])
+
+	b[6] = (b[6] & 0x0f) | 0x50
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
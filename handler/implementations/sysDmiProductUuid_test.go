@@ -0,0 +1,83 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package implementations_test
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/handler/implementations"
+)
+
+func TestSysDmiProductUuidHandler_Read(t *testing.T) {
+
+	const path = "/sys/class/dmi/id/product_uuid"
+
+	ios.NewIOnode("", path, 0).WriteFile([]byte("4c4c4544-0042-3510-8051-b3c04f4d4e32\n"))
+
+	newCntr := func(id string) domain.ContainerIface {
+		return css.ContainerCreate(
+			id,
+			uint32(1001),
+			time.Time{},
+			231072,
+			65535,
+			231072,
+			65535,
+			nil,
+			nil,
+			nil,
+			nil,
+			domain.CgroupPaths{},
+			"",
+			domain.ResourceLimits{})
+	}
+
+	h := &implementations.SysDmiProductUuidHandler{
+		Name:    "sysDmiProductUuid",
+		Path:    path,
+		Type:    domain.NODE_SUBSTITUTION,
+		Enabled: true,
+	}
+
+	read := func(cntr domain.ContainerIface) string {
+		n := ios.NewIOnode("product_uuid", path, 0)
+		buf := make([]byte, 64)
+		sz, err := h.Read(n, &domain.HandlerRequest{Pid: 1001, Data: buf, Container: cntr})
+		assert.NoError(t, err)
+		return string(buf[:sz])
+	}
+
+	uuidRe := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-5[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}\n$`)
+
+	// Test-case 1: Every container is handed a well-formed UUID of its own,
+	// different from the host's one.
+	u1 := read(newCntr("node1"))
+	u2 := read(newCntr("node2"))
+	assert.Regexp(t, uuidRe, u1)
+	assert.Regexp(t, uuidRe, u2)
+	assert.NotEqual(t, u1, u2)
+	assert.NotEqual(t, "4c4c4544-0042-3510-8051-b3c04f4d4e32\n", u1)
+
+	// Test-case 2: The UUID is preserved across restarts of the container
+	// (i.e. with its state discarded).
+	assert.Equal(t, u1, read(newCntr("node1")))
+}
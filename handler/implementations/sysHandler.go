@@ -21,7 +21,6 @@ import (
 	"syscall"
 
 	"github.com/nestybox/sysbox-fs/domain"
-	"github.com/sirupsen/logrus"
 )
 
 //
@@ -45,8 +44,6 @@ func (h *SysHandler) Lookup(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (os.FileInfo, error) {
 
-	logrus.Debugf("Executing Lookup() method on %v handler", h.Name)
-
 	return n.Stat()
 }
 
@@ -54,8 +51,6 @@ func (h *SysHandler) Getattr(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (*syscall.Stat_t, error) {
 
-	logrus.Debugf("Executing Getattr() method on %v handler", h.Name)
-
 	return nil, nil
 }
 
@@ -63,15 +58,11 @@ func (h *SysHandler) Open(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) error {
 
-	logrus.Debugf("Executing %v Open() method", h.Name)
-
 	return nil
 }
 
 func (h *SysHandler) Close(n domain.IOnodeIface) error {
 
-	logrus.Debugf("Executing Close() method on %v handler", h.Name)
-
 	return nil
 }
 
@@ -79,8 +70,6 @@ func (h *SysHandler) Read(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (int, error) {
 
-	logrus.Debugf("Executing %v Read() method", h.Name)
-
 	return 0, nil
 }
 
@@ -88,8 +77,6 @@ func (h *SysHandler) Write(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (int, error) {
 
-	logrus.Debugf("Executing %v Write() method", h.Name)
-
 	return 0, nil
 }
 
@@ -109,7 +96,7 @@ func (h *SysHandler) GetPath() string {
 }
 
 func (h *SysHandler) GetEnabled() bool {
-	return h.Enabled
+	return getEnabled(&h.Enabled)
 }
 
 func (h *SysHandler) GetType() domain.HandlerType {
@@ -121,7 +108,7 @@ func (h *SysHandler) GetService() domain.HandlerServiceIface {
 }
 
 func (h *SysHandler) SetEnabled(val bool) {
-	h.Enabled = val
+	setEnabled(&h.Enabled, val)
 }
 
 func (h *SysHandler) SetService(hs domain.HandlerServiceIface) {
@@ -0,0 +1,304 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package implementations
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/fuse"
+)
+
+//
+// /sys/kernel/mm/hugepages/hugepages-<size>kB/{nr_hugepages,free_hugepages}
+// handler
+//
+// Documentation: nr_hugepages holds the size of the persistent huge page pool
+// of the given page size, and free_hugepages the number of pages within it
+// that are yet to be allocated.
+//
+// Within a sys container both are bounded by the container's hugetlb cgroup
+// limit for the given page size: free pages are further restricted to the
+// ones not yet charged to the cgroup. Writes into nr_hugepages are stored at
+// sys-container level (IOW, the host FS value will be left untouched), and are
+// shared with /proc/sys/vm/nr_hugepages for the default huge page size.
+//
+
+// Storage of the pool size of the default huge page size.
+const procNrHugepagesPath = "/proc/sys/vm/nr_hugepages"
+
+type SysHugepagesHandler struct {
+	Name      string
+	Path      string
+	Type      domain.HandlerType
+	Enabled   bool
+	Cacheable bool
+	Service   domain.HandlerServiceIface
+}
+
+func (h *SysHugepagesHandler) Lookup(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (os.FileInfo, error) {
+
+	return n.Stat()
+}
+
+func (h *SysHugepagesHandler) Getattr(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (*syscall.Stat_t, error) {
+
+	return nil, nil
+}
+
+func (h *SysHugepagesHandler) Open(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) error {
+
+	flags := n.OpenFlags()
+	if flags != syscall.O_RDONLY && flags != syscall.O_WRONLY {
+		return fuse.IOerror{Code: syscall.EACCES}
+	}
+
+	if flags == syscall.O_WRONLY && filepath.Base(n.Path()) == "free_hugepages" {
+		return fuse.IOerror{Code: syscall.EACCES}
+	}
+
+	return nil
+}
+
+func (h *SysHugepagesHandler) Close(n domain.IOnodeIface) error {
+
+	return nil
+}
+
+func (h *SysHugepagesHandler) Read(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	// We are dealing with a single integer element being read, so we can save
+	// some cycles by returning right away if offset is any higher than zero.
+	if req.Offset > 0 {
+		return 0, io.EOF
+	}
+
+	cntr := req.Container
+
+	// Ensure operation is generated from within a registered sys container.
+	if cntr == nil {
+		logger.Errorf("Could not find the container originating this request (pid %v)",
+			req.Pid)
+		return 0, errors.New("Container not found")
+	}
+
+	kB, err := h.pageSizeKB(n)
+	if err != nil {
+		return 0, fuse.IOerror{Code: syscall.EINVAL}
+	}
+
+	var data string
+
+	if filepath.Base(n.Path()) == "nr_hugepages" {
+		data, err = h.readNrHugepages(n, cntr, kB)
+	} else {
+		data, err = h.readFreeHugepages(n, cntr, kB)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	data += "\n"
+
+	return copyResultBuffer(req.Data, []byte(data))
+}
+
+func (h *SysHugepagesHandler) Write(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	cntr := req.Container
+
+	// Ensure operation is generated from within a registered sys container.
+	if cntr == nil {
+		logger.Errorf("Could not find the container originating this request (pid %v)",
+			req.Pid)
+		return 0, errors.New("Container not found")
+	}
+
+	// free_hugepages is read-only.
+	if filepath.Base(n.Path()) != "nr_hugepages" {
+		return 0, fuse.IOerror{Code: syscall.EACCES}
+	}
+
+	kB, err := h.pageSizeKB(n)
+	if err != nil {
+		return 0, fuse.IOerror{Code: syscall.EINVAL}
+	}
+
+	newVal := strings.TrimSpace(string(req.Data))
+	newValInt, err := strconv.ParseUint(newVal, 10, 64)
+	if err != nil {
+		return 0, fuse.IOerror{Code: syscall.EINVAL}
+	}
+
+	// Page counts can't exceed the container's hugetlb limit.
+	ios := h.Service.IOService()
+	if maxPages, ok := hugetlbMaxPages(ios, cntr, kB); ok && newValInt > maxPages {
+		return 0, fuse.IOerror{Code: syscall.EINVAL}
+	}
+
+	// Store the new value within the container struct.
+	path, name := h.storage(n, kB)
+	cntr.SetData(path, name, newVal)
+
+	return len(req.Data), nil
+}
+
+func (h *SysHugepagesHandler) ReadDirAll(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) ([]os.FileInfo, error) {
+
+	return nil, nil
+}
+
+// Returns the huge page size (kB) of the node, as per the name of the directory
+// holding it (e.g. "hugepages-2048kB").
+func (h *SysHugepagesHandler) pageSizeKB(n domain.IOnodeIface) (uint64, error) {
+
+	dir := filepath.Base(filepath.Dir(n.Path()))
+	size := strings.TrimSuffix(strings.TrimPrefix(dir, "hugepages-"), "kB")
+
+	return strconv.ParseUint(size, 10, 64)
+}
+
+// Returns the container-state entry storing the pool size of the given huge
+// page size (kB).
+func (h *SysHugepagesHandler) storage(
+	n domain.IOnodeIface,
+	kB uint64) (string, string) {
+
+	if kB == hugePageSizeKB(h.Service.IOService()) {
+		return procNrHugepagesPath, filepath.Base(procNrHugepagesPath)
+	}
+
+	return n.Path(), filepath.Base(n.Path())
+}
+
+// Returns the pool size reported to the container: the one written within the
+// container, or otherwise the host one bounded by the container's limit.
+func (h *SysHugepagesHandler) readNrHugepages(
+	n domain.IOnodeIface,
+	cntr domain.ContainerIface,
+	kB uint64) (string, error) {
+
+	path, name := h.storage(n, kB)
+	if data, ok := cntr.Data(path, name); ok {
+		return data, nil
+	}
+
+	pages, err := h.readHostPages(n)
+	if err != nil {
+		return "", err
+	}
+
+	if maxPages, ok := hugetlbMaxPages(h.Service.IOService(), cntr, kB); ok && pages > maxPages {
+		pages = maxPages
+	}
+
+	return strconv.FormatUint(pages, 10), nil
+}
+
+// Returns the free pages reported to the container: the host ones, bounded by
+// the pages the container can still charge to its hugetlb cgroup.
+func (h *SysHugepagesHandler) readFreeHugepages(
+	n domain.IOnodeIface,
+	cntr domain.ContainerIface,
+	kB uint64) (string, error) {
+
+	pages, err := h.readHostPages(n)
+	if err != nil {
+		return "", err
+	}
+
+	ios := h.Service.IOService()
+
+	if limit, ok := hugetlbLimit(ios, cntr, kB); ok {
+		usage, _ := hugetlbUsage(ios, cntr, kB)
+
+		var avail uint64
+		if limit > usage {
+			avail = (limit - usage) / (kB << 10)
+		}
+		if pages > avail {
+			pages = avail
+		}
+	}
+
+	return strconv.FormatUint(pages, 10), nil
+}
+
+func (h *SysHugepagesHandler) readHostPages(n domain.IOnodeIface) (uint64, error) {
+
+	// Read from host FS to extract the existing value.
+	curHostVal, err := n.ReadLine()
+	if err != nil && err != io.EOF {
+		logger.Errorf("Could not read from file %v", n.Path())
+		return 0, fuse.IOerror{Code: syscall.EIO}
+	}
+
+	// High-level verification to ensure that format is the expected one.
+	pages, err := strconv.ParseUint(curHostVal, 10, 64)
+	if err != nil {
+		logger.Errorf("Unsupported content read from file %v, error %v", n.Path(), err)
+		return 0, fuse.IOerror{Code: syscall.EINVAL}
+	}
+
+	return pages, nil
+}
+
+func (h *SysHugepagesHandler) GetName() string {
+	return h.Name
+}
+
+func (h *SysHugepagesHandler) GetPath() string {
+	return h.Path
+}
+
+func (h *SysHugepagesHandler) GetEnabled() bool {
+	return getEnabled(&h.Enabled)
+}
+
+func (h *SysHugepagesHandler) GetType() domain.HandlerType {
+	return h.Type
+}
+
+func (h *SysHugepagesHandler) GetService() domain.HandlerServiceIface {
+	return h.Service
+}
+
+func (h *SysHugepagesHandler) SetEnabled(val bool) {
+	setEnabled(&h.Enabled, val)
+}
+
+func (h *SysHugepagesHandler) SetService(hs domain.HandlerServiceIface) {
+	h.Service = hs
+}
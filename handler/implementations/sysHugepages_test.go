@@ -0,0 +1,94 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package implementations_test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/handler/implementations"
+	"github.com/nestybox/sysbox-fs/mocks"
+)
+
+func TestSysHugepagesHandler_Read(t *testing.T) {
+
+	const dir = "/sys/kernel/mm/hugepages/hugepages-1048576kB"
+	const gb = 1024 * 1024 * 1024
+
+	hs := &mocks.HandlerServiceIface{}
+	hs.On("IOService").Return(ios)
+
+	// Host with 16 1GB pages (10 of them free), and a (cgroup-v2) container
+	// limited to 8 of them, 3 of which are already charged to it.
+	ios.NewIOnode("", dir+"/nr_hugepages", 0).WriteFile([]byte("16"))
+	ios.NewIOnode("", dir+"/free_hugepages", 0).WriteFile([]byte("10"))
+	ios.NewIOnode("", "/sys/fs/cgroup/c2/hugetlb.1GB.max", 0).WriteFile(
+		[]byte(strconv.Itoa(8 * gb)))
+	ios.NewIOnode("", "/sys/fs/cgroup/c2/hugetlb.1GB.current", 0).WriteFile(
+		[]byte(strconv.Itoa(3 * gb)))
+
+	cntr := css.ContainerCreate(
+		"c2",
+		uint32(1001),
+		time.Time{},
+		231072,
+		65535,
+		231072,
+		65535,
+		nil,
+		nil,
+		nil,
+		nil,
+		domain.CgroupPaths{V2: "/c2"},
+		"",
+		domain.ResourceLimits{})
+
+	tests := []struct {
+		name string
+		file string
+		want string
+	}{
+		// Test-case 1: Pool size bounded by the container's limit.
+		{"1", "nr_hugepages", "8\n"},
+
+		// Test-case 2: Free pages bounded by the container's remaining quota.
+		{"2", "free_hugepages", "5\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			h := &implementations.SysHugepagesHandler{
+				Name:    "sysHugepages",
+				Path:    dir + "/" + tt.file,
+				Type:    domain.NODE_SUBSTITUTION,
+				Enabled: true,
+				Service: hs,
+			}
+			n := ios.NewIOnode(tt.file, dir+"/"+tt.file, 0)
+
+			buf := make([]byte, 32)
+			sz, err := h.Read(n, &domain.HandlerRequest{Pid: 1001, Data: buf, Container: cntr})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, string(buf[:sz]))
+		})
+	}
+}
@@ -0,0 +1,129 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package implementations
+
+import (
+	"errors"
+	"os"
+	"syscall"
+
+	"github.com/nestybox/sysbox-fs/domain"
+)
+
+//
+// /sys/kernel/mm/hugepages directory handler
+//
+// Serves both the hugepages directory and its per page-size subdirectories
+// (e.g. hugepages-2048kB), whose nr_hugepages / free_hugepages files are
+// emulated as per the container's hugetlb cgroup (see SysHugepagesHandler).
+// Only the page sizes supported by the host are listed.
+//
+type SysKernelMmHugepagesHandler struct {
+	Name      string
+	Path      string
+	Type      domain.HandlerType
+	Enabled   bool
+	Cacheable bool
+	Service   domain.HandlerServiceIface
+}
+
+func (h *SysKernelMmHugepagesHandler) Lookup(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (os.FileInfo, error) {
+
+	return n.Stat()
+}
+
+func (h *SysKernelMmHugepagesHandler) Getattr(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (*syscall.Stat_t, error) {
+
+	// Ensure operation is generated from within a registered sys container.
+	if req.Container == nil {
+		logger.Errorf("Could not find the container originating this request (pid %v)",
+			req.Pid)
+		return nil, errors.New("Container not found")
+	}
+
+	stat := &syscall.Stat_t{
+		Uid: req.Container.UID(),
+		Gid: req.Container.GID(),
+	}
+
+	return stat, nil
+}
+
+func (h *SysKernelMmHugepagesHandler) Open(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) error {
+
+	return nil
+}
+
+func (h *SysKernelMmHugepagesHandler) Close(node domain.IOnodeIface) error {
+
+	return nil
+}
+
+func (h *SysKernelMmHugepagesHandler) Read(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	return 0, nil
+}
+
+func (h *SysKernelMmHugepagesHandler) Write(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	return 0, nil
+}
+
+func (h *SysKernelMmHugepagesHandler) ReadDirAll(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) ([]os.FileInfo, error) {
+
+	return n.ReadDirAll()
+}
+
+func (h *SysKernelMmHugepagesHandler) GetName() string {
+	return h.Name
+}
+
+func (h *SysKernelMmHugepagesHandler) GetPath() string {
+	return h.Path
+}
+
+func (h *SysKernelMmHugepagesHandler) GetEnabled() bool {
+	return getEnabled(&h.Enabled)
+}
+
+func (h *SysKernelMmHugepagesHandler) GetType() domain.HandlerType {
+	return h.Type
+}
+
+func (h *SysKernelMmHugepagesHandler) GetService() domain.HandlerServiceIface {
+	return h.Service
+}
+
+func (h *SysKernelMmHugepagesHandler) SetEnabled(val bool) {
+	setEnabled(&h.Enabled, val)
+}
+
+func (h *SysKernelMmHugepagesHandler) SetService(hs domain.HandlerServiceIface) {
+	h.Service = hs
+}
@@ -0,0 +1,145 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package implementations
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/fuse"
+)
+
+//
+// /sys/fs/bpf and /sys/kernel/security directory handler
+//
+// Mountpoints of pseudo file-systems (bpffs, securityfs) that can't be mounted
+// within a sys container. These are presented as empty directories owned by
+// the container's root, and the mounts of their file-systems on top of them
+// are faked by the mount-syscall interception logic (see seccomp/mount.go), so
+// that the systemd units that mount them don't fail. Handlers are registered
+// for both the mountpoint and its contents (i.e. "<mountpoint>/**"), so that
+// the host's file-system (e.g. pinned bpf objects) isn't reachable through it.
+//
+type SysStubDirHandler struct {
+	Name      string
+	Path      string
+	Type      domain.HandlerType
+	Enabled   bool
+	Cacheable bool
+	Service   domain.HandlerServiceIface
+}
+
+func (h *SysStubDirHandler) Lookup(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (os.FileInfo, error) {
+
+	// Nothing lives within the stub.
+	if n.Path() != strings.TrimSuffix(h.Path, "/**") {
+		return nil, fuse.IOerror{Code: syscall.ENOENT}
+	}
+
+	info, err := n.Stat()
+	if err != nil || !info.IsDir() {
+		return syntheticFileInfo(filepath.Base(n.Path()), os.ModeDir|0755), nil
+	}
+
+	return info, nil
+}
+
+func (h *SysStubDirHandler) Getattr(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (*syscall.Stat_t, error) {
+
+	// Ensure operation is generated from within a registered sys container.
+	if req.Container == nil {
+		logger.Errorf("Could not find the container originating this request (pid %v)",
+			req.Pid)
+		return nil, errors.New("Container not found")
+	}
+
+	stat := &syscall.Stat_t{
+		Uid: req.Container.UID(),
+		Gid: req.Container.GID(),
+	}
+
+	return stat, nil
+}
+
+func (h *SysStubDirHandler) Open(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) error {
+
+	return nil
+}
+
+func (h *SysStubDirHandler) Close(node domain.IOnodeIface) error {
+
+	return nil
+}
+
+func (h *SysStubDirHandler) Read(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	return 0, nil
+}
+
+func (h *SysStubDirHandler) Write(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	return 0, fuse.IOerror{Code: syscall.EACCES}
+}
+
+func (h *SysStubDirHandler) ReadDirAll(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) ([]os.FileInfo, error) {
+
+	return nil, nil
+}
+
+func (h *SysStubDirHandler) GetName() string {
+	return h.Name
+}
+
+func (h *SysStubDirHandler) GetPath() string {
+	return h.Path
+}
+
+func (h *SysStubDirHandler) GetEnabled() bool {
+	return getEnabled(&h.Enabled)
+}
+
+func (h *SysStubDirHandler) GetType() domain.HandlerType {
+	return h.Type
+}
+
+func (h *SysStubDirHandler) GetService() domain.HandlerServiceIface {
+	return h.Service
+}
+
+func (h *SysStubDirHandler) SetEnabled(val bool) {
+	setEnabled(&h.Enabled, val)
+}
+
+func (h *SysStubDirHandler) SetService(hs domain.HandlerServiceIface) {
+	h.Service = hs
+}
@@ -17,10 +17,18 @@
 package implementations
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/fuse"
 )
 
 // copytResultBuffer function copies the obtained 'result' buffer into the 'I/O'
@@ -45,6 +53,68 @@ func copyResultBuffer(ioBuf []byte, result []byte) (int, error) {
 	return length, nil
 }
 
+// Serves a read request out of the given content, starting at the request's
+// offset (see domain.ContentIface).
+func readContentAt(data []byte, req *domain.HandlerRequest) (int, error) {
+
+	if req.Offset >= int64(len(data)) {
+		return 0, io.EOF
+	}
+
+	return copyResultBuffer(req.Data, data[req.Offset:])
+}
+
+//
+// Serves a read request out of the host file backing the given node, starting
+// at the request's offset. Multi-line files (e.g. /proc/meminfo) are read by
+// the kernel in several chunks, each one at a different offset.
+//
+func readHostFileAt(
+	ios domain.IOServiceIface,
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	if err := ios.OpenNode(n); err != nil {
+		return 0, err
+	}
+	defer ios.CloseNode(n)
+
+	len, err := ios.ReadAtNode(n, req.Data, req.Offset)
+	if err != nil && err != io.EOF {
+		return 0, err
+	}
+
+	return len, nil
+}
+
+// Returns the host value of the resource backing the given node, to be
+// snapshotted upon container registration (see domain.HostSnapshotIface).
+func hostSnapshot(n domain.IOnodeIface) (string, bool) {
+
+	val, err := n.ReadLine()
+	if err != nil && err != io.EOF {
+		return "", false
+	}
+
+	return val, true
+}
+
+// Same as hostSnapshot(), for resources consisting of a single integer.
+func hostIntSnapshot(n domain.IOnodeIface) (string, bool) {
+
+	val, ok := hostSnapshot(n)
+	if !ok {
+		return "", false
+	}
+
+	// High-level verification to ensure that format is the expected one.
+	if _, err := strconv.Atoi(val); err != nil {
+		return "", false
+	}
+
+	return val, true
+}
+
 // EmulatedFilesInfo is a handler aid that finds files within the given
 // directory node that are emulated by sysbox-fs. It returns a map that lists
 // each file's name and it's info.
@@ -63,11 +133,30 @@ func emulatedFilesInfo(hs domain.HandlerServiceIface,
 
 	var emulatedFilesInfo = make(map[string]os.FileInfo)
 
-	// For every emulated resource, invoke its Lookup() handler to obtain
-	// the information required to satisfy this ongoing readDirAll()
-	// instruction.
+	// The Lookup() handlers of the emulated resources stat the host-fs nodes
+	// they emulate, so we obtain the info of all of them in a single batch
+	// rather than instantiating one ionode (and handler call) per resource.
+	var names = make([]string, 0, len(emulatedResources))
+	for _, handlerPath := range emulatedResources {
+		names = append(names, filepath.Base(handlerPath))
+	}
+
+	ios := hs.IOService()
+	infos, err := ios.StatAllNode(ios.NewIOnode("", n.Path(), 0), names)
+	if err == nil {
+		for _, info := range infos {
+			emulatedFilesInfo[info.Name()] = info
+		}
+	}
+
+	// Resources missing from the batch are looked up individually, so that
+	// their handlers get to decide how to proceed.
 	for _, handlerPath := range emulatedResources {
 
+		if _, ok := emulatedFilesInfo[filepath.Base(handlerPath)]; ok {
+			continue
+		}
+
 		// Lookup the associated handler within handler-DB.
 		handler, ok := hs.FindHandler(handlerPath)
 		if !ok {
@@ -75,14 +164,15 @@ func emulatedFilesInfo(hs domain.HandlerServiceIface,
 		}
 
 		// Create temporary ionode to represent handler-path.
-		ios := hs.IOService()
 		newIOnode := ios.NewIOnode("", handlerPath, 0)
 
 		// Handler execution.
 		info, err := handler.Lookup(newIOnode, req)
 		if err != nil {
 			if !hs.IgnoreErrors() {
-				return nil, fmt.Errorf("Lookup for %v failed: %s", handlerPath, err)
+				// The original error is returned to preserve its errno.
+				logger.Debugf("Lookup for %v failed: %s", handlerPath, err)
+				return nil, err
 			} else {
 				return nil, nil
 			}
@@ -93,3 +183,97 @@ func emulatedFilesInfo(hs domain.HandlerServiceIface,
 
 	return emulatedFilesInfo, nil
 }
+
+//
+// Handlers are enabled / disabled at runtime (config reloads) while being
+// served, so their 'Enabled' attribute is accessed under this lock.
+//
+var enabledLock sync.RWMutex
+
+func getEnabled(enabled *bool) bool {
+	enabledLock.RLock()
+	defer enabledLock.RUnlock()
+
+	return *enabled
+}
+
+func setEnabled(enabled *bool, val bool) {
+	enabledLock.Lock()
+	defer enabledLock.Unlock()
+
+	*enabled = val
+}
+
+//
+// Translates the owner of a node stat'ed through nsenter, hence expressed in
+// the container's user-ns, into the host's user-ns. Ids not mapped into the
+// container, which the kernel reports as the overflow id, are deemed to be
+// the host's root ones.
+//
+func hostOwnedFileInfo(c domain.ContainerIface, info domain.FileInfo) domain.FileInfo {
+
+	if info.Fsys == nil {
+		return info
+	}
+
+	stat := *info.Fsys
+	stat.Uid = idToHost(c.UidMappings(), stat.Uid)
+	stat.Gid = idToHost(c.GidMappings(), stat.Gid)
+	info.Fsys = &stat
+
+	return info
+}
+
+func idToHost(mappings []domain.IDMapping, id uint32) uint32 {
+
+	if id == domain.OverflowID {
+		return 0
+	}
+
+	if hostId, ok := domain.IDToHost(mappings, id); ok {
+		return hostId
+	}
+
+	return 0
+}
+
+// Determines if the given error (as returned by the nsenter agent) reports a
+// non-existent resource.
+func isNotExist(err error) bool {
+	var ioerr fuse.IOerror
+	if errors.As(err, &ioerr) {
+		return ioerr.Code == syscall.ENOENT
+	}
+
+	return os.IsNotExist(err)
+}
+
+//
+// Returns the attributes of a node emulated from scratch, for resources that
+// may be present neither in the host FS nor within the container (e.g. sysctls
+// of kernel modules not loaded). Nodes are reported as owned by the host's
+// root, which the fuse layer shifts into the container's root.
+//
+func syntheticFileInfo(name string, mode os.FileMode) os.FileInfo {
+
+	stat := &syscall.Stat_t{
+		Mode:  syscall.S_IFREG | uint32(mode.Perm()),
+		Nlink: 1,
+	}
+	if mode.IsDir() {
+		stat.Mode = syscall.S_IFDIR | uint32(mode.Perm())
+	}
+
+	now := time.Now()
+	stat.Mtim = syscall.NsecToTimespec(now.UnixNano())
+	stat.Ctim = stat.Mtim
+	stat.Atim = stat.Mtim
+
+	return domain.FileInfo{
+		Fname:    name,
+		Fmode:    mode,
+		FmodTime: now,
+		FisDir:   mode.IsDir(),
+		Fsys:     stat,
+	}
+}
@@ -0,0 +1,338 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package implementations
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/fuse"
+)
+
+// This is a base handler for sysctls consisting of a vector of integer values
+// separated by white spaces (e.g. kernel.sem or net.ipv4.tcp_rmem). Written
+// values are validated field by field against the layout of each sysctl (see
+// vectorSysctls). Namespaced sysctls are then accessed (via nsenter) within
+// the namespaces of the process originating the request; the remaining ones
+// are emulated at sys-container level, starting with the host's value.
+
+//
+// Layout of a multi-value sysctl. Each field is validated against the bounds
+// at its same position; vectors with a variable number of fields (one per
+// memory zone, cpu, etc) are described by a single bound that applies to all
+// of them, and must keep the number of fields of the current value.
+//
+type intVector struct {
+	bounds     [][2]int
+	variable   bool
+	namespaced bool
+}
+
+// Layout of the multi-value sysctls served by VectorIntBaseHandler.
+var vectorSysctls = map[string]intVector{
+	// semmsl, semmns, semopm, semmni (see ipc/sem.c).
+	"/proc/sys/kernel/sem": {
+		bounds: [][2]int{
+			{0, math.MaxInt32},
+			{0, math.MaxInt32},
+			{0, math.MaxInt32},
+			{0, 1 << 15},
+		},
+		namespaced: true,
+	},
+	// min, default, max (bytes).
+	"/proc/sys/net/ipv4/tcp_rmem": {
+		bounds: [][2]int{
+			{1, math.MaxInt32},
+			{1, math.MaxInt32},
+			{1, math.MaxInt32},
+		},
+		namespaced: true,
+	},
+	"/proc/sys/net/ipv4/tcp_wmem": {
+		bounds: [][2]int{
+			{1, math.MaxInt32},
+			{1, math.MaxInt32},
+			{1, math.MaxInt32},
+		},
+		namespaced: true,
+	},
+	// One ratio per memory zone.
+	"/proc/sys/vm/lowmem_reserve_ratio": {
+		bounds:   [][2]int{{0, math.MaxInt32}},
+		variable: true,
+	},
+}
+
+//
+// Parses and validates the given multi-value sysctl content as per its layout.
+// The number of fields of the current value (if any) is enforced on vectors
+// with a variable number of fields. Returns the vector in the kernel's
+// (tab-separated) format.
+//
+func parseIntVector(v intVector, data string, cur string) (string, error) {
+
+	fields := strings.Fields(data)
+
+	switch {
+	case v.variable && cur != "":
+		if len(fields) != len(strings.Fields(cur)) {
+			return "", fuse.IOerror{Code: syscall.EINVAL}
+		}
+	case !v.variable:
+		if len(fields) != len(v.bounds) {
+			return "", fuse.IOerror{Code: syscall.EINVAL}
+		}
+	}
+
+	if len(fields) == 0 {
+		return "", fuse.IOerror{Code: syscall.EINVAL}
+	}
+
+	for i, f := range fields {
+		val, err := strconv.Atoi(f)
+		if err != nil {
+			return "", fuse.IOerror{Code: syscall.EINVAL}
+		}
+
+		bounds := v.bounds[0]
+		if !v.variable {
+			bounds = v.bounds[i]
+		}
+
+		if val < bounds[0] || val > bounds[1] {
+			return "", fuse.IOerror{Code: syscall.EINVAL}
+		}
+	}
+
+	return strings.Join(fields, "\t"), nil
+}
+
+type VectorIntBaseHandler struct {
+	Name      string
+	Path      string
+	Type      domain.HandlerType
+	Enabled   bool
+	Cacheable bool
+	Service   domain.HandlerServiceIface
+}
+
+func (h *VectorIntBaseHandler) Lookup(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (os.FileInfo, error) {
+
+	return n.Stat()
+}
+
+func (h *VectorIntBaseHandler) Getattr(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (*syscall.Stat_t, error) {
+
+	return nil, nil
+}
+
+func (h *VectorIntBaseHandler) Open(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) error {
+
+	flags := n.OpenFlags()
+	if flags != syscall.O_RDONLY && flags != syscall.O_WRONLY {
+		return fuse.IOerror{Code: syscall.EACCES}
+	}
+
+	if !vectorSysctls[n.Path()].namespaced {
+		return nil
+	}
+
+	commonHandler, ok := h.Service.FindHandler("commonHandler")
+	if !ok {
+		return fmt.Errorf("No commonHandler found")
+	}
+
+	return commonHandler.Open(n, req)
+}
+
+func (h *VectorIntBaseHandler) Close(n domain.IOnodeIface) error {
+
+	return nil
+}
+
+func (h *VectorIntBaseHandler) Read(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	// We are dealing with a single line element being read, so we can save
+	// some cycles by returning right away if offset is any higher than zero.
+	if req.Offset > 0 {
+		return 0, io.EOF
+	}
+
+	name := n.Name()
+	path := n.Path()
+	cntr := req.Container
+
+	// Ensure operation is generated from within a registered sys container.
+	if cntr == nil {
+		logger.Errorf("Could not find the container originating this request (pid %v)",
+			req.Pid)
+		return 0, errors.New("Container not found")
+	}
+
+	if vectorSysctls[path].namespaced {
+		commonHandler, ok := h.Service.FindHandler("commonHandler")
+		if !ok {
+			return 0, fmt.Errorf("No commonHandler found")
+		}
+
+		return commonHandler.Read(n, req)
+	}
+
+	// Check if this resource has been initialized for this container. Otherwise,
+	// fetch the information from the host FS and store it accordingly within
+	// the container struct.
+	data, ok := cntr.Data(path, name)
+	if !ok {
+		// Read from host FS to extract the existing value.
+		curHostVal, err := n.ReadLine()
+		if err != nil && err != io.EOF {
+			logger.Errorf("Could not read from file %v", h.Path)
+			return 0, fuse.IOerror{Code: syscall.EIO}
+		}
+
+		data = strings.Join(strings.Fields(curHostVal), "\t")
+		cntr.SetData(path, name, data)
+	}
+
+	data += "\n"
+
+	return copyResultBuffer(req.Data, []byte(data))
+}
+
+func (h *VectorIntBaseHandler) Write(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	name := n.Name()
+	path := n.Path()
+	cntr := req.Container
+
+	// Ensure operation is generated from within a registered sys container.
+	if cntr == nil {
+		logger.Errorf("Could not find the container originating this request (pid %v)",
+			req.Pid)
+		return 0, errors.New("Container not found")
+	}
+
+	v, ok := vectorSysctls[path]
+	if !ok {
+		logger.Errorf("Unsupported multi-value sysctl %v", path)
+		return 0, fuse.IOerror{Code: syscall.EINVAL}
+	}
+
+	if v.namespaced {
+		// Ensure that only proper values are allowed as per this resource's
+		// layout.
+		if _, err := parseIntVector(v, string(req.Data), ""); err != nil {
+			return 0, err
+		}
+
+		commonHandler, ok := h.Service.FindHandler("commonHandler")
+		if !ok {
+			return 0, fmt.Errorf("No commonHandler found")
+		}
+
+		return commonHandler.Write(n, req)
+	}
+
+	// The current value is needed to validate vectors of variable length.
+	curVal, ok := cntr.Data(path, name)
+	if !ok {
+		hostVal, err := n.ReadLine()
+		if err != nil && err != io.EOF {
+			logger.Errorf("Could not read from file %v", h.Path)
+			return 0, fuse.IOerror{Code: syscall.EIO}
+		}
+		curVal = hostVal
+	}
+
+	newVal, err := parseIntVector(v, string(req.Data), curVal)
+	if err != nil {
+		return 0, err
+	}
+
+	// Store the new value within the container struct.
+	cntr.SetData(path, name, newVal)
+
+	return len(req.Data), nil
+}
+
+func (h *VectorIntBaseHandler) ReadDirAll(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) ([]os.FileInfo, error) {
+
+	return nil, nil
+}
+
+// Initial value of this resource within a sys container.
+func (h *VectorIntBaseHandler) HostSnapshot(n domain.IOnodeIface) (string, bool) {
+
+	if vectorSysctls[n.Path()].namespaced {
+		return "", false
+	}
+
+	val, ok := hostSnapshot(n)
+	if !ok {
+		return "", false
+	}
+
+	return strings.Join(strings.Fields(val), "\t"), true
+}
+
+func (h *VectorIntBaseHandler) GetName() string {
+	return h.Name
+}
+
+func (h *VectorIntBaseHandler) GetPath() string {
+	return h.Path
+}
+
+func (h *VectorIntBaseHandler) GetEnabled() bool {
+	return getEnabled(&h.Enabled)
+}
+
+func (h *VectorIntBaseHandler) GetType() domain.HandlerType {
+	return h.Type
+}
+
+func (h *VectorIntBaseHandler) GetService() domain.HandlerServiceIface {
+	return h.Service
+}
+
+func (h *VectorIntBaseHandler) SetEnabled(val bool) {
+	setEnabled(&h.Enabled, val)
+}
+
+func (h *VectorIntBaseHandler) SetService(hs domain.HandlerServiceIface) {
+	h.Service = hs
+}
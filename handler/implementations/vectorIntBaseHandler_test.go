@@ -0,0 +1,112 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package implementations_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/handler/implementations"
+)
+
+func TestVectorIntBaseHandler_Write(t *testing.T) {
+
+	const lowmemPath = "/proc/sys/vm/lowmem_reserve_ratio"
+	const semPath = "/proc/sys/kernel/sem"
+
+	// Host with four memory zones.
+	ios.NewIOnode("", lowmemPath, 0).WriteFile([]byte("256\t256\t32\t0\n"))
+
+	cntr := css.ContainerCreate(
+		"vector",
+		uint32(1001),
+		time.Time{},
+		231072,
+		65535,
+		231072,
+		65535,
+		nil,
+		nil,
+		nil,
+		nil,
+		domain.CgroupPaths{},
+		"",
+		domain.ResourceLimits{})
+
+	tests := []struct {
+		name    string
+		path    string
+		data    string
+		wantErr bool
+	}{
+		// Test-case 1: One ratio per zone.
+		{"1", lowmemPath, "128 128 16 0\n", false},
+
+		// Test-case 2: Number of zones doesn't match.
+		{"2", lowmemPath, "128 128\n", true},
+
+		// Test-case 3: Non-numeric field.
+		{"3", lowmemPath, "128 128 foo 0\n", true},
+
+		// Test-case 4: Too few fields for a fixed-size vector.
+		{"4", semPath, "32000 1024000000 500\n", true},
+
+		// Test-case 5: Field out of bounds (semmni).
+		{"5", semPath, "32000 1024000000 500 65536\n", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			h := &implementations.VectorIntBaseHandler{
+				Name:    "vectorInt",
+				Path:    tt.path,
+				Type:    domain.NODE_SUBSTITUTION,
+				Enabled: true,
+			}
+			n := ios.NewIOnode("", tt.path, 0)
+
+			_, err := h.Write(n, &domain.HandlerRequest{
+				Pid:       1001,
+				Data:      []byte(tt.data),
+				Container: cntr,
+			})
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	// Emulated values are reported in the kernel's format.
+	h := &implementations.VectorIntBaseHandler{
+		Name:    "vmLowmemReserveRatio",
+		Path:    lowmemPath,
+		Type:    domain.NODE_SUBSTITUTION,
+		Enabled: true,
+	}
+	n := ios.NewIOnode("", lowmemPath, 0)
+
+	buf := make([]byte, 64)
+	sz, err := h.Read(n, &domain.HandlerRequest{Pid: 1001, Data: buf, Container: cntr})
+	assert.NoError(t, err)
+	assert.Equal(t, "128\t128\t16\t0\n", string(buf[:sz]))
+}
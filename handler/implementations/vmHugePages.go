@@ -0,0 +1,232 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package implementations
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/fuse"
+)
+
+//
+// /proc/sys/vm/{nr_hugepages,nr_overcommit_hugepages,hugetlb_shm_group} handler
+//
+// Documentation: nr_hugepages and nr_overcommit_hugepages respectively define
+// the size of the persistent huge page pool, and the number of surplus huge
+// pages that can be allocated beyond it, for the default huge page size.
+// hugetlb_shm_group holds the group id allowed to create SysV shared memory
+// segments backed by huge pages.
+//
+// Hugepage setup scripts (e.g. DPDK, databases) check and adjust these upon
+// start-up. The page counts reported within a sys container are bounded by the
+// container's hugetlb cgroup limit for the default page size, and so are the
+// values written into them.
+//
+// Note: As these are system-wide attributes, changes will be only made
+// superficially (at sys-container level). IOW, the host FS value will be left
+// untouched.
+//
+
+type VmHugePagesHandler struct {
+	Name      string
+	Path      string
+	Type      domain.HandlerType
+	Enabled   bool
+	Cacheable bool
+	Service   domain.HandlerServiceIface
+}
+
+func (h *VmHugePagesHandler) Lookup(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (os.FileInfo, error) {
+
+	return n.Stat()
+}
+
+func (h *VmHugePagesHandler) Getattr(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (*syscall.Stat_t, error) {
+
+	return nil, nil
+}
+
+func (h *VmHugePagesHandler) Open(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) error {
+
+	flags := n.OpenFlags()
+	if flags != syscall.O_RDONLY && flags != syscall.O_WRONLY {
+		return fuse.IOerror{Code: syscall.EACCES}
+	}
+
+	if err := n.Open(); err != nil {
+		logger.Debugf("Error opening file %v", h.Path)
+		return fuse.IOerror{Code: syscall.EIO}
+	}
+
+	return nil
+}
+
+func (h *VmHugePagesHandler) Close(n domain.IOnodeIface) error {
+
+	if err := n.Close(); err != nil {
+		logger.Debugf("Error closing file %v", h.Path)
+		return fuse.IOerror{Code: syscall.EIO}
+	}
+
+	return nil
+}
+
+func (h *VmHugePagesHandler) Read(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	// We are dealing with a single integer element being read, so we can save
+	// some cycles by returning right away if offset is any higher than zero.
+	if req.Offset > 0 {
+		return 0, io.EOF
+	}
+
+	name := n.Name()
+	path := n.Path()
+	cntr := req.Container
+
+	// Ensure operation is generated from within a registered sys container.
+	if cntr == nil {
+		logger.Errorf("Could not find the container originating this request (pid %v)",
+			req.Pid)
+		return 0, errors.New("Container not found")
+	}
+
+	// Values written within the container prevail. Otherwise, the host value
+	// is reported, bounded by the container's hugetlb limit. The latter isn't
+	// stored, as limits can be updated along the container's life-cycle.
+	data, ok := cntr.Data(path, name)
+	if !ok {
+		// Read from host FS to extract the existing value.
+		curHostVal, err := n.ReadLine()
+		if err != nil && err != io.EOF {
+			logger.Errorf("Could not read from file %v", h.Path)
+			return 0, fuse.IOerror{Code: syscall.EIO}
+		}
+
+		// High-level verification to ensure that format is the expected one.
+		curHostValInt, err := strconv.ParseUint(curHostVal, 10, 64)
+		if err != nil {
+			logger.Errorf("Unsupported content read from file %v, error %v", h.Path, err)
+			return 0, fuse.IOerror{Code: syscall.EINVAL}
+		}
+
+		data = curHostVal
+
+		if maxPages, ok := h.maxPages(name, cntr); ok && curHostValInt > maxPages {
+			data = strconv.FormatUint(maxPages, 10)
+		}
+	}
+
+	data += "\n"
+
+	return copyResultBuffer(req.Data, []byte(data))
+}
+
+func (h *VmHugePagesHandler) Write(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) (int, error) {
+
+	name := n.Name()
+	path := n.Path()
+	cntr := req.Container
+
+	// Ensure operation is generated from within a registered sys container.
+	if cntr == nil {
+		logger.Errorf("Could not find the container originating this request (pid %v)",
+			req.Pid)
+		return 0, errors.New("Container not found")
+	}
+
+	newVal := strings.TrimSpace(string(req.Data))
+	newValInt, err := strconv.ParseUint(newVal, 10, 64)
+	if err != nil {
+		return 0, fuse.IOerror{Code: syscall.EINVAL}
+	}
+
+	// Page counts can't exceed the container's hugetlb limit.
+	if maxPages, ok := h.maxPages(name, cntr); ok && newValInt > maxPages {
+		return 0, fuse.IOerror{Code: syscall.EINVAL}
+	}
+
+	// Store the new value within the container struct.
+	cntr.SetData(path, name, newVal)
+
+	return len(req.Data), nil
+}
+
+func (h *VmHugePagesHandler) ReadDirAll(
+	n domain.IOnodeIface,
+	req *domain.HandlerRequest) ([]os.FileInfo, error) {
+
+	return nil, nil
+}
+
+// Returns the number of default-sized huge pages the container is limited to,
+// for the resources holding page counts.
+func (h *VmHugePagesHandler) maxPages(
+	name string,
+	cntr domain.ContainerIface) (uint64, bool) {
+
+	if name == "hugetlb_shm_group" {
+		return 0, false
+	}
+
+	ios := h.Service.IOService()
+
+	return hugetlbMaxPages(ios, cntr, hugePageSizeKB(ios))
+}
+
+func (h *VmHugePagesHandler) GetName() string {
+	return h.Name
+}
+
+func (h *VmHugePagesHandler) GetPath() string {
+	return h.Path
+}
+
+func (h *VmHugePagesHandler) GetEnabled() bool {
+	return getEnabled(&h.Enabled)
+}
+
+func (h *VmHugePagesHandler) GetType() domain.HandlerType {
+	return h.Type
+}
+
+func (h *VmHugePagesHandler) GetService() domain.HandlerServiceIface {
+	return h.Service
+}
+
+func (h *VmHugePagesHandler) SetEnabled(val bool) {
+	setEnabled(&h.Enabled, val)
+}
+
+func (h *VmHugePagesHandler) SetService(hs domain.HandlerServiceIface) {
+	h.Service = hs
+}
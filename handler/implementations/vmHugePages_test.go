@@ -0,0 +1,101 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package implementations_test
+
+import (
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/fuse"
+	"github.com/nestybox/sysbox-fs/handler/implementations"
+	"github.com/nestybox/sysbox-fs/mocks"
+)
+
+func TestVmHugePagesHandler(t *testing.T) {
+
+	const path = "/proc/sys/vm/nr_hugepages"
+
+	hs := &mocks.HandlerServiceIface{}
+	hs.On("IOService").Return(ios)
+
+	h := &implementations.VmHugePagesHandler{
+		Name:      "vmNrHugepages",
+		Path:      path,
+		Type:      domain.NODE_SUBSTITUTION,
+		Enabled:   true,
+		Cacheable: true,
+		Service:   hs,
+	}
+
+	// Host with 1024 huge pages of 2MB, and a container limited to 100 of
+	// them.
+	ios.NewIOnode("meminfo", "/proc/meminfo", 0).WriteFile(
+		[]byte("MemTotal:       16318480 kB\nHugepagesize:       2048 kB\n"))
+	ios.NewIOnode("", "/sys/fs/cgroup/hugetlb/c1/hugetlb.2MB.limit_in_bytes", 0).WriteFile(
+		[]byte(strconv.Itoa(100 * 2048 * 1024)))
+
+	n := ios.NewIOnode("nr_hugepages", path, 0)
+	n.WriteFile([]byte("1024"))
+
+	cntr := css.ContainerCreate(
+		"c1",
+		uint32(1001),
+		time.Time{},
+		231072,
+		65535,
+		231072,
+		65535,
+		nil,
+		nil,
+		nil,
+		nil,
+		domain.CgroupPaths{V1: map[string]string{"hugetlb": "/c1"}},
+		"",
+		domain.ResourceLimits{})
+
+	read := func() string {
+		buf := make([]byte, 32)
+		sz, err := h.Read(n, &domain.HandlerRequest{Pid: 1001, Data: buf, Container: cntr})
+		assert.NoError(t, err)
+		return string(buf[:sz])
+	}
+	write := func(val string) error {
+		_, err := h.Write(n, &domain.HandlerRequest{Pid: 1001, Data: []byte(val), Container: cntr})
+		return err
+	}
+
+	// Test-case 1: Host value bounded by the container's hugetlb limit.
+	assert.Equal(t, "100\n", read())
+
+	// Test-case 2: Values within the limit are stored per container.
+	assert.NoError(t, write("64\n"))
+	assert.Equal(t, "64\n", read())
+	val, _ := n.ReadLine()
+	assert.Equal(t, "1024", val)
+
+	// Test-case 3: Values beyond the limit are rejected.
+	assert.Equal(t, fuse.IOerror{Code: syscall.EINVAL}, write("101"))
+	assert.Equal(t, "64\n", read())
+
+	// Test-case 4: Negative values are rejected.
+	assert.Equal(t, fuse.IOerror{Code: syscall.EINVAL}, write("-1"))
+}
@@ -24,8 +24,6 @@ import (
 	"strings"
 	"syscall"
 
-	"github.com/sirupsen/logrus"
-
 	"github.com/nestybox/sysbox-fs/domain"
 	"github.com/nestybox/sysbox-fs/fuse"
 )
@@ -51,8 +49,6 @@ func (h *VsExpireNoDestConnHandler) Lookup(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (os.FileInfo, error) {
 
-	logrus.Debugf("Executing Lookup() method on %v handler", h.Name)
-
 	return n.Stat()
 }
 
@@ -60,8 +56,6 @@ func (h *VsExpireNoDestConnHandler) Getattr(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (*syscall.Stat_t, error) {
 
-	logrus.Debugf("Executing Getattr() method on %v handler", h.Name)
-
 	return nil, nil
 }
 
@@ -69,8 +63,6 @@ func (h *VsExpireNoDestConnHandler) Open(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) error {
 
-	logrus.Debugf("Executing %v Open() method\n", h.Name)
-
 	flags := n.OpenFlags()
 	if flags != syscall.O_RDONLY && flags != syscall.O_WRONLY {
 		return fuse.IOerror{Code: syscall.EACCES}
@@ -84,7 +76,7 @@ func (h *VsExpireNoDestConnHandler) Open(
 	}
 
 	if err := n.Open(); err != nil {
-		logrus.Debugf("Error opening file %v", h.Path)
+		logger.Debugf("Error opening file %v", h.Path)
 		return fuse.IOerror{Code: syscall.EIO}
 	}
 
@@ -93,10 +85,8 @@ func (h *VsExpireNoDestConnHandler) Open(
 
 func (h *VsExpireNoDestConnHandler) Close(n domain.IOnodeIface) error {
 
-	logrus.Debugf("Executing Close() method on %v handler", h.Name)
-
 	if err := n.Close(); err != nil {
-		logrus.Debugf("Error closing file %v", h.Path)
+		logger.Debugf("Error closing file %v", h.Path)
 		return fuse.IOerror{Code: syscall.EIO}
 	}
 
@@ -107,8 +97,6 @@ func (h *VsExpireNoDestConnHandler) Read(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (int, error) {
 
-	logrus.Debugf("Executing %v Read() method", h.Name)
-
 	// We are dealing with a single boolean element being read, so we can save
 	// some cycles by returning right away if offset is any higher than zero.
 	if req.Offset > 0 {
@@ -121,7 +109,7 @@ func (h *VsExpireNoDestConnHandler) Read(
 
 	// Ensure operation is generated from within a registered sys container.
 	if cntr == nil {
-		logrus.Errorf("Could not find the container originating this request (pid %v)",
+		logger.Errorf("Could not find the container originating this request (pid %v)",
 			req.Pid)
 		return 0, errors.New("Container not found")
 	}
@@ -150,15 +138,13 @@ func (h *VsExpireNoDestConnHandler) Write(
 	n domain.IOnodeIface,
 	req *domain.HandlerRequest) (int, error) {
 
-	logrus.Debugf("Executing %v Write() method", h.Name)
-
 	name := n.Name()
 	path := n.Path()
 	cntr := req.Container
 
 	// Ensure operation is generated from within a registered sys container.
 	if cntr == nil {
-		logrus.Errorf("Could not find the container originating this request (pid %v)",
+		logger.Errorf("Could not find the container originating this request (pid %v)",
 			req.Pid)
 		return 0, errors.New("Container not found")
 	}
@@ -166,7 +152,7 @@ func (h *VsExpireNoDestConnHandler) Write(
 	newVal := strings.TrimSpace(string(req.Data))
 	newValInt, err := strconv.Atoi(newVal)
 	if err != nil {
-		logrus.Errorf("Unexpected error: %v", err)
+		logger.Errorf("Unexpected error: %v", err)
 		return 0, err
 	}
 
@@ -191,14 +177,14 @@ func (h *VsExpireNoDestConnHandler) fetchFile(
 	// Read from kernel to extract the existing expire_nodest_conn value.
 	curHostVal, err := n.ReadLine()
 	if err != nil && err != io.EOF {
-		logrus.Errorf("Could not read from file %v", h.Path)
+		logger.Errorf("Could not read from file %v", h.Path)
 		return "", err
 	}
 
 	// High-level verification to ensure that format is the expected one.
 	_, err = strconv.Atoi(curHostVal)
 	if err != nil {
-		logrus.Errorf("Unexpected content read from file %v, error %v", h.Path, err)
+		logger.Errorf("Unexpected content read from file %v, error %v", h.Path, err)
 		return "", err
 	}
 
@@ -213,7 +199,7 @@ func (h *VsExpireNoDestConnHandler) pushFile(
 	msg := []byte(strconv.Itoa(newValInt))
 	err := n.WriteFile(msg)
 	if err != nil {
-		logrus.Errorf("Could not write to file: %v", err)
+		logger.Errorf("Could not write to file: %v", err)
 		return err
 	}
 
@@ -229,7 +215,7 @@ func (h *VsExpireNoDestConnHandler) GetPath() string {
 }
 
 func (h *VsExpireNoDestConnHandler) GetEnabled() bool {
-	return h.Enabled
+	return getEnabled(&h.Enabled)
 }
 
 func (h *VsExpireNoDestConnHandler) GetType() domain.HandlerType {
@@ -241,7 +227,7 @@ func (h *VsExpireNoDestConnHandler) GetService() domain.HandlerServiceIface {
 }
 
 func (h *VsExpireNoDestConnHandler) SetEnabled(val bool) {
-	h.Enabled = val
+	setEnabled(&h.Enabled, val)
 }
 
 func (h *VsExpireNoDestConnHandler) SetService(hs domain.HandlerServiceIface) {
@@ -0,0 +1,292 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package handler
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/nestybox/sysbox-fs/audit"
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/fuse"
+	"github.com/nestybox/sysbox-fs/logging"
+	"github.com/nestybox/sysbox-fs/tracing"
+)
+
+// Max size of the values recorded in the audit trail.
+const auditMaxValue = 4096
+
+//
+// Appends the given middlewares to the chain wrapped around the handlers'
+// execution. Middlewares are executed in the order in which they are added,
+// the first one being the outermost.
+//
+func (hs *handlerService) Use(mws ...domain.HandlerMiddleware) {
+	hs.Lock()
+	defer hs.Unlock()
+
+	hs.middlewares = append(hs.middlewares, mws...)
+
+	chain := domain.HandlerFunc(callHandler)
+	for i := len(hs.middlewares) - 1; i >= 0; i-- {
+		chain = hs.middlewares[i](chain)
+	}
+	hs.chain = chain
+}
+
+// Executes the given handler call through the middleware chain.
+func (hs *handlerService) Invoke(c *domain.HandlerCall) error {
+	hs.RLock()
+	chain := hs.chain
+	hs.RUnlock()
+
+	if chain == nil {
+		return callHandler(c)
+	}
+
+	return chain(c)
+}
+
+// Hands the given call over to its handler (innermost link of the chain).
+func callHandler(c *domain.HandlerCall) error {
+
+	var err error
+
+	switch c.Op {
+	case domain.FuseOpLookup:
+		c.Info, err = c.Handler.Lookup(c.Node, c.Req)
+	case domain.FuseOpOpen:
+		err = c.Handler.Open(c.Node, c.Req)
+	case domain.FuseOpRead:
+		c.N, err = c.Handler.Read(c.Node, c.Req)
+	case domain.FuseOpWrite:
+		c.N, err = c.Handler.Write(c.Node, c.Req)
+	case domain.FuseOpReadDir:
+		c.Entries, err = c.Handler.ReadDirAll(c.Node, c.Req)
+	default:
+		err = fmt.Errorf("Unsupported %v operation on %v handler",
+			c.Op, c.Handler.GetName())
+	}
+
+	return err
+}
+
+//
+// Accounts the call within the container originating it, and emits a
+// structured debug entry describing its outcome, so that requests can be
+// correlated and aggregated per container, handler and operation. Calls
+// exceeding the slow-operation threshold are logged as warnings. The call is
+// also traced as a child span of the fuse request.
+//
+func trackingMiddleware(next domain.HandlerFunc) domain.HandlerFunc {
+
+	return func(c *domain.HandlerCall) error {
+
+		req := c.Req
+		root := tracing.FromContext(req.Context())
+
+		ctx, span := tracing.Start(req.Context(), "handler."+c.Handler.GetName())
+		req.Ctx = ctx
+
+		start := time.Now()
+
+		err := next(c)
+
+		failed := err != nil && err != io.EOF
+
+		if failed {
+			span.SetError(err)
+			root.SetError(err)
+		}
+		span.End()
+
+		if req.Container != nil {
+			req.Container.AccountOp(c.Op, failed)
+		}
+
+		latency := time.Since(start)
+		slow := logging.IsSlow(latency)
+
+		if !slow && !logger.IsLevelEnabled(logrus.DebugLevel) {
+			return err
+		}
+
+		fields := logrus.Fields{
+			logging.FieldOp:      c.Op.String(),
+			logging.FieldPath:    c.Node.Path(),
+			logging.FieldPid:     req.Pid,
+			logging.FieldHandler: c.Handler.GetName(),
+			logging.FieldLatency: latency.String(),
+		}
+		if req.Container != nil {
+			fields[logging.FieldContainerID] = req.Container.ID()
+		}
+
+		entry := logger.WithFields(fields)
+		if failed {
+			entry = entry.WithError(err)
+		}
+
+		switch {
+		case slow:
+			entry.Warn("Slow request")
+		case failed:
+			entry.Debug("Request failed")
+		default:
+			entry.Debug("Request completed")
+		}
+
+		return err
+	}
+}
+
+// Logs the execution of every handler call.
+func loggingMiddleware(next domain.HandlerFunc) domain.HandlerFunc {
+
+	return func(c *domain.HandlerCall) error {
+
+		logger.Debugf("Executing %v operation on %v handler for %v (Req ID=%#x)",
+			c.Op, c.Handler.GetName(), c.Node.Path(), c.Req.ID)
+
+		return next(c)
+	}
+}
+
+//
+// Rejects the writes of values that don't honor the constraints of the
+// resource: writers must hold the capabilities that the kernel would demand
+// (EPERM otherwise), and values must honor the bounds sysbox-mgr defined for
+// the resource, if any (EINVAL otherwise, much like the kernel's sysctls do
+// with out-of-range values).
+//
+func (hs *handlerService) validationMiddleware(next domain.HandlerFunc) domain.HandlerFunc {
+
+	return func(c *domain.HandlerCall) error {
+
+		if c.Op != domain.FuseOpWrite {
+			return next(c)
+		}
+
+		if !hs.WriteAllowed(c.Handler, c.Req) {
+			return fuse.IOerror{Code: syscall.EPERM}
+		}
+
+		if cntr := c.Req.Container; cntr != nil {
+			if p, ok := cntr.Policy(c.Node.Path()); ok {
+				if err := p.Check(string(c.Req.Data)); err != nil {
+					logger.Debugf("Write on %v rejected by policy: %v",
+						c.Node.Path(), err)
+					return fuse.IOerror{Code: syscall.EINVAL}
+				}
+			}
+		}
+
+		return next(c)
+	}
+}
+
+//
+// Returns a middleware recording the writes carried out by the handlers into
+// the given audit trail, along with the values they replace.
+//
+func AuditMiddleware(log *audit.Log) domain.HandlerMiddleware {
+
+	return func(next domain.HandlerFunc) domain.HandlerFunc {
+
+		return func(c *domain.HandlerCall) error {
+
+			if c.Op != domain.FuseOpWrite {
+				return next(c)
+			}
+
+			oldVal := auditRead(c)
+
+			err := next(c)
+
+			auditWrite(log, c, oldVal, err)
+
+			return err
+		}
+	}
+}
+
+//
+// Obtains the value of the resource prior to a write call, for auditing
+// purposes. Errors are reported as part of the value, as the write itself may
+// still succeed.
+//
+func auditRead(c *domain.HandlerCall) string {
+
+	wreq := c.Req
+
+	// The node being written may be open for writing only, so the resource is
+	// read through a node of its own, opened for reading.
+	ios := c.Handler.GetService().IOService()
+	ionode := ios.NewIOnode(c.Node.Name(), c.Node.Path(), 0)
+	ionode.SetOpenFlags(syscall.O_RDONLY)
+
+	request := &domain.HandlerRequest{
+		ID:        wreq.ID,
+		Pid:       wreq.Pid,
+		Uid:       wreq.Uid,
+		Gid:       wreq.Gid,
+		Data:      make([]byte, auditMaxValue),
+		Container: wreq.Container,
+		Ctx:       wreq.Ctx,
+	}
+
+	if err := c.Handler.Open(ionode, request); err != nil {
+		return fmt.Sprintf("<unknown: %v>", err)
+	}
+	defer c.Handler.Close(ionode)
+
+	n, err := c.Handler.Read(ionode, request)
+	if err != nil && err != io.EOF {
+		return fmt.Sprintf("<unknown: %v>", err)
+	}
+
+	return strings.TrimSpace(string(request.Data[:n]))
+}
+
+func auditWrite(log *audit.Log, c *domain.HandlerCall, oldVal string, err error) {
+
+	req := c.Req
+
+	e := &audit.Entry{
+		Pid:      req.Pid,
+		Uid:      req.Uid,
+		Path:     c.Node.Path(),
+		Handler:  c.Handler.GetName(),
+		OldValue: oldVal,
+		NewValue: strings.TrimSpace(string(req.Data)),
+	}
+	if req.Container != nil {
+		e.ContainerID = req.Container.ID()
+	}
+	if err != nil && err != io.EOF {
+		e.Error = err.Error()
+	}
+
+	if err := log.Record(e); err != nil {
+		logger.Errorf("Unable to record audit entry for %v: %v", c.Node.Path(), err)
+	}
+}
@@ -0,0 +1,179 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package handler
+
+import (
+	"errors"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/fuse"
+	"github.com/nestybox/sysbox-fs/mocks"
+)
+
+func Test_handlerService_Use(t *testing.T) {
+
+	var trace []string
+
+	mw := func(name string) domain.HandlerMiddleware {
+		return func(next domain.HandlerFunc) domain.HandlerFunc {
+			return func(c *domain.HandlerCall) error {
+				trace = append(trace, name)
+				return next(c)
+			}
+		}
+	}
+
+	const path = "/sys/module/nf_conntrack/parameters/hashsize"
+
+	h := &mocks.HandlerIface{}
+	h.On("GetPath").Return(path)
+
+	n := &mocks.IOnodeIface{}
+	n.On("Path").Return(path)
+
+	hs := NewHandlerService().(*handlerService)
+	hs.Use(mw("outer"), mw("middle"))
+	hs.Use(mw("inner"))
+
+	req := &domain.HandlerRequest{Data: []byte("1024")}
+	h.On("Write", n, req).Return(4, nil)
+
+	call := &domain.HandlerCall{Op: domain.FuseOpWrite, Handler: h, Node: n, Req: req}
+	assert.NoError(t, hs.Invoke(call))
+	assert.Equal(t, 4, call.N)
+	assert.Equal(t, []string{"outer", "middle", "inner"}, trace)
+}
+
+func Test_handlerService_validationMiddleware(t *testing.T) {
+
+	const path = "/sys/module/nf_conntrack/parameters/hashsize"
+
+	min, max := int64(1024), int64(65536)
+
+	cntr := &mocks.ContainerIface{}
+	cntr.On("Policy", path).Return(domain.ValuePolicy{Min: &min, Max: &max}, true)
+
+	h := &mocks.HandlerIface{}
+	h.On("GetPath").Return(path)
+
+	n := &mocks.IOnodeIface{}
+	n.On("Path").Return(path)
+
+	hs := NewHandlerService().(*handlerService)
+	hs.Use(hs.validationMiddleware)
+
+	tests := []struct {
+		name string
+		op   domain.FuseOp
+		data string
+		err  error
+	}{
+		// Test-case 1: Value within the policy bounds.
+		{"1", domain.FuseOpWrite, "4096", nil},
+
+		// Test-case 2: Value beyond the policy bounds.
+		{"2", domain.FuseOpWrite, "131072", fuse.IOerror{Code: syscall.EINVAL}},
+
+		// Test-case 3: Reads are let through.
+		{"3", domain.FuseOpRead, "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &domain.HandlerRequest{Data: []byte(tt.data), Container: cntr}
+			h.On("Write", n, req).Return(len(tt.data), nil)
+			h.On("Read", n, req).Return(0, nil)
+
+			call := &domain.HandlerCall{Op: tt.op, Handler: h, Node: n, Req: req}
+			assert.Equal(t, tt.err, hs.Invoke(call))
+		})
+	}
+}
+
+func Test_auditRead(t *testing.T) {
+
+	const path = "/proc/sys/net/core/somaxconn"
+
+	hs := &mocks.HandlerServiceIface{}
+
+	n := &mocks.IOnodeIface{}
+	n.On("Name").Return("somaxconn")
+	n.On("Path").Return(path)
+	n.On("OpenFlags").Return(syscall.O_WRONLY)
+
+	readOnly := mock.MatchedBy(func(i domain.IOnodeIface) bool {
+		return i.Path() == path && i.OpenFlags() == syscall.O_RDONLY
+	})
+
+	tests := []struct {
+		name    string
+		openErr error
+		want    string
+	}{
+		// Test-case 1: Value read through a read-only node.
+		{"1", nil, "4096"},
+
+		// Test-case 2: Resources that can't be open for reading.
+		{"2", errors.New("EACCES"), "<unknown: EACCES>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ios := &mocks.IOServiceIface{}
+			ios.On("NewIOnode", "somaxconn", path, mock.Anything).Return(
+				&testIOnode{IOnodeIface: n})
+			hs.ExpectedCalls = nil
+			hs.On("IOService").Return(ios)
+
+			h := &mocks.HandlerIface{}
+			h.On("GetService").Return(hs)
+			h.On("Open", readOnly, mock.Anything).Return(tt.openErr)
+			if tt.openErr == nil {
+				h.On("Read", readOnly, mock.Anything).Run(func(args mock.Arguments) {
+					req := args.Get(1).(*domain.HandlerRequest)
+					copy(req.Data, "4096\n")
+				}).Return(5, nil)
+				h.On("Close", readOnly).Return(nil)
+			}
+
+			req := &domain.HandlerRequest{Data: []byte("8192")}
+			call := &domain.HandlerCall{Op: domain.FuseOpWrite, Handler: h, Node: n, Req: req}
+
+			assert.Equal(t, tt.want, auditRead(call))
+			h.AssertExpectations(t)
+		})
+	}
+}
+
+// IOnode keeping track of its own open flags.
+type testIOnode struct {
+	domain.IOnodeIface
+	flags int
+}
+
+func (i *testIOnode) OpenFlags() int {
+	return i.flags
+}
+
+func (i *testIOnode) SetOpenFlags(flags int) {
+	i.flags = flags
+}
@@ -0,0 +1,145 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package handler
+
+import (
+	"strings"
+
+	"github.com/nestybox/sysbox-fs/domain"
+)
+
+// Wildcards supported within handler paths, so that a single handler can serve
+// interface-indexed or per-entry dynamic subtrees.
+const (
+	// Matches any single path element (e.g.
+	// "/proc/sys/net/ipv4/conf/*/forwarding").
+	wildcardElem = "*"
+
+	// Matches one or more trailing path elements, making the handler a prefix
+	// one (e.g. "/proc/sys/net/ipv4/neigh/**").
+	wildcardTree = "**"
+)
+
+// Reports whether the given handler path holds any wildcard.
+func isPathPattern(path string) bool {
+	for _, e := range pathElems(path) {
+		if e == wildcardElem || e == wildcardTree {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Splits the given path into its elements ("/" has none).
+func pathElems(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+
+	return strings.Split(path, "/")
+}
+
+//
+// Trie of handlers indexed by path element. Lookups return the most specific
+// handler matching the path: at every level, literal elements take precedence
+// over '*' wildcards, and these over '**' ones, which amounts to a
+// longest-prefix match among prefix handlers.
+//
+type pathTrie struct {
+	children map[string]*pathTrie
+	handler  domain.HandlerIface
+}
+
+func newPathTrie() *pathTrie {
+	return &pathTrie{}
+}
+
+// Inserts the handler serving the given path (pattern).
+func (t *pathTrie) insert(path string, h domain.HandlerIface) {
+
+	node := t
+	for _, e := range pathElems(path) {
+		if node.children == nil {
+			node.children = make(map[string]*pathTrie)
+		}
+		child, ok := node.children[e]
+		if !ok {
+			child = &pathTrie{}
+			node.children[e] = child
+		}
+		node = child
+	}
+
+	node.handler = h
+}
+
+// Removes the handler serving the given path (pattern), pruning the branches
+// left empty.
+func (t *pathTrie) remove(path string) {
+	t.removeElems(pathElems(path))
+}
+
+func (t *pathTrie) removeElems(elems []string) bool {
+
+	if len(elems) == 0 {
+		t.handler = nil
+	} else if child, ok := t.children[elems[0]]; ok {
+		if child.removeElems(elems[1:]) {
+			delete(t.children, elems[0])
+		}
+	}
+
+	return t.handler == nil && len(t.children) == 0
+}
+
+// Returns the most specific handler matching the given path.
+func (t *pathTrie) lookup(path string) (domain.HandlerIface, bool) {
+
+	h := t.match(pathElems(path))
+	if h == nil {
+		return nil, false
+	}
+
+	return h, true
+}
+
+func (t *pathTrie) match(elems []string) domain.HandlerIface {
+
+	if len(elems) == 0 {
+		return t.handler
+	}
+
+	if child, ok := t.children[elems[0]]; ok {
+		if h := child.match(elems[1:]); h != nil {
+			return h
+		}
+	}
+
+	if child, ok := t.children[wildcardElem]; ok {
+		if h := child.match(elems[1:]); h != nil {
+			return h
+		}
+	}
+
+	if child, ok := t.children[wildcardTree]; ok {
+		return child.handler
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package handler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nestybox/sysbox-fs/handler/implementations"
+)
+
+func Test_pathTrie_lookup(t *testing.T) {
+
+	handlers := []string{
+		"/",
+		"/proc/sys/net/ipv4/conf/*/forwarding",
+		"/proc/sys/net/ipv4/conf/all/forwarding",
+		"/proc/sys/net/ipv4/neigh/**",
+		"/proc/sys/net/ipv4/neigh/default/**",
+		"/proc/sys/net/ipv4/neigh/default/gc_thresh1",
+	}
+
+	trie := newPathTrie()
+	for _, p := range handlers {
+		trie.insert(p, &implementations.CommonHandler{Name: p, Path: p})
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		// Test-case 1: Root path.
+		{"1", "/", "/"},
+
+		// Test-case 2: Single-element wildcard.
+		{"2", "/proc/sys/net/ipv4/conf/eth0/forwarding", "/proc/sys/net/ipv4/conf/*/forwarding"},
+
+		// Test-case 3: Literal elements take precedence over wildcards.
+		{"3", "/proc/sys/net/ipv4/conf/all/forwarding", "/proc/sys/net/ipv4/conf/all/forwarding"},
+
+		// Test-case 4: Single-element wildcards don't match subtrees.
+		{"4", "/proc/sys/net/ipv4/conf/eth0/foo/forwarding", ""},
+
+		// Test-case 5: Prefix handler.
+		{"5", "/proc/sys/net/ipv4/neigh/eth0/gc_stale_time", "/proc/sys/net/ipv4/neigh/**"},
+
+		// Test-case 6: Longest prefix wins.
+		{"6", "/proc/sys/net/ipv4/neigh/default/gc_thresh2", "/proc/sys/net/ipv4/neigh/default/**"},
+
+		// Test-case 7: Exact match.
+		{"7", "/proc/sys/net/ipv4/neigh/default/gc_thresh1", "/proc/sys/net/ipv4/neigh/default/gc_thresh1"},
+
+		// Test-case 8: Prefix handlers don't match their own root.
+		{"8", "/proc/sys/net/ipv4/neigh", ""},
+
+		// Test-case 9: No handler.
+		{"9", "/proc/sys/kernel/panic", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, ok := trie.lookup(tt.path)
+			assert.Equal(t, tt.want != "", ok)
+			if ok {
+				assert.Equal(t, tt.want, h.GetPath())
+			}
+		})
+	}
+
+	// Removed handlers are no longer matched, while the remaining ones along
+	// the same branch are.
+	trie.remove("/proc/sys/net/ipv4/neigh/default/**")
+
+	h, ok := trie.lookup("/proc/sys/net/ipv4/neigh/default/gc_thresh2")
+	assert.True(t, ok)
+	assert.Equal(t, "/proc/sys/net/ipv4/neigh/**", h.GetPath())
+
+	h, ok = trie.lookup("/proc/sys/net/ipv4/neigh/default/gc_thresh1")
+	assert.True(t, ok)
+	assert.Equal(t, "/proc/sys/net/ipv4/neigh/default/gc_thresh1", h.GetPath())
+}
@@ -17,62 +17,254 @@
 package ipc
 
 import (
-	"github.com/sirupsen/logrus"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/nestybox/sysbox-fs/crash"
 	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/logging"
 	grpc "github.com/nestybox/sysbox-ipc/sysboxFsGrpc"
 	grpcCodes "google.golang.org/grpc/codes"
 	grpcStatus "google.golang.org/grpc/status"
 )
 
+// Logger of the ipc subsystem.
+var logger = logging.Subsystem(logging.Ipc)
+
+//
+// Version of the ipc protocol spoken by sysbox-fs. Peers (sysbox-runc,
+// sysbox-mgr) announce their own version through a handshake message, and
+// those within the [minProtoVersion, ProtoVersion] range are accepted. Bump
+// ProtoVersion whenever the semantics of the existing messages change, and
+// minProtoVersion whenever backwards compatibility is broken.
+//
+const (
+	ProtoVersion    uint32 = 1
+	minProtoVersion uint32 = 1
+)
+
+// Location of the ipc socket when none is explicitly set; must match the one
+// sysbox-ipc listens on by default.
+const DefaultSocketPath = "/run/sysbox/sysfs.sock"
+
+// Location of sysbox-mgr's ipc socket, to which the restart requests of sys
+// containers are forwarded.
+const DefaultMgrSocketPath = "/run/sysbox/sysmgr.sock"
+
+// Sends ipc messages to sysbox peers; swapped by unit-tests.
+var sendMessage = grpc.SendMessage
+
+// Time allowed for the fuse-servers to answer health-check probes.
+const healthCheckTimeout = 5 * time.Second
+
 type ipcService struct {
 	grpcServer *grpc.Server
+	auth       *peerAuth
+	socketPath string // ipc socket location (sysbox-ipc's default if empty)
+	mgrSocket  string // sysbox-mgr's socket location (default if empty)
 	css        domain.ContainerStateServiceIface
 	prs        domain.ProcessServiceIface
 	ios        domain.IOServiceIface
+	fss        domain.FuseServerServiceIface
+	hds        domain.HandlerServiceIface
 }
 
 func NewIpcService() domain.IpcServiceIface {
-	return &ipcService{}
+	return &ipcService{
+		auth: newPeerAuth(),
+	}
 }
 
 func (ips *ipcService) Setup(
 	css domain.ContainerStateServiceIface,
 	prs domain.ProcessServiceIface,
-	ios domain.IOServiceIface) {
+	ios domain.IOServiceIface,
+	fss domain.FuseServerServiceIface,
+	hds domain.HandlerServiceIface) {
 
 	ips.css = css
 	ips.prs = prs
 	ips.ios = ios
+	ips.fss = fss
+	ips.hds = hds
 
-	// Instantiate a grpcServer for inter-process communication.
-	ips.grpcServer = grpc.NewServer(
+	// Instantiate a grpcServer for inter-process communication. Peers are
+	// authenticated during connection establishment (see auth.go).
+	ips.grpcServer = grpc.NewServerWithCreds(
 		ips,
 		&grpc.CallbacksMap{
-			grpc.ContainerPreRegisterMessage: ContainerPreRegister,
-			grpc.ContainerRegisterMessage:    ContainerRegister,
-			grpc.ContainerUnregisterMessage:  ContainerUnregister,
-			grpc.ContainerUpdateMessage:      ContainerUpdate,
+			grpc.ContainerPreRegisterMessage: recoverable(ContainerPreRegister),
+			grpc.ContainerRegisterMessage:    recoverable(ContainerRegister),
+			grpc.ContainerUnregisterMessage:  recoverable(ContainerUnregister),
+			grpc.ContainerUpdateMessage:      recoverable(ContainerUpdate),
+			grpc.ContainerStateExportMessage: recoverable(ContainerStateExport),
+			grpc.ContainerStateImportMessage: recoverable(ContainerStateImport),
+			grpc.ContainerQueryMessage:       recoverable(ContainerQuery),
+			grpc.HealthMessage:               recoverable(Health),
+			grpc.HandshakeMessage:            recoverable(Handshake),
+			grpc.ContainerListMessage:        recoverable(ContainerList),
+			grpc.ContainerInspectMessage:     recoverable(ContainerInspect),
+			grpc.ContainerOverrideMessage:    recoverable(ContainerOverride),
+			grpc.DrainMessage:                recoverable(Drain),
+			grpc.UndrainMessage:              recoverable(Undrain),
+			grpc.HandlerListMessage:          recoverable(HandlerList),
+			grpc.ContainerSetMessage:         recoverable(ContainerSet),
+			grpc.CacheInvalidateMessage:      recoverable(CacheInvalidate),
+			grpc.ContainerPolicyMessage:      recoverable(ContainerPolicy),
 		},
+		ips.auth,
 	)
 }
 
+// Shields the ipc server from panics within the given callback.
+func recoverable(
+	cb func(ctx interface{}, data *grpc.ContainerData) error,
+) func(ctx interface{}, data *grpc.ContainerData) error {
+
+	return func(ctx interface{}, data *grpc.ContainerData) error {
+		defer crash.Recover("ipc callback")
+		return cb(ctx, data)
+	}
+}
+
+//
+// Defines the peers allowed to connect to sysbox-fs' ipc socket: uids, and
+// optionally, executables (e.g. sysbox-runc, sysbox-mgr). Must be invoked
+// prior to Init().
+//
+func (ips *ipcService) SetAuthorizedPeers(uids []uint32, exes []string) {
+	ips.auth.setPeers(uids, exes)
+}
+
+//
+// Overrides the location of the ipc socket. Must be invoked prior to Init().
+//
+func (ips *ipcService) SetSocketPath(path string) {
+	ips.socketPath = path
+}
+
+//
+// Returns the location of the ipc socket.
+//
+func (ips *ipcService) SocketPath() string {
+	if ips.socketPath != "" {
+		return ips.socketPath
+	}
+	return DefaultSocketPath
+}
+
+//
+// Returns the location of sysbox-mgr's ipc socket.
+//
+func (ips *ipcService) MgrSocketPath() string {
+	if ips.mgrSocket != "" {
+		return ips.mgrSocket
+	}
+	return DefaultMgrSocketPath
+}
+
+//
+// Forwards the restart requests of sys containers (i.e. reboot(2) issued from
+// their init's pid namespace) to sysbox-mgr, which is in charge of bringing
+// them back up. Notifications are sent asynchronously, as events are delivered
+// from within the seccomp-notification path.
+//
+func (ips *ipcService) HandleContainerEvent(e domain.ContainerEvent) {
+
+	if e.Type != domain.ContainerRebootEvent || e.Container == nil {
+		return
+	}
+
+	go ips.notifyReboot(e.Container.ID())
+}
+
+func (ips *ipcService) notifyReboot(id string) {
+
+	defer crash.Recover("ipc reboot notification")
+
+	data := &grpc.ContainerData{
+		Id:              id,
+		RebootRequested: true,
+	}
+
+	_, err := sendMessage(ips.MgrSocketPath(), grpc.ContainerRebootMessage, data)
+	if err != nil {
+		logger.Warnf("Unable to notify reboot of container %s: %v", id, err)
+		return
+	}
+
+	logger.Debugf("Reboot of container %s notified to sysbox-mgr", id)
+}
+
 func (ips *ipcService) Init() error {
+	if ips.socketPath != "" {
+		return ips.grpcServer.InitAt(ips.socketPath)
+	}
 	return ips.grpcServer.Init()
 }
 
+func Handshake(ctx interface{}, data *grpc.ContainerData) error {
+
+	logger.Debugf("Handshake message received (peer protocol version: %d)",
+		data.ProtoVersion)
+
+	if err := checkProtoVersion(data.ProtoVersion); err != nil {
+		return err
+	}
+
+	// Hand our own version back to the peer.
+	data.ProtoVersion = ProtoVersion
+
+	return nil
+}
+
+// checkProtoVersion verifies that the protocol version announced by an ipc
+// peer is one that sysbox-fs can serve.
+func checkProtoVersion(v uint32) error {
+
+	if v < minProtoVersion || v > ProtoVersion {
+		logger.Errorf("Unsupported ipc protocol version %d (supported: %d-%d)",
+			v, minProtoVersion, ProtoVersion)
+
+		return grpcStatus.Errorf(
+			grpcCodes.FailedPrecondition,
+			"sysbox-fs ipc protocol version mismatch: peer version %d, "+
+				"supported versions %d-%d; make sure sysbox components are "+
+				"running the same release",
+			v, minProtoVersion, ProtoVersion,
+		)
+	}
+
+	return nil
+}
+
 func ContainerPreRegister(ctx interface{}, data *grpc.ContainerData) error {
 
-	logrus.Infof("Container pre-registration message received for id: %s", data.Id)
+	logger.Infof("Container pre-registration message received for id: %s", data.Id)
+
+	// Peers that went through the handshake also tag their registration
+	// requests; older ones (version zero) are let through for compatibility.
+	if data.ProtoVersion != 0 {
+		if err := checkProtoVersion(data.ProtoVersion); err != nil {
+			return err
+		}
+	}
 
 	ipcService := ctx.(*ipcService)
 
-	err := ipcService.css.ContainerPreRegister(data.Id)
+	// Container metadata (if provided) is handed over ahead of the
+	// registration, so that sysbox-fs can warm up the container state while
+	// the container's init process is being created.
+	cntr := ipcService.containerCreate(data)
+
+	err := ipcService.css.ContainerPreRegister(data.Id, cntr)
 	if err != nil {
 		return err
 	}
 
-	logrus.Infof("Container pre-registration successfully completed for id: %s",
+	logger.Infof("Container pre-registration successfully completed for id: %s",
 		data.Id)
 
 	return nil
@@ -80,30 +272,20 @@ func ContainerPreRegister(ctx interface{}, data *grpc.ContainerData) error {
 
 func ContainerRegister(ctx interface{}, data *grpc.ContainerData) error {
 
-	logrus.Infof("Container registration message received for id: %s", data.Id)
+	logger.Infof("Container registration message received for id: %s", data.Id)
 
 	ipcService := ctx.(*ipcService)
 
 	// Create temporary container struct to be passed as reference to containerDB,
 	// where the matching (real) container will be identified and then updated.
-	cntr := ipcService.css.ContainerCreate(
-		data.Id,
-		uint32(data.InitPid),
-		data.Ctime,
-		uint32(data.UidFirst),
-		uint32(data.UidSize),
-		uint32(data.GidFirst),
-		uint32(data.GidSize),
-		data.ProcRoPaths,
-		data.ProcMaskPaths,
-	)
+	cntr := ipcService.containerCreate(data)
 
 	err := ipcService.css.ContainerRegister(cntr)
 	if err != nil {
 		return err
 	}
 
-	logrus.Infof("Container registration successfully completed for id: %s",
+	logger.Infof("Container registration successfully completed for id: %s",
 		data.Id)
 
 	return nil
@@ -111,7 +293,7 @@ func ContainerRegister(ctx interface{}, data *grpc.ContainerData) error {
 
 func ContainerUnregister(ctx interface{}, data *grpc.ContainerData) error {
 
-	logrus.Infof("Container unregistration message received for id: %s", data.Id)
+	logger.Infof("Container unregistration message received for id: %s", data.Id)
 
 	ipcService := ctx.(*ipcService)
 
@@ -130,21 +312,77 @@ func ContainerUnregister(ctx interface{}, data *grpc.ContainerData) error {
 		return err
 	}
 
-	logrus.Infof("Container unregistration successfully completed for id: %s",
+	// Let the peer know if the container is going away due to a reboot
+	// requested from within it, so that it can be restarted.
+	data.RebootRequested = cntr.RebootRequested()
+
+	logger.Infof("Container unregistration successfully completed for id: %s",
 		data.Id)
 
 	return nil
 }
 
+//
+// Unregisters all the containers at once and rejects further registrations
+// until an undrain request arrives. Sent by sysbox-mgr during host shutdowns
+// and sysbox upgrades.
+//
+func Drain(ctx interface{}, data *grpc.ContainerData) error {
+
+	logger.Infof("Drain message received")
+
+	ipcService := ctx.(*ipcService)
+
+	n, err := ipcService.css.ContainerDBDrain()
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("Drain successfully completed (%d containers unregistered)", n)
+
+	return nil
+}
+
+//
+// Re-enables the container registrations rejected since the last drain request
+// (e.g. once an aborted sysbox upgrade is rolled back).
+//
+func Undrain(ctx interface{}, data *grpc.ContainerData) error {
+
+	logger.Infof("Undrain message received")
+
+	ipcService := ctx.(*ipcService)
+
+	ipcService.css.ContainerDBUndrain()
+
+	return nil
+}
+
 func ContainerUpdate(ctx interface{}, data *grpc.ContainerData) error {
 
-	logrus.Infof("Container update message received for id: %s", data.Id)
+	logger.Infof("Container update message received for id: %s", data.Id)
 
 	ipcService := ctx.(*ipcService)
 
 	// Create temporary container struct to be passed as reference to containerDB,
 	// where the matching (real) container will be identified and then updated.
-	cntr := ipcService.css.ContainerCreate(
+	cntr := ipcService.containerCreate(data)
+
+	err := ipcService.css.ContainerUpdate(cntr)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("Container update successfully processed for id: %s", data.Id)
+
+	return nil
+}
+
+// Helper function to build a temporary container struct out of the metadata
+// received through the ipc channel.
+func (ips *ipcService) containerCreate(data *grpc.ContainerData) domain.ContainerIface {
+
+	cntr := ips.css.ContainerCreate(
 		data.Id,
 		uint32(data.InitPid),
 		data.Ctime,
@@ -154,14 +392,510 @@ func ContainerUpdate(ctx interface{}, data *grpc.ContainerData) error {
 		uint32(data.GidSize),
 		data.ProcRoPaths,
 		data.ProcMaskPaths,
+		idMappings(data.UidMappings, data.UidMappingsSet),
+		idMappings(data.GidMappings, data.GidMappingsSet),
+		cgroupPathsFromData(data),
+		data.Hostname,
+		domain.ResourceLimits{
+			CpusetCpus:   data.CpusetCpus,
+			CpusetMems:   data.CpusetMems,
+			CpuQuota:     data.CpuQuota,
+			CpuPeriod:    data.CpuPeriod,
+			CpuShares:    data.CpuShares,
+			MemLimit:     data.MemLimit,
+			MemSwapLimit: data.MemSwapLimit,
+		},
 	)
 
-	err := ipcService.css.ContainerUpdate(cntr)
+	// Mount options of the container's emulated view (enforced by its fuse
+	// server).
+	cntr.SetMountOptions(domain.MountOptions{
+		ReadOnly:             data.MountReadOnly,
+		HiddenPaths:          data.MountHiddenPaths,
+		RestrictBlockDevices: data.MountRestrictBlockDevices,
+		BlockDevices:         data.MountBlockDevices,
+	})
+
+	return cntr
+}
+
+//
+// Converts the uid/gid mappings received from sysbox-mgr into sysbox-fs'
+// internal representation. As empty lists aren't distinguishable from absent
+// ones in the wire format, peers flag the mappings they convey ('set'); these
+// are returned as a non-nil slice even if empty, so that they replace the
+// existing ones, whereas absent mappings (nil) are left untouched.
+//
+func idMappings(m []grpc.IDMapping, set bool) []domain.IDMapping {
+
+	if len(m) == 0 {
+		if set {
+			return []domain.IDMapping{}
+		}
+		return nil
+	}
+
+	res := make([]domain.IDMapping, len(m))
+	for i, e := range m {
+		res[i] = domain.IDMapping{
+			ContainerID: e.ContainerID,
+			HostID:      e.HostID,
+			Size:        e.Size,
+		}
+	}
+
+	return res
+}
+
+// Extracts the cgroup paths received from sysbox-mgr. Paths flagged as
+// conveyed are returned with a non-nil v1 map, even if empty (e.g. cgroup-v2
+// hosts), so that they replace the existing ones (see idMappings()).
+func cgroupPathsFromData(data *grpc.ContainerData) domain.CgroupPaths {
+
+	paths := domain.CgroupPaths{
+		V1: data.CgroupV1Paths,
+		V2: data.CgroupV2Path,
+	}
+
+	if data.CgroupPathsSet && paths.V1 == nil {
+		paths.V1 = map[string]string{}
+	}
+
+	return paths
+}
+
+// Converts sysbox-fs' internal uid/gid mappings into their ipc representation.
+func grpcIdMappings(m []domain.IDMapping) []grpc.IDMapping {
+
+	if len(m) == 0 {
+		return nil
+	}
+
+	res := make([]grpc.IDMapping, len(m))
+	for i, e := range m {
+		res[i] = grpc.IDMapping{
+			ContainerID: e.ContainerID,
+			HostID:      e.HostID,
+			Size:        e.Size,
+		}
+	}
+
+	return res
+}
+
+func ContainerQuery(ctx interface{}, data *grpc.ContainerData) error {
+
+	logger.Debugf("Container query message received for id: %s", data.Id)
+
+	ipcService := ctx.(*ipcService)
+
+	cntr := ipcService.css.ContainerLookupById(data.Id)
+	if cntr == nil {
+		return grpcStatus.Errorf(
+			grpcCodes.NotFound,
+			"Container %s not found",
+			data.Id,
+		)
+	}
+
+	containerDataFill(cntr, data)
+
+	return nil
+}
+
+func ContainerList(ctx interface{}, data *grpc.ContainerData) error {
+
+	logger.Debugf("Container list message received")
+
+	ipcService := ctx.(*ipcService)
+
+	cntrs := ipcService.css.ContainerList()
+
+	data.ContainerIds = make([]string, len(cntrs))
+	data.OpStats = make([]grpc.ContainerOpStats, len(cntrs))
+	for i, c := range cntrs {
+		data.ContainerIds[i] = c.ID()
+		data.OpStats[i] = grpcOpStats(c)
+	}
+
+	// Busiest containers (top talkers) go first in the op-stats report.
+	sort.SliceStable(data.OpStats, func(i, j int) bool {
+		return opsTotal(data.OpStats[i]) > opsTotal(data.OpStats[j])
+	})
+
+	return nil
+}
+
+func ContainerInspect(ctx interface{}, data *grpc.ContainerData) error {
+
+	logger.Debugf("Container inspect message received for id: %s", data.Id)
+
+	ipcService := ctx.(*ipcService)
+
+	cntr := ipcService.css.ContainerLookupById(data.Id)
+	if cntr == nil {
+		return grpcStatus.Errorf(
+			grpcCodes.NotFound,
+			"Container %s not found",
+			data.Id,
+		)
+	}
+
+	// Emulated state (e.g. sysctl values) is dumped in the same format
+	// utilized by state-export requests.
+	state, err := ipcService.css.ContainerStateExport(data.Id)
 	if err != nil {
 		return err
 	}
 
-	logrus.Infof("Container update successfully processed for id: %s", data.Id)
+	containerDataFill(cntr, data)
+	data.State = state
+
+	return nil
+}
+
+//
+// Sets the overrides of the emulated resources of a given container (e.g.
+// forced kernel.osrelease value, hidden /proc/kallsyms). The received set fully
+// replaces the existing one, so an empty set clears all overrides.
+//
+func ContainerOverride(ctx interface{}, data *grpc.ContainerData) error {
+
+	logger.Infof("Container override message received for id: %s", data.Id)
+
+	ipcService := ctx.(*ipcService)
+
+	cntr := ipcService.css.ContainerLookupById(data.Id)
+	if cntr == nil {
+		return grpcStatus.Errorf(
+			grpcCodes.NotFound,
+			"Container %s not found",
+			data.Id,
+		)
+	}
+
+	overrides := make(map[string]domain.NodeOverride, len(data.Overrides))
+	for _, o := range data.Overrides {
+		if !filepath.IsAbs(o.Path) {
+			return grpcStatus.Errorf(
+				grpcCodes.InvalidArgument,
+				"Invalid override path %q for container %s",
+				o.Path, data.Id,
+			)
+		}
+
+		overrides[filepath.Clean(o.Path)] = domain.NodeOverride{
+			Hide:  o.Hide,
+			Value: o.Value,
+		}
+	}
+
+	cntr.SetOverrides(overrides)
+
+	logger.Infof("Container override successfully processed for id: %s", data.Id)
+
+	return nil
+}
+
+//
+// Sets the bounds of the values that a given container can write into its
+// emulated resources (e.g. a cap on fs.inotify.max_user_watches). The received
+// set fully replaces the existing one, so an empty set clears all policies.
+// Policy defaults only take effect if received ahead of the container's
+// registration.
+//
+func ContainerPolicy(ctx interface{}, data *grpc.ContainerData) error {
+
+	logger.Infof("Container policy message received for id: %s", data.Id)
+
+	ipcService := ctx.(*ipcService)
+
+	cntr := ipcService.css.ContainerLookupById(data.Id)
+	if cntr == nil {
+		return grpcStatus.Errorf(
+			grpcCodes.NotFound,
+			"Container %s not found",
+			data.Id,
+		)
+	}
+
+	policies := make(map[string]domain.ValuePolicy, len(data.Policies))
+	for _, p := range data.Policies {
+		if !filepath.IsAbs(p.Path) {
+			return grpcStatus.Errorf(
+				grpcCodes.InvalidArgument,
+				"Invalid policy path %q for container %s",
+				p.Path, data.Id,
+			)
+		}
+
+		if p.Min != nil && p.Max != nil && *p.Min > *p.Max {
+			return grpcStatus.Errorf(
+				grpcCodes.InvalidArgument,
+				"Invalid policy bounds for %q in container %s: min %d > max %d",
+				p.Path, data.Id, *p.Min, *p.Max,
+			)
+		}
+
+		policy := domain.ValuePolicy{
+			Min:     p.Min,
+			Max:     p.Max,
+			Allowed: p.Allowed,
+			Default: strings.TrimSpace(p.Default),
+		}
+
+		if policy.Default != "" {
+			if err := policy.Check(policy.Default); err != nil {
+				return grpcStatus.Errorf(
+					grpcCodes.InvalidArgument,
+					"Invalid policy default for %q in container %s: %v",
+					p.Path, data.Id, err,
+				)
+			}
+		}
+
+		policies[filepath.Clean(p.Path)] = policy
+	}
+
+	cntr.SetPolicies(policies)
+
+	logger.Infof("Container policy successfully processed for id: %s", data.Id)
+
+	return nil
+}
+
+//
+// Writes a value into an emulated resource of a given container (e.g.
+// "/proc/sys/net/ipv4/ip_forward"), as if the container's init process had
+// written it. Meant for troubleshooting purposes.
+//
+func ContainerSet(ctx interface{}, data *grpc.ContainerData) error {
+
+	logger.Infof("Container set message received for id: %s, path: %s",
+		data.Id, data.Path)
+
+	ipcService := ctx.(*ipcService)
+
+	cntr := ipcService.css.ContainerLookupById(data.Id)
+	if cntr == nil {
+		return grpcStatus.Errorf(
+			grpcCodes.NotFound,
+			"Container %s not found",
+			data.Id,
+		)
+	}
+
+	if !filepath.IsAbs(data.Path) {
+		return grpcStatus.Errorf(
+			grpcCodes.InvalidArgument,
+			"Invalid path %q for container %s",
+			data.Path, data.Id,
+		)
+	}
+
+	path := filepath.Clean(data.Path)
+	ionode := ipcService.ios.NewIOnode(filepath.Base(path), path, 0)
+
+	handler, ok := ipcService.hds.LookupHandler(ionode)
+	if !ok {
+		return grpcStatus.Errorf(
+			grpcCodes.NotFound,
+			"No supported handler for %s resource",
+			path,
+		)
+	}
+
+	request := &domain.HandlerRequest{
+		Pid:       cntr.InitPid(),
+		Uid:       cntr.UID(),
+		Gid:       cntr.GID(),
+		Data:      []byte(data.Value),
+		Container: cntr,
+	}
+
+	if _, err := handler.Write(ionode, request); err != nil {
+		return grpcStatus.Errorf(
+			grpcCodes.Internal,
+			"Unable to write %s for container %s: %v",
+			path, data.Id, err,
+		)
+	}
+
+	// Let the container's processes polling the resource know about the
+	// new value.
+	if err := ipcService.fss.NotifyChange(data.Id, path); err != nil {
+		logger.Warnf("Unable to notify change of %s for container %s: %v",
+			path, data.Id, err)
+	}
+
+	logger.Infof("Container set successfully processed for id: %s, path: %s",
+		data.Id, path)
+
+	return nil
+}
+
+//
+// Reports the handlers known to sysbox-fs, sorted by path. Handlers disabled
+// through the configuration are reported too, as they are simply left out of
+// the handler DB.
+//
+func HandlerList(ctx interface{}, data *grpc.ContainerData) error {
+
+	logger.Debugf("Handler list message received")
+
+	ipcService := ctx.(*ipcService)
+
+	registered := ipcService.hds.HandlerDB()
+
+	for _, h := range ipcService.hds.Handlers() {
+		_, enabled := registered[h.GetPath()]
+
+		data.Handlers = append(data.Handlers, grpc.HandlerInfo{
+			Name:    h.GetName(),
+			Path:    h.GetPath(),
+			Enabled: enabled,
+		})
+	}
+
+	sort.Slice(data.Handlers, func(i, j int) bool {
+		return data.Handlers[i].Path < data.Handlers[j].Path
+	})
+
+	return nil
+}
+
+//
+// Drops the node-attributes cached by the fuse-server of a given container, or
+// by every fuse-server if no container id is provided.
+//
+func CacheInvalidate(ctx interface{}, data *grpc.ContainerData) error {
+
+	logger.Infof("Cache invalidate message received for id: %q", data.Id)
+
+	ipcService := ctx.(*ipcService)
+
+	if err := ipcService.fss.InvalidateCache(data.Id); err != nil {
+		return grpcStatus.Errorf(grpcCodes.NotFound, "%v", err)
+	}
+
+	return nil
+}
+
+// Helper function to hand the attributes of a container back to the ipc
+// requester.
+func containerDataFill(cntr domain.ContainerIface, data *grpc.ContainerData) {
+
+	cgroupPaths := cntr.CgroupPaths()
+	limits := cntr.Limits()
+
+	data.InitPid = int32(cntr.InitPid())
+	data.Ctime = cntr.Ctime()
+	data.UidFirst = int32(cntr.UID())
+	data.GidFirst = int32(cntr.GID())
+	data.ProcRoPaths = cntr.ProcRoPaths()
+	data.ProcMaskPaths = cntr.ProcMaskPaths()
+	data.UidMappings = grpcIdMappings(cntr.UidMappings())
+	data.GidMappings = grpcIdMappings(cntr.GidMappings())
+	data.UidMappingsSet = true
+	data.GidMappingsSet = true
+	data.CgroupV1Paths = cgroupPaths.V1
+	data.CgroupV2Path = cgroupPaths.V2
+	data.CgroupPathsSet = true
+	data.Hostname = cntr.Hostname()
+	data.CpusetCpus = limits.CpusetCpus
+	data.CpusetMems = limits.CpusetMems
+	data.CpuQuota = limits.CpuQuota
+	data.CpuPeriod = limits.CpuPeriod
+	data.CpuShares = limits.CpuShares
+	data.MemLimit = limits.MemLimit
+	data.MemSwapLimit = limits.MemSwapLimit
+	data.RebootRequested = cntr.RebootRequested()
+	data.OpStats = []grpc.ContainerOpStats{grpcOpStats(cntr)}
+}
+
+func opsTotal(s grpc.ContainerOpStats) uint64 {
+	return s.Lookups + s.Opens + s.Reads + s.Writes + s.ReadDirs
+}
+
+func grpcOpStats(cntr domain.ContainerIface) grpc.ContainerOpStats {
+
+	s := cntr.OpStats()
+
+	return grpc.ContainerOpStats{
+		Id:       cntr.ID(),
+		Lookups:  s.Lookups,
+		Opens:    s.Opens,
+		Reads:    s.Reads,
+		Writes:   s.Writes,
+		ReadDirs: s.ReadDirs,
+		Errors:   s.Errors,
+	}
+}
+
+//
+// Health-check requests are only served once sysbox-fs is fully initialized
+// (ipc service is the last one to be launched). The outcome of the runtime
+// checks is reported back to the requester within the message: overall health
+// plus the description of every failed check.
+//
+func Health(ctx interface{}, data *grpc.ContainerData) error {
+
+	logger.Debugf("Health-check message received")
+
+	ipcService := ctx.(*ipcService)
+
+	var status []string
+
+	// Every fuse-server must be serving requests.
+	if ipcService.fss != nil {
+		if err := ipcService.fss.HealthCheck(healthCheckTimeout); err != nil {
+			status = append(status, err.Error())
+		}
+	}
+
+	data.Healthy = len(status) == 0
+	data.HealthStatus = status
+
+	if !data.Healthy {
+		logger.Warnf("Health-check failed: %v", status)
+	}
+
+	return nil
+}
+
+func ContainerStateExport(ctx interface{}, data *grpc.ContainerData) error {
+
+	logger.Infof("Container state-export message received for id: %s", data.Id)
+
+	ipcService := ctx.(*ipcService)
+
+	state, err := ipcService.css.ContainerStateExport(data.Id)
+	if err != nil {
+		return err
+	}
+
+	// Exported state is handed back to the requester within the message.
+	data.State = state
+
+	logger.Infof("Container state-export successfully completed for id: %s",
+		data.Id)
+
+	return nil
+}
+
+func ContainerStateImport(ctx interface{}, data *grpc.ContainerData) error {
+
+	logger.Infof("Container state-import message received for id: %s", data.Id)
+
+	ipcService := ctx.(*ipcService)
+
+	err := ipcService.css.ContainerStateImport(data.Id, data.State)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("Container state-import successfully completed for id: %s",
+		data.Id)
 
 	return nil
 }
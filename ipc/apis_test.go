@@ -27,8 +27,10 @@ import (
 	"github.com/nestybox/sysbox-fs/ipc"
 	"github.com/nestybox/sysbox-fs/mocks"
 	"github.com/nestybox/sysbox-fs/state"
+	"github.com/nestybox/sysbox-fs/sysio"
 	grpc "github.com/nestybox/sysbox-ipc/sysboxFsGrpc"
 	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/mock"
 )
 
 // Sysbox-fs global services for all state's pkg unit-tests.
@@ -43,7 +45,7 @@ func TestMain(m *testing.M) {
 	// Test-cases common settings.
 	//
 	css = &mocks.ContainerStateServiceIface{}
-	css.On("Setup", nil, nil, nil).Return(nil)
+	css.On("Setup", nil, nil, nil, "").Return(nil)
 
 	// Run test-suite.
 	m.Run()
@@ -105,7 +107,7 @@ func Test_ipcService_Setup(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			ips := ipc.NewIpcService()
-			ips.Setup(tt.args.css, tt.args.prs, tt.args.ios)
+			ips.Setup(tt.args.css, tt.args.prs, tt.args.ios, nil, nil)
 		})
 	}
 }
@@ -140,8 +142,11 @@ func TestContainerPreRegister(t *testing.T) {
 		data *grpc.ContainerData
 	}
 
+	var c1 = &mocks.ContainerIface{}
+	c1.On("SetMountOptions", domain.MountOptions{}).Return()
+
 	var ctx = ipc.NewIpcService()
-	ctx.Setup(css, nil, nil)
+	ctx.Setup(css, nil, nil, nil, nil)
 
 	var a1 = args{
 		ctx: ctx,
@@ -164,7 +169,23 @@ func TestContainerPreRegister(t *testing.T) {
 			args:    a1,
 			wantErr: false,
 			prepare: func() {
-				css.On("ContainerPreRegister", a1.data.Id).Return(nil)
+				css.On("ContainerCreate",
+					a1.data.Id,
+					uint32(a1.data.InitPid),
+					a1.data.Ctime,
+					uint32(a1.data.UidFirst),
+					uint32(a1.data.UidSize),
+					uint32(a1.data.GidFirst),
+					uint32(a1.data.GidSize),
+					a1.data.ProcRoPaths,
+					a1.data.ProcMaskPaths,
+					[]domain.IDMapping(nil),
+					[]domain.IDMapping(nil),
+					domain.CgroupPaths{},
+					a1.data.Hostname,
+					domain.ResourceLimits{}).Return(c1)
+
+				css.On("ContainerPreRegister", a1.data.Id, c1).Return(nil)
 			},
 		},
 		{
@@ -176,10 +197,41 @@ func TestContainerPreRegister(t *testing.T) {
 			args:    a1,
 			wantErr: true,
 			prepare: func() {
-				css.On("ContainerPreRegister", a1.data.Id).Return(
+				css.On("ContainerCreate",
+					a1.data.Id,
+					uint32(a1.data.InitPid),
+					a1.data.Ctime,
+					uint32(a1.data.UidFirst),
+					uint32(a1.data.UidSize),
+					uint32(a1.data.GidFirst),
+					uint32(a1.data.GidSize),
+					a1.data.ProcRoPaths,
+					a1.data.ProcMaskPaths,
+					[]domain.IDMapping(nil),
+					[]domain.IDMapping(nil),
+					domain.CgroupPaths{},
+					a1.data.Hostname,
+					domain.ResourceLimits{}).Return(c1)
+
+				css.On("ContainerPreRegister", a1.data.Id, c1).Return(
 					errors.New("Container pre-registration error: container %s already present"))
 			},
 		},
+		{
+			//
+			// Test-case 3: Pre-registration request from a peer speaking an
+			// unsupported protocol version. Error expected and css not reached.
+			//
+			name: "3",
+			args: args{
+				ctx: ctx,
+				data: &grpc.ContainerData{
+					Id:           "c1",
+					ProtoVersion: ipc.ProtoVersion + 1,
+				},
+			},
+			wantErr: true,
+		},
 	}
 
 	//
@@ -212,15 +264,25 @@ func TestContainerRegister(t *testing.T) {
 		data *grpc.ContainerData
 	}
 
-	var c1 domain.ContainerIface
+	var c1 = &mocks.ContainerIface{}
+	c1.On("SetMountOptions", domain.MountOptions{
+		ReadOnly:             true,
+		HiddenPaths:          []string{"/proc/sys/kernel/random"},
+		RestrictBlockDevices: true,
+		BlockDevices:         []string{"loop0"},
+	}).Return()
 
 	var ctx = ipc.NewIpcService()
-	ctx.Setup(css, nil, nil)
+	ctx.Setup(css, nil, nil, nil, nil)
 
 	var a1 = args{
 		ctx: ctx,
 		data: &grpc.ContainerData{
-			Id: "c1",
+			Id:                        "c1",
+			MountReadOnly:             true,
+			MountHiddenPaths:          []string{"/proc/sys/kernel/random"},
+			MountRestrictBlockDevices: true,
+			MountBlockDevices:         []string{"loop0"},
 		},
 	}
 
@@ -247,7 +309,12 @@ func TestContainerRegister(t *testing.T) {
 					uint32(a1.data.GidFirst),
 					uint32(a1.data.GidSize),
 					a1.data.ProcRoPaths,
-					a1.data.ProcMaskPaths).Return(c1)
+					a1.data.ProcMaskPaths,
+					[]domain.IDMapping(nil),
+					[]domain.IDMapping(nil),
+					domain.CgroupPaths{},
+					a1.data.Hostname,
+					domain.ResourceLimits{}).Return(c1)
 
 				css.On("ContainerRegister", c1).Return(nil)
 			},
@@ -271,7 +338,12 @@ func TestContainerRegister(t *testing.T) {
 					uint32(a1.data.GidFirst),
 					uint32(a1.data.GidSize),
 					a1.data.ProcRoPaths,
-					a1.data.ProcMaskPaths).Return(c1)
+					a1.data.ProcMaskPaths,
+					[]domain.IDMapping(nil),
+					[]domain.IDMapping(nil),
+					domain.CgroupPaths{},
+					a1.data.Hostname,
+					domain.ResourceLimits{}).Return(c1)
 
 				css.On("ContainerRegister", c1).Return(
 					errors.New("registration error found"))
@@ -319,10 +391,33 @@ func TestContainerUnregister(t *testing.T) {
 		65535,
 		nil,
 		nil,
+		nil,
+		nil,
+		domain.CgroupPaths{},
+		"",
+		domain.ResourceLimits{},
+	)
+
+	var c2 = state.NewContainerStateService().ContainerCreate(
+		"c2",
+		1002,
+		time.Time{},
+		231072,
+		65535,
+		231072,
+		65535,
+		nil,
+		nil,
+		nil,
+		nil,
+		domain.CgroupPaths{},
+		"",
+		domain.ResourceLimits{},
 	)
+	c2.SetRebootRequested()
 
 	var ctx = ipc.NewIpcService()
-	ctx.Setup(css, nil, nil)
+	ctx.Setup(css, nil, nil, nil, nil)
 
 	var a1 = args{
 		ctx: ctx,
@@ -331,11 +426,19 @@ func TestContainerUnregister(t *testing.T) {
 		},
 	}
 
+	var a2 = args{
+		ctx: ctx,
+		data: &grpc.ContainerData{
+			Id: "c2",
+		},
+	}
+
 	tests := []struct {
-		name    string
-		args    args
-		wantErr bool
-		prepare func()
+		name       string
+		args       args
+		wantErr    bool
+		wantReboot bool
+		prepare    func()
 	}{
 		{
 			//
@@ -362,6 +465,21 @@ func TestContainerUnregister(t *testing.T) {
 				css.On("ContainerLookupById", a1.data.Id).Return(nil)
 			},
 		},
+		{
+			//
+			// Test-case 3: Containers that requested a reboot must have it
+			// reported back to the peer.
+			//
+			name:       "3",
+			args:       a2,
+			wantErr:    false,
+			wantReboot: true,
+			prepare: func() {
+
+				css.On("ContainerLookupById", a2.data.Id).Return(c2)
+				css.On("ContainerUnregister", c2).Return(nil)
+			},
+		},
 	}
 
 	//
@@ -382,6 +500,11 @@ func TestContainerUnregister(t *testing.T) {
 				t.Errorf("ContainerUnregister() error = %v, wantErr %v", err, tt.wantErr)
 			}
 
+			if tt.args.data.RebootRequested != tt.wantReboot {
+				t.Errorf("ContainerUnregister() reboot = %v, want %v",
+					tt.args.data.RebootRequested, tt.wantReboot)
+			}
+
 			// Ensure that mocks were properly invoked.
 			css.AssertExpectations(t)
 		})
@@ -394,10 +517,11 @@ func TestContainerUpdate(t *testing.T) {
 		data *grpc.ContainerData
 	}
 
-	var c1 domain.ContainerIface
+	var c1 = &mocks.ContainerIface{}
+	c1.On("SetMountOptions", domain.MountOptions{}).Return()
 
 	var ctx = ipc.NewIpcService()
-	ctx.Setup(css, nil, nil)
+	ctx.Setup(css, nil, nil, nil, nil)
 
 	var a1 = args{
 		ctx: ctx,
@@ -430,7 +554,12 @@ func TestContainerUpdate(t *testing.T) {
 					uint32(a1.data.GidFirst),
 					uint32(a1.data.GidSize),
 					a1.data.ProcRoPaths,
-					a1.data.ProcMaskPaths).Return(c1)
+					a1.data.ProcMaskPaths,
+					[]domain.IDMapping(nil),
+					[]domain.IDMapping(nil),
+					domain.CgroupPaths{},
+					a1.data.Hostname,
+					domain.ResourceLimits{}).Return(c1)
 
 				css.On("ContainerUpdate", c1).Return(nil)
 			},
@@ -454,12 +583,54 @@ func TestContainerUpdate(t *testing.T) {
 					uint32(a1.data.GidFirst),
 					uint32(a1.data.GidSize),
 					a1.data.ProcRoPaths,
-					a1.data.ProcMaskPaths).Return(c1)
+					a1.data.ProcMaskPaths,
+					[]domain.IDMapping(nil),
+					[]domain.IDMapping(nil),
+					domain.CgroupPaths{},
+					a1.data.Hostname,
+					domain.ResourceLimits{}).Return(c1)
 
 				css.On("ContainerUpdate", c1).Return(
 					errors.New("registration error found"))
 			},
 		},
+		{
+			//
+			// Test-case 3: Mappings and cgroup paths flagged as conveyed are
+			// handed over even if empty, so that they're cleared.
+			//
+			name: "3",
+			args: args{
+				ctx: ctx,
+				data: &grpc.ContainerData{
+					Id:             "c1",
+					UidMappingsSet: true,
+					GidMappingsSet: true,
+					CgroupPathsSet: true,
+				},
+			},
+			wantErr: false,
+			prepare: func() {
+
+				css.On("ContainerCreate",
+					"c1",
+					uint32(0),
+					time.Time{},
+					uint32(0),
+					uint32(0),
+					uint32(0),
+					uint32(0),
+					[]string(nil),
+					[]string(nil),
+					[]domain.IDMapping{},
+					[]domain.IDMapping{},
+					domain.CgroupPaths{V1: map[string]string{}},
+					"",
+					domain.ResourceLimits{}).Return(c1)
+
+				css.On("ContainerUpdate", c1).Return(nil)
+			},
+		},
 	}
 
 	//
@@ -485,3 +656,962 @@ func TestContainerUpdate(t *testing.T) {
 		})
 	}
 }
+
+func TestContainerStateExport(t *testing.T) {
+
+	var ctx = ipc.NewIpcService()
+	ctx.Setup(css, nil, nil, nil, nil)
+
+	tests := []struct {
+		name    string
+		data    *grpc.ContainerData
+		wantErr bool
+		want    []byte
+		prepare func()
+	}{
+		{
+			//
+			// Test-case 1: Proper state-export request. Exported state is
+			// expected to be handed back within the message.
+			//
+			name:    "1",
+			data:    &grpc.ContainerData{Id: "c1"},
+			wantErr: false,
+			want:    []byte(`{"version":1}`),
+			prepare: func() {
+				css.On("ContainerStateExport", "c1").Return([]byte(`{"version":1}`), nil)
+			},
+		},
+		{
+			//
+			// Test-case 2: Verify proper behavior during css' export error.
+			//
+			name:    "2",
+			data:    &grpc.ContainerData{Id: "c2"},
+			wantErr: true,
+			want:    nil,
+			prepare: func() {
+				css.On("ContainerStateExport", "c2").Return(nil, errors.New("Error"))
+			},
+		},
+	}
+
+	//
+	// Testcase executions.
+	//
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			// Reset mock expectations from previous iterations.
+			css.ExpectedCalls = nil
+
+			// Prepare the mocks.
+			if tt.prepare != nil {
+				tt.prepare()
+			}
+
+			if err := ipc.ContainerStateExport(ctx, tt.data); (err != nil) != tt.wantErr {
+				t.Errorf("ContainerStateExport() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(tt.data.State, tt.want) {
+				t.Errorf("ContainerStateExport() state = %s, want %s", tt.data.State, tt.want)
+			}
+
+			// Ensure that mocks were properly invoked.
+			css.AssertExpectations(t)
+		})
+	}
+}
+
+func TestContainerStateImport(t *testing.T) {
+
+	var ctx = ipc.NewIpcService()
+	ctx.Setup(css, nil, nil, nil, nil)
+
+	var state = []byte(`{"version":1}`)
+
+	tests := []struct {
+		name    string
+		data    *grpc.ContainerData
+		wantErr bool
+		prepare func()
+	}{
+		{
+			//
+			// Test-case 1: Proper state-import request. No errors expected.
+			//
+			name:    "1",
+			data:    &grpc.ContainerData{Id: "c1", State: state},
+			wantErr: false,
+			prepare: func() {
+				css.On("ContainerStateImport", "c1", state).Return(nil)
+			},
+		},
+		{
+			//
+			// Test-case 2: Verify proper behavior during css' import error.
+			//
+			name:    "2",
+			data:    &grpc.ContainerData{Id: "c2", State: state},
+			wantErr: true,
+			prepare: func() {
+				css.On("ContainerStateImport", "c2", state).Return(errors.New("Error"))
+			},
+		},
+	}
+
+	//
+	// Testcase executions.
+	//
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			// Reset mock expectations from previous iterations.
+			css.ExpectedCalls = nil
+
+			// Prepare the mocks.
+			if tt.prepare != nil {
+				tt.prepare()
+			}
+
+			if err := ipc.ContainerStateImport(ctx, tt.data); (err != nil) != tt.wantErr {
+				t.Errorf("ContainerStateImport() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			// Ensure that mocks were properly invoked.
+			css.AssertExpectations(t)
+		})
+	}
+}
+
+func TestContainerQuery(t *testing.T) {
+
+	var ctx = ipc.NewIpcService()
+	ctx.Setup(css, nil, nil, nil, nil)
+
+	var c1 = &mocks.ContainerIface{}
+	var ctime = time.Date(2020, 01, 01, 0, 0, 0, 0, time.UTC)
+
+	c1.On("InitPid").Return(uint32(1001))
+	c1.On("Ctime").Return(ctime)
+	c1.On("UID").Return(uint32(165536))
+	c1.On("GID").Return(uint32(165536))
+	c1.On("ProcRoPaths").Return([]string{"/proc/sys"})
+	c1.On("ProcMaskPaths").Return([]string(nil))
+	c1.On("UidMappings").Return(
+		[]domain.IDMapping{{ContainerID: 0, HostID: 165536, Size: 65536}})
+	c1.On("GidMappings").Return([]domain.IDMapping(nil))
+	c1.On("CgroupPaths").Return(domain.CgroupPaths{V2: "/sys/fs/cgroup/c1"})
+	c1.On("Hostname").Return("c1-host")
+	c1.On("Limits").Return(domain.ResourceLimits{CpusetCpus: "0-1", MemLimit: 1 << 30})
+	c1.On("RebootRequested").Return(true)
+	c1.On("ID").Return("c1")
+	c1.On("OpStats").Return(domain.ContainerOpStats{Lookups: 2, Reads: 3, Errors: 1})
+
+	tests := []struct {
+		name    string
+		data    *grpc.ContainerData
+		wantErr bool
+		want    *grpc.ContainerData
+		prepare func()
+	}{
+		{
+			//
+			// Test-case 1: Proper query request. Container attributes are
+			// expected to be handed back within the message.
+			//
+			name:    "1",
+			data:    &grpc.ContainerData{Id: "c1"},
+			wantErr: false,
+			want: &grpc.ContainerData{
+				Id:          "c1",
+				InitPid:     1001,
+				Ctime:       ctime,
+				UidFirst:    165536,
+				GidFirst:    165536,
+				ProcRoPaths: []string{"/proc/sys"},
+				UidMappings: []grpc.IDMapping{
+					{ContainerID: 0, HostID: 165536, Size: 65536},
+				},
+				UidMappingsSet:  true,
+				GidMappingsSet:  true,
+				CgroupV2Path:    "/sys/fs/cgroup/c1",
+				CgroupPathsSet:  true,
+				Hostname:        "c1-host",
+				CpusetCpus:      "0-1",
+				MemLimit:        1 << 30,
+				RebootRequested: true,
+				OpStats: []grpc.ContainerOpStats{
+					{Id: "c1", Lookups: 2, Reads: 3, Errors: 1},
+				},
+			},
+			prepare: func() {
+				css.On("ContainerLookupById", "c1").Return(c1)
+			},
+		},
+		{
+			//
+			// Test-case 2: Query of a non-registered container. Error expected.
+			//
+			name:    "2",
+			data:    &grpc.ContainerData{Id: "c2"},
+			wantErr: true,
+			want:    &grpc.ContainerData{Id: "c2"},
+			prepare: func() {
+				css.On("ContainerLookupById", "c2").Return(nil)
+			},
+		},
+	}
+
+	//
+	// Testcase executions.
+	//
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			// Reset mock expectations from previous iterations.
+			css.ExpectedCalls = nil
+
+			// Prepare the mocks.
+			if tt.prepare != nil {
+				tt.prepare()
+			}
+
+			if err := ipc.ContainerQuery(ctx, tt.data); (err != nil) != tt.wantErr {
+				t.Errorf("ContainerQuery() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(tt.data, tt.want) {
+				t.Errorf("ContainerQuery() data = %+v, want %+v", tt.data, tt.want)
+			}
+
+			// Ensure that mocks were properly invoked.
+			css.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandshake(t *testing.T) {
+
+	var ctx = ipc.NewIpcService()
+	ctx.Setup(css, nil, nil, nil, nil)
+
+	tests := []struct {
+		name    string
+		data    *grpc.ContainerData
+		wantErr bool
+		want    uint32
+	}{
+		{
+			//
+			// Test-case 1: Peer speaking our protocol version. Our version is
+			// expected to be handed back.
+			//
+			name:    "1",
+			data:    &grpc.ContainerData{ProtoVersion: ipc.ProtoVersion},
+			wantErr: false,
+			want:    ipc.ProtoVersion,
+		},
+		{
+			//
+			// Test-case 2: Peer speaking a newer protocol version. Error expected.
+			//
+			name:    "2",
+			data:    &grpc.ContainerData{ProtoVersion: ipc.ProtoVersion + 1},
+			wantErr: true,
+			want:    ipc.ProtoVersion + 1,
+		},
+		{
+			//
+			// Test-case 3: Peer not announcing any version. Error expected.
+			//
+			name:    "3",
+			data:    &grpc.ContainerData{},
+			wantErr: true,
+			want:    0,
+		},
+	}
+
+	//
+	// Testcase executions.
+	//
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			if err := ipc.Handshake(ctx, tt.data); (err != nil) != tt.wantErr {
+				t.Errorf("Handshake() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.data.ProtoVersion != tt.want {
+				t.Errorf("Handshake() version = %v, want %v",
+					tt.data.ProtoVersion, tt.want)
+			}
+		})
+	}
+}
+
+func TestHealth(t *testing.T) {
+
+	var fss = &mocks.FuseServerServiceIface{}
+	var ctx = ipc.NewIpcService()
+	ctx.Setup(css, nil, nil, fss, nil)
+
+	tests := []struct {
+		name       string
+		wantHealth bool
+		wantStatus []string
+		prepare    func()
+	}{
+		{
+			//
+			// Test-case 1: All fuse-servers responsive. Healthy state expected.
+			//
+			name:       "1",
+			wantHealth: true,
+			wantStatus: nil,
+			prepare: func() {
+				fss.On("HealthCheck", mock.Anything).Return(nil)
+			},
+		},
+		{
+			//
+			// Test-case 2: Unresponsive fuse-server. Unhealthy state expected,
+			// along with the failure's description.
+			//
+			name:       "2",
+			wantHealth: false,
+			wantStatus: []string{"fuse-server for container c1 is unresponsive"},
+			prepare: func() {
+				fss.On("HealthCheck", mock.Anything).Return(
+					errors.New("fuse-server for container c1 is unresponsive"))
+			},
+		},
+	}
+
+	//
+	// Testcase executions.
+	//
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			// Reset mock expectations from previous iterations.
+			fss.ExpectedCalls = nil
+
+			// Prepare the mocks.
+			if tt.prepare != nil {
+				tt.prepare()
+			}
+
+			data := &grpc.ContainerData{}
+
+			if err := ipc.Health(ctx, data); err != nil {
+				t.Errorf("Health() error = %v", err)
+			}
+
+			if data.Healthy != tt.wantHealth {
+				t.Errorf("Health() healthy = %v, want %v", data.Healthy, tt.wantHealth)
+			}
+			if !reflect.DeepEqual(data.HealthStatus, tt.wantStatus) {
+				t.Errorf("Health() status = %v, want %v",
+					data.HealthStatus, tt.wantStatus)
+			}
+
+			// Ensure that mocks were properly invoked.
+			fss.AssertExpectations(t)
+		})
+	}
+}
+
+func TestContainerList(t *testing.T) {
+
+	var ctx = ipc.NewIpcService()
+	ctx.Setup(css, nil, nil, nil, nil)
+
+	var c1 = &mocks.ContainerIface{}
+	var c2 = &mocks.ContainerIface{}
+	c1.On("ID").Return("c1")
+	c2.On("ID").Return("c2")
+	c1.On("OpStats").Return(domain.ContainerOpStats{Reads: 1})
+	c2.On("OpStats").Return(domain.ContainerOpStats{Lookups: 5, Writes: 5})
+
+	// Reset mock expectations from previous tests.
+	css.ExpectedCalls = nil
+
+	css.On("ContainerList").Return([]domain.ContainerIface{c1, c2})
+
+	data := &grpc.ContainerData{}
+	if err := ipc.ContainerList(ctx, data); err != nil {
+		t.Errorf("ContainerList() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(data.ContainerIds, []string{"c1", "c2"}) {
+		t.Errorf("ContainerList() ids = %v, want [c1 c2]", data.ContainerIds)
+	}
+
+	// Top talkers are expected first in the op-stats report.
+	wantStats := []grpc.ContainerOpStats{
+		{Id: "c2", Lookups: 5, Writes: 5},
+		{Id: "c1", Reads: 1},
+	}
+	if !reflect.DeepEqual(data.OpStats, wantStats) {
+		t.Errorf("ContainerList() op-stats = %+v, want %+v", data.OpStats, wantStats)
+	}
+
+	css.AssertExpectations(t)
+}
+
+func TestContainerInspect(t *testing.T) {
+
+	var ctx = ipc.NewIpcService()
+	ctx.Setup(css, nil, nil, nil, nil)
+
+	var c1 = &mocks.ContainerIface{}
+	var state = []byte(`{"version":1,"id":"c1"}`)
+
+	c1.On("InitPid").Return(uint32(1001))
+	c1.On("Ctime").Return(time.Time{})
+	c1.On("UID").Return(uint32(165536))
+	c1.On("GID").Return(uint32(165536))
+	c1.On("ProcRoPaths").Return([]string(nil))
+	c1.On("ProcMaskPaths").Return([]string(nil))
+	c1.On("UidMappings").Return([]domain.IDMapping(nil))
+	c1.On("GidMappings").Return([]domain.IDMapping(nil))
+	c1.On("CgroupPaths").Return(domain.CgroupPaths{})
+	c1.On("Hostname").Return("c1-host")
+	c1.On("Limits").Return(domain.ResourceLimits{})
+	c1.On("RebootRequested").Return(false)
+	c1.On("ID").Return("c1")
+	c1.On("OpStats").Return(domain.ContainerOpStats{})
+
+	tests := []struct {
+		name    string
+		data    *grpc.ContainerData
+		wantErr bool
+		prepare func()
+	}{
+		{
+			//
+			// Test-case 1: Proper inspect request. Both metadata and emulated
+			// state are expected.
+			//
+			name:    "1",
+			data:    &grpc.ContainerData{Id: "c1"},
+			wantErr: false,
+			prepare: func() {
+				css.On("ContainerLookupById", "c1").Return(c1)
+				css.On("ContainerStateExport", "c1").Return(state, nil)
+			},
+		},
+		{
+			//
+			// Test-case 2: Inspect request of a non-registered container. Error
+			// expected.
+			//
+			name:    "2",
+			data:    &grpc.ContainerData{Id: "c2"},
+			wantErr: true,
+			prepare: func() {
+				css.On("ContainerLookupById", "c2").Return(nil)
+			},
+		},
+	}
+
+	//
+	// Testcase executions.
+	//
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			// Reset mock expectations from previous iterations.
+			css.ExpectedCalls = nil
+
+			// Prepare the mocks.
+			if tt.prepare != nil {
+				tt.prepare()
+			}
+
+			err := ipc.ContainerInspect(ctx, tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ContainerInspect() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err == nil &&
+				(tt.data.Hostname != "c1-host" || !reflect.DeepEqual(tt.data.State, state)) {
+				t.Errorf("ContainerInspect() data = %+v", tt.data)
+			}
+
+			// Ensure that mocks were properly invoked.
+			css.AssertExpectations(t)
+		})
+	}
+}
+
+func TestContainerOverride(t *testing.T) {
+
+	var ctx = ipc.NewIpcService()
+	ctx.Setup(css, nil, nil, nil, nil)
+
+	var c1 = &mocks.ContainerIface{}
+
+	tests := []struct {
+		name    string
+		data    *grpc.ContainerData
+		wantErr bool
+		prepare func()
+	}{
+		{
+			//
+			// Test-case 1: Proper override request. Paths are expected to be
+			// normalized.
+			//
+			name: "1",
+			data: &grpc.ContainerData{
+				Id: "c1",
+				Overrides: []grpc.NodeOverride{
+					{Path: "/proc/sys/kernel/osrelease", Value: "5.4.0"},
+					{Path: "/proc//kallsyms", Hide: true},
+				},
+			},
+			wantErr: false,
+			prepare: func() {
+				css.On("ContainerLookupById", "c1").Return(c1)
+				c1.On("SetOverrides", map[string]domain.NodeOverride{
+					"/proc/sys/kernel/osrelease": {Value: "5.4.0"},
+					"/proc/kallsyms":             {Hide: true},
+				}).Return()
+			},
+		},
+		{
+			//
+			// Test-case 2: Relative override path. Error expected.
+			//
+			name: "2",
+			data: &grpc.ContainerData{
+				Id:        "c1",
+				Overrides: []grpc.NodeOverride{{Path: "kallsyms", Hide: true}},
+			},
+			wantErr: true,
+			prepare: func() {
+				css.On("ContainerLookupById", "c1").Return(c1)
+			},
+		},
+		{
+			//
+			// Test-case 3: Override request for a non-registered container.
+			// Error expected.
+			//
+			name:    "3",
+			data:    &grpc.ContainerData{Id: "c2"},
+			wantErr: true,
+			prepare: func() {
+				css.On("ContainerLookupById", "c2").Return(nil)
+			},
+		},
+	}
+
+	//
+	// Testcase executions.
+	//
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			// Reset mock expectations from previous iterations.
+			css.ExpectedCalls = nil
+			c1.ExpectedCalls = nil
+
+			// Prepare the mocks.
+			if tt.prepare != nil {
+				tt.prepare()
+			}
+
+			if err := ipc.ContainerOverride(ctx, tt.data); (err != nil) != tt.wantErr {
+				t.Errorf("ContainerOverride() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			// Ensure that mocks were properly invoked.
+			css.AssertExpectations(t)
+			c1.AssertExpectations(t)
+		})
+	}
+}
+
+func TestContainerPolicy(t *testing.T) {
+
+	var ctx = ipc.NewIpcService()
+	ctx.Setup(css, nil, nil, nil, nil)
+
+	var c1 = &mocks.ContainerIface{}
+
+	var min, max int64 = 8192, 524288
+
+	tests := []struct {
+		name    string
+		data    *grpc.ContainerData
+		wantErr bool
+		prepare func()
+	}{
+		{
+			//
+			// Test-case 1: Proper policy request. Paths are expected to be
+			// normalized.
+			//
+			name: "1",
+			data: &grpc.ContainerData{
+				Id: "c1",
+				Policies: []grpc.ValuePolicy{
+					{Path: "/proc/sys/fs/inotify//max_user_watches", Min: &min, Max: &max},
+					{Path: "/proc/sys/net/ipv4/ip_forward", Allowed: []string{"0"}},
+				},
+			},
+			wantErr: false,
+			prepare: func() {
+				css.On("ContainerLookupById", "c1").Return(c1)
+				c1.On("SetPolicies", map[string]domain.ValuePolicy{
+					"/proc/sys/fs/inotify/max_user_watches": {Min: &min, Max: &max},
+					"/proc/sys/net/ipv4/ip_forward":         {Allowed: []string{"0"}},
+				}).Return()
+			},
+		},
+		{
+			//
+			// Test-case 2: Inverted bounds. Error expected.
+			//
+			name: "2",
+			data: &grpc.ContainerData{
+				Id: "c1",
+				Policies: []grpc.ValuePolicy{
+					{Path: "/proc/sys/fs/inotify/max_user_watches", Min: &max, Max: &min},
+				},
+			},
+			wantErr: true,
+			prepare: func() {
+				css.On("ContainerLookupById", "c1").Return(c1)
+			},
+		},
+		{
+			//
+			// Test-case 3: Policy request for a non-registered container.
+			// Error expected.
+			//
+			name:    "3",
+			data:    &grpc.ContainerData{Id: "c2"},
+			wantErr: true,
+			prepare: func() {
+				css.On("ContainerLookupById", "c2").Return(nil)
+			},
+		},
+	}
+
+	//
+	// Testcase executions.
+	//
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			// Reset mock expectations from previous iterations.
+			css.ExpectedCalls = nil
+			c1.ExpectedCalls = nil
+
+			// Prepare the mocks.
+			if tt.prepare != nil {
+				tt.prepare()
+			}
+
+			if err := ipc.ContainerPolicy(ctx, tt.data); (err != nil) != tt.wantErr {
+				t.Errorf("ContainerPolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			// Ensure that mocks were properly invoked.
+			css.AssertExpectations(t)
+			c1.AssertExpectations(t)
+		})
+	}
+}
+
+func TestValuePolicy_Check(t *testing.T) {
+
+	var min, max int64 = 10, 100
+
+	tests := []struct {
+		name    string
+		policy  domain.ValuePolicy
+		value   string
+		wantErr bool
+	}{
+		// Test-case 1: Value within bounds.
+		{"1", domain.ValuePolicy{Min: &min, Max: &max}, "50\n", false},
+		// Test-case 2: Value below the minimum.
+		{"2", domain.ValuePolicy{Min: &min}, "5", true},
+		// Test-case 3: Value above the maximum.
+		{"3", domain.ValuePolicy{Max: &max}, "101", true},
+		// Test-case 4: Multi-field value with an out-of-bounds field.
+		{"4", domain.ValuePolicy{Max: &max}, "10 20 300", true},
+		// Test-case 5: Non-integer value under integer bounds.
+		{"5", domain.ValuePolicy{Max: &max}, "foo", true},
+		// Test-case 6: Allowed value.
+		{"6", domain.ValuePolicy{Allowed: []string{"cubic", "reno"}}, "reno\n", false},
+		// Test-case 7: Value not allowed.
+		{"7", domain.ValuePolicy{Allowed: []string{"cubic", "reno"}}, "bbr", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.policy.Check(tt.value); (err != nil) != tt.wantErr {
+				t.Errorf("ValuePolicy.Check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDrain(t *testing.T) {
+
+	var ctx = ipc.NewIpcService()
+	ctx.Setup(css, nil, nil, nil, nil)
+
+	tests := []struct {
+		name    string
+		wantErr bool
+		prepare func()
+	}{
+		{
+			//
+			// Test-case 1: Proper drain request. No errors expected.
+			//
+			name:    "1",
+			wantErr: false,
+			prepare: func() {
+				css.On("ContainerDBDrain").Return(2, nil)
+			},
+		},
+		{
+			//
+			// Test-case 2: Verify proper behavior during css' drain error.
+			//
+			name:    "2",
+			wantErr: true,
+			prepare: func() {
+				css.On("ContainerDBDrain").Return(2, errors.New("Drain error"))
+			},
+		},
+	}
+
+	//
+	// Testcase executions.
+	//
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			// Reset mock expectations from previous iterations.
+			css.ExpectedCalls = nil
+
+			// Prepare the mocks.
+			if tt.prepare != nil {
+				tt.prepare()
+			}
+
+			if err := ipc.Drain(ctx, &grpc.ContainerData{}); (err != nil) != tt.wantErr {
+				t.Errorf("Drain() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			// Ensure that mocks were properly invoked.
+			css.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUndrain(t *testing.T) {
+
+	var ctx = ipc.NewIpcService()
+	ctx.Setup(css, nil, nil, nil, nil)
+
+	css.ExpectedCalls = nil
+	css.On("ContainerDBUndrain").Return()
+
+	if err := ipc.Undrain(ctx, &grpc.ContainerData{}); err != nil {
+		t.Errorf("Undrain() error = %v", err)
+	}
+
+	css.AssertExpectations(t)
+}
+
+func TestContainerSet(t *testing.T) {
+
+	const path = "/proc/sys/net/ipv4/ip_forward"
+
+	var ios = sysio.NewIOService(domain.IOMemFileService)
+	var hds = &mocks.HandlerServiceIface{}
+	var fss = &mocks.FuseServerServiceIface{}
+	var ctx = ipc.NewIpcService()
+	ctx.Setup(css, nil, ios, fss, hds)
+
+	var c1 = &mocks.ContainerIface{}
+	var h = &mocks.HandlerIface{}
+
+	// Handlers are expected to be looked up by the resource's path.
+	var node = mock.MatchedBy(func(i domain.IOnodeIface) bool {
+		return i.Path() == path
+	})
+
+	tests := []struct {
+		name    string
+		data    *grpc.ContainerData
+		wantErr bool
+		prepare func()
+	}{
+		{
+			//
+			// Test-case 1: Proper set request. Value is expected to be written
+			// on behalf of the container's init process, and its pollers
+			// notified.
+			//
+			name:    "1",
+			data:    &grpc.ContainerData{Id: "c1", Path: path, Value: "1\n"},
+			wantErr: false,
+			prepare: func() {
+				css.On("ContainerLookupById", "c1").Return(c1)
+				c1.On("InitPid").Return(uint32(1001))
+				c1.On("UID").Return(uint32(165536))
+				c1.On("GID").Return(uint32(165536))
+				hds.On("LookupHandler", node).Return(h, true)
+				h.On("Write", node, &domain.HandlerRequest{
+					Pid:       1001,
+					Uid:       165536,
+					Gid:       165536,
+					Data:      []byte("1\n"),
+					Container: c1,
+				}).Return(2, nil)
+				fss.On("NotifyChange", "c1", path).Return(nil)
+			},
+		},
+		{
+			//
+			// Test-case 2: Handler failing to write the value. Error expected.
+			//
+			name:    "2",
+			data:    &grpc.ContainerData{Id: "c1", Path: path, Value: "foo\n"},
+			wantErr: true,
+			prepare: func() {
+				css.On("ContainerLookupById", "c1").Return(c1)
+				c1.On("InitPid").Return(uint32(1001))
+				c1.On("UID").Return(uint32(165536))
+				c1.On("GID").Return(uint32(165536))
+				hds.On("LookupHandler", node).Return(h, true)
+				h.On("Write", node, mock.Anything).Return(0, errors.New("EINVAL"))
+			},
+		},
+		{
+			//
+			// Test-case 3: Relative path. Error expected.
+			//
+			name:    "3",
+			data:    &grpc.ContainerData{Id: "c1", Path: "ip_forward", Value: "1\n"},
+			wantErr: true,
+			prepare: func() {
+				css.On("ContainerLookupById", "c1").Return(c1)
+			},
+		},
+		{
+			//
+			// Test-case 4: Set request for a non-registered container. Error
+			// expected.
+			//
+			name:    "4",
+			data:    &grpc.ContainerData{Id: "c2", Path: path, Value: "1\n"},
+			wantErr: true,
+			prepare: func() {
+				css.On("ContainerLookupById", "c2").Return(nil)
+			},
+		},
+	}
+
+	//
+	// Testcase executions.
+	//
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			// Reset mock expectations from previous iterations.
+			css.ExpectedCalls = nil
+			c1.ExpectedCalls = nil
+			hds.ExpectedCalls = nil
+			fss.ExpectedCalls = nil
+			h.ExpectedCalls = nil
+
+			// Prepare the mocks.
+			if tt.prepare != nil {
+				tt.prepare()
+			}
+
+			if err := ipc.ContainerSet(ctx, tt.data); (err != nil) != tt.wantErr {
+				t.Errorf("ContainerSet() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			// Ensure that mocks were properly invoked.
+			css.AssertExpectations(t)
+			c1.AssertExpectations(t)
+			hds.AssertExpectations(t)
+			fss.AssertExpectations(t)
+			h.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandlerList(t *testing.T) {
+
+	var hds = &mocks.HandlerServiceIface{}
+	var ctx = ipc.NewIpcService()
+	ctx.Setup(css, nil, nil, nil, hds)
+
+	var h1 = &mocks.HandlerIface{}
+	var h2 = &mocks.HandlerIface{}
+	h1.On("GetName").Return("uptime")
+	h1.On("GetPath").Return("/proc/uptime")
+	h2.On("GetName").Return("swaps")
+	h2.On("GetPath").Return("/proc/swaps")
+
+	// Disabled handlers are only known by the full handler set.
+	hds.On("Handlers").Return([]domain.HandlerIface{h1, h2})
+	hds.On("HandlerDB").Return(map[string]domain.HandlerIface{
+		"/proc/uptime": h1,
+	})
+
+	data := &grpc.ContainerData{}
+	if err := ipc.HandlerList(ctx, data); err != nil {
+		t.Errorf("HandlerList() error = %v", err)
+	}
+
+	// Handlers are expected to be sorted by path.
+	want := []grpc.HandlerInfo{
+		{Name: "swaps", Path: "/proc/swaps", Enabled: false},
+		{Name: "uptime", Path: "/proc/uptime", Enabled: true},
+	}
+	if !reflect.DeepEqual(data.Handlers, want) {
+		t.Errorf("HandlerList() handlers = %+v, want %+v", data.Handlers, want)
+	}
+
+	hds.AssertExpectations(t)
+}
+
+func TestCacheInvalidate(t *testing.T) {
+
+	var fss = &mocks.FuseServerServiceIface{}
+	var ctx = ipc.NewIpcService()
+	ctx.Setup(css, nil, nil, fss, nil)
+
+	fss.On("InvalidateCache", "").Return(nil)
+	fss.On("InvalidateCache", "c1").Return(nil)
+	fss.On("InvalidateCache", "c2").Return(errors.New("not found"))
+
+	if err := ipc.CacheInvalidate(ctx, &grpc.ContainerData{}); err != nil {
+		t.Errorf("CacheInvalidate() error = %v", err)
+	}
+	if err := ipc.CacheInvalidate(ctx, &grpc.ContainerData{Id: "c1"}); err != nil {
+		t.Errorf("CacheInvalidate() error = %v", err)
+	}
+	if err := ipc.CacheInvalidate(ctx, &grpc.ContainerData{Id: "c2"}); err == nil {
+		t.Errorf("CacheInvalidate() expected error for unknown container")
+	}
+
+	fss.AssertExpectations(t)
+}
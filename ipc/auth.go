@@ -0,0 +1,348 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package ipc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/sys/unix"
+	"google.golang.org/grpc/credentials"
+)
+
+//
+// peerAuth implements grpc's TransportCredentials interface to restrict the
+// access to sysbox-fs' ipc socket. Peer credentials are obtained from the
+// kernel (SO_PEERCRED) during connection establishment, so they can't be
+// spoofed by the client. Connections from non-authorized peers are dropped
+// before any message is processed.
+//
+type peerAuth struct {
+	sync.RWMutex
+
+	// Uids allowed to connect. Only root is allowed by default.
+	uids map[uint32]struct{}
+
+	// Executables (fully resolved path) allowed to connect. Any executable is
+	// allowed if empty.
+	exes map[string]struct{}
+}
+
+// SO_PEERPIDFD socket option (Linux 6.5+), not yet exported by x/sys.
+const soPeerPidfd = 77
+
+// peerAuthInfo carries the credentials of an authorized ipc peer.
+type peerAuthInfo struct {
+	cred unix.Ucred
+}
+
+func (pai peerAuthInfo) AuthType() string {
+	return "peercred"
+}
+
+func newPeerAuth() *peerAuth {
+	return &peerAuth{
+		uids: map[uint32]struct{}{0: {}},
+	}
+}
+
+func (pa *peerAuth) setPeers(uids []uint32, exes []string) {
+	pa.Lock()
+	defer pa.Unlock()
+
+	pa.uids = make(map[uint32]struct{}, len(uids))
+	for _, uid := range uids {
+		pa.uids[uid] = struct{}{}
+	}
+
+	pa.exes = make(map[string]struct{}, len(exes))
+	for _, exe := range exes {
+		path, err := resolveExe(exe)
+		if err != nil {
+			logger.Warnf("Authorized ipc executable %s could not be resolved: %v",
+				exe, err)
+			path = filepath.Clean(exe)
+		}
+		pa.exes[path] = struct{}{}
+	}
+}
+
+// resolveExe returns the absolute path, free of symlinks, of the given
+// executable. Executables not specified through a path are looked up in $PATH.
+func resolveExe(exe string) (string, error) {
+
+	if !strings.Contains(exe, "/") {
+		path, err := exec.LookPath(exe)
+		if err != nil {
+			return "", err
+		}
+		exe = path
+	}
+
+	path, err := filepath.Abs(exe)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.EvalSymlinks(path)
+}
+
+// authorize verifies that the given peer's uid is allowed to interact with
+// sysbox-fs.
+func (pa *peerAuth) authorize(cred *unix.Ucred) error {
+	pa.RLock()
+	defer pa.RUnlock()
+
+	if _, ok := pa.uids[cred.Uid]; !ok {
+		return fmt.Errorf("uid %d not authorized", cred.Uid)
+	}
+
+	return nil
+}
+
+// authorizeExe verifies that the peer at the other end of the given connection
+// runs one of the authorized executables. Both the executable's path and its
+// file identity (device and inode) must match, so neither a homonymous binary
+// nor one bind-mounted over the authorized path (e.g. within a container's
+// mount-ns) qualify.
+func (pa *peerAuth) authorizeExe(conn net.Conn, cred *unix.Ucred) error {
+	pa.RLock()
+	defer pa.RUnlock()
+
+	if len(pa.exes) == 0 {
+		return nil
+	}
+
+	peer, err := newPeerProc(conn, cred.Pid)
+	if err != nil {
+		return fmt.Errorf("could not identify process of pid %d: %v",
+			cred.Pid, err)
+	}
+	defer peer.close()
+
+	procExe := "/proc/" + strconv.Itoa(int(cred.Pid)) + "/exe"
+
+	exe, err := os.Readlink(procExe)
+	if err != nil {
+		return fmt.Errorf("could not identify executable of pid %d: %v",
+			cred.Pid, err)
+	}
+
+	var exeStat unix.Stat_t
+	if err := unix.Stat(procExe, &exeStat); err != nil {
+		return fmt.Errorf("could not identify executable of pid %d: %v",
+			cred.Pid, err)
+	}
+
+	// The information collected above must belong to the peer, and not to a
+	// process that recycled its pid.
+	if err := peer.verify(); err != nil {
+		return fmt.Errorf("pid %d no longer identifies the peer: %v",
+			cred.Pid, err)
+	}
+
+	if _, ok := pa.exes[exe]; !ok {
+		return fmt.Errorf("executable %s not authorized", exe)
+	}
+
+	var authStat unix.Stat_t
+	if err := unix.Stat(exe, &authStat); err != nil ||
+		authStat.Dev != exeStat.Dev || authStat.Ino != exeStat.Ino {
+		return fmt.Errorf("executable %s not authorized (file mismatch)", exe)
+	}
+
+	return nil
+}
+
+func (pa *peerAuth) ServerHandshake(conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+
+	cred, err := peerCred(conn)
+	if err != nil {
+		logger.Errorf("ipc connection rejected: %v", err)
+		conn.Close()
+		return nil, nil, err
+	}
+
+	err = pa.authorize(cred)
+	if err == nil {
+		err = pa.authorizeExe(conn, cred)
+	}
+	if err != nil {
+		logger.Errorf("ipc connection rejected (pid %d, uid %d, gid %d): %v",
+			cred.Pid, cred.Uid, cred.Gid, err)
+		conn.Close()
+		return nil, nil, err
+	}
+
+	return conn, peerAuthInfo{cred: *cred}, nil
+}
+
+func (pa *peerAuth) ClientHandshake(
+	ctx context.Context,
+	authority string,
+	conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+
+	return nil, nil, errors.New("peercred credentials are server-side only")
+}
+
+func (pa *peerAuth) Info() credentials.ProtocolInfo {
+	return credentials.ProtocolInfo{SecurityProtocol: "peercred"}
+}
+
+func (pa *peerAuth) Clone() credentials.TransportCredentials {
+	return pa
+}
+
+func (pa *peerAuth) OverrideServerName(string) error {
+	return nil
+}
+
+// peerCred obtains the credentials of the process at the other end of a unix
+// socket connection.
+func peerCred(conn net.Conn) (*unix.Ucred, error) {
+
+	uc, ok := conn.(*net.UnixConn)
+	if !ok {
+		return nil, fmt.Errorf("unexpected connection type %T", conn)
+	}
+
+	raw, err := uc.SyscallConn()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		cred    *unix.Ucred
+		credErr error
+	)
+
+	err = raw.Control(func(fd uintptr) {
+		cred, credErr = unix.GetsockoptUcred(int(fd), unix.SOL_SOCKET, unix.SO_PEERCRED)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if credErr != nil {
+		return nil, credErr
+	}
+
+	return cred, nil
+}
+
+//
+// peerProc pins the identity of the process at the other end of a unix socket
+// connection while its attributes are being inspected through procfs, where
+// it's referred to by pid.
+//
+// A pidfd of the peer is obtained straight from the kernel (SO_PEERPIDFD)
+// where supported. Older kernels only provide the peer's pid (SO_PEERCRED),
+// which is just trusted while the connection remains open on the peer's side:
+// a peer gone before (or while) it's inspected could have its pid recycled.
+//
+type peerProc struct {
+	conn   net.Conn
+	pidfd  int  // -1 if pidfds are not supported
+	pinned bool // pidfd obtained through SO_PEERPIDFD
+}
+
+func newPeerProc(conn net.Conn, pid int32) (*peerProc, error) {
+
+	raw, err := conn.(*net.UnixConn).SyscallConn()
+	if err != nil {
+		return nil, err
+	}
+
+	p := &peerProc{conn: conn, pidfd: -1}
+
+	var sockErr error
+	err = raw.Control(func(fd uintptr) {
+		p.pidfd, sockErr = unix.GetsockoptInt(int(fd), unix.SOL_SOCKET, soPeerPidfd)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if sockErr == nil {
+		p.pinned = true
+		return p, nil
+	}
+	if sockErr != unix.ENOPROTOOPT {
+		return nil, sockErr
+	}
+
+	r, _, errno := unix.Syscall(unix.SYS_PIDFD_OPEN, uintptr(pid), 0, 0)
+	switch errno {
+	case 0:
+		p.pidfd = int(r)
+	case unix.ENOSYS:
+		p.pidfd = -1
+	default:
+		return nil, errno
+	}
+
+	return p, p.verify()
+}
+
+// verify checks that the peer is still alive, and thereby, that its pid
+// hasn't been recycled.
+func (p *peerProc) verify() error {
+
+	if p.pidfd >= 0 {
+		_, _, errno := unix.Syscall6(unix.SYS_PIDFD_SEND_SIGNAL,
+			uintptr(p.pidfd), 0, 0, 0, 0, 0)
+		if errno != 0 {
+			return errno
+		}
+		if p.pinned {
+			return nil
+		}
+	}
+
+	raw, err := p.conn.(*net.UnixConn).SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	var hup bool
+	err = raw.Control(func(fd uintptr) {
+		fds := []unix.PollFd{{Fd: int32(fd), Events: unix.POLLRDHUP}}
+		n, err := unix.Poll(fds, 0)
+		hup = err == nil && n > 0 &&
+			fds[0].Revents&(unix.POLLRDHUP|unix.POLLHUP) != 0
+	})
+	if err != nil {
+		return err
+	}
+	if hup {
+		return errors.New("peer disconnected")
+	}
+
+	return nil
+}
+
+func (p *peerProc) close() {
+	if p.pidfd >= 0 {
+		unix.Close(p.pidfd)
+	}
+}
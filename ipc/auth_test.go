@@ -0,0 +1,193 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package ipc
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/sys/unix"
+)
+
+// Returns both ends of a connected unix socket.
+func unixConnPair(t *testing.T) (net.Conn, net.Conn) {
+
+	fds, err := unix.Socketpair(unix.AF_UNIX, unix.SOCK_STREAM, 0)
+	if err != nil {
+		t.Fatalf("socketpair error: %v", err)
+	}
+
+	var conns [2]net.Conn
+	for i, fd := range fds {
+		f := os.NewFile(uintptr(fd), "")
+		conns[i], err = net.FileConn(f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("fileconn error: %v", err)
+		}
+	}
+
+	return conns[0], conns[1]
+}
+
+func Test_peerAuth_ServerHandshake(t *testing.T) {
+
+	uid := uint32(os.Getuid())
+	exe, _ := os.Executable()
+	exe, _ = filepath.EvalSymlinks(exe)
+
+	dir, err := ioutil.TempDir("", "sysbox-fs-auth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Symlink to the peer's executable.
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink(exe, link); err != nil {
+		t.Fatal(err)
+	}
+
+	// Executable homonymous to the peer's one.
+	other := filepath.Join(dir, filepath.Base(exe))
+	if err := ioutil.WriteFile(other, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		uids    []uint32
+		exes    []string
+		wantErr bool
+	}{
+		{
+			// Test-case 1: Peer's uid authorized.
+			name:    "1",
+			uids:    []uint32{uid},
+			wantErr: false,
+		},
+		{
+			// Test-case 2: Peer's uid not authorized.
+			name:    "2",
+			uids:    []uint32{uid + 1},
+			wantErr: true,
+		},
+		{
+			// Test-case 3: Peer's uid and executable authorized.
+			name:    "3",
+			uids:    []uint32{uid},
+			exes:    []string{"/usr/bin/sysbox-runc", exe},
+			wantErr: false,
+		},
+		{
+			// Test-case 4: Peer's executable not authorized.
+			name:    "4",
+			uids:    []uint32{uid},
+			exes:    []string{"/usr/bin/sysbox-runc", "/usr/bin/sysbox-mgr"},
+			wantErr: true,
+		},
+		{
+			// Test-case 5: Peer's executable authorized through a symlink.
+			name:    "5",
+			uids:    []uint32{uid},
+			exes:    []string{link},
+			wantErr: false,
+		},
+		{
+			// Test-case 6: Executable sharing the base name of the peer's
+			// one authorized. Peer must be rejected.
+			name:    "6",
+			uids:    []uint32{uid},
+			exes:    []string{other},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			pa := newPeerAuth()
+			pa.setPeers(tt.uids, tt.exes)
+
+			srv, cli := unixConnPair(t)
+			defer cli.Close()
+
+			conn, info, err := pa.ServerHandshake(srv)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ServerHandshake() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+
+			assert.Equal(t, "peercred", info.AuthType())
+			assert.Equal(t, uid, info.(peerAuthInfo).cred.Uid)
+			assert.Equal(t, int32(os.Getpid()), info.(peerAuthInfo).cred.Pid)
+		})
+	}
+}
+
+func Test_peerAuth_Default(t *testing.T) {
+
+	pa := newPeerAuth()
+
+	// Only root is allowed by default.
+	assert.Nil(t, pa.authorize(&unix.Ucred{Uid: 0}))
+	assert.NotNil(t, pa.authorize(&unix.Ucred{Uid: 1000}))
+}
+
+func Test_peerProc_verify(t *testing.T) {
+
+	cmd := exec.Command("sleep", "30")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, _, errno := unix.Syscall(unix.SYS_PIDFD_OPEN, uintptr(cmd.Process.Pid), 0, 0)
+	if errno != 0 {
+		cmd.Process.Kill()
+		cmd.Wait()
+		t.Skipf("pidfd_open not supported: %v", errno)
+	}
+
+	srv, cli := unixConnPair(t)
+	defer srv.Close()
+
+	p := &peerProc{conn: srv, pidfd: int(r)}
+	defer p.close()
+
+	assert.Nil(t, p.verify())
+
+	// Peer's process gone. Its pid can't be trusted any longer.
+	cmd.Process.Kill()
+	cmd.Wait()
+	assert.NotNil(t, p.verify())
+
+	// Without pidfd support, the peer is trusted while connected.
+	p = &peerProc{conn: srv, pidfd: -1}
+	assert.Nil(t, p.verify())
+
+	cli.Close()
+	assert.NotNil(t, p.verify())
+}
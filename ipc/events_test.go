@@ -0,0 +1,127 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package ipc
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/mocks"
+	grpc "github.com/nestybox/sysbox-ipc/sysboxFsGrpc"
+)
+
+func Test_ipcService_HandleContainerEvent(t *testing.T) {
+
+	type sent struct {
+		socket string
+		msg    grpc.MessageType
+		data   *grpc.ContainerData
+	}
+
+	sentCh := make(chan sent, 1)
+	var sendErr error
+
+	origSendMessage := sendMessage
+	sendMessage = func(
+		socket string,
+		msg grpc.MessageType,
+		data *grpc.ContainerData) (*grpc.ContainerData, error) {
+
+		sentCh <- sent{socket, msg, data}
+		return nil, sendErr
+	}
+	defer func() { sendMessage = origSendMessage }()
+
+	cntr := &mocks.ContainerIface{}
+	cntr.On("ID").Return("c1")
+
+	tests := []struct {
+		name     string
+		ips      *ipcService
+		evType   domain.ContainerEventType
+		sendErr  error
+		wantSent bool
+		wantSock string
+	}{
+		{
+			//
+			// Test-case 1: Reboot events are forwarded to sysbox-mgr's
+			// default socket.
+			//
+			name:     "1",
+			ips:      &ipcService{},
+			evType:   domain.ContainerRebootEvent,
+			wantSent: true,
+			wantSock: DefaultMgrSocketPath,
+		},
+		{
+			//
+			// Test-case 2: Same as above, but with an explicit socket location
+			// and a failing peer (just logged).
+			//
+			name:     "2",
+			ips:      &ipcService{mgrSocket: "/tmp/sysmgr.sock"},
+			evType:   domain.ContainerRebootEvent,
+			sendErr:  errors.New("connection refused"),
+			wantSent: true,
+			wantSock: "/tmp/sysmgr.sock",
+		},
+		{
+			//
+			// Test-case 3: Other lifecycle events are ignored.
+			//
+			name:     "3",
+			ips:      &ipcService{},
+			evType:   domain.ContainerUnregisterEvent,
+			wantSent: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			sendErr = tt.sendErr
+
+			tt.ips.HandleContainerEvent(
+				domain.ContainerEvent{Type: tt.evType, Container: cntr})
+
+			select {
+			case s := <-sentCh:
+				if !tt.wantSent {
+					t.Fatalf("unexpected message sent to %s", s.socket)
+				}
+				if s.socket != tt.wantSock {
+					t.Errorf("socket = %s, want %s", s.socket, tt.wantSock)
+				}
+				if s.msg != grpc.ContainerRebootMessage {
+					t.Errorf("message = %v, want %v",
+						s.msg, grpc.ContainerRebootMessage)
+				}
+				if s.data.Id != "c1" || !s.data.RebootRequested {
+					t.Errorf("unexpected message data %+v", s.data)
+				}
+
+			case <-time.After(100 * time.Millisecond):
+				if tt.wantSent {
+					t.Fatalf("reboot notification not sent")
+				}
+			}
+		})
+	}
+}
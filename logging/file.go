@@ -0,0 +1,154 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package logging
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+//
+// Log file destination supporting size-based rotation and re-opening. The
+// latter allows external tools (e.g. logrotate) to rotate the file without
+// requiring a sysbox-fs restart.
+//
+type File struct {
+	sync.Mutex
+	path       string
+	file       *os.File
+	size       int64 // current file size
+	maxSize    int64 // rotation threshold in bytes (zero disables rotation)
+	maxBackups int   // number of rotated files to keep
+}
+
+//
+// Opens the log file at 'path'. Rotation only applies to regular files, so
+// character devices such as /dev/stdout can be passed too.
+//
+func OpenFile(path string, maxSize int64, maxBackups int) (*File, error) {
+
+	f := &File{
+		path:       path,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+	}
+
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (f *File) open() error {
+
+	file, err := os.OpenFile(
+		f.path,
+		os.O_CREATE|os.O_WRONLY|os.O_APPEND|os.O_SYNC,
+		0666,
+	)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	f.file = file
+	f.size = info.Size()
+
+	// Non-regular files can't be rotated.
+	if !info.Mode().IsRegular() {
+		f.maxSize = 0
+	}
+
+	return nil
+}
+
+func (f *File) Write(p []byte) (int, error) {
+
+	f.Lock()
+	defer f.Unlock()
+
+	if f.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			// Keep logging into the current file rather than losing entries.
+			fmt.Fprintf(os.Stderr, "sysbox-fs: log rotation failed: %v\n", err)
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+
+	return n, err
+}
+
+//
+// Closes and re-opens the log file. Meant to be invoked once the file has
+// been renamed by an external log-rotation tool.
+//
+func (f *File) Reopen() error {
+
+	f.Lock()
+	defer f.Unlock()
+
+	old := f.file
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	return old.Close()
+}
+
+// Shifts the existing backups (path.1 -> path.2, etc), moves the current file
+// to path.1 and starts a new one. Caller must hold the lock.
+func (f *File) rotate() error {
+
+	if f.maxBackups > 0 {
+		for i := f.maxBackups - 1; i > 0; i-- {
+			src := fmt.Sprintf("%s.%d", f.path, i)
+			dst := fmt.Sprintf("%s.%d", f.path, i+1)
+			if err := os.Rename(src, dst); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		if err := os.Rename(f.path, f.path+".1"); err != nil {
+			return err
+		}
+	} else if err := os.Truncate(f.path, 0); err != nil {
+		return err
+	}
+
+	old := f.file
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	return old.Close()
+}
+
+func (f *File) Close() error {
+
+	f.Lock()
+	defer f.Unlock()
+
+	return f.file.Close()
+}
@@ -0,0 +1,133 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+//
+// Package logging provides per-subsystem loggers. Each subsystem logger shares
+// the output and formatter of logrus' standard logger, but carries its own
+// log-level so that a subsystem can be made more (or less) verbose than the
+// rest of sysbox-fs.
+//
+package logging
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Subsystems whose log-level can be individually adjusted.
+const (
+	Fuse     = "fuse"
+	Handlers = "handlers"
+	Nsenter  = "nsenter"
+	Ipc      = "ipc"
+	Tracing  = "tracing"
+)
+
+// Structured field names attached to log entries.
+const (
+	FieldContainerID = "container-id"
+	FieldPid         = "pid"
+	FieldHandler     = "handler"
+	FieldPath        = "path"
+	FieldOp          = "op"
+	FieldLatency     = "latency"
+)
+
+var (
+	mu         sync.Mutex
+	subsystems = make(map[string]*logrus.Logger)
+)
+
+//
+// Returns the logger associated to the given subsystem, creating it if
+// needed. Meant to be invoked during package initialization.
+//
+func Subsystem(name string) *logrus.Logger {
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if l, ok := subsystems[name]; ok {
+		return l
+	}
+
+	l := &logrus.Logger{
+		Out:       stdWriter{},
+		Formatter: stdFormatter{},
+		Hooks:     make(logrus.LevelHooks),
+		Level:     logrus.GetLevel(),
+	}
+	subsystems[name] = l
+
+	return l
+}
+
+// Returns the names of the supported subsystems.
+func Subsystems() []string {
+	return []string{Fuse, Handlers, Ipc, Nsenter, Tracing}
+}
+
+//
+// Sets the log-level of the standard logger and of every subsystem logger.
+// Subsystems present in 'overrides' are set to the given level instead.
+//
+func SetLevels(base logrus.Level, overrides map[string]logrus.Level) error {
+
+	for name := range overrides {
+		if !IsSubsystem(name) {
+			return fmt.Errorf("unknown logging subsystem %q", name)
+		}
+	}
+
+	logrus.SetLevel(base)
+
+	for _, name := range Subsystems() {
+		level, ok := overrides[name]
+		if !ok {
+			level = base
+		}
+		Subsystem(name).SetLevel(level)
+	}
+
+	return nil
+}
+
+// Reports whether 'name' is a supported subsystem.
+func IsSubsystem(name string) bool {
+	for _, s := range Subsystems() {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Forwards the subsystem's output to the one of the standard logger, so that
+// logrus.SetOutput() calls apply to every logger.
+type stdWriter struct{}
+
+func (stdWriter) Write(p []byte) (int, error) {
+	return logrus.StandardLogger().Out.Write(p)
+}
+
+// Same as above but for the log formatter.
+type stdFormatter struct{}
+
+func (stdFormatter) Format(e *logrus.Entry) ([]byte, error) {
+	return logrus.StandardLogger().Formatter.Format(e)
+}
@@ -0,0 +1,121 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetLevels(t *testing.T) {
+
+	defer logrus.SetLevel(logrus.GetLevel())
+
+	tests := []struct {
+		name      string
+		base      logrus.Level
+		overrides map[string]logrus.Level
+		want      map[string]logrus.Level
+		wantErr   bool
+	}{
+		{
+			//
+			// Test-case 1: No overrides, all subsystems inherit base level.
+			//
+			name: "1",
+			base: logrus.WarnLevel,
+			want: map[string]logrus.Level{
+				Fuse:     logrus.WarnLevel,
+				Handlers: logrus.WarnLevel,
+				Ipc:      logrus.WarnLevel,
+				Nsenter:  logrus.WarnLevel,
+				Tracing:  logrus.WarnLevel,
+			},
+		},
+		{
+			//
+			// Test-case 2: Overridden subsystems get their own level.
+			//
+			name:      "2",
+			base:      logrus.InfoLevel,
+			overrides: map[string]logrus.Level{Fuse: logrus.DebugLevel, Ipc: logrus.ErrorLevel},
+			want: map[string]logrus.Level{
+				Fuse:     logrus.DebugLevel,
+				Handlers: logrus.InfoLevel,
+				Ipc:      logrus.ErrorLevel,
+				Nsenter:  logrus.InfoLevel,
+				Tracing:  logrus.InfoLevel,
+			},
+		},
+		{
+			//
+			// Test-case 3: Unknown subsystem.
+			//
+			name:      "3",
+			base:      logrus.InfoLevel,
+			overrides: map[string]logrus.Level{"foo": logrus.DebugLevel},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := SetLevels(tt.base, tt.overrides)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetLevels() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			assert.Equal(t, tt.base, logrus.GetLevel())
+			for name, level := range tt.want {
+				assert.Equal(t, level, Subsystem(name).GetLevel(), name)
+			}
+		})
+	}
+}
+
+func TestSubsystemOutput(t *testing.T) {
+
+	std := logrus.StandardLogger()
+	out, formatter := std.Out, std.Formatter
+	defer func() {
+		logrus.SetOutput(out)
+		logrus.SetFormatter(formatter)
+	}()
+
+	// Subsystem loggers must honor the standard logger's settings, including
+	// those made after their creation.
+	var buf bytes.Buffer
+	logrus.SetOutput(&buf)
+	logrus.SetFormatter(&logrus.JSONFormatter{})
+
+	l := Subsystem(Fuse)
+	l.SetLevel(logrus.InfoLevel)
+	l.WithField(FieldPath, "/proc/uptime").Info("test")
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("invalid json output %q: %v", buf.String(), err)
+	}
+	assert.Equal(t, "/proc/uptime", entry[FieldPath])
+	assert.Equal(t, "test", entry["msg"])
+}
@@ -0,0 +1,44 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package logging
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+//
+// Slow-operation reporting. Handler executions and nsenter requests lasting
+// longer than the configured threshold are logged as warnings regardless of
+// the subsystem's log-level, so that latency regressions show up without
+// having to enable debug logging.
+//
+
+// Threshold in nanoseconds; zero disables the reporting.
+var slowThreshold int64
+
+// Sets the duration above which operations are reported as slow. Can be
+// invoked at any time (e.g. upon config reload).
+func SetSlowThreshold(d time.Duration) {
+	atomic.StoreInt64(&slowThreshold, int64(d))
+}
+
+// Reports whether an operation that lasted 'd' is to be logged as slow.
+func IsSlow(d time.Duration) bool {
+	t := atomic.LoadInt64(&slowThreshold)
+	return t > 0 && int64(d) >= t
+}
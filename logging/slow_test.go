@@ -0,0 +1,40 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package logging
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsSlow(t *testing.T) {
+
+	defer SetSlowThreshold(0)
+
+	// Disabled by default.
+	assert.False(t, IsSlow(time.Hour))
+
+	SetSlowThreshold(100 * time.Millisecond)
+	assert.False(t, IsSlow(99*time.Millisecond))
+	assert.True(t, IsSlow(100*time.Millisecond))
+	assert.True(t, IsSlow(time.Second))
+
+	SetSlowThreshold(0)
+	assert.False(t, IsSlow(time.Second))
+}
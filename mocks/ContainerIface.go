@@ -29,6 +29,41 @@ type ContainerIface struct {
 	mock.Mock
 }
 
+// AccountOp provides a mock function with given fields: op, failed
+func (_m *ContainerIface) AccountOp(op domain.FuseOp, failed bool) {
+	_m.Called(op, failed)
+}
+
+// CgroupPaths provides a mock function with given fields:
+func (_m *ContainerIface) CgroupPaths() domain.CgroupPaths {
+	ret := _m.Called()
+
+	var r0 domain.CgroupPaths
+	if rf, ok := ret.Get(0).(func() domain.CgroupPaths); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(domain.CgroupPaths)
+	}
+
+	return r0
+}
+
+// Children provides a mock function with given fields:
+func (_m *ContainerIface) Children() []domain.ContainerIface {
+	ret := _m.Called()
+
+	var r0 []domain.ContainerIface
+	if rf, ok := ret.Get(0).(func() []domain.ContainerIface); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.ContainerIface)
+		}
+	}
+
+	return r0
+}
+
 // Ctime provides a mock function with given fields:
 func (_m *ContainerIface) Ctime() time.Time {
 	ret := _m.Called()
@@ -64,6 +99,41 @@ func (_m *ContainerIface) Data(path string, name string) (string, bool) {
 	return r0, r1
 }
 
+// DataSize provides a mock function with given fields:
+func (_m *ContainerIface) DataSize() int {
+	ret := _m.Called()
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func() int); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	return r0
+}
+
+// DataValue provides a mock function with given fields: path, name
+func (_m *ContainerIface) DataValue(path string, name string) (domain.StateValue, bool) {
+	ret := _m.Called(path, name)
+
+	var r0 domain.StateValue
+	if rf, ok := ret.Get(0).(func(string, string) domain.StateValue); ok {
+		r0 = rf(path, name)
+	} else {
+		r0 = ret.Get(0).(domain.StateValue)
+	}
+
+	var r1 bool
+	if rf, ok := ret.Get(1).(func(string, string) bool); ok {
+		r1 = rf(path, name)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	return r0, r1
+}
+
 // GID provides a mock function with given fields:
 func (_m *ContainerIface) GID() uint32 {
 	ret := _m.Called()
@@ -78,6 +148,36 @@ func (_m *ContainerIface) GID() uint32 {
 	return r0
 }
 
+// GidMappings provides a mock function with given fields:
+func (_m *ContainerIface) GidMappings() []domain.IDMapping {
+	ret := _m.Called()
+
+	var r0 []domain.IDMapping
+	if rf, ok := ret.Get(0).(func() []domain.IDMapping); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.IDMapping)
+		}
+	}
+
+	return r0
+}
+
+// Hostname provides a mock function with given fields:
+func (_m *ContainerIface) Hostname() string {
+	ret := _m.Called()
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
 // ID provides a mock function with given fields:
 func (_m *ContainerIface) ID() string {
 	ret := _m.Called()
@@ -136,6 +236,120 @@ func (_m *ContainerIface) IsSpecPath(s string) bool {
 	return r0
 }
 
+// Level provides a mock function with given fields:
+func (_m *ContainerIface) Level() uint {
+	ret := _m.Called()
+
+	var r0 uint
+	if rf, ok := ret.Get(0).(func() uint); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(uint)
+	}
+
+	return r0
+}
+
+// Limits provides a mock function with given fields:
+func (_m *ContainerIface) Limits() domain.ResourceLimits {
+	ret := _m.Called()
+
+	var r0 domain.ResourceLimits
+	if rf, ok := ret.Get(0).(func() domain.ResourceLimits); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(domain.ResourceLimits)
+	}
+
+	return r0
+}
+
+// MountOptions provides a mock function with given fields:
+func (_m *ContainerIface) MountOptions() domain.MountOptions {
+	ret := _m.Called()
+
+	var r0 domain.MountOptions
+	if rf, ok := ret.Get(0).(func() domain.MountOptions); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(domain.MountOptions)
+	}
+
+	return r0
+}
+
+// OpStats provides a mock function with given fields:
+func (_m *ContainerIface) OpStats() domain.ContainerOpStats {
+	ret := _m.Called()
+
+	var r0 domain.ContainerOpStats
+	if rf, ok := ret.Get(0).(func() domain.ContainerOpStats); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(domain.ContainerOpStats)
+	}
+
+	return r0
+}
+
+// Override provides a mock function with given fields: path
+func (_m *ContainerIface) Override(path string) (domain.NodeOverride, bool) {
+	ret := _m.Called(path)
+
+	var r0 domain.NodeOverride
+	if rf, ok := ret.Get(0).(func(string) domain.NodeOverride); ok {
+		r0 = rf(path)
+	} else {
+		r0 = ret.Get(0).(domain.NodeOverride)
+	}
+
+	var r1 bool
+	if rf, ok := ret.Get(1).(func(string) bool); ok {
+		r1 = rf(path)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	return r0, r1
+}
+
+// Parent provides a mock function with given fields:
+func (_m *ContainerIface) Parent() domain.ContainerIface {
+	ret := _m.Called()
+
+	var r0 domain.ContainerIface
+	if rf, ok := ret.Get(0).(func() domain.ContainerIface); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(domain.ContainerIface)
+		}
+	}
+
+	return r0
+}
+
+// Policy provides a mock function with given fields: path
+func (_m *ContainerIface) Policy(path string) (domain.ValuePolicy, bool) {
+	ret := _m.Called(path)
+
+	var r0 domain.ValuePolicy
+	if rf, ok := ret.Get(0).(func(string) domain.ValuePolicy); ok {
+		r0 = rf(path)
+	} else {
+		r0 = ret.Get(0).(domain.ValuePolicy)
+	}
+
+	var r1 bool
+	if rf, ok := ret.Get(1).(func(string) bool); ok {
+		r1 = rf(path)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	return r0, r1
+}
+
 // ProcMaskPaths provides a mock function with given fields:
 func (_m *ContainerIface) ProcMaskPaths() []string {
 	ret := _m.Called()
@@ -168,11 +382,46 @@ func (_m *ContainerIface) ProcRoPaths() []string {
 	return r0
 }
 
+// RebootRequested provides a mock function with given fields:
+func (_m *ContainerIface) RebootRequested() bool {
+	ret := _m.Called()
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func() bool); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
 // SetData provides a mock function with given fields: path, name, data
 func (_m *ContainerIface) SetData(path string, name string, data string) {
 	_m.Called(path, name, data)
 }
 
+// SetDataValue provides a mock function with given fields: path, name, val, version
+func (_m *ContainerIface) SetDataValue(path string, name string, val interface{}, version uint64) (uint64, error) {
+	ret := _m.Called(path, name, val, version)
+
+	var r0 uint64
+	if rf, ok := ret.Get(0).(func(string, string, interface{}, uint64) uint64); ok {
+		r0 = rf(path, name, val, version)
+	} else {
+		r0 = ret.Get(0).(uint64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, interface{}, uint64) error); ok {
+		r1 = rf(path, name, val, version)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // SetInitProc provides a mock function with given fields: pid, uid, gid
 func (_m *ContainerIface) SetInitProc(pid uint32, uid uint32, gid uint32) error {
 	ret := _m.Called(pid, uid, gid)
@@ -187,6 +436,26 @@ func (_m *ContainerIface) SetInitProc(pid uint32, uid uint32, gid uint32) error
 	return r0
 }
 
+// SetMountOptions provides a mock function with given fields: o
+func (_m *ContainerIface) SetMountOptions(o domain.MountOptions) {
+	_m.Called(o)
+}
+
+// SetOverrides provides a mock function with given fields: o
+func (_m *ContainerIface) SetOverrides(o map[string]domain.NodeOverride) {
+	_m.Called(o)
+}
+
+// SetPolicies provides a mock function with given fields: p
+func (_m *ContainerIface) SetPolicies(p map[string]domain.ValuePolicy) {
+	_m.Called(p)
+}
+
+// SetRebootRequested provides a mock function with given fields:
+func (_m *ContainerIface) SetRebootRequested() {
+	_m.Called()
+}
+
 // SetService provides a mock function with given fields: css
 func (_m *ContainerIface) SetService(css domain.ContainerStateServiceIface) {
 	_m.Called(css)
@@ -219,3 +488,19 @@ func (_m *ContainerIface) UID() uint32 {
 
 	return r0
 }
+
+// UidMappings provides a mock function with given fields:
+func (_m *ContainerIface) UidMappings() []domain.IDMapping {
+	ret := _m.Called()
+
+	var r0 []domain.IDMapping
+	if rf, ok := ret.Get(0).(func() []domain.IDMapping); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.IDMapping)
+		}
+	}
+
+	return r0
+}
@@ -29,13 +29,13 @@ type ContainerStateServiceIface struct {
 	mock.Mock
 }
 
-// ContainerCreate provides a mock function with given fields: id, pid, ctime, uidFirst, uidSize, gidFirst, gidSize, procRoPaths, procMaskPaths
-func (_m *ContainerStateServiceIface) ContainerCreate(id string, pid uint32, ctime time.Time, uidFirst uint32, uidSize uint32, gidFirst uint32, gidSize uint32, procRoPaths []string, procMaskPaths []string) domain.ContainerIface {
-	ret := _m.Called(id, pid, ctime, uidFirst, uidSize, gidFirst, gidSize, procRoPaths, procMaskPaths)
+// ContainerCreate provides a mock function with given fields: id, pid, ctime, uidFirst, uidSize, gidFirst, gidSize, procRoPaths, procMaskPaths, uidMappings, gidMappings, cgroupPaths, hostname, limits
+func (_m *ContainerStateServiceIface) ContainerCreate(id string, pid uint32, ctime time.Time, uidFirst uint32, uidSize uint32, gidFirst uint32, gidSize uint32, procRoPaths []string, procMaskPaths []string, uidMappings []domain.IDMapping, gidMappings []domain.IDMapping, cgroupPaths domain.CgroupPaths, hostname string, limits domain.ResourceLimits) domain.ContainerIface {
+	ret := _m.Called(id, pid, ctime, uidFirst, uidSize, gidFirst, gidSize, procRoPaths, procMaskPaths, uidMappings, gidMappings, cgroupPaths, hostname, limits)
 
 	var r0 domain.ContainerIface
-	if rf, ok := ret.Get(0).(func(string, uint32, time.Time, uint32, uint32, uint32, uint32, []string, []string) domain.ContainerIface); ok {
-		r0 = rf(id, pid, ctime, uidFirst, uidSize, gidFirst, gidSize, procRoPaths, procMaskPaths)
+	if rf, ok := ret.Get(0).(func(string, uint32, time.Time, uint32, uint32, uint32, uint32, []string, []string, []domain.IDMapping, []domain.IDMapping, domain.CgroupPaths, string, domain.ResourceLimits) domain.ContainerIface); ok {
+		r0 = rf(id, pid, ctime, uidFirst, uidSize, gidFirst, gidSize, procRoPaths, procMaskPaths, uidMappings, gidMappings, cgroupPaths, hostname, limits)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(domain.ContainerIface)
@@ -45,6 +45,92 @@ func (_m *ContainerStateServiceIface) ContainerCreate(id string, pid uint32, cti
 	return r0
 }
 
+// ContainerDBCheckpoint provides a mock function with given fields:
+func (_m *ContainerStateServiceIface) ContainerDBCheckpoint() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ContainerDBDrain provides a mock function with given fields:
+func (_m *ContainerStateServiceIface) ContainerDBDrain() (int, error) {
+	ret := _m.Called()
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func() int); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ContainerDBExport provides a mock function with given fields:
+func (_m *ContainerStateServiceIface) ContainerDBExport() ([]byte, error) {
+	ret := _m.Called()
+
+	var r0 []byte
+	if rf, ok := ret.Get(0).(func() []byte); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ContainerDBImport provides a mock function with given fields: buf
+func (_m *ContainerStateServiceIface) ContainerDBImport(buf []byte) error {
+	ret := _m.Called(buf)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func([]byte) error); ok {
+		r0 = rf(buf)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ContainerDBRestore provides a mock function with given fields:
+func (_m *ContainerStateServiceIface) ContainerDBRestore() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // ContainerDBSize provides a mock function with given fields:
 func (_m *ContainerStateServiceIface) ContainerDBSize() int {
 	ret := _m.Called()
@@ -59,6 +145,27 @@ func (_m *ContainerStateServiceIface) ContainerDBSize() int {
 	return r0
 }
 
+// ContainerDBUndrain provides a mock function with given fields:
+func (_m *ContainerStateServiceIface) ContainerDBUndrain() {
+	_m.Called()
+}
+
+// ContainerList provides a mock function with given fields:
+func (_m *ContainerStateServiceIface) ContainerList() []domain.ContainerIface {
+	ret := _m.Called()
+
+	var r0 []domain.ContainerIface
+	if rf, ok := ret.Get(0).(func() []domain.ContainerIface); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.ContainerIface)
+		}
+	}
+
+	return r0
+}
+
 // ContainerLookupById provides a mock function with given fields: id
 func (_m *ContainerStateServiceIface) ContainerLookupById(id string) domain.ContainerIface {
 	ret := _m.Called(id)
@@ -91,6 +198,22 @@ func (_m *ContainerStateServiceIface) ContainerLookupByInode(usernsInode uint64)
 	return r0
 }
 
+// ContainerLookupByPid provides a mock function with given fields: pid
+func (_m *ContainerStateServiceIface) ContainerLookupByPid(pid uint32) domain.ContainerIface {
+	ret := _m.Called(pid)
+
+	var r0 domain.ContainerIface
+	if rf, ok := ret.Get(0).(func(uint32) domain.ContainerIface); ok {
+		r0 = rf(pid)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(domain.ContainerIface)
+		}
+	}
+
+	return r0
+}
+
 // ContainerLookupByProcess provides a mock function with given fields: process
 func (_m *ContainerStateServiceIface) ContainerLookupByProcess(process domain.ProcessIface) domain.ContainerIface {
 	ret := _m.Called(process)
@@ -107,13 +230,13 @@ func (_m *ContainerStateServiceIface) ContainerLookupByProcess(process domain.Pr
 	return r0
 }
 
-// ContainerPreRegister provides a mock function with given fields: id
-func (_m *ContainerStateServiceIface) ContainerPreRegister(id string) error {
-	ret := _m.Called(id)
+// ContainerPreRegister provides a mock function with given fields: id, c
+func (_m *ContainerStateServiceIface) ContainerPreRegister(id string, c domain.ContainerIface) error {
+	ret := _m.Called(id, c)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string) error); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(string, domain.ContainerIface) error); ok {
+		r0 = rf(id, c)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -135,6 +258,43 @@ func (_m *ContainerStateServiceIface) ContainerRegister(c domain.ContainerIface)
 	return r0
 }
 
+// ContainerStateExport provides a mock function with given fields: id
+func (_m *ContainerStateServiceIface) ContainerStateExport(id string) ([]byte, error) {
+	ret := _m.Called(id)
+
+	var r0 []byte
+	if rf, ok := ret.Get(0).(func(string) []byte); ok {
+		r0 = rf(id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ContainerStateImport provides a mock function with given fields: id, state
+func (_m *ContainerStateServiceIface) ContainerStateImport(id string, state []byte) error {
+	ret := _m.Called(id, state)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, []byte) error); ok {
+		r0 = rf(id, state)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // ContainerUnregister provides a mock function with given fields: c
 func (_m *ContainerStateServiceIface) ContainerUnregister(c domain.ContainerIface) error {
 	ret := _m.Called(c)
@@ -195,7 +355,41 @@ func (_m *ContainerStateServiceIface) ProcessService() domain.ProcessServiceIfac
 	return r0
 }
 
-// Setup provides a mock function with given fields: fss, prs, ios
-func (_m *ContainerStateServiceIface) Setup(fss domain.FuseServerServiceIface, prs domain.ProcessServiceIface, ios domain.IOServiceIface) {
-	_m.Called(fss, prs, ios)
+// ReaperStart provides a mock function with given fields: interval
+func (_m *ContainerStateServiceIface) ReaperStart(interval time.Duration) {
+	_m.Called(interval)
+}
+
+// ReaperStop provides a mock function with given fields:
+func (_m *ContainerStateServiceIface) ReaperStop() {
+	_m.Called()
+}
+
+// Setup provides a mock function with given fields: fss, prs, ios, stateDir
+func (_m *ContainerStateServiceIface) Setup(fss domain.FuseServerServiceIface, prs domain.ProcessServiceIface, ios domain.IOServiceIface, stateDir string) {
+	_m.Called(fss, prs, ios, stateDir)
+}
+
+// Stop provides a mock function with given fields:
+func (_m *ContainerStateServiceIface) Stop() {
+	_m.Called()
+}
+
+// Subscribe provides a mock function with given fields: h
+func (_m *ContainerStateServiceIface) Subscribe(h domain.ContainerEventHandler) int {
+	ret := _m.Called(h)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(domain.ContainerEventHandler) int); ok {
+		r0 = rf(h)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	return r0
+}
+
+// Unsubscribe provides a mock function with given fields: id
+func (_m *ContainerStateServiceIface) Unsubscribe(id int) {
+	_m.Called(id)
 }
@@ -52,6 +52,22 @@ func (_m *FuseServerIface) Destroy() error {
 	return r0
 }
 
+// InvalidateCache provides a mock function with given fields:
+func (_m *FuseServerIface) InvalidateCache() {
+	_m.Called()
+}
+
+// InvalidateNodes provides a mock function with given fields: paths
+func (_m *FuseServerIface) InvalidateNodes(paths ...string) {
+	_va := make([]interface{}, len(paths))
+	for _i := range paths {
+		_va[_i] = paths[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, _va...)
+	_m.Called(_ca...)
+}
+
 // MountPoint provides a mock function with given fields:
 func (_m *FuseServerIface) MountPoint() string {
 	ret := _m.Called()
@@ -66,6 +82,11 @@ func (_m *FuseServerIface) MountPoint() string {
 	return r0
 }
 
+// NotifyChange provides a mock function with given fields: path
+func (_m *FuseServerIface) NotifyChange(path string) {
+	_m.Called(path)
+}
+
 // Run provides a mock function with given fields:
 func (_m *FuseServerIface) Run() error {
 	ret := _m.Called()
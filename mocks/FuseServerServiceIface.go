@@ -20,6 +20,8 @@ package mocks
 import (
 	domain "github.com/nestybox/sysbox-fs/domain"
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // FuseServerServiceIface is an autogenerated mock type for the FuseServerServiceIface type
@@ -27,6 +29,11 @@ type FuseServerServiceIface struct {
 	mock.Mock
 }
 
+// AbortFuseService provides a mock function with given fields:
+func (_m *FuseServerServiceIface) AbortFuseService() {
+	_m.Called()
+}
+
 // CreateFuseServer provides a mock function with given fields: cntr
 func (_m *FuseServerServiceIface) CreateFuseServer(cntr domain.ContainerIface) error {
 	ret := _m.Called(cntr)
@@ -60,7 +67,114 @@ func (_m *FuseServerServiceIface) DestroyFuseService() {
 	_m.Called()
 }
 
+// DiscardFuseConns provides a mock function with given fields:
+func (_m *FuseServerServiceIface) DiscardFuseConns() {
+	_m.Called()
+}
+
+// ExportFuseConns provides a mock function with given fields:
+func (_m *FuseServerServiceIface) ExportFuseConns() []domain.FuseConnState {
+	ret := _m.Called()
+
+	var r0 []domain.FuseConnState
+	if rf, ok := ret.Get(0).(func() []domain.FuseConnState); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.FuseConnState)
+		}
+	}
+
+	return r0
+}
+
+// HealthCheck provides a mock function with given fields: timeout
+func (_m *FuseServerServiceIface) HealthCheck(timeout time.Duration) error {
+	ret := _m.Called(timeout)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(time.Duration) error); ok {
+		r0 = rf(timeout)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ImportFuseConns provides a mock function with given fields: conns
+func (_m *FuseServerServiceIface) ImportFuseConns(conns []domain.FuseConnState) error {
+	ret := _m.Called(conns)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func([]domain.FuseConnState) error); ok {
+		r0 = rf(conns)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// InvalidateCache provides a mock function with given fields: cntrId
+func (_m *FuseServerServiceIface) InvalidateCache(cntrId string) error {
+	ret := _m.Called(cntrId)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(cntrId)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// InvalidateNodes provides a mock function with given fields: cntrId, paths
+func (_m *FuseServerServiceIface) InvalidateNodes(cntrId string, paths []string) error {
+	ret := _m.Called(cntrId, paths)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, []string) error); ok {
+		r0 = rf(cntrId, paths)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NotifyChange provides a mock function with given fields: cntrId, path
+func (_m *FuseServerServiceIface) NotifyChange(cntrId string, path string) error {
+	ret := _m.Called(cntrId, path)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(cntrId, path)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Setup provides a mock function with given fields: mp, css, ios, hds
 func (_m *FuseServerServiceIface) Setup(mp string, css domain.ContainerStateServiceIface, ios domain.IOServiceIface, hds domain.HandlerServiceIface) {
 	_m.Called(mp, css, ios, hds)
 }
+
+// Usage provides a mock function with given fields:
+func (_m *FuseServerServiceIface) Usage() []domain.FuseServerUsage {
+	ret := _m.Called()
+
+	var r0 []domain.FuseServerUsage
+	if rf, ok := ret.Get(0).(func() []domain.FuseServerUsage); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.FuseServerUsage)
+		}
+	}
+
+	return r0
+}
@@ -131,6 +131,22 @@ func (_m *HandlerServiceIface) HandlerDB() map[string]domain.HandlerIface {
 	return r0
 }
 
+// Handlers provides a mock function with given fields:
+func (_m *HandlerServiceIface) Handlers() []domain.HandlerIface {
+	ret := _m.Called()
+
+	var r0 []domain.HandlerIface
+	if rf, ok := ret.Get(0).(func() []domain.HandlerIface); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.HandlerIface)
+		}
+	}
+
+	return r0
+}
+
 // HostUserNsInode provides a mock function with given fields:
 func (_m *HandlerServiceIface) HostUserNsInode() uint64 {
 	ret := _m.Called()
@@ -145,6 +161,20 @@ func (_m *HandlerServiceIface) HostUserNsInode() uint64 {
 	return r0
 }
 
+// Invoke provides a mock function with given fields: c
+func (_m *HandlerServiceIface) Invoke(c *domain.HandlerCall) error {
+	ret := _m.Called(c)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*domain.HandlerCall) error); ok {
+		r0 = rf(c)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // IOService provides a mock function with given fields:
 func (_m *HandlerServiceIface) IOService() domain.IOServiceIface {
 	ret := _m.Called()
@@ -244,6 +274,11 @@ func (_m *HandlerServiceIface) RegisterHandler(h domain.HandlerIface) error {
 	return r0
 }
 
+// SetDefaultValues provides a mock function with given fields: vals
+func (_m *HandlerServiceIface) SetDefaultValues(vals map[string]string) {
+	_m.Called(vals)
+}
+
 // SetStateService provides a mock function with given fields: css
 func (_m *HandlerServiceIface) SetStateService(css domain.ContainerStateServiceIface) {
 	_m.Called(css)
@@ -254,6 +289,20 @@ func (_m *HandlerServiceIface) Setup(hdlrs []domain.HandlerIface, ignoreErrors b
 	_m.Called(hdlrs, ignoreErrors, css, nss, prs, ios)
 }
 
+// Start provides a mock function with given fields:
+func (_m *HandlerServiceIface) Start() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // StateService provides a mock function with given fields:
 func (_m *HandlerServiceIface) StateService() domain.ContainerStateServiceIface {
 	ret := _m.Called()
@@ -270,6 +319,16 @@ func (_m *HandlerServiceIface) StateService() domain.ContainerStateServiceIface
 	return r0
 }
 
+// Stop provides a mock function with given fields:
+func (_m *HandlerServiceIface) Stop() {
+	_m.Called()
+}
+
+// SyncHandlers provides a mock function with given fields: hdlrs
+func (_m *HandlerServiceIface) SyncHandlers(hdlrs []domain.HandlerIface) {
+	_m.Called(hdlrs)
+}
+
 // UnregisterHandler provides a mock function with given fields: h
 func (_m *HandlerServiceIface) UnregisterHandler(h domain.HandlerIface) error {
 	ret := _m.Called(h)
@@ -283,3 +342,28 @@ func (_m *HandlerServiceIface) UnregisterHandler(h domain.HandlerIface) error {
 
 	return r0
 }
+
+// Use provides a mock function with given fields: mws
+func (_m *HandlerServiceIface) Use(mws ...domain.HandlerMiddleware) {
+	_va := make([]interface{}, len(mws))
+	for _i := range mws {
+		_va[_i] = mws[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, _va...)
+	_m.Called(_ca...)
+}
+
+// WriteAllowed provides a mock function with given fields: h, req
+func (_m *HandlerServiceIface) WriteAllowed(h domain.HandlerIface, req *domain.HandlerRequest) bool {
+	ret := _m.Called(h, req)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(domain.HandlerIface, *domain.HandlerRequest) bool); ok {
+		r0 = rf(h, req)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
@@ -224,6 +224,27 @@ func (_m *IOServiceIface) RemoveAllIOnodes() error {
 	return r0
 }
 
+// SeekNode provides a mock function with given fields: i, off, whence
+func (_m *IOServiceIface) SeekNode(i domain.IOnodeIface, off int64, whence int) (int64, error) {
+	ret := _m.Called(i, off, whence)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(domain.IOnodeIface, int64, int) int64); ok {
+		r0 = rf(i, off, whence)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(domain.IOnodeIface, int64, int) error); ok {
+		r1 = rf(i, off, whence)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // SeekResetNode provides a mock function with given fields: i
 func (_m *IOServiceIface) SeekResetNode(i domain.IOnodeIface) (int64, error) {
 	ret := _m.Called(i)
@@ -245,6 +266,29 @@ func (_m *IOServiceIface) SeekResetNode(i domain.IOnodeIface) (int64, error) {
 	return r0, r1
 }
 
+// StatAllNode provides a mock function with given fields: i, names
+func (_m *IOServiceIface) StatAllNode(i domain.IOnodeIface, names []string) ([]os.FileInfo, error) {
+	ret := _m.Called(i, names)
+
+	var r0 []os.FileInfo
+	if rf, ok := ret.Get(0).(func(domain.IOnodeIface, []string) []os.FileInfo); ok {
+		r0 = rf(i, names)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]os.FileInfo)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(domain.IOnodeIface, []string) error); ok {
+		r1 = rf(i, names)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // StatNode provides a mock function with given fields: i
 func (_m *IOServiceIface) StatNode(i domain.IOnodeIface) (os.FileInfo, error) {
 	ret := _m.Called(i)
@@ -268,6 +312,27 @@ func (_m *IOServiceIface) StatNode(i domain.IOnodeIface) (os.FileInfo, error) {
 	return r0, r1
 }
 
+// WriteAtNode provides a mock function with given fields: i, p, off
+func (_m *IOServiceIface) WriteAtNode(i domain.IOnodeIface, p []byte, off int64) (int, error) {
+	ret := _m.Called(i, p, off)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(domain.IOnodeIface, []byte, int64) int); ok {
+		r0 = rf(i, p, off)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(domain.IOnodeIface, []byte, int64) error); ok {
+		r1 = rf(i, p, off)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // WriteNode provides a mock function with given fields: i, p
 func (_m *IOServiceIface) WriteNode(i domain.IOnodeIface, p []byte) (int, error) {
 	ret := _m.Called(i, p)
@@ -270,6 +270,69 @@ func (_m *IOnodeIface) ReadLine() (string, error) {
 	return r0, r1
 }
 
+// Remove provides a mock function with given fields:
+func (_m *IOnodeIface) Remove() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RemoveAll provides a mock function with given fields:
+func (_m *IOnodeIface) RemoveAll() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Rename provides a mock function with given fields: newpath
+func (_m *IOnodeIface) Rename(newpath string) error {
+	ret := _m.Called(newpath)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(newpath)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Seek provides a mock function with given fields: off, whence
+func (_m *IOnodeIface) Seek(off int64, whence int) (int64, error) {
+	ret := _m.Called(off, whence)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(int64, int) int64); ok {
+		r0 = rf(off, whence)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int64, int) error); ok {
+		r1 = rf(off, whence)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // SeekReset provides a mock function with given fields:
 func (_m *IOnodeIface) SeekReset() (int64, error) {
 	ret := _m.Called()
@@ -324,6 +387,29 @@ func (_m *IOnodeIface) Stat() (os.FileInfo, error) {
 	return r0, r1
 }
 
+// StatAll provides a mock function with given fields: names
+func (_m *IOnodeIface) StatAll(names []string) ([]os.FileInfo, error) {
+	ret := _m.Called(names)
+
+	var r0 []os.FileInfo
+	if rf, ok := ret.Get(0).(func([]string) []os.FileInfo); ok {
+		r0 = rf(names)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]os.FileInfo)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]string) error); ok {
+		r1 = rf(names)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Write provides a mock function with given fields: p
 func (_m *IOnodeIface) Write(p []byte) (int, error) {
 	ret := _m.Called(p)
@@ -345,6 +431,27 @@ func (_m *IOnodeIface) Write(p []byte) (int, error) {
 	return r0, r1
 }
 
+// WriteAt provides a mock function with given fields: p, off
+func (_m *IOnodeIface) WriteAt(p []byte, off int64) (int, error) {
+	ret := _m.Called(p, off)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func([]byte, int64) int); ok {
+		r0 = rf(p, off)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]byte, int64) error); ok {
+		r1 = rf(p, off)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // WriteFile provides a mock function with given fields: p
 func (_m *IOnodeIface) WriteFile(p []byte) error {
 	ret := _m.Called(p)
@@ -18,6 +18,8 @@
 package mocks
 
 import (
+	context "context"
+
 	domain "github.com/nestybox/sysbox-fs/domain"
 	mock "github.com/stretchr/testify/mock"
 )
@@ -75,13 +77,13 @@ func (_m *NSenterEventIface) ReceiveResponse() *domain.NSenterMessage {
 	return r0
 }
 
-// SendRequest provides a mock function with given fields:
-func (_m *NSenterEventIface) SendRequest() error {
-	ret := _m.Called()
+// SendRequest provides a mock function with given fields: ctx
+func (_m *NSenterEventIface) SendRequest(ctx context.Context) error {
+	ret := _m.Called(ctx)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func() error); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -3,6 +3,8 @@
 package mocks
 
 import (
+	context "context"
+
 	domain "github.com/nestybox/sysbox-fs/domain"
 	mock "github.com/stretchr/testify/mock"
 )
@@ -44,13 +46,13 @@ func (_m *NSenterServiceIface) ReceiveResponseEvent(e domain.NSenterEventIface)
 	return r0
 }
 
-// SendRequestEvent provides a mock function with given fields: e
-func (_m *NSenterServiceIface) SendRequestEvent(e domain.NSenterEventIface) error {
-	ret := _m.Called(e)
+// SendRequestEvent provides a mock function with given fields: ctx, e
+func (_m *NSenterServiceIface) SendRequestEvent(ctx context.Context, e domain.NSenterEventIface) error {
+	ret := _m.Called(ctx, e)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(domain.NSenterEventIface) error); ok {
-		r0 = rf(e)
+	if rf, ok := ret.Get(0).(func(context.Context, domain.NSenterEventIface) error); ok {
+		r0 = rf(ctx, e)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -58,6 +60,11 @@ func (_m *NSenterServiceIface) SendRequestEvent(e domain.NSenterEventIface) erro
 	return r0
 }
 
+// SetConcurrencyLimits provides a mock function with given fields: total, perContainer
+func (_m *NSenterServiceIface) SetConcurrencyLimits(total int, perContainer int) {
+	_m.Called(total, perContainer)
+}
+
 // Setup provides a mock function with given fields: prs
 func (_m *NSenterServiceIface) Setup(prs domain.ProcessServiceIface) {
 	_m.Called(prs)
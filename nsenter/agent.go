@@ -0,0 +1,394 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package nsenter
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/nestybox/sysbox-fs/domain"
+)
+
+// Env variable instructing the nsenter child to operate as an agent.
+const nsenterAgentEnv = "_SYSBOX_NSENTER_AGENT"
+
+// Agents not utilized during this period are terminated.
+const agentIdleTimeout = 30 * time.Second
+
+// Maximum number of live agents per container. Launching agents beyond this
+// limit causes the least recently used ones of the container to be terminated.
+const agentsPerContainer = 8
+
+// Request types that can be served by agents. Requests that alter the nsenter
+// process itself (e.g. mount requests adjust its personality) must be served
+// by a dedicated process.
+func agentRequest(t domain.NSenterMsgType) bool {
+
+	switch t {
+	case domain.LookupRequest,
+		domain.OpenFileRequest,
+		domain.ReadFileRequest,
+		domain.WriteFileRequest,
+		domain.ReadDirRequest,
+		domain.StatRequest,
+		domain.ReadlinkRequest,
+		domain.MkdirRequest,
+		domain.ChownRequest,
+		domain.CgroupRequest,
+		domain.NetDevRequest,
+		domain.BatchRequest:
+		return true
+	}
+
+	return false
+}
+
+//
+// nsenterAgent represents a long-lived sysbox-fs child process that has joined
+// a given set of container namespaces, and that serves requests over a pipe.
+// This way, the fork + setns + exec costs are only paid once, instead of once
+// per request.
+//
+// Agents are shared by all the processes living in the joined namespaces, so
+// the number of agents of a container is bound to the number of namespace
+// sets (e.g. inner containers) within it, and not to the number of processes
+// issuing requests.
+//
+type nsenterAgent struct {
+	sync.Mutex                          // serializes agent requests
+	key        string                   // agent-set key (joined namespaces)
+	pid        uint32                   // pid through which namespaces were joined
+	nsInodes   map[domain.NStype]uint64 // inodes of the joined namespaces
+	userns     []domain.Inode           // requester's user-ns followed by its ancestors
+	process    *os.Process              // agent process
+	pipe       *os.File                 // pipe to communicate with the agent
+	lastUsed   int64                    // time of the last request (unix nsecs)
+	closed     int32                    // set once the agent is terminated
+	closeOnce  sync.Once
+}
+
+//
+// Set of running agents, indexed by joined namespaces.
+//
+type agentSet struct {
+	sync.Mutex
+	agents  map[string]*nsenterAgent
+	reaper  *zombieReaper
+	prs     domain.ProcessServiceIface
+	janitor sync.Once
+}
+
+func newAgentSet(reaper *zombieReaper) *agentSet {
+	return &agentSet{
+		agents: make(map[string]*nsenterAgent),
+		reaper: reaper,
+	}
+}
+
+// Agents joining the same namespaces are interchangeable, regardless of the
+// process through which these were reached.
+func agentKey(ns []domain.NStype, inodes map[domain.NStype]uint64) string {
+
+	entries := make([]string, 0, len(ns))
+	for _, nstype := range ns {
+		entries = append(entries, nstype+":"+strconv.FormatUint(inodes[nstype], 10))
+	}
+
+	return strings.Join(entries, ",")
+}
+
+//
+// Sends the event's request to the associated agent (launching it if needed),
+// and collects its response. Returns 'true' if the request made it to the
+// agent, in which case it must not be retried.
+//
+func (as *agentSet) send(ctx context.Context, e *NSenterEvent) (bool, error) {
+
+	a, err := as.get(e)
+	if err != nil {
+		return false, err
+	}
+
+	a.Lock()
+	defer a.Unlock()
+
+	if a.isClosed() {
+		return false, fmt.Errorf("agent for pid %d terminated", a.pid)
+	}
+
+	data, err := frameMessage(e.ReqMsg)
+	if err != nil {
+		return false, err
+	}
+
+	// Failing agents are terminated here, and dropped from the set during the
+	// next lookup.
+	if _, err := a.pipe.Write(data); err != nil {
+		a.close(as.reaper)
+		return false, err
+	}
+
+	// Agents stuck beyond the request's deadline are killed.
+	done := make(chan struct{})
+	go watchContext(ctx, a.process, done)
+	err = e.processResponse(a.pipe)
+	close(done)
+
+	if err != nil {
+		a.close(as.reaper)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			logger.Warnf("nsenter agent request for pid %d aborted: %v", e.Pid, ctxErr)
+			return true, contextError(ctxErr)
+		}
+		return true, err
+	}
+
+	atomic.StoreInt64(&a.lastUsed, time.Now().UnixNano())
+
+	return true, nil
+}
+
+// Returns the agent associated to the event's namespaces, launching it if not
+// present (or if the existing one is stale).
+func (as *agentSet) get(e *NSenterEvent) (*nsenterAgent, error) {
+
+	inodes, err := nsInodes(e.Pid, *e.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	key := agentKey(*e.Namespace, inodes)
+
+	as.Lock()
+	defer as.Unlock()
+
+	if a, ok := as.agents[key]; ok {
+		if !a.isClosed() {
+			return a, nil
+		}
+		delete(as.agents, key)
+	}
+
+	userns, err := as.usernsChain(e.Pid)
+	if err != nil {
+		return nil, err
+	}
+
+	as.reaper.nsenterStarted()
+	pipe, process, err := e.launch(true)
+	as.reaper.nsenterEnded()
+	if err != nil {
+		return nil, err
+	}
+
+	a := &nsenterAgent{
+		key:      key,
+		pid:      e.Pid,
+		nsInodes: inodes,
+		userns:   userns,
+		process:  process,
+		pipe:     pipe,
+		lastUsed: time.Now().UnixNano(),
+	}
+	as.agents[key] = a
+
+	// Requests in progress (if any) are let complete.
+	for _, old := range as.evict(a) {
+		go func(old *nsenterAgent) {
+			old.Lock()
+			old.close(as.reaper)
+			old.Unlock()
+		}(old)
+	}
+
+	as.janitor.Do(func() { go as.expire() })
+
+	logger.Debugf("nsenter agent launched for pid %d (agent pid %d)",
+		e.Pid, process.Pid)
+
+	return a, nil
+}
+
+// Drops the least recently used agents of the given agent's container beyond
+// the per-container limit, and returns them. Agent-set lock must be held.
+func (as *agentSet) evict(a *nsenterAgent) []*nsenterAgent {
+
+	var agents []*nsenterAgent
+	for _, other := range as.agents {
+		if other.container() == a.container() {
+			agents = append(agents, other)
+		}
+	}
+
+	if len(agents) <= agentsPerContainer {
+		return nil
+	}
+
+	sort.Slice(agents, func(i, j int) bool {
+		return atomic.LoadInt64(&agents[i].lastUsed) <
+			atomic.LoadInt64(&agents[j].lastUsed)
+	})
+
+	evicted := agents[:len(agents)-agentsPerContainer]
+	for _, e := range evicted {
+		delete(as.agents, e.key)
+	}
+
+	return evicted
+}
+
+// Periodically terminates idle agents. Notice that agents keep the joined
+// namespaces alive, so they must not outlive their containers for long.
+func (as *agentSet) expire() {
+
+	for range time.Tick(agentIdleTimeout) {
+		var idle []*nsenterAgent
+
+		as.Lock()
+		for key, a := range as.agents {
+			lastUsed := time.Unix(0, atomic.LoadInt64(&a.lastUsed))
+			if a.isClosed() || time.Since(lastUsed) > agentIdleTimeout {
+				idle = append(idle, a)
+				delete(as.agents, key)
+			}
+		}
+		as.Unlock()
+
+		// Requests in progress (if any) are let complete.
+		for _, a := range idle {
+			a.Lock()
+			a.close(as.reaper)
+			a.Unlock()
+		}
+	}
+}
+
+// Terminates the agents that joined the namespaces of the container
+// identified by the given user-ns, including those of its inner containers.
+// Invoked once the container is unregistered, as agents keep its namespaces
+// alive.
+func (as *agentSet) reapContainer(userns domain.Inode) int {
+
+	var reaped []*nsenterAgent
+
+	as.Lock()
+	for key, a := range as.agents {
+		for _, inode := range a.userns {
+			if inode == userns {
+				reaped = append(reaped, a)
+				delete(as.agents, key)
+				break
+			}
+		}
+	}
+	as.Unlock()
+
+	// Requests in progress (if any) are let complete.
+	for _, a := range reaped {
+		go func(a *nsenterAgent) {
+			a.Lock()
+			a.close(as.reaper)
+			a.Unlock()
+		}(a)
+	}
+
+	return len(reaped)
+}
+
+// Returns the user-ns of the given process followed by its ancestors, up to
+// sysbox-fs' one.
+func (as *agentSet) usernsChain(pid uint32) ([]domain.Inode, error) {
+
+	inodes, err := nsInodes(pid, []domain.NStype{domain.NStypeUser})
+	if err != nil {
+		return nil, err
+	}
+
+	chain := []domain.Inode{inodes[domain.NStypeUser]}
+
+	if as.prs == nil {
+		return chain, nil
+	}
+
+	ancestors, err := as.prs.ProcessCreate(pid, 0, 0).UserNsInodeAncestors()
+	if err != nil {
+		return nil, err
+	}
+
+	return append(chain, ancestors...), nil
+}
+
+// Returns the user-ns identifying the container the agent belongs to: the
+// outermost one below sysbox-fs' user-ns.
+func (a *nsenterAgent) container() domain.Inode {
+
+	if len(a.userns) < 2 {
+		return a.userns[0]
+	}
+
+	return a.userns[len(a.userns)-2]
+}
+
+func (a *nsenterAgent) isClosed() bool {
+	return atomic.LoadInt32(&a.closed) == 1
+}
+
+// Terminates the agent.
+func (a *nsenterAgent) close(reaper *zombieReaper) {
+
+	a.closeOnce.Do(func() {
+		atomic.StoreInt32(&a.closed, 1)
+
+		// Agents exit upon pipe closure.
+		unix.Shutdown(int(a.pipe.Fd()), unix.SHUT_WR)
+		a.pipe.Close()
+
+		reaper.nsenterStarted()
+		a.process.Wait()
+		reaper.nsenterEnded()
+
+		logger.Debugf("nsenter agent for pid %d terminated", a.pid)
+	})
+}
+
+// Returns the inodes of the given namespaces of a process.
+func nsInodes(pid uint32, ns []domain.NStype) (map[domain.NStype]uint64, error) {
+
+	inodes := make(map[domain.NStype]uint64, len(ns))
+
+	for _, nstype := range ns {
+		var st syscall.Stat_t
+
+		path := fmt.Sprintf("/proc/%d/ns/%s", pid, nstype)
+		if err := syscall.Stat(path, &st); err != nil {
+			return nil, err
+		}
+		inodes[nstype] = st.Ino
+	}
+
+	return inodes, nil
+}
@@ -0,0 +1,154 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package nsenter
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/sys/unix"
+
+	"github.com/nestybox/sysbox-fs/domain"
+)
+
+// Launches a stand-in for an agent process, which exits upon pipe closure
+// just like real agents do.
+func newTestAgent(t *testing.T, key string, userns ...domain.Inode) *nsenterAgent {
+
+	fds, err := unix.Socketpair(unix.AF_UNIX, unix.SOCK_STREAM|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pipe := os.NewFile(uintptr(fds[0]), "agent-pipe")
+	child := os.NewFile(uintptr(fds[1]), "agent-child-pipe")
+
+	cmd := exec.Command("cat")
+	cmd.Stdin = child
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	child.Close()
+
+	return &nsenterAgent{
+		key:      key,
+		userns:   userns,
+		process:  cmd.Process,
+		pipe:     pipe,
+		lastUsed: time.Now().UnixNano(),
+	}
+}
+
+// Waits for the given agent to be terminated.
+func waitClosed(t *testing.T, a *nsenterAgent) {
+
+	for i := 0; i < 100 && !a.isClosed(); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.True(t, a.isClosed(), "agent %s not terminated", a.key)
+}
+
+func Test_agentKey(t *testing.T) {
+
+	ns := []domain.NStype{domain.NStypeUser, domain.NStypeNet}
+
+	// Processes within the same namespaces share their agent.
+	self, err := nsInodes(uint32(os.Getpid()), ns)
+	assert.Nil(t, err)
+	parent, err := nsInodes(uint32(os.Getppid()), ns)
+	assert.Nil(t, err)
+
+	assert.Equal(t, agentKey(ns, self), agentKey(ns, parent))
+
+	// Agents joining different namespaces don't.
+	other := map[domain.NStype]uint64{
+		domain.NStypeUser: self[domain.NStypeUser],
+		domain.NStypeNet:  self[domain.NStypeNet] + 1,
+	}
+	assert.NotEqual(t, agentKey(ns, self), agentKey(ns, other))
+}
+
+func Test_agentSet_reapContainer(t *testing.T) {
+
+	as := newAgentSet(newZombieReaper())
+
+	// Agents of container c1 (user-ns 100), including one of an inner
+	// container (user-ns 101), and of container c2 (user-ns 200). User-ns
+	// 1 stands for the host's one.
+	c1 := newTestAgent(t, "c1", 100, 1)
+	c1Inner := newTestAgent(t, "c1-inner", 101, 100, 1)
+	c2 := newTestAgent(t, "c2", 200, 1)
+
+	for _, a := range []*nsenterAgent{c1, c1Inner, c2} {
+		as.agents[a.key] = a
+	}
+
+	assert.Equal(t, 2, as.reapContainer(100))
+
+	waitClosed(t, c1)
+	waitClosed(t, c1Inner)
+
+	assert.Len(t, as.agents, 1)
+	assert.False(t, c2.isClosed())
+
+	assert.Equal(t, 0, as.reapContainer(100))
+	assert.Equal(t, 1, as.reapContainer(200))
+	waitClosed(t, c2)
+	assert.Empty(t, as.agents)
+}
+
+func Test_agentSet_evict(t *testing.T) {
+
+	as := newAgentSet(newZombieReaper())
+
+	// Agents of the inner containers (user-ns 101) within c1 (user-ns 100)
+	// count towards c1's limit, while those of c2 (user-ns 200) don't.
+	var c1 []*nsenterAgent
+	for i := 0; i < agentsPerContainer; i++ {
+		a := newTestAgent(t, fmt.Sprintf("c1-%d", i), 101, 100, 1)
+		a.lastUsed = int64(i + 1)
+		as.agents[a.key] = a
+		c1 = append(c1, a)
+	}
+
+	c2 := newTestAgent(t, "c2", 200, 1)
+	c2.lastUsed = 0
+	as.agents[c2.key] = c2
+
+	// Within the limit.
+	assert.Empty(t, as.evict(c1[0]))
+
+	// Beyond the limit. The least recently used agent of c1 is evicted.
+	a := newTestAgent(t, "c1-new", 100, 1)
+	as.agents[a.key] = a
+
+	evicted := as.evict(a)
+	if assert.Len(t, evicted, 1) {
+		assert.Equal(t, c1[0], evicted[0])
+	}
+
+	assert.Len(t, as.agents, agentsPerContainer+1)
+	assert.NotContains(t, as.agents, c1[0].key)
+	assert.Contains(t, as.agents, c2.key)
+
+	for _, a := range append(c1, a, c2) {
+		a.close(as.reaper)
+	}
+}
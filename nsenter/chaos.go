@@ -0,0 +1,114 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package nsenter
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/fuse"
+)
+
+// Ensure the nsenter service supports runtime fault injection.
+var _ domain.ChaosIface = (*nsenterService)(nil)
+
+//
+// Random faults injected into nsenter requests (see domain.ChaosConfig).
+// Failed requests are answered with EIO without ever reaching the container's
+// namespaces, delayed ones are held before being served, and crashed ones
+// have their nsenter process killed right after it joins the namespaces.
+//
+type chaos struct {
+	sync.Mutex
+	cfg domain.ChaosConfig
+}
+
+// Faults drawn for a given request.
+type chaosFaults struct {
+	fail  bool
+	delay time.Duration
+	crash bool
+}
+
+func (c *chaos) set(cfg domain.ChaosConfig) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.cfg = cfg
+}
+
+func (c *chaos) get() domain.ChaosConfig {
+	c.Lock()
+	defer c.Unlock()
+
+	return c.cfg
+}
+
+// Draws the faults to inject into a request.
+func (c *chaos) draw() chaosFaults {
+
+	cfg := c.get()
+
+	var f chaosFaults
+
+	if cfg.IsZero() {
+		return f
+	}
+
+	if cfg.DelayRate > 0 && rand.Float64() < cfg.DelayRate {
+		f.delay = cfg.Delay
+	}
+	if cfg.ErrorRate > 0 && rand.Float64() < cfg.ErrorRate {
+		f.fail = true
+	}
+	if cfg.CrashRate > 0 && rand.Float64() < cfg.CrashRate {
+		f.crash = true
+	}
+
+	return f
+}
+
+// Applies the delay and failure faults. Returns the error to hand back to
+// the request's originator, if any.
+func (f chaosFaults) apply(ctx context.Context) error {
+
+	if f.delay > 0 {
+		select {
+		case <-time.After(f.delay):
+		case <-ctx.Done():
+			return contextError(ctx.Err())
+		}
+	}
+
+	if f.fail {
+		return fuse.IOerror{Code: syscall.EIO, Message: "injected nsenter fault"}
+	}
+
+	return nil
+}
+
+func (s *nsenterService) SetChaos(cfg domain.ChaosConfig) {
+	s.chaos.set(cfg)
+}
+
+func (s *nsenterService) Chaos() domain.ChaosConfig {
+	return s.chaos.get()
+}
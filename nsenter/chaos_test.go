@@ -0,0 +1,64 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package nsenter
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/fuse"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_chaos(t *testing.T) {
+
+	var c chaos
+
+	// No faults by default.
+	assert.Equal(t, chaosFaults{}, c.draw())
+	assert.NoError(t, c.draw().apply(context.Background()))
+
+	c.set(domain.ChaosConfig{
+		ErrorRate: 1,
+		DelayRate: 1,
+		Delay:     time.Millisecond,
+		CrashRate: 1,
+	})
+	f := c.draw()
+	assert.Equal(t, chaosFaults{fail: true, delay: time.Millisecond, crash: true}, f)
+
+	err := f.apply(context.Background())
+	assert.Equal(t, syscall.EIO, err.(fuse.IOerror).Code)
+
+	// Delays are cut short by cancelled requests.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = chaosFaults{delay: time.Hour}.apply(ctx)
+	assert.Equal(t, syscall.EINTR, err.(fuse.IOerror).Code)
+}
+
+func Test_nsenterService_Chaos(t *testing.T) {
+
+	s := NewNSenterService()
+
+	cfg := domain.ChaosConfig{ErrorRate: 0.5}
+	s.(domain.ChaosIface).SetChaos(cfg)
+	assert.Equal(t, cfg, s.(domain.ChaosIface).Chaos())
+}
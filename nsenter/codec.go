@@ -0,0 +1,166 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package nsenter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"io"
+
+	"github.com/nestybox/sysbox-fs/domain"
+)
+
+//
+// Messages exchanged between sysbox-fs and its nsenter children are gob
+// encoded, and delimited by a 4-byte (big-endian) length prefix:
+//
+//   +--------+---------------------------------+
+//   | length | frame{Type, Payload} (gob)      |
+//   +--------+---------------------------------+
+//
+// The payload is gob encoded on its own, so that its concrete type can be
+// picked based on the message type once the frame is received. Unlike json,
+// gob preserves arbitrary bytes in strings (e.g. sysctl values with newlines
+// or non-UTF8 content).
+//
+
+// Upper bound of the frames accepted from the other end.
+const maxFrameSize = 64 << 20
+
+type frame struct {
+	Type    domain.NSenterMsgType
+	Payload []byte
+}
+
+// Encodes a message into a (non length-prefixed) frame.
+func encodeMessage(m *domain.NSenterMessage) ([]byte, error) {
+
+	payload, err := encodePayload(m)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(frame{m.Type, payload}); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func encodePayload(m *domain.NSenterMessage) ([]byte, error) {
+
+	if m.Payload == nil {
+		return nil, nil
+	}
+
+	v := m.Payload
+
+	// Batches are encoded as a list of frames, as each sub-message carries a
+	// payload of its own type.
+	if batch, ok := v.(domain.BatchPayload); ok {
+		frames := make([][]byte, 0, len(batch))
+		for i := range batch {
+			f, err := encodeMessage(&batch[i])
+			if err != nil {
+				return nil, err
+			}
+			frames = append(frames, f)
+		}
+		v = frames
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, fmt.Errorf("Error encoding %s payload: %v", m.Type, err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Decodes a (non length-prefixed) frame.
+func decodeFrame(data []byte) (*frame, error) {
+
+	var f frame
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&f); err != nil {
+		return nil, err
+	}
+
+	return &f, nil
+}
+
+// Decodes a frame's payload into 'v', which must be a pointer to the type
+// associated to the frame's message type.
+func decodePayload(payload []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(payload)).Decode(v)
+}
+
+// Encodes a message into a length-prefixed frame.
+func frameMessage(m *domain.NSenterMessage) ([]byte, error) {
+
+	data, err := encodeMessage(m)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := make([]byte, 4+len(data))
+	binary.BigEndian.PutUint32(msg, uint32(len(data)))
+	copy(msg[4:], data)
+
+	return msg, nil
+}
+
+// Writes a length-prefixed message into 'w'. Header and frame are written at
+// once, so that pipe writes are atomic for small messages.
+func writeMessage(w io.Writer, m *domain.NSenterMessage) error {
+
+	msg, err := frameMessage(m)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(msg)
+	return err
+}
+
+// Reads a length-prefixed frame from 'r'. Returns io.EOF if the other end
+// closed the channel in between messages.
+func readFrame(r io.Reader) (*frame, error) {
+
+	var hdr [4]byte
+
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, err
+	}
+
+	size := binary.BigEndian.Uint32(hdr[:])
+	if size > maxFrameSize {
+		return nil, fmt.Errorf("nsenter frame too large (%d bytes)", size)
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	return decodeFrame(data)
+}
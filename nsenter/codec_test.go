@@ -0,0 +1,148 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package nsenter
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/fuse"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_codec_Response(t *testing.T) {
+
+	tests := []struct {
+		name string
+		msg  *domain.NSenterMessage
+		want *domain.NSenterMessage
+	}{
+		{
+			//
+			// Test-case 1: Values with newlines and non-UTF8 bytes are
+			// preserved.
+			//
+			name: "1",
+			msg: &domain.NSenterMessage{
+				Type:    domain.ReadFileResponse,
+				Payload: "a\nb\xff\"c",
+			},
+			want: &domain.NSenterMessage{
+				Type:    domain.ReadFileResponse,
+				Payload: "a\nb\xff\"c",
+			},
+		},
+		{
+			//
+			// Test-case 2: Errors keep their errno and operation context.
+			//
+			name: "2",
+			msg: &domain.NSenterMessage{
+				Type: domain.ErrorResponse,
+				Payload: &fuse.IOerror{
+					RcvError: os.NewSyscallError("open", syscall.ENOENT),
+				},
+			},
+			want: &domain.NSenterMessage{
+				Type: domain.ErrorResponse,
+				Payload: fuse.IOerror{
+					Type:    "*os.SyscallError",
+					Code:    syscall.ENOENT,
+					Message: "open: no such file or directory",
+					Op:      "open",
+				},
+			},
+		},
+		{
+			//
+			// Test-case 3: Batches carry sub-responses of different types.
+			//
+			name: "3",
+			msg: &domain.NSenterMessage{
+				Type: domain.BatchResponse,
+				Payload: domain.BatchPayload{
+					{Type: domain.ReadFileResponse, Payload: "1"},
+					{Type: domain.ErrorResponse, Payload: &fuse.IOerror{RcvError: syscall.EACCES}},
+					{Type: domain.WriteFileResponse},
+				},
+			},
+			want: &domain.NSenterMessage{
+				Type: domain.BatchResponse,
+				Payload: domain.BatchPayload{
+					{Type: domain.ReadFileResponse, Payload: "1"},
+					{Type: domain.ErrorResponse, Payload: fuse.IOerror{
+						Type:    "syscall.Errno",
+						Code:    syscall.EACCES,
+						Message: syscall.EACCES.Error(),
+					}},
+					{Type: domain.WriteFileResponse, Payload: ""},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			assert.Nil(t, writeMessage(&buf, tt.msg))
+
+			var e NSenterEvent
+			assert.Nil(t, e.processResponse(&buf))
+			assert.Equal(t, tt.want, e.ResMsg)
+		})
+	}
+}
+
+func Test_codec_Framing(t *testing.T) {
+
+	msg := &domain.NSenterMessage{
+		Type:    domain.ReadFileRequest,
+		Payload: &domain.ReadFilePayload{File: "/proc/sys/kernel/domainname"},
+	}
+
+	data, err := frameMessage(msg)
+	assert.Nil(t, err)
+
+	// Consecutive messages on the same stream.
+	stream := bytes.NewReader(append(append([]byte{}, data...), data...))
+	for i := 0; i < 2; i++ {
+		f, err := readFrame(stream)
+		assert.Nil(t, err)
+		assert.Equal(t, domain.ReadFileRequest, f.Type)
+
+		var p domain.ReadFilePayload
+		assert.Nil(t, decodePayload(f.Payload, &p))
+		assert.Equal(t, "/proc/sys/kernel/domainname", p.File)
+	}
+
+	// Channel closed in between messages.
+	_, err = readFrame(stream)
+	assert.Equal(t, io.EOF, err)
+
+	// Truncated message.
+	_, err = readFrame(bytes.NewReader(data[:len(data)-1]))
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+
+	// Oversized message.
+	_, err = readFrame(bytes.NewReader([]byte{0xff, 0xff, 0xff, 0xff}))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,169 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package nsenter
+
+import (
+	"fmt"
+	"syscall"
+
+	cap "github.com/nestybox/sysbox-libs/capability"
+	libseccomp "github.com/nestybox/sysbox-libs/libseccomp-golang"
+
+	"github.com/nestybox/sysbox-fs/domain"
+)
+
+//
+// The nsenter process runs with full capabilities within the container
+// namespaces. To limit the damage that a handler tricked into acting on an
+// unexpected path could cause, the process confines itself before serving its
+// first request: it drops the capabilities not required by the request, and
+// installs a seccomp filter that rejects (EPERM) syscalls that none of its
+// operations should ever need.
+//
+
+// Capabilities retained to serve file-based requests (see agentRequest()).
+// Writes to some namespaced sysctls require CAP_SYS_ADMIN, CAP_NET_ADMIN or
+// CAP_SYS_RESOURCE.
+var fileRequestCaps = []cap.Cap{
+	cap.CAP_CHOWN,
+	cap.CAP_DAC_OVERRIDE,
+	cap.CAP_DAC_READ_SEARCH,
+	cap.CAP_FOWNER,
+	cap.CAP_NET_ADMIN,
+	cap.CAP_SYS_ADMIN,
+	cap.CAP_SYS_RESOURCE,
+}
+
+// Syscalls rejected for all requests.
+var deniedSyscalls = []string{
+	"acct",
+	"add_key",
+	"adjtimex",
+	"bpf",
+	"clock_settime",
+	"delete_module",
+	"execve",
+	"execveat",
+	"finit_module",
+	"init_module",
+	"ioperm",
+	"iopl",
+	"kexec_file_load",
+	"kexec_load",
+	"keyctl",
+	"name_to_handle_at",
+	"open_by_handle_at",
+	"perf_event_open",
+	"pivot_root",
+	"process_vm_readv",
+	"process_vm_writev",
+	"ptrace",
+	"reboot",
+	"request_key",
+	"setns",
+	"settimeofday",
+	"swapoff",
+	"swapon",
+	"unshare",
+	"userfaultfd",
+}
+
+// Syscalls only required by mount / umount requests (the process personality
+// is adjusted to match the one of the process performing the syscall).
+var mountSyscalls = []string{
+	"capset",
+	"chroot",
+	"mount",
+	"setresgid",
+	"setresuid",
+	"umount2",
+}
+
+// Confines the nsenter process based on the type of request it's about to
+// serve. Agents serve file-based requests only, so they are confined once.
+func confine(t domain.NSenterMsgType) error {
+
+	fileRequest := agentRequest(t)
+
+	// The personality adjustment performed by mount requests already narrows
+	// their capabilities down to the ones of the original process.
+	if fileRequest {
+		if err := dropCapabilities(fileRequestCaps); err != nil {
+			return fmt.Errorf("failed to drop capabilities: %v", err)
+		}
+	}
+
+	denied := deniedSyscalls
+	if fileRequest {
+		denied = append(denied[:len(denied):len(denied)], mountSyscalls...)
+	}
+
+	if err := loadSeccompFilter(denied); err != nil {
+		return fmt.Errorf("failed to load seccomp filter: %v", err)
+	}
+
+	return nil
+}
+
+// Restricts the effective, permitted, inheritable and bounding capability
+// sets of the current process to the given capabilities.
+func dropCapabilities(keep []cap.Cap) error {
+
+	c, err := cap.NewPid2(0)
+	if err != nil {
+		return err
+	}
+
+	if err := c.Load(); err != nil {
+		return err
+	}
+
+	c.Clear(cap.CAPS | cap.BOUNDS)
+	c.Set(cap.CAPS|cap.BOUNDS, keep...)
+
+	return c.Apply(cap.CAPS | cap.BOUNDS)
+}
+
+// Installs a seccomp filter that fails the given syscalls with EPERM. Syscalls
+// not known for the current architecture are skipped.
+func loadSeccompFilter(denied []string) error {
+
+	filter, err := libseccomp.NewFilter(libseccomp.ActAllow)
+	if err != nil {
+		return err
+	}
+	defer filter.Release()
+
+	// Allows loading the filter regardless of the capabilities retained above.
+	if err := filter.SetNoNewPrivsBit(true); err != nil {
+		return err
+	}
+
+	deny := libseccomp.ActErrno.SetReturnCode(int16(syscall.EPERM))
+
+	for _, name := range denied {
+		id, err := libseccomp.GetSyscallFromName(name)
+		if err != nil {
+			continue
+		}
+		if err := filter.AddRule(id, deny); err != nil {
+			return err
+		}
+	}
+
+	return filter.Load()
+}
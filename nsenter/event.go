@@ -18,6 +18,7 @@ package nsenter
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -30,6 +31,7 @@ import (
 	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	_ "github.com/nestybox/sysbox-runc/libcontainer/nsenter"
 	"github.com/nestybox/sysbox-runc/libcontainer/utils"
@@ -39,7 +41,9 @@ import (
 
 	"github.com/nestybox/sysbox-fs/domain"
 	"github.com/nestybox/sysbox-fs/fuse"
+	"github.com/nestybox/sysbox-fs/logging"
 	"github.com/nestybox/sysbox-fs/process"
+	"github.com/nestybox/sysbox-fs/tracing"
 	"github.com/nestybox/sysbox-runc/libcontainer"
 )
 
@@ -120,37 +124,36 @@ func (e *NSenterEvent) GetResponseMsg() *domain.NSenterMessage {
 // Called by sysbox-fs handler routines to parse the response generated
 // by sysbox-fs' grand-child processes.
 //
-func (e *NSenterEvent) processResponse(pipe io.Reader) error {
+func (e *NSenterEvent) processResponse(r io.Reader) error {
 
-	// Raw message payload to aid in decoding generic messages (see below
-	// explanation).
-	var payload json.RawMessage
-	nsenterMsg := domain.NSenterMessage{
-		Payload: &payload,
-	}
-
-	// Decode received msg header to help us determine the payload type.
-	// Received message will be decoded in two phases. The decode instruction
-	// below help us determine the message-type being received. Based on the
-	// obtained type, we are able to decode the payload generated by the
-	// remote-end. This second step is executed as part of a subsequent
-	// unmarshal instruction (see further below).
-	if err := json.NewDecoder(pipe).Decode(&nsenterMsg); err != nil {
-		logrus.Warnf("Error decoding received nsenterMsg response: %s", err)
+	// Received messages are decoded in two phases. Reading the frame helps us
+	// determine the message-type being received. Based on the obtained type,
+	// we are able to decode the payload generated by the remote-end (see
+	// further below).
+	f, err := readFrame(r)
+	if err != nil {
+		logger.Warnf("Error decoding received nsenterMsg response: %s", err)
 		return fmt.Errorf("Error decoding received nsenterMsg response: %s", err)
 	}
 
+	return e.processResponseFrame(f)
+}
+
+func (e *NSenterEvent) processResponseFrame(nsenterMsg *frame) error {
+
+	payload := nsenterMsg.Payload
+
 	switch nsenterMsg.Type {
 
 	case domain.LookupResponse:
-		logrus.Debug("Received nsenterEvent lookupResponse message.")
+		logger.Debug("Received nsenterEvent lookupResponse message.")
 
 		var p domain.FileInfo
 
 		if payload != nil {
-			err := json.Unmarshal(payload, &p)
+			err := decodePayload(payload, &p)
 			if err != nil {
-				logrus.Error(err)
+				logger.Error(err)
 				return err
 			}
 		}
@@ -162,14 +165,14 @@ func (e *NSenterEvent) processResponse(pipe io.Reader) error {
 		break
 
 	case domain.OpenFileResponse:
-		logrus.Debug("Received nsenterEvent OpenResponse message.")
+		logger.Debug("Received nsenterEvent OpenResponse message.")
 
 		var p int
 
 		if payload != nil {
-			err := json.Unmarshal(payload, &p)
+			err := decodePayload(payload, &p)
 			if err != nil {
-				logrus.Error(err)
+				logger.Error(err)
 				return err
 			}
 		}
@@ -181,14 +184,14 @@ func (e *NSenterEvent) processResponse(pipe io.Reader) error {
 		break
 
 	case domain.ReadFileResponse:
-		logrus.Debug("Received nsenterEvent readResponse message.")
+		logger.Debug("Received nsenterEvent readResponse message.")
 
 		var p string
 
 		if payload != nil {
-			err := json.Unmarshal(payload, &p)
+			err := decodePayload(payload, &p)
 			if err != nil {
-				logrus.Error(err)
+				logger.Error(err)
 				return err
 			}
 		}
@@ -200,7 +203,7 @@ func (e *NSenterEvent) processResponse(pipe io.Reader) error {
 		break
 
 	case domain.WriteFileResponse:
-		logrus.Debug("Received nsenterEvent writeResponse message.")
+		logger.Debug("Received nsenterEvent writeResponse message.")
 
 		e.ResMsg = &domain.NSenterMessage{
 			Type:    nsenterMsg.Type,
@@ -209,14 +212,90 @@ func (e *NSenterEvent) processResponse(pipe io.Reader) error {
 		break
 
 	case domain.ReadDirResponse:
-		logrus.Debug("Received nsenterEvent readDirAllResponse message.")
+		logger.Debug("Received nsenterEvent readDirAllResponse message.")
 
 		var p []domain.FileInfo
 
 		if payload != nil {
-			err := json.Unmarshal(payload, &p)
+			err := decodePayload(payload, &p)
+			if err != nil {
+				logger.Error(err)
+				return err
+			}
+		}
+
+		e.ResMsg = &domain.NSenterMessage{
+			Type:    nsenterMsg.Type,
+			Payload: p,
+		}
+		break
+
+	case domain.StatResponse:
+		logger.Debug("Received nsenterEvent statResponse message.")
+
+		var p domain.FileInfo
+
+		if payload != nil {
+			err := decodePayload(payload, &p)
+			if err != nil {
+				logger.Error(err)
+				return err
+			}
+		}
+
+		e.ResMsg = &domain.NSenterMessage{
+			Type:    nsenterMsg.Type,
+			Payload: p,
+		}
+		break
+
+	case domain.ReadlinkResponse:
+		logger.Debug("Received nsenterEvent readlinkResponse message.")
+
+		var p string
+
+		if payload != nil {
+			err := decodePayload(payload, &p)
+			if err != nil {
+				logger.Error(err)
+				return err
+			}
+		}
+
+		e.ResMsg = &domain.NSenterMessage{
+			Type:    nsenterMsg.Type,
+			Payload: p,
+		}
+		break
+
+	case domain.CgroupResponse:
+		logger.Debug("Received nsenterEvent cgroupResponse message.")
+
+		var p map[string]string
+
+		if payload != nil {
+			err := decodePayload(payload, &p)
+			if err != nil {
+				logger.Error(err)
+				return err
+			}
+		}
+
+		e.ResMsg = &domain.NSenterMessage{
+			Type:    nsenterMsg.Type,
+			Payload: p,
+		}
+		break
+
+	case domain.NetDevResponse:
+		logger.Debug("Received nsenterEvent netDevResponse message.")
+
+		var p domain.NetDevInfo
+
+		if payload != nil {
+			err := decodePayload(payload, &p)
 			if err != nil {
-				logrus.Error(err)
+				logger.Error(err)
 				return err
 			}
 		}
@@ -227,8 +306,17 @@ func (e *NSenterEvent) processResponse(pipe io.Reader) error {
 		}
 		break
 
+	case domain.MkdirResponse, domain.ChownResponse:
+		logger.Debugf("Received nsenterEvent %s message.", nsenterMsg.Type)
+
+		e.ResMsg = &domain.NSenterMessage{
+			Type:    nsenterMsg.Type,
+			Payload: "",
+		}
+		break
+
 	case domain.MountSyscallResponse:
-		logrus.Debug("Received nsenterEvent mountSyscallResponse message.")
+		logger.Debug("Received nsenterEvent mountSyscallResponse message.")
 
 		e.ResMsg = &domain.NSenterMessage{
 			Type:    nsenterMsg.Type,
@@ -237,7 +325,7 @@ func (e *NSenterEvent) processResponse(pipe io.Reader) error {
 		break
 
 	case domain.UmountSyscallResponse:
-		logrus.Debug("Received nsenterEvent umountSyscallResponse message.")
+		logger.Debug("Received nsenterEvent umountSyscallResponse message.")
 
 		e.ResMsg = &domain.NSenterMessage{
 			Type:    nsenterMsg.Type,
@@ -245,15 +333,49 @@ func (e *NSenterEvent) processResponse(pipe io.Reader) error {
 		}
 		break
 
+	case domain.BatchResponse:
+		logger.Debug("Received nsenterEvent batchResponse message.")
+
+		var raw [][]byte
+
+		if payload != nil {
+			err := decodePayload(payload, &raw)
+			if err != nil {
+				logger.Error(err)
+				return err
+			}
+		}
+
+		// Each sub-response is decoded as a standalone one.
+		p := make(domain.BatchPayload, 0, len(raw))
+		for _, r := range raw {
+			var sub NSenterEvent
+
+			f, err := decodeFrame(r)
+			if err == nil {
+				err = sub.processResponseFrame(f)
+			}
+			if err != nil {
+				return err
+			}
+			p = append(p, *sub.ResMsg)
+		}
+
+		e.ResMsg = &domain.NSenterMessage{
+			Type:    nsenterMsg.Type,
+			Payload: p,
+		}
+		break
+
 	case domain.ErrorResponse:
-		logrus.Debug("Received nsenterEvent errorResponse message.")
+		logger.Debug("Received nsenterEvent errorResponse message.")
 
 		var p fuse.IOerror
 
 		if payload != nil {
-			err := json.Unmarshal(payload, &p)
+			err := decodePayload(payload, &p)
 			if err != nil {
-				logrus.Error(err)
+				logger.Error(err)
 				return err
 			}
 		}
@@ -303,20 +425,178 @@ func (e *NSenterEvent) namespacePaths() []string {
 // nsexec logic, which will serve to enter the container namespaces that host
 // these resources.
 //
-func (e *NSenterEvent) SendRequest() error {
+func (e *NSenterEvent) SendRequest(ctx context.Context) error {
+
+	ctx, span := tracing.Start(ctx, "nsenter."+e.ReqMsg.Type)
+	span.SetAttribute(logging.FieldPid, e.Pid)
+
+	start := time.Now()
+	err := e.sendRequest(ctx)
+
+	if latency := time.Since(start); logging.IsSlow(latency) {
+		entry := logger.WithFields(logrus.Fields{
+			logging.FieldOp:      e.ReqMsg.Type,
+			logging.FieldPid:     e.Pid,
+			logging.FieldLatency: latency.String(),
+		})
+		if err != nil {
+			entry = entry.WithError(err)
+		}
+		entry.Warn("Slow nsenter request")
+	}
+
+	span.SetError(err)
+	span.End()
+
+	return err
+}
+
+func (e *NSenterEvent) sendRequest(ctx context.Context) error {
+
+	logger.Debug("Executing nsenterEvent's request() method")
+
+	// Request already aborted (e.g. interrupted fuse request).
+	if err := ctx.Err(); err != nil {
+		return contextError(err)
+	}
 
-	logrus.Debug("Executing nsenterEvent's request() method")
+	// Wait for the concurrency limits to allow this request through.
+	if e.service != nil && e.service.limiter != nil {
+		_, wspan := tracing.Start(ctx, "nsenter.wait")
+		release, err := e.service.limiter.acquire(ctx, e.Pid)
+		wspan.End()
+		if err != nil {
+			logger.Warnf("nsenter request for pid %d not served: %v", e.Pid, err)
+			return err
+		}
+		defer release()
+	}
+
+	var faults chaosFaults
+	if e.service != nil {
+		faults = e.service.chaos.draw()
+		if err := faults.apply(ctx); err != nil {
+			logger.Debugf("nsenter request for pid %d not served: %v", e.Pid, err)
+			return err
+		}
+	}
+
+	// Requests that don't alter the state of the nsenter process are served by
+	// the long-lived agent associated to the target namespaces (see agent.go).
+	// Fall back to a dedicated nsenter process if the agent couldn't process
+	// the request. Requests whose nsenter process is to crash can't be served
+	// by the agent, since it's shared with other requests.
+	if e.service != nil && e.service.agents != nil && agentRequest(e.ReqMsg.Type) &&
+		!faults.crash {
+		actx, aspan := tracing.Start(ctx, "nsenter.agent")
+		sent, err := e.service.agents.send(actx, e)
+		aspan.SetError(err)
+		aspan.End()
+		if err == nil || sent {
+			return err
+		}
+		logger.Debugf("nsenter agent unavailable for pid %d: %v", e.Pid, err)
+	}
 
 	// Alert the zombie reaper that nsenter is about to start
 	e.reaper.nsenterStarted()
 	defer e.reaper.nsenterEnded()
 
+	// Forking the nsenter child and reading in the target namespaces are traced
+	// separately, as either of them can dominate the request's latency.
+	_, fspan := tracing.Start(ctx, "nsenter.fork")
+	parentPipe, process, err := e.launch(false)
+	fspan.SetError(err)
+	fspan.End()
+	if err != nil {
+		return err
+	}
+	defer parentPipe.Close()
+
+	_, xspan := tracing.Start(ctx, "nsenter.exec")
+	defer xspan.End()
+
+	if faults.crash {
+		logger.Debugf("Killing nsenter process %d (injected fault)", process.Pid)
+		process.Kill()
+	}
+
+	// Transfer the nsenterEvent details to grand-child for processing.
+	err = writeMessage(parentPipe, e.ReqMsg)
+	if err != nil {
+		logger.Warnf("Error while writing nsenter payload into pipeline (%v)", err)
+		e.reaper.nsenterReapReq()
+		return err
+	}
+
+	// Wait for sysbox-fs' grand-child response and process it accordingly.
+	// The grand-child is killed if the request expires or is cancelled in the
+	// meantime.
+	done := make(chan struct{})
+	go watchContext(ctx, process, done)
+	ierr := e.processResponse(parentPipe)
+	close(done)
+	xspan.SetError(ierr)
+
+	// Destroy the socket pair.
+	if err := unix.Shutdown(int(parentPipe.Fd()), unix.SHUT_WR); err != nil {
+		logger.Warnf("Error shutting down sysbox-fs nsenter pipe: %s", err)
+	}
+
+	if ierr != nil {
+		e.reaper.nsenterReapReq()
+		if err := ctx.Err(); err != nil {
+			logger.Warnf("nsenter request for pid %d aborted: %v", e.Pid, err)
+			return contextError(err)
+		}
+		return ierr
+	}
+
+	process.Wait()
+
+	return nil
+}
+
+//
+// Kills the given nsenter process if the context is done before the 'done'
+// channel is closed.
+//
+func watchContext(ctx context.Context, p *os.Process, done <-chan struct{}) {
+
+	select {
+	case <-ctx.Done():
+		p.Kill()
+	case <-done:
+	}
+}
+
+//
+// Translates context errors into the ones expected by fuse clients: EINTR for
+// interrupted requests and ETIMEDOUT for expired ones.
+//
+func contextError(err error) error {
+
+	code := syscall.ETIMEDOUT
+	if err == context.Canceled {
+		code = syscall.EINTR
+	}
+
+	return fuse.IOerror{Code: code, Message: err.Error()}
+}
+
+//
+// Launches a sysbox-fs grand-child process within the event's namespaces.
+// Returns the pipe to communicate with it, along with its process handle.
+// If 'agent' is set, the grand-child will keep serving requests until the
+// pipe is closed. Callers must have notified the zombie reaper.
+//
+func (e *NSenterEvent) launch(agent bool) (*os.File, *os.Process, error) {
+
 	// Create a socket pair.
 	parentPipe, childPipe, err := utils.NewSockPair("nsenterPipe")
 	if err != nil {
-		return errors.New("Error creating sysbox-fs nsenter pipe")
+		return nil, nil, errors.New("Error creating sysbox-fs nsenter pipe")
 	}
-	defer parentPipe.Close()
 
 	// Obtain the FS path for all the namespaces to be nsenter'ed into, and
 	// define the associated netlink-payload to transfer to child process.
@@ -329,12 +609,20 @@ func (e *NSenterEvent) SendRequest() error {
 		Value: []byte(strings.Join(namespaces, ",")),
 	})
 
+	env := []string{
+		"_LIBCONTAINER_INITPIPE=3",
+		fmt.Sprintf("GOMAXPROCS=%s", os.Getenv("GOMAXPROCS")),
+	}
+	if agent {
+		env = append(env, nsenterAgentEnv+"=1")
+	}
+
 	// Prepare exec.cmd in charged of running: "sysbox-fs nsenter".
 	cmd := &exec.Cmd{
 		Path:       "/proc/self/exe",
 		Args:       []string{os.Args[0], "nsenter"},
 		ExtraFiles: []*os.File{childPipe},
-		Env:        []string{"_LIBCONTAINER_INITPIPE=3", fmt.Sprintf("GOMAXPROCS=%s", os.Getenv("GOMAXPROCS"))},
+		Env:        env,
 		Stdin:      nil,
 		Stdout:     nil,
 		Stderr:     nil,
@@ -344,42 +632,48 @@ func (e *NSenterEvent) SendRequest() error {
 	err = cmd.Start()
 	childPipe.Close()
 	if err != nil {
-		logrus.Errorf("Error launching sysbox-fs first child process: %s", err)
-		return errors.New("Error launching sysbox-fs first child process")
+		parentPipe.Close()
+		logger.Errorf("Error launching sysbox-fs first child process: %s", err)
+		return nil, nil, errors.New("Error launching sysbox-fs first child process")
 	}
 
 	// Send the config to child process.
 	if _, err := io.Copy(parentPipe, bytes.NewReader(r.Serialize())); err != nil {
-		logrus.Warnf("Error copying payload to pipe: %s", err)
+		parentPipe.Close()
+		logger.Warnf("Error copying payload to pipe: %s", err)
 		e.reaper.nsenterReapReq()
-		return errors.New("Error copying payload to pipe")
+		return nil, nil, errors.New("Error copying payload to pipe")
 	}
 
 	// Wait for sysbox-fs' first child process to finish.
 	status, err := cmd.Process.Wait()
 	if err != nil {
-		logrus.Warnf("Error waiting for sysbox-fs first child process %d: %s", cmd.Process.Pid, err)
+		parentPipe.Close()
+		logger.Warnf("Error waiting for sysbox-fs first child process %d: %s", cmd.Process.Pid, err)
 		e.reaper.nsenterReapReq()
-		return err
+		return nil, nil, err
 	}
 	if !status.Success() {
-		logrus.Warnf("Sysbox-fs first child process error status: pid = %d", cmd.Process.Pid)
+		parentPipe.Close()
+		logger.Warnf("Sysbox-fs first child process error status: pid = %d", cmd.Process.Pid)
 		e.reaper.nsenterReapReq()
-		return errors.New("Error waiting for sysbox-fs first child process")
+		return nil, nil, errors.New("Error waiting for sysbox-fs first child process")
 	}
 
 	// Receive sysbox-fs' first-child pid.
 	var pid pid
 	decoder := json.NewDecoder(parentPipe)
 	if err := decoder.Decode(&pid); err != nil {
-		logrus.Warnf("Error receiving first-child pid: %s", err)
-		return errors.New("Error receiving first-child pid")
+		parentPipe.Close()
+		logger.Warnf("Error receiving first-child pid: %s", err)
+		return nil, nil, errors.New("Error receiving first-child pid")
 	}
 
 	firstChildProcess, err := os.FindProcess(pid.PidFirstChild)
 	if err != nil {
-		logrus.Warnf("Error finding first-child pid: %s", err)
-		return err
+		parentPipe.Close()
+		logger.Warnf("Error finding first-child pid: %s", err)
+		return nil, nil, err
 	}
 
 	// Wait for sysbox-fs' second child process to finish. Ignore the error in
@@ -390,41 +684,12 @@ func (e *NSenterEvent) SendRequest() error {
 	// go runtime.
 	process, err := os.FindProcess(pid.Pid)
 	if err != nil {
-		logrus.Warnf("Error finding grand-child pid %d: %s", pid.Pid, err)
-		return err
-	}
-	cmd.Process = process
-
-	// Transfer the nsenterEvent details to grand-child for processing.
-	data, err := json.Marshal(*(e.ReqMsg))
-	if err != nil {
-		logrus.Warnf("Error while encoding nsenter payload (%v).", err)
-		e.reaper.nsenterReapReq()
-		return err
-	}
-	_, err = parentPipe.Write(data)
-	if err != nil {
-		logrus.Warnf("Error while writing nsenter payload into pipeline (%v)", err)
-		e.reaper.nsenterReapReq()
-		return err
-	}
-
-	// Wait for sysbox-fs' grand-child response and process it accordingly.
-	ierr := e.processResponse(parentPipe)
-
-	// Destroy the socket pair.
-	if err := unix.Shutdown(int(parentPipe.Fd()), unix.SHUT_WR); err != nil {
-		logrus.Warnf("Error shutting down sysbox-fs nsenter pipe: %s", err)
-	}
-
-	if ierr != nil {
-		e.reaper.nsenterReapReq()
-		return ierr
+		parentPipe.Close()
+		logger.Warnf("Error finding grand-child pid %d: %s", pid.Pid, err)
+		return nil, nil, err
 	}
 
-	cmd.Wait()
-
-	return nil
+	return parentPipe, process, nil
 }
 
 func (e *NSenterEvent) ReceiveResponse() *domain.NSenterMessage {
@@ -611,6 +876,184 @@ func (e *NSenterEvent) processDirReadRequest() error {
 	return nil
 }
 
+func (e *NSenterEvent) processStatRequest() error {
+
+	payload := e.ReqMsg.Payload.(domain.StatPayload)
+
+	info, err := os.Lstat(payload.Entry)
+	if err != nil {
+		e.ResMsg = &domain.NSenterMessage{
+			Type:    domain.ErrorResponse,
+			Payload: &fuse.IOerror{RcvError: err},
+		}
+		return nil
+	}
+
+	// Create a response message.
+	e.ResMsg = &domain.NSenterMessage{
+		Type: domain.StatResponse,
+		Payload: domain.FileInfo{
+			Fname:    info.Name(),
+			Fsize:    info.Size(),
+			Fmode:    info.Mode(),
+			FmodTime: info.ModTime(),
+			FisDir:   info.IsDir(),
+			Fsys:     info.Sys().(*syscall.Stat_t),
+		},
+	}
+
+	return nil
+}
+
+func (e *NSenterEvent) processReadlinkRequest() error {
+
+	payload := e.ReqMsg.Payload.(domain.ReadlinkPayload)
+
+	target, err := os.Readlink(payload.Link)
+	if err != nil {
+		e.ResMsg = &domain.NSenterMessage{
+			Type:    domain.ErrorResponse,
+			Payload: &fuse.IOerror{RcvError: err},
+		}
+		return nil
+	}
+
+	// Create a response message.
+	e.ResMsg = &domain.NSenterMessage{
+		Type:    domain.ReadlinkResponse,
+		Payload: target,
+	}
+
+	return nil
+}
+
+func (e *NSenterEvent) processMkdirRequest() error {
+
+	payload := e.ReqMsg.Payload.(domain.MkdirPayload)
+
+	// Notice that the resulting permissions are subject to the umask of the
+	// nsenter process.
+	err := os.Mkdir(payload.Dir, payload.Mode)
+	if err != nil {
+		e.ResMsg = &domain.NSenterMessage{
+			Type:    domain.ErrorResponse,
+			Payload: &fuse.IOerror{RcvError: err},
+		}
+		return nil
+	}
+
+	// Create a response message.
+	e.ResMsg = &domain.NSenterMessage{
+		Type:    domain.MkdirResponse,
+		Payload: nil,
+	}
+
+	return nil
+}
+
+func (e *NSenterEvent) processChownRequest() error {
+
+	payload := e.ReqMsg.Payload.(domain.ChownPayload)
+
+	// Symlinks themselves are chown'ed, not their targets.
+	err := os.Lchown(payload.Entry, payload.Uid, payload.Gid)
+	if err != nil {
+		e.ResMsg = &domain.NSenterMessage{
+			Type:    domain.ErrorResponse,
+			Payload: &fuse.IOerror{RcvError: err},
+		}
+		return nil
+	}
+
+	// Create a response message.
+	e.ResMsg = &domain.NSenterMessage{
+		Type:    domain.ChownResponse,
+		Payload: nil,
+	}
+
+	return nil
+}
+
+//
+// Cgroup paths in /proc/<pid>/cgroup are displayed relative to the cgroup
+// namespace root of the reading process; hence, by reading this file within
+// the container's cgroup namespace, we obtain the container's view of them.
+//
+func (e *NSenterEvent) processCgroupRequest() error {
+
+	payload := e.ReqMsg.Payload.(domain.CgroupPayload)
+
+	data, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/cgroup", payload.Pid))
+	if err != nil {
+		e.ResMsg = &domain.NSenterMessage{
+			Type:    domain.ErrorResponse,
+			Payload: &fuse.IOerror{RcvError: err},
+		}
+		return nil
+	}
+
+	// Create a response message.
+	e.ResMsg = &domain.NSenterMessage{
+		Type:    domain.CgroupResponse,
+		Payload: parseCgroupFile(string(data)),
+	}
+
+	return nil
+}
+
+// Parses the content of a /proc/<pid>/cgroup file ("id:controllers:path"
+// entries) into a controller -> path map.
+func parseCgroupFile(data string) map[string]string {
+
+	paths := make(map[string]string)
+
+	for _, line := range strings.Split(data, "\n") {
+		fields := strings.SplitN(line, ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+
+		// Cgroup-v2 unified hierarchy.
+		if fields[1] == "" {
+			paths[""] = fields[2]
+			continue
+		}
+
+		// Co-mounted cgroup-v1 controllers (e.g. "cpu,cpuacct").
+		for _, ctrl := range strings.Split(fields[1], ",") {
+			paths[ctrl] = fields[2]
+		}
+	}
+
+	return paths
+}
+
+//
+// Network interfaces (and their statistics) are obtained within the network
+// namespace of the container; the response carries a domain.NetDevInfo.
+//
+func (e *NSenterEvent) processNetDevRequest() error {
+
+	payload := e.ReqMsg.Payload.(domain.NetDevPayload)
+
+	info, err := netDevInfo(payload.Interface)
+	if err != nil {
+		e.ResMsg = &domain.NSenterMessage{
+			Type:    domain.ErrorResponse,
+			Payload: &fuse.IOerror{RcvError: err},
+		}
+		return nil
+	}
+
+	// Create a response message.
+	e.ResMsg = &domain.NSenterMessage{
+		Type:    domain.NetDevResponse,
+		Payload: info,
+	}
+
+	return nil
+}
+
 func (e *NSenterEvent) processMountSyscallRequest() error {
 
 	var (
@@ -652,8 +1095,24 @@ func (e *NSenterEvent) processMountSyscallRequest() error {
 		}
 	}
 
+	// Targets of optional instructions found missing (see below).
+	skipped := make(map[string]bool)
+
 	// Perform mount instructions.
 	for i = 0; i < len(payload); i++ {
+		// Optional instructions (i.e. submounts) are skipped if their target
+		// is not present in the mounted file-system. The remounts of skipped
+		// submounts are skipped too.
+		if payload[i].Optional {
+			if skipped[payload[i].Target] {
+				continue
+			}
+			if _, serr := os.Lstat(payload[i].Target); os.IsNotExist(serr) {
+				skipped[payload[i].Target] = true
+				continue
+			}
+		}
+
 		err = unix.Mount(
 			payload[i].Source,
 			payload[i].Target,
@@ -672,6 +1131,9 @@ func (e *NSenterEvent) processMountSyscallRequest() error {
 		// TODO: ideally we would revert remounts too, but to do this we need information
 		// that we don't have at this stage.
 		for j := i - 1; j >= 0; j-- {
+			if skipped[payload[j].Target] {
+				continue
+			}
 			if payload[j].Flags&unix.MS_REMOUNT != unix.MS_REMOUNT {
 				_ = unix.Unmount(payload[j].Target, 0)
 			}
@@ -737,36 +1199,59 @@ func (e *NSenterEvent) processUmountSyscallRequest() error {
 	return nil
 }
 
-// Method in charge of processing all requests generated by sysbox-fs' master
-// instance.
-func (e *NSenterEvent) processRequest(pipe io.Reader) error {
+//
+// Serves each of the sub-requests of a batch, in order, within the current
+// process. Only requests that don't alter the state of the nsenter process
+// (see agentRequest()) can be batched, and batches can't be nested.
+//
+func (e *NSenterEvent) processBatchRequest(reqs [][]byte) error {
+
+	res := make(domain.BatchPayload, 0, len(reqs))
+
+	for _, r := range reqs {
+		sub := NSenterEvent{service: e.service}
+
+		f, err := decodeFrame(r)
+		if err == nil &&
+			(f.Type == domain.BatchRequest || !agentRequest(f.Type)) {
+			err = syscall.EINVAL
+		}
+		if err == nil {
+			err = sub.processRequestFrame(f)
+		}
+		if err != nil {
+			sub.ResMsg = &domain.NSenterMessage{
+				Type:    domain.ErrorResponse,
+				Payload: &fuse.IOerror{RcvError: err},
+			}
+		}
 
-	// Raw message payload to aid in decoding generic messages (see below
-	// explanation).
-	var payload json.RawMessage
-	nsenterMsg := domain.NSenterMessage{
-		Payload: &payload,
+		res = append(res, *sub.ResMsg)
 	}
 
-	// Decode received msg header to help us determine the payload type.
-	// Received message will be decoded in two phases. The decode instruction
-	// below help us determine the message-type being received. Based on the
-	// obtained type, we are able to decode the payload generated by the
-	// remote-end. This second step is executed as part of a subsequent
-	// unmarshal instruction (see further below).
-	if err := json.NewDecoder(pipe).Decode(&nsenterMsg); err != nil {
-		logrus.Warnf("Error decoding received nsenterMsg request (%v).", err)
-		return errors.New("Error decoding received event request.")
+	// Create a response message.
+	e.ResMsg = &domain.NSenterMessage{
+		Type:    domain.BatchResponse,
+		Payload: res,
 	}
 
+	return nil
+}
+
+// Method in charge of processing all requests generated by sysbox-fs' master
+// instance. Frames are decoded in two phases (see processResponse()).
+func (e *NSenterEvent) processRequestFrame(nsenterMsg *frame) error {
+
+	payload := nsenterMsg.Payload
+
 	switch nsenterMsg.Type {
 
 	case domain.LookupRequest:
 		var p domain.LookupPayload
 		if payload != nil {
-			err := json.Unmarshal(payload, &p)
+			err := decodePayload(payload, &p)
 			if err != nil {
-				logrus.Error(err)
+				logger.Error(err)
 				return err
 			}
 		}
@@ -780,9 +1265,9 @@ func (e *NSenterEvent) processRequest(pipe io.Reader) error {
 	case domain.OpenFileRequest:
 		var p domain.OpenFilePayload
 		if payload != nil {
-			err := json.Unmarshal(payload, &p)
+			err := decodePayload(payload, &p)
 			if err != nil {
-				logrus.Error(err)
+				logger.Error(err)
 				return err
 			}
 		}
@@ -796,9 +1281,9 @@ func (e *NSenterEvent) processRequest(pipe io.Reader) error {
 	case domain.ReadFileRequest:
 		var p domain.ReadFilePayload
 		if payload != nil {
-			err := json.Unmarshal(payload, &p)
+			err := decodePayload(payload, &p)
 			if err != nil {
-				logrus.Error(err)
+				logger.Error(err)
 				return err
 			}
 		}
@@ -812,9 +1297,9 @@ func (e *NSenterEvent) processRequest(pipe io.Reader) error {
 	case domain.WriteFileRequest:
 		var p domain.WriteFilePayload
 		if payload != nil {
-			err := json.Unmarshal(payload, &p)
+			err := decodePayload(payload, &p)
 			if err != nil {
-				logrus.Error(err)
+				logger.Error(err)
 				return err
 			}
 		}
@@ -828,9 +1313,9 @@ func (e *NSenterEvent) processRequest(pipe io.Reader) error {
 	case domain.ReadDirRequest:
 		var p domain.ReadDirPayload
 		if payload != nil {
-			err := json.Unmarshal(payload, &p)
+			err := decodePayload(payload, &p)
 			if err != nil {
-				logrus.Error(err)
+				logger.Error(err)
 				return err
 			}
 		}
@@ -841,12 +1326,108 @@ func (e *NSenterEvent) processRequest(pipe io.Reader) error {
 		}
 		return e.processDirReadRequest()
 
+	case domain.StatRequest:
+		var p domain.StatPayload
+		if payload != nil {
+			err := decodePayload(payload, &p)
+			if err != nil {
+				logger.Error(err)
+				return err
+			}
+		}
+
+		e.ReqMsg = &domain.NSenterMessage{
+			Type:    nsenterMsg.Type,
+			Payload: p,
+		}
+		return e.processStatRequest()
+
+	case domain.ReadlinkRequest:
+		var p domain.ReadlinkPayload
+		if payload != nil {
+			err := decodePayload(payload, &p)
+			if err != nil {
+				logger.Error(err)
+				return err
+			}
+		}
+
+		e.ReqMsg = &domain.NSenterMessage{
+			Type:    nsenterMsg.Type,
+			Payload: p,
+		}
+		return e.processReadlinkRequest()
+
+	case domain.MkdirRequest:
+		var p domain.MkdirPayload
+		if payload != nil {
+			err := decodePayload(payload, &p)
+			if err != nil {
+				logger.Error(err)
+				return err
+			}
+		}
+
+		e.ReqMsg = &domain.NSenterMessage{
+			Type:    nsenterMsg.Type,
+			Payload: p,
+		}
+		return e.processMkdirRequest()
+
+	case domain.ChownRequest:
+		var p domain.ChownPayload
+		if payload != nil {
+			err := decodePayload(payload, &p)
+			if err != nil {
+				logger.Error(err)
+				return err
+			}
+		}
+
+		e.ReqMsg = &domain.NSenterMessage{
+			Type:    nsenterMsg.Type,
+			Payload: p,
+		}
+		return e.processChownRequest()
+
+	case domain.CgroupRequest:
+		var p domain.CgroupPayload
+		if payload != nil {
+			err := decodePayload(payload, &p)
+			if err != nil {
+				logger.Error(err)
+				return err
+			}
+		}
+
+		e.ReqMsg = &domain.NSenterMessage{
+			Type:    nsenterMsg.Type,
+			Payload: p,
+		}
+		return e.processCgroupRequest()
+
+	case domain.NetDevRequest:
+		var p domain.NetDevPayload
+		if payload != nil {
+			err := decodePayload(payload, &p)
+			if err != nil {
+				logger.Error(err)
+				return err
+			}
+		}
+
+		e.ReqMsg = &domain.NSenterMessage{
+			Type:    nsenterMsg.Type,
+			Payload: p,
+		}
+		return e.processNetDevRequest()
+
 	// case domain.SetAttrRequest:
 	// 	var p domain.SetAttrPayload
 	// 	if payload != nil {
-	// 		err := json.Unmarshal(payload, &p)
+	// 		err := decodePayload(payload, &p)
 	// 		if err != nil {
-	// 			logrus.Error(err)
+	// 			logger.Error(err)
 	// 			return err
 	// 		}
 	// 	}
@@ -860,9 +1441,9 @@ func (e *NSenterEvent) processRequest(pipe io.Reader) error {
 	case domain.MountSyscallRequest:
 		var p []domain.MountSyscallPayload
 		if payload != nil {
-			err := json.Unmarshal(payload, &p)
+			err := decodePayload(payload, &p)
 			if err != nil {
-				logrus.Error(err)
+				logger.Error(err)
 				return err
 			}
 		}
@@ -877,9 +1458,9 @@ func (e *NSenterEvent) processRequest(pipe io.Reader) error {
 	case domain.UmountSyscallRequest:
 		var p []domain.UmountSyscallPayload
 		if payload != nil {
-			err := json.Unmarshal(payload, &p)
+			err := decodePayload(payload, &p)
 			if err != nil {
-				logrus.Error(err)
+				logger.Error(err)
 				return err
 			}
 		}
@@ -891,10 +1472,22 @@ func (e *NSenterEvent) processRequest(pipe io.Reader) error {
 
 		return e.processUmountSyscallRequest()
 
+	case domain.BatchRequest:
+		var p [][]byte
+		if payload != nil {
+			err := decodePayload(payload, &p)
+			if err != nil {
+				logger.Error(err)
+				return err
+			}
+		}
+
+		return e.processBatchRequest(p)
+
 	default:
 		e.ResMsg = &domain.NSenterMessage{
 			Type:    domain.ErrorResponse,
-			Payload: "Unsupported request",
+			Payload: &fuse.IOerror{RcvError: syscall.EINVAL},
 		}
 	}
 
@@ -922,34 +1515,54 @@ func Init() (err error) {
 	var pipe = os.NewFile(uintptr(pipefd), "pipe")
 	defer pipe.Close()
 
+	var nsenterService nsenterService
+	var processService = process.NewProcessService()
+
+	nsenterService.Setup(processService)
+
+	// Agents serve requests until sysbox-fs closes the pipe.
+	agent := os.Getenv(nsenterAgentEnv) != ""
+
 	// Clear the current process's environment to clean any libcontainer
 	// specific env vars.
 	os.Clearenv()
 
-	var nsenterService nsenterService
-	var processService = process.NewProcessService()
+	confined := false
 
-	nsenterService.Setup(processService)
-	var event = NSenterEvent{service: &nsenterService}
+	for {
+		var event = NSenterEvent{service: &nsenterService}
 
-	// Process incoming request.
-	err = event.processRequest(pipe)
-	if err != nil {
-		event.ResMsg = &domain.NSenterMessage{
-			Type:    domain.ErrorResponse,
-			Payload: &fuse.IOerror{RcvError: err},
+		// Process incoming request. The process is confined right before
+		// serving its first one (see confine.go).
+		f, err := readFrame(pipe)
+		if err == io.EOF {
+			// Agents are expected to be terminated through pipe closure.
+			return nil
+		}
+		if err != nil {
+			logger.Warnf("Error decoding received nsenterMsg request (%v).", err)
+			err = errors.New("Error decoding received event request.")
+		} else if !confined {
+			err = confine(f.Type)
+			confined = err == nil
+		}
+		if err == nil {
+			err = event.processRequestFrame(f)
+		}
+		if err != nil {
+			event.ResMsg = &domain.NSenterMessage{
+				Type:    domain.ErrorResponse,
+				Payload: &fuse.IOerror{RcvError: err},
+			}
 		}
-	}
 
-	// Encode / push response back to sysbox-main.
-	data, err := json.Marshal(*(event.ResMsg))
-	if err != nil {
-		return err
-	}
-	_, err = pipe.Write(data)
-	if err != nil {
-		return err
-	}
+		// Encode / push response back to sysbox-main.
+		if err := writeMessage(pipe, event.ResMsg); err != nil {
+			return err
+		}
 
-	return nil
+		if !agent {
+			return nil
+		}
+	}
 }
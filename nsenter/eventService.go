@@ -17,23 +17,73 @@
 package nsenter
 
 import (
+	"context"
+	"os"
+	"time"
+
 	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/logging"
 )
 
+// Logger of the nsenter subsystem.
+var logger = logging.Subsystem(logging.Nsenter)
+
+// Maximum duration of nsenter requests whose callers set no deadline.
+const requestTimeout = 30 * time.Second
+
 type nsenterService struct {
-	prs    domain.ProcessServiceIface // for process class interactions (capabilities)
-	reaper *zombieReaper
+	prs     domain.ProcessServiceIface // for process class interactions (capabilities)
+	reaper  *zombieReaper
+	agents  *agentSet // long-lived nsenter processes (see agent.go)
+	limiter *limiter  // nsenter concurrency limits (see limiter.go)
+	chaos   chaos     // injected faults (see chaos.go)
 }
 
 func NewNSenterService() domain.NSenterServiceIface {
+
+	reaper := newZombieReaper()
+
 	return &nsenterService{
-		reaper: newZombieReaper(),
+		reaper:  reaper,
+		agents:  newAgentSet(reaper),
+		limiter: newLimiter(0, 0),
 	}
 }
 
 func (s *nsenterService) Setup(prs domain.ProcessServiceIface) {
 
 	s.prs = prs
+	s.agents.prs = prs
+}
+
+// Releases the nsenter agents of the containers being unregistered.
+func (s *nsenterService) HandleContainerEvent(e domain.ContainerEvent) {
+
+	if e.Type != domain.ContainerUnregisterEvent {
+		return
+	}
+
+	initProc := e.Container.InitProc()
+	if initProc == nil {
+		return
+	}
+
+	userns, err := initProc.UserNsInode()
+	if err != nil {
+		return
+	}
+
+	if n := s.agents.reapContainer(userns); n > 0 {
+		logger.Debugf("Released %d nsenter agents of container %s", n,
+			e.Container.ID())
+	}
+}
+
+// Sets the maximum number of nsenter requests served concurrently, overall and
+// per container (zero means no limit). Meant to be called during setup.
+func (s *nsenterService) SetConcurrencyLimits(total, perContainer int) {
+
+	s.limiter = newLimiter(total, perContainer)
 }
 
 func (s *nsenterService) NewEvent(
@@ -44,15 +94,51 @@ func (s *nsenterService) NewEvent(
 
 	return &NSenterEvent{
 		Pid:       pid,
-		Namespace: ns,
+		Namespace: supportedNamespaces(ns),
 		ReqMsg:    req,
 		ResMsg:    res,
 		reaper:    s.reaper,
+		service:   s,
+	}
+}
+
+// Cgroup namespaces are only present in kernels 4.6+. In hosts lacking them
+// the cgroup namespace is left out of the namespaces to join, so that the
+// remaining ones can still be entered.
+var cgroupnsSupported = func() bool {
+	_, err := os.Stat("/proc/self/ns/cgroup")
+	return err == nil
+}()
+
+func supportedNamespaces(ns *[]domain.NStype) *[]domain.NStype {
+
+	if ns == nil || cgroupnsSupported {
+		return ns
+	}
+
+	filtered := make([]domain.NStype, 0, len(*ns))
+	for _, nstype := range *ns {
+		if nstype != domain.NStypeCgroup {
+			filtered = append(filtered, nstype)
+		}
 	}
+
+	return &filtered
 }
 
-func (s *nsenterService) SendRequestEvent(e domain.NSenterEventIface) error {
-	return e.SendRequest()
+func (s *nsenterService) SendRequestEvent(
+	ctx context.Context,
+	e domain.NSenterEventIface) error {
+
+	// Requests are bounded in time even if the caller set no deadline, so
+	// that a hung namespace operation can't block the caller forever.
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, requestTimeout)
+		defer cancel()
+	}
+
+	return e.SendRequest(ctx)
 }
 
 func (s *nsenterService) ReceiveResponseEvent(
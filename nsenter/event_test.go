@@ -0,0 +1,73 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package nsenter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseCgroupFile(t *testing.T) {
+
+	tests := []struct {
+		name string
+		data string
+		want map[string]string
+	}{
+		{
+			//
+			// Test-case 1: Cgroup-v1 hierarchies, including co-mounted and
+			// named ones.
+			//
+			name: "1",
+			data: "12:cpu,cpuacct:/\n" +
+				"5:memory:/inner\n" +
+				"1:name=systemd:/init.scope\n",
+			want: map[string]string{
+				"cpu":          "/",
+				"cpuacct":      "/",
+				"memory":       "/inner",
+				"name=systemd": "/init.scope",
+			},
+		},
+		{
+			//
+			// Test-case 2: Cgroup-v2 unified hierarchy.
+			//
+			name: "2",
+			data: "0::/system.slice/docker.service\n",
+			want: map[string]string{
+				"": "/system.slice/docker.service",
+			},
+		},
+		{
+			//
+			// Test-case 3: Empty file.
+			//
+			name: "3",
+			data: "",
+			want: map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, parseCgroupFile(tt.data))
+		})
+	}
+}
@@ -0,0 +1,165 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package nsenter
+
+import (
+	"context"
+	"sync"
+
+	"github.com/nestybox/sysbox-fs/domain"
+)
+
+//
+// Bounds the number of nsenter requests being concurrently served, both
+// globally and per container, so that a container spamming emulated resources
+// can't flood the host with nsenter processes. Requests exceeding the limits
+// are queued until a slot is released or their context expires.
+//
+// Containers are identified by the user namespace of the target process, as
+// every sys container has its own one.
+//
+// Notice that this only bounds the requests in flight. Live nsenter agents,
+// which outlast the requests, are bounded separately (see agent.go).
+//
+type limiter struct {
+	sync.Mutex
+	total        chan struct{}     // global slots (nil if unlimited)
+	perContainer int               // slots per container (0 if unlimited)
+	containers   map[uint64]*slots // per-container slots, indexed by userns inode
+}
+
+type slots struct {
+	sem   chan struct{}
+	users int // requests holding or waiting for a slot
+}
+
+func newLimiter(total, perContainer int) *limiter {
+
+	l := &limiter{
+		perContainer: perContainer,
+		containers:   make(map[uint64]*slots),
+	}
+
+	if total > 0 {
+		l.total = make(chan struct{}, total)
+	}
+
+	return l
+}
+
+// Waits for a slot to serve a request targeting the given pid. Returns the
+// function to release it.
+func (l *limiter) acquire(ctx context.Context, pid uint32) (func(), error) {
+
+	releaseContainer := func() {}
+
+	// The per-container slot is obtained first, so that requests queued by a
+	// busy container don't hold global slots while waiting.
+	if l.perContainer > 0 {
+		if key, ok := l.containerKey(pid); ok {
+			cs := l.get(key)
+			if err := wait(ctx, cs.sem); err != nil {
+				l.put(key, cs)
+				return nil, err
+			}
+			releaseContainer = func() {
+				<-cs.sem
+				l.put(key, cs)
+			}
+		}
+	}
+
+	if err := wait(ctx, l.total); err != nil {
+		releaseContainer()
+		return nil, err
+	}
+
+	return func() {
+		signal(l.total)
+		releaseContainer()
+	}, nil
+}
+
+// Returns the userns inode of the given pid. Processes already gone are
+// only subject to the global limit (their requests will fail anyway).
+func (l *limiter) containerKey(pid uint32) (uint64, bool) {
+
+	inodes, err := nsInodes(pid, []domain.NStype{domain.NStypeUser})
+	if err != nil {
+		return 0, false
+	}
+
+	return inodes[domain.NStypeUser], true
+}
+
+func (l *limiter) get(key uint64) *slots {
+
+	l.Lock()
+	defer l.Unlock()
+
+	cs, ok := l.containers[key]
+	if !ok {
+		cs = &slots{sem: make(chan struct{}, l.perContainer)}
+		l.containers[key] = cs
+	}
+	cs.users++
+
+	return cs
+}
+
+// Drops the per-container slots once no longer in use.
+func (l *limiter) put(key uint64, cs *slots) {
+
+	l.Lock()
+	defer l.Unlock()
+
+	cs.users--
+	if cs.users == 0 {
+		delete(l.containers, key)
+	}
+}
+
+// Takes a slot of the given semaphore (if any), waiting for it as long as the
+// context allows.
+func wait(ctx context.Context, sem chan struct{}) error {
+
+	if sem == nil {
+		return nil
+	}
+
+	select {
+	case sem <- struct{}{}:
+		return nil
+	default:
+	}
+
+	logger.Debug("nsenter concurrency limit reached, queueing request")
+
+	select {
+	case sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return contextError(ctx.Err())
+	}
+}
+
+func signal(sem chan struct{}) {
+
+	if sem != nil {
+		<-sem
+	}
+}
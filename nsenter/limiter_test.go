@@ -0,0 +1,93 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package nsenter
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/nestybox/sysbox-fs/fuse"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_limiter_PerContainer(t *testing.T) {
+
+	l := newLimiter(0, 2)
+	pid := uint32(os.Getpid())
+
+	ctx := context.Background()
+
+	r1, err := l.acquire(ctx, pid)
+	assert.Nil(t, err)
+	r2, err := l.acquire(ctx, pid)
+	assert.Nil(t, err)
+
+	// Third request queues until a slot is released.
+	acquired := make(chan func())
+	go func() {
+		r3, err := l.acquire(ctx, pid)
+		assert.Nil(t, err)
+		acquired <- r3
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("per-container limit not enforced")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	r1()
+
+	select {
+	case r3 := <-acquired:
+		r3()
+	case <-time.After(time.Second):
+		t.Fatal("queued request not served upon slot release")
+	}
+
+	r2()
+
+	// Per-container slots are dropped once unused.
+	assert.Empty(t, l.containers)
+}
+
+func Test_limiter_Expiry(t *testing.T) {
+
+	l := newLimiter(1, 0)
+	pid := uint32(os.Getpid())
+
+	release, err := l.acquire(context.Background(), pid)
+	assert.Nil(t, err)
+	defer release()
+
+	// Queued requests fail once their deadline expires.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err = l.acquire(ctx, pid)
+	assert.Equal(t, syscall.ETIMEDOUT, err.(fuse.IOerror).Code)
+
+	// Cancelled ones too.
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+
+	_, err = l.acquire(ctx, pid)
+	assert.Equal(t, syscall.EINTR, err.(fuse.IOerror).Code)
+}
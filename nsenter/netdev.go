@@ -0,0 +1,213 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package nsenter
+
+import (
+	"syscall"
+	"unsafe"
+
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+
+	"github.com/nestybox/sysbox-fs/domain"
+)
+
+// Netlink attribute carrying the 64-bit statistics of a link (struct
+// rtnl_link_stats64).
+const iflaStats64 = 23
+
+// Names of the rtnl_link_stats64 counters, in order, as presented by sysfs
+// (/sys/class/net/<if>/statistics).
+var netDevStats = []string{
+	"rx_packets",
+	"tx_packets",
+	"rx_bytes",
+	"tx_bytes",
+	"rx_errors",
+	"tx_errors",
+	"rx_dropped",
+	"tx_dropped",
+	"multicast",
+	"collisions",
+	"rx_length_errors",
+	"rx_over_errors",
+	"rx_crc_errors",
+	"rx_frame_errors",
+	"rx_fifo_errors",
+	"rx_missed_errors",
+	"tx_aborted_errors",
+	"tx_carrier_errors",
+	"tx_fifo_errors",
+	"tx_heartbeat_errors",
+	"tx_window_errors",
+	"rx_compressed",
+	"tx_compressed",
+	"rx_nohandler",
+}
+
+// RFC 2863 operational states, as presented by sysfs.
+var netDevOperStates = []string{
+	"unknown",
+	"notpresent",
+	"down",
+	"lowerlayerdown",
+	"testing",
+	"dormant",
+	"up",
+}
+
+//
+// Returns the attributes and statistics of the given network interface within
+// the network namespace of the calling process. These are obtained through
+// netlink (and the ethtool ioctl for the link speed), as the sysfs instance
+// reachable from sysbox-fs' mount namespace reflects the host's interfaces.
+//
+func netDevInfo(name string) (*domain.NetDevInfo, error) {
+
+	rib, err := syscall.NetlinkRIB(syscall.RTM_GETLINK, syscall.AF_UNSPEC)
+	if err != nil {
+		return nil, err
+	}
+
+	msgs, err := syscall.ParseNetlinkMessage(rib)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, m := range msgs {
+		if m.Header.Type != syscall.RTM_NEWLINK || len(m.Data) < syscall.SizeofIfInfomsg {
+			continue
+		}
+
+		attrs, err := syscall.ParseNetlinkRouteAttr(&m)
+		if err != nil {
+			return nil, err
+		}
+
+		info := &domain.NetDevInfo{Stats: make(map[string]uint64)}
+
+		var ifname string
+
+		for _, a := range attrs {
+			switch a.Attr.Type {
+			case syscall.IFLA_IFNAME:
+				ifname = string(a.Value[:clen(a.Value)])
+			case syscall.IFLA_MTU:
+				info.Mtu = int(nl.NativeEndian().Uint32(a.Value))
+			case syscall.IFLA_OPERSTATE:
+				info.OperState = netDevOperStates[0]
+				if len(a.Value) > 0 && int(a.Value[0]) < len(netDevOperStates) {
+					info.OperState = netDevOperStates[a.Value[0]]
+				}
+			case iflaStats64:
+				for i, stat := range netDevStats {
+					if len(a.Value) < (i+1)*8 {
+						break
+					}
+					info.Stats[stat] = nl.NativeEndian().Uint64(a.Value[i*8:])
+				}
+			}
+		}
+
+		if ifname != name {
+			continue
+		}
+
+		// As sysfs does, the speed is only reported for interfaces up.
+		ifi := (*syscall.IfInfomsg)(unsafe.Pointer(&m.Data[0]))
+		if ifi.Flags&syscall.IFF_UP != 0 {
+			if speed, err := netDevSpeed(name); err == nil {
+				info.Speed = &speed
+			}
+		}
+
+		return info, nil
+	}
+
+	return nil, syscall.ENODEV
+}
+
+// ethtool_cmd structure (ETHTOOL_GSET).
+type ethtoolCmd struct {
+	Cmd           uint32
+	Supported     uint32
+	Advertising   uint32
+	Speed         uint16
+	Duplex        uint8
+	Port          uint8
+	PhyAddress    uint8
+	Transceiver   uint8
+	Autoneg       uint8
+	MdioSupport   uint8
+	Maxtxpkt      uint32
+	Maxrxpkt      uint32
+	SpeedHi       uint16
+	EthTpMdix     uint8
+	EthTpMdixCtrl uint8
+	LpAdvertising uint32
+	Reserved      [2]uint32
+}
+
+// ifreq structure carrying a pointer to the ioctl's data.
+type ifreqData struct {
+	Name [unix.IFNAMSIZ]byte
+	Data uintptr
+	_    [16]byte
+}
+
+const ethtoolGset = 0x1
+
+// Returns the speed (Mbps) of the given interface, or -1 if unknown.
+func netDevSpeed(name string) (int64, error) {
+
+	fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_DGRAM, 0)
+	if err != nil {
+		return 0, err
+	}
+	defer syscall.Close(fd)
+
+	cmd := ethtoolCmd{Cmd: ethtoolGset}
+
+	var ifr ifreqData
+	copy(ifr.Name[:unix.IFNAMSIZ-1], name)
+	ifr.Data = uintptr(unsafe.Pointer(&cmd))
+
+	_, _, errno := syscall.Syscall(
+		syscall.SYS_IOCTL,
+		uintptr(fd),
+		unix.SIOCETHTOOL,
+		uintptr(unsafe.Pointer(&ifr)))
+	if errno != 0 {
+		return 0, errno
+	}
+
+	// SPEED_UNKNOWN (0xffffffff) is presented as -1.
+	speed := uint32(cmd.Speed) | uint32(cmd.SpeedHi)<<16
+
+	return int64(int32(speed)), nil
+}
+
+// Returns the length of the given nul-terminated string.
+func clen(b []byte) int {
+	for i := 0; i < len(b); i++ {
+		if b[i] == 0 {
+			return i
+		}
+	}
+
+	return len(b)
+}
@@ -21,7 +21,7 @@ import (
 	"syscall"
 	"time"
 
-	"github.com/sirupsen/logrus"
+	"github.com/nestybox/sysbox-fs/crash"
 )
 
 type zombieReaper struct {
@@ -35,7 +35,7 @@ func newZombieReaper() *zombieReaper {
 		signal: make(chan bool),
 	}
 
-	go reaper(zr.signal, &zr.mu)
+	crash.Go("nsenter zombie reaper", func() { reaper(zr.signal, &zr.mu) })
 	return zr
 }
 
@@ -50,7 +50,7 @@ func (zr *zombieReaper) nsenterEnded() {
 func (zr *zombieReaper) nsenterReapReq() {
 	select {
 	case zr.signal <- true:
-		logrus.Debugf("nsenter child reaping requested")
+		logger.Debugf("nsenter child reaping requested")
 	default:
 		// no action required (someone else has signaled already)
 	}
@@ -80,12 +80,12 @@ func reaper(signal chan bool, mu *sync.RWMutex) {
 			// WNOHANG: if there is no child to reap, don't block
 			wpid, err := syscall.Wait4(-1, &wstatus, syscall.WNOHANG, nil)
 			if err != nil || wpid == 0 {
-				logrus.Infof("reaper: nothing to reap")
+				logger.Infof("reaper: nothing to reap")
 				mu.Unlock()
 				break
 			}
 
-			logrus.Infof("reaper: reaped pid %d", wpid)
+			logger.Infof("reaper: reaped pid %d", wpid)
 			mu.Unlock()
 		}
 	}
@@ -123,6 +123,11 @@ func (p *process) IsSysAdminCapabilitySet() bool {
 	return p.isCapabilitySet(cap.EFFECTIVE, cap.CAP_SYS_ADMIN)
 }
 
+// Reports whether the given capability is part of the process' effective set.
+func (p *process) IsCapabilitySet(c domain.Capability) bool {
+	return p.isCapabilitySet(cap.EFFECTIVE, cap.Cap(c))
+}
+
 func (p *process) GetEffCaps() [2]uint32 {
 	if p.cap == nil {
 		if err := p.initCapability(); err != nil {
@@ -270,40 +275,132 @@ func (p *process) UserNsInode() (domain.Inode, error) {
 
 func (p *process) UserNsInodeParent() (domain.Inode, error) {
 
-	// ioctl to retrieve the parent namespace.
-	const NS_GET_PARENT = 0xb702
-
-	usernsPath := filepath.Join(
-		"/proc",
-		strconv.FormatUint(uint64(p.pid), 10),
-		"ns",
-		"user",
-	)
+	nsFd, err := os.Open(p.nsPath(string(domain.NStypeUser)))
+	if err != nil {
+		return 0, err
+	}
+	defer nsFd.Close()
 
-	// Open /proc/<pid>/ns/user to obtain a file-desc to refer to.
-	childNsFd, err := os.Open(usernsPath)
+	parentNsFd, err := nsParent(int(nsFd.Fd()))
 	if err != nil {
 		return 0, err
 	}
-	defer childNsFd.Close()
+	defer syscall.Close(parentNsFd)
+
+	return nsFdInode(parentNsFd)
+}
+
+//
+// Returns the inodes of the ancestors of the process' user-ns, closest first.
+// The user-ns hierarchy is walked up till the user-ns of sysbox-fs is reached
+// (beyond which the kernel refuses to go), which is included in the result.
+//
+func (p *process) UserNsInodeAncestors() ([]domain.Inode, error) {
+
+	f, err := os.Open(p.nsPath(string(domain.NStypeUser)))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var ancestors []domain.Inode
+
+	nsFd := int(f.Fd())
+
+	for {
+		parentNsFd, err := nsParent(nsFd)
+		if nsFd != int(f.Fd()) {
+			syscall.Close(nsFd)
+		}
+		if err == syscall.EPERM {
+			return ancestors, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		nsFd = parentNsFd
+
+		nsInode, err := nsFdInode(nsFd)
+		if err != nil {
+			syscall.Close(nsFd)
+			return nil, err
+		}
+		ancestors = append(ancestors, nsInode)
+	}
+}
+
+//
+// Reports whether the process' pid-ns is the one identified by the given
+// inode, or any of its descendants. The pid-ns hierarchy is walked up till
+// a match is found, or till the pid-ns of sysbox-fs is reached (beyond which
+// the kernel refuses to go).
+//
+func (p *process) PidNsWithin(inode domain.Inode) (bool, error) {
+
+	f, err := os.Open(p.nsPath(string(domain.NStypePid)))
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	nsFd := int(f.Fd())
+
+	for {
+		nsInode, err := nsFdInode(nsFd)
+		if err != nil {
+			return false, err
+		}
+		if nsInode == inode {
+			return true, nil
+		}
+
+		parentNsFd, err := nsParent(nsFd)
+		if nsFd != int(f.Fd()) {
+			syscall.Close(nsFd)
+		}
+		if err == syscall.EPERM {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+
+		nsFd = parentNsFd
+	}
+}
+
+// Returns the path of the given namespace of the process.
+func (p *process) nsPath(ns string) string {
+	return filepath.Join("/proc", strconv.FormatUint(uint64(p.pid), 10), "ns", ns)
+}
+
+// Returns a file-descriptor referring to the parent of the namespace referred
+// to by the given one.
+func nsParent(nsFd int) (int, error) {
+
+	// ioctl to retrieve the parent namespace.
+	const NS_GET_PARENT = 0xb702
 
-	// Launch ioctl to collect parent namespace fd.
 	ret, _, errno := unix.Syscall(
 		unix.SYS_IOCTL,
-		childNsFd.Fd(),
+		uintptr(nsFd),
 		uintptr(NS_GET_PARENT),
 		0)
 	if errno != 0 {
-		return 0, errno
+		return -1, errno
 	}
-	parentNsFd := (int)((uintptr)(unsafe.Pointer(ret)))
-	defer syscall.Close(parentNsFd)
 
-	// Run stat() over the returned file descriptor to obtain the inode that
-	// uniquely identifies the parent namespace.
+	return (int)((uintptr)(unsafe.Pointer(ret))), nil
+}
+
+// Runs stat() over the given namespace file-descriptor to obtain the inode
+// that uniquely identifies the namespace.
+func nsFdInode(nsFd int) (domain.Inode, error) {
+
 	var stat syscall.Stat_t
-	err = syscall.Fstat(parentNsFd, &stat)
-	if err != nil {
+
+	if err := syscall.Fstat(nsFd, &stat); err != nil {
 		return 0, err
 	}
 
@@ -17,11 +17,15 @@
 package process
 
 import (
+	"fmt"
 	"io/ioutil"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 	"syscall"
 	"testing"
+	"time"
 
 	"github.com/nestybox/sysbox-fs/domain"
 	cap "github.com/nestybox/sysbox-libs/capability"
@@ -903,6 +907,134 @@ func TestPathAccessSymlink(t *testing.T) {
 	}
 }
 
+func TestUserNsInodeAncestors(t *testing.T) {
+
+	// Process two user-ns levels below ours (unshare execs into sleep once
+	// its own user-ns is created).
+	cmd := exec.Command("unshare", "-U", "sleep", "30")
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Cloneflags:  syscall.CLONE_NEWUSER,
+		UidMappings: []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getuid(), Size: 1}},
+		GidMappings: []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getgid(), Size: 1}},
+	}
+	if err := cmd.Start(); err != nil {
+		t.Skipf("could not create nested user-ns: %v", err)
+	}
+	defer func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+
+	pid := cmd.Process.Pid
+
+	for i := 0; ; i++ {
+		comm, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/comm", pid))
+		if err == nil && strings.TrimSpace(string(comm)) == "sleep" {
+			break
+		}
+		if i == 100 {
+			t.Skipf("could not create nested user-ns: %v", err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	var st syscall.Stat_t
+	if err := syscall.Stat("/proc/self/ns/user", &st); err != nil {
+		t.Fatalf("failed to stat user-ns: %v", err)
+	}
+
+	p := &process{pid: uint32(pid)}
+
+	ancestors, err := p.UserNsInodeAncestors()
+	if err != nil {
+		t.Fatalf("UserNsInodeAncestors() failed: %v", err)
+	}
+
+	// The walk stops at our own user-ns.
+	if len(ancestors) != 2 || ancestors[1] != st.Ino || ancestors[0] == st.Ino {
+		t.Fatalf("UserNsInodeAncestors() = %v, want [<inode> %d]", ancestors, st.Ino)
+	}
+
+	p = &process{pid: uint32(os.Getpid())}
+
+	ancestors, err = p.UserNsInodeAncestors()
+	if err != nil || len(ancestors) != 0 {
+		t.Fatalf("UserNsInodeAncestors() = %v, %v; want none", ancestors, err)
+	}
+}
+
+func TestPidNsWithin(t *testing.T) {
+
+	// Process two pid-ns levels below ours, within our user-ns, as an inner
+	// container's process would be (e.g. docker without userns-remap). The
+	// unshare process is the init of the first level, and forks sleep into
+	// the second one.
+	cmd := exec.Command("unshare", "-p", "-f", "sleep", "30")
+	cmd.SysProcAttr = &syscall.SysProcAttr{Cloneflags: syscall.CLONE_NEWPID}
+	if err := cmd.Start(); err != nil {
+		t.Skipf("could not create nested pid-ns: %v", err)
+	}
+	defer func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+
+	pid := cmd.Process.Pid
+
+	var child int
+
+	for i := 0; ; i++ {
+		children, _ := ioutil.ReadFile(fmt.Sprintf("/proc/%d/task/%d/children", pid, pid))
+		fmt.Sscan(string(children), &child)
+		if child != 0 {
+			comm, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/comm", child))
+			if err == nil && strings.TrimSpace(string(comm)) == "sleep" {
+				break
+			}
+		}
+		if i == 100 {
+			t.Skipf("could not create nested pid-ns")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	nsInode := func(pid int, ns string) domain.Inode {
+		var st syscall.Stat_t
+		if err := syscall.Stat(fmt.Sprintf("/proc/%d/ns/%s", pid, ns), &st); err != nil {
+			t.Fatalf("failed to stat %s-ns of pid %d: %v", ns, pid, err)
+		}
+		return st.Ino
+	}
+
+	self := os.Getpid()
+
+	if nsInode(child, "user") != nsInode(self, "user") {
+		t.Fatalf("nested pid-ns process not within our user-ns")
+	}
+
+	p := &process{pid: uint32(child)}
+
+	// The pid-ns hierarchy is walked up through the intermediate level.
+	for _, inode := range []domain.Inode{
+		nsInode(child, "pid"),
+		nsInode(pid, "pid"),
+		nsInode(self, "pid"),
+	} {
+		ok, err := p.PidNsWithin(inode)
+		if err != nil || !ok {
+			t.Fatalf("PidNsWithin(%d) = %v, %v; want true", inode, ok, err)
+		}
+	}
+
+	// Ancestors aren't within their descendants.
+	p = &process{pid: uint32(pid)}
+
+	ok, err := p.PidNsWithin(nsInode(child, "pid"))
+	if err != nil || ok {
+		t.Fatalf("PidNsWithin(%d) = %v, %v; want false", nsInode(child, "pid"), ok, err)
+	}
+}
+
 // TODO:
 // * test symlink resolution limit
 // * test long path
@@ -17,6 +17,7 @@
 package seccomp
 
 import (
+	"context"
 	"fmt"
 	"path/filepath"
 	"strings"
@@ -27,6 +28,17 @@ import (
 	"golang.org/x/sys/unix"
 )
 
+//
+// Pseudo file-systems that can't be mounted within a sys container, indexed by
+// fs type, along with their usual mountpoints. Their mounts at these
+// mountpoints (e.g. by systemd units) are faked, as sysbox-fs already presents
+// a stub (empty) directory mounted on each of them (see SysStubDirHandler).
+//
+var stubMounts = map[string]string{
+	"bpf":        "/sys/fs/bpf",
+	"securityfs": "/sys/kernel/security",
+}
+
 // MountSyscall information structure.
 type mountSyscallInfo struct {
 	syscallCtx                  // syscall generic info
@@ -42,6 +54,12 @@ func (m *mountSyscallInfo) process() (*sysResponse, error) {
 	// sysbox-fs.
 	if mh.isNewMount(m.Flags) {
 
+		if m.isStubMount() {
+			logrus.Debugf("Faking %s mount over sysbox-fs stub at %s",
+				m.FsType, m.Target)
+			return m.tracer.createSuccessResponse(m.reqId), nil
+		}
+
 		mip, err := NewMountInfoParser(mh, m.cntr, m.pid, true)
 		if err != nil {
 			return nil, err
@@ -155,7 +173,7 @@ func (m *mountSyscallInfo) processProcMount(
 	)
 
 	// Launch nsenter-event.
-	err := nss.SendRequestEvent(event)
+	err := nss.SendRequestEvent(context.Background(), event)
 	if err != nil {
 		return nil, err
 	}
@@ -191,17 +209,22 @@ func (m *mountSyscallInfo) createProcPayload(
 		}
 	}
 
-	// Sysbox-fs "/proc" bind-mounts.
+	// Sysbox-fs "/proc" bind-mounts. These are flagged as optional, as some of
+	// the nodes emulated by sysbox-fs may not be present in the new procfs
+	// instance (e.g. per-netns sysctls of a netns where the corresponding
+	// kernel module hasn't been initialized yet). Those are skipped rather than
+	// failing the whole mount operation.
 	procBindMounts := m.tracer.mountHelper.procMounts
 	for _, v := range procBindMounts {
 		relPath := strings.TrimPrefix(v, "/proc")
 
 		newelem := &domain.MountSyscallPayload{
-			Source: v,
-			Target: filepath.Join(m.Target, relPath),
-			FsType: "",
-			Flags:  unix.MS_BIND,
-			Data:   "",
+			Source:   v,
+			Target:   filepath.Join(m.Target, relPath),
+			FsType:   "",
+			Flags:    unix.MS_BIND,
+			Data:     "",
+			Optional: true,
 		}
 		payload = append(payload, newelem)
 	}
@@ -215,11 +238,12 @@ func (m *mountSyscallInfo) createProcPayload(
 		relPath := strings.TrimPrefix(v, "/proc")
 
 		newelem := &domain.MountSyscallPayload{
-			Source: v,
-			Target: filepath.Join(m.Target, relPath),
-			FsType: "",
-			Flags:  unix.MS_BIND,
-			Data:   "",
+			Source:   v,
+			Target:   filepath.Join(m.Target, relPath),
+			FsType:   "",
+			Flags:    unix.MS_BIND,
+			Data:     "",
+			Optional: true,
 		}
 		payload = append(payload, newelem)
 	}
@@ -233,11 +257,12 @@ func (m *mountSyscallInfo) createProcPayload(
 		relPath := strings.TrimPrefix(v, "/proc")
 
 		newelem := &domain.MountSyscallPayload{
-			Source: v,
-			Target: filepath.Join(m.Target, relPath),
-			FsType: "",
-			Flags:  unix.MS_BIND,
-			Data:   "",
+			Source:   v,
+			Target:   filepath.Join(m.Target, relPath),
+			FsType:   "",
+			Flags:    unix.MS_BIND,
+			Data:     "",
+			Optional: true,
 		}
 		payload = append(payload, newelem)
 	}
@@ -254,8 +279,9 @@ func (m *mountSyscallInfo) createProcPayload(
 				Target: filepath.Join(m.Target, relPath),
 				FsType: "",
 				// TODO: Avoid hard-coding these flags.
-				Flags: unix.MS_RDONLY | unix.MS_BIND | unix.MS_REMOUNT | unix.MS_NOSUID | unix.MS_NODEV | unix.MS_NOEXEC,
-				Data:  "",
+				Flags:    unix.MS_RDONLY | unix.MS_BIND | unix.MS_REMOUNT | unix.MS_NOSUID | unix.MS_NODEV | unix.MS_NOEXEC,
+				Data:     "",
+				Optional: true,
 			}
 			payload = append(payload, newelem)
 		}
@@ -293,7 +319,7 @@ func (m *mountSyscallInfo) processSysMount(
 	)
 
 	// Launch nsenter-event.
-	err := nss.SendRequestEvent(event)
+	err := nss.SendRequestEvent(context.Background(), event)
 	if err != nil {
 		return nil, err
 	}
@@ -329,17 +355,18 @@ func (m *mountSyscallInfo) createSysPayload(
 		}
 	}
 
-	// Sysbox-fs "/sys" bind-mounts.
+	// Sysbox-fs "/sys" bind-mounts (optional, as in the "/proc" case).
 	sysBindMounts := m.tracer.mountHelper.sysMounts
 	for _, v := range sysBindMounts {
 		relPath := strings.TrimPrefix(v, "/sys")
 
 		newelem := &domain.MountSyscallPayload{
-			Source: v,
-			Target: filepath.Join(m.Target, relPath),
-			FsType: "",
-			Flags:  unix.MS_BIND,
-			Data:   "",
+			Source:   v,
+			Target:   filepath.Join(m.Target, relPath),
+			FsType:   "",
+			Flags:    unix.MS_BIND,
+			Data:     "",
+			Optional: true,
 		}
 		payload = append(payload, newelem)
 	}
@@ -356,8 +383,9 @@ func (m *mountSyscallInfo) createSysPayload(
 				Target: filepath.Join(m.Target, relPath),
 				FsType: "",
 				// TODO: Avoid hard-coding these flags.
-				Flags: unix.MS_RDONLY | unix.MS_BIND | unix.MS_REMOUNT | unix.MS_NOSUID | unix.MS_NODEV | unix.MS_NOEXEC,
-				Data:  "",
+				Flags:    unix.MS_RDONLY | unix.MS_BIND | unix.MS_REMOUNT | unix.MS_NOSUID | unix.MS_NODEV | unix.MS_NOEXEC,
+				Data:     "",
+				Optional: true,
 			}
 			payload = append(payload, newelem)
 		}
@@ -394,7 +422,7 @@ func (m *mountSyscallInfo) processOverlayMount(
 	)
 
 	// Launch nsenter-event.
-	err := nss.SendRequestEvent(event)
+	err := nss.SendRequestEvent(context.Background(), event)
 	if err != nil {
 		return nil, err
 	}
@@ -468,7 +496,7 @@ func (m *mountSyscallInfo) processNfsMount(
 	)
 
 	// Launch nsenter-event.
-	err := nss.SendRequestEvent(event)
+	err := nss.SendRequestEvent(context.Background(), event)
 	if err != nil {
 		return nil, err
 	}
@@ -524,7 +552,7 @@ func (m *mountSyscallInfo) processRemount(
 	)
 
 	// Launch nsenter-event.
-	err := nss.SendRequestEvent(event)
+	err := nss.SendRequestEvent(context.Background(), event)
 	if err != nil {
 		return nil, err
 	}
@@ -647,7 +675,7 @@ func (m *mountSyscallInfo) processBindMount(
 	)
 
 	// Launch nsenter-event.
-	err := nss.SendRequestEvent(event)
+	err := nss.SendRequestEvent(context.Background(), event)
 	if err != nil {
 		return nil, err
 	}
@@ -707,6 +735,18 @@ func (m *mountSyscallInfo) createBindMountPayload(
 // a 'root' attribute different than default one ("/"). This is typically the case
 // in 'chroot'ed environments. Method's goal is to make all the required adjustments
 // so that sysbox-fs can carry out the mount in the expected context.
+// Reports whether the mount request targets one of the stub mountpoints (see
+// stubMounts). Chroot'ed processes are left to mount these on their own.
+func (m *mountSyscallInfo) isStubMount() bool {
+
+	target, ok := stubMounts[m.FsType]
+	if !ok || m.root != "/" {
+		return false
+	}
+
+	return filepath.Clean(m.Target) == target
+}
+
 func (m *mountSyscallInfo) pathAdjust() {
 
 	root := m.syscallCtx.root
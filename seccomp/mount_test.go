@@ -0,0 +1,105 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package seccomp
+
+import (
+	"testing"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/sys/unix"
+)
+
+func Test_mountSyscallInfo_createSysPayload(t *testing.T) {
+
+	tracer := &syscallTracer{
+		mountHelper: &mountHelper{
+			sysMounts: []string{"/sys/kernel/mm/transparent_hugepage/enabled"},
+		},
+	}
+
+	mip := &mountInfoParser{
+		mpInfo: make(map[string]*mountInfo),
+		idInfo: make(map[int]*mountInfo),
+	}
+
+	m := &mountSyscallInfo{
+		syscallCtx: syscallCtx{tracer: tracer},
+		MountSyscallPayload: &domain.MountSyscallPayload{
+			Source: "sysfs",
+			Target: "/var/lib/docker/x/merged/sys",
+			FsType: "sysfs",
+			Flags:  unix.MS_RDONLY,
+		},
+	}
+
+	payload := *m.createSysPayload(mip)
+	assert.Equal(t, 3, len(payload))
+
+	// The sysfs mount itself is mandatory.
+	assert.Equal(t, "sysfs", payload[0].FsType)
+	assert.False(t, payload[0].Optional)
+
+	// Submounts (and their read-only remounts) are skipped if missing.
+	for _, p := range payload[1:] {
+		assert.Equal(t,
+			"/var/lib/docker/x/merged/sys/kernel/mm/transparent_hugepage/enabled",
+			p.Target)
+		assert.True(t, p.Optional)
+	}
+	assert.Equal(t, uint64(unix.MS_BIND), payload[1].Flags)
+	assert.NotZero(t, payload[2].Flags&unix.MS_REMOUNT)
+}
+
+func Test_mountSyscallInfo_isStubMount(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		fsType string
+		target string
+		root   string
+		want   bool
+	}{
+		// Test-case 1: Mounts at the usual mountpoints are faked.
+		{"1", "bpf", "/sys/fs/bpf", "/", true},
+		{"2", "securityfs", "/sys/kernel/security/", "/", true},
+
+		// Test-case 3: Other mountpoints are left alone.
+		{"3", "bpf", "/mnt/bpf", "/", false},
+
+		// Test-case 4: So are chroot'ed processes.
+		{"4", "bpf", "/sys/fs/bpf", "/var/chroot", false},
+
+		// Test-case 5: And other file-systems.
+		{"5", "tmpfs", "/sys/fs/bpf", "/", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &mountSyscallInfo{
+				syscallCtx: syscallCtx{root: tt.root},
+				MountSyscallPayload: &domain.MountSyscallPayload{
+					Source: tt.fsType,
+					Target: tt.target,
+					FsType: tt.fsType,
+				},
+			}
+
+			assert.Equal(t, tt.want, m.isStubMount())
+		})
+	}
+}
@@ -0,0 +1,100 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package seccomp
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+
+	"github.com/nestybox/sysbox-fs/domain"
+)
+
+type rebootSyscallInfo struct {
+	syscallCtx        // syscall generic info
+	cmd        uint32 // reboot command (LINUX_REBOOT_CMD_*)
+}
+
+// Reboot syscall processing wrapper instruction.
+//
+// Within a pid namespace other than the initial one, the kernel translates
+// restart / halt requests into the termination of the namespace's init
+// process, which is reported to its parent as killed by SIGHUP (restart) or
+// SIGINT (halt). Sys containers rely on this behavior: the kernel is let
+// handle the request, and restarts are additionally recorded in the container
+// state, so that sysbox peers can bring the container back up (with a fresh
+// uptime and boot_id) rather than leaving it stopped.
+func (r *rebootSyscallInfo) process() (*sysResponse, error) {
+
+	switch r.cmd {
+
+	// Ctrl-Alt-Del behavior is a host-wide setting, so there's nothing to do
+	// here. Calls are acknowledged though, as init processes (e.g. systemd)
+	// set it up during boot.
+	case unix.LINUX_REBOOT_CMD_CAD_ON, unix.LINUX_REBOOT_CMD_CAD_OFF:
+		return r.tracer.createSuccessResponse(r.reqId), nil
+
+	case unix.LINUX_REBOOT_CMD_RESTART, unix.LINUX_REBOOT_CMD_RESTART2:
+		// Requests coming from pid namespaces nested within the sys container
+		// only affect those (e.g. an inner container being restarted).
+		sameNs, err := r.isContainerPidNs()
+		if err != nil {
+			return nil, err
+		}
+
+		if sameNs {
+			logrus.Infof("Reboot requested by container %s (pid %d)",
+				r.cntr.ID(), r.pid)
+			r.cntr.SetRebootRequested()
+		}
+
+	case unix.LINUX_REBOOT_CMD_HALT, unix.LINUX_REBOOT_CMD_POWER_OFF:
+		logrus.Debugf("Halt requested from pid %d", r.pid)
+	}
+
+	return r.tracer.createContinueResponse(r.reqId), nil
+}
+
+// Returns true if the process generating the syscall lives in the pid
+// namespace of the sys container's init process (typically, it will be the
+// init process itself, e.g. systemd-shutdown).
+func (r *rebootSyscallInfo) isContainerPidNs() (bool, error) {
+
+	initProc := r.cntr.InitProc()
+	if initProc == nil {
+		return false, fmt.Errorf("missing init process for container %s",
+			r.cntr.ID())
+	}
+
+	initInodes, err := initProc.NsInodes()
+	if err != nil {
+		return false, err
+	}
+
+	process := r.tracer.sms.prs.ProcessCreate(r.pid, 0, 0)
+	inodes, err := process.NsInodes()
+	if err != nil {
+		return false, err
+	}
+
+	return inodes[domain.NStypePid] == initInodes[domain.NStypePid], nil
+}
+
+func (r *rebootSyscallInfo) String() string {
+	return fmt.Sprintf("cmd: %#x, pid: %d", r.cmd, r.pid)
+}
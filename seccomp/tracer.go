@@ -25,6 +25,7 @@ import (
 	"strings"
 	"syscall"
 
+	"github.com/nestybox/sysbox-fs/crash"
 	"github.com/nestybox/sysbox-fs/domain"
 	unixIpc "github.com/nestybox/sysbox-ipc/unix"
 	libseccomp "github.com/nestybox/sysbox-libs/libseccomp-golang"
@@ -119,8 +120,8 @@ func newSyscallTracer(sms *SyscallMonitorService) *syscallTracer {
 		tracer.syscalls[syscallId] = syscall
 	}
 
-	// Populate bind-mounts hashmap. Note that handlers are not operating at
-	// this point, so there's no need to acquire locks for this operation.
+	// Populate bind-mounts hashmap out of a snapshot of the handlerDB, which
+	// may be concurrently updated by a configuration reload.
 	handlerDB := sms.hds.HandlerDB()
 	if handlerDB == nil {
 		logrus.Warnf("Seccomp-tracer initialization error: missing handlerDB")
@@ -163,7 +164,7 @@ func (t *syscallTracer) start() error {
 	}
 	t.pollsrv = pollsrv
 
-	go t.sessionsMonitor()
+	crash.Go("seccomp sessions monitor", func() { t.sessionsMonitor() })
 
 	return nil
 }
@@ -476,9 +477,12 @@ func (t *syscallTracer) processUmount(
 	umount.root = process.Root()
 
 	// To simplify umount processing logic, convert to absolute path if dealing
-	// with a relative path request.
+	// with a relative path request. Absolute paths are cleaned up too, so that
+	// they can be matched against the mountpoints (e.g. "/proc/sys/").
 	if !filepath.IsAbs(umount.Target) {
 		umount.Target = filepath.Join(umount.cwd, umount.Target)
+	} else {
+		umount.Target = filepath.Clean(umount.Target)
 	}
 
 	// Process umount syscall.
@@ -488,21 +492,39 @@ func (t *syscallTracer) processUmount(
 func (t *syscallTracer) processReboot(
 	req *sysRequest,
 	fd int32,
-	cntrID domain.ContainerIface) (*sysResponse, error) {
+	cntr domain.ContainerIface) (*sysResponse, error) {
+
+	logrus.Debugf("Received reboot syscall from pid %d", req.Pid)
+
+	reboot := &rebootSyscallInfo{
+		syscallCtx: syscallCtx{
+			syscallNum: int32(req.Data.Syscall),
+			reqId:      req.Id,
+			pid:        req.Pid,
+			cntr:       cntr,
+			tracer:     t,
+		},
+		cmd: uint32(req.Data.Args[2]),
+	}
 
-	logrus.Warnf("Received reboot syscall")
+	logrus.Debug(reboot)
 
-	return t.createSuccessResponse(req.Id), nil
+	// Process reboot syscall.
+	return reboot.process()
 }
 
+// Swap devices are a host-wide resource, so sys containers are not allowed to
+// activate or deactivate them. Both swapon and swapoff are rejected with EPERM
+// (just as the kernel does for unprivileged processes), which is coherent with
+// the emulated /proc/swaps, where no swap device is ever listed.
 func (t *syscallTracer) processSwapon(
 	req *sysRequest,
 	fd int32,
 	cntr domain.ContainerIface) (*sysResponse, error) {
 
-	logrus.Warnf("Received swapon syscall")
+	logrus.Debugf("Rejecting swapon syscall from pid %d", req.Pid)
 
-	return t.createSuccessResponse(req.Id), nil
+	return t.createErrorResponse(req.Id, syscall.EPERM), nil
 }
 
 func (t *syscallTracer) processSwapoff(
@@ -510,9 +532,9 @@ func (t *syscallTracer) processSwapoff(
 	fd int32,
 	cntr domain.ContainerIface) (*sysResponse, error) {
 
-	logrus.Warnf("Received swapoff syscall")
+	logrus.Debugf("Rejecting swapoff syscall from pid %d", req.Pid)
 
-	return t.createSuccessResponse(req.Id), nil
+	return t.createErrorResponse(req.Id, syscall.EPERM), nil
 }
 
 // processMemParser iterates through the tracee process' /proc/pid/mem file to
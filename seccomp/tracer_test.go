@@ -96,3 +96,24 @@ func Test_syscallTracer_createErrorResponse(t *testing.T) {
 		})
 	}
 }
+
+func Test_syscallTracer_processSwap(t *testing.T) {
+
+	tracer := &syscallTracer{}
+
+	want := &sysResponse{
+		Id:    1,
+		Error: int32(syscall.EPERM),
+	}
+
+	// Both swapon and swapoff must be rejected with EPERM.
+	resp, err := tracer.processSwapon(&sysRequest{Id: 1}, 0, nil)
+	if err != nil || !reflect.DeepEqual(resp, want) {
+		t.Errorf("syscallTracer.processSwapon() = %v, %v, want %v", resp, err, want)
+	}
+
+	resp, err = tracer.processSwapoff(&sysRequest{Id: 1}, 0, nil)
+	if err != nil || !reflect.DeepEqual(resp, want) {
+		t.Errorf("syscallTracer.processSwapoff() = %v, %v, want %v", resp, err, want)
+	}
+}
@@ -17,6 +17,7 @@
 package seccomp
 
 import (
+	"context"
 	"fmt"
 	"path/filepath"
 	"syscall"
@@ -57,21 +58,22 @@ func (u *umountSyscallInfo) process() (*sysResponse, error) {
 
 	if mip.IsSysboxfsBaseMount(u.Target) {
 
-		// Special case: disallow unmounting of /proc; we must do this because we
+		// Special case: never unmount /proc; we must do this because we
 		// use /proc as the source of other procfs mounts within the container
 		// (i.e., if a new procfs is mounted at /some/path/inside/container/proc,
 		// we bind-mount /proc/uptime to /some/path/inside/container/proc/uptime).
-		// This restriction should be fine because unmounting /proc inside the
-		// container is not a useful thing to do anyways. Same rationale applies
-		// to "/sys".
+		// Same rationale applies to "/sys". The unmount is faked though, as
+		// failing it would break shutdown sequences (e.g. systemd's) that
+		// unmount all file-systems before halting the container.
 		//
 		// Also, notice that we want to clearly differentiate the root /proc and
 		// /sys mounts from those that are present within 'chroot'ed contexts. In
 		// the later case we want to allow users to mount (and umount) both /proc
 		// and /sys file-systems.
 		if (u.Target == "/proc" || u.Target == "/sys") && (u.syscallCtx.root == "/") {
-			resp := u.tracer.createErrorResponse(u.reqId, syscall.EBUSY)
-			return resp, nil
+			logrus.Debugf("Ignoring unmount of sysbox-fs managed base mount at %s",
+				u.Target)
+			return u.tracer.createSuccessResponse(u.reqId), nil
 		}
 
 		// If under the base mount there are any submounts *not* managed by
@@ -137,7 +139,7 @@ func (u *umountSyscallInfo) processUmount(
 	)
 
 	// Launch nsenter-event.
-	err := nss.SendRequestEvent(event)
+	err := nss.SendRequestEvent(context.Background(), event)
 	if err != nil {
 		return nil, err
 	}
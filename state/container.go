@@ -18,8 +18,10 @@ package state
 
 import (
 	"fmt"
+	"reflect"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/nestybox/sysbox-fs/domain"
@@ -39,9 +41,22 @@ type container struct {
 	gidSize       uint32                            // Gid range size
 	procRoPaths   []string                          // OCI spec read-only proc paths
 	procMaskPaths []string                          // OCI spec masked proc paths
+	uidMappings   []domain.IDMapping                // user-ns uid mappings
+	gidMappings   []domain.IDMapping                // user-ns gid mappings
+	cgroupPaths   domain.CgroupPaths                // cgroup v1/v2 paths
+	hostname      string                            // container's hostname
+	limits        domain.ResourceLimits             // cpu/memory resource limits
 	specPaths     map[string]struct{}               // OCI spec hashmap including all paths
 	dataStore     domain.StateDataMap               // Handler's container-specific storage blob
+	overrides     map[string]domain.NodeOverride    // emulated resources' overrides
+	policies      map[string]domain.ValuePolicy     // emulated resources' value bounds
+	mountOpts     domain.MountOptions               // mount options of the emulated view
 	initProc      domain.ProcessIface               // container's init process
+	parent        *container                        // parent container (nested sys containers)
+	children      map[string]*container             // child containers (nested sys containers)
+	ready         chan struct{}                     // closed upon pre-registration warm-up completion
+	reboot        bool                              // restart requested from within the container
+	ops           domain.ContainerOpStats           // fuse ops accounting (atomically updated)
 	service       domain.ContainerStateServiceIface // backpointer to service
 }
 
@@ -98,6 +113,41 @@ func (c *container) ProcMaskPaths() []string {
 	return c.procMaskPaths
 }
 
+func (c *container) UidMappings() []domain.IDMapping {
+	c.RLock()
+	defer c.RUnlock()
+
+	return c.uidMappings
+}
+
+func (c *container) GidMappings() []domain.IDMapping {
+	c.RLock()
+	defer c.RUnlock()
+
+	return c.gidMappings
+}
+
+func (c *container) CgroupPaths() domain.CgroupPaths {
+	c.RLock()
+	defer c.RUnlock()
+
+	return c.cgroupPaths
+}
+
+func (c *container) Hostname() string {
+	c.RLock()
+	defer c.RUnlock()
+
+	return c.hostname
+}
+
+func (c *container) Limits() domain.ResourceLimits {
+	c.RLock()
+	defer c.RUnlock()
+
+	return c.limits
+}
+
 func (c *container) IsSpecPath(s string) bool {
 	c.RLock()
 	defer c.RUnlock()
@@ -122,7 +172,72 @@ func (c *container) Data(path string, name string) (string, bool) {
 		return "", false
 	}
 
-	return c.dataStore[path][name], true
+	return c.dataStore[path][name].String(), true
+}
+
+func (c *container) DataValue(path string, name string) (domain.StateValue, bool) {
+	c.RLock()
+	defer c.RUnlock()
+
+	val, ok := c.dataStore[path][name]
+
+	return val, ok
+}
+
+// Number of entries held in the container's data store.
+func (c *container) DataSize() int {
+	c.RLock()
+	defer c.RUnlock()
+
+	var n int
+	for _, entries := range c.dataStore {
+		n += len(entries)
+	}
+
+	return n
+}
+
+func (c *container) Override(path string) (domain.NodeOverride, bool) {
+	c.RLock()
+	defer c.RUnlock()
+
+	o, ok := c.overrides[path]
+
+	return o, ok
+}
+
+func (c *container) Policy(path string) (domain.ValuePolicy, bool) {
+	c.RLock()
+	defer c.RUnlock()
+
+	p, ok := c.policies[path]
+
+	return p, ok
+}
+
+func (c *container) MountOptions() domain.MountOptions {
+	c.RLock()
+	defer c.RUnlock()
+
+	return c.mountOpts
+}
+
+func (c *container) RebootRequested() bool {
+	c.RLock()
+	defer c.RUnlock()
+
+	return c.reboot
+}
+
+func (c *container) OpStats() domain.ContainerOpStats {
+	return domain.ContainerOpStats{
+		Lookups:  atomic.LoadUint64(&c.ops.Lookups),
+		Opens:    atomic.LoadUint64(&c.ops.Opens),
+		Reads:    atomic.LoadUint64(&c.ops.Reads),
+		Writes:   atomic.LoadUint64(&c.ops.Writes),
+		ReadDirs: atomic.LoadUint64(&c.ops.ReadDirs),
+		Errors:   atomic.LoadUint64(&c.ops.Errors),
+	}
 }
 
 func (c *container) InitProc() domain.ProcessIface {
@@ -132,6 +247,42 @@ func (c *container) InitProc() domain.ProcessIface {
 	return c.initProc
 }
 
+func (c *container) Parent() domain.ContainerIface {
+	c.RLock()
+	defer c.RUnlock()
+
+	if c.parent == nil {
+		return nil
+	}
+
+	return c.parent
+}
+
+func (c *container) Children() []domain.ContainerIface {
+	c.RLock()
+	defer c.RUnlock()
+
+	var children []domain.ContainerIface
+
+	for _, child := range c.children {
+		children = append(children, child)
+	}
+
+	return children
+}
+
+// Level returns the nesting level of the container: zero for containers
+// launched at host level, one for those launched within these, and so on.
+func (c *container) Level() uint {
+	var level uint
+
+	for p := c.Parent(); p != nil; p = p.Parent() {
+		level++
+	}
+
+	return level
+}
+
 // String() specialization for container type.
 func (c *container) String() string {
 	c.RLock()
@@ -154,6 +305,11 @@ func (c *container) update(src *container) error {
 	c.Lock()
 	defer c.Unlock()
 
+	// Pre-registered containers lack a backpointer to the service.
+	if c.service == nil {
+		c.service = src.service
+	}
+
 	if c.initPid != src.initPid {
 		// Initialize initProc.
 		c.initProc = src.service.ProcessService().ProcessCreate(
@@ -184,16 +340,199 @@ func (c *container) update(src *container) error {
 		c.gidSize = src.gidSize
 	}
 
-	// Unconditional malloc + copy -- think about how to optimize if no changes
-	// are detected.
-	c.procRoPaths = make([]string, len(src.procRoPaths))
-	copy(c.procRoPaths, src.procRoPaths)
-	c.procMaskPaths = make([]string, len(src.procMaskPaths))
-	copy(c.procMaskPaths, src.procMaskPaths)
+	// The spec-paths index is only rebuilt if not already done during the
+	// pre-registration phase.
+	if c.specPaths == nil ||
+		!reflect.DeepEqual(c.procRoPaths, src.procRoPaths) ||
+		!reflect.DeepEqual(c.procMaskPaths, src.procMaskPaths) {
+
+		c.procRoPaths = make([]string, len(src.procRoPaths))
+		copy(c.procRoPaths, src.procRoPaths)
+		c.procMaskPaths = make([]string, len(src.procMaskPaths))
+		copy(c.procMaskPaths, src.procMaskPaths)
+		c.buildSpecPaths()
+	}
+
+	if src.uidMappings != nil {
+		c.uidMappings = make([]domain.IDMapping, len(src.uidMappings))
+		copy(c.uidMappings, src.uidMappings)
+	}
+
+	if src.gidMappings != nil {
+		c.gidMappings = make([]domain.IDMapping, len(src.gidMappings))
+		copy(c.gidMappings, src.gidMappings)
+	}
+
+	if cgroupPathsSet(src.cgroupPaths) {
+		c.cgroupPaths = cloneCgroupPaths(src.cgroupPaths)
+	}
+
+	if c.hostname != src.hostname {
+		c.hostname = src.hostname
+	}
+
+	if c.limits != src.limits {
+		c.limits = src.limits
+	}
+
+	// Mount options may be conveyed either during pre-registration or
+	// registration.
+	if !src.mountOpts.IsZero() {
+		c.mountOpts = cloneMountOptions(src.mountOpts)
+	}
 
 	return nil
 }
 
+// buildSpecPaths indexes the OCI spec paths (read-only and masked) of the
+// container. Callers must hold the container lock.
+func (c *container) buildSpecPaths() {
+	c.specPaths = make(map[string]struct{},
+		len(c.procRoPaths)+len(c.procMaskPaths))
+
+	for _, p := range c.procRoPaths {
+		c.specPaths[p] = struct{}{}
+	}
+	for _, p := range c.procMaskPaths {
+		c.specPaths[p] = struct{}{}
+	}
+}
+
+// cgroupPathsSet reports whether the given cgroup paths were conveyed by the
+// peer. A nil v1 map along with an empty v2 path stands for 'not conveyed',
+// whereas an empty (non-nil) v1 map stands for 'no v1 paths'.
+func cgroupPathsSet(p domain.CgroupPaths) bool {
+	return p.V1 != nil || p.V2 != ""
+}
+
+// cgroupPathsEqual reports whether the given cgroup paths are the same ones
+// (nil and empty v1 maps being equivalent).
+func cgroupPathsEqual(a, b domain.CgroupPaths) bool {
+	if a.V2 != b.V2 || len(a.V1) != len(b.V1) {
+		return false
+	}
+	for k, v := range a.V1 {
+		if w, ok := b.V1[k]; !ok || w != v {
+			return false
+		}
+	}
+
+	return true
+}
+
+// cloneCgroupPaths returns a deep copy of the given cgroup paths.
+func cloneCgroupPaths(p domain.CgroupPaths) domain.CgroupPaths {
+	v1 := make(map[string]string, len(p.V1))
+	for k, v := range p.V1 {
+		v1[k] = v
+	}
+
+	return domain.CgroupPaths{V1: v1, V2: p.V2}
+}
+
+// cloneMountOptions returns a deep copy of the given mount options.
+func cloneMountOptions(o domain.MountOptions) domain.MountOptions {
+	if o.HiddenPaths != nil {
+		o.HiddenPaths = append([]string(nil), o.HiddenPaths...)
+	}
+	if o.BlockDevices != nil {
+		o.BlockDevices = append([]string(nil), o.BlockDevices...)
+	}
+
+	return o
+}
+
+// waitReady waits for the pre-registration warm-up of the container to
+// complete. Returns 'false' if it didn't within the given timeout.
+func (c *container) waitReady(timeout time.Duration) bool {
+	c.RLock()
+	ready := c.ready
+	c.RUnlock()
+
+	if ready == nil {
+		return true
+	}
+
+	select {
+	case <-ready:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// updateResources refreshes the resource-related attributes (cgroup paths and
+// limits) of a registered container. Attributes not present in the source
+// container are left untouched. Returns 'true' if any change was applied.
+func (c *container) updateResources(src *container) bool {
+	c.Lock()
+	defer c.Unlock()
+
+	var changed bool
+
+	if cgroupPathsSet(src.cgroupPaths) &&
+		!cgroupPathsEqual(c.cgroupPaths, src.cgroupPaths) {
+		c.cgroupPaths = cloneCgroupPaths(src.cgroupPaths)
+		changed = true
+	}
+
+	if src.limits != (domain.ResourceLimits{}) && c.limits != src.limits {
+		c.limits = src.limits
+		changed = true
+	}
+
+	return changed
+}
+
+// invalidateData drops the container-specific state stored by handlers for the
+// given paths, which forces them to regenerate it during the next access.
+func (c *container) invalidateData(paths ...string) {
+	c.Lock()
+	defer c.Unlock()
+
+	for _, p := range paths {
+		delete(c.dataStore, p)
+	}
+}
+
+// setParent links the container to its parent container. Callers must hold the
+// service lock.
+func (c *container) setParent(parent *container) {
+	c.Lock()
+	c.parent = parent
+	c.Unlock()
+
+	parent.Lock()
+	if parent.children == nil {
+		parent.children = make(map[string]*container)
+	}
+	parent.children[c.id] = c
+	parent.Unlock()
+}
+
+// detach unlinks the container from its parent and children (if any). Callers
+// must hold the service lock.
+func (c *container) detach() {
+	c.Lock()
+	parent := c.parent
+	children := c.children
+	c.parent = nil
+	c.children = nil
+	c.Unlock()
+
+	if parent != nil {
+		parent.Lock()
+		delete(parent.children, c.id)
+		parent.Unlock()
+	}
+
+	for _, child := range children {
+		child.Lock()
+		child.parent = nil
+		child.Unlock()
+	}
+}
+
 func (c *container) SetCtime(t time.Time) {
 	c.Lock()
 	defer c.Unlock()
@@ -202,8 +541,33 @@ func (c *container) SetCtime(t time.Time) {
 }
 
 func (c *container) SetData(path string, name string, data string) {
+	val, _ := domain.NewStateValue(data)
+
+	c.SetDataValue(path, name, val, 0)
+}
+
+//
+// SetDataValue stores a typed value (string, int, bool, or any serializable
+// structured value) in the container's data-store. If a non-zero version is
+// passed, the update is only carried out if it matches the one of the stored
+// value (ErrDataVersionConflict returned otherwise). Returns the version of the
+// updated value.
+//
+func (c *container) SetDataValue(
+	path string,
+	name string,
+	val interface{},
+	version uint64) (uint64, error) {
+
+	newVal, ok := val.(domain.StateValue)
+	if !ok {
+		var err error
+		if newVal, err = domain.NewStateValue(val); err != nil {
+			return 0, err
+		}
+	}
+
 	c.Lock()
-	defer c.Unlock()
 
 	if c.dataStore == nil {
 		c.dataStore = make(domain.StateDataMap)
@@ -213,7 +577,116 @@ func (c *container) SetData(path string, name string, data string) {
 		c.dataStore[path] = make(domain.StateData)
 	}
 
-	c.dataStore[path][name] = data
+	curVal := c.dataStore[path][name]
+	if version != 0 && version != curVal.Version() {
+		c.Unlock()
+		return curVal.Version(), domain.ErrDataVersionConflict
+	}
+
+	newVersion := curVal.Version() + 1
+	newVal = newVal.WithVersion(newVersion)
+	c.dataStore[path][name] = newVal
+	c.Unlock()
+
+	// Journal the updated state. Notice that this must be done without
+	// holding the container lock.
+	if css, ok := c.service.(*containerStateService); ok {
+		css.journalAppend(c.id, path, name, newVal)
+	}
+
+	return newVersion, nil
+}
+
+//
+// SetOverrides replaces the set of overrides of the container's emulated
+// resources, which is keyed by resource path.
+//
+func (c *container) SetOverrides(o map[string]domain.NodeOverride) {
+	c.Lock()
+	c.overrides = make(map[string]domain.NodeOverride, len(o))
+	for path, override := range o {
+		c.overrides[path] = override
+	}
+	c.Unlock()
+
+	if css, ok := c.service.(*containerStateService); ok {
+		css.checkpointAsync()
+	}
+}
+
+//
+// SetPolicies replaces the set of value bounds of the container's emulated
+// resources, which is keyed by resource path.
+//
+func (c *container) SetPolicies(p map[string]domain.ValuePolicy) {
+	c.Lock()
+	c.policies = make(map[string]domain.ValuePolicy, len(p))
+	for path, policy := range p {
+		c.policies[path] = policy
+	}
+	c.Unlock()
+
+	if css, ok := c.service.(*containerStateService); ok {
+		css.checkpointAsync()
+	}
+}
+
+//
+// SetMountOptions sets the mount options of the container's emulated view.
+// These are expected to be set once, prior to the container's registration.
+//
+func (c *container) SetMountOptions(o domain.MountOptions) {
+	c.Lock()
+	c.mountOpts = cloneMountOptions(o)
+	c.Unlock()
+}
+
+//
+// SetRebootRequested records that the container asked to be restarted (i.e.
+// reboot(2) issued from within it). The restart itself is up to the sysbox
+// peers, which are notified through a reboot event the first time the request
+// is made, and can also learn about it through container queries.
+//
+func (c *container) SetRebootRequested() {
+	c.Lock()
+	if c.reboot {
+		c.Unlock()
+		return
+	}
+	c.reboot = true
+	c.Unlock()
+
+	if css, ok := c.service.(*containerStateService); ok {
+		css.bus.publish(domain.ContainerEvent{
+			Type:      domain.ContainerRebootEvent,
+			Container: c,
+		})
+	}
+}
+
+//
+// Accounts a fuse operation served on behalf of the container. Counters are
+// atomically updated (rather than under the container lock) as this is
+// invoked for every single fuse request.
+//
+func (c *container) AccountOp(op domain.FuseOp, failed bool) {
+
+	switch op {
+	case domain.FuseOpLookup:
+		atomic.AddUint64(&c.ops.Lookups, 1)
+	case domain.FuseOpOpen:
+		atomic.AddUint64(&c.ops.Opens, 1)
+	case domain.FuseOpRead:
+		atomic.AddUint64(&c.ops.Reads, 1)
+	case domain.FuseOpWrite:
+		atomic.AddUint64(&c.ops.Writes, 1)
+	case domain.FuseOpReadDir:
+		atomic.AddUint64(&c.ops.ReadDirs, 1)
+	}
+
+	if failed {
+		atomic.AddUint64(&c.ops.Errors, 1)
+	}
 }
 
 // Exclusively utilized for unit-testing purposes.
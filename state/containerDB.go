@@ -17,6 +17,7 @@
 package state
 
 import (
+	"sort"
 	"sync"
 	"time"
 
@@ -24,9 +25,22 @@ import (
 	grpcCodes "google.golang.org/grpc/codes"
 	grpcStatus "google.golang.org/grpc/status"
 
+	"github.com/nestybox/sysbox-fs/crash"
 	"github.com/nestybox/sysbox-fs/domain"
 )
 
+// Emulated resources whose content is a function of the container's resource
+// limits (cpuset, memory, etc). Any state cached for these must be discarded
+// whenever the limits are modified (e.g. 'docker update').
+var limitsDependentPaths = []string{
+	"/proc/cpuinfo",
+	"/proc/meminfo",
+}
+
+// Maximum time that container registrations wait for the pre-registration
+// warm-up to complete.
+const preRegisterWarmUpTimeout = 5 * time.Second
+
 type containerStateService struct {
 	sync.RWMutex
 
@@ -46,6 +60,40 @@ type containerStateService struct {
 
 	// Pointer to the service providing file-system I/O capabilities.
 	ios domain.IOServiceIface
+
+	// Directory where container-state is checkpointed. Persistence is disabled
+	// if empty.
+	stateDir string
+
+	// Serializes checkpoint write-outs.
+	persistLock sync.Mutex
+
+	// Asynchronous checkpoint requests (see checkpointAsync()), and flusher's
+	// termination channels.
+	flushCh     chan struct{}
+	flushStop   chan struct{}
+	flushDone   chan struct{}
+	flusherOnce sync.Once
+
+	// Data-store updates carried out since the last checkpoint (see
+	// journal.go).
+	journal dataJournal
+
+	// Sharded lock to serialize container-table lookups against table
+	// modifications (see locks.go).
+	tables shardedLock
+
+	// Pid-ns inode -> container cache (see pidcache.go).
+	pidns pidnsCache
+
+	// Container lifecycle events' subscribers.
+	bus eventBus
+
+	// Stale-container reaper's termination channel.
+	reaperStop chan struct{}
+
+	// Set once the service has been drained (see ContainerDBDrain()).
+	draining bool
 }
 
 func NewContainerStateService() domain.ContainerStateServiceIface {
@@ -61,11 +109,22 @@ func NewContainerStateService() domain.ContainerStateServiceIface {
 func (css *containerStateService) Setup(
 	fss domain.FuseServerServiceIface,
 	prs domain.ProcessServiceIface,
-	ios domain.IOServiceIface) {
+	ios domain.IOServiceIface,
+	stateDir string) {
 
 	css.fss = fss
 	css.prs = prs
 	css.ios = ios
+	css.setStateDirectory(stateDir)
+}
+
+//
+// Stops the service's background tasks (stale-container reaper and checkpoint
+// flusher). Invoked upon sysbox-fs shutdown.
+//
+func (css *containerStateService) Stop() {
+	css.ReaperStop()
+	css.flusherStop()
 }
 
 func (css *containerStateService) ContainerCreate(
@@ -78,6 +137,11 @@ func (css *containerStateService) ContainerCreate(
 	gidSize uint32,
 	procRoPaths []string,
 	procMaskPaths []string,
+	uidMappings []domain.IDMapping,
+	gidMappings []domain.IDMapping,
+	cgroupPaths domain.CgroupPaths,
+	hostname string,
+	limits domain.ResourceLimits,
 ) domain.ContainerIface {
 
 	newcntr := &container{
@@ -90,6 +154,11 @@ func (css *containerStateService) ContainerCreate(
 		gidSize:       gidSize,
 		procRoPaths:   procRoPaths,
 		procMaskPaths: procMaskPaths,
+		uidMappings:   uidMappings,
+		gidMappings:   gidMappings,
+		cgroupPaths:   cgroupPaths,
+		hostname:      hostname,
+		limits:        limits,
 		specPaths:     make(map[string]struct{}),
 		service:       css,
 	}
@@ -97,9 +166,31 @@ func (css *containerStateService) ContainerCreate(
 	return newcntr
 }
 
-func (css *containerStateService) ContainerPreRegister(id string) error {
+//
+// ContainerPreRegister carries out the first phase of the container
+// registration, which takes place before the container's init process is
+// created. If container metadata is provided (c != nil), this one is stored
+// right away, and a warm-up of the container state is launched in the
+// background (see preRegisterWarmUp()) to take it off the critical path of
+// the container's first /proc accesses.
+//
+func (css *containerStateService) ContainerPreRegister(
+	id string,
+	c domain.ContainerIface) error {
+
 	css.Lock()
 
+	if css.draining {
+		css.Unlock()
+		logrus.Errorf("Container pre-registration error: service drained (container %s)",
+			id)
+		return grpcStatus.Errorf(
+			grpcCodes.Unavailable,
+			"Container %s rejected: sysbox-fs is being drained",
+			id,
+		)
+	}
+
 	// Ensure that new container's id is not already present.
 	if _, ok := css.idTable[id]; ok {
 		css.Unlock()
@@ -113,6 +204,12 @@ func (css *containerStateService) ContainerPreRegister(id string) error {
 	}
 
 	cntr := &container{id: id}
+
+	if c != nil {
+		cntr.update(c.(*container))
+		cntr.ready = make(chan struct{})
+	}
+
 	css.idTable[cntr.id] = cntr
 
 	// Create dedicated fuse-server for each sys container.
@@ -130,14 +227,55 @@ func (css *containerStateService) ContainerPreRegister(id string) error {
 
 	css.Unlock()
 
+	if cntr.ready != nil {
+		crash.Go("container warm-up", func() { css.preRegisterWarmUp(cntr) })
+	}
+
 	return nil
 }
 
+//
+// Pre-registration warm-up: subscribers are notified of the new container so
+// that they can prepare whatever state they need ahead of time (e.g. caches,
+// nsenter agents).
+//
+func (css *containerStateService) preRegisterWarmUp(cntr *container) {
+
+	css.bus.publish(domain.ContainerEvent{
+		Type:      domain.ContainerPreRegisterEvent,
+		Container: cntr,
+	})
+
+	close(cntr.ready)
+}
+
 func (css *containerStateService) ContainerRegister(c domain.ContainerIface) error {
-	css.Lock()
 
 	cntr := c.(*container)
 
+	// Let the pre-registration warm-up (if any) complete before committing the
+	// registration. Notice that this must be done without holding the service
+	// lock, as warm-up subscribers may need to interact with this service.
+	if preCntr, ok := css.ContainerLookupById(cntr.id).(*container); ok {
+		if !preCntr.waitReady(preRegisterWarmUpTimeout) {
+			logrus.Warnf("Container %s pre-registration warm-up not completed in %v",
+				cntr.id, preRegisterWarmUpTimeout)
+		}
+	}
+
+	css.Lock()
+
+	if css.draining {
+		css.Unlock()
+		logrus.Errorf("Container registration error: service drained (container %s)",
+			cntr.id)
+		return grpcStatus.Errorf(
+			grpcCodes.Unavailable,
+			"Container %s rejected: sysbox-fs is being drained",
+			cntr.id,
+		)
+	}
+
 	// Ensure that container's id is already present (pregistration completed).
 	currCntr, ok := css.idTable[cntr.id]
 	if !ok {
@@ -165,6 +303,7 @@ func (css *containerStateService) ContainerRegister(c domain.ContainerIface) err
 
 	usernsInode, err := currCntr.InitProc().UserNsInode()
 	if err != nil {
+		css.Unlock()
 		logrus.Errorf("Container registration error: container %s with invalid user-ns",
 			cntr.id)
 		return grpcStatus.Errorf(
@@ -188,10 +327,30 @@ func (css *containerStateService) ContainerRegister(c domain.ContainerIface) err
 	}
 
 	css.usernsTable[usernsInode] = currCntr
+
+	// Nested sys containers' user-ns are created within their parent's
+	// user-ns (possibly through intermediate ones), so this is what we rely
+	// on to identify the parent container.
+	if ancestors, err := currCntr.InitProc().UserNsInodeAncestors(); err == nil {
+		for _, inode := range ancestors {
+			if parent, ok := css.usernsTable[inode]; ok {
+				currCntr.setParent(parent)
+				break
+			}
+		}
+	}
+
 	css.Unlock()
 
 	logrus.Info(cntr.String())
 
+	css.bus.publish(domain.ContainerEvent{
+		Type:      domain.ContainerRegisterEvent,
+		Container: currCntr,
+	})
+
+	css.ContainerDBCheckpoint()
+
 	return nil
 }
 
@@ -214,13 +373,37 @@ func (css *containerStateService) ContainerUpdate(c domain.ContainerIface) error
 	}
 
 	// Update the existing container-state struct with the one being received.
-	// Only 'creation-time' attribute is supported for now.
+	// Only 'creation-time' and resource-related attributes (cgroup paths and
+	// limits) are supported for now.
 	currCntr.SetCtime(cntr.ctime)
 
+	resourcesChanged := currCntr.updateResources(cntr)
+	if resourcesChanged {
+		logrus.Infof("Container %s resources updated: %+v", cntr.id, cntr.limits)
+		currCntr.invalidateData(limitsDependentPaths...)
+	}
+
 	css.Unlock()
 
+	// Cached attributes of the limits-dependent resources (e.g. their size) are
+	// also outdated at this point, so have the fuse-server drop them too.
+	if resourcesChanged {
+		err := css.fss.InvalidateNodes(cntr.id, limitsDependentPaths)
+		if err != nil {
+			logrus.Warnf("Container %s: unable to invalidate cached nodes: %v",
+				cntr.id, err)
+		}
+	}
+
 	logrus.Info(currCntr.String())
 
+	css.bus.publish(domain.ContainerEvent{
+		Type:      domain.ContainerUpdateEvent,
+		Container: currCntr,
+	})
+
+	css.ContainerDBCheckpoint()
+
 	return nil
 }
 
@@ -245,6 +428,7 @@ func (css *containerStateService) ContainerUnregister(c domain.ContainerIface) e
 
 	usernsInode, err := cntr.InitProc().UserNsInode()
 	if err != nil {
+		css.Unlock()
 		logrus.Errorf("Container unregistration error: could not find userns-inode for container %s",
 			cntr.id)
 		return grpcStatus.Errorf(
@@ -289,18 +473,104 @@ func (css *containerStateService) ContainerUnregister(c domain.ContainerIface) e
 		)
 	}
 
+	currCntrIdTable.detach()
+	css.pidns.evictContainer(currCntrIdTable)
+
 	delete(css.idTable, cntr.id)
 	delete(css.usernsTable, usernsInode)
 	css.Unlock()
 
 	logrus.Info(currCntrIdTable.String())
 
+	css.bus.publish(domain.ContainerEvent{
+		Type:      domain.ContainerUnregisterEvent,
+		Container: currCntrIdTable,
+	})
+
+	css.ContainerDBCheckpoint()
+
 	return nil
 }
 
+//
+// ContainerDBDrain unregisters all the containers (pre-registered ones
+// included) in one go, and rejects any further registration. Utilized by
+// sysbox-mgr during host shutdowns and sysbox upgrades, where tearing down
+// containers one by one would race with the daemon's termination. Returns the
+// number of drained containers.
+//
+func (css *containerStateService) ContainerDBDrain() (int, error) {
+	css.Lock()
+
+	css.draining = true
+
+	ids := make([]string, 0, len(css.idTable))
+	for id := range css.idTable {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	// Tearing down the fuse-servers quiesces the handlers and discards the
+	// fuse-level caches (nodeDB) of every container.
+	var failed []string
+	cntrs := make([]*container, len(ids))
+	for i, id := range ids {
+		cntr := css.idTable[id]
+		if err := css.fss.DestroyFuseServer(id); err != nil {
+			logrus.Errorf("Container drain error: unable to destroy fuseServer for container %s: %v",
+				id, err)
+			failed = append(failed, id)
+		}
+		cntr.detach()
+		css.pidns.evictContainer(cntr)
+		cntrs[i] = cntr
+	}
+
+	css.idTable = make(map[string]*container)
+	css.usernsTable = make(map[domain.Inode]*container)
+	css.Unlock()
+
+	for _, cntr := range cntrs {
+		css.bus.publish(domain.ContainerEvent{
+			Type:      domain.ContainerUnregisterEvent,
+			Container: cntr,
+		})
+	}
+
+	logrus.Infof("Drained %d containers", len(cntrs))
+
+	// Flush the (now empty) container-state.
+	if err := css.ContainerDBCheckpoint(); err != nil {
+		return len(cntrs), err
+	}
+
+	if len(failed) > 0 {
+		return len(cntrs), grpcStatus.Errorf(
+			grpcCodes.Internal,
+			"Unable to destroy fuse-servers of containers %v",
+			failed,
+		)
+	}
+
+	return len(cntrs), nil
+}
+
+//
+// Lifts the drained condition of the service (see ContainerDBDrain()), so that
+// container registrations are accepted again.
+//
+func (css *containerStateService) ContainerDBUndrain() {
+	css.Lock()
+	css.draining = false
+	css.Unlock()
+
+	logrus.Infof("Container registrations re-enabled")
+}
+
 func (css *containerStateService) ContainerLookupById(id string) domain.ContainerIface {
-	css.RLock()
-	defer css.RUnlock()
+	shard := css.tables.shardById(id)
+	shard.RLock()
+	defer shard.RUnlock()
 
 	cntr, ok := css.idTable[id]
 	if !ok {
@@ -313,8 +583,9 @@ func (css *containerStateService) ContainerLookupById(id string) domain.Containe
 func (css *containerStateService) ContainerLookupByInode(
 	usernsInode domain.Inode) domain.ContainerIface {
 
-	css.RLock()
-	defer css.RUnlock()
+	shard := css.tables.shardByInode(usernsInode)
+	shard.RLock()
+	defer shard.RUnlock()
 
 	cntr, ok := css.usernsTable[usernsInode]
 	if !ok {
@@ -351,31 +622,55 @@ func (css *containerStateService) ContainerLookupByProcess(
 	if cntr == nil {
 		// If no container is found then determine if we are dealing with a nested
 		// container scenario. If that's the case, it's natural to expect sysbox-fs
-		// to be totally unaware of L2 containers launching this request, so we
-		// would be tempted to discard it. To avoid that we obtain the parent user
-		// namespace (and its associated inode), and we search through containerDB
-		// once again. If there's a match then we serve this request making use of
-		// the parent (L1) system container state.
-		parentUsernsInode, err := p.UserNsInodeParent()
+		// to be totally unaware of the inner containers launching this request
+		// (e.g. docker containers with userns-remap, or "unshare -U" processes),
+		// which may be several levels deep within the sys container, so we would
+		// be tempted to discard it. To avoid that we walk up the user-ns hierarchy
+		// of the process, and serve this request making use of the state of the
+		// closest system container found along the way.
+		ancestors, err := p.UserNsInodeAncestors()
 		if err != nil {
-			logrus.Errorf("Could not identify a parent user-namespace for pid %d",
+			logrus.Errorf("Could not identify the parent user-namespaces of pid %d",
 				p.Pid())
 			return nil
 		}
 
-		parentCntr := css.ContainerLookupByInode(parentUsernsInode)
-		if parentCntr == nil {
-			logrus.Errorf("Could not find the container originating this request (userNsInode %d)",
-				usernsInode)
-			return nil
+		for _, inode := range ancestors {
+			if cntr := css.ContainerLookupByInode(inode); cntr != nil {
+				return cntr
+			}
 		}
 
-		return parentCntr
+		logrus.Errorf("Could not find the container originating this request (userNsInode %d)",
+			usernsInode)
+		return nil
 	}
 
 	return cntr
 }
 
+// ContainerList returns all the registered containers sorted by container-id.
+// Pre-registered containers are also included.
+func (css *containerStateService) ContainerList() []domain.ContainerIface {
+	// Any shard will do to exclude table modifications.
+	shard := &css.tables.shards[0]
+	shard.RLock()
+	defer shard.RUnlock()
+
+	ids := make([]string, 0, len(css.idTable))
+	for id := range css.idTable {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	cntrs := make([]domain.ContainerIface, len(ids))
+	for i, id := range ids {
+		cntrs[i] = css.idTable[id]
+	}
+
+	return cntrs
+}
+
 func (css *containerStateService) FuseServerService() domain.FuseServerServiceIface {
 	return css.fss
 }
@@ -384,9 +679,19 @@ func (css *containerStateService) ProcessService() domain.ProcessServiceIface {
 	return css.prs
 }
 
+func (css *containerStateService) Subscribe(h domain.ContainerEventHandler) int {
+	return css.bus.subscribe(h)
+}
+
+func (css *containerStateService) Unsubscribe(id int) {
+	css.bus.unsubscribe(id)
+}
+
 func (css *containerStateService) ContainerDBSize() int {
-	css.RLock()
-	defer css.RUnlock()
+	// Any shard will do to exclude table modifications.
+	shard := &css.tables.shards[0]
+	shard.RLock()
+	defer shard.RUnlock()
 
 	return len(css.idTable)
 }
@@ -17,9 +17,14 @@
 package state
 
 import (
+	"fmt"
 	"io/ioutil"
+	"os"
+	"os/exec"
 	"reflect"
+	"strings"
 	"sync"
+	"syscall"
 	"testing"
 	"time"
 
@@ -28,6 +33,10 @@ import (
 	"github.com/nestybox/sysbox-fs/process"
 	"github.com/nestybox/sysbox-fs/sysio"
 	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	grpcCodes "google.golang.org/grpc/codes"
+	grpcStatus "google.golang.org/grpc/status"
 )
 
 // Sysbox-fs global services for all state's pkg unit-tests.
@@ -76,15 +85,17 @@ func Test_containerStateService_Setup(t *testing.T) {
 	}
 
 	type args struct {
-		fss domain.FuseServerServiceIface
-		prs domain.ProcessServiceIface
-		ios domain.IOServiceIface
+		fss      domain.FuseServerServiceIface
+		prs      domain.ProcessServiceIface
+		ios      domain.IOServiceIface
+		stateDir string
 	}
 
 	a1 := args{
-		fss: fss,
-		prs: prs,
-		ios: ios,
+		fss:      fss,
+		prs:      prs,
+		ios:      ios,
+		stateDir: "",
 	}
 
 	tests := []struct {
@@ -108,7 +119,7 @@ func Test_containerStateService_Setup(t *testing.T) {
 				prs:         tt.fields.prs,
 				ios:         tt.fields.ios,
 			}
-			css.Setup(tt.args.fss, tt.args.prs, tt.args.ios)
+			css.Setup(tt.args.fss, tt.args.prs, tt.args.ios, tt.args.stateDir)
 		})
 	}
 }
@@ -148,6 +159,11 @@ func Test_containerStateService_ContainerCreate(t *testing.T) {
 		gidSize       uint32
 		procRoPaths   []string
 		procMaskPaths []string
+		uidMappings   []domain.IDMapping
+		gidMappings   []domain.IDMapping
+		cgroupPaths   domain.CgroupPaths
+		hostname      string
+		limits        domain.ResourceLimits
 	}
 
 	// Manually create a container to compare with.
@@ -167,6 +183,43 @@ func Test_containerStateService_ContainerCreate(t *testing.T) {
 		service:       css,
 	}
 
+	// Container carrying the full set of metadata received at registration
+	// time.
+	var c2 = &container{
+		id:            "2",
+		initPid:       1002,
+		ctime:         time.Time{},
+		uidFirst:      165536,
+		uidSize:       65536,
+		gidFirst:      165536,
+		gidSize:       65536,
+		procRoPaths:   nil,
+		procMaskPaths: nil,
+		uidMappings: []domain.IDMapping{
+			{ContainerID: 0, HostID: 165536, Size: 65536},
+		},
+		gidMappings: []domain.IDMapping{
+			{ContainerID: 0, HostID: 165536, Size: 65536},
+		},
+		cgroupPaths: domain.CgroupPaths{
+			V1: map[string]string{
+				"cpu":    "/sys/fs/cgroup/cpu/docker/2",
+				"memory": "/sys/fs/cgroup/memory/docker/2",
+			},
+		},
+		hostname: "syscont",
+		limits: domain.ResourceLimits{
+			CpusetCpus: "0-1",
+			CpuQuota:   100000,
+			CpuPeriod:  100000,
+			MemLimit:   1 << 30,
+		},
+		specPaths: make(map[string]struct{}),
+		dataStore: nil,
+		initProc:  nil,
+		service:   css,
+	}
+
 	tests := []struct {
 		name   string
 		fields fields
@@ -188,7 +241,33 @@ func Test_containerStateService_ContainerCreate(t *testing.T) {
 			c1.gidSize,
 			nil,
 			nil,
+			nil,
+			nil,
+			domain.CgroupPaths{},
+			"",
+			domain.ResourceLimits{},
 		}, c1},
+
+		//
+		// Testcase 2: Same as above but with all the registration metadata
+		// (id-mappings, cgroup paths, hostname and resource limits) in place.
+		//
+		{"2", f1, args{
+			c2.id,
+			c2.initPid,
+			c2.ctime,
+			c2.uidFirst,
+			c2.uidSize,
+			c2.gidFirst,
+			c2.gidSize,
+			nil,
+			nil,
+			c2.uidMappings,
+			c2.gidMappings,
+			c2.cgroupPaths,
+			c2.hostname,
+			c2.limits,
+		}, c2},
 	}
 
 	//
@@ -205,7 +284,12 @@ func Test_containerStateService_ContainerCreate(t *testing.T) {
 				tt.args.gidFirst,
 				tt.args.gidSize,
 				tt.args.procRoPaths,
-				tt.args.procMaskPaths); !reflect.DeepEqual(got, tt.want) {
+				tt.args.procMaskPaths,
+				tt.args.uidMappings,
+				tt.args.gidMappings,
+				tt.args.cgroupPaths,
+				tt.args.hostname,
+				tt.args.limits); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("containerStateService.ContainerCreate() = %v, want %v",
 					got, tt.want)
 			}
@@ -299,7 +383,7 @@ func Test_containerStateService_ContainerPreRegister(t *testing.T) {
 				tt.prepare(css)
 			}
 
-			if err := css.ContainerPreRegister(tt.args.id); (err != nil) != tt.wantErr {
+			if err := css.ContainerPreRegister(tt.args.id, nil); (err != nil) != tt.wantErr {
 				t.Errorf("containerStateService.ContainerPreRegister() error = %v, wantErr %v",
 					err, tt.wantErr)
 			}
@@ -481,6 +565,15 @@ func Test_containerStateService_ContainerUpdate(t *testing.T) {
 		initProc: f1.prs.ProcessCreate(2002, 0, 0),
 	}
 
+	// Update request carrying new resource limits for c1.
+	var c3 = &container{
+		id: "c1",
+		limits: domain.ResourceLimits{
+			CpusetCpus: "0-1",
+			MemLimit:   1 << 30,
+		},
+	}
+
 	type args struct {
 		c domain.ContainerIface
 	}
@@ -490,6 +583,7 @@ func Test_containerStateService_ContainerUpdate(t *testing.T) {
 		args    args
 		wantErr bool
 		prepare func()
+		verify  func()
 	}{
 		{
 			//
@@ -525,6 +619,33 @@ func Test_containerStateService_ContainerUpdate(t *testing.T) {
 				f1.usernsTable[inode] = c2
 			},
 		},
+		{
+			//
+			// Test-case 3: Update the resource limits of a registered container.
+			// Cached state of limits-dependent resources must be discarded, while
+			// the remaining state should be preserved.
+			//
+			name:    "3",
+			fields:  f1,
+			args:    args{c3},
+			wantErr: false,
+			prepare: func() {
+				c1.SetData("/proc/meminfo", "meminfo", "MemTotal: 16G")
+				c1.SetData("/proc/sys/kernel/panic", "panic", "1")
+				fss.On("InvalidateNodes", "c1", limitsDependentPaths).Return(nil).Once()
+			},
+			verify: func() {
+				assert.Equal(t, c3.limits, c1.Limits())
+				fss.AssertCalled(t, "InvalidateNodes", "c1", limitsDependentPaths)
+
+				_, ok := c1.Data("/proc/meminfo", "meminfo")
+				assert.False(t, ok)
+
+				val, ok := c1.Data("/proc/sys/kernel/panic", "panic")
+				assert.True(t, ok)
+				assert.Equal(t, "1", val)
+			},
+		},
 	}
 
 	//
@@ -553,6 +674,10 @@ func Test_containerStateService_ContainerUpdate(t *testing.T) {
 				t.Errorf("containerStateService.ContainerUpdate() error = %v, wantErr %v",
 					err, tt.wantErr)
 			}
+
+			if tt.verify != nil {
+				tt.verify()
+			}
 		})
 	}
 }
@@ -996,3 +1121,234 @@ func Test_containerStateService_ContainerLookupByProcess(t *testing.T) {
 		})
 	}
 }
+
+func Test_containerStateService_ContainerLookupByProcessNested(t *testing.T) {
+
+	// Requester two user-ns levels below the sys container's one (i.e. ours),
+	// as an inner container's process would be (unshare execs into sleep once
+	// its own user-ns is created).
+	cmd := exec.Command("unshare", "-U", "sleep", "30")
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Cloneflags:  syscall.CLONE_NEWUSER,
+		UidMappings: []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getuid(), Size: 1}},
+		GidMappings: []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getgid(), Size: 1}},
+	}
+	if err := cmd.Start(); err != nil {
+		t.Skipf("could not create nested user-ns: %v", err)
+	}
+	defer func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+
+	pid := cmd.Process.Pid
+
+	for i := 0; ; i++ {
+		comm, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/comm", pid))
+		if err == nil && strings.TrimSpace(string(comm)) == "sleep" {
+			break
+		}
+		if i == 100 {
+			t.Skipf("could not create nested user-ns: %v", err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	var st syscall.Stat_t
+	if err := syscall.Stat("/proc/self/ns/user", &st); err != nil {
+		t.Fatalf("failed to stat user-ns: %v", err)
+	}
+
+	ios.RemoveAllIOnodes()
+
+	// The requester's own user-ns is unknown to sysbox-fs.
+	p := prs.ProcessCreate(uint32(pid), 0, 0)
+	p.CreateNsInodes(654321)
+
+	c1 := &container{id: "c1"}
+
+	css := &containerStateService{
+		idTable:     map[string]*container{c1.id: c1},
+		usernsTable: map[domain.Inode]*container{st.Ino: c1},
+		fss:         fss,
+		prs:         prs,
+		ios:         ios,
+	}
+
+	assert.Equal(t, domain.ContainerIface(c1), css.ContainerLookupByProcess(p))
+
+	// No container within the requester's user-ns hierarchy.
+	delete(css.usernsTable, st.Ino)
+	assert.Nil(t, css.ContainerLookupByProcess(p))
+}
+
+func Test_containerStateService_ContainerList(t *testing.T) {
+
+	var c1 = &container{id: "c1"}
+	var c2 = &container{id: "c2"}
+	var c3 = &container{id: "c3"}
+
+	css := &containerStateService{
+		idTable: map[string]*container{
+			c3.id: c3,
+			c1.id: c1,
+			c2.id: c2,
+		},
+		usernsTable: make(map[domain.Inode]*container),
+	}
+
+	// Containers are expected to be sorted by id.
+	assert.Equal(t,
+		[]domain.ContainerIface{c1, c2, c3},
+		css.ContainerList())
+
+	// Empty table.
+	css.idTable = make(map[string]*container)
+	assert.Equal(t, []domain.ContainerIface{}, css.ContainerList())
+}
+
+func Test_containerStateService_ContainerPreRegisterWarmUp(t *testing.T) {
+
+	css := &containerStateService{
+		idTable:     make(map[string]*container),
+		usernsTable: make(map[domain.Inode]*container),
+		fss:         fss,
+		prs:         prs,
+		ios:         ios,
+	}
+
+	// Initialize memory-based mock FS.
+	ios.RemoveAllIOnodes()
+
+	fss.ExpectedCalls = nil
+	fss.On("CreateFuseServer", mock.Anything).Return(nil)
+
+	// Warm-up subscribers are expected to see the pre-registration metadata.
+	release := make(chan struct{})
+	var hostname string
+	css.Subscribe(func(e domain.ContainerEvent) {
+		if e.Type == domain.ContainerPreRegisterEvent {
+			hostname = e.Container.Hostname()
+			<-release
+		}
+	})
+
+	meta := css.ContainerCreate(
+		"c1",
+		0,
+		time.Time{},
+		165536,
+		65536,
+		165536,
+		65536,
+		[]string{"/proc/sys"},
+		[]string{"/proc/kcore"},
+		nil,
+		nil,
+		domain.CgroupPaths{},
+		"c1-host",
+		domain.ResourceLimits{},
+	)
+
+	// Pre-registration must not wait for the warm-up to complete.
+	assert.Nil(t, css.ContainerPreRegister("c1", meta))
+
+	cntr := css.idTable["c1"]
+	assert.False(t, cntr.waitReady(10*time.Millisecond))
+
+	close(release)
+	assert.True(t, cntr.waitReady(time.Second))
+
+	assert.Equal(t, "c1-host", hostname)
+	assert.True(t, cntr.IsSpecPath("/proc/sys"))
+	assert.True(t, cntr.IsSpecPath("/proc/kcore"))
+	assert.False(t, cntr.IsSpecPath("/proc/uptime"))
+}
+
+func Test_containerStateService_ContainerDBDrain(t *testing.T) {
+
+	css := &containerStateService{
+		idTable:     make(map[string]*container),
+		usernsTable: make(map[domain.Inode]*container),
+		fss:         fss,
+		prs:         prs,
+		ios:         ios,
+	}
+
+	// Initialize memory-based mock FS.
+	ios.RemoveAllIOnodes()
+
+	fss.ExpectedCalls = nil
+	fss.Calls = nil
+	fss.On("CreateFuseServer", mock.Anything).Return(nil)
+	fss.On("DestroyFuseServer", mock.Anything).Return(nil)
+
+	var unregistered []string
+	css.Subscribe(func(e domain.ContainerEvent) {
+		if e.Type == domain.ContainerUnregisterEvent {
+			unregistered = append(unregistered, e.Container.ID())
+		}
+	})
+
+	// One registered and one pre-registered container.
+	cntr := css.ContainerCreate(
+		"c1",
+		1001,
+		time.Time{},
+		165536,
+		65536,
+		165536,
+		65536,
+		nil,
+		nil,
+		nil,
+		nil,
+		domain.CgroupPaths{},
+		"",
+		domain.ResourceLimits{},
+	)
+	prs.ProcessCreate(1001, 0, 0).CreateNsInodes(123456)
+
+	assert.Nil(t, css.ContainerPreRegister("c1", nil))
+	assert.Nil(t, css.ContainerRegister(cntr))
+	assert.Nil(t, css.ContainerPreRegister("c2", nil))
+
+	n, err := css.ContainerDBDrain()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, 0, css.ContainerDBSize())
+	assert.Empty(t, css.usernsTable)
+	assert.Equal(t, []string{"c1", "c2"}, unregistered)
+	fss.AssertNumberOfCalls(t, "DestroyFuseServer", 2)
+
+	// Further registrations are expected to be rejected.
+	c3 := css.ContainerCreate(
+		"c3",
+		3003,
+		time.Time{},
+		165536,
+		65536,
+		165536,
+		65536,
+		nil,
+		nil,
+		nil,
+		nil,
+		domain.CgroupPaths{},
+		"",
+		domain.ResourceLimits{},
+	)
+	prs.ProcessCreate(3003, 0, 0).CreateNsInodes(345678)
+
+	err = css.ContainerPreRegister("c3", nil)
+	assert.Equal(t, grpcCodes.Unavailable, grpcStatus.Code(err))
+	err = css.ContainerRegister(c3)
+	assert.Equal(t, grpcCodes.Unavailable, grpcStatus.Code(err))
+
+	// Registrations are accepted again once the service is undrained.
+	css.ContainerDBUndrain()
+
+	assert.Nil(t, css.ContainerPreRegister("c3", nil))
+	assert.Nil(t, css.ContainerRegister(c3))
+	assert.Equal(t, 1, css.ContainerDBSize())
+}
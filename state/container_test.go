@@ -26,6 +26,11 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+func stringValue(s string) domain.StateValue {
+	v, _ := domain.NewStateValue(s)
+	return v
+}
+
 func Test_container_ID(t *testing.T) {
 
 	var cs1 = &container{
@@ -122,9 +127,9 @@ func Test_container_Ctime(t *testing.T) {
 func Test_container_Data(t *testing.T) {
 
 	var cs1 = &container{
-		dataStore: map[string](map[string]string){
-			"/proc/uptime":  {"uptime": "100"},
-			"/proc/cpuinfo": {"cpuinfo": "foo \n bar"},
+		dataStore: domain.StateDataMap{
+			"/proc/uptime":  {"uptime": stringValue("100")},
+			"/proc/cpuinfo": {"cpuinfo": stringValue("foo \n bar")},
 		},
 	}
 
@@ -233,8 +238,8 @@ func Test_container_SetCtime(t *testing.T) {
 func Test_container_SetData(t *testing.T) {
 
 	var cs1 = &container{
-		dataStore: map[string](map[string]string){
-			"/proc/cpuinfo": {"cpuinfo": "foo \n bar"},
+		dataStore: domain.StateDataMap{
+			"/proc/cpuinfo": {"cpuinfo": stringValue("foo \n bar")},
 		},
 	}
 
@@ -274,6 +279,63 @@ func Test_container_SetData(t *testing.T) {
 	}
 }
 
+func Test_container_SetDataValue(t *testing.T) {
+
+	type limits struct {
+		Min int `json:"min"`
+		Max int `json:"max"`
+	}
+
+	var cs1 = &container{}
+
+	// Typed values.
+	v, err := cs1.SetDataValue("/proc/sys/kernel/panic", "panic", 5, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1), v)
+
+	_, err = cs1.SetDataValue("/proc/sys/net/foo", "enabled", true, 0)
+	assert.Nil(t, err)
+
+	_, err = cs1.SetDataValue("/proc/sys/net/bar", "limits", limits{1, 10}, 0)
+	assert.Nil(t, err)
+
+	val, ok := cs1.DataValue("/proc/sys/kernel/panic", "panic")
+	assert.True(t, ok)
+	i, ok := val.Int()
+	assert.True(t, ok)
+	assert.Equal(t, int64(5), i)
+
+	data, ok := cs1.Data("/proc/sys/kernel/panic", "panic")
+	assert.True(t, ok)
+	assert.Equal(t, "5", data)
+
+	val, _ = cs1.DataValue("/proc/sys/net/foo", "enabled")
+	b, ok := val.Bool()
+	assert.True(t, ok)
+	assert.True(t, b)
+
+	var l limits
+	val, _ = cs1.DataValue("/proc/sys/net/bar", "limits")
+	assert.Nil(t, val.Decode(&l))
+	assert.Equal(t, limits{1, 10}, l)
+
+	_, ok = cs1.DataValue("/proc/sys/net/bar", "missing")
+	assert.False(t, ok)
+
+	// Versioned updates: a writer holding the current version succeeds, a
+	// writer holding a stale one must be rejected.
+	v, err = cs1.SetDataValue("/proc/sys/kernel/panic", "panic", 10, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2), v)
+
+	v, err = cs1.SetDataValue("/proc/sys/kernel/panic", "panic", 20, 1)
+	assert.Equal(t, domain.ErrDataVersionConflict, err)
+	assert.Equal(t, uint64(2), v)
+
+	data, _ = cs1.Data("/proc/sys/kernel/panic", "panic")
+	assert.Equal(t, "10", data)
+}
+
 func Test_container_update(t *testing.T) {
 	type fields struct {
 		RWMutex       sync.RWMutex
@@ -366,3 +428,136 @@ func Test_container_update(t *testing.T) {
 		})
 	}
 }
+
+func Test_container_updatePresence(t *testing.T) {
+
+	c := &container{
+		uidMappings: []domain.IDMapping{{ContainerID: 0, HostID: 165536, Size: 65536}},
+		gidMappings: []domain.IDMapping{{ContainerID: 0, HostID: 165536, Size: 65536}},
+		cgroupPaths: domain.CgroupPaths{V1: map[string]string{"cpu": "/c1"}},
+	}
+
+	// Attributes not conveyed (nil) are left untouched.
+	assert.NoError(t, c.update(&container{}))
+	assert.Len(t, c.uidMappings, 1)
+	assert.Len(t, c.gidMappings, 1)
+	assert.Equal(t, "/c1", c.cgroupPaths.V1["cpu"])
+
+	assert.False(t, c.updateResources(&container{}))
+
+	// Cgroup paths conveyed as empty are cleared.
+	assert.True(t, c.updateResources(&container{
+		cgroupPaths: domain.CgroupPaths{V1: map[string]string{}},
+	}))
+	assert.Empty(t, c.cgroupPaths.V1)
+	assert.False(t, c.updateResources(&container{
+		cgroupPaths: domain.CgroupPaths{V1: map[string]string{}},
+	}))
+
+	// So are the empty mappings.
+	assert.NoError(t, c.update(&container{
+		uidMappings: []domain.IDMapping{},
+		gidMappings: []domain.IDMapping{},
+	}))
+	assert.Empty(t, c.uidMappings)
+	assert.Empty(t, c.gidMappings)
+}
+
+func Test_container_Hierarchy(t *testing.T) {
+
+	var l0 = &container{id: "l0"}
+	var l1 = &container{id: "l1"}
+	var l2 = &container{id: "l2"}
+
+	// Build a three-level hierarchy: l0 -> l1 -> l2.
+	l1.setParent(l0)
+	l2.setParent(l1)
+
+	assert.Nil(t, l0.Parent())
+	assert.Equal(t, l0, l1.Parent())
+	assert.Equal(t, l1, l2.Parent())
+
+	assert.Equal(t, uint(0), l0.Level())
+	assert.Equal(t, uint(1), l1.Level())
+	assert.Equal(t, uint(2), l2.Level())
+
+	assert.Equal(t, []domain.ContainerIface{l1}, l0.Children())
+	assert.Equal(t, []domain.ContainerIface{l2}, l1.Children())
+	assert.Empty(t, l2.Children())
+
+	// Detach the intermediate container: l2 must be orphaned and l0 must be
+	// left with no children.
+	l1.detach()
+
+	assert.Nil(t, l1.Parent())
+	assert.Empty(t, l1.Children())
+	assert.Empty(t, l0.Children())
+	assert.Nil(t, l2.Parent())
+	assert.Equal(t, uint(0), l2.Level())
+}
+
+func Test_container_Overrides(t *testing.T) {
+
+	var c = &container{id: "c1"}
+
+	_, ok := c.Override("/proc/sys/kernel/osrelease")
+	assert.False(t, ok)
+
+	c.SetOverrides(map[string]domain.NodeOverride{
+		"/proc/sys/kernel/osrelease": {Value: "5.4.0-generic"},
+		"/proc/kallsyms":             {Hide: true},
+	})
+
+	o, ok := c.Override("/proc/sys/kernel/osrelease")
+	assert.True(t, ok)
+	assert.Equal(t, domain.NodeOverride{Value: "5.4.0-generic"}, o)
+
+	o, ok = c.Override("/proc/kallsyms")
+	assert.True(t, ok)
+	assert.True(t, o.Hide)
+
+	// Overrides must survive checkpoint/restore cycles.
+	assert.Equal(t, c.overrides, c.checkpoint().Overrides)
+
+	// New sets fully replace the existing one.
+	c.SetOverrides(nil)
+	_, ok = c.Override("/proc/kallsyms")
+	assert.False(t, ok)
+}
+
+func Test_container_AccountOp(t *testing.T) {
+
+	var c = &container{id: "c1"}
+	var wg sync.WaitGroup
+
+	// Counters are updated concurrently by the fuse request handlers.
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.AccountOp(domain.FuseOpLookup, false)
+			c.AccountOp(domain.FuseOpRead, false)
+			c.AccountOp(domain.FuseOpWrite, true)
+		}()
+	}
+	wg.Wait()
+
+	want := domain.ContainerOpStats{Lookups: 10, Reads: 10, Writes: 10, Errors: 10}
+	assert.Equal(t, want, c.OpStats())
+	assert.Equal(t, uint64(30), c.OpStats().Total())
+}
+
+func Test_container_DataSize(t *testing.T) {
+
+	var c = &container{id: "c1"}
+	assert.Equal(t, 0, c.DataSize())
+
+	c.SetData("/proc/sys/net/ipv4", "ip_forward", "1")
+	c.SetData("/proc/sys/net/ipv4", "tcp_syncookies", "1")
+	c.SetData("/proc/uptime", "uptime", "10.00 20.00")
+	assert.Equal(t, 3, c.DataSize())
+
+	// Overwrites don't add entries.
+	c.SetData("/proc/sys/net/ipv4", "ip_forward", "0")
+	assert.Equal(t, 3, c.DataSize())
+}
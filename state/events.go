@@ -0,0 +1,78 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package state
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/nestybox/sysbox-fs/domain"
+)
+
+//
+// Publish/subscribe mechanism to notify container lifecycle events (register,
+// update, unregister) to the interested parties.
+//
+type eventBus struct {
+	sync.RWMutex
+	subs   map[int]domain.ContainerEventHandler // subscribers indexed by id
+	nextId int                                  // id to assign to next subscriber
+}
+
+func (b *eventBus) subscribe(h domain.ContainerEventHandler) int {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.subs == nil {
+		b.subs = make(map[int]domain.ContainerEventHandler)
+	}
+
+	id := b.nextId
+	b.subs[id] = h
+	b.nextId++
+
+	return id
+}
+
+func (b *eventBus) unsubscribe(id int) {
+	b.Lock()
+	defer b.Unlock()
+
+	delete(b.subs, id)
+}
+
+// Delivers the event to all the subscribers, in subscription order. Notice
+// that callbacks are invoked synchronously and without holding any lock, so
+// subscribers are free to interact with the container-state service.
+func (b *eventBus) publish(e domain.ContainerEvent) {
+	b.RLock()
+	ids := make([]int, 0, len(b.subs))
+	for id := range b.subs {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	handlers := make([]domain.ContainerEventHandler, 0, len(ids))
+	for _, id := range ids {
+		handlers = append(handlers, b.subs[id])
+	}
+	b.RUnlock()
+
+	for _, h := range handlers {
+		h(e)
+	}
+}
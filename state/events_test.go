@@ -0,0 +1,125 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_containerStateService_Subscribe(t *testing.T) {
+
+	css := &containerStateService{
+		idTable:     make(map[string]*container),
+		usernsTable: make(map[domain.Inode]*container),
+		fss:         fss,
+		prs:         prs,
+		ios:         ios,
+	}
+
+	// Initialize memory-based mock FS.
+	ios.RemoveAllIOnodes()
+
+	fss.ExpectedCalls = nil
+	fss.On("CreateFuseServer", mock.Anything).Return(nil)
+	fss.On("DestroyFuseServer", mock.Anything).Return(nil)
+
+	var events1, events2 []domain.ContainerEventType
+
+	id1 := css.Subscribe(func(e domain.ContainerEvent) {
+		assert.Equal(t, "c1", e.Container.ID())
+		events1 = append(events1, e.Type)
+	})
+	css.Subscribe(func(e domain.ContainerEvent) {
+		events2 = append(events2, e.Type)
+	})
+
+	cntr := css.ContainerCreate(
+		"c1",
+		1001,
+		time.Time{},
+		165536,
+		65536,
+		165536,
+		65536,
+		nil,
+		nil,
+		nil,
+		nil,
+		domain.CgroupPaths{},
+		"",
+		domain.ResourceLimits{},
+	)
+	prs.ProcessCreate(1001, 0, 0).CreateNsInodes(123456)
+
+	assert.Nil(t, css.ContainerPreRegister("c1", nil))
+	assert.Nil(t, css.ContainerRegister(cntr))
+	assert.Nil(t, css.ContainerUpdate(cntr))
+
+	// No more events expected for the first subscriber.
+	css.Unsubscribe(id1)
+
+	assert.Nil(t, css.ContainerUnregister(css.ContainerLookupById("c1")))
+
+	assert.Equal(t,
+		[]domain.ContainerEventType{
+			domain.ContainerRegisterEvent,
+			domain.ContainerUpdateEvent,
+		},
+		events1)
+
+	assert.Equal(t,
+		[]domain.ContainerEventType{
+			domain.ContainerRegisterEvent,
+			domain.ContainerUpdateEvent,
+			domain.ContainerUnregisterEvent,
+		},
+		events2)
+}
+
+func Test_container_SetRebootRequested(t *testing.T) {
+
+	css := &containerStateService{
+		idTable:     make(map[string]*container),
+		usernsTable: make(map[domain.Inode]*container),
+		fss:         fss,
+		prs:         prs,
+		ios:         ios,
+	}
+
+	var events []domain.ContainerEventType
+
+	css.Subscribe(func(e domain.ContainerEvent) {
+		assert.Equal(t, "c1", e.Container.ID())
+		events = append(events, e.Type)
+	})
+
+	cntr := &container{id: "c1", service: css}
+
+	// Only the first request is expected to be published.
+	cntr.SetRebootRequested()
+	cntr.SetRebootRequested()
+
+	assert.True(t, cntr.reboot)
+	assert.Equal(t,
+		[]domain.ContainerEventType{domain.ContainerRebootEvent},
+		events)
+}
@@ -0,0 +1,224 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package state
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/nestybox/sysbox-fs/domain"
+)
+
+// Name of the file (within the state directory) journaling the data-store
+// updates carried out since the last checkpoint.
+const containerJournalFile = "containers.journal"
+
+// Number of journaled updates after which a checkpoint is requested, so that
+// the journal is folded into it (and truncated).
+const journalCompactThreshold = 1024
+
+//
+// Data-store updates are appended to a write-ahead journal rather than
+// triggering a full checkpoint each, so that containers frequently writing
+// into their emulated resources (e.g. tuning daemons) don't incur a write-out
+// of the whole container-state per operation. Journal records are written
+// into the page-cache (no fsync), so they survive sysbox-fs crashes, and are
+// replayed on top of the checkpoint during restoration.
+//
+type journalRecord struct {
+	Id    string            `json:"id"`
+	Path  string            `json:"path"`
+	Name  string            `json:"name"`
+	Value domain.StateValue `json:"value"`
+}
+
+type dataJournal struct {
+	sync.Mutex
+
+	// Journal file; opened on demand upon the first append.
+	file domain.IOnodeIface
+
+	// Number of records appended since the last checkpoint.
+	records int
+}
+
+//
+// Journals a data-store update. A checkpoint is requested (asynchronously)
+// once the journal grows beyond journalCompactThreshold records, or if the
+// update can't be journaled. Must be called without holding the container
+// lock.
+//
+func (css *containerStateService) journalAppend(
+	id string,
+	path string,
+	name string,
+	val domain.StateValue) {
+
+	stateDir := css.stateDirectory()
+
+	// Persistence disabled.
+	if stateDir == "" {
+		return
+	}
+
+	rec, err := json.Marshal(journalRecord{id, path, name, val})
+	if err != nil {
+		logrus.Errorf("Unable to journal update of %s for container %s: %v",
+			path, id, err)
+		css.checkpointAsync()
+		return
+	}
+	rec = append(rec, '\n')
+
+	j := &css.journal
+	j.Lock()
+
+	if j.file == nil {
+		if err := css.journalOpen(stateDir); err != nil {
+			j.Unlock()
+			css.checkpointAsync()
+			return
+		}
+	}
+
+	if _, err := j.file.Write(rec); err != nil {
+		logrus.Errorf("Unable to journal update of %s for container %s: %v",
+			path, id, err)
+		j.file.Close()
+		j.file = nil
+		j.Unlock()
+		css.checkpointAsync()
+		return
+	}
+
+	j.records++
+	compact := j.records >= journalCompactThreshold
+
+	j.Unlock()
+
+	if compact {
+		css.checkpointAsync()
+	}
+}
+
+// Opens the journal file for appending. Journal lock must be held.
+func (css *containerStateService) journalOpen(stateDir string) error {
+
+	dir := css.ios.NewIOnode("", stateDir, 0700)
+	if err := dir.MkdirAll(); err != nil {
+		logrus.Errorf("Unable to create state directory %s: %v", stateDir, err)
+		return err
+	}
+
+	path := filepath.Join(stateDir, containerJournalFile)
+
+	file := css.ios.NewIOnode("", path, 0600)
+	file.SetOpenFlags(syscall.O_WRONLY | syscall.O_CREAT | syscall.O_APPEND)
+	if err := file.Open(); err != nil {
+		logrus.Errorf("Unable to open container-state journal %s: %v", path, err)
+		return err
+	}
+
+	css.journal.file = file
+
+	return nil
+}
+
+//
+// Discards the journal's records, as these are already reflected in the
+// latest checkpoint. Journal lock must be held.
+//
+func (css *containerStateService) journalReset(stateDir string) {
+
+	j := &css.journal
+
+	if j.file != nil {
+		j.file.Close()
+		j.file = nil
+	}
+	j.records = 0
+
+	path := filepath.Join(stateDir, containerJournalFile)
+
+	err := css.ios.NewIOnode("", path, 0600).Remove()
+	if err != nil && !os.IsNotExist(err) {
+		logrus.Warnf("Unable to reset container-state journal %s: %v", path, err)
+	}
+}
+
+//
+// Applies the journal found in the given state directory to the existing
+// containers. Records are only applied if newer than the value in place, as
+// updates racing with a checkpoint may be journaled after it (and the
+// relative order of the records of a given resource isn't guaranteed either).
+// A truncated record (e.g. sysbox-fs crashed while journaling it) ends the
+// replay.
+//
+func (css *containerStateService) journalReplay(stateDir string) {
+
+	path := filepath.Join(stateDir, containerJournalFile)
+
+	buf, err := css.ios.NewIOnode("", path, 0600).ReadFile()
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logrus.Warnf("Unable to read container-state journal %s: %v", path, err)
+		}
+		return
+	}
+
+	var applied int
+
+	for _, line := range bytes.Split(buf, []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+
+		var rec journalRecord
+		if err := json.Unmarshal(line, &rec); err != nil {
+			logrus.Warnf("Truncated container-state journal %s: %v", path, err)
+			break
+		}
+
+		css.RLock()
+		cntr, ok := css.idTable[rec.Id]
+		css.RUnlock()
+		if !ok {
+			continue
+		}
+
+		cntr.Lock()
+		if cntr.dataStore == nil {
+			cntr.dataStore = make(domain.StateDataMap)
+		}
+		if _, ok := cntr.dataStore[rec.Path]; !ok {
+			cntr.dataStore[rec.Path] = make(domain.StateData)
+		}
+		if rec.Value.Version() > cntr.dataStore[rec.Path][rec.Name].Version() {
+			cntr.dataStore[rec.Path][rec.Name] = rec.Value
+			applied++
+		}
+		cntr.Unlock()
+	}
+
+	logrus.Infof("Applied %d container-state journal records", applied)
+}
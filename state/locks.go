@@ -0,0 +1,84 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package state
+
+import (
+	"hash/fnv"
+	"sync"
+
+	"github.com/nestybox/sysbox-fs/domain"
+)
+
+//
+// Sharded reader/writer lock protecting the container tables.
+//
+// Container lookups are executed in the hot-path of every intercepted
+// syscall and fuse request, whereas table modifications only take place
+// during container registration / unregistration. A single RWMutex would
+// have all readers contending over the same cache-line on busy hosts, so
+// readers are spread across multiple shards instead (based on the lookup
+// key), and writers are required to acquire all of them.
+//
+
+const tableShards = 32
+
+type tableShard struct {
+	sync.RWMutex
+	_ [40]byte // cache-line padding
+}
+
+type shardedLock struct {
+	shards [tableShards]tableShard
+}
+
+func (l *shardedLock) lockAll() {
+	for i := range l.shards {
+		l.shards[i].Lock()
+	}
+}
+
+func (l *shardedLock) unlockAll() {
+	for i := len(l.shards) - 1; i >= 0; i-- {
+		l.shards[i].Unlock()
+	}
+}
+
+func (l *shardedLock) shardById(id string) *tableShard {
+	h := fnv.New32a()
+	h.Write([]byte(id))
+
+	return &l.shards[h.Sum32()%tableShards]
+}
+
+func (l *shardedLock) shardByInode(inode domain.Inode) *tableShard {
+	return &l.shards[inode%tableShards]
+}
+
+//
+// Container-table write-locking. Notice that these methods shadow the ones
+// of the embedded RWMutex, which is still acquired to serialize writers.
+//
+
+func (css *containerStateService) Lock() {
+	css.RWMutex.Lock()
+	css.tables.lockAll()
+}
+
+func (css *containerStateService) Unlock() {
+	css.tables.unlockAll()
+	css.RWMutex.Unlock()
+}
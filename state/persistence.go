@@ -0,0 +1,472 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/nestybox/sysbox-fs/crash"
+	"github.com/nestybox/sysbox-fs/domain"
+)
+
+// Name of the file (within the state directory) holding the checkpointed
+// container-state.
+const containerDBFile = "containers.json"
+
+// Current version of the checkpoint format. To be bumped up every time an
+// incompatible change is introduced.
+//
+// Version history:
+//
+//
+//
+//
+//
+//
+//	1: string-only data-store.
+//	2: typed / versioned data-store.
+const containerDBVersion = 2
+
+//
+// Checkpoint representation of the container-state. Notice that only those
+// attributes that can't be re-constructed from the container's init process
+// are persisted.
+//
+type containerCheckpoint struct {
+	Id            string                `json:"id"`
+	InitPid       uint32                `json:"initPid"`
+	Ctime         time.Time             `json:"ctime"`
+	UidFirst      uint32                `json:"uidFirst"`
+	UidSize       uint32                `json:"uidSize"`
+	GidFirst      uint32                `json:"gidFirst"`
+	GidSize       uint32                `json:"gidSize"`
+	ProcRoPaths   []string              `json:"procRoPaths,omitempty"`
+	ProcMaskPaths []string              `json:"procMaskPaths,omitempty"`
+	UidMappings   []domain.IDMapping    `json:"uidMappings,omitempty"`
+	GidMappings   []domain.IDMapping    `json:"gidMappings,omitempty"`
+	CgroupPaths   domain.CgroupPaths    `json:"cgroupPaths"`
+	Hostname      string                `json:"hostname,omitempty"`
+	Limits        domain.ResourceLimits `json:"limits"`
+	Data          domain.StateDataMap   `json:"data,omitempty"`
+
+	Overrides map[string]domain.NodeOverride `json:"overrides,omitempty"`
+	Policies  map[string]domain.ValuePolicy  `json:"policies,omitempty"`
+
+	MountOptions domain.MountOptions `json:"mountOptions"`
+}
+
+type containerDBCheckpoint struct {
+	Version    int                   `json:"version"`
+	Containers []containerCheckpoint `json:"containers"`
+}
+
+// Version 1 checkpoint format, where data-store values are plain strings.
+type containerCheckpointV1 struct {
+	containerCheckpoint
+	Data map[string]map[string]string `json:"data,omitempty"`
+}
+
+type containerDBCheckpointV1 struct {
+	Version    int                     `json:"version"`
+	Containers []containerCheckpointV1 `json:"containers"`
+}
+
+// Converts a version 1 checkpoint into the current format.
+func (db *containerDBCheckpointV1) upgrade() containerDBCheckpoint {
+
+	res := containerDBCheckpoint{Version: containerDBVersion}
+
+	for _, c := range db.Containers {
+		cc := c.containerCheckpoint
+
+		if c.Data != nil {
+			cc.Data = make(domain.StateDataMap, len(c.Data))
+			for path, data := range c.Data {
+				cc.Data[path] = make(domain.StateData, len(data))
+				for name, val := range data {
+					cc.Data[path][name], _ = domain.NewStateValue(val)
+				}
+			}
+		}
+
+		res.Containers = append(res.Containers, cc)
+	}
+
+	return res
+}
+
+func (c *container) checkpoint() containerCheckpoint {
+	c.RLock()
+	defer c.RUnlock()
+
+	cc := containerCheckpoint{
+		Id:            c.id,
+		InitPid:       c.initPid,
+		Ctime:         c.ctime,
+		UidFirst:      c.uidFirst,
+		UidSize:       c.uidSize,
+		GidFirst:      c.gidFirst,
+		GidSize:       c.gidSize,
+		ProcRoPaths:   c.procRoPaths,
+		ProcMaskPaths: c.procMaskPaths,
+		UidMappings:   c.uidMappings,
+		GidMappings:   c.gidMappings,
+		CgroupPaths:   c.cgroupPaths,
+		Hostname:      c.hostname,
+		Limits:        c.limits,
+		MountOptions:  cloneMountOptions(c.mountOpts),
+	}
+
+	if c.overrides != nil {
+		cc.Overrides = make(map[string]domain.NodeOverride, len(c.overrides))
+		for path, o := range c.overrides {
+			cc.Overrides[path] = o
+		}
+	}
+
+	if c.policies != nil {
+		cc.Policies = make(map[string]domain.ValuePolicy, len(c.policies))
+		for path, p := range c.policies {
+			cc.Policies[path] = p
+		}
+	}
+
+	if c.dataStore != nil {
+		cc.Data = make(domain.StateDataMap, len(c.dataStore))
+		for path, data := range c.dataStore {
+			cc.Data[path] = make(domain.StateData, len(data))
+			for name, val := range data {
+				cc.Data[path][name] = val
+			}
+		}
+	}
+
+	return cc
+}
+
+//
+// ContainerDBExport serializes the state of all the registered containers.
+// Pre-registered containers are skipped as their initialization is still in
+// progress.
+//
+func (css *containerStateService) ContainerDBExport() ([]byte, error) {
+
+	var db = containerDBCheckpoint{Version: containerDBVersion}
+
+	shard := &css.tables.shards[0]
+	shard.RLock()
+	for _, cntr := range css.usernsTable {
+		db.Containers = append(db.Containers, cntr.checkpoint())
+	}
+	shard.RUnlock()
+
+	return json.Marshal(db)
+}
+
+//
+// ContainerDBCheckpoint dumps the state of all the registered containers into
+// the state directory, so that it can be recovered after a sysbox-fs restart.
+//
+func (css *containerStateService) ContainerDBCheckpoint() error {
+
+	stateDir := css.stateDirectory()
+
+	// Persistence disabled.
+	if stateDir == "" {
+		return nil
+	}
+
+	// The state is exported while holding the persistence lock, so that
+	// concurrent checkpoints can't write out stale copies of it. The journal
+	// lock is held too, as the journal is discarded once the checkpoint is in
+	// place.
+	css.persistLock.Lock()
+	defer css.persistLock.Unlock()
+
+	css.journal.Lock()
+	defer css.journal.Unlock()
+
+	buf, err := css.ContainerDBExport()
+	if err != nil {
+		return err
+	}
+
+	dir := css.ios.NewIOnode("", stateDir, 0700)
+	if err := dir.MkdirAll(); err != nil {
+		logrus.Errorf("Unable to create state directory %s: %v", stateDir, err)
+		return err
+	}
+
+	// Write into a temporary file first to prevent partially-written
+	// checkpoints from being picked up during restoration.
+	path := filepath.Join(stateDir, containerDBFile)
+	tmpPath := path + ".tmp"
+
+	tmp := css.ios.NewIOnode("", tmpPath, 0600)
+	if err := tmp.WriteFile(buf); err != nil {
+		logrus.Errorf("Unable to checkpoint container-state into %s: %v",
+			tmpPath, err)
+		return err
+	}
+
+	if err := tmp.Rename(path); err != nil {
+		logrus.Errorf("Unable to checkpoint container-state into %s: %v",
+			path, err)
+		return err
+	}
+
+	css.journalReset(stateDir)
+
+	return nil
+}
+
+// Returns the directory where container-state is checkpointed, or an empty
+// string if persistence is disabled.
+func (css *containerStateService) stateDirectory() string {
+	css.RLock()
+	defer css.RUnlock()
+
+	return css.stateDir
+}
+
+func (css *containerStateService) setStateDirectory(stateDir string) {
+	css.Lock()
+	css.stateDir = stateDir
+	css.Unlock()
+}
+
+// Requests an asynchronous checkpoint. Utilized in the container update paths
+// to prevent containers' emulated-resource writes from being serialized by the
+// checkpoint write-outs. Requests arriving while a checkpoint is in progress
+// are coalesced into a single one.
+func (css *containerStateService) checkpointAsync() {
+
+	// Persistence disabled.
+	if css.stateDirectory() == "" {
+		return
+	}
+
+	css.flusherOnce.Do(func() {
+		flushCh := make(chan struct{}, 1)
+		stop := make(chan struct{})
+		done := make(chan struct{})
+
+		css.Lock()
+		css.flushCh = flushCh
+		css.flushStop = stop
+		css.flushDone = done
+		css.Unlock()
+
+		go func() {
+			defer close(done)
+			defer crash.Recover("container-state flusher")
+
+			for {
+				select {
+				case <-flushCh:
+					css.ContainerDBCheckpoint()
+				case <-stop:
+					// Honor the request (if any) still pending.
+					select {
+					case <-flushCh:
+						css.ContainerDBCheckpoint()
+					default:
+					}
+					return
+				}
+			}
+		}()
+	})
+
+	css.RLock()
+	flushCh := css.flushCh
+	css.RUnlock()
+
+	// Requests arriving once the flusher is stopped are dropped (nil channel).
+	select {
+	case flushCh <- struct{}{}:
+	default:
+	}
+}
+
+// Stops the asynchronous checkpoint flusher, once the pending request (if
+// any) has been served. No further asynchronous checkpoints are carried out
+// past this point.
+func (css *containerStateService) flusherStop() {
+
+	// Prevent the flusher from being launched if not running yet.
+	css.flusherOnce.Do(func() {})
+
+	css.Lock()
+	stop, done := css.flushStop, css.flushDone
+	css.flushCh = nil
+	css.flushStop = nil
+	css.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
+
+//
+// ContainerDBRestore recreates the state of the containers checkpointed by a
+// previous sysbox-fs instance.
+//
+func (css *containerStateService) ContainerDBRestore() error {
+
+	stateDir := css.stateDirectory()
+
+	// Persistence disabled.
+	if stateDir == "" {
+		return nil
+	}
+
+	path := filepath.Join(stateDir, containerDBFile)
+
+	buf, err := css.ios.NewIOnode("", path, 0600).ReadFile()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		logrus.Errorf("Unable to read container-state checkpoint %s: %v",
+			path, err)
+		return err
+	}
+
+	if err := css.ContainerDBImport(buf); err != nil {
+		logrus.Errorf("Unable to restore container-state checkpoint %s: %v",
+			path, err)
+		return err
+	}
+
+	return nil
+}
+
+//
+// ContainerDBImport recreates the containers serialized by ContainerDBExport().
+// Containers whose init process is no longer around are discarded.
+//
+func (css *containerStateService) ContainerDBImport(buf []byte) error {
+
+	var hdr struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(buf, &hdr); err != nil {
+		return err
+	}
+
+	var db containerDBCheckpoint
+
+	switch hdr.Version {
+	case containerDBVersion:
+		if err := json.Unmarshal(buf, &db); err != nil {
+			return err
+		}
+
+	case 1:
+		var dbV1 containerDBCheckpointV1
+		if err := json.Unmarshal(buf, &dbV1); err != nil {
+			return err
+		}
+		db = dbV1.upgrade()
+
+	default:
+		return fmt.Errorf("Unsupported container-state checkpoint version %d",
+			hdr.Version)
+	}
+
+	// Checkpointing is disabled while the restoration is in progress, as we
+	// don't want to overwrite the state being processed.
+	css.Lock()
+	stateDir := css.stateDir
+	css.stateDir = ""
+	css.Unlock()
+
+	for i := range db.Containers {
+		cc := &db.Containers[i]
+
+		if err := css.containerRestore(cc); err != nil {
+			logrus.Warnf("Container %s could not be restored: %v", cc.Id, err)
+			continue
+		}
+
+		logrus.Infof("Container %s successfully restored", cc.Id)
+	}
+
+	// Bring the restored state up to date with the updates journaled since
+	// the checkpoint was taken.
+	if stateDir != "" {
+		css.journalReplay(stateDir)
+	}
+
+	css.setStateDirectory(stateDir)
+
+	// Refresh the checkpoint to get rid of the non-restored containers.
+	return css.ContainerDBCheckpoint()
+}
+
+func (css *containerStateService) containerRestore(cc *containerCheckpoint) error {
+
+	cntr := css.ContainerCreate(
+		cc.Id,
+		cc.InitPid,
+		cc.Ctime,
+		cc.UidFirst,
+		cc.UidSize,
+		cc.GidFirst,
+		cc.GidSize,
+		cc.ProcRoPaths,
+		cc.ProcMaskPaths,
+		cc.UidMappings,
+		cc.GidMappings,
+		cc.CgroupPaths,
+		cc.Hostname,
+		cc.Limits,
+	)
+
+	if err := css.ContainerPreRegister(cc.Id, nil); err != nil {
+		return err
+	}
+
+	if err := css.ContainerRegister(cntr); err != nil {
+		// Undo the pre-registration.
+		css.Lock()
+		delete(css.idTable, cc.Id)
+		css.Unlock()
+		css.fss.DestroyFuseServer(cc.Id)
+
+		return err
+	}
+
+	// Restore the emulated resources' state.
+	currCntr := css.ContainerLookupById(cc.Id).(*container)
+	currCntr.Lock()
+	currCntr.dataStore = cc.Data
+	currCntr.overrides = cc.Overrides
+	currCntr.policies = cc.Policies
+	currCntr.mountOpts = cc.MountOptions
+	currCntr.Unlock()
+
+	return nil
+}
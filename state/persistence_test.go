@@ -0,0 +1,291 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_containerStateService_ContainerDBRestore(t *testing.T) {
+
+	const stateDir = "/var/lib/sysbox-fs"
+
+	newCss := func(dir string) *containerStateService {
+		return &containerStateService{
+			idTable:     make(map[string]*container),
+			usernsTable: make(map[domain.Inode]*container),
+			fss:         fss,
+			prs:         prs,
+			ios:         ios,
+			stateDir:    dir,
+		}
+	}
+
+	// Registers a container in the passed css and stores an emulated value
+	// within its data-store.
+	register := func(
+		css *containerStateService,
+		id string,
+		pid uint32,
+		inode domain.Inode) {
+
+		cntr := css.ContainerCreate(
+			id,
+			pid,
+			time.Time{},
+			165536,
+			65536,
+			165536,
+			65536,
+			nil,
+			nil,
+			[]domain.IDMapping{{ContainerID: 0, HostID: 165536, Size: 65536}},
+			[]domain.IDMapping{{ContainerID: 0, HostID: 165536, Size: 65536}},
+			domain.CgroupPaths{V2: "/sys/fs/cgroup/" + id},
+			id+"-host",
+			domain.ResourceLimits{CpusetCpus: "0-1"},
+		)
+
+		prs.ProcessCreate(pid, 0, 0).CreateNsInodes(inode)
+
+		assert.Nil(t, css.ContainerPreRegister(id, nil))
+		assert.Nil(t, css.ContainerRegister(cntr))
+
+		css.ContainerLookupById(id).SetData("/proc/sys/kernel/panic", "panic", "5")
+	}
+
+	tests := []struct {
+		name     string
+		stateDir string
+		wantErr  bool
+		prepare  func()
+		verify   func(css *containerStateService)
+	}{
+		{
+			//
+			// Test-case 1: Restore a container-state checkpoint made of two
+			// containers, one of which (c2) is no longer running. Only c1 is
+			// expected to be recovered, along with its emulated state.
+			//
+			name:     "1",
+			stateDir: stateDir,
+			wantErr:  false,
+			prepare: func() {
+				// Data-store updates are asynchronously checkpointed, so
+				// persistence is only enabled once all the state is in place
+				// to keep things deterministic.
+				css := newCss("")
+
+				register(css, "c1", 1001, 123456)
+				register(css, "c2", 2002, 654321)
+
+				css.setStateDirectory(stateDir)
+				assert.Nil(t, css.ContainerDBCheckpoint())
+
+				// Emulate c2's init process exit.
+				ios.NewIOnode("", "/proc/2002", 0).RemoveAll()
+			},
+			verify: func(css *containerStateService) {
+				assert.Equal(t, 1, css.ContainerDBSize())
+				assert.Nil(t, css.ContainerLookupById("c2"))
+
+				c1 := css.ContainerLookupById("c1")
+				if !assert.NotNil(t, c1) {
+					return
+				}
+				assert.Equal(t, uint32(1001), c1.InitPid())
+				assert.Equal(t, "c1-host", c1.Hostname())
+				assert.Equal(t, "0-1", c1.Limits().CpusetCpus)
+				assert.Equal(t, "/sys/fs/cgroup/c1", c1.CgroupPaths().V2)
+
+				val, ok := c1.Data("/proc/sys/kernel/panic", "panic")
+				assert.True(t, ok)
+				assert.Equal(t, "5", val)
+			},
+		},
+		{
+			//
+			// Test-case 2: No checkpoint present. No error expected. A
+			// dedicated state directory is used as the in-memory FS isn't
+			// guaranteed to be fully cleared across test-cases.
+			//
+			name:     "2",
+			stateDir: stateDir + "-empty",
+			wantErr:  false,
+			verify: func(css *containerStateService) {
+				assert.Equal(t, 0, css.ContainerDBSize())
+			},
+		},
+		{
+			//
+			// Test-case 3: Corrupted checkpoint. Error expected.
+			//
+			name:     "3",
+			stateDir: stateDir,
+			wantErr:  true,
+			prepare: func() {
+				n := ios.NewIOnode("", stateDir+"/"+containerDBFile, 0600)
+				n.WriteFile([]byte("{corrupted"))
+			},
+		},
+		{
+			//
+			// Test-case 4: Restore a checkpoint generated by an older sysbox-fs
+			// version (string-only data-store).
+			//
+			name:     "4",
+			stateDir: stateDir,
+			wantErr:  false,
+			prepare: func() {
+				prs.ProcessCreate(1001, 0, 0).CreateNsInodes(123456)
+
+				n := ios.NewIOnode("", stateDir+"/"+containerDBFile, 0600)
+				n.WriteFile([]byte(`{"version":1,"containers":[{"id":"c1",` +
+					`"initPid":1001,"data":{"/proc/sys/kernel/panic":{"panic":"5"}}}]}`))
+			},
+			verify: func(css *containerStateService) {
+				c1 := css.ContainerLookupById("c1")
+				if !assert.NotNil(t, c1) {
+					return
+				}
+
+				val, ok := c1.Data("/proc/sys/kernel/panic", "panic")
+				assert.True(t, ok)
+				assert.Equal(t, "5", val)
+			},
+		},
+		{
+			//
+			// Test-case 5: Data-store updates journaled after the checkpoint
+			// was taken are replayed on top of it. A truncated record at the
+			// end of the journal is ignored.
+			//
+			name:     "5",
+			stateDir: stateDir,
+			wantErr:  false,
+			prepare: func() {
+				css := newCss("")
+
+				register(css, "c1", 1001, 123456)
+
+				css.setStateDirectory(stateDir)
+				assert.Nil(t, css.ContainerDBCheckpoint())
+
+				c1 := css.ContainerLookupById("c1")
+				c1.SetData("/proc/sys/kernel/panic", "panic", "7")
+				c1.SetData("/proc/sys/kernel/sysrq", "sysrq", "0")
+
+				css.journal.file.Write([]byte(`{"id":"c1","path":`))
+			},
+			verify: func(css *containerStateService) {
+				c1 := css.ContainerLookupById("c1")
+				if !assert.NotNil(t, c1) {
+					return
+				}
+
+				val, ok := c1.Data("/proc/sys/kernel/panic", "panic")
+				assert.True(t, ok)
+				assert.Equal(t, "7", val)
+
+				val, ok = c1.Data("/proc/sys/kernel/sysrq", "sysrq")
+				assert.True(t, ok)
+				assert.Equal(t, "0", val)
+
+				// The journal is folded into the refreshed checkpoint.
+				_, err := ios.NewIOnode("", stateDir+"/"+containerJournalFile, 0).Stat()
+				assert.Error(t, err)
+			},
+		},
+	}
+
+	//
+	// Testcase executions.
+	//
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			// Initialize memory-based mock FS.
+			ios.RemoveAllIOnodes()
+
+			// Reset mock expectations from previous iterations.
+			fss.ExpectedCalls = nil
+			fss.On("CreateFuseServer", mock.Anything).Return(nil)
+			fss.On("DestroyFuseServer", mock.Anything).Return(nil)
+
+			if tt.prepare != nil {
+				tt.prepare()
+			}
+
+			css := newCss(tt.stateDir)
+
+			if err := css.ContainerDBRestore(); (err != nil) != tt.wantErr {
+				t.Errorf("containerStateService.ContainerDBRestore() error = %v, wantErr %v",
+					err, tt.wantErr)
+			}
+
+			if tt.verify != nil {
+				tt.verify(css)
+			}
+		})
+	}
+}
+
+func Test_containerStateService_flusherStop(t *testing.T) {
+
+	const stateDir = "/var/lib/sysbox-fs-flusher"
+
+	ios.RemoveAllIOnodes()
+
+	css := &containerStateService{
+		idTable:     make(map[string]*container),
+		usernsTable: make(map[domain.Inode]*container),
+		fss:         fss,
+		prs:         prs,
+		ios:         ios,
+		stateDir:    stateDir,
+	}
+
+	checkpoint := ios.NewIOnode("", stateDir+"/"+containerDBFile, 0600)
+
+	// Requests pending at stop time are served before the flusher exits.
+	css.checkpointAsync()
+	css.Stop()
+
+	_, err := checkpoint.Stat()
+	assert.NoError(t, err)
+
+	select {
+	case <-css.flushDone:
+	default:
+		t.Fatal("flusher still running")
+	}
+
+	// Requests are dropped once the flusher is stopped.
+	assert.NoError(t, checkpoint.Remove())
+	css.checkpointAsync()
+
+	_, err = checkpoint.Stat()
+	assert.Error(t, err)
+
+	// Stopping an already stopped service is harmless.
+	css.Stop()
+}
@@ -0,0 +1,174 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package state
+
+import (
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/nestybox/sysbox-libs/pidmonitor"
+	"github.com/sirupsen/logrus"
+
+	"github.com/nestybox/sysbox-fs/crash"
+	"github.com/nestybox/sysbox-fs/domain"
+)
+
+//
+// Cache to speed up pid -> container resolutions.
+//
+// Resolving the container associated to a process requires the collection of
+// all its namespace inodes (and potentially its ancestor user-ns), which turns
+// out to be expensive when dealing with lots of short-lived processes hitting
+// emulated resources. Instead, the cache is indexed by the pid-ns inode of the
+// requesting processes, which is obtained through a single /proc lookup.
+//
+// Entries are lazily populated during lookups, and invalidated upon exit of
+// the process that originated them (as pid-ns inodes can be recycled once
+// the namespace is gone), or upon unregistration of their container.
+//
+type pidnsCache struct {
+	sync.RWMutex
+	table map[domain.Inode]pidnsCacheEntry // pid-ns inode -> container
+	pids  map[uint32]domain.Inode          // monitored pid -> pid-ns inode
+	pm    *pidmonitor.PidMon               // process-exit monitor
+	once  sync.Once
+}
+
+type pidnsCacheEntry struct {
+	cntr *container // container associated to the pid-ns
+	pid  uint32     // process that originated the entry
+}
+
+func (c *pidnsCache) lookup(inode domain.Inode) *container {
+	c.RLock()
+	defer c.RUnlock()
+
+	if e, ok := c.table[inode]; ok {
+		return e.cntr
+	}
+
+	return nil
+}
+
+func (c *pidnsCache) insert(inode domain.Inode, pid uint32, cntr *container) {
+
+	c.once.Do(func() {
+		pm, err := pidmonitor.New(&pidmonitor.Cfg{Poll: 100})
+		if err != nil {
+			logrus.Errorf("Could not initialize pid-ns cache's pidMonitor: %v", err)
+			return
+		}
+		c.pm = pm
+
+		crash.Go("pid-ns cache monitor", c.monitor)
+	})
+
+	// Entries can't be invalidated without a working pid monitor.
+	if c.pm == nil {
+		return
+	}
+
+	c.Lock()
+	if c.table == nil {
+		c.table = make(map[domain.Inode]pidnsCacheEntry)
+		c.pids = make(map[uint32]domain.Inode)
+	}
+	if _, ok := c.table[inode]; ok {
+		c.Unlock()
+		return
+	}
+	// A process may have switched pid-ns (unshare) since it originated a
+	// previous entry, which would be left unmonitored otherwise.
+	if old, ok := c.pids[pid]; ok && c.table[old].pid == pid {
+		delete(c.table, old)
+	}
+	c.table[inode] = pidnsCacheEntry{cntr: cntr, pid: pid}
+	c.pids[pid] = inode
+	c.Unlock()
+
+	c.pm.AddEvent([]pidmonitor.PidEvent{
+		{Pid: pid, Event: pidmonitor.Exit},
+	})
+}
+
+// Drops the entry originated by the given (exited) process.
+func (c *pidnsCache) evict(pid uint32) {
+	c.Lock()
+	defer c.Unlock()
+
+	inode, ok := c.pids[pid]
+	if !ok {
+		return
+	}
+
+	if e, ok := c.table[inode]; ok && e.pid == pid {
+		delete(c.table, inode)
+	}
+	delete(c.pids, pid)
+}
+
+// Drops all the entries associated to the given container.
+func (c *pidnsCache) evictContainer(cntr *container) {
+	c.Lock()
+	defer c.Unlock()
+
+	for inode, e := range c.table {
+		if e.cntr == cntr {
+			delete(c.table, inode)
+			delete(c.pids, e.pid)
+		}
+	}
+}
+
+func (c *pidnsCache) monitor() {
+	for pidList := range c.pm.EventCh {
+		for _, pidEvent := range pidList {
+			c.evict(pidEvent.Pid)
+		}
+	}
+}
+
+func (css *containerStateService) ContainerLookupByPid(pid uint32) domain.ContainerIface {
+
+	nsPath := filepath.Join(
+		"/proc",
+		strconv.FormatUint(uint64(pid), 10),
+		"ns",
+		"pid",
+	)
+
+	inode, err := css.ios.NewIOnode("", nsPath, 0).GetNsInode()
+	if err != nil {
+		logrus.Errorf("Could not find a pid-namespace for pid %d", pid)
+		return nil
+	}
+
+	if cntr := css.pidns.lookup(inode); cntr != nil {
+		return cntr
+	}
+
+	// Cache miss: resolve through the process' user-ns.
+	cntr := css.ContainerLookupByProcess(css.prs.ProcessCreate(pid, 0, 0))
+	if cntr == nil {
+		return nil
+	}
+
+	css.pidns.insert(inode, pid, cntr.(*container))
+
+	return cntr
+}
@@ -0,0 +1,81 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package state
+
+import (
+	"testing"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_containerStateService_ContainerLookupByPid(t *testing.T) {
+
+	var c1 = &container{
+		id:       "c1",
+		initProc: prs.ProcessCreate(1001, 0, 0),
+	}
+
+	css := &containerStateService{
+		idTable:     map[string]*container{c1.id: c1},
+		usernsTable: make(map[domain.Inode]*container),
+		fss:         fss,
+		prs:         prs,
+		ios:         ios,
+	}
+
+	// Initialize memory-based mock FS.
+	ios.RemoveAllIOnodes()
+
+	fss.ExpectedCalls = nil
+	fss.On("DestroyFuseServer", mock.Anything).Return(nil)
+
+	c1.InitProc().CreateNsInodes(123456)
+	css.usernsTable[123456] = c1
+
+	// Processes with no associated container.
+	assert.Nil(t, css.ContainerLookupByPid(9999))
+
+	prs.ProcessCreate(3003, 0, 0).CreateNsInodes(777777)
+	assert.Nil(t, css.ContainerLookupByPid(3003))
+	assert.Empty(t, css.pidns.table)
+
+	// Lazy population of the cache.
+	prs.ProcessCreate(2002, 0, 0).CreateNsInodes(123456)
+
+	assert.Equal(t, c1, css.ContainerLookupByPid(2002))
+	assert.Equal(t, c1, css.pidns.lookup(123456))
+
+	// Entries are shared by all the processes within the same pid-ns.
+	assert.Equal(t, c1, css.ContainerLookupByPid(1001))
+	assert.Len(t, css.pidns.table, 1)
+
+	// Exit of a process not originating the entry leaves it in place.
+	css.pidns.evict(1001)
+	assert.Equal(t, c1, css.pidns.lookup(123456))
+
+	// Exit of the originating process invalidates the entry.
+	css.pidns.evict(2002)
+	assert.Nil(t, css.pidns.lookup(123456))
+
+	// Container unregistration invalidates its entries.
+	assert.Equal(t, c1, css.ContainerLookupByPid(1001))
+	assert.Nil(t, css.ContainerUnregister(c1))
+	assert.Nil(t, css.pidns.lookup(123456))
+	assert.Nil(t, css.ContainerLookupByPid(1001))
+}
@@ -0,0 +1,122 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package state
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/nestybox/sysbox-fs/crash"
+	"github.com/nestybox/sysbox-fs/domain"
+)
+
+//
+// Stale-container reaper. Containers are expected to be unregistered by
+// sysbox-runc upon termination, but this notification can be missed (e.g.
+// sysbox-runc / container-manager crash). The reaper periodically looks for
+// registered containers whose init process is gone, and unregisters them to
+// release their associated resources (fuse-server, emulated state, etc).
+//
+
+func (css *containerStateService) ReaperStart(interval time.Duration) {
+
+	css.Lock()
+	if css.reaperStop != nil || interval <= 0 {
+		css.Unlock()
+		return
+	}
+	stop := make(chan struct{})
+	css.reaperStop = stop
+	css.Unlock()
+
+	go func() {
+		defer crash.Recover("container reaper")
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				css.reap()
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+func (css *containerStateService) ReaperStop() {
+
+	css.Lock()
+	defer css.Unlock()
+
+	if css.reaperStop != nil {
+		close(css.reaperStop)
+		css.reaperStop = nil
+	}
+}
+
+// Unregisters all the stale containers. Returns the number of reaped ones.
+func (css *containerStateService) reap() int {
+
+	var stale []*container
+
+	shard := &css.tables.shards[0]
+	shard.RLock()
+	for usernsInode, cntr := range css.usernsTable {
+		if !css.isAlive(cntr, usernsInode) {
+			stale = append(stale, cntr)
+		}
+	}
+	shard.RUnlock()
+
+	var reaped int
+
+	for _, cntr := range stale {
+		logrus.Warnf("Reaping stale container %s (init pid %d not found)",
+			cntr.ID(), cntr.InitPid())
+
+		if err := css.ContainerUnregister(cntr); err != nil {
+			logrus.Errorf("Unable to reap stale container %s: %v", cntr.ID(), err)
+			continue
+		}
+		reaped++
+	}
+
+	return reaped
+}
+
+// A container is considered alive as long as its init process is around and
+// still lives within the container's user-ns (i.e. its pid has not been
+// recycled).
+func (css *containerStateService) isAlive(
+	cntr *container,
+	usernsInode domain.Inode) bool {
+
+	// A fresh process instance is required here, as the container's initProc
+	// caches its ns inodes.
+	proc := css.prs.ProcessCreate(cntr.InitPid(), 0, 0)
+
+	inode, err := proc.UserNsInode()
+	if err != nil {
+		return false
+	}
+
+	return inode == usernsInode
+}
@@ -0,0 +1,93 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_containerStateService_reap(t *testing.T) {
+
+	css := &containerStateService{
+		idTable:     make(map[string]*container),
+		usernsTable: make(map[domain.Inode]*container),
+		fss:         fss,
+		prs:         prs,
+		ios:         ios,
+	}
+
+	// Initialize memory-based mock FS.
+	ios.RemoveAllIOnodes()
+
+	fss.ExpectedCalls = nil
+	fss.On("CreateFuseServer", mock.Anything).Return(nil)
+	fss.On("DestroyFuseServer", mock.Anything).Return(nil)
+
+	var cntrs = []struct {
+		id    string
+		pid   uint32
+		inode domain.Inode
+	}{
+		{"c1", 1001, 111111},
+		{"c2", 2002, 222222},
+		{"c3", 3003, 333333},
+	}
+
+	for _, c := range cntrs {
+		cntr := css.ContainerCreate(
+			c.id,
+			c.pid,
+			time.Time{},
+			165536,
+			65536,
+			165536,
+			65536,
+			nil,
+			nil,
+			nil,
+			nil,
+			domain.CgroupPaths{},
+			"",
+			domain.ResourceLimits{},
+		)
+		prs.ProcessCreate(c.pid, 0, 0).CreateNsInodes(c.inode)
+
+		assert.Nil(t, css.ContainerPreRegister(c.id, nil))
+		assert.Nil(t, css.ContainerRegister(cntr))
+	}
+
+	// Nothing to reap yet.
+	assert.Equal(t, 0, css.reap())
+	assert.Equal(t, 3, css.ContainerDBSize())
+
+	// c2's init process is gone.
+	ios.NewIOnode("", "/proc/2002", 0).RemoveAll()
+
+	// c3's init pid has been recycled by a process in a different user-ns.
+	prs.ProcessCreate(3003, 0, 0).CreateNsInodes(999999)
+
+	assert.Equal(t, 2, css.reap())
+	assert.Equal(t, 1, css.ContainerDBSize())
+	assert.NotNil(t, css.ContainerLookupById("c1"))
+	assert.Nil(t, css.ContainerLookupById("c2"))
+	assert.Nil(t, css.ContainerLookupById("c3"))
+}
@@ -0,0 +1,120 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package state
+
+import (
+	"encoding/json"
+
+	"github.com/sirupsen/logrus"
+	grpcCodes "google.golang.org/grpc/codes"
+	grpcStatus "google.golang.org/grpc/status"
+
+	"github.com/nestybox/sysbox-fs/domain"
+)
+
+// Current version of the container-state snapshot format.
+const containerSnapshotVersion = 1
+
+//
+// Snapshot of the emulated state of a single container. Utilized to preserve
+// the container's view of the emulated resources (e.g. /proc/sys) across
+// checkpoint/restore cycles and container migrations.
+//
+type containerSnapshot struct {
+	Version int                 `json:"version"`
+	Id      string              `json:"id"`
+	Data    domain.StateDataMap `json:"data,omitempty"`
+}
+
+func (css *containerStateService) ContainerStateExport(id string) ([]byte, error) {
+
+	cntr, ok := css.ContainerLookupById(id).(*container)
+	if !ok {
+		logrus.Errorf("Container state export error: container %s not found", id)
+		return nil, grpcStatus.Errorf(
+			grpcCodes.NotFound,
+			"Container %s not found",
+			id,
+		)
+	}
+
+	snap := containerSnapshot{
+		Version: containerSnapshotVersion,
+		Id:      id,
+		Data:    cntr.checkpoint().Data,
+	}
+
+	buf, err := json.Marshal(snap)
+	if err != nil {
+		return nil, grpcStatus.Errorf(
+			grpcCodes.Internal,
+			"Container %s state could not be exported: %v",
+			id, err,
+		)
+	}
+
+	return buf, nil
+}
+
+func (css *containerStateService) ContainerStateImport(id string, state []byte) error {
+
+	cntr, ok := css.ContainerLookupById(id).(*container)
+	if !ok {
+		logrus.Errorf("Container state import error: container %s not found", id)
+		return grpcStatus.Errorf(
+			grpcCodes.NotFound,
+			"Container %s not found",
+			id,
+		)
+	}
+
+	var snap containerSnapshot
+	if err := json.Unmarshal(state, &snap); err != nil {
+		logrus.Errorf("Container state import error: invalid state for container %s: %v",
+			id, err)
+		return grpcStatus.Errorf(
+			grpcCodes.InvalidArgument,
+			"Container %s with invalid state",
+			id,
+		)
+	}
+
+	if snap.Version != containerSnapshotVersion {
+		logrus.Errorf("Container state import error: unsupported version %d for container %s",
+			snap.Version, id)
+		return grpcStatus.Errorf(
+			grpcCodes.InvalidArgument,
+			"Container %s state with unsupported version %d",
+			id, snap.Version,
+		)
+	}
+
+	// The snapshot fully replaces the existing emulated state. Notice that the
+	// container-id within the snapshot is purposely ignored, as restored /
+	// migrated containers may have been assigned a different one.
+	cntr.Lock()
+	cntr.dataStore = snap.Data
+	cntr.Unlock()
+
+	// State derived from the (potentially different) source host's resources
+	// must be regenerated.
+	cntr.invalidateData(limitsDependentPaths...)
+
+	css.ContainerDBCheckpoint()
+
+	return nil
+}
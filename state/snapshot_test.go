@@ -0,0 +1,67 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package state
+
+import (
+	"testing"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_containerStateService_ContainerStateExportImport(t *testing.T) {
+
+	var c1 = &container{id: "c1"}
+	var c2 = &container{id: "c2"}
+
+	css := &containerStateService{
+		idTable: map[string]*container{
+			c1.id: c1,
+			c2.id: c2,
+		},
+		usernsTable: make(map[domain.Inode]*container),
+	}
+
+	c1.SetData("/proc/sys/kernel/panic", "panic", "5")
+	c1.SetDataValue("/proc/sys/net/netfilter/nf_conntrack_max", "nf_conntrack_max",
+		131072, 0)
+
+	// Unknown container. Error expected.
+	_, err := css.ContainerStateExport("c3")
+	assert.NotNil(t, err)
+
+	state, err := css.ContainerStateExport("c1")
+	assert.Nil(t, err)
+
+	// Import c1's state into c2 (e.g. migrated container).
+	assert.Nil(t, css.ContainerStateImport("c2", state))
+
+	data, ok := c2.Data("/proc/sys/kernel/panic", "panic")
+	assert.True(t, ok)
+	assert.Equal(t, "5", data)
+
+	val, ok := c2.DataValue("/proc/sys/net/netfilter/nf_conntrack_max",
+		"nf_conntrack_max")
+	assert.True(t, ok)
+	i, _ := val.Int()
+	assert.Equal(t, int64(131072), i)
+
+	// Unknown container / invalid state / unsupported version. Errors expected.
+	assert.NotNil(t, css.ContainerStateImport("c3", state))
+	assert.NotNil(t, css.ContainerStateImport("c2", []byte("{invalid")))
+	assert.NotNil(t, css.ContainerStateImport("c2", []byte(`{"version":99}`)))
+}
@@ -0,0 +1,122 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package sysio
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//
+// Read-through cache of host sysctl values (/proc/sys). Handlers read these
+// values to seed the defaults of every container, so without a cache each
+// container's first access opens the same host files over and over again.
+//
+// Entries are dropped after HostCacheTTL, and right away when the node is
+// written, removed or renamed through the IO service. Note that procfs
+// doesn't generate inotify events, so changes made to the host sysctls by
+// other processes are only noticed once the entries expire.
+//
+
+// Prefix of the host paths whose values are cached.
+const hostCachePrefix = "/proc/sys/"
+
+// Expiration of the cached host values (in nanoseconds); zero disables the
+// cache.
+var hostCacheTTL int64 = int64(5 * time.Second)
+
+// Sets the expiration of the cached host values. Can be invoked at any time
+// (e.g. upon config reload); a zero 'ttl' disables the cache.
+func SetHostCacheTTL(ttl time.Duration) {
+	atomic.StoreInt64(&hostCacheTTL, int64(ttl))
+}
+
+type hostCacheEntry struct {
+	val     string
+	expires time.Time
+}
+
+type hostCache struct {
+	sync.Mutex
+	entries map[string]hostCacheEntry
+}
+
+func newHostCache() *hostCache {
+	return &hostCache{
+		entries: make(map[string]hostCacheEntry),
+	}
+}
+
+// Reports whether the value of the given path is to be cached.
+func hostCacheable(path string) bool {
+	return strings.HasPrefix(path, hostCachePrefix) &&
+		atomic.LoadInt64(&hostCacheTTL) > 0
+}
+
+func (c *hostCache) get(path string) (string, bool) {
+	c.Lock()
+	defer c.Unlock()
+
+	e, ok := c.entries[path]
+	if !ok {
+		return "", false
+	}
+
+	if time.Now().After(e.expires) {
+		delete(c.entries, path)
+		return "", false
+	}
+
+	return e.val, true
+}
+
+func (c *hostCache) set(path string, val string) {
+	ttl := time.Duration(atomic.LoadInt64(&hostCacheTTL))
+
+	c.Lock()
+	defer c.Unlock()
+
+	c.entries[path] = hostCacheEntry{
+		val:     val,
+		expires: time.Now().Add(ttl),
+	}
+}
+
+// Drops the entry of the given path, along with the ones of the nodes under
+// it (e.g. upon removal of a directory).
+func (c *hostCache) invalidate(path string) {
+	c.Lock()
+	defer c.Unlock()
+
+	delete(c.entries, path)
+
+	prefix := strings.TrimSuffix(path, "/") + "/"
+	for p := range c.entries {
+		if strings.HasPrefix(p, prefix) {
+			delete(c.entries, p)
+		}
+	}
+}
+
+func (c *hostCache) flush() {
+	c.Lock()
+	defer c.Unlock()
+
+	c.entries = make(map[string]hostCacheEntry)
+}
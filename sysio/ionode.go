@@ -32,6 +32,12 @@ func NewIOService(t domain.IOServiceType) domain.IOServiceIface {
 	case domain.IOMemFileService:
 		return newIOFileService(domain.IOMemFileService)
 
+	case domain.IOFaultFileService:
+		return newIOFaultService(domain.IOMemFileService)
+
+	case domain.IOChaosFileService:
+		return newIOFaultService(domain.IOOsFileService)
+
 	//case domain.IOBufferNode:
 	//	return &ioBufferService{}
 
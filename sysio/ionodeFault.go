@@ -0,0 +1,335 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package sysio
+
+import (
+	"io"
+	"math/rand"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/nestybox/sysbox-fs/domain"
+)
+
+// Ensure IOnodeFault implements IOnode's interfaces.
+var _ domain.IOServiceIface = (*ioFaultService)(nil)
+var _ domain.IOnodeIface = (*IOnodeFault)(nil)
+var _ domain.ChaosIface = (*ioFaultService)(nil)
+
+//
+// Memory-backed I/O service with fault injection capabilities. Nodes behave
+// as the ones of the IOMemFileService, except for the operations matching
+// any of the injected faults, which allows handlers' behavior under host-FS
+// failures to be exercised deterministically. Utilized in UT scenarios.
+//
+// The host-backed variant of this service (IOChaosFileService) is utilized in
+// production when runtime fault injection is enabled: its nodes serve the host
+// FS, and randomly fail or delay operations as per the chaos settings (see
+// SetChaos()).
+//
+
+// FaultOp identifies the class of I/O operations a fault applies to.
+type FaultOp int
+
+const (
+	FaultOpen    FaultOp = iota // Open()
+	FaultRead                   // Read(), ReadAt(), ReadFile(), ReadLine()
+	FaultWrite                  // Write(), WriteAt(), WriteFile()
+	FaultReadDir                // ReadDirAll()
+	FaultStat                   // Stat(), StatAll()
+)
+
+//
+// Fault describes an error condition to inject into the I/O operations of the
+// nodes matching 'Path'.
+//
+type Fault struct {
+	Path       string        // node path; all nodes if empty
+	Op         FaultOp       // operations affected
+	Nth        int           // only the Nth matching operation (1-based); all if zero
+	Err        error         // error to return (e.g. syscall.EIO)
+	ShortWrite int           // writes are truncated to this many bytes if not zero
+	Latency    time.Duration // delay introduced before serving the operation
+
+	hits int // matching operations seen so far
+}
+
+// FaultInjector is implemented by the I/O services supporting fault injection.
+type FaultInjector interface {
+	InjectFault(f Fault)
+	ClearFaults()
+}
+
+type ioFaultService struct {
+	*ioFileService
+	sync.Mutex
+	faults []*Fault
+	chaos  domain.ChaosConfig
+}
+
+// Error returned by the operations failed as per the chaos settings.
+var chaosFault = &Fault{Err: syscall.EIO}
+
+func newIOFaultService(fsType domain.IOServiceType) domain.IOServiceIface {
+
+	return &ioFaultService{
+		ioFileService: newIOFileService(fsType).(*ioFileService),
+	}
+}
+
+func (s *ioFaultService) NewIOnode(
+	n string,
+	p string,
+	mode os.FileMode) domain.IOnodeIface {
+
+	return &IOnodeFault{
+		IOnodeFile: s.ioFileService.NewIOnode(n, p, mode).(*IOnodeFile),
+		fts:        s,
+	}
+}
+
+// Host-backed services are reported as regular ones, since their nodes are
+// expected to behave as such (e.g. attributes of the fuse root node).
+func (s *ioFaultService) GetServiceType() domain.IOServiceType {
+	if s.ioFileService.fsType == domain.IOOsFileService {
+		return domain.IOOsFileService
+	}
+
+	return domain.IOFaultFileService
+}
+
+// Registers a fault to be injected in subsequent operations.
+func (s *ioFaultService) InjectFault(f Fault) {
+	s.Lock()
+	defer s.Unlock()
+
+	f.hits = 0
+	s.faults = append(s.faults, &f)
+}
+
+// Removes all the injected faults.
+func (s *ioFaultService) ClearFaults() {
+	s.Lock()
+	defer s.Unlock()
+
+	s.faults = nil
+}
+
+// Sets the random faults to inject into all operations.
+func (s *ioFaultService) SetChaos(c domain.ChaosConfig) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.chaos = c
+}
+
+func (s *ioFaultService) Chaos() domain.ChaosConfig {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.chaos
+}
+
+//
+// Returns the fault to apply to the given operation, if any. Latencies of all
+// the matching faults are accumulated, and applied by the caller.
+//
+func (s *ioFaultService) match(path string, op FaultOp) (*Fault, time.Duration) {
+	s.Lock()
+	defer s.Unlock()
+
+	var (
+		fault   *Fault
+		latency time.Duration
+	)
+
+	for _, f := range s.faults {
+		if f.Op != op || (f.Path != "" && f.Path != path) {
+			continue
+		}
+
+		f.hits++
+		if f.Nth != 0 && f.hits != f.Nth {
+			continue
+		}
+
+		latency += f.Latency
+		if fault == nil && (f.Err != nil || f.ShortWrite != 0) {
+			fault = f
+		}
+	}
+
+	if s.chaos.DelayRate > 0 && rand.Float64() < s.chaos.DelayRate {
+		latency += s.chaos.Delay
+	}
+	if fault == nil && s.chaos.ErrorRate > 0 && rand.Float64() < s.chaos.ErrorRate {
+		fault = chaosFault
+	}
+
+	return fault, latency
+}
+
+//
+// IOnode class specialization with fault injection capabilities.
+//
+type IOnodeFault struct {
+	*IOnodeFile
+	fts *ioFaultService
+}
+
+// Applies the faults matching the given operation. Returns the error to hand
+// back to the caller, if any.
+func (i *IOnodeFault) inject(op FaultOp) (*Fault, error) {
+
+	f, latency := i.fts.match(i.path, op)
+	if latency > 0 {
+		time.Sleep(latency)
+	}
+
+	if f == nil {
+		return nil, nil
+	}
+
+	return f, f.Err
+}
+
+func (i *IOnodeFault) Open() error {
+
+	if _, err := i.inject(FaultOpen); err != nil {
+		return err
+	}
+
+	return i.IOnodeFile.Open()
+}
+
+func (i *IOnodeFault) Read(p []byte) (int, error) {
+
+	if _, err := i.inject(FaultRead); err != nil {
+		return 0, err
+	}
+
+	return i.IOnodeFile.Read(p)
+}
+
+func (i *IOnodeFault) ReadAt(p []byte, off int64) (int, error) {
+
+	if _, err := i.inject(FaultRead); err != nil {
+		return 0, err
+	}
+
+	return i.IOnodeFile.ReadAt(p, off)
+}
+
+func (i *IOnodeFault) ReadFile() ([]byte, error) {
+
+	if _, err := i.inject(FaultRead); err != nil {
+		return nil, err
+	}
+
+	return i.IOnodeFile.ReadFile()
+}
+
+func (i *IOnodeFault) ReadLine() (string, error) {
+
+	if _, err := i.inject(FaultRead); err != nil {
+		return "", err
+	}
+
+	return i.IOnodeFile.ReadLine()
+}
+
+func (i *IOnodeFault) Write(p []byte) (int, error) {
+
+	f, err := i.inject(FaultWrite)
+	if err != nil {
+		return 0, err
+	}
+
+	if f != nil && f.ShortWrite < len(p) {
+		n, err := i.IOnodeFile.Write(p[:f.ShortWrite])
+		if err != nil {
+			return n, err
+		}
+		return n, io.ErrShortWrite
+	}
+
+	return i.IOnodeFile.Write(p)
+}
+
+func (i *IOnodeFault) WriteAt(p []byte, off int64) (int, error) {
+
+	f, err := i.inject(FaultWrite)
+	if err != nil {
+		return 0, err
+	}
+
+	if f != nil && f.ShortWrite < len(p) {
+		n, err := i.IOnodeFile.WriteAt(p[:f.ShortWrite], off)
+		if err != nil {
+			return n, err
+		}
+		return n, io.ErrShortWrite
+	}
+
+	return i.IOnodeFile.WriteAt(p, off)
+}
+
+func (i *IOnodeFault) WriteFile(p []byte) error {
+
+	f, err := i.inject(FaultWrite)
+	if err != nil {
+		return err
+	}
+
+	if f != nil && f.ShortWrite < len(p) {
+		if err := i.IOnodeFile.WriteFile(p[:f.ShortWrite]); err != nil {
+			return err
+		}
+		return io.ErrShortWrite
+	}
+
+	return i.IOnodeFile.WriteFile(p)
+}
+
+func (i *IOnodeFault) ReadDirAll() ([]os.FileInfo, error) {
+
+	if _, err := i.inject(FaultReadDir); err != nil {
+		return nil, err
+	}
+
+	return i.IOnodeFile.ReadDirAll()
+}
+
+func (i *IOnodeFault) Stat() (os.FileInfo, error) {
+
+	if _, err := i.inject(FaultStat); err != nil {
+		return nil, err
+	}
+
+	return i.IOnodeFile.Stat()
+}
+
+func (i *IOnodeFault) StatAll(names []string) ([]os.FileInfo, error) {
+
+	if _, err := i.inject(FaultStat); err != nil {
+		return nil, err
+	}
+
+	return i.IOnodeFile.StatAll(names)
+}
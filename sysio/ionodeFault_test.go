@@ -0,0 +1,196 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package sysio_test
+
+import (
+	"io"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/nestybox/sysbox-fs/domain"
+	"github.com/nestybox/sysbox-fs/sysio"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIOnodeFault(t *testing.T) {
+
+	const path = "/proc/sys/net/ipv4/ip_forward"
+
+	tests := []struct {
+		name   string
+		faults []sysio.Fault
+		verify func(t *testing.T, i domain.IOnodeIface)
+	}{
+		{
+			//
+			// Test-case 1: No faults injected. Node is expected to behave as a
+			// regular memory-backed one.
+			//
+			name: "1",
+			verify: func(t *testing.T, i domain.IOnodeIface) {
+				data, err := i.ReadFile()
+				assert.NoError(t, err)
+				assert.Equal(t, "0\n", string(data))
+
+				assert.NoError(t, i.WriteFile([]byte("1\n")))
+				line, err := i.ReadLine()
+				assert.NoError(t, err)
+				assert.Equal(t, "1", line)
+			},
+		},
+		{
+			//
+			// Test-case 2: EIO on the 2nd read only.
+			//
+			name: "2",
+			faults: []sysio.Fault{
+				{Path: path, Op: sysio.FaultRead, Nth: 2, Err: syscall.EIO},
+			},
+			verify: func(t *testing.T, i domain.IOnodeIface) {
+				_, err := i.ReadFile()
+				assert.NoError(t, err)
+				_, err = i.ReadLine()
+				assert.Equal(t, syscall.EIO, err)
+				_, err = i.ReadFile()
+				assert.NoError(t, err)
+			},
+		},
+		{
+			//
+			// Test-case 3: Short writes. Only the first byte is expected to
+			// make it to the node.
+			//
+			name: "3",
+			faults: []sysio.Fault{
+				{Op: sysio.FaultWrite, ShortWrite: 1},
+			},
+			verify: func(t *testing.T, i domain.IOnodeIface) {
+				i.SetOpenFlags(syscall.O_WRONLY | syscall.O_TRUNC)
+				assert.NoError(t, i.Open())
+				n, err := i.Write([]byte("1\n"))
+				assert.Equal(t, 1, n)
+				assert.Equal(t, io.ErrShortWrite, err)
+				assert.NoError(t, i.Close())
+
+				data, err := i.ReadFile()
+				assert.NoError(t, err)
+				assert.Equal(t, "1", string(data))
+			},
+		},
+		{
+			//
+			// Test-case 4: Faults scoped to a different path don't apply.
+			//
+			name: "4",
+			faults: []sysio.Fault{
+				{Path: "/proc/uptime", Op: sysio.FaultOpen, Err: syscall.EACCES},
+				{Path: "/proc/uptime", Op: sysio.FaultStat, Err: syscall.ENOENT},
+			},
+			verify: func(t *testing.T, i domain.IOnodeIface) {
+				assert.NoError(t, i.Open())
+				assert.NoError(t, i.Close())
+				_, err := i.Stat()
+				assert.NoError(t, err)
+			},
+		},
+		{
+			//
+			// Test-case 5: Latency injection on every open, along with an
+			// error on readdir.
+			//
+			name: "5",
+			faults: []sysio.Fault{
+				{Op: sysio.FaultOpen, Latency: 10 * time.Millisecond},
+				{Op: sysio.FaultReadDir, Err: syscall.EIO},
+			},
+			verify: func(t *testing.T, i domain.IOnodeIface) {
+				for n := 0; n < 2; n++ {
+					start := time.Now()
+					assert.NoError(t, i.Open())
+					assert.True(t, time.Since(start) >= 10*time.Millisecond)
+					assert.NoError(t, i.Close())
+				}
+
+				_, err := i.ReadDirAll()
+				assert.Equal(t, syscall.EIO, err)
+			},
+		},
+	}
+
+	//
+	// Testcase executions.
+	//
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			fios := sysio.NewIOService(domain.IOFaultFileService)
+			assert.Equal(t, domain.IOFaultFileService, fios.GetServiceType())
+
+			i := fios.NewIOnode("ip_forward", path, 0644)
+			assert.NoError(t, i.WriteFile([]byte("0\n")))
+
+			injector := fios.(sysio.FaultInjector)
+			for _, f := range tt.faults {
+				injector.InjectFault(f)
+			}
+
+			tt.verify(t, i)
+
+			// Nodes are expected to behave normally once faults are cleared.
+			injector.ClearFaults()
+			assert.NoError(t, i.WriteFile([]byte("0\n")))
+			_, err := i.ReadFile()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestIOnodeFault_Chaos(t *testing.T) {
+
+	const path = "/proc/sys/net/ipv4/ip_forward"
+
+	fios := sysio.NewIOService(domain.IOFaultFileService)
+	chaos := fios.(domain.ChaosIface)
+
+	i := fios.NewIOnode("ip_forward", path, 0644)
+	assert.NoError(t, i.WriteFile([]byte("0\n")))
+
+	// All operations are failed.
+	chaos.SetChaos(domain.ChaosConfig{ErrorRate: 1})
+	_, err := i.ReadFile()
+	assert.Equal(t, syscall.EIO, err)
+	assert.Equal(t, syscall.EIO, i.WriteFile([]byte("1\n")))
+	_, err = i.Stat()
+	assert.Equal(t, syscall.EIO, err)
+
+	// All operations are delayed.
+	chaos.SetChaos(domain.ChaosConfig{DelayRate: 1, Delay: 10 * time.Millisecond})
+	start := time.Now()
+	_, err = i.ReadFile()
+	assert.NoError(t, err)
+	assert.True(t, time.Since(start) >= 10*time.Millisecond)
+
+	chaos.SetChaos(domain.ChaosConfig{})
+	data, err := i.ReadFile()
+	assert.NoError(t, err)
+	assert.Equal(t, "0\n", string(data))
+
+	// Host-backed services are reported as regular ones.
+	assert.Equal(t, domain.IOOsFileService,
+		sysio.NewIOService(domain.IOChaosFileService).GetServiceType())
+}
@@ -22,6 +22,7 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strconv"
 	"syscall"
 
@@ -40,11 +41,14 @@ var _ domain.IOnodeIface = (*IOnodeFile)(nil)
 type ioFileService struct {
 	fsType domain.IOServiceType
 	appFs  afero.Fs
+	cache  *hostCache // host sysctl values (see hostcache.go)
 }
 
 func newIOFileService(fsType domain.IOServiceType) domain.IOServiceIface {
 
-	var fs = &ioFileService{}
+	var fs = &ioFileService{
+		cache: newHostCache(),
+	}
 
 	if fsType == domain.IOMemFileService {
 		fs.appFs = afero.NewMemMapFs()
@@ -91,6 +95,10 @@ func (s *ioFileService) ReadAtNode(i domain.IOnodeIface, p []byte, off int64) (i
 	return i.ReadAt(p, off)
 }
 
+func (s *ioFileService) WriteAtNode(i domain.IOnodeIface, p []byte, off int64) (int, error) {
+	return i.WriteAt(p, off)
+}
+
 func (s *ioFileService) ReadDirAllNode(i domain.IOnodeIface) ([]os.FileInfo, error) {
 	return i.ReadDirAll()
 }
@@ -107,13 +115,25 @@ func (s *ioFileService) StatNode(i domain.IOnodeIface) (os.FileInfo, error) {
 	return i.Stat()
 }
 
+func (s *ioFileService) StatAllNode(
+	i domain.IOnodeIface,
+	names []string) ([]os.FileInfo, error) {
+	return i.StatAll(names)
+}
+
 func (s *ioFileService) SeekResetNode(i domain.IOnodeIface) (int64, error) {
 	return i.SeekReset()
 }
 
+func (s *ioFileService) SeekNode(i domain.IOnodeIface, off int64, whence int) (int64, error) {
+	return i.Seek(off, whence)
+}
+
 // Eliminate all nodes from a previously created file-system. Utilized exclusively
 // for unit-testing purposes (i.e. afero.MemFs).
 func (s *ioFileService) RemoveAllIOnodes() error {
+	s.cache.flush()
+
 	if err := s.appFs.RemoveAll("/"); err != nil {
 		return err
 	}
@@ -169,6 +189,8 @@ func (i *IOnodeFile) Write(p []byte) (n int, err error) {
 		return 0, fmt.Errorf("File not currently opened.")
 	}
 
+	i.fss.cache.invalidate(i.path)
+
 	return i.file.Write(p)
 }
 
@@ -190,6 +212,17 @@ func (i *IOnodeFile) ReadAt(p []byte, off int64) (n int, err error) {
 	return i.file.ReadAt(p, off)
 }
 
+func (i *IOnodeFile) WriteAt(p []byte, off int64) (n int, err error) {
+
+	if i.file == nil {
+		return 0, fmt.Errorf("File not currently opened.")
+	}
+
+	i.fss.cache.invalidate(i.path)
+
+	return i.file.WriteAt(p, off)
+}
+
 func (i *IOnodeFile) ReadDirAll() ([]os.FileInfo, error) {
 	return afero.ReadDir(i.fss.appFs, i.path)
 }
@@ -220,6 +253,13 @@ func (i *IOnodeFile) ReadLine() (string, error) {
 
 	var res string
 
+	cacheable := hostCacheable(i.path)
+	if cacheable {
+		if val, ok := i.fss.cache.get(i.path); ok {
+			return val, nil
+		}
+	}
+
 	// Open file and return empty string if an error is received.
 	inFile, err := i.fss.appFs.Open(i.path)
 	if err != nil {
@@ -233,11 +273,17 @@ func (i *IOnodeFile) ReadLine() (string, error) {
 	scanner.Scan()
 	res = scanner.Text()
 
+	if cacheable {
+		i.fss.cache.set(i.path, res)
+	}
+
 	return res, nil
 }
 
 func (i *IOnodeFile) WriteFile(p []byte) error {
 
+	i.fss.cache.invalidate(i.path)
+
 	if i.fss.fsType == domain.IOMemFileService {
 		err := afero.WriteFile(i.fss.appFs, i.path, p, 0644)
 		if err != nil {
@@ -293,18 +339,47 @@ func (i *IOnodeFile) Stat() (os.FileInfo, error) {
 	return i.fss.appFs.Stat(i.path)
 }
 
+//
+// Stats the given entries of the directory represented by this node, sparing
+// callers a node (and handler) per entry. Entries not present in the directory
+// are skipped, so the returned slice may be shorter than 'names'.
+//
+func (i *IOnodeFile) StatAll(names []string) ([]os.FileInfo, error) {
+
+	var infos = make([]os.FileInfo, 0, len(names))
+
+	for _, name := range names {
+		info, err := i.fss.appFs.Stat(filepath.Join(i.path, name))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+
+	return infos, nil
+}
+
 func (i *IOnodeFile) SeekReset() (int64, error) {
+	return i.Seek(0, io.SeekStart)
+}
+
+func (i *IOnodeFile) Seek(off int64, whence int) (int64, error) {
 
 	if i.file == nil {
 		return 0, fmt.Errorf("File not currently opened.")
 	}
 
-	return i.file.Seek(io.SeekStart, 0)
+	return i.file.Seek(off, whence)
 }
 
 // Eliminate a node from a previously created file-system. Utilized exclusively
 // for unit-testing purposes (i.e. afero.MemFs).
 func (i *IOnodeFile) Remove() error {
+	i.fss.cache.invalidate(i.path)
+
 	if err := i.fss.appFs.Remove(i.path); err != nil {
 		return err
 	}
@@ -315,6 +390,8 @@ func (i *IOnodeFile) Remove() error {
 // Eliminate all nodes under the path indicated by the given ionode. Utilized
 // exclusively for unit-testing purposes (i.e. afero.MemFs).
 func (i *IOnodeFile) RemoveAll() error {
+	i.fss.cache.invalidate(i.path)
+
 	if err := i.fss.appFs.RemoveAll(i.path); err != nil {
 		return err
 	}
@@ -322,6 +399,19 @@ func (i *IOnodeFile) RemoveAll() error {
 	return nil
 }
 
+func (i *IOnodeFile) Rename(newpath string) error {
+	i.fss.cache.invalidate(i.path)
+	i.fss.cache.invalidate(newpath)
+
+	if err := i.fss.appFs.Rename(i.path, newpath); err != nil {
+		return err
+	}
+
+	i.path = newpath
+
+	return nil
+}
+
 func (i *IOnodeFile) Name() string {
 	return i.name
 }
@@ -17,10 +17,12 @@
 package sysio_test
 
 import (
+	"io"
 	"io/ioutil"
 	"os"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/nestybox/sysbox-fs/domain"
 	"github.com/nestybox/sysbox-fs/sysio"
@@ -484,6 +486,200 @@ func TestIOnodeFile_ReadAt(t *testing.T) {
 	}
 }
 
+func TestIOnodeFile_WriteAt(t *testing.T) {
+	type fields struct {
+		name  string
+		path  string
+		flags int
+		mode  os.FileMode
+	}
+
+	var f1 = fields{
+		name:  "node_1",
+		path:  "/proc/sys/net/node_1",
+		flags: os.O_RDWR,
+		mode:  0600,
+	}
+
+	type args struct {
+		p   []byte
+		off int64
+	}
+
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		wantN   int
+		wantErr bool
+		want    string
+		prepare func(i domain.IOnodeIface)
+	}{
+		{
+			//
+			// Test-case 1: Overwrite the middle of the file. No errors expected.
+			//
+			name:    "1",
+			fields:  f1,
+			args:    args{p: []byte("CONTENT"), off: 5},
+			wantN:   len("CONTENT"),
+			wantErr: false,
+			want:    "file CONTENT 0123456789",
+			prepare: func(i domain.IOnodeIface) {
+
+				// Create memfs file.
+				i.WriteFile([]byte("file content 0123456789"))
+
+				// Open file as WriteAt() expects it to be already opened.
+				i.Open()
+			},
+		},
+		{
+			//
+			// Test-case 2: Append at the end of the file. No errors expected.
+			//
+			name:    "2",
+			fields:  f1,
+			args:    args{p: []byte(" abc"), off: int64(len("file content 0123456789"))},
+			wantN:   len(" abc"),
+			wantErr: false,
+			want:    "file content 0123456789 abc",
+			prepare: func(i domain.IOnodeIface) {
+
+				// Create memfs file.
+				i.WriteFile([]byte("file content 0123456789"))
+
+				// Open file as WriteAt() expects it to be already opened.
+				i.Open()
+			},
+		},
+		{
+			//
+			// Test-case 3: Verify proper behavior when file is not opened.
+			//
+			name:    "3",
+			fields:  f1,
+			args:    args{p: []byte("CONTENT"), off: 5},
+			wantN:   0,
+			wantErr: true,
+			prepare: func(i domain.IOnodeIface) {},
+		},
+	}
+
+	//
+	// Testcase executions.
+	//
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := ios.NewIOnode(
+				tt.fields.name,
+				tt.fields.path,
+				tt.fields.mode,
+			)
+			i.SetOpenFlags(tt.fields.flags)
+
+			// Initialize memory-based fs.
+			ios.RemoveAllIOnodes()
+
+			// Prepare the mocks.
+			if tt.prepare != nil {
+				tt.prepare(i)
+			}
+
+			gotN, err := ios.WriteAtNode(i, tt.args.p, tt.args.off)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("IOnodeFile.WriteAt() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if gotN != tt.wantN {
+				t.Errorf("IOnodeFile.WriteAt() = %v, want %v", gotN, tt.wantN)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			content, _ := i.ReadFile()
+			assert.Equal(t, tt.want, string(content))
+		})
+	}
+}
+
+func TestIOnodeFile_Seek(t *testing.T) {
+
+	i := ios.NewIOnode("node_1", "/proc/sys/net/node_1", 0600)
+
+	// Initialize memory-based fs.
+	ios.RemoveAllIOnodes()
+
+	// Seek() expects the file to be already opened.
+	_, err := i.Seek(0, io.SeekStart)
+	assert.Error(t, err)
+
+	i.WriteFile([]byte("file content 0123456789"))
+	assert.NoError(t, i.Open())
+
+	tests := []struct {
+		name    string
+		off     int64
+		whence  int
+		wantOff int64
+		want    string
+	}{
+		{
+			//
+			// Test-case 1: Absolute offset.
+			//
+			name:    "1",
+			off:     5,
+			whence:  io.SeekStart,
+			wantOff: 5,
+			want:    "content",
+		},
+		{
+			//
+			// Test-case 2: Offset relative to the current position (i.e. right
+			// after the previous read).
+			//
+			name:    "2",
+			off:     1,
+			whence:  io.SeekCurrent,
+			wantOff: 13,
+			want:    "0123456",
+		},
+		{
+			//
+			// Test-case 3: Offset relative to the end of the file.
+			//
+			name:    "3",
+			off:     -3,
+			whence:  io.SeekEnd,
+			wantOff: 20,
+			want:    "789",
+		},
+	}
+
+	//
+	// Testcase executions.
+	//
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			off, err := ios.SeekNode(i, tt.off, tt.whence)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantOff, off)
+
+			p := make([]byte, len(tt.want))
+			n, err := i.Read(p)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, string(p[:n]))
+		})
+	}
+
+	off, err := i.SeekReset()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), off)
+}
+
 func TestIOnodeFile_ReadDirAll(t *testing.T) {
 	type fields struct {
 		name string
@@ -748,6 +944,126 @@ func TestIOnodeFile_ReadLine(t *testing.T) {
 	}
 }
 
+func TestIOnodeFile_ReadLineHostCache(t *testing.T) {
+	type fields struct {
+		name string
+		path string
+		mode os.FileMode
+	}
+
+	var f1 = fields{
+		name: "node_1",
+		path: "/proc/sys/net/node_1",
+		mode: 0600,
+	}
+
+	// Node referring to the same file through a different path, so that its
+	// writes go unnoticed by the cache (as the ones done by host processes).
+	var alias = ios.NewIOnode("node_1", "/proc/sys/net/../net/node_1", 0600)
+
+	tests := []struct {
+		name    string
+		fields  fields
+		ttl     time.Duration
+		want    string
+		prepare func(i domain.IOnodeIface)
+	}{
+		{
+			//
+			// Test-case 1: Host value changes behind our back. Cached value
+			// expected.
+			//
+			name:   "1",
+			fields: f1,
+			ttl:    time.Minute,
+			want:   "1",
+			prepare: func(i domain.IOnodeIface) {
+				i.WriteFile([]byte("1"))
+				i.ReadLine()
+				alias.WriteFile([]byte("2"))
+			},
+		},
+		{
+			//
+			// Test-case 2: Host value written through the IO service. Cached
+			// value must be dropped.
+			//
+			name:   "2",
+			fields: f1,
+			ttl:    time.Minute,
+			want:   "2",
+			prepare: func(i domain.IOnodeIface) {
+				i.WriteFile([]byte("1"))
+				i.ReadLine()
+				i.WriteFile([]byte("2"))
+			},
+		},
+		{
+			//
+			// Test-case 3: Cached value expired. Current host value expected.
+			//
+			name:   "3",
+			fields: f1,
+			ttl:    time.Millisecond,
+			want:   "2",
+			prepare: func(i domain.IOnodeIface) {
+				i.WriteFile([]byte("1"))
+				i.ReadLine()
+				alias.WriteFile([]byte("2"))
+				time.Sleep(2 * time.Millisecond)
+			},
+		},
+		{
+			//
+			// Test-case 4: Cache disabled. Current host value expected.
+			//
+			name:   "4",
+			fields: f1,
+			ttl:    0,
+			want:   "2",
+			prepare: func(i domain.IOnodeIface) {
+				i.WriteFile([]byte("1"))
+				i.ReadLine()
+				alias.WriteFile([]byte("2"))
+			},
+		},
+	}
+
+	defer sysio.SetHostCacheTTL(5 * time.Second)
+
+	//
+	// Testcase executions.
+	//
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := ios.NewIOnode(
+				tt.fields.name,
+				tt.fields.path,
+				tt.fields.mode,
+			)
+
+			// Initialize memory-based fs.
+			ios.RemoveAllIOnodes()
+
+			sysio.SetHostCacheTTL(tt.ttl)
+
+			// Prepare the mocks.
+			if tt.prepare != nil {
+				tt.prepare(i)
+			}
+
+			got, err := i.ReadLine()
+			if err != nil {
+				t.Errorf("IOnodeFile.ReadLine() error = %v", err)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("IOnodeFile.ReadLine() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestIOnodeFile_WriteFile(t *testing.T) {
 	type fields struct {
 		name string
@@ -1110,3 +1426,88 @@ func TestIOnodeFile_Stat(t *testing.T) {
 		})
 	}
 }
+
+func TestIOnodeFile_StatAll(t *testing.T) {
+	type fields struct {
+		name string
+		path string
+		mode os.FileMode
+	}
+
+	var f1 = fields{
+		name: "net",
+		path: "/proc/sys/net",
+		mode: 0600,
+	}
+
+	tests := []struct {
+		name    string
+		fields  fields
+		names   []string
+		want    []string
+		wantErr bool
+		prepare func(i domain.IOnodeIface)
+	}{
+		{
+			//
+			// Test-case 1: Regular StatAll operation. No errors expected.
+			//
+			name:    "1",
+			fields:  f1,
+			names:   []string{"node_1", "node_2"},
+			want:    []string{"node_1", "node_2"},
+			wantErr: false,
+			prepare: func(i domain.IOnodeIface) {
+				ios.NewIOnode("", "/proc/sys/net/node_1", 0).WriteFile([]byte("1"))
+				ios.NewIOnode("", "/proc/sys/net/node_2", 0).WriteFile([]byte("2"))
+				ios.NewIOnode("", "/proc/sys/net/node_3", 0).WriteFile([]byte("3"))
+			},
+		},
+		{
+			//
+			// Test-case 2: Entries not present in the directory are skipped.
+			//
+			name:    "2",
+			fields:  f1,
+			names:   []string{"node_1", "node_2"},
+			want:    []string{"node_2"},
+			wantErr: false,
+			prepare: func(i domain.IOnodeIface) {
+				ios.NewIOnode("", "/proc/sys/net/node_2", 0).WriteFile([]byte("2"))
+			},
+		},
+	}
+
+	//
+	// Testcase executions.
+	//
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := ios.NewIOnode(
+				tt.fields.name,
+				tt.fields.path,
+				tt.fields.mode,
+			)
+
+			// Initialize memory-based fs.
+			ios.RemoveAllIOnodes()
+
+			// Prepare the mocks.
+			if tt.prepare != nil {
+				tt.prepare(i)
+			}
+
+			got, err := ios.StatAllNode(i, tt.names)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("IOnodeFile.StatAll() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			var gotNames []string
+			for _, info := range got {
+				gotNames = append(gotNames, info.Name())
+			}
+			assert.Equal(t, tt.want, gotNames)
+		})
+	}
+}
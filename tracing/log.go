@@ -0,0 +1,111 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package tracing
+
+import (
+	"context"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Structured field names of the exported spans.
+const (
+	FieldTraceID  = "trace-id"
+	FieldSpanID   = "span-id"
+	FieldParentID = "parent-id"
+	FieldSpan     = "span"
+	FieldDuration = "duration"
+)
+
+//
+// LogExporter emits every span as a structured log entry through the given
+// logger, for a quick look at the traces without any tracing backend.
+//
+type LogExporter struct {
+	Logger logrus.FieldLogger
+}
+
+func (l *LogExporter) ExportSpans(
+	ctx context.Context,
+	spans []sdktrace.ReadOnlySpan) error {
+
+	for _, s := range spans {
+		fields := logrus.Fields{
+			FieldTraceID:  s.SpanContext().TraceID().String(),
+			FieldSpanID:   s.SpanContext().SpanID().String(),
+			FieldSpan:     s.Name(),
+			FieldDuration: s.EndTime().Sub(s.StartTime()).String(),
+		}
+		if s.Parent().IsValid() {
+			fields[FieldParentID] = s.Parent().SpanID().String()
+		}
+		for _, kv := range s.Attributes() {
+			fields[string(kv.Key)] = kv.Value.AsInterface()
+		}
+		if s.Status().Code == codes.Error {
+			fields[logrus.ErrorKey] = s.Status().Description
+		}
+
+		l.Logger.WithFields(fields).Info("Span completed")
+	}
+
+	return nil
+}
+
+func (l *LogExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+//
+// Returns an exporter appending the spans to the given file, in
+// OpenTelemetry's JSON encoding (one span per line), so that they can be
+// shipped to any tracing backend (e.g. through an OpenTelemetry collector).
+//
+func NewFileExporter(path string) (sdktrace.SpanExporter, error) {
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := stdouttrace.New(stdouttrace.WithWriter(f))
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &fileExporter{SpanExporter: e, file: f}, nil
+}
+
+// Exporter closing its file upon shutdown.
+type fileExporter struct {
+	sdktrace.SpanExporter
+	file *os.File
+}
+
+func (e *fileExporter) Shutdown(ctx context.Context) error {
+	err := e.SpanExporter.Shutdown(ctx)
+	if cerr := e.file.Close(); err == nil {
+		err = cerr
+	}
+
+	return err
+}
@@ -0,0 +1,102 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package tracing
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+//
+// Span processor holding back the spans of every trace until its root span
+// ends, at which point they are handed to the next processor (in the order in
+// which they ended, i.e. root span last) if the trace lasted at least as long
+// as the threshold, or dropped otherwise.
+//
+type thresholdProcessor struct {
+	sync.Mutex
+	next      sdktrace.SpanProcessor
+	threshold time.Duration
+	traces    map[trace.TraceID][]sdktrace.ReadOnlySpan // indexed by trace id
+}
+
+func newThresholdProcessor(
+	next sdktrace.SpanProcessor,
+	threshold time.Duration) *thresholdProcessor {
+
+	return &thresholdProcessor{
+		next:      next,
+		threshold: threshold,
+		traces:    make(map[trace.TraceID][]sdktrace.ReadOnlySpan),
+	}
+}
+
+func (p *thresholdProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+
+	// Traces are tracked from their root span onwards.
+	if s.Parent().IsValid() {
+		return
+	}
+
+	p.Lock()
+	p.traces[s.SpanContext().TraceID()] = nil
+	p.Unlock()
+}
+
+func (p *thresholdProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+
+	id := s.SpanContext().TraceID()
+
+	p.Lock()
+
+	// Spans outliving their trace's root are dropped.
+	spans, ok := p.traces[id]
+	if !ok {
+		p.Unlock()
+		return
+	}
+	spans = append(spans, s)
+
+	if s.Parent().IsValid() {
+		p.traces[id] = spans
+		p.Unlock()
+		return
+	}
+
+	delete(p.traces, id)
+	p.Unlock()
+
+	if s.EndTime().Sub(s.StartTime()) < p.threshold {
+		return
+	}
+
+	for _, span := range spans {
+		p.next.OnEnd(span)
+	}
+}
+
+func (p *thresholdProcessor) Shutdown(ctx context.Context) error {
+	return p.next.Shutdown(ctx)
+}
+
+func (p *thresholdProcessor) ForceFlush(ctx context.Context) error {
+	return p.next.ForceFlush(ctx)
+}
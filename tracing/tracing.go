@@ -0,0 +1,210 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+//
+// Package tracing provides request tracing across the fuse, handler and
+// nsenter layers of sysbox-fs, on top of the OpenTelemetry SDK.
+//
+// Spans are produced by an OpenTelemetry tracer and handed to the configured
+// span exporter (e.g. OpenTelemetry's stdouttrace, or the LogExporter below).
+// Spans are buffered per trace and only exported once the root span ends, and
+// only if the trace lasted at least as long as the configured threshold, which
+// allows slow requests to be captured in their entirety without paying for the
+// rest.
+//
+// Tracing is disabled until an exporter is set, in which case Start() returns
+// a nil span whose methods are no-ops.
+//
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Name under which sysbox-fs' spans are reported.
+const instrumentationName = "github.com/nestybox/sysbox-fs"
+
+// Span represents a timed operation within a trace.
+type Span struct {
+	span trace.Span
+}
+
+var (
+	mu       sync.RWMutex
+	provider *sdktrace.TracerProvider
+	tracer   trace.Tracer
+)
+
+//
+// Enables tracing by setting the exporter to hand finished traces to, along
+// with the minimum duration of the traces to export. A nil exporter disables
+// tracing. The spans pending export by the previous exporter (if any) are
+// flushed before returning.
+//
+func SetExporter(e sdktrace.SpanExporter, min time.Duration) {
+	mu.Lock()
+	prev := provider
+
+	if e == nil {
+		provider, tracer = nil, nil
+	} else {
+		// Spans are exported in batches, off the requests' path.
+		proc := newThresholdProcessor(sdktrace.NewBatchSpanProcessor(e), min)
+
+		provider = sdktrace.NewTracerProvider(
+			sdktrace.WithSampler(sdktrace.AlwaysSample()),
+			sdktrace.WithResource(resource.NewSchemaless(
+				semconv.ServiceNameKey.String("sysbox-fs"))),
+			sdktrace.WithSpanProcessor(proc),
+		)
+		tracer = provider.Tracer(instrumentationName)
+	}
+	mu.Unlock()
+
+	if prev != nil {
+		prev.Shutdown(context.Background())
+	}
+}
+
+// Flushes the spans pending export.
+func Flush() {
+	mu.RLock()
+	p := provider
+	mu.RUnlock()
+
+	if p != nil {
+		p.ForceFlush(context.Background())
+	}
+}
+
+// Enabled reports whether tracing is currently active.
+func Enabled() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	return tracer != nil
+}
+
+//
+// Starts a span with the given name. The span becomes a child of the span
+// carried by 'ctx', if any, or the root of a new trace otherwise. The returned
+// context carries the new span and must be passed down to child operations.
+//
+func Start(ctx context.Context, name string) (context.Context, *Span) {
+
+	mu.RLock()
+	t := tracer
+	mu.RUnlock()
+
+	if t == nil {
+		return ctx, nil
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	ctx, s := t.Start(ctx, name)
+
+	return ctx, &Span{span: s}
+}
+
+// Returns the span carried by the given context, if any.
+func FromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+
+	s := trace.SpanFromContext(ctx)
+	if !s.SpanContext().IsValid() {
+		return nil
+	}
+
+	return &Span{span: s}
+}
+
+// Returns the identity (trace and span ids) of the span.
+func (s *Span) SpanContext() trace.SpanContext {
+	if s == nil {
+		return trace.SpanContext{}
+	}
+
+	return s.span.SpanContext()
+}
+
+// Attaches a key/value pair to the span.
+func (s *Span) SetAttribute(key string, val interface{}) {
+	if s == nil {
+		return
+	}
+
+	s.span.SetAttributes(keyValue(key, val))
+}
+
+// Records the error the spanned operation completed with.
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+//
+// Ends the span. Ending the root span completes the trace, which is then
+// exported if it lasted at least as long as the configured threshold.
+//
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+
+	s.span.End()
+}
+
+// Converts a span attribute into its OpenTelemetry representation.
+func keyValue(key string, val interface{}) attribute.KeyValue {
+
+	switch v := val.(type) {
+	case string:
+		return attribute.String(key, v)
+	case bool:
+		return attribute.Bool(key, v)
+	case int:
+		return attribute.Int(key, v)
+	case int64:
+		return attribute.Int64(key, v)
+	case uint32:
+		return attribute.Int64(key, int64(v))
+	case uint64:
+		return attribute.Int64(key, int64(v))
+	case float64:
+		return attribute.Float64(key, v)
+	}
+
+	return attribute.String(key, fmt.Sprint(val))
+}
@@ -0,0 +1,191 @@
+//
+// Copyright 2019-2020 Nestybox, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestStart(t *testing.T) {
+
+	// Tracing disabled: no spans, context left untouched.
+	ctx := context.Background()
+	sctx, span := Start(ctx, "root")
+	assert.Nil(t, span)
+	assert.Equal(t, ctx, sctx)
+	assert.Nil(t, FromContext(sctx))
+	span.SetAttribute("foo", "bar")
+	span.SetError(errors.New("boom"))
+	span.End()
+
+	r := tracetest.NewInMemoryExporter()
+	SetExporter(r, 0)
+	defer SetExporter(nil, 0)
+
+	ctx, root := Start(context.Background(), "root")
+	cctx, child := Start(ctx, "child")
+	_, grandchild := Start(cctx, "grandchild")
+	grandchild.SetError(errors.New("boom"))
+
+	rootId := root.SpanContext()
+	assert.Equal(t, rootId, FromContext(ctx).SpanContext())
+	assert.Equal(t, rootId.TraceID(), child.SpanContext().TraceID())
+	assert.Equal(t, rootId.TraceID(), grandchild.SpanContext().TraceID())
+	assert.NotEqual(t, rootId.SpanID(), child.SpanContext().SpanID())
+
+	// Nothing is exported until the root span ends.
+	grandchild.End()
+	child.End()
+	Flush()
+	assert.Empty(t, r.GetSpans())
+
+	root.End()
+	Flush()
+
+	spans := r.GetSpans()
+	if assert.Len(t, spans, 3) {
+		assert.Equal(t, "grandchild", spans[0].Name)
+		assert.Equal(t, "child", spans[1].Name)
+		assert.Equal(t, "root", spans[2].Name)
+
+		assert.Equal(t, child.SpanContext().SpanID(), spans[0].Parent.SpanID())
+		assert.Equal(t, rootId.SpanID(), spans[1].Parent.SpanID())
+		assert.False(t, spans[2].Parent.IsValid())
+
+		assert.Equal(t, codes.Error, spans[0].Status.Code)
+		assert.Equal(t, "boom", spans[0].Status.Description)
+	}
+
+	// A new root span starts a new trace.
+	_, other := Start(context.Background(), "other")
+	assert.NotEqual(t, rootId.TraceID(), other.SpanContext().TraceID())
+	other.End()
+	Flush()
+	assert.Len(t, r.GetSpans(), 4)
+
+	// Spans outliving their root are dropped.
+	ctx, root = Start(context.Background(), "root")
+	_, child = Start(ctx, "child")
+	root.End()
+	child.End()
+	Flush()
+	assert.Len(t, r.GetSpans(), 5)
+}
+
+func TestThreshold(t *testing.T) {
+
+	r := tracetest.NewInMemoryExporter()
+	SetExporter(r, time.Hour)
+	defer SetExporter(nil, 0)
+
+	_, fast := Start(context.Background(), "fast")
+	fast.End()
+	Flush()
+	assert.Empty(t, r.GetSpans())
+
+	SetExporter(r, time.Millisecond)
+
+	_, slow := Start(context.Background(), "slow")
+	time.Sleep(2 * time.Millisecond)
+	slow.End()
+	Flush()
+	assert.Len(t, r.GetSpans(), 1)
+}
+
+func TestLogExporter(t *testing.T) {
+
+	var buf bytes.Buffer
+	logger := &logrus.Logger{
+		Out:       &buf,
+		Formatter: &logrus.JSONFormatter{},
+		Hooks:     make(logrus.LevelHooks),
+		Level:     logrus.InfoLevel,
+	}
+
+	SetExporter(&LogExporter{Logger: logger}, 0)
+
+	ctx, root := Start(context.Background(), "fuse.Read")
+	root.SetAttribute("path", "/proc/sys/net/ipv4/ip_forward")
+	root.SetAttribute("pid", uint32(1001))
+	_, child := Start(ctx, "nsenter.fork")
+	child.SetError(errors.New("boom"))
+	child.End()
+	root.End()
+
+	// Flush the pending spans.
+	SetExporter(nil, 0)
+
+	dec := json.NewDecoder(&buf)
+
+	var entry map[string]interface{}
+	assert.NoError(t, dec.Decode(&entry))
+	assert.Equal(t, "nsenter.fork", entry[FieldSpan])
+	assert.Equal(t, root.SpanContext().SpanID().String(), entry[FieldParentID])
+	assert.Equal(t, "boom", entry[logrus.ErrorKey])
+
+	entry = nil
+	assert.NoError(t, dec.Decode(&entry))
+	assert.Equal(t, "fuse.Read", entry[FieldSpan])
+	assert.Equal(t, root.SpanContext().TraceID().String(), entry[FieldTraceID])
+	assert.Equal(t, "/proc/sys/net/ipv4/ip_forward", entry["path"])
+	assert.Equal(t, float64(1001), entry["pid"])
+	assert.NotContains(t, entry, FieldParentID)
+}
+
+func TestFileExporter(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "tracing")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "spans.json")
+
+	e, err := NewFileExporter(path)
+	assert.NoError(t, err)
+
+	SetExporter(e, 0)
+
+	_, root := Start(context.Background(), "fuse.Write")
+	root.End()
+
+	// Flush the pending spans and close the file.
+	SetExporter(nil, 0)
+
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if assert.Len(t, lines, 1) {
+		var span map[string]interface{}
+		assert.NoError(t, json.Unmarshal([]byte(lines[0]), &span))
+		assert.Equal(t, "fuse.Write", span["Name"])
+	}
+}